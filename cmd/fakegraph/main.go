@@ -0,0 +1,76 @@
+// Command fakegraph runs the in-memory Meta Graph API fake from
+// pkg/whatsapp/whatsapptest, so whatomate can be run end-to-end locally
+// without a Meta account.
+//
+// Point whatomate at it with [whatsapp] base_url = "http://localhost:9090",
+// add a WhatsApp account using the printed credentials, and drive inbound
+// traffic through the /_fake/ control endpoints.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/shridarpatil/whatomate/pkg/whatsapp/whatsapptest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9090", "Address to listen on")
+	publicURL := flag.String("public-url", "", "URL the fake is reachable at, used in media download links (default http://<addr>)")
+	webhookURL := flag.String("webhook-url", "http://localhost:8080/api/webhook", "whatomate webhook endpoint to push events to (empty to disable)")
+	appSecret := flag.String("app-secret", "fake-app-secret", "App secret used to sign webhooks")
+	token := flag.String("token", "fake-access-token", "Access token required on API requests")
+	phoneID := flag.String("phone-id", "100000000000001", "Phone number ID to register")
+	businessID := flag.String("business-id", "200000000000001", "WhatsApp Business Account ID to register")
+	displayPhone := flag.String("display-phone", "+1 555-010-0000", "Display phone number")
+	reviewDelay := flag.Duration("template-review-delay", 5*time.Second, "Approve submitted templates after this delay (0 to review manually)")
+	autoDeliver := flag.Bool("auto-deliver", true, "Push sent and delivered statuses for outgoing messages")
+	flag.Parse()
+
+	if *publicURL == "" {
+		*publicURL = "http://" + *addr
+	}
+
+	srv := whatsapptest.New(whatsapptest.Config{
+		BaseURL:             *publicURL,
+		AccessToken:         *token,
+		WebhookURL:          *webhookURL,
+		AppSecret:           *appSecret,
+		TemplateReviewDelay: *reviewDelay,
+		AutoDeliver:         *autoDeliver,
+		StatusDelay:         time.Second,
+	})
+	phone := srv.AddPhoneNumber(whatsapptest.PhoneNumber{
+		ID:                 *phoneID,
+		BusinessID:         *businessID,
+		DisplayPhoneNumber: *displayPhone,
+	})
+	acct := srv.Account(phone.ID)
+
+	fmt.Printf(`Fake Graph API listening on %s
+
+Set in config.toml:
+  [whatsapp]
+  base_url = %q
+
+Add a WhatsApp account with:
+  Phone ID:      %s
+  Business ID:   %s
+  App ID:        %s
+  Access token:  %s
+  App secret:    %s
+  API version:   %s
+
+Send an inbound message:
+  curl -X POST %s/_fake/inbound -d '{"phone_id":"%s","from":"15551234567","name":"Test User","text":"Hello"}'
+`, *publicURL, *publicURL, acct.PhoneID, acct.BusinessID, acct.AppID, acct.AccessToken, *appSecret, acct.APIVersion, *publicURL, acct.PhoneID)
+
+	server := &http.Server{Addr: *addr, Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "fakegraph: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -44,6 +44,8 @@ func main() {
 		runServer(os.Args[2:])
 	case "worker":
 		runWorker(os.Args[2:])
+	case "replay-webhooks":
+		runReplayWebhooks(os.Args[2:])
 	case "version":
 		fmt.Printf("Whatomate %s (built %s)\n", Version, BuildTime)
 	case "help", "-h", "--help":
@@ -64,6 +66,7 @@ Usage:
 Commands:
   server    Start the API server (with optional embedded workers)
   worker    Start background workers only (no API server)
+  replay-webhooks  Re-process stored WhatsApp webhooks for a time range
   version   Show version information
   help      Show this help message
 
@@ -76,12 +79,18 @@ Worker Options:
   -config string    Path to config file (default "config.toml")
   -workers int      Number of workers to run (default 1)
 
+Replay Webhooks Options:
+  -config string    Path to config file (default "config.toml")
+  -from string      Start of the range, RFC 3339 (required)
+  -to string        End of the range, RFC 3339 (default now)
+
 Examples:
   whatomate server                     # API + 1 embedded worker
   whatomate server -workers 0          # API only (no workers)
   whatomate server -workers 4          # API + 4 embedded workers
   whatomate server -migrate            # Run migrations and start server
   whatomate worker -workers 4          # 4 workers only (no API)
+  whatomate replay-webhooks -from 2024-05-01T10:00:00Z -to 2024-05-01T12:00:00Z
 
 Deployment Scenarios:
   All-in-one:    whatomate server
@@ -169,6 +178,7 @@ func runServer(args []string) {
 
 	// Initialize WhatsApp client
 	waClient := whatsapp.NewWithBaseURL(lo, cfg.WhatsApp.BaseURL)
+	waClient.Limiter = whatsapp.NewRedisLimiter(rdb, lo, cfg.WhatsApp.SendRate, cfg.WhatsApp.BulkReserve)
 
 	// Initialize WebSocket hub
 	wsHub := websocket.NewHub(lo)
@@ -230,6 +240,9 @@ func runServer(args []string) {
 	if err := app.StartCampaignStatsSubscriber(); err != nil {
 		lo.Error("Failed to start campaign stats subscriber", "error", err)
 	}
+	if err := app.StartAccountAuthSubscriber(); err != nil {
+		lo.Error("Failed to start account auth subscriber", "error", err)
+	}
 
 	// Parse allowed origins for CORS
 	allowedOrigins := middleware.ParseAllowedOrigins(cfg.Server.AllowedOrigins)
@@ -267,6 +280,31 @@ func runServer(args []string) {
 	go slaProcessor.Start(slaCtx)
 	lo.Info("SLA processor started")
 
+	// Start campaign scheduler (dispatches campaigns whose scheduled_at has passed
+	// and creates the runs of recurring campaigns)
+	campaignScheduler := handlers.NewCampaignScheduler(app, 30*time.Second)
+	schedulerCtx, schedulerCancel := context.WithCancel(context.Background())
+	go campaignScheduler.Start(schedulerCtx)
+	lo.Info("Campaign scheduler started")
+
+	// Start scheduled message processor (sends send-later chat messages once due)
+	scheduledMessageProcessor := handlers.NewScheduledMessageProcessor(app, 30*time.Second)
+	scheduledMessageCtx, scheduledMessageCancel := context.WithCancel(context.Background())
+	go scheduledMessageProcessor.Start(scheduledMessageCtx)
+	lo.Info("Scheduled message processor started")
+
+	// Start webhook event processor (re-runs stored webhooks lost to a crash)
+	webhookProcessor := handlers.NewWebhookEventProcessor(app, 30*time.Second)
+	webhookCtx, webhookCancel := context.WithCancel(context.Background())
+	go webhookProcessor.Start(webhookCtx)
+	lo.Info("Webhook event processor started")
+
+	// Start account health monitor (catches quality and tier changes whose webhooks were missed)
+	healthMonitor := handlers.NewAccountHealthMonitor(app, 30*time.Minute)
+	healthCtx, healthCancel := context.WithCancel(context.Background())
+	go healthMonitor.Start(healthCtx)
+	lo.Info("Account health monitor started")
+
 	// Start embedded workers
 	var workers []*worker.Worker
 	var workerCancel context.CancelFunc
@@ -305,6 +343,7 @@ func runServer(args []string) {
 	lo.Info("Stopping campaign stats subscriber...")
 	app.StopCampaignStatsSubscriber()
 	lo.Info("Campaign stats subscriber stopped")
+	app.StopAccountAuthSubscriber()
 
 	// Stop SLA processor
 	lo.Info("Stopping SLA processor...")
@@ -312,6 +351,26 @@ func runServer(args []string) {
 	slaProcessor.Stop()
 	lo.Info("SLA processor stopped")
 
+	// Stop campaign scheduler
+	schedulerCancel()
+	campaignScheduler.Stop()
+	lo.Info("Campaign scheduler stopped")
+
+	// Stop scheduled message processor
+	scheduledMessageCancel()
+	scheduledMessageProcessor.Stop()
+	lo.Info("Scheduled message processor stopped")
+
+	// Stop webhook event processor
+	webhookCancel()
+	webhookProcessor.Stop()
+	lo.Info("Webhook event processor stopped")
+
+	// Stop account health monitor
+	healthCancel()
+	healthMonitor.Stop()
+	lo.Info("Account health monitor stopped")
+
 	// Stop workers first
 	if workerCancel != nil {
 		lo.Info("Stopping workers...", "count", len(workers))
@@ -407,6 +466,20 @@ func runWorker(args []string) {
 
 	lo.Info("Workers started", "count", *workerCount)
 
+	// Workers also run the campaign scheduler so deployments without an API
+	// replica still dispatch scheduled campaigns. Claims are atomic, so running
+	// it alongside the server is safe.
+	schedulerApp := &handlers.App{
+		Config: cfg,
+		DB:     db,
+		Redis:  rdb,
+		Log:    lo,
+		Queue:  queue.NewRedisQueue(rdb, lo),
+	}
+	campaignScheduler := handlers.NewCampaignScheduler(schedulerApp, 30*time.Second)
+	go campaignScheduler.Start(ctx)
+	lo.Info("Campaign scheduler started")
+
 	// Wait for shutdown signal or error
 	select {
 	case sig := <-quit:
@@ -420,6 +493,8 @@ func runWorker(args []string) {
 	}
 
 	// Cleanup
+	campaignScheduler.Stop()
+
 	lo.Info("Shutting down workers...")
 	for _, w := range workers {
 		if w != nil {
@@ -431,6 +506,82 @@ func runWorker(args []string) {
 	lo.Info("Workers stopped")
 }
 
+// ============================================================================
+// REPLAY WEBHOOKS COMMAND
+// ============================================================================
+
+func runReplayWebhooks(args []string) {
+	replayFlags := flag.NewFlagSet("replay-webhooks", flag.ExitOnError)
+	configPath := replayFlags.String("config", "config.toml", "Path to config file")
+	fromFlag := replayFlags.String("from", "", "Start of the range, RFC 3339 (required)")
+	toFlag := replayFlags.String("to", "", "End of the range, RFC 3339 (default now)")
+	_ = replayFlags.Parse(args)
+
+	lo := logf.New(logf.Opts{
+		EnableColor:     true,
+		Level:           logf.InfoLevel,
+		TimestampFormat: "2006-01-02 15:04:05",
+		DefaultFields:   []any{"app", "whatomate-replay"},
+	})
+
+	if *fromFlag == "" {
+		lo.Fatal("-from is required")
+	}
+	from, err := time.Parse(time.RFC3339, *fromFlag)
+	if err != nil {
+		lo.Fatal("Invalid -from", "error", err)
+	}
+	to := time.Now()
+	if *toFlag != "" {
+		if to, err = time.Parse(time.RFC3339, *toFlag); err != nil {
+			lo.Fatal("Invalid -to", "error", err)
+		}
+	}
+	if !to.After(from) {
+		lo.Fatal("-to must be after -from")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		lo.Fatal("Failed to load config", "error", err)
+	}
+
+	db, err := database.NewPostgres(&cfg.Database, cfg.App.Debug)
+	if err != nil {
+		lo.Fatal("Failed to connect to database", "error", err)
+	}
+
+	rdb, err := database.NewRedis(&cfg.Redis)
+	if err != nil {
+		lo.Fatal("Failed to connect to Redis", "error", err)
+	}
+
+	waClient := whatsapp.NewWithBaseURL(lo, cfg.WhatsApp.BaseURL)
+	waClient.Limiter = whatsapp.NewRedisLimiter(rdb, lo, cfg.WhatsApp.SendRate, cfg.WhatsApp.BulkReserve)
+
+	wsHub := websocket.NewHub(lo)
+	go wsHub.Run()
+
+	app := &handlers.App{
+		Config:   cfg,
+		DB:       db,
+		Redis:    rdb,
+		Log:      lo,
+		WhatsApp: waClient,
+		WSHub:    wsHub,
+		Queue:    queue.NewRedisQueue(rdb, lo),
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	result, err := app.ReplayWebhookEvents(ctx, from, to, nil)
+	fmt.Printf("Replayed %d webhook events (%d failed)\n", result.Replayed, result.Failed)
+	if err != nil {
+		lo.Fatal("Replay stopped", "error", err)
+	}
+}
+
 // ============================================================================
 // ROUTES
 // ============================================================================
@@ -512,6 +663,10 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 		if len(path) >= 28 && path[:28] == "/api/custom-actions/redirect" {
 			return r
 		}
+		// Skip auth for notification rule triggers (authenticated by the per-rule token)
+		if len(path) >= 32 && path[:32] == "/api/notification-rules/trigger/" {
+			return r
+		}
 		// Apply auth for all other /api routes (supports both JWT and API key)
 		if len(path) > 4 && path[:4] == "/api" {
 			return middleware.AuthWithDB(app.Config.JWT.Secret, app.DB)(r)
@@ -563,14 +718,29 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	// Accounts
 	g.GET("/api/accounts", app.ListAccounts)
 	g.POST("/api/accounts", app.CreateAccount)
+	g.GET("/api/accounts/embedded-signup/config", app.GetEmbeddedSignupConfig)
+	g.POST("/api/accounts/embedded-signup", app.CompleteEmbeddedSignup)
 	g.GET("/api/accounts/{id}", app.GetAccount)
 	g.PUT("/api/accounts/{id}", app.UpdateAccount)
 	g.DELETE("/api/accounts/{id}", app.DeleteAccount)
 	g.POST("/api/accounts/{id}/test", app.TestAccountConnection)
 	g.POST("/api/accounts/{id}/subscribe", app.SubscribeApp)
+	g.PUT("/api/accounts/{id}/token", app.RotateAccountToken)
+	g.GET("/api/accounts/{id}/blocked_users", app.ListBlockedUsers)
 	g.GET("/api/accounts/{id}/business_profile", app.GetBusinessProfile)
 	g.PUT("/api/accounts/{id}/business_profile", app.UpdateBusinessProfile)
 	g.POST("/api/accounts/{id}/business_profile/photo", app.UpdateProfilePicture)
+	g.GET("/api/accounts/{id}/health", app.GetAccountHealth)
+	g.POST("/api/accounts/{id}/health/refresh", app.RefreshAccountHealth)
+	g.POST("/api/accounts/{id}/phone/request_code", app.RequestPhoneVerificationCode)
+	g.POST("/api/accounts/{id}/phone/verify_code", app.VerifyPhoneCode)
+	g.POST("/api/accounts/{id}/phone/register", app.RegisterPhone)
+	g.POST("/api/accounts/{id}/phone/deregister", app.DeregisterPhone)
+	g.POST("/api/accounts/{id}/phone/two_step_pin", app.SetPhoneTwoStepPIN)
+	g.GET("/api/accounts/{id}/phone/display_name", app.GetPhoneDisplayNameStatus)
+
+	// Webhook events
+	g.POST("/api/webhook-events/replay", app.ReplayWebhookEventsHandler)
 
 	// Contacts
 	g.GET("/api/contacts", app.ListContacts)
@@ -580,8 +750,17 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.DELETE("/api/contacts/{id}", app.DeleteContact)
 	g.PUT("/api/contacts/{id}/assign", app.AssignContact)
 	g.PUT("/api/contacts/{id}/tags", app.UpdateContactTags)
+	g.POST("/api/contacts/{id}/block", app.BlockContact)
+	g.DELETE("/api/contacts/{id}/block", app.UnblockContact)
 	g.GET("/api/contacts/{id}/session-data", app.GetContactSessionData)
 
+	// Suppression list (opted-out numbers)
+	g.GET("/api/suppressions", app.ListSuppressions)
+	g.POST("/api/suppressions", app.CreateSuppression)
+	g.POST("/api/suppressions/import", app.ImportSuppressions)
+	g.GET("/api/suppressions/export", app.ExportSuppressions)
+	g.DELETE("/api/suppressions/{id}", app.DeleteSuppression)
+
 	// Generic Import/Export
 	g.POST("/api/export", app.ExportData)
 	g.POST("/api/import", app.ImportData)
@@ -608,6 +787,9 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.POST("/api/contacts/{id}/notes", app.CreateConversationNote)
 	g.PUT("/api/contacts/{id}/notes/{note_id}", app.UpdateConversationNote)
 	g.DELETE("/api/contacts/{id}/notes/{note_id}", app.DeleteConversationNote)
+	g.GET("/api/contacts/{id}/scheduled-messages", app.ListScheduledMessages)
+	g.POST("/api/contacts/{id}/scheduled-messages", app.CreateScheduledMessage)
+	g.POST("/api/contacts/{id}/scheduled-messages/{scheduled_id}/cancel", app.CancelScheduledMessage)
 
 	// Media (serves media files for messages, auth-protected)
 	g.GET("/api/media/{message_id}", app.ServeMedia)
@@ -650,6 +832,27 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.DELETE("/api/campaigns/{id}/recipients/{recipientId}", app.DeleteCampaignRecipient)
 	g.POST("/api/campaigns/{id}/media", app.UploadCampaignMedia)
 	g.GET("/api/campaigns/{id}/media", app.ServeCampaignMedia)
+	g.GET("/api/campaigns/{id}/variants", app.GetCampaignVariants)
+	g.POST("/api/campaigns/{id}/variants/{variantId}/media", app.UploadCampaignVariantMedia)
+	g.GET("/api/campaigns/{id}/variants/{variantId}/media", app.ServeCampaignVariantMedia)
+
+	// Recurring Campaigns
+	g.GET("/api/recurring-campaigns", app.ListRecurringCampaigns)
+	g.POST("/api/recurring-campaigns", app.CreateRecurringCampaign)
+	g.GET("/api/recurring-campaigns/{id}", app.GetRecurringCampaign)
+	g.PUT("/api/recurring-campaigns/{id}", app.UpdateRecurringCampaign)
+	g.DELETE("/api/recurring-campaigns/{id}", app.DeleteRecurringCampaign)
+	g.POST("/api/recurring-campaigns/{id}/pause", app.PauseRecurringCampaign)
+	g.POST("/api/recurring-campaigns/{id}/resume", app.ResumeRecurringCampaign)
+	g.GET("/api/recurring-campaigns/{id}/runs", app.ListRecurringCampaignRuns)
+
+	// Contact Segments (campaign audiences)
+	g.GET("/api/segments", app.ListSegments)
+	g.POST("/api/segments", app.CreateSegment)
+	g.POST("/api/segments/preview", app.PreviewSegment)
+	g.GET("/api/segments/{id}", app.GetSegment)
+	g.PUT("/api/segments/{id}", app.UpdateSegment)
+	g.DELETE("/api/segments/{id}", app.DeleteSegment)
 
 	// Chatbot Settings
 	g.GET("/api/chatbot/settings", app.GetChatbotSettings)
@@ -712,6 +915,8 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.GET("/api/analytics/agents", app.GetAgentAnalytics)
 	g.GET("/api/analytics/agents/{id}", app.GetAgentDetails)
 	g.GET("/api/analytics/agents/comparison", app.GetAgentComparison)
+	g.GET("/api/analytics/spend", app.GetSpendAnalytics)
+	g.GET("/api/analytics/ads", app.GetAdAnalytics)
 
 	// Meta WhatsApp Analytics
 	g.GET("/api/analytics/meta", app.GetMetaAnalytics)
@@ -734,6 +939,10 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.PUT("/api/org/settings", app.UpdateOrganizationSettings)
 	g.POST("/api/org/audio", app.UploadOrgAudio)
 
+	// Pricing rate card
+	g.GET("/api/pricing/rates", app.GetPricingRates)
+	g.PUT("/api/pricing/rates", app.UpdatePricingRates)
+
 	// Organizations
 	g.GET("/api/organizations", app.ListOrganizations)
 	g.POST("/api/organizations", app.CreateOrganization)
@@ -756,6 +965,16 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.DELETE("/api/webhooks/{id}", app.DeleteWebhook)
 	g.POST("/api/webhooks/{id}/test", app.TestWebhook)
 
+	// Notification Rules
+	g.GET("/api/notification-rules", app.ListNotificationRules)
+	g.POST("/api/notification-rules", app.CreateNotificationRule)
+	g.GET("/api/notification-rules/{id}", app.GetNotificationRule)
+	g.PUT("/api/notification-rules/{id}", app.UpdateNotificationRule)
+	g.DELETE("/api/notification-rules/{id}", app.DeleteNotificationRule)
+	g.GET("/api/notification-rules/{id}/logs", app.ListNotificationRuleLogs)
+	g.POST("/api/notification-rules/{id}/regenerate-token", app.RegenerateNotificationRuleToken)
+	g.POST("/api/notification-rules/trigger/{token}", app.TriggerNotificationRule) // Public, token-authenticated
+
 	// Custom Actions
 	g.GET("/api/custom-actions", app.ListCustomActions)
 	g.POST("/api/custom-actions", app.CreateCustomAction)
@@ -806,6 +1025,11 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.PUT("/api/products/{id}", app.UpdateCatalogProduct)
 	g.DELETE("/api/products/{id}", app.DeleteCatalogProduct)
 
+	// Orders
+	g.GET("/api/orders", app.ListOrders)
+	g.GET("/api/orders/{id}", app.GetOrder)
+	g.PUT("/api/orders/{id}/status", app.UpdateOrderStatus)
+
 	// Serve embedded frontend (SPA)
 	if frontend.IsEmbedded() {
 		lo.Info("Serving embedded frontend", "base_path", basePath)
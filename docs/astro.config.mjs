@@ -53,6 +53,8 @@ export default defineConfig({
             { label: 'Canned Responses', slug: 'api-reference/canned-responses' },
             { label: 'Custom Actions', slug: 'api-reference/custom-actions' },
             { label: 'Webhooks', slug: 'api-reference/webhooks' },
+            { label: 'Notification Rules', slug: 'api-reference/notification-rules' },
+            { label: 'Orders', slug: 'api-reference/orders' },
             { label: 'Analytics', slug: 'api-reference/analytics' },
           ],
         },
@@ -21,7 +21,9 @@ import {
   Tags,
   Phone,
   PhoneCall,
-  PhoneForwarded
+  PhoneForwarded,
+  Wallet,
+  MousePointerClick
 } from 'lucide-vue-next'
 import type { Component } from 'vue'
 
@@ -78,6 +80,18 @@ export const navigationItems: NavItem[] = [
     icon: LineChart,
     permission: 'analytics'
   },
+  {
+    name: 'nav.spend',
+    path: '/analytics/spend',
+    icon: Wallet,
+    permission: 'analytics'
+  },
+  {
+    name: 'nav.ads',
+    path: '/analytics/ads',
+    icon: MousePointerClick,
+    permission: 'analytics'
+  },
   {
     name: 'nav.templates',
     path: '/templates',
@@ -119,6 +133,7 @@ export const navigationItems: NavItem[] = [
       { name: 'nav.chatbot', path: '/settings/chatbot', icon: Bot, permission: 'settings.chatbot' },
       { name: 'nav.accounts', path: '/settings/accounts', icon: Users, permission: 'accounts' },
       { name: 'nav.contacts', path: '/settings/contacts', icon: Contact, permission: 'contacts' },
+      { name: 'nav.suppressionList', path: '/settings/suppression-list', icon: UserX, permission: 'contacts' },
       { name: 'nav.cannedResponses', path: '/settings/canned-responses', icon: MessageSquareText, permission: 'canned_responses' },
       { name: 'nav.tags', path: '/settings/tags', icon: Tags, permission: 'tags' },
       { name: 'nav.teams', path: '/settings/teams', icon: Users, permission: 'teams' },
@@ -62,6 +62,20 @@ export function getTagColorClass(color: string): string {
   return tagColor?.class || TAG_COLORS.find(c => c.value === 'gray')!.class
 }
 
+// Meta quality rating badge classes (dark-first, light: prefix for light mode)
+export function getQualityRatingClass(rating: string): string {
+  switch (rating) {
+    case 'GREEN':
+      return 'bg-green-900 text-green-300 light:bg-green-100 light:text-green-800'
+    case 'YELLOW':
+      return 'bg-yellow-900 text-yellow-300 light:bg-yellow-100 light:text-yellow-800'
+    case 'RED':
+      return 'bg-red-900 text-red-300 light:bg-red-100 light:text-red-800'
+    default:
+      return 'bg-gray-800 text-gray-300 light:bg-gray-100 light:text-gray-800'
+  }
+}
+
 // Helper function to get label from value
 export function getLabelFromValue<T extends readonly { value: string; label: string }[]>(
   options: T,
@@ -82,6 +82,18 @@ const router = createRouter({
           component: () => import('@/views/settings/CampaignsView.vue'),
           meta: { permission: 'campaigns' }
         },
+        {
+          path: 'campaigns/segments',
+          name: 'segments',
+          component: () => import('@/views/settings/SegmentsView.vue'),
+          meta: { permission: 'campaigns' }
+        },
+        {
+          path: 'campaigns/recurring',
+          name: 'recurring-campaigns',
+          component: () => import('@/views/settings/RecurringCampaignsView.vue'),
+          meta: { permission: 'campaigns' }
+        },
         {
           path: 'chatbot',
           name: 'chatbot',
@@ -140,6 +152,18 @@ const router = createRouter({
           component: () => import('@/views/analytics/MetaInsightsView.vue'),
           meta: { permission: 'analytics' }
         },
+        {
+          path: 'analytics/spend',
+          name: 'spend',
+          component: () => import('@/views/analytics/SpendView.vue'),
+          meta: { permission: 'analytics' }
+        },
+        {
+          path: 'analytics/ads',
+          name: 'ad-analytics',
+          component: () => import('@/views/analytics/AdsView.vue'),
+          meta: { permission: 'analytics' }
+        },
         {
           path: 'settings',
           name: 'settings',
@@ -170,6 +194,12 @@ const router = createRouter({
           component: () => import('@/views/settings/ContactsView.vue'),
           meta: { permission: 'contacts' }
         },
+        {
+          path: 'settings/suppression-list',
+          name: 'suppression-list',
+          component: () => import('@/views/settings/SuppressionListView.vue'),
+          meta: { permission: 'contacts' }
+        },
         {
           path: 'settings/tags',
           name: 'tags',
@@ -264,6 +294,8 @@ const navigationOrder = [
   { path: '/chatbot/transfers', permission: 'transfers' },
   { path: '/analytics/agents', permission: 'analytics.agents' },
   { path: '/analytics/meta-insights', permission: 'analytics' },
+  { path: '/analytics/spend', permission: 'analytics' },
+  { path: '/analytics/ads', permission: 'analytics' },
   { path: '/templates', permission: 'templates' },
   { path: '/flows', permission: 'flows.whatsapp' },
   { path: '/campaigns', permission: 'campaigns' },
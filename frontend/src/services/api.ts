@@ -110,7 +110,7 @@ export const accountsService = {
 }
 
 export const contactsService = {
-  list: (params?: { search?: string; page?: number; limit?: number; tags?: string }) =>
+  list: (params?: { search?: string; page?: number; limit?: number; tags?: string; blocked?: boolean }) =>
     api.get('/contacts', { params }),
   get: (id: string) => api.get(`/contacts/${id}`),
   create: (data: any) => api.post('/contacts', data),
@@ -120,6 +120,9 @@ export const contactsService = {
     api.put(`/contacts/${id}/assign`, { user_id: userId }),
   updateTags: (id: string, tags: string[]) =>
     api.put(`/contacts/${id}/tags`, { tags }),
+  block: (id: string, reason?: string) =>
+    api.post(`/contacts/${id}/block`, { reason }),
+  unblock: (id: string) => api.delete(`/contacts/${id}/block`),
   getSessionData: (id: string) => api.get(`/contacts/${id}/session-data`)
 }
 
@@ -182,10 +185,24 @@ export const dataService = {
   }
 }
 
+export interface SharedLocation {
+  latitude: number
+  longitude: number
+  name?: string
+  address?: string
+}
+
+export interface SharedContactCard {
+  name: { formatted_name: string; first_name?: string; last_name?: string }
+  phones?: Array<{ phone: string; type?: string; wa_id?: string }>
+  emails?: Array<{ email: string; type?: string }>
+  org?: { company?: string; department?: string; title?: string }
+}
+
 export const messagesService = {
   list: (contactId: string, params?: { page?: number; limit?: number; before_id?: string; account?: string }) =>
     api.get(`/contacts/${contactId}/messages`, { params }),
-  send: (contactId: string, data: { type: string; content: any; reply_to_message_id?: string; whatsapp_account?: string }) =>
+  send: (contactId: string, data: { type: string; content: any; reply_to_message_id?: string; whatsapp_account?: string; location?: SharedLocation; contacts?: SharedContactCard[] }) =>
     api.post(`/contacts/${contactId}/messages`, data),
   sendTemplate: (contactId: string, data: { template_name: string; template_params?: Record<string, string>; account_name?: string }) =>
     api.post('/messages/template', { contact_id: contactId, ...data }),
@@ -248,7 +265,18 @@ export const campaignsService = {
     })
   },
   getMedia: (campaignId: string) =>
-    api.get(`/campaigns/${campaignId}/media`, { responseType: 'arraybuffer' })
+    api.get(`/campaigns/${campaignId}/media`, { responseType: 'arraybuffer' }),
+  // A/B variants
+  getVariants: (campaignId: string) => api.get(`/campaigns/${campaignId}/variants`),
+  uploadVariantMedia: (campaignId: string, variantId: string, file: File) => {
+    const formData = new FormData()
+    formData.append('file', file)
+    const csrfToken = getCookie('whm_csrf')
+    return axios.post(`${api.defaults.baseURL}/campaigns/${campaignId}/variants/${variantId}/media`, formData, {
+      withCredentials: true,
+      headers: csrfToken ? { 'X-CSRF-Token': csrfToken } : {}
+    })
+  }
 }
 
 export const chatbotService = {
@@ -323,11 +351,138 @@ export const cannedResponsesService = {
   use: (id: string) => api.post(`/canned-responses/${id}/use`)
 }
 
+export interface ContactSegment {
+  id: string
+  name: string
+  description: string
+  tags: string[]
+  metadata: Record<string, any>
+  inbound_within_days: number
+  no_inbound_for_days: number
+  assigned_user_id?: string
+  whatsapp_account?: string
+  contact_count?: number
+  created_at: string
+  updated_at: string
+}
+
+export type ContactSegmentRules = Omit<ContactSegment, 'id' | 'contact_count' | 'created_at' | 'updated_at'>
+
+export const segmentsService = {
+  list: (params?: { search?: string; page?: number; limit?: number }) =>
+    api.get<{ segments: ContactSegment[]; total?: number }>('/segments', { params }),
+  get: (id: string) => api.get(`/segments/${id}`),
+  create: (data: ContactSegmentRules) => api.post('/segments', data),
+  update: (id: string, data: ContactSegmentRules) => api.put(`/segments/${id}`, data),
+  delete: (id: string) => api.delete(`/segments/${id}`),
+  preview: (data: Partial<ContactSegmentRules>) => api.post('/segments/preview', data)
+}
+
+export interface RecurringCampaignRecipient {
+  phone_number: string
+  recipient_name?: string
+  template_params?: Record<string, any>
+}
+
+export interface RecurringCampaign {
+  id: string
+  name: string
+  whatsapp_account: string
+  template_id: string
+  template_name?: string
+  cron_expression: string
+  timezone: string
+  status: 'active' | 'paused'
+  next_run_at?: string
+  last_run_at?: string
+  sender_accounts?: string[]
+  sender_strategy?: string
+  segment_id?: string
+  segment_params?: Record<string, string>
+  recipients: RecurringCampaignRecipient[]
+  created_at: string
+  updated_at: string
+}
+
+export interface RecurringCampaignRun {
+  id: string
+  name: string
+  status: string
+  total_recipients: number
+  sent_count: number
+  delivered_count: number
+  read_count: number
+  failed_count: number
+  scheduled_at?: string
+  started_at?: string
+  completed_at?: string
+}
+
+export const recurringCampaignsService = {
+  list: (params?: { search?: string; page?: number; limit?: number }) =>
+    api.get<{ recurring_campaigns: RecurringCampaign[]; total?: number }>('/recurring-campaigns', { params }),
+  get: (id: string) => api.get(`/recurring-campaigns/${id}`),
+  create: (data: any) => api.post('/recurring-campaigns', data),
+  update: (id: string, data: any) => api.put(`/recurring-campaigns/${id}`, data),
+  delete: (id: string) => api.delete(`/recurring-campaigns/${id}`),
+  pause: (id: string) => api.post(`/recurring-campaigns/${id}/pause`),
+  resume: (id: string) => api.post(`/recurring-campaigns/${id}/resume`),
+  runs: (id: string, params?: { page?: number; limit?: number }) =>
+    api.get<{ runs: RecurringCampaignRun[]; total?: number }>(`/recurring-campaigns/${id}/runs`, { params })
+}
+
 export const agentAnalyticsService = {
   getSummary: (params?: { from?: string; to?: string; agent_id?: string }) =>
     api.get('/analytics/agents', { params })
 }
 
+// Spend analytics and the rate card used to price conversations
+export type SpendGroupBy = 'account' | 'campaign' | 'template' | 'agent' | 'category' | 'country'
+
+export interface SpendTotal {
+  currency: string
+  cost: number
+  conversations: number
+  unrated: number
+}
+
+export interface SpendBreakdownRow extends SpendTotal {
+  key: string
+  label: string
+}
+
+export interface PricingRate {
+  id?: string
+  country_code: string
+  category: string
+  rate: number
+  currency?: string
+}
+
+export const spendService = {
+  get: (params: { from?: string; to?: string; group_by: SpendGroupBy }) =>
+    api.get<{ group_by: SpendGroupBy; totals: SpendTotal[]; breakdown: SpendBreakdownRow[] }>('/analytics/spend', { params }),
+  getRates: () => api.get<{ currency: string; rates: PricingRate[] }>('/pricing/rates'),
+  updateRates: (data: { currency: string; rates: PricingRate[] }) => api.put('/pricing/rates', data)
+}
+
+// Attribution of conversations, transfers and orders to click-to-WhatsApp ads
+export interface AdAnalyticsRow {
+  ad_id: string
+  headline: string
+  source_url: string
+  conversations: number
+  new_contacts: number
+  transfers: number
+  orders: number
+  revenue: { currency: string; amount: number }[]
+}
+
+export const adAnalyticsService = {
+  get: (params: { from?: string; to?: string }) =>
+    api.get<{ ads: AdAnalyticsRow[] }>('/analytics/ads', { params })
+}
+
 // Meta WhatsApp Analytics Types
 export type MetaAnalyticsType =
   | 'analytics'
@@ -573,6 +728,10 @@ export const organizationService = {
     transfer_timeout_secs?: number
     hold_music_file?: string
     ringback_file?: string
+    opt_out_keywords?: string[]
+    opt_in_keywords?: string[]
+    marketing_cap_max?: number
+    marketing_cap_window_hours?: number
   }) => api.put('/org/settings', data),
   uploadOrgAudio: (file: File, type: 'hold_music' | 'ringback') => {
     const formData = new FormData()
@@ -794,6 +953,39 @@ export interface Tag {
   updated_at: string
 }
 
+export interface SuppressionEntry {
+  id: string
+  phone_number: string
+  source: 'keyword' | 'manual' | 'import'
+  reason?: string
+  created_by_id?: string
+  created_at: string
+}
+
+export interface SuppressionImportResult {
+  created: number
+  skipped: number
+  invalid: number
+}
+
+export const suppressionsService = {
+  list: (params?: { search?: string; source?: string; page?: number; limit?: number }) =>
+    api.get<{ suppressions: SuppressionEntry[]; total?: number; page?: number; limit?: number }>('/suppressions', { params }),
+  create: (data: { phone_number: string; reason?: string }) =>
+    api.post<SuppressionEntry>('/suppressions', data),
+  delete: (id: string) => api.delete(`/suppressions/${id}`),
+  importFile: (file: File) => {
+    const formData = new FormData()
+    formData.append('file', file)
+    return api.post<SuppressionImportResult>('/suppressions/import', formData, {
+      headers: { 'Content-Type': 'multipart/form-data' }
+    })
+  },
+  importNumbers: (data: { phone_numbers: string[]; reason?: string }) =>
+    api.post<SuppressionImportResult>('/suppressions/import', data),
+  export: () => api.get('/suppressions/export', { responseType: 'blob' })
+}
+
 export const tagsService = {
   list: (params?: { search?: string; page?: number; limit?: number }) =>
     api.get<{ tags: Tag[]; total?: number; page?: number; limit?: number }>('/tags', { params }),
@@ -826,6 +1018,89 @@ export const notesService = {
     api.delete(`/contacts/${contactId}/notes/${noteId}`)
 }
 
+// Scheduled messages
+export type ScheduledMessageStatus = 'pending' | 'sending' | 'sent' | 'failed' | 'window_closed' | 'cancelled' | 'suppressed'
+
+export interface ScheduledMessage {
+  id: string
+  contact_id: string
+  whatsapp_account: string
+  created_by_id: string
+  created_by_name: string
+  send_at: string
+  status: ScheduledMessageStatus
+  type: 'text' | 'template'
+  content: string
+  template_id?: string
+  template_params?: Record<string, string>
+  fallback_template_id?: string
+  fallback_template_params?: Record<string, string>
+  message_id?: string
+  used_fallback: boolean
+  sent_at?: string
+  error_message?: string
+  cancelled_at?: string
+  created_at: string
+}
+
+export interface CreateScheduledMessageData {
+  send_at: string
+  type?: 'text' | 'template'
+  content?: string
+  template_id?: string
+  template_params?: Record<string, string>
+  whatsapp_account?: string
+  fallback_template_id?: string
+  fallback_template_params?: Record<string, string>
+}
+
+export const scheduledMessagesService = {
+  list: (contactId: string, params?: { status?: ScheduledMessageStatus; page?: number; limit?: number }) =>
+    api.get<{ scheduled_messages: ScheduledMessage[]; total: number }>(`/contacts/${contactId}/scheduled-messages`, { params }),
+  create: (contactId: string, data: CreateScheduledMessageData) =>
+    api.post<ScheduledMessage>(`/contacts/${contactId}/scheduled-messages`, data),
+  cancel: (contactId: string, id: string) =>
+    api.post<ScheduledMessage>(`/contacts/${contactId}/scheduled-messages/${id}/cancel`)
+}
+
+// Orders
+export type OrderStatus = 'pending' | 'confirmed' | 'shipped' | 'delivered' | 'cancelled'
+
+export interface OrderItem {
+  id: string
+  product_id?: string
+  product_retailer_id: string
+  name: string
+  quantity: number
+  unit_price: number
+  currency: string
+}
+
+export interface Order {
+  id: string
+  whatsapp_account: string
+  contact_id: string
+  contact_name: string
+  catalog_id?: string
+  meta_catalog_id: string
+  status: OrderStatus
+  note: string
+  total_amount: number
+  currency: string
+  item_count: number
+  items?: OrderItem[]
+  created_at: string
+  updated_at: string
+}
+
+export const ordersService = {
+  list: (params?: { status?: OrderStatus; contact_id?: string; whatsapp_account?: string; page?: number; limit?: number }) =>
+    api.get<{ orders: Order[]; total: number; page: number; limit: number }>('/orders', { params }),
+  get: (id: string) => api.get<Order>(`/orders/${id}`),
+  updateStatus: (id: string, status: OrderStatus) =>
+    api.put<Order>(`/orders/${id}/status`, { status })
+}
+
 // Calling - Call Logs & IVR Flows
 export interface CallLog {
   id: string
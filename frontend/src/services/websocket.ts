@@ -60,6 +60,9 @@ const WS_TYPE_TRANSFER_ESCALATION = 'transfer_escalation'
 // Campaign types
 const WS_TYPE_CAMPAIGN_STATS_UPDATE = 'campaign_stats_update'
 
+// Account health types
+const WS_TYPE_ACCOUNT_HEALTH_ALERT = 'account_health_alert'
+
 // Permission types
 const WS_TYPE_PERMISSIONS_UPDATED = 'permissions_updated'
 
@@ -204,6 +207,9 @@ class WebSocketService {
         case WS_TYPE_CAMPAIGN_STATS_UPDATE:
           this.handleCampaignStatsUpdate(message.payload)
           break
+        case WS_TYPE_ACCOUNT_HEALTH_ALERT:
+          this.handleAccountHealthAlert(message.payload)
+          break
         case WS_TYPE_PERMISSIONS_UPDATED:
           this.handlePermissionsUpdated()
           break
@@ -271,6 +277,7 @@ class WebSocketService {
         is_reply: payload.is_reply,
         reply_to_message_id: payload.reply_to_message_id,
         reply_to_message: payload.reply_to_message,
+        context: payload.context,
         reactions: payload.reactions,
         created_at: payload.created_at,
         updated_at: payload.updated_at
@@ -478,6 +485,21 @@ class WebSocketService {
     this.campaignStatsCallbacks.forEach(callback => callback(payload))
   }
 
+  private handleAccountHealthAlert(payload: any) {
+    const description = payload.description ||
+      (payload.new_value ? `${payload.old_value || '-'} → ${payload.new_value}` : payload.event)
+    const notify = payload.severity === 'critical' ? toast.error : toast.warning
+
+    notify(`Account ${payload.whatsapp_account}: ${payload.kind} alert`, {
+      description,
+      duration: 10000,
+      action: {
+        label: 'View',
+        onClick: () => router.push('/settings/accounts')
+      }
+    })
+  }
+
   private async handlePermissionsUpdated() {
     const authStore = useAuthStore()
 
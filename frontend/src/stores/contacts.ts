@@ -1,6 +1,6 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
-import { contactsService, messagesService } from '@/services/api'
+import { contactsService, messagesService, type SharedLocation, type SharedContactCard } from '@/services/api'
 
 export interface Contact {
   id: string
@@ -17,15 +17,43 @@ export interface Contact {
   unread_count: number
   assigned_user_id?: string
   whatsapp_account?: string
+  is_blocked?: boolean
+  blocked_reason?: string
+  blocked_at?: string
+  ad_id?: string
+  referral?: {
+    source_type: string
+    source_id: string
+    source_url: string
+    headline?: string
+    body?: string
+    ctwa_clid?: string
+  }
+  referred_at?: string
+  opt_in_source?: string
+  opted_in_at?: string
   created_at: string
   updated_at: string
 }
 
 export interface ReplyPreview {
   id: string
+  wamid?: string
   content: any
   message_type: string
   direction: 'incoming' | 'outgoing'
+  media_url?: string
+  template_name?: string
+  campaign_id?: string
+}
+
+// Meta's context of an incoming reply or forward
+export interface MessageContext {
+  id?: string
+  from?: string
+  forwarded?: boolean
+  frequently_forwarded?: boolean
+  referred_product?: { catalog_id: string; product_retailer_id: string }
 }
 
 export interface Reaction {
@@ -63,6 +91,7 @@ export interface Message {
   is_reply?: boolean
   reply_to_message_id?: string
   reply_to_message?: ReplyPreview
+  context?: MessageContext
   reactions?: Reaction[]
   whatsapp_account?: string
   created_at: string
@@ -212,9 +241,9 @@ export const useContactsStore = defineStore('contacts', () => {
     }
   }
 
-  async function sendMessage(contactId: string, type: string, content: any, replyToMessageId?: string, whatsappAccount?: string) {
+  async function sendMessage(contactId: string, type: string, content: any, replyToMessageId?: string, whatsappAccount?: string, extra?: { location?: SharedLocation; contacts?: SharedContactCard[] }) {
     try {
-      const response = await messagesService.send(contactId, { type, content, reply_to_message_id: replyToMessageId, whatsapp_account: whatsappAccount })
+      const response = await messagesService.send(contactId, { type, content, reply_to_message_id: replyToMessageId, whatsapp_account: whatsappAccount, ...extra })
       // API returns { status: "success", data: { ... } }
       const newMessage = response.data.data || response.data
       // Use addMessage which has duplicate checking (WebSocket may also broadcast this)
@@ -334,6 +363,22 @@ export const useContactsStore = defineStore('contacts', () => {
     }
   }
 
+  function updateContactBlock(updated: Contact) {
+    const block = {
+      status: updated.status,
+      is_blocked: updated.is_blocked,
+      blocked_reason: updated.blocked_reason,
+      blocked_at: updated.blocked_at
+    }
+    const contact = contacts.value.find(c => c.id === updated.id)
+    if (contact) {
+      Object.assign(contact, block)
+    }
+    if (currentContact.value?.id === updated.id) {
+      currentContact.value = { ...currentContact.value, ...block }
+    }
+  }
+
   return {
     contacts,
     currentContact,
@@ -367,6 +412,7 @@ export const useContactsStore = defineStore('contacts', () => {
     setReplyingTo,
     clearReplyingTo,
     updateMessageReactions,
-    updateContactTags
+    updateContactTags,
+    updateContactBlock
   }
 })
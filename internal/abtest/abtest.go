@@ -0,0 +1,81 @@
+// Package abtest splits the recipients of an A/B tested campaign between its
+// template variants. Assignment is a pure function of the campaign and the
+// recipient's phone number, so a recipient keeps their variant across
+// retries, restarts and workers.
+package abtest
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// buckets is the resolution of the test group split; 10000 buckets allow
+// test percentages down to 0.01%
+const buckets = 10000
+
+// hash maps a recipient to a number stable for the campaign. salt keeps the
+// test group split independent of the variant split.
+func hash(campaignID uuid.UUID, phone, salt string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(campaignID[:])
+	_, _ = h.Write([]byte(phone + "|" + salt))
+	return h.Sum64()
+}
+
+// InTestGroup reports whether a recipient gets one of the variants rather
+// than waiting for the winner. Without winner mode every recipient does.
+func InTestGroup(campaign *models.BulkMessageCampaign, phone string) bool {
+	if campaign.WinnerAfterHours <= 0 {
+		return true
+	}
+	return hash(campaign.ID, phone, "test")%buckets < uint64(campaign.WinnerTestPercent)*buckets/100
+}
+
+// Assign returns the variant a recipient is sent. held is true when the
+// recipient is outside the test group and no winner has been picked yet;
+// the recipient must not be sent until then. A campaign without variants
+// returns nil and false.
+func Assign(campaign *models.BulkMessageCampaign, phone string) (variant *models.CampaignVariant, held bool) {
+	if len(campaign.Variants) == 0 {
+		return nil, false
+	}
+
+	if !InTestGroup(campaign, phone) {
+		if campaign.WinnerVariantID == nil {
+			return nil, true
+		}
+		for i := range campaign.Variants {
+			if campaign.Variants[i].ID == *campaign.WinnerVariantID {
+				return &campaign.Variants[i], false
+			}
+		}
+		return nil, true
+	}
+
+	// Order by name so the split doesn't depend on how variants were loaded
+	variants := make([]*models.CampaignVariant, len(campaign.Variants))
+	for i := range campaign.Variants {
+		variants[i] = &campaign.Variants[i]
+	}
+	slices.SortFunc(variants, func(a, b *models.CampaignVariant) int { return cmp.Compare(a.Name, b.Name) })
+
+	total := 0
+	for _, v := range variants {
+		total += max(v.Weight, 0)
+	}
+	if total == 0 {
+		return variants[0], false
+	}
+	n := int(hash(campaign.ID, phone, "variant") % uint64(total))
+	for _, v := range variants {
+		n -= max(v.Weight, 0)
+		if n < 0 {
+			return v, false
+		}
+	}
+	return variants[len(variants)-1], false
+}
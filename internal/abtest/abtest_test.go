@@ -0,0 +1,92 @@
+package abtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCampaign(weights ...int) *models.BulkMessageCampaign {
+	campaign := &models.BulkMessageCampaign{}
+	campaign.ID = uuid.New()
+	for i, w := range weights {
+		v := models.CampaignVariant{Name: string(rune('A' + i)), Weight: w}
+		v.ID = uuid.New()
+		campaign.Variants = append(campaign.Variants, v)
+	}
+	return campaign
+}
+
+func phone(i int) string {
+	return fmt.Sprintf("1555%07d", i)
+}
+
+func TestAssign_NoVariants(t *testing.T) {
+	variant, held := Assign(testCampaign(), phone(1))
+	assert.Nil(t, variant)
+	assert.False(t, held)
+}
+
+func TestAssign_Deterministic(t *testing.T) {
+	campaign := testCampaign(1, 1, 1)
+	first, _ := Assign(campaign, phone(42))
+	require.NotNil(t, first)
+	firstID := first.ID
+
+	// Load order does not change the assignment
+	campaign.Variants[0], campaign.Variants[2] = campaign.Variants[2], campaign.Variants[0]
+	for i := 0; i < 5; i++ {
+		again, _ := Assign(campaign, phone(42))
+		assert.Equal(t, firstID, again.ID)
+	}
+}
+
+func TestAssign_Weights(t *testing.T) {
+	campaign := testCampaign(3, 1)
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		v, held := Assign(campaign, phone(i))
+		require.False(t, held)
+		counts[v.Name]++
+	}
+	// 3:1 split; generous bounds keep the test stable
+	assert.InDelta(t, 3000, counts["A"], 200)
+	assert.InDelta(t, 1000, counts["B"], 200)
+}
+
+func TestAssign_WinnerMode(t *testing.T) {
+	campaign := testCampaign(1, 1)
+	campaign.WinnerAfterHours = 4
+	campaign.WinnerTestPercent = 20
+
+	var test, heldCount int
+	for i := 0; i < 2000; i++ {
+		v, held := Assign(campaign, phone(i))
+		if held {
+			assert.Nil(t, v)
+			assert.False(t, InTestGroup(campaign, phone(i)))
+			heldCount++
+		} else {
+			assert.True(t, InTestGroup(campaign, phone(i)))
+			test++
+		}
+	}
+	assert.InDelta(t, 400, test, 80)
+
+	// Once a winner is picked the held recipients get it, and the test
+	// group keeps the variants it was sent
+	winner := campaign.Variants[1]
+	campaign.WinnerVariantID = &winner.ID
+	for i := 0; i < 2000; i++ {
+		v, held := Assign(campaign, phone(i))
+		require.False(t, held)
+		if !InTestGroup(campaign, phone(i)) {
+			assert.Equal(t, winner.ID, v.ID)
+		}
+	}
+	assert.Positive(t, heldCount)
+}
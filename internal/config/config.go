@@ -97,6 +97,15 @@ type WhatsAppConfig struct {
 	WebhookVerifyToken string `koanf:"webhook_verify_token"`
 	APIVersion         string `koanf:"api_version"`
 	BaseURL            string `koanf:"base_url"` // Meta Graph API base URL
+
+	// Per phone number send limit shared by campaigns, chatbot and agents
+	SendRate    int     `koanf:"send_rate"`    // Messages per second when an account sets none
+	BulkReserve float64 `koanf:"bulk_reserve"` // Fraction of the rate held back from campaigns for live chats
+
+	// Embedded Signup lets businesses connect their numbers through this Meta app
+	AppID                  string `koanf:"app_id"`
+	AppSecret              string `koanf:"app_secret"`
+	EmbeddedSignupConfigID string `koanf:"embedded_signup_config_id"` // Facebook Login for Business configuration
 }
 
 type AIConfig struct {
@@ -250,6 +259,13 @@ func setDefaults(cfg *Config) {
 	if cfg.RateLimit.WindowSeconds == 0 {
 		cfg.RateLimit.WindowSeconds = 60
 	}
+	// Send rate defaults (Meta's default throughput is 80 messages/sec)
+	if cfg.WhatsApp.SendRate == 0 {
+		cfg.WhatsApp.SendRate = 80
+	}
+	if cfg.WhatsApp.BulkReserve == 0 {
+		cfg.WhatsApp.BulkReserve = 0.2
+	}
 	// Calling defaults
 	if cfg.Calling.MaxCallDuration == 0 {
 		cfg.Calling.MaxCallDuration = 300
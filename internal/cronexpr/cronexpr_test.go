@@ -0,0 +1,89 @@
+package cronexpr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, expr string) *Schedule {
+	t.Helper()
+	s, err := Parse(expr)
+	require.NoError(t, err)
+	return s
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 4, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 4, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON", time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 1 * *", time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 9 15 * FRI", time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC)},
+		{"0 9 31 * *", time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			assert.Equal(t, tt.want, mustParse(t, tt.expr).Next(from))
+		})
+	}
+}
+
+func TestNext_Never(t *testing.T) {
+	assert.True(t, mustParse(t, "0 0 30 2 *").Next(time.Now()).IsZero())
+}
+
+func TestNext_TimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	from := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC).In(loc) // 16:00 IST
+	next := mustParse(t, "0 9 * * *").Next(from)
+	assert.Equal(t, time.Date(2026, 3, 5, 9, 0, 0, 0, loc), next)
+	assert.Equal(t, time.Date(2026, 3, 5, 3, 30, 0, 0, time.UTC), next.UTC())
+}
+
+func TestNext_DST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// 2:30 doesn't exist on 8 March 2026, so that day is skipped
+	from := time.Date(2026, 3, 7, 12, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2026, 3, 9, 2, 30, 0, 0, loc), mustParse(t, "30 2 * * *").Next(from))
+}
+
+func TestRunsPerHour(t *testing.T) {
+	assert.Equal(t, 1, mustParse(t, "@hourly").RunsPerHour())
+	assert.Equal(t, 4, mustParse(t, "*/15 9 * * *").RunsPerHour())
+}
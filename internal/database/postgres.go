@@ -73,11 +73,22 @@ func GetMigrationModels() []MigrationModel {
 		{"Message", &models.Message{}},
 		{"Template", &models.Template{}},
 		{"WhatsAppFlow", &models.WhatsAppFlow{}},
+		{"InboundWebhookEvent", &models.InboundWebhookEvent{}},
+		{"AccountHealthEvent", &models.AccountHealthEvent{}},
+
+		// Pricing
+		{"PricingRate", &models.PricingRate{}},
+		{"ConversationCost", &models.ConversationCost{}},
 
 		// Bulk & Notifications
+		{"ContactSegment", &models.ContactSegment{}}, // Campaign audiences
 		{"BulkMessageCampaign", &models.BulkMessageCampaign{}},
+		{"CampaignVariant", &models.CampaignVariant{}},
 		{"BulkMessageRecipient", &models.BulkMessageRecipient{}},
+		{"RecurringCampaign", &models.RecurringCampaign{}},
 		{"NotificationRule", &models.NotificationRule{}},
+		{"NotificationRuleLog", &models.NotificationRuleLog{}},
+		{"SuppressionEntry", &models.SuppressionEntry{}}, // Opted-out numbers
 
 		// Chatbot models
 		{"ChatbotSettings", &models.ChatbotSettings{}},
@@ -99,12 +110,19 @@ func GetMigrationModels() []MigrationModel {
 		{"Catalog", &models.Catalog{}},
 		{"CatalogProduct", &models.CatalogProduct{}},
 
+		// Orders
+		{"Order", &models.Order{}},
+		{"OrderItem", &models.OrderItem{}},
+
 		// Dashboard
 		{"Widget", &models.Widget{}},
 
 		// Conversation Notes
 		{"ConversationNote", &models.ConversationNote{}},
 
+		// Scheduled chat messages
+		{"ScheduledMessage", &models.ScheduledMessage{}},
+
 		// Calling / IVR
 		{"CallLog", &models.CallLog{}},
 		{"IVRFlow", &models.IVRFlow{}},
@@ -250,6 +268,8 @@ func getIndexes() []string {
 		`CREATE INDEX IF NOT EXISTS idx_ai_contexts_account ON ai_contexts(whats_app_account, is_enabled, priority DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_bulk_campaigns_account ON bulk_message_campaigns(whats_app_account, status)`,
 		`CREATE INDEX IF NOT EXISTS idx_notification_rules_account ON notification_rules(whats_app_account, is_enabled)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_rules_trigger_token ON notification_rules(trigger_token) WHERE trigger_token != ''`,
+		`CREATE INDEX IF NOT EXISTS idx_notification_rule_logs_rule ON notification_rule_logs(rule_id, created_at DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_messages_account ON messages(whats_app_account, created_at DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_contacts_account ON contacts(whats_app_account)`,
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_canned_responses_org_name ON canned_responses(organization_id, name)`,
@@ -273,6 +293,8 @@ func getIndexes() []string {
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_user_org_unique ON user_organizations(user_id, organization_id) WHERE deleted_at IS NULL`,
 		// Conversation notes
 		`CREATE INDEX IF NOT EXISTS idx_conversation_notes_contact ON conversation_notes(organization_id, contact_id, created_at DESC)`,
+		// Scheduled messages
+		`CREATE INDEX IF NOT EXISTS idx_scheduled_messages_due ON scheduled_messages(status, send_at)`,
 		// Call logs
 		`CREATE INDEX IF NOT EXISTS idx_call_logs_org_status ON call_logs(organization_id, status, created_at DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_call_logs_contact ON call_logs(contact_id, created_at DESC)`,
@@ -0,0 +1,105 @@
+// Package frequencycap limits how many marketing templates a contact receives
+// in a rolling window, counted from the messages already sent to them.
+package frequencycap
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"gorm.io/gorm"
+)
+
+// Organization settings keys holding the organization-wide cap
+const (
+	MaxSetting         = "marketing_cap_max"
+	WindowHoursSetting = "marketing_cap_window_hours"
+)
+
+// DefaultWindowHours is the window used when a cap sets no window
+const DefaultWindowHours = 24
+
+// MaxWindowHours bounds a cap's window, and so how far a recipient can be deferred
+const MaxWindowHours = 7 * 24
+
+// Cap allows at most Max marketing templates per contact in any Window.
+// A cap with Max 0 is off.
+type Cap struct {
+	Max    int
+	Window time.Duration
+}
+
+// Active reports whether the cap limits anything
+func (c Cap) Active() bool {
+	return c.Max > 0 && c.Window > 0
+}
+
+func newCap(max, windowHours int) Cap {
+	if windowHours <= 0 {
+		windowHours = DefaultWindowHours
+	}
+	return Cap{Max: max, Window: time.Duration(windowHours) * time.Hour}
+}
+
+// OrgCap returns the cap set in an organization's settings
+func OrgCap(settings models.JSONB) Cap {
+	return newCap(settingInt(settings, MaxSetting), settingInt(settings, WindowHoursSetting))
+}
+
+// settingInt reads a number from settings, which hold float64 once loaded
+// from the database
+func settingInt(settings models.JSONB, key string) int {
+	switch v := settings[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// CampaignCap returns the cap a campaign sets on top of its organization's
+func CampaignCap(campaign *models.BulkMessageCampaign) Cap {
+	return newCap(campaign.FrequencyCapMax, campaign.FrequencyCapWindowHours)
+}
+
+// IsMarketing reports whether sends of the template count against caps
+func IsMarketing(template *models.Template) bool {
+	return template != nil && strings.EqualFold(template.Category, "MARKETING")
+}
+
+// Check reports whether sending a contact another marketing template at now
+// would exceed any of the caps, and if so the earliest time none would be.
+// Sent, delivered and read template messages count when Meta billed them as
+// marketing or their template is a marketing one.
+func Check(db *gorm.DB, orgID, contactID uuid.UUID, now time.Time, caps ...Cap) (bool, time.Time, error) {
+	var freeAt time.Time
+	for _, c := range caps {
+		if !c.Active() {
+			continue
+		}
+		var sentAt []time.Time
+		err := db.Model(&models.Message{}).
+			Where("organization_id = ? AND contact_id = ? AND direction = ? AND message_type = ?",
+				orgID, contactID, models.DirectionOutgoing, models.MessageTypeTemplate).
+			Where("status IN ?", []models.MessageStatus{models.MessageStatusSent, models.MessageStatusDelivered, models.MessageStatusRead}).
+			Where("created_at > ?", now.Add(-c.Window)).
+			Where("pricing_category = ? OR template_name IN (?)", "marketing",
+				db.Model(&models.Template{}).Select("name").Where("organization_id = ? AND UPPER(category) = ?", orgID, "MARKETING")).
+			Order("created_at DESC").
+			Limit(c.Max).
+			Pluck("created_at", &sentAt).Error
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		if len(sentAt) < c.Max {
+			continue
+		}
+		// The cap frees up when the oldest of the last Max sends leaves the window
+		if at := sentAt[c.Max-1].Add(c.Window); at.After(freeAt) {
+			freeAt = at
+		}
+	}
+	return !freeAt.IsZero(), freeAt, nil
+}
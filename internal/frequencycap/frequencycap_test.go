@@ -0,0 +1,96 @@
+package frequencycap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgCap(t *testing.T) {
+	assert.False(t, OrgCap(nil).Active())
+
+	// Settings decoded from JSON hold float64; a missing window is the default
+	c := OrgCap(models.JSONB{MaxSetting: float64(2)})
+	assert.True(t, c.Active())
+	assert.Equal(t, 2, c.Max)
+	assert.Equal(t, DefaultWindowHours*time.Hour, c.Window)
+
+	c = OrgCap(models.JSONB{MaxSetting: 3, WindowHoursSetting: float64(48)})
+	assert.Equal(t, 3, c.Max)
+	assert.Equal(t, 48*time.Hour, c.Window)
+}
+
+func TestCampaignCap(t *testing.T) {
+	assert.False(t, CampaignCap(&models.BulkMessageCampaign{}).Active())
+
+	c := CampaignCap(&models.BulkMessageCampaign{FrequencyCapMax: 1, FrequencyCapWindowHours: 72})
+	assert.Equal(t, Cap{Max: 1, Window: 72 * time.Hour}, c)
+}
+
+func TestIsMarketing(t *testing.T) {
+	assert.True(t, IsMarketing(&models.Template{Category: "MARKETING"}))
+	assert.True(t, IsMarketing(&models.Template{Category: "marketing"}))
+	assert.False(t, IsMarketing(&models.Template{Category: "UTILITY"}))
+	assert.False(t, IsMarketing(nil))
+}
+
+func TestCheck(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	uid := uuid.New().String()[:8]
+	org := models.Organization{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "test-" + uid, Slug: "test-" + uid}
+	require.NoError(t, db.Create(&org).Error)
+	contact := models.Contact{BaseModel: models.BaseModel{ID: uuid.New()}, OrganizationID: org.ID, PhoneNumber: "1555" + uid}
+	require.NoError(t, db.Create(&contact).Error)
+	require.NoError(t, db.Create(&models.Template{
+		OrganizationID: org.ID, WhatsAppAccount: "acct", Name: "promo-" + uid, Language: "en", Category: "MARKETING",
+	}).Error)
+
+	now := time.Now()
+	send := func(ago time.Duration, templateName, pricing string, status models.MessageStatus) {
+		msg := models.Message{
+			BaseModel:       models.BaseModel{ID: uuid.New(), CreatedAt: now.Add(-ago)},
+			OrganizationID:  org.ID,
+			WhatsAppAccount: "acct",
+			ContactID:       contact.ID,
+			Direction:       models.DirectionOutgoing,
+			MessageType:     models.MessageTypeTemplate,
+			TemplateName:    templateName,
+			PricingCategory: pricing,
+			Status:          status,
+		}
+		require.NoError(t, db.Create(&msg).Error)
+	}
+
+	daily := Cap{Max: 2, Window: 24 * time.Hour}
+	capped, _, err := Check(db, org.ID, contact.ID, now, daily)
+	require.NoError(t, err)
+	assert.False(t, capped)
+
+	// Failed sends, utility templates and sends outside the window don't count
+	send(time.Hour, "promo-"+uid, "", models.MessageStatusFailed)
+	send(time.Hour, "receipt-"+uid, "utility", models.MessageStatusDelivered)
+	send(30*time.Hour, "promo-"+uid, "", models.MessageStatusRead)
+	send(10*time.Hour, "promo-"+uid, "", models.MessageStatusDelivered)
+	capped, _, err = Check(db, org.ID, contact.ID, now, daily)
+	require.NoError(t, err)
+	assert.False(t, capped)
+
+	// A send Meta billed as marketing counts whatever its template
+	send(2*time.Hour, "other-"+uid, "marketing", models.MessageStatusSent)
+	capped, freeAt, err := Check(db, org.ID, contact.ID, now, daily)
+	require.NoError(t, err)
+	assert.True(t, capped)
+	assert.WithinDuration(t, now.Add(14*time.Hour), freeAt, time.Second)
+
+	// The latest time across caps wins, and inactive caps are ignored
+	weekly := Cap{Max: 3, Window: 7 * 24 * time.Hour}
+	capped, freeAt, err = Check(db, org.ID, contact.ID, now, Cap{}, daily, weekly)
+	require.NoError(t, err)
+	assert.True(t, capped)
+	assert.WithinDuration(t, now.Add(-30*time.Hour+7*24*time.Hour), freeAt, time.Second)
+}
@@ -0,0 +1,668 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	accountHealthSourceWebhook = "webhook"
+	accountHealthSourcePoll    = "poll"
+	accountHealthSourceSend    = "send" // A message send was rejected
+	accountHealthSourceAPI     = "api"  // Changed by a user, e.g. a token rotation
+
+	// accountHealthHistoryLimit caps the events returned with an account's health
+	accountHealthHistoryLimit = 50
+)
+
+// qualityRanks orders quality ratings from worst to best; UNKNOWN is unranked
+var qualityRanks = map[models.QualityRating]int{
+	models.QualityRatingRed:    1,
+	models.QualityRatingYellow: 2,
+	models.QualityRatingGreen:  3,
+}
+
+// tierRanks orders Meta's messaging limit tiers from lowest to highest
+var tierRanks = map[string]int{
+	"TIER_50":        1,
+	"TIER_250":       2,
+	"TIER_1K":        3,
+	"TIER_2K":        4,
+	"TIER_10K":       5,
+	"TIER_100K":      6,
+	"TIER_UNLIMITED": 7,
+}
+
+// AccountHealthValue holds the change value fields of the
+// phone_number_quality_update, account_update and account_alerts webhooks.
+// The event name is read from the shared "event" field of the change value.
+type AccountHealthValue struct {
+	// phone_number_quality_update
+	DisplayPhoneNumber string `json:"display_phone_number,omitempty"`
+	CurrentLimit       string `json:"current_limit,omitempty"`
+
+	// account_update
+	PhoneNumber string `json:"phone_number,omitempty"`
+	BanInfo     *struct {
+		WABABanState string `json:"waba_ban_state"`
+		WABABanDate  string `json:"waba_ban_date"`
+	} `json:"ban_info,omitempty"`
+	ViolationInfo *struct {
+		ViolationType string `json:"violation_type"`
+	} `json:"violation_info,omitempty"`
+	RestrictionInfo []struct {
+		RestrictionType string `json:"restriction_type"`
+	} `json:"restriction_info,omitempty"`
+
+	// account_alerts
+	EntityType       string `json:"entity_type,omitempty"`
+	EntityID         string `json:"entity_id,omitempty"`
+	AlertSeverity    string `json:"alert_severity,omitempty"`
+	AlertStatus      string `json:"alert_status,omitempty"`
+	AlertType        string `json:"alert_type,omitempty"`
+	AlertDescription string `json:"alert_description,omitempty"`
+}
+
+// AccountHealthResponse is an account's current health with recent history
+type AccountHealthResponse struct {
+	Account            string                      `json:"account"`
+	Status             models.AccountStatus        `json:"status"`
+	DisplayPhoneNumber string                      `json:"display_phone_number"`
+	QualityRating      models.QualityRating        `json:"quality_rating"`
+	MessagingLimitTier string                      `json:"messaging_limit_tier"`
+	HealthCheckedAt    *time.Time                  `json:"health_checked_at,omitempty"`
+	TokenType          string                      `json:"token_type,omitempty"`
+	TokenExpiresAt     *time.Time                  `json:"token_expires_at,omitempty"`
+	TokenCheckedAt     *time.Time                  `json:"token_checked_at,omitempty"`
+	TokenError         string                      `json:"token_error,omitempty"`
+	AutoPauseCampaigns bool                        `json:"auto_pause_campaigns"`
+	Events             []models.AccountHealthEvent `json:"events"`
+}
+
+// processAccountHealthWebhook applies a phone_number_quality_update,
+// account_update or account_alerts change to the accounts it concerns
+func (a *App) processAccountHealthWebhook(wabaID, field, event string, value AccountHealthValue) {
+	accounts := a.accountsForHealthWebhook(wabaID, value)
+	if len(accounts) == 0 {
+		a.Log.Warn("No WhatsApp accounts found for account health webhook", "field", field, "waba_id", wabaID)
+		return
+	}
+
+	for i := range accounts {
+		account := &accounts[i]
+		switch field {
+		case "phone_number_quality_update":
+			a.applyQualityUpdate(account, event, value)
+		case "account_update":
+			a.applyAccountUpdate(account, event, value)
+		case "account_alerts":
+			a.applyAccountAlert(account, value)
+		}
+	}
+}
+
+// accountsForHealthWebhook finds the accounts of a business account that a
+// health webhook is about. Quality updates and some account updates name a
+// phone number, matched against the display number saved by the health
+// check; when none is known yet and the business has a single account, that
+// account is used.
+func (a *App) accountsForHealthWebhook(wabaID string, value AccountHealthValue) []models.WhatsAppAccount {
+	var accounts []models.WhatsAppAccount
+	if err := a.DB.Where("business_id = ?", wabaID).Find(&accounts).Error; err != nil {
+		a.Log.Error("Failed to find WhatsApp accounts for WABA", "error", err, "waba_id", wabaID)
+		return nil
+	}
+
+	if value.EntityType == "PHONE_NUMBER" && value.EntityID != "" {
+		return filterAccounts(accounts, func(acc models.WhatsAppAccount) bool { return acc.PhoneID == value.EntityID })
+	}
+
+	phone := phoneDigits(value.DisplayPhoneNumber)
+	if phone == "" {
+		phone = phoneDigits(value.PhoneNumber)
+	}
+	if phone == "" {
+		return accounts
+	}
+	matched := filterAccounts(accounts, func(acc models.WhatsAppAccount) bool {
+		return phoneDigits(acc.DisplayPhoneNumber) == phone
+	})
+	if len(matched) == 0 && len(accounts) == 1 && accounts[0].DisplayPhoneNumber == "" {
+		return accounts
+	}
+	return matched
+}
+
+func filterAccounts(accounts []models.WhatsAppAccount, keep func(models.WhatsAppAccount) bool) []models.WhatsAppAccount {
+	var out []models.WhatsAppAccount
+	for _, acc := range accounts {
+		if keep(acc) {
+			out = append(out, acc)
+		}
+	}
+	return out
+}
+
+// phoneDigits strips formatting from a phone number, e.g. "+1 555-010-0000"
+func phoneDigits(phone string) string {
+	var b strings.Builder
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// applyQualityUpdate handles a phone_number_quality_update webhook. FLAGGED
+// means the quality rating dropped to RED; UNFLAGGED only says it recovered,
+// so the actual rating is fetched. UPGRADE and DOWNGRADE carry the new tier.
+func (a *App) applyQualityUpdate(account *models.WhatsAppAccount, event string, value AccountHealthValue) {
+	event = strings.ToUpper(event)
+	if value.CurrentLimit != "" {
+		a.applyMessagingLimitTier(account, strings.ToUpper(value.CurrentLimit), accountHealthSourceWebhook, event)
+	}
+
+	switch event {
+	case "FLAGGED":
+		a.applyQualityRating(account, models.QualityRatingRed, accountHealthSourceWebhook, event)
+	case "UNFLAGGED":
+		if a.WhatsApp == nil {
+			return
+		}
+		a.decryptAccountSecrets(account)
+		if err := a.refreshAccountHealth(context.Background(), account, accountHealthSourceWebhook); err != nil {
+			a.Log.Error("Failed to refresh account health", "error", err, "account", account.Name)
+		}
+	}
+}
+
+// applyAccountUpdate handles an account_update webhook: bans and
+// restrictions change the account status, violations are recorded as alerts
+func (a *App) applyAccountUpdate(account *models.WhatsAppAccount, event string, value AccountHealthValue) {
+	event = strings.ToUpper(event)
+	details := models.JSONB{}
+
+	switch {
+	case value.BanInfo != nil:
+		details["waba_ban_state"] = value.BanInfo.WABABanState
+		details["waba_ban_date"] = value.BanInfo.WABABanDate
+		switch value.BanInfo.WABABanState {
+		case "DISABLE":
+			a.applyAccountStatus(account, models.AccountStatusDisabled, accountHealthSourceWebhook, event, details)
+		case "REINSTATE":
+			a.applyAccountStatus(account, models.AccountStatusActive, accountHealthSourceWebhook, event, details)
+		default:
+			a.recordAccountHealthEvent(account, models.AccountHealthEvent{
+				Kind:        models.AccountHealthAlert,
+				Source:      accountHealthSourceWebhook,
+				Event:       event,
+				Severity:    "critical",
+				Description: "Business account ban state: " + value.BanInfo.WABABanState,
+				Downgrade:   true,
+				Details:     details,
+			})
+		}
+	case event == "ACCOUNT_RESTRICTION":
+		var restrictions []string
+		for _, r := range value.RestrictionInfo {
+			restrictions = append(restrictions, r.RestrictionType)
+		}
+		details["restrictions"] = restrictions
+		if len(restrictions) > 0 {
+			a.applyAccountStatus(account, models.AccountStatusRestricted, accountHealthSourceWebhook, event, details)
+		} else {
+			a.applyAccountStatus(account, models.AccountStatusActive, accountHealthSourceWebhook, event, details)
+		}
+	case event == "ACCOUNT_VIOLATION":
+		description := "Policy violation reported"
+		if value.ViolationInfo != nil {
+			details["violation_type"] = value.ViolationInfo.ViolationType
+			description += ": " + value.ViolationInfo.ViolationType
+		}
+		a.recordAccountHealthEvent(account, models.AccountHealthEvent{
+			Kind:        models.AccountHealthAlert,
+			Source:      accountHealthSourceWebhook,
+			Event:       event,
+			Severity:    "warning",
+			Description: description,
+			Downgrade:   true,
+			Details:     details,
+		})
+	default:
+		a.recordAccountHealthEvent(account, models.AccountHealthEvent{
+			Kind:     models.AccountHealthStatus,
+			Source:   accountHealthSourceWebhook,
+			Event:    event,
+			Severity: "info",
+		})
+	}
+}
+
+// applyAccountAlert records an account_alerts webhook. Active warning and
+// critical alerts notify the organization.
+func (a *App) applyAccountAlert(account *models.WhatsAppAccount, value AccountHealthValue) {
+	severity := "info"
+	switch strings.ToUpper(value.AlertSeverity) {
+	case "CRITICAL":
+		severity = "critical"
+	case "WARNING":
+		severity = "warning"
+	}
+
+	a.recordAccountHealthEvent(account, models.AccountHealthEvent{
+		Kind:        models.AccountHealthAlert,
+		Source:      accountHealthSourceWebhook,
+		Event:       value.AlertType,
+		NewValue:    value.AlertStatus,
+		Severity:    severity,
+		Description: value.AlertDescription,
+		Downgrade:   severity != "info" && strings.ToUpper(value.AlertStatus) == "ACTIVE",
+		Details: models.JSONB{
+			"entity_type": value.EntityType,
+			"entity_id":   value.EntityID,
+		},
+	})
+}
+
+// applyQualityRating saves a new quality rating and records the change.
+// The update is conditional on the previous rating, so replicas checking
+// the same account at once record the change only once. A drop to RED
+// pauses the account's campaigns if it opted in.
+func (a *App) applyQualityRating(account *models.WhatsAppAccount, rating models.QualityRating, source, metaEvent string) {
+	if _, ok := qualityRanks[rating]; !ok {
+		rating = models.QualityRatingUnknown
+	}
+	old := account.QualityRating
+	if rating == old {
+		return
+	}
+	if !a.updateAccountHealthColumn(account, "quality_rating", old, rating) {
+		return
+	}
+	account.QualityRating = rating
+
+	// An account seen for the first time is treated as previously GREEN, so
+	// one that is already RED still raises an alert
+	oldRank, ok := qualityRanks[old]
+	if !ok {
+		oldRank = qualityRanks[models.QualityRatingGreen]
+	}
+	newRank := qualityRanks[rating]
+
+	event := models.AccountHealthEvent{
+		Kind:      models.AccountHealthQuality,
+		Source:    source,
+		Event:     metaEvent,
+		OldValue:  string(old),
+		NewValue:  string(rating),
+		Severity:  "info",
+		Downgrade: newRank > 0 && newRank < oldRank,
+	}
+	switch rating {
+	case models.QualityRatingRed:
+		event.Severity = "critical"
+		event.Description = "Quality rating dropped to RED"
+	case models.QualityRatingYellow:
+		event.Severity = "warning"
+	}
+	if rating == models.QualityRatingRed && old != models.QualityRatingRed {
+		if paused := a.pauseCampaignsForLowQuality(account); paused > 0 {
+			event.Details = models.JSONB{"paused_campaigns": paused}
+		}
+	}
+	a.recordAccountHealthEvent(account, event)
+}
+
+// applyMessagingLimitTier saves a new messaging limit tier and records the change
+func (a *App) applyMessagingLimitTier(account *models.WhatsAppAccount, tier, source, metaEvent string) {
+	old := account.MessagingLimitTier
+	if tier == "" || tier == old {
+		return
+	}
+	if !a.updateAccountHealthColumn(account, "messaging_limit_tier", old, tier) {
+		return
+	}
+	account.MessagingLimitTier = tier
+
+	downgrade := tierRanks[old] > 0 && tierRanks[tier] > 0 && tierRanks[tier] < tierRanks[old]
+	severity := "info"
+	if downgrade {
+		severity = "warning"
+	}
+	a.recordAccountHealthEvent(account, models.AccountHealthEvent{
+		Kind:      models.AccountHealthTier,
+		Source:    source,
+		Event:     metaEvent,
+		OldValue:  old,
+		NewValue:  tier,
+		Severity:  severity,
+		Downgrade: downgrade,
+	})
+}
+
+// applyAccountStatus saves a new account status and records the change
+func (a *App) applyAccountStatus(account *models.WhatsAppAccount, status models.AccountStatus, source, metaEvent string, details models.JSONB) {
+	old := account.Status
+	if status == old {
+		return
+	}
+	if !a.updateAccountHealthColumn(account, "status", old, status) {
+		return
+	}
+	account.Status = status
+
+	event := models.AccountHealthEvent{
+		Kind:     models.AccountHealthStatus,
+		Source:   source,
+		Event:    metaEvent,
+		OldValue: string(old),
+		NewValue: string(status),
+		Severity: "info",
+		Details:  details,
+	}
+	switch status {
+	case models.AccountStatusDisabled:
+		event.Severity = "critical"
+		event.Description = "Business account disabled by Meta"
+		event.Downgrade = true
+	case models.AccountStatusRestricted:
+		event.Severity = "warning"
+		event.Description = "Business account messaging restricted by Meta"
+		event.Downgrade = true
+	case models.AccountStatusAuthFailed:
+		event.Severity = "critical"
+		event.Description = "Access token rejected by Meta. Rotate the token to resume sending."
+		event.Downgrade = true
+	}
+	a.recordAccountHealthEvent(account, event)
+}
+
+// updateAccountHealthColumn sets a health column only if it still holds the
+// value the caller read. Returns false if the update failed or another
+// replica got there first. Accounts created before health tracking hold NULL,
+// which is read as empty.
+func (a *App) updateAccountHealthColumn(account *models.WhatsAppAccount, column string, old, value any) bool {
+	result := a.DB.Model(&models.WhatsAppAccount{}).
+		Where("id = ? AND COALESCE("+column+", '') = ?", account.ID, old).
+		Update(column, value)
+	if result.Error != nil {
+		a.Log.Error("Failed to update account health", "error", result.Error, "account", account.Name, "column", column)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		return false
+	}
+	a.InvalidateWhatsAppAccountCache(account.PhoneID)
+	return true
+}
+
+// recordAccountHealthEvent stores a health event and alerts the organization
+// over WebSocket and outbound webhooks when it is a downgrade
+func (a *App) recordAccountHealthEvent(account *models.WhatsAppAccount, event models.AccountHealthEvent) {
+	event.OrganizationID = account.OrganizationID
+	event.WhatsAppAccount = account.Name
+	if err := a.DB.Create(&event).Error; err != nil {
+		a.Log.Error("Failed to record account health event", "error", err, "account", account.Name)
+	}
+
+	a.Log.Info("Account health changed",
+		"account", account.Name,
+		"kind", event.Kind,
+		"event", event.Event,
+		"old", event.OldValue,
+		"new", event.NewValue,
+		"downgrade", event.Downgrade,
+	)
+
+	if !event.Downgrade {
+		return
+	}
+
+	data := AccountAlertEventData{
+		WhatsAppAccount: account.Name,
+		Kind:            event.Kind,
+		Event:           event.Event,
+		OldValue:        event.OldValue,
+		NewValue:        event.NewValue,
+		Severity:        event.Severity,
+		Description:     event.Description,
+	}
+	if a.WSHub != nil {
+		a.WSHub.BroadcastToOrg(account.OrganizationID, websocket.WSMessage{
+			Type:    websocket.TypeAccountHealthAlert,
+			Payload: data,
+		})
+	}
+	a.DispatchWebhook(account.OrganizationID, models.WebhookEventAccountAlert, data)
+}
+
+// pauseCampaignsForLowQuality pauses the account's queued, running and
+// scheduled campaigns, unless the account opted out. Campaigns with a sender
+// pool keep running, as the worker moves their traffic to the pool's other
+// numbers. Returns how many were paused.
+func (a *App) pauseCampaignsForLowQuality(account *models.WhatsAppAccount) int {
+	if !account.AutoPauseCampaigns {
+		return 0
+	}
+
+	var paused []models.BulkMessageCampaign
+	result := a.DB.Model(&paused).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("organization_id = ? AND whats_app_account = ? AND status IN ?", account.OrganizationID, account.Name, []models.CampaignStatus{
+			models.CampaignStatusScheduled,
+			models.CampaignStatusQueued,
+			models.CampaignStatusProcessing,
+		}).
+		Where("sender_accounts IS NULL OR jsonb_array_length(sender_accounts) = 0").
+		Update("status", models.CampaignStatusPaused)
+	if result.Error != nil {
+		a.Log.Error("Failed to pause campaigns after quality drop", "error", result.Error, "account", account.Name)
+		return 0
+	}
+
+	for _, campaign := range paused {
+		a.Log.Warn("Campaign paused after quality dropped to RED", "campaign_id", campaign.ID, "account", account.Name)
+		if a.WSHub != nil {
+			a.WSHub.BroadcastToOrg(account.OrganizationID, websocket.WSMessage{
+				Type: websocket.TypeCampaignStatsUpdate,
+				Payload: map[string]interface{}{
+					"campaign_id": campaign.ID.String(),
+					"status":      models.CampaignStatusPaused,
+				},
+			})
+		}
+	}
+	return len(paused)
+}
+
+// refreshAccountHealth pulls the phone number's quality rating and messaging
+// tier from Meta. The account's secrets must already be decrypted.
+func (a *App) refreshAccountHealth(ctx context.Context, account *models.WhatsAppAccount, source string) error {
+	health, err := a.WhatsApp.GetPhoneNumberHealth(ctx, a.toWhatsAppAccount(account))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := a.DB.Model(&models.WhatsAppAccount{}).Where("id = ?", account.ID).Updates(map[string]any{
+		"display_phone_number": health.DisplayPhoneNumber,
+		"health_checked_at":    now,
+	}).Error; err != nil {
+		return err
+	}
+	account.DisplayPhoneNumber = health.DisplayPhoneNumber
+	account.HealthCheckedAt = &now
+
+	a.applyQualityRating(account, models.QualityRating(strings.ToUpper(health.QualityRating)), source, "")
+	a.applyMessagingLimitTier(account, strings.ToUpper(health.MessagingLimitTier), source, "")
+	return nil
+}
+
+// AccountHealthMonitor periodically pulls the quality rating and messaging
+// tier of every account, catching changes whose webhooks were missed, and
+// inspects each access token for expiry or revocation
+type AccountHealthMonitor struct {
+	app      *App
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewAccountHealthMonitor creates a new account health monitor
+func NewAccountHealthMonitor(app *App, interval time.Duration) *AccountHealthMonitor {
+	return &AccountHealthMonitor{
+		app:      app,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the monitoring loop
+func (m *AccountHealthMonitor) Start(ctx context.Context) {
+	m.app.Log.Info("Account health monitor started", "interval", m.interval)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.app.Log.Info("Account health monitor stopped by context")
+			return
+		case <-m.stopCh:
+			m.app.Log.Info("Account health monitor stopped")
+			return
+		case <-ticker.C:
+			m.CheckAccounts(ctx)
+		}
+	}
+}
+
+// Stop stops the account health monitor
+func (m *AccountHealthMonitor) Stop() {
+	close(m.stopCh)
+}
+
+// CheckAccounts refreshes the health of every account. Returns the number of
+// accounts checked successfully.
+func (m *AccountHealthMonitor) CheckAccounts(ctx context.Context) int {
+	var accounts []models.WhatsAppAccount
+	if err := m.app.DB.Find(&accounts).Error; err != nil {
+		m.app.Log.Error("Failed to load accounts for health check", "error", err)
+		return 0
+	}
+
+	checked := 0
+	for i := range accounts {
+		if ctx.Err() != nil {
+			break
+		}
+		account := &accounts[i]
+		m.app.decryptAccountSecrets(account)
+		if err := m.app.refreshAccountHealth(ctx, account, accountHealthSourcePoll); err != nil {
+			m.app.Log.Warn("Account health check failed", "error", err, "account", account.Name)
+			if apiErr, ok := whatsapp.AsAPIError(err); ok && apiErr.Class() == whatsapp.ErrorClassAuth {
+				m.app.markAccountAuthFailed(account, accountHealthSourcePoll, apiErr)
+			}
+			continue
+		}
+		if err := m.app.checkAccountToken(ctx, account, accountHealthSourcePoll); err != nil {
+			m.app.Log.Warn("Access token check failed", "error", err, "account", account.Name)
+		}
+		checked++
+	}
+	return checked
+}
+
+// GetAccountHealth returns an account's quality rating, messaging tier and
+// status with its recent health events
+func (a *App) GetAccountHealth(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAccounts, models.ActionRead); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "account")
+	if err != nil {
+		return nil
+	}
+
+	account, err := findByIDAndOrg[models.WhatsAppAccount](a.DB, r, id, orgID, "Account")
+	if err != nil {
+		return nil
+	}
+
+	return a.sendAccountHealth(r, account)
+}
+
+// RefreshAccountHealth pulls an account's health from Meta right away
+func (a *App) RefreshAccountHealth(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAccounts, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "account")
+	if err != nil {
+		return nil
+	}
+
+	account, err := a.resolveWhatsAppAccountByID(r, id, orgID)
+	if err != nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := a.refreshAccountHealth(ctx, account, accountHealthSourcePoll); err != nil {
+		a.Log.Error("Failed to refresh account health", "error", err, "account", account.Name)
+		if apiErr, ok := whatsapp.AsAPIError(err); ok && apiErr.Class() == whatsapp.ErrorClassAuth {
+			a.markAccountAuthFailed(account, accountHealthSourcePoll, apiErr)
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusBadGateway, "Failed to fetch account health from Meta", nil, "")
+	}
+	if err := a.checkAccountToken(ctx, account, accountHealthSourcePoll); err != nil {
+		a.Log.Warn("Access token check failed", "error", err, "account", account.Name)
+	}
+
+	return a.sendAccountHealth(r, account)
+}
+
+func (a *App) sendAccountHealth(r *fastglue.Request, account *models.WhatsAppAccount) error {
+	var events []models.AccountHealthEvent
+	if err := a.DB.Where("organization_id = ? AND whats_app_account = ?", account.OrganizationID, account.Name).
+		Order("created_at DESC").
+		Limit(accountHealthHistoryLimit).
+		Find(&events).Error; err != nil {
+		a.Log.Error("Failed to load account health events", "error", err, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load account health", nil, "")
+	}
+
+	return r.SendEnvelope(AccountHealthResponse{
+		Account:            account.Name,
+		Status:             account.Status,
+		DisplayPhoneNumber: account.DisplayPhoneNumber,
+		QualityRating:      account.QualityRating,
+		MessagingLimitTier: account.MessagingLimitTier,
+		HealthCheckedAt:    account.HealthCheckedAt,
+		TokenType:          account.TokenType,
+		TokenExpiresAt:     account.TokenExpiresAt,
+		TokenCheckedAt:     account.TokenCheckedAt,
+		TokenError:         account.TokenError,
+		AutoPauseCampaigns: account.AutoPauseCampaigns,
+		Events:             events,
+	})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/config"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp/whatsapptest"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accountHealthTestApp creates a webhook test App with Redis, which the
+// account cache and outbound webhooks need. Skips without TEST_REDIS_URL.
+func accountHealthTestApp(t *testing.T) *App {
+	t.Helper()
+	app := webhookTestApp(t)
+	app.Redis = testutil.SetupTestRedis(t)
+	if app.Redis == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	app.Config = &config.Config{}
+	t.Cleanup(app.wg.Wait)
+	return app
+}
+
+// healthWebhook builds a raw account health webhook for a business account.
+func healthWebhook(t *testing.T, businessID, field, value string) *WebhookPayload {
+	t.Helper()
+	body := fmt.Sprintf(`{
+		"object": "whatsapp_business_account",
+		"entry": [{"id": %q, "changes": [{"field": %q, "value": %s}]}]
+	}`, businessID, field, value)
+
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+	return &payload
+}
+
+func healthEvents(t *testing.T, app *App, account models.WhatsAppAccount, kind models.AccountHealthEventKind) []models.AccountHealthEvent {
+	t.Helper()
+	var events []models.AccountHealthEvent
+	require.NoError(t, app.DB.Where("whats_app_account = ? AND kind = ?", account.Name, kind).
+		Order("created_at").Find(&events).Error)
+	return events
+}
+
+func TestWebhookPayload_AccountHealthFields(t *testing.T) {
+	t.Parallel()
+
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal([]byte(`{"entry": [{"id": "waba", "changes": [
+		{"field": "phone_number_quality_update", "value": {"display_phone_number": "15550001111", "event": "DOWNGRADE", "current_limit": "TIER_250"}},
+		{"field": "account_update", "value": {"event": "ACCOUNT_RESTRICTION", "restriction_info": [{"restriction_type": "RESTRICTED_BIZ_INITIATED_MESSAGING", "expiration": 1700000000}]}},
+		{"field": "account_alerts", "value": {"entity_type": "PHONE_NUMBER", "entity_id": "123", "alert_severity": "CRITICAL", "alert_status": "ACTIVE", "alert_type": "OBA_APPROVED"}}
+	]}]}`), &payload))
+
+	changes := payload.Entry[0].Changes
+	assert.Equal(t, "DOWNGRADE", changes[0].Value.Event)
+	assert.Equal(t, "15550001111", changes[0].Value.DisplayPhoneNumber)
+	assert.Equal(t, "TIER_250", changes[0].Value.CurrentLimit)
+
+	assert.Equal(t, "ACCOUNT_RESTRICTION", changes[1].Value.Event)
+	require.Len(t, changes[1].Value.RestrictionInfo, 1)
+	assert.Equal(t, "RESTRICTED_BIZ_INITIATED_MESSAGING", changes[1].Value.RestrictionInfo[0].RestrictionType)
+
+	assert.Equal(t, "PHONE_NUMBER", changes[2].Value.EntityType)
+	assert.Equal(t, "123", changes[2].Value.EntityID)
+	assert.Equal(t, "CRITICAL", changes[2].Value.AlertSeverity)
+}
+
+func TestProcessAccountHealthWebhook_FlaggedPausesCampaigns(t *testing.T) {
+	app := accountHealthTestApp(t)
+	org, _, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+	require.True(t, account.AutoPauseCampaigns)
+	require.NoError(t, app.DB.Model(&campaign).Update("status", models.CampaignStatusProcessing).Error)
+
+	payload := healthWebhook(t, account.BusinessID, "phone_number_quality_update",
+		`{"display_phone_number": "15550001111", "event": "FLAGGED", "current_limit": "TIER_1K"}`)
+	app.dispatchWebhookPayload(payload, false)
+	// A redelivery must not record the drop twice
+	app.dispatchWebhookPayload(payload, true)
+
+	var updated models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&updated, account.ID).Error)
+	assert.Equal(t, models.QualityRatingRed, updated.QualityRating)
+	assert.Equal(t, "TIER_1K", updated.MessagingLimitTier)
+
+	var paused models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&paused, campaign.ID).Error)
+	assert.Equal(t, models.CampaignStatusPaused, paused.Status)
+
+	events := healthEvents(t, app, account, models.AccountHealthQuality)
+	require.Len(t, events, 1)
+	assert.Equal(t, "FLAGGED", events[0].Event)
+	assert.Equal(t, "RED", events[0].NewValue)
+	assert.Equal(t, "critical", events[0].Severity)
+	assert.True(t, events[0].Downgrade)
+	assert.EqualValues(t, 1, events[0].Details["paused_campaigns"])
+}
+
+func TestProcessAccountHealthWebhook_FlaggedKeepsPooledCampaigns(t *testing.T) {
+	app := accountHealthTestApp(t)
+	org, _, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+	require.NoError(t, app.DB.Model(&campaign).Updates(map[string]interface{}{
+		"status":          models.CampaignStatusProcessing,
+		"sender_accounts": models.StringArray{"backup-number"},
+		"sender_strategy": models.SenderStrategyRoundRobin,
+	}).Error)
+
+	payload := healthWebhook(t, account.BusinessID, "phone_number_quality_update",
+		`{"display_phone_number": "15550001111", "event": "FLAGGED", "current_limit": "TIER_1K"}`)
+	app.dispatchWebhookPayload(payload, false)
+
+	// The worker moves pooled traffic off the RED number instead
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&updated, campaign.ID).Error)
+	assert.Equal(t, models.CampaignStatusProcessing, updated.Status)
+}
+
+func TestProcessAccountHealthWebhook_AutoPauseDisabled(t *testing.T) {
+	app := accountHealthTestApp(t)
+	org, _, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+	require.NoError(t, app.DB.Model(&account).Update("auto_pause_campaigns", false).Error)
+	require.NoError(t, app.DB.Model(&campaign).Update("status", models.CampaignStatusProcessing).Error)
+
+	app.dispatchWebhookPayload(healthWebhook(t, account.BusinessID, "phone_number_quality_update",
+		`{"display_phone_number": "15550001111", "event": "FLAGGED", "current_limit": "TIER_1K"}`), false)
+
+	var running models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&running, campaign.ID).Error)
+	assert.Equal(t, models.CampaignStatusProcessing, running.Status)
+}
+
+func TestProcessAccountHealthWebhook_TierDowngrade(t *testing.T) {
+	app := accountHealthTestApp(t)
+	org, _, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+	require.NoError(t, app.DB.Model(&account).Updates(map[string]any{
+		"display_phone_number": "+1 555-000-1111",
+		"messaging_limit_tier": "TIER_10K",
+	}).Error)
+
+	app.dispatchWebhookPayload(healthWebhook(t, account.BusinessID, "phone_number_quality_update",
+		`{"display_phone_number": "15550001111", "event": "DOWNGRADE", "current_limit": "TIER_1K"}`), false)
+
+	events := healthEvents(t, app, account, models.AccountHealthTier)
+	require.Len(t, events, 1)
+	assert.Equal(t, "TIER_10K", events[0].OldValue)
+	assert.Equal(t, "TIER_1K", events[0].NewValue)
+	assert.True(t, events[0].Downgrade)
+
+	// An update for another number of the business is ignored
+	app.dispatchWebhookPayload(healthWebhook(t, account.BusinessID, "phone_number_quality_update",
+		`{"display_phone_number": "15559999999", "event": "DOWNGRADE", "current_limit": "TIER_250"}`), false)
+
+	var updated models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&updated, account.ID).Error)
+	assert.Equal(t, "TIER_1K", updated.MessagingLimitTier)
+}
+
+func TestProcessAccountHealthWebhook_AccountRestriction(t *testing.T) {
+	app := accountHealthTestApp(t)
+	org, _, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+	require.NoError(t, app.DB.Model(&account).Update("status", models.AccountStatusActive).Error)
+
+	app.dispatchWebhookPayload(healthWebhook(t, account.BusinessID, "account_update",
+		`{"event": "ACCOUNT_RESTRICTION", "restriction_info": [{"restriction_type": "RESTRICTED_BIZ_INITIATED_MESSAGING"}]}`), false)
+
+	var updated models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&updated, account.ID).Error)
+	assert.Equal(t, models.AccountStatusRestricted, updated.Status)
+
+	// Restrictions lifted
+	app.dispatchWebhookPayload(healthWebhook(t, account.BusinessID, "account_update",
+		`{"event": "ACCOUNT_RESTRICTION", "restriction_info": []}`), false)
+
+	require.NoError(t, app.DB.First(&updated, account.ID).Error)
+	assert.Equal(t, models.AccountStatusActive, updated.Status)
+
+	events := healthEvents(t, app, account, models.AccountHealthStatus)
+	require.Len(t, events, 2)
+	assert.True(t, events[0].Downgrade)
+	assert.False(t, events[1].Downgrade)
+}
+
+func TestAccountHealthMonitor_CheckAccounts(t *testing.T) {
+	app := accountHealthTestApp(t)
+	srv := whatsapptest.New(whatsapptest.Config{}).Start()
+	t.Cleanup(srv.Close)
+	app.WhatsApp = srv.Client(testutil.NopLogger())
+
+	org, _, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+	srv.AddPhoneNumber(whatsapptest.PhoneNumber{
+		ID:                 account.PhoneID,
+		BusinessID:         account.BusinessID,
+		DisplayPhoneNumber: "+1 555-000-2222",
+		QualityRating:      "YELLOW",
+		MessagingLimitTier: "TIER_10K",
+	})
+
+	monitor := NewAccountHealthMonitor(app, 0)
+	assert.GreaterOrEqual(t, monitor.CheckAccounts(context.Background()), 1)
+
+	var updated models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&updated, account.ID).Error)
+	assert.Equal(t, "+1 555-000-2222", updated.DisplayPhoneNumber)
+	assert.Equal(t, models.QualityRatingYellow, updated.QualityRating)
+	assert.Equal(t, "TIER_10K", updated.MessagingLimitTier)
+	assert.NotNil(t, updated.HealthCheckedAt)
+
+	events := healthEvents(t, app, account, models.AccountHealthQuality)
+	require.Len(t, events, 1)
+	assert.Equal(t, accountHealthSourcePoll, events[0].Source)
+	assert.True(t, events[0].Downgrade)
+}
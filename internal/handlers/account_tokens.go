@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/crypto"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// tokenExpiryWarning is how long before expiry an access token starts
+	// raising daily alerts
+	tokenExpiryWarning = 7 * 24 * time.Hour
+	// tokenExpiryAlertInterval keeps expiry alerts to one per account per day
+	tokenExpiryAlertInterval = 24 * time.Hour
+)
+
+// RotateTokenRequest is the body of PUT /api/accounts/{id}/token
+type RotateTokenRequest struct {
+	AccessToken string `json:"access_token"`
+}
+
+// tokenInspectionApp returns the app credentials used to inspect an
+// account's token: the account's own app, else the Embedded Signup app.
+// Empty credentials make the token inspect itself.
+func (a *App) tokenInspectionApp(account *models.WhatsAppAccount) (appID, appSecret string) {
+	if account.AppID != "" && account.AppSecret != "" {
+		return account.AppID, account.AppSecret
+	}
+	if a.Config != nil && a.Config.WhatsApp.AppSecret != "" && account.AppID == a.Config.WhatsApp.AppID {
+		return a.Config.WhatsApp.AppID, a.Config.WhatsApp.AppSecret
+	}
+	return "", ""
+}
+
+// checkAccountToken inspects the account's access token and saves its type
+// and expiry. A token Meta reports invalid, or one without messaging
+// permission, marks the account auth_failed; a working token clears it.
+// The account's secrets must already be decrypted.
+func (a *App) checkAccountToken(ctx context.Context, account *models.WhatsAppAccount, source string) error {
+	appID, appSecret := a.tokenInspectionApp(account)
+	info, err := a.WhatsApp.DebugToken(ctx, appID, appSecret, account.AccessToken, account.APIVersion)
+	if err != nil {
+		// A token that cannot inspect itself is no longer valid
+		if apiErr, ok := whatsapp.AsAPIError(err); ok && appSecret == "" && apiErr.Class() == whatsapp.ErrorClassAuth {
+			a.markAccountAuthFailed(account, source, apiErr)
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := info.ExpiresAtTime()
+	if err := a.DB.Model(&models.WhatsAppAccount{}).Where("id = ?", account.ID).Updates(map[string]any{
+		"token_type":       info.Type,
+		"token_expires_at": expiresAt,
+		"token_checked_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	account.TokenType = info.Type
+	account.TokenExpiresAt = expiresAt
+	account.TokenCheckedAt = &now
+
+	switch {
+	case !info.IsValid:
+		reason := "Access token is no longer valid"
+		if info.Error != nil && info.Error.Message != "" {
+			reason = info.Error.Message
+		}
+		a.setAccountAuthFailed(account, source, "TOKEN_INVALID", reason, nil)
+	case !info.HasScope(whatsapp.ScopeBusinessMessaging):
+		a.setAccountAuthFailed(account, source, "TOKEN_MISSING_PERMISSION",
+			"Access token lacks the "+whatsapp.ScopeBusinessMessaging+" permission", nil)
+	default:
+		a.clearAccountAuthFailed(account, source, "TOKEN_VALID")
+		if expiresAt != nil && time.Until(*expiresAt) < tokenExpiryWarning {
+			a.alertTokenExpiring(account, *expiresAt)
+		}
+	}
+	return nil
+}
+
+// markAccountAuthFailed records a Meta auth error on an account, e.g. an
+// OAuthException returned for a send
+func (a *App) markAccountAuthFailed(account *models.WhatsAppAccount, source string, apiErr *whatsapp.APIError) {
+	a.setAccountAuthFailed(account, source, "OAUTH_EXCEPTION", apiErr.Message, models.JSONB{
+		"code":    apiErr.Code,
+		"subcode": apiErr.Subcode,
+	})
+}
+
+// setAccountAuthFailed saves why the token was rejected and moves the account
+// to auth_failed, alerting the organization on the transition
+func (a *App) setAccountAuthFailed(account *models.WhatsAppAccount, source, event, reason string, details models.JSONB) {
+	if err := a.DB.Model(&models.WhatsAppAccount{}).Where("id = ?", account.ID).
+		Update("token_error", reason).Error; err != nil {
+		a.Log.Error("Failed to save token error", "error", err, "account", account.Name)
+	}
+	account.TokenError = reason
+
+	if details == nil {
+		details = models.JSONB{}
+	}
+	details["reason"] = reason
+	a.applyAccountStatus(account, models.AccountStatusAuthFailed, source, event, details)
+}
+
+// clearAccountAuthFailed returns an auth_failed account to active once its
+// token works again
+func (a *App) clearAccountAuthFailed(account *models.WhatsAppAccount, source, event string) {
+	if account.Status != models.AccountStatusAuthFailed && account.TokenError == "" {
+		return
+	}
+	if err := a.DB.Model(&models.WhatsAppAccount{}).Where("id = ?", account.ID).
+		Update("token_error", "").Error; err != nil {
+		a.Log.Error("Failed to clear token error", "error", err, "account", account.Name)
+	}
+	account.TokenError = ""
+	if account.Status == models.AccountStatusAuthFailed {
+		a.applyAccountStatus(account, models.AccountStatusActive, source, event, nil)
+	}
+}
+
+// alertTokenExpiring warns the organization that the account's token is
+// about to expire, at most once a day
+func (a *App) alertTokenExpiring(account *models.WhatsAppAccount, expiresAt time.Time) {
+	var recent int64
+	a.DB.Model(&models.AccountHealthEvent{}).
+		Where("organization_id = ? AND whats_app_account = ? AND kind = ? AND event = ? AND created_at > ?",
+			account.OrganizationID, account.Name, models.AccountHealthToken, "TOKEN_EXPIRING", time.Now().Add(-tokenExpiryAlertInterval)).
+		Count(&recent)
+	if recent > 0 {
+		return
+	}
+
+	a.recordAccountHealthEvent(account, models.AccountHealthEvent{
+		Kind:        models.AccountHealthToken,
+		Source:      accountHealthSourcePoll,
+		Event:       "TOKEN_EXPIRING",
+		NewValue:    expiresAt.UTC().Format(time.RFC3339),
+		Severity:    "warning",
+		Description: fmt.Sprintf("Access token expires on %s. Rotate it to avoid failed sends.", expiresAt.UTC().Format("2006-01-02 15:04 MST")),
+		Downgrade:   true,
+	})
+}
+
+// RotateAccountToken replaces an account's access token. The new token is
+// validated against the account's phone number and business account first;
+// an auth_failed account returns to active.
+func (a *App) RotateAccountToken(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAccounts, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "account")
+	if err != nil {
+		return nil
+	}
+
+	var req RotateTokenRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if req.AccessToken == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "access_token is required", nil, "")
+	}
+
+	account, err := a.resolveWhatsAppAccountByID(r, id, orgID)
+	if err != nil {
+		return nil
+	}
+
+	ctx := r.RequestCtx
+	if _, err := a.WhatsApp.ValidateCredentials(ctx, account.PhoneID, account.BusinessID, req.AccessToken, account.APIVersion); err != nil {
+		a.Log.Warn("Rotated access token failed validation", "error", err, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "New access token failed validation: "+err.Error(), nil, "")
+	}
+
+	encAccessToken, err := crypto.Encrypt(req.AccessToken, a.Config.App.EncryptionKey)
+	if err != nil {
+		a.Log.Error("Failed to encrypt access token", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to rotate access token", nil, "")
+	}
+	if err := a.DB.Model(&models.WhatsAppAccount{}).Where("id = ?", account.ID).Updates(map[string]any{
+		"access_token":     encAccessToken,
+		"token_type":       "",
+		"token_expires_at": nil,
+		"token_checked_at": nil,
+	}).Error; err != nil {
+		a.Log.Error("Failed to rotate access token", "error", err, "account", account.Name)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to rotate access token", nil, "")
+	}
+	// Senders re-read the account, with the new token, on their next cache miss
+	a.InvalidateWhatsAppAccountCache(account.PhoneID)
+	account.AccessToken = req.AccessToken
+	account.TokenType = ""
+	account.TokenExpiresAt = nil
+	account.TokenCheckedAt = nil
+
+	a.recordAccountHealthEvent(account, models.AccountHealthEvent{
+		Kind:        models.AccountHealthToken,
+		Source:      accountHealthSourceAPI,
+		Event:       "TOKEN_ROTATED",
+		Severity:    "info",
+		Description: "Access token rotated",
+	})
+	a.clearAccountAuthFailed(account, accountHealthSourceAPI, "TOKEN_ROTATED")
+
+	// Pick up the new token's expiry; the rotation stands even if this fails
+	if err := a.checkAccountToken(ctx, account, accountHealthSourceAPI); err != nil {
+		a.Log.Warn("Failed to inspect rotated access token", "error", err, "account", account.Name)
+	}
+
+	a.Log.Info("Access token rotated", "account", account.Name, "user_id", userID)
+	return r.SendEnvelope(accountToResponse(*account))
+}
@@ -0,0 +1,106 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestApp_RefreshAccountHealth_RevokedTokenMarksAuthFailed(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+	srv.RevokeToken(account.AccessToken)
+
+	req := phoneRequest(t, nil, account.ID, orgID, userID)
+	require.NoError(t, app.RefreshAccountHealth(req))
+	assert.Equal(t, fasthttp.StatusBadGateway, testutil.GetResponseStatusCode(req))
+
+	var updated models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&updated, account.ID).Error)
+	assert.Equal(t, models.AccountStatusAuthFailed, updated.Status)
+	assert.NotEmpty(t, updated.TokenError)
+
+	var event models.AccountHealthEvent
+	require.NoError(t, app.DB.Where("whats_app_account = ? AND kind = ?", account.Name, models.AccountHealthStatus).
+		First(&event).Error)
+	assert.Equal(t, "OAUTH_EXCEPTION", event.Event)
+	assert.Equal(t, string(models.AccountStatusAuthFailed), event.NewValue)
+	assert.True(t, event.Downgrade)
+}
+
+func TestApp_RefreshAccountHealth_RecordsTokenExpiry(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+	expiresAt := time.Now().Add(3 * 24 * time.Hour).Truncate(time.Second)
+	srv.SetTokenExpiry(account.AccessToken, expiresAt)
+
+	// Two checks in a row raise a single expiry warning
+	for range 2 {
+		req := phoneRequest(t, nil, account.ID, orgID, userID)
+		require.NoError(t, app.RefreshAccountHealth(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	}
+
+	var updated models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&updated, account.ID).Error)
+	assert.Equal(t, models.AccountStatusActive, updated.Status)
+	assert.Equal(t, "SYSTEM_USER", updated.TokenType)
+	require.NotNil(t, updated.TokenExpiresAt)
+	assert.True(t, expiresAt.Equal(*updated.TokenExpiresAt))
+	assert.NotNil(t, updated.TokenCheckedAt)
+
+	var warnings int64
+	app.DB.Model(&models.AccountHealthEvent{}).
+		Where("whats_app_account = ? AND kind = ? AND event = ?", account.Name, models.AccountHealthToken, "TOKEN_EXPIRING").
+		Count(&warnings)
+	assert.Equal(t, int64(1), warnings)
+}
+
+func TestApp_RotateAccountToken(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+	srv.RevokeToken(account.AccessToken)
+
+	req := phoneRequest(t, nil, account.ID, orgID, userID)
+	require.NoError(t, app.RefreshAccountHealth(req))
+	require.Equal(t, fasthttp.StatusBadGateway, testutil.GetResponseStatusCode(req))
+
+	// A token Meta rejects is not saved
+	req = phoneRequest(t, handlers.RotateTokenRequest{AccessToken: account.AccessToken}, account.ID, orgID, userID)
+	require.NoError(t, app.RotateAccountToken(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+
+	req = phoneRequest(t, handlers.RotateTokenRequest{AccessToken: "rotated-token"}, account.ID, orgID, userID)
+	require.NoError(t, app.RotateAccountToken(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.AccountResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, string(models.AccountStatusActive), resp.Data.Status)
+	assert.Empty(t, resp.Data.TokenError)
+	assert.NotNil(t, resp.Data.TokenCheckedAt)
+
+	var updated models.WhatsAppAccount
+	require.NoError(t, app.DB.First(&updated, account.ID).Error)
+	assert.Equal(t, "rotated-token", updated.AccessToken)
+	assert.Equal(t, models.AccountStatusActive, updated.Status)
+	assert.Empty(t, updated.TokenError)
+}
+
+func TestApp_RotateAccountToken_RequiresToken(t *testing.T) {
+	t.Parallel()
+	app, _, account, orgID, userID := phoneTestSetup(t)
+
+	req := phoneRequest(t, handlers.RotateTokenRequest{}, account.ID, orgID, userID)
+	require.NoError(t, app.RotateAccountToken(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/crypto"
@@ -16,6 +17,9 @@ import (
 	"github.com/zerodha/fastglue"
 )
 
+// defaultAccountAPIVersion is the Graph API version new accounts use unless one is given
+const defaultAccountAPIVersion = "v21.0"
+
 // AccountRequest represents the request body for creating/updating an account
 type AccountRequest struct {
 	Name               string `json:"name" validate:"required"`
@@ -29,27 +33,39 @@ type AccountRequest struct {
 	IsDefaultIncoming  bool   `json:"is_default_incoming"`
 	IsDefaultOutgoing  bool   `json:"is_default_outgoing"`
 	AutoReadReceipt    bool   `json:"auto_read_receipt"`
+	SendRateLimit      int    `json:"send_rate_limit"`
+	AutoPauseCampaigns *bool  `json:"auto_pause_campaigns"` // Defaults to true
 }
 
 // AccountResponse represents the response for an account (without sensitive data)
 type AccountResponse struct {
-	ID                 uuid.UUID `json:"id"`
-	Name               string    `json:"name"`
-	AppID              string    `json:"app_id"`
-	PhoneID            string    `json:"phone_id"`
-	BusinessID         string    `json:"business_id"`
-	WebhookVerifyToken string    `json:"webhook_verify_token"`
-	APIVersion         string    `json:"api_version"`
-	IsDefaultIncoming  bool      `json:"is_default_incoming"`
-	IsDefaultOutgoing  bool      `json:"is_default_outgoing"`
-	AutoReadReceipt    bool      `json:"auto_read_receipt"`
-	Status             string    `json:"status"`
-	HasAccessToken     bool      `json:"has_access_token"`
-	HasAppSecret       bool      `json:"has_app_secret"`
-	PhoneNumber        string    `json:"phone_number,omitempty"`
-	DisplayName        string    `json:"display_name,omitempty"`
-	CreatedAt          string    `json:"created_at"`
-	UpdatedAt          string    `json:"updated_at"`
+	ID                 uuid.UUID  `json:"id"`
+	Name               string     `json:"name"`
+	AppID              string     `json:"app_id"`
+	PhoneID            string     `json:"phone_id"`
+	BusinessID         string     `json:"business_id"`
+	WebhookVerifyToken string     `json:"webhook_verify_token"`
+	APIVersion         string     `json:"api_version"`
+	IsDefaultIncoming  bool       `json:"is_default_incoming"`
+	IsDefaultOutgoing  bool       `json:"is_default_outgoing"`
+	AutoReadReceipt    bool       `json:"auto_read_receipt"`
+	SendRateLimit      int        `json:"send_rate_limit"`
+	AutoPauseCampaigns bool       `json:"auto_pause_campaigns"`
+	Status             string     `json:"status"`
+	DisplayPhoneNumber string     `json:"display_phone_number,omitempty"`
+	QualityRating      string     `json:"quality_rating,omitempty"`
+	MessagingLimitTier string     `json:"messaging_limit_tier,omitempty"`
+	HealthCheckedAt    *time.Time `json:"health_checked_at,omitempty"`
+	TokenType          string     `json:"token_type,omitempty"`
+	TokenExpiresAt     *time.Time `json:"token_expires_at,omitempty"`
+	TokenCheckedAt     *time.Time `json:"token_checked_at,omitempty"`
+	TokenError         string     `json:"token_error,omitempty"`
+	HasAccessToken     bool       `json:"has_access_token"`
+	HasAppSecret       bool       `json:"has_app_secret"`
+	PhoneNumber        string     `json:"phone_number,omitempty"`
+	DisplayName        string     `json:"display_name,omitempty"`
+	CreatedAt          string     `json:"created_at"`
+	UpdatedAt          string     `json:"updated_at"`
 }
 
 // ListAccounts returns all WhatsApp accounts for the organization
@@ -92,6 +108,9 @@ func (a *App) CreateAccount(r *fastglue.Request) error {
 	if req.Name == "" || req.PhoneID == "" || req.BusinessID == "" || req.AccessToken == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Name, phone_id, business_id, and access_token are required", nil, "")
 	}
+	if req.SendRateLimit < 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "send_rate_limit cannot be negative", nil, "")
+	}
 
 	// Generate webhook verify token if not provided
 	webhookVerifyToken := req.WebhookVerifyToken
@@ -102,7 +121,7 @@ func (a *App) CreateAccount(r *fastglue.Request) error {
 	// Set default API version
 	apiVersion := req.APIVersion
 	if apiVersion == "" {
-		apiVersion = "v21.0"
+		apiVersion = defaultAccountAPIVersion
 	}
 
 	encKey := a.Config.App.EncryptionKey
@@ -130,7 +149,9 @@ func (a *App) CreateAccount(r *fastglue.Request) error {
 		IsDefaultIncoming:  req.IsDefaultIncoming,
 		IsDefaultOutgoing:  req.IsDefaultOutgoing,
 		AutoReadReceipt:    req.AutoReadReceipt,
-		Status:             "active",
+		SendRateLimit:      req.SendRateLimit,
+		AutoPauseCampaigns: req.AutoPauseCampaigns == nil || *req.AutoPauseCampaigns,
+		Status:             models.AccountStatusActive,
 	}
 
 	// If this is set as default, unset other defaults
@@ -149,6 +170,11 @@ func (a *App) CreateAccount(r *fastglue.Request) error {
 		a.Log.Error("Failed to create account", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create account", nil, "")
 	}
+	// GORM skips zero values that have a column default, so an explicit
+	// opt-out has to be written separately
+	if !account.AutoPauseCampaigns {
+		a.DB.Model(&account).Update("auto_pause_campaigns", false)
+	}
 
 	return r.SendEnvelope(accountToResponse(account))
 }
@@ -194,6 +220,9 @@ func (a *App) UpdateAccount(r *fastglue.Request) error {
 	if err := a.decodeRequest(r, &req); err != nil {
 		return nil
 	}
+	if req.SendRateLimit < 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "send_rate_limit cannot be negative", nil, "")
+	}
 
 	// Update fields if provided
 	if req.Name != "" {
@@ -215,6 +244,10 @@ func (a *App) UpdateAccount(r *fastglue.Request) error {
 			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update account", nil, "")
 		}
 		account.AccessToken = enc
+		// The health monitor inspects the new token on its next run
+		account.TokenType = ""
+		account.TokenExpiresAt = nil
+		account.TokenCheckedAt = nil
 	}
 	if req.AppSecret != "" {
 		enc, err := crypto.Encrypt(req.AppSecret, a.Config.App.EncryptionKey)
@@ -231,6 +264,10 @@ func (a *App) UpdateAccount(r *fastglue.Request) error {
 		account.APIVersion = req.APIVersion
 	}
 	account.AutoReadReceipt = req.AutoReadReceipt
+	account.SendRateLimit = req.SendRateLimit
+	if req.AutoPauseCampaigns != nil {
+		account.AutoPauseCampaigns = *req.AutoPauseCampaigns
+	}
 
 	// Handle default flags
 	if req.IsDefaultIncoming && !account.IsDefaultIncoming {
@@ -385,7 +422,17 @@ func accountToResponse(acc models.WhatsAppAccount) AccountResponse {
 		IsDefaultIncoming:  acc.IsDefaultIncoming,
 		IsDefaultOutgoing:  acc.IsDefaultOutgoing,
 		AutoReadReceipt:    acc.AutoReadReceipt,
-		Status:             acc.Status,
+		SendRateLimit:      acc.SendRateLimit,
+		AutoPauseCampaigns: acc.AutoPauseCampaigns,
+		Status:             string(acc.Status),
+		DisplayPhoneNumber: acc.DisplayPhoneNumber,
+		QualityRating:      string(acc.QualityRating),
+		MessagingLimitTier: acc.MessagingLimitTier,
+		HealthCheckedAt:    acc.HealthCheckedAt,
+		TokenType:          acc.TokenType,
+		TokenExpiresAt:     acc.TokenExpiresAt,
+		TokenCheckedAt:     acc.TokenCheckedAt,
+		TokenError:         acc.TokenError,
 		HasAccessToken:     acc.AccessToken != "",
 		HasAppSecret:       acc.AppSecret != "",
 		CreatedAt:          acc.CreatedAt.Format("2006-01-02T15:04:05Z"),
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// IncomingReferral is the referral Meta attaches to the first message a user
+// sends after clicking a click-to-WhatsApp ad or a post
+type IncomingReferral struct {
+	SourceURL      string `json:"source_url"`
+	SourceID       string `json:"source_id"`
+	SourceType     string `json:"source_type"` // "ad" or "post"
+	Headline       string `json:"headline,omitempty"`
+	Body           string `json:"body,omitempty"`
+	MediaType      string `json:"media_type,omitempty"`
+	ImageURL       string `json:"image_url,omitempty"`
+	VideoURL       string `json:"video_url,omitempty"`
+	ThumbnailURL   string `json:"thumbnail_url,omitempty"`
+	CtwaClid       string `json:"ctwa_clid,omitempty"` // Click ID, for reporting conversions back to Meta
+	WelcomeMessage *struct {
+		Text string `json:"text"`
+	} `json:"welcome_message,omitempty"`
+}
+
+// AdID returns the ID of the ad the user clicked, or "" if the referral is
+// from a post or there is none
+func (ref *IncomingReferral) AdID() string {
+	if ref == nil || ref.SourceType != "ad" {
+		return ""
+	}
+	return ref.SourceID
+}
+
+func (ref *IncomingReferral) toJSONB() models.JSONB {
+	out := models.JSONB{
+		"source_url":  ref.SourceURL,
+		"source_id":   ref.SourceID,
+		"source_type": ref.SourceType,
+	}
+	optional := map[string]string{
+		"headline":      ref.Headline,
+		"body":          ref.Body,
+		"media_type":    ref.MediaType,
+		"image_url":     ref.ImageURL,
+		"video_url":     ref.VideoURL,
+		"thumbnail_url": ref.ThumbnailURL,
+		"ctwa_clid":     ref.CtwaClid,
+	}
+	for k, v := range optional {
+		if v != "" {
+			out[k] = v
+		}
+	}
+	if ref.WelcomeMessage != nil && ref.WelcomeMessage.Text != "" {
+		out["welcome_message"] = map[string]interface{}{"text": ref.WelcomeMessage.Text}
+	}
+	return out
+}
+
+// recordContactReferral keeps the first ad or post a contact messaged from.
+// Later referrals are only kept on their messages.
+func (a *App) recordContactReferral(contact *models.Contact, ref *IncomingReferral) {
+	if contact.Referral != nil {
+		return
+	}
+
+	now := time.Now()
+	referral := ref.toJSONB()
+	result := a.DB.Model(&models.Contact{}).
+		Where("id = ? AND referral IS NULL", contact.ID).
+		Updates(map[string]interface{}{
+			"ad_id":       ref.AdID(),
+			"referral":    referral,
+			"referred_at": now,
+		})
+	if result.Error != nil {
+		a.Log.Error("Failed to save contact referral", "error", result.Error, "contact_id", contact.ID)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Another message recorded the first touch already
+		return
+	}
+	contact.AdID = ref.AdID()
+	contact.Referral = referral
+	contact.ReferredAt = &now
+}
+
+// AdAnalyticsRow is the attribution of one click-to-WhatsApp ad
+type AdAnalyticsRow struct {
+	AdID          string      `json:"ad_id"`
+	Headline      string      `json:"headline"`
+	SourceURL     string      `json:"source_url"`
+	Conversations int64       `json:"conversations"` // Contacts who messaged from the ad in the period
+	NewContacts   int64       `json:"new_contacts"`  // Contacts whose first touch was the ad
+	Transfers     int64       `json:"transfers"`
+	Orders        int64       `json:"orders"`
+	Revenue       []AdRevenue `json:"revenue"`
+}
+
+// AdRevenue is an ad's order total in one currency, in cents
+type AdRevenue struct {
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+// AdAnalyticsResponse is the response for ad attribution analytics
+type AdAnalyticsResponse struct {
+	Ads []AdAnalyticsRow `json:"ads"`
+}
+
+// GetAdAnalytics attributes conversations, transfers and orders to the
+// click-to-WhatsApp ads contacts came from. Transfers and orders count
+// towards the contact's first-touch ad.
+func (a *App) GetAdAnalytics(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAnalytics, models.ActionRead); err != nil {
+		return nil
+	}
+
+	fromStr := string(r.RequestCtx.QueryArgs().Peek("from"))
+	toStr := string(r.RequestCtx.QueryArgs().Peek("to"))
+
+	now := time.Now()
+	var periodStart, periodEnd time.Time
+	if fromStr != "" && toStr != "" {
+		var errMsg string
+		periodStart, periodEnd, errMsg = parseDateRange(fromStr, toStr)
+		if errMsg != "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, errMsg, nil, "")
+		}
+	} else {
+		// Default to current month
+		periodStart = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		periodEnd = now
+	}
+
+	rows := map[string]*AdAnalyticsRow{}
+	row := func(adID string) *AdAnalyticsRow {
+		if rows[adID] == nil {
+			rows[adID] = &AdAnalyticsRow{AdID: adID, Revenue: []AdRevenue{}}
+		}
+		return rows[adID]
+	}
+	fail := func(what string, err error) error {
+		a.Log.Error("Failed to load ad analytics", "error", err, "query", what)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load ad analytics", nil, "")
+	}
+
+	var conversations []struct {
+		AdID      string
+		Headline  string
+		SourceURL string
+		Count     int64
+	}
+	if err := a.DB.Raw(`
+		SELECT ad_id, COALESCE(MAX(referral->>'headline'), '') AS headline,
+			COALESCE(MAX(referral->>'source_url'), '') AS source_url, COUNT(DISTINCT contact_id) AS count
+		FROM messages
+		WHERE organization_id = ? AND direction = ? AND ad_id <> '' AND created_at >= ? AND created_at <= ? AND deleted_at IS NULL
+		GROUP BY ad_id
+	`, orgID, models.DirectionIncoming, periodStart, periodEnd).Scan(&conversations).Error; err != nil {
+		return fail("conversations", err)
+	}
+	for _, c := range conversations {
+		ad := row(c.AdID)
+		ad.Headline = c.Headline
+		ad.SourceURL = c.SourceURL
+		ad.Conversations = c.Count
+	}
+
+	var counts []struct {
+		AdID  string
+		Count int64
+	}
+	if err := a.DB.Raw(`
+		SELECT ad_id, COUNT(*) AS count FROM contacts
+		WHERE organization_id = ? AND ad_id <> '' AND referred_at >= ? AND referred_at <= ? AND deleted_at IS NULL
+		GROUP BY ad_id
+	`, orgID, periodStart, periodEnd).Scan(&counts).Error; err != nil {
+		return fail("new_contacts", err)
+	}
+	for _, c := range counts {
+		row(c.AdID).NewContacts = c.Count
+	}
+
+	counts = nil
+	if err := a.DB.Raw(`
+		SELECT ad_id, COUNT(*) AS count FROM agent_transfers
+		WHERE organization_id = ? AND ad_id <> '' AND transferred_at >= ? AND transferred_at <= ? AND deleted_at IS NULL
+		GROUP BY ad_id
+	`, orgID, periodStart, periodEnd).Scan(&counts).Error; err != nil {
+		return fail("transfers", err)
+	}
+	for _, c := range counts {
+		row(c.AdID).Transfers = c.Count
+	}
+
+	var orders []struct {
+		AdID     string
+		Currency string
+		Count    int64
+		Amount   int64
+	}
+	if err := a.DB.Raw(`
+		SELECT ad_id, currency, COUNT(*) AS count, COALESCE(SUM(total_amount), 0) AS amount FROM orders
+		WHERE organization_id = ? AND ad_id <> '' AND created_at >= ? AND created_at <= ? AND deleted_at IS NULL
+		GROUP BY ad_id, currency ORDER BY amount DESC
+	`, orgID, periodStart, periodEnd).Scan(&orders).Error; err != nil {
+		return fail("orders", err)
+	}
+	for _, o := range orders {
+		ad := row(o.AdID)
+		ad.Orders += o.Count
+		ad.Revenue = append(ad.Revenue, AdRevenue{Currency: o.Currency, Amount: o.Amount})
+	}
+
+	response := AdAnalyticsResponse{Ads: make([]AdAnalyticsRow, 0, len(rows))}
+	for _, ad := range rows {
+		response.Ads = append(response.Ads, *ad)
+	}
+	sort.Slice(response.Ads, func(i, j int) bool {
+		if response.Ads[i].Conversations != response.Ads[j].Conversations {
+			return response.Ads[i].Conversations > response.Ads[j].Conversations
+		}
+		return response.Ads[i].AdID < response.Ads[j].AdID
+	})
+
+	return r.SendEnvelope(response)
+}
@@ -0,0 +1,98 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestApp_GetAdAnalytics(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+	now := time.Now()
+	referral := models.JSONB{"source_type": "ad", "source_id": "ad-1", "headline": "Summer sale", "source_url": "https://fb.me/1"}
+	for i := 0; i < 2; i++ {
+		contact := testutil.CreateTestContact(t, app.DB, org.ID)
+		require.NoError(t, app.DB.Model(contact).Updates(map[string]any{
+			"ad_id": "ad-1", "referral": referral, "referred_at": now,
+		}).Error)
+		require.NoError(t, app.DB.Create(&models.Message{
+			OrganizationID:    org.ID,
+			WhatsAppAccount:   "main",
+			ContactID:         contact.ID,
+			WhatsAppMessageID: "wamid." + uuid.New().String(),
+			Direction:         models.DirectionIncoming,
+			MessageType:       models.MessageTypeText,
+			Content:           "Hi",
+			Status:            models.MessageStatusReceived,
+			AdID:              "ad-1",
+			Referral:          referral,
+		}).Error)
+	}
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	createTestAgentTransfer(t, app, org.ID, contact.ID, nil, models.TransferStatusActive, models.TransferSourceManual, now, nil)
+	require.NoError(t, app.DB.Model(&models.AgentTransfer{}).Where("contact_id = ?", contact.ID).Update("ad_id", "ad-2").Error)
+	require.NoError(t, app.DB.Create(&models.Order{
+		OrganizationID:    org.ID,
+		WhatsAppAccount:   "main",
+		ContactID:         contact.ID,
+		WhatsAppMessageID: "wamid." + uuid.New().String(),
+		Status:            models.OrderStatusPending,
+		TotalAmount:       2500,
+		Currency:          "USD",
+		AdID:              "ad-2",
+	}).Error)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.GetAdAnalytics(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.AdAnalyticsResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	require.Len(t, resp.Data.Ads, 2)
+
+	ad1 := resp.Data.Ads[0]
+	assert.Equal(t, "ad-1", ad1.AdID)
+	assert.Equal(t, "Summer sale", ad1.Headline)
+	assert.Equal(t, int64(2), ad1.Conversations)
+	assert.Equal(t, int64(2), ad1.NewContacts)
+	assert.Zero(t, ad1.Orders)
+
+	ad2 := resp.Data.Ads[1]
+	assert.Equal(t, "ad-2", ad2.AdID)
+	assert.Equal(t, int64(1), ad2.Transfers)
+	assert.Equal(t, int64(1), ad2.Orders)
+	assert.Equal(t, []handlers.AdRevenue{{Currency: "USD", Amount: 2500}}, ad2.Revenue)
+}
+
+func TestApp_GetAdAnalytics_InvalidRange(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetQueryParam(req, "from", "yesterday")
+	testutil.SetQueryParam(req, "to", "2026-01-31")
+	require.NoError(t, app.GetAdAnalytics(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
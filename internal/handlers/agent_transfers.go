@@ -410,6 +410,9 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 	if err != nil {
 		return nil
 	}
+	if contact.IsBlocked {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Contact is blocked", nil, "")
+	}
 
 	// Check for existing active transfer
 	var existingCount int64
@@ -489,6 +492,7 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		TeamID:              teamID,
 		TransferredByUserID: &userID,
 		Notes:               req.Notes,
+		AdID:                contact.AdID,
 		TransferredAt:       time.Now(),
 	}
 
@@ -870,6 +874,7 @@ func (a *App) PickNextTransfer(r *fastglue.Request) error {
 	// Build query for picking transfer with row-level locking
 	query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
 		Where("organization_id = ? AND status = ? AND agent_id IS NULL", orgID, models.TransferStatusActive).
+		Where("contact_id NOT IN (SELECT id FROM contacts WHERE organization_id = ? AND is_blocked = ?)", orgID, true).
 		Order("transferred_at ASC")
 
 	if teamIDStr != "" {
@@ -1137,6 +1142,16 @@ func (a *App) broadcastTransferAssigned(transfer *models.AgentTransfer) {
 // saveAndFinalizeTransfer handles the common post-creation steps for agent transfers:
 // sets SLA deadlines, saves to DB, updates contact assignment, optionally ends chatbot sessions, and broadcasts.
 func (a *App) saveAndFinalizeTransfer(transfer *models.AgentTransfer, account *models.WhatsAppAccount, contact *models.Contact, settings *models.ChatbotSettings, endChatbotSession bool) error {
+	// Blocked contacts never enter the agent queue
+	if contact.IsBlocked {
+		return errContactBlocked
+	}
+
+	// Attribute the transfer to the ad the contact came from
+	if transfer.AdID == "" {
+		transfer.AdID = contact.AdID
+	}
+
 	// Set SLA deadlines
 	if settings != nil {
 		a.SetSLADeadlines(transfer, settings)
@@ -10,6 +10,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/shridarpatil/whatomate/internal/calling"
 	"github.com/shridarpatil/whatomate/internal/config"
+	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/queue"
 	"github.com/shridarpatil/whatomate/internal/storage"
 	"github.com/shridarpatil/whatomate/internal/tts"
@@ -31,6 +32,8 @@ type App struct {
 	WSHub             *websocket.Hub
 	Queue             queue.Queue
 	CampaignSubCancel context.CancelFunc
+	// AccountAuthSubCancel stops the subscriber for token rejections seen by the worker
+	AccountAuthSubCancel context.CancelFunc
 	// HTTPClient is a shared HTTP client with connection pooling for external API calls
 	HTTPClient *http.Client
 	// CallManager handles WebRTC call sessions (nil when calling is disabled)
@@ -178,6 +181,44 @@ func (a *App) StopCampaignStatsSubscriber() {
 	}
 }
 
+// StartAccountAuthSubscriber listens for access tokens Meta rejected during
+// worker sends and marks those accounts auth_failed
+func (a *App) StartAccountAuthSubscriber() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.AccountAuthSubCancel = cancel
+
+	subscriber := queue.NewSubscriber(a.Redis, a.Log)
+
+	err := subscriber.SubscribeAccountAuthFailures(ctx, func(failure *queue.AccountAuthFailure) {
+		var account models.WhatsAppAccount
+		if err := a.DB.Where("id = ? AND organization_id = ?", failure.AccountID, failure.OrganizationID).
+			First(&account).Error; err != nil {
+			a.Log.Error("Failed to load account for auth failure", "error", err, "account_id", failure.AccountID)
+			return
+		}
+		a.markAccountAuthFailed(&account, accountHealthSourceSend, &whatsapp.APIError{
+			Code:    failure.Code,
+			Subcode: failure.Subcode,
+			Message: failure.Message,
+		})
+	})
+
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	a.Log.Info("Account auth subscriber started")
+	return nil
+}
+
+// StopAccountAuthSubscriber stops the account auth subscriber
+func (a *App) StopAccountAuthSubscriber() {
+	if a.AccountAuthSubCancel != nil {
+		a.AccountAuthSubCancel()
+	}
+}
+
 // getOrgAndUserID extracts both organization ID and user ID from the request context.
 // Returns an error if either is missing or invalid.
 func (a *App) getOrgAndUserID(r *fastglue.Request) (orgID, userID uuid.UUID, err error) {
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/abtest"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// staleClaimTimeout is how long a campaign may sit in queued without having started
+// before the scheduler assumes the dispatcher that claimed it died and releases it
+const staleClaimTimeout = 10 * time.Minute
+
+// CampaignScheduler dispatches scheduled campaigns once their start time is reached
+type CampaignScheduler struct {
+	app      *App
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewCampaignScheduler creates a new campaign scheduler
+func NewCampaignScheduler(app *App, interval time.Duration) *CampaignScheduler {
+	return &CampaignScheduler{
+		app:      app,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the scheduling loop
+func (s *CampaignScheduler) Start(ctx context.Context) {
+	s.app.Log.Info("Campaign scheduler started", "interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.app.Log.Info("Campaign scheduler stopped by context")
+			return
+		case <-s.stopCh:
+			s.app.Log.Info("Campaign scheduler stopped")
+			return
+		case <-ticker.C:
+			s.CreateDueRecurringRuns(ctx)
+			s.DispatchDueCampaigns(ctx)
+			s.SendDueWinners(ctx)
+		}
+	}
+}
+
+// Stop stops the campaign scheduler
+func (s *CampaignScheduler) Stop() {
+	close(s.stopCh)
+}
+
+// DispatchDueCampaigns starts every scheduled campaign whose scheduled_at has passed.
+// Returns the number of campaigns dispatched by this instance.
+func (s *CampaignScheduler) DispatchDueCampaigns(ctx context.Context) int {
+	s.releaseStaleClaims()
+
+	var campaigns []models.BulkMessageCampaign
+	if err := s.app.DB.Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?", models.CampaignStatusScheduled, time.Now()).
+		Order("scheduled_at ASC").
+		Find(&campaigns).Error; err != nil {
+		s.app.Log.Error("Failed to load scheduled campaigns", "error", err)
+		return 0
+	}
+
+	dispatched := 0
+	for i := range campaigns {
+		if s.dispatchCampaign(ctx, &campaigns[i]) {
+			dispatched++
+		}
+	}
+	return dispatched
+}
+
+// dispatchCampaign claims a single scheduled campaign and enqueues its recipients.
+// The claim is a conditional status update, so when several replicas race for the
+// same campaign only the one whose update affects a row goes on to enqueue it.
+func (s *CampaignScheduler) dispatchCampaign(ctx context.Context, campaign *models.BulkMessageCampaign) bool {
+	result := s.app.DB.Model(&models.BulkMessageCampaign{}).
+		Where("id = ? AND status = ?", campaign.ID, models.CampaignStatusScheduled).
+		Update("status", models.CampaignStatusQueued)
+	if result.Error != nil {
+		s.app.Log.Error("Failed to claim scheduled campaign", "error", result.Error, "campaign_id", campaign.ID)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		// Another instance claimed it, or it was cancelled/started manually in the meantime
+		return false
+	}
+	campaign.Status = models.CampaignStatusQueued
+
+	// Template may have been deleted since the campaign was scheduled
+	if campaign.TemplateID != uuid.Nil {
+		var count int64
+		if err := s.app.DB.Model(&models.Template{}).
+			Where("id = ? AND organization_id = ?", campaign.TemplateID, campaign.OrganizationID).
+			Count(&count).Error; err != nil {
+			s.app.Log.Error("Failed to check campaign template", "error", err, "campaign_id", campaign.ID)
+			s.app.DB.Model(campaign).Update("status", models.CampaignStatusScheduled)
+			return false
+		}
+		if count == 0 {
+			s.app.Log.Warn("Scheduled campaign template no longer exists", "campaign_id", campaign.ID)
+			s.app.DB.Model(campaign).Update("status", models.CampaignStatusFailed)
+			return false
+		}
+	}
+
+	if campaign.SegmentID != nil {
+		if _, _, err := s.app.resolveCampaignSegment(campaign); err != nil {
+			s.app.Log.Error("Failed to resolve campaign segment", "error", err, "campaign_id", campaign.ID)
+			s.app.DB.Model(campaign).Update("status", models.CampaignStatusScheduled)
+			return false
+		}
+	}
+
+	var recipients []models.BulkMessageRecipient
+	if err := s.app.DB.Where("campaign_id = ? AND status = ?", campaign.ID, models.MessageStatusPending).Find(&recipients).Error; err != nil {
+		s.app.Log.Error("Failed to load recipients", "error", err, "campaign_id", campaign.ID)
+		s.app.DB.Model(campaign).Update("status", models.CampaignStatusScheduled)
+		return false
+	}
+
+	if len(recipients) == 0 && campaign.RecurringCampaignID != nil {
+		// A run whose segment matched nobody this time; the next run may find someone
+		s.app.Log.Info("Recurring campaign run has no recipients, completing it", "campaign_id", campaign.ID)
+		now := time.Now()
+		s.app.DB.Model(campaign).Updates(map[string]any{"status": models.CampaignStatusCompleted, "completed_at": now})
+		return false
+	}
+	if len(recipients) == 0 {
+		// Nothing to send; hand it back to the user as a draft so recipients can be added
+		s.app.Log.Warn("Scheduled campaign has no pending recipients, reverting to draft", "campaign_id", campaign.ID)
+		s.app.DB.Model(campaign).Update("status", models.CampaignStatusDraft)
+		return false
+	}
+
+	if err := s.app.enqueueCampaignRecipients(ctx, campaign, recipients); err != nil {
+		// Some recipients may already be on the queue, so retrying would send them
+		// twice. Fail the campaign instead so an operator can inspect it.
+		s.app.Log.Error("Failed to enqueue scheduled campaign, marking as failed", "error", err, "campaign_id", campaign.ID)
+		s.app.DB.Model(campaign).Update("status", models.CampaignStatusFailed)
+		return false
+	}
+
+	s.app.Log.Info("Scheduled campaign dispatched", "campaign_id", campaign.ID, "recipients", len(recipients))
+	return true
+}
+
+// releaseStaleClaims returns scheduled campaigns that were claimed but never started
+// back to scheduled. This covers a dispatcher dying between the claim and enqueueing,
+// which would otherwise leave the campaign queued forever.
+func (s *CampaignScheduler) releaseStaleClaims() {
+	result := s.app.DB.Model(&models.BulkMessageCampaign{}).
+		Where("status = ? AND started_at IS NULL AND scheduled_at IS NOT NULL AND updated_at < ?",
+			models.CampaignStatusQueued, time.Now().Add(-staleClaimTimeout)).
+		Update("status", models.CampaignStatusScheduled)
+	if result.Error != nil {
+		s.app.Log.Error("Failed to release stale campaign claims", "error", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		s.app.Log.Warn("Released stale campaign claims", "count", result.RowsAffected)
+	}
+}
+
+// SendDueWinners picks the winning variant of every A/B tested campaign whose
+// test window has passed and enqueues the recipients held for it. Returns the
+// number of campaigns whose winner this instance sent.
+func (s *CampaignScheduler) SendDueWinners(ctx context.Context) int {
+	var campaigns []models.BulkMessageCampaign
+	if err := preloadVariants(s.app.DB).
+		Where("status = ? AND winner_after_hours > 0 AND winner_variant_id IS NULL AND started_at IS NOT NULL", models.CampaignStatusProcessing).
+		Where("started_at + winner_after_hours * interval '1 hour' <= ?", time.Now()).
+		Find(&campaigns).Error; err != nil {
+		s.app.Log.Error("Failed to load campaigns awaiting a winner", "error", err)
+		return 0
+	}
+
+	sent := 0
+	for i := range campaigns {
+		if s.sendWinner(ctx, &campaigns[i]) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// sendWinner records the campaign's best variant and enqueues its held
+// recipients. Recording the winner is conditional on none being set, so only
+// one replica enqueues them.
+func (s *CampaignScheduler) sendWinner(ctx context.Context, campaign *models.BulkMessageCampaign) bool {
+	if len(campaign.Variants) == 0 {
+		return false
+	}
+
+	stats, err := s.app.campaignVariantStats(campaign)
+	if err != nil {
+		s.app.Log.Error("Failed to compute variant stats", "error", err, "campaign_id", campaign.ID)
+		return false
+	}
+	winner := pickWinner(stats, campaign.WinnerMetric)
+
+	result := s.app.DB.Model(&models.BulkMessageCampaign{}).
+		Where("id = ? AND winner_variant_id IS NULL", campaign.ID).
+		Update("winner_variant_id", winner.VariantID)
+	if result.Error != nil {
+		s.app.Log.Error("Failed to record A/B test winner", "error", result.Error, "campaign_id", campaign.ID)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		return false
+	}
+	campaign.WinnerVariantID = &winner.VariantID
+
+	// Held recipients are the pending ones outside the test group; pending test
+	// group recipients are already on the queue
+	var pending []models.BulkMessageRecipient
+	if err := s.app.DB.Where("campaign_id = ? AND status = ?", campaign.ID, models.MessageStatusPending).
+		Find(&pending).Error; err != nil {
+		s.app.Log.Error("Failed to load held recipients", "error", err, "campaign_id", campaign.ID)
+		return false
+	}
+	var recipients []models.BulkMessageRecipient
+	for _, recipient := range pending {
+		if !abtest.InTestGroup(campaign, recipient.PhoneNumber) {
+			recipients = append(recipients, recipient)
+		}
+	}
+
+	s.app.Log.Info("A/B test winner picked", "campaign_id", campaign.ID, "variant", winner.Name, "metric", campaign.WinnerMetric, "recipients", len(recipients))
+
+	if err := s.app.enqueueRecipientJobs(ctx, campaign, recipients); err != nil {
+		// The winner is recorded, so held recipients would never be retried; fail
+		// the campaign so an operator can inspect it, as for scheduled dispatch
+		s.app.Log.Error("Failed to enqueue A/B test remainder, marking as failed", "error", err, "campaign_id", campaign.ID)
+		s.app.DB.Model(campaign).Update("status", models.CampaignStatusFailed)
+		return false
+	}
+	return true
+}
+
+// CreateDueRecurringRuns creates a run for every active recurring campaign
+// whose next occurrence has passed and dispatches it. Returns the number of
+// runs this instance created.
+func (s *CampaignScheduler) CreateDueRecurringRuns(ctx context.Context) int {
+	now := time.Now()
+	var due []models.RecurringCampaign
+	if err := s.app.DB.Where("status = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", models.RecurringCampaignStatusActive, now).
+		Order("next_run_at ASC").
+		Find(&due).Error; err != nil {
+		s.app.Log.Error("Failed to load due recurring campaigns", "error", err)
+		return 0
+	}
+
+	created := 0
+	for i := range due {
+		run, err := s.app.createRecurringRun(&due[i], now)
+		if err != nil {
+			s.app.Log.Error("Failed to create recurring campaign run", "error", err, "recurring_campaign_id", due[i].ID)
+			continue
+		}
+		if run == nil {
+			continue
+		}
+		created++
+		s.app.Log.Info("Recurring campaign run created", "recurring_campaign_id", due[i].ID, "campaign_id", run.ID, "next_run_at", due[i].NextRunAt)
+
+		// A run that fails to dispatch here stays scheduled and is retried by
+		// DispatchDueCampaigns like any other scheduled campaign
+		s.dispatchCampaign(ctx, run)
+	}
+	return created
+}
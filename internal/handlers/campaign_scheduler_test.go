@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaignScheduler_DispatchesDueCampaign(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("sched-due")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("sched-due-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusScheduled)
+	require.NoError(t, app.DB.Model(campaign).Update("scheduled_at", time.Now().Add(-time.Minute)).Error)
+	createTestRecipient(t, app, campaign.ID, "+1234567890", models.MessageStatusPending)
+	createTestRecipient(t, app, campaign.ID, "+0987654321", models.MessageStatusPending)
+
+	scheduler := handlers.NewCampaignScheduler(app, time.Minute)
+	assert.Equal(t, 1, scheduler.DispatchDueCampaigns(context.Background()))
+	assert.Len(t, mockQueue.Jobs, 2)
+
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("id = ?", campaign.ID).First(&updated).Error)
+	assert.Equal(t, models.CampaignStatusProcessing, updated.Status)
+	assert.NotNil(t, updated.StartedAt)
+
+	// A second pass (e.g. another replica) must not dispatch it again
+	other := handlers.NewCampaignScheduler(app, time.Minute)
+	assert.Equal(t, 0, other.DispatchDueCampaigns(context.Background()))
+	assert.Len(t, mockQueue.Jobs, 2)
+}
+
+func TestCampaignScheduler_SkipsFutureCampaign(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("sched-future")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("sched-future-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusScheduled)
+	require.NoError(t, app.DB.Model(campaign).Update("scheduled_at", time.Now().Add(time.Hour)).Error)
+	createTestRecipient(t, app, campaign.ID, "+1234567890", models.MessageStatusPending)
+
+	scheduler := handlers.NewCampaignScheduler(app, time.Minute)
+	assert.Equal(t, 0, scheduler.DispatchDueCampaigns(context.Background()))
+	assert.Empty(t, mockQueue.Jobs)
+
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("id = ?", campaign.ID).First(&updated).Error)
+	assert.Equal(t, models.CampaignStatusScheduled, updated.Status)
+}
+
+func TestCampaignScheduler_FailsOnEnqueueFailure(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	mockQueue.Error = errors.New("redis unavailable")
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("sched-fail")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("sched-fail-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusScheduled)
+	require.NoError(t, app.DB.Model(campaign).Update("scheduled_at", time.Now().Add(-time.Minute)).Error)
+	createTestRecipient(t, app, campaign.ID, "+1234567890", models.MessageStatusPending)
+
+	scheduler := handlers.NewCampaignScheduler(app, time.Minute)
+	assert.Equal(t, 0, scheduler.DispatchDueCampaigns(context.Background()))
+
+	// Not retried, since some recipients may already have been enqueued
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("id = ?", campaign.ID).First(&updated).Error)
+	assert.Equal(t, models.CampaignStatusFailed, updated.Status)
+}
+
+func TestCampaignScheduler_ReleasesStaleClaim(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("sched-stale")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("sched-stale-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	// Claimed by a dispatcher that died before enqueueing anything
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusQueued)
+	require.NoError(t, app.DB.Exec("UPDATE bulk_message_campaigns SET scheduled_at = ?, updated_at = ? WHERE id = ?",
+		time.Now().Add(-time.Hour), time.Now().Add(-time.Hour), campaign.ID).Error)
+	createTestRecipient(t, app, campaign.ID, "+1234567890", models.MessageStatusPending)
+
+	scheduler := handlers.NewCampaignScheduler(app, time.Minute)
+	assert.Equal(t, 1, scheduler.DispatchDueCampaigns(context.Background()))
+	assert.Len(t, mockQueue.Jobs, 1)
+
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("id = ?", campaign.ID).First(&updated).Error)
+	assert.Equal(t, models.CampaignStatusProcessing, updated.Status)
+}
+
+func TestCampaignScheduler_ResolvesSegment(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("sched-segment")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("sched-segment-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	createSegmentContact(t, app.DB, org.ID, "15550000031", "Alice", []string{"vip"}, nil)
+	createSegmentContact(t, app.DB, org.ID, "15550000032", "Bob", []string{"vip"}, nil)
+
+	segment := &models.ContactSegment{OrganizationID: org.ID, Name: "VIPs", CreatedByID: user.ID, Tags: models.StringArray{"vip"}}
+	require.NoError(t, app.DB.Create(segment).Error)
+
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusScheduled)
+	require.NoError(t, app.DB.Model(campaign).Updates(map[string]any{
+		"scheduled_at":   time.Now().Add(-time.Minute),
+		"segment_id":     segment.ID,
+		"segment_params": models.JSONB{"1": "profile_name"},
+	}).Error)
+
+	scheduler := handlers.NewCampaignScheduler(app, time.Minute)
+	assert.Equal(t, 1, scheduler.DispatchDueCampaigns(context.Background()))
+	require.Len(t, mockQueue.Jobs, 2)
+	assert.ElementsMatch(t, []any{"Alice", "Bob"}, []any{mockQueue.Jobs[0].TemplateParams["1"], mockQueue.Jobs[1].TemplateParams["1"]})
+}
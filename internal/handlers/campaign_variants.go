@@ -0,0 +1,447 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// maxCampaignVariants is how many template variants an A/B test may compare
+const maxCampaignVariants = 5
+
+// CampaignVariantRequest is a template variant in a campaign create/update request.
+// ID is set to keep an existing variant, along with its uploaded header media.
+type CampaignVariantRequest struct {
+	ID         *uuid.UUID `json:"id"`
+	Name       string     `json:"name"`
+	TemplateID string     `json:"template_id"`
+	Weight     int        `json:"weight"`
+}
+
+// CampaignVariantResponse represents a campaign variant in API responses
+type CampaignVariantResponse struct {
+	ID                  uuid.UUID `json:"id"`
+	Name                string    `json:"name"`
+	TemplateID          uuid.UUID `json:"template_id"`
+	TemplateName        string    `json:"template_name,omitempty"`
+	Weight              int       `json:"weight"`
+	HeaderMediaID       string    `json:"header_media_id,omitempty"`
+	HeaderMediaFilename string    `json:"header_media_filename,omitempty"`
+	HeaderMediaMimeType string    `json:"header_media_mime_type,omitempty"`
+}
+
+// CampaignVariantStats reports how a variant performed. Rates are fractions
+// of the messages sent.
+type CampaignVariantStats struct {
+	VariantID    uuid.UUID `json:"variant_id"`
+	Name         string    `json:"name"`
+	Recipients   int64     `json:"recipients"`
+	Sent         int64     `json:"sent"`
+	Delivered    int64     `json:"delivered"`
+	Read         int64     `json:"read"`
+	Replied      int64     `json:"replied"`
+	Clicked      int64     `json:"clicked"`
+	Failed       int64     `json:"failed"`
+	DeliveryRate float64   `json:"delivery_rate"`
+	ReadRate     float64   `json:"read_rate"`
+	ReplyRate    float64   `json:"reply_rate"`
+	ClickRate    float64   `json:"click_rate"`
+}
+
+// rate returns the stats' rate for a winner metric
+func (s *CampaignVariantStats) rate(metric models.VariantMetric) float64 {
+	switch metric {
+	case models.VariantMetricDelivered:
+		return s.DeliveryRate
+	case models.VariantMetricReplied:
+		return s.ReplyRate
+	case models.VariantMetricClicked:
+		return s.ClickRate
+	default:
+		return s.ReadRate
+	}
+}
+
+// validVariantMetric reports whether m is a known winner metric
+func validVariantMetric(m models.VariantMetric) bool {
+	switch m {
+	case models.VariantMetricDelivered, models.VariantMetricRead, models.VariantMetricReplied, models.VariantMetricClicked:
+		return true
+	}
+	return false
+}
+
+// validateCampaignVariants checks a campaign's variants and returns them as
+// models sorted by name, along with their templates in the same order.
+// Unnamed variants are named A, B, C... by position and weights default to 1.
+func (a *App) validateCampaignVariants(orgID uuid.UUID, reqs []CampaignVariantRequest) ([]models.CampaignVariant, []*models.Template, error) {
+	if len(reqs) == 1 {
+		return nil, nil, fmt.Errorf("An A/B test needs at least 2 variants")
+	}
+	if len(reqs) > maxCampaignVariants {
+		return nil, nil, fmt.Errorf("An A/B test can have at most %d variants", maxCampaignVariants)
+	}
+
+	variants := make([]models.CampaignVariant, len(reqs))
+	templates := make([]*models.Template, len(reqs))
+	var names []string
+	for i, req := range reqs {
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = string(rune('A' + i))
+		}
+		if slices.Contains(names, name) {
+			return nil, nil, fmt.Errorf("Duplicate variant name %s", name)
+		}
+		names = append(names, name)
+
+		weight := req.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		if weight < 0 {
+			return nil, nil, fmt.Errorf("Variant %s has a negative weight", name)
+		}
+
+		templateID, err := uuid.Parse(req.TemplateID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid template ID for variant %s", name)
+		}
+		var template models.Template
+		if err := a.DB.Where("id = ? AND organization_id = ?", templateID, orgID).First(&template).Error; err != nil {
+			return nil, nil, fmt.Errorf("Template for variant %s not found", name)
+		}
+
+		variants[i] = models.CampaignVariant{Name: name, TemplateID: templateID, Weight: weight}
+		if req.ID != nil {
+			variants[i].ID = *req.ID
+		}
+		templates[i] = &template
+	}
+
+	// Keep name order, so the first variant is the one whose template the
+	// campaign reports as its own
+	order := make([]int, len(variants))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(x, y int) int { return strings.Compare(variants[x].Name, variants[y].Name) })
+	sortedVariants := make([]models.CampaignVariant, len(variants))
+	sortedTemplates := make([]*models.Template, len(templates))
+	for i, j := range order {
+		sortedVariants[i], sortedTemplates[i] = variants[j], templates[j]
+	}
+	return sortedVariants, sortedTemplates, nil
+}
+
+// validateWinnerMode checks the winner settings of a campaign with the given
+// number of variants and returns the metric to store
+func validateWinnerMode(afterHours, testPercent int, metric models.VariantMetric, variantCount int) (models.VariantMetric, error) {
+	if afterHours == 0 {
+		return "", nil
+	}
+	if afterHours < 0 {
+		return "", fmt.Errorf("Winner delay cannot be negative")
+	}
+	if variantCount == 0 {
+		return "", fmt.Errorf("Winner mode needs template variants")
+	}
+	if testPercent < 1 || testPercent > 99 {
+		return "", fmt.Errorf("Winner test percent must be between 1 and 99")
+	}
+	if metric == "" {
+		metric = models.VariantMetricRead
+	}
+	if !validVariantMetric(metric) {
+		return "", fmt.Errorf("Invalid winner metric")
+	}
+	return metric, nil
+}
+
+// replaceCampaignVariants makes variants the campaign's variant set. Variants
+// whose ID matches an existing one are updated in place and keep their header
+// media unless their template changed; the rest are created, and existing
+// variants that are not listed are deleted.
+func replaceCampaignVariants(tx *gorm.DB, campaignID uuid.UUID, variants []models.CampaignVariant) error {
+	var existing []models.CampaignVariant
+	if err := tx.Where("campaign_id = ?", campaignID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	var keep []uuid.UUID
+	for i := range variants {
+		v := &variants[i]
+		v.CampaignID = campaignID
+		idx := slices.IndexFunc(existing, func(e models.CampaignVariant) bool { return e.ID == v.ID })
+		if v.ID == uuid.Nil || idx < 0 {
+			v.ID = uuid.Nil
+			if err := tx.Create(v).Error; err != nil {
+				return err
+			}
+			keep = append(keep, v.ID)
+			continue
+		}
+
+		updates := map[string]any{"name": v.Name, "template_id": v.TemplateID, "weight": v.Weight}
+		if existing[idx].TemplateID != v.TemplateID {
+			updates["header_media_id"] = ""
+			updates["header_media_filename"] = ""
+			updates["header_media_mime_type"] = ""
+			updates["header_media_local_path"] = ""
+		}
+		if err := tx.Model(&existing[idx]).Updates(updates).Error; err != nil {
+			return err
+		}
+		keep = append(keep, v.ID)
+	}
+
+	query := tx.Where("campaign_id = ?", campaignID)
+	if len(keep) > 0 {
+		query = query.Where("id NOT IN ?", keep)
+	}
+	return query.Delete(&models.CampaignVariant{}).Error
+}
+
+// variantsToResponse converts a campaign's variants to API responses
+func variantsToResponse(variants []models.CampaignVariant) []CampaignVariantResponse {
+	if len(variants) == 0 {
+		return nil
+	}
+	result := make([]CampaignVariantResponse, len(variants))
+	for i, v := range variants {
+		result[i] = CampaignVariantResponse{
+			ID:                  v.ID,
+			Name:                v.Name,
+			TemplateID:          v.TemplateID,
+			Weight:              v.Weight,
+			HeaderMediaID:       v.HeaderMediaID,
+			HeaderMediaFilename: v.HeaderMediaFilename,
+			HeaderMediaMimeType: v.HeaderMediaMimeType,
+		}
+		if v.Template != nil {
+			result[i].TemplateName = v.Template.Name
+		}
+	}
+	return result
+}
+
+// preloadVariants loads a campaign's variants, with templates, ordered by name
+func preloadVariants(db *gorm.DB) *gorm.DB {
+	return db.Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name ASC")
+	}).Preload("Variants.Template")
+}
+
+// campaignVariantStats computes per-variant delivery, read, reply and button
+// click counts from the campaign's recipients. A reply is any inbound message
+// from the contact after the send other than a button tap; a click is a quick
+// reply button tap on the campaign message itself.
+func (a *App) campaignVariantStats(campaign *models.BulkMessageCampaign) ([]CampaignVariantStats, error) {
+	var rows []struct {
+		VariantID  uuid.UUID
+		Recipients int64
+		Sent       int64
+		Delivered  int64
+		Read       int64
+		Failed     int64
+		Replied    int64
+		Clicked    int64
+	}
+	if err := a.DB.Raw(`
+		SELECT r.variant_id,
+			COUNT(*) AS recipients,
+			COUNT(*) FILTER (WHERE r.status IN ('sent','delivered','read')) AS sent,
+			COUNT(*) FILTER (WHERE r.status IN ('delivered','read')) AS delivered,
+			COUNT(*) FILTER (WHERE r.status = 'read') AS read,
+			COUNT(*) FILTER (WHERE r.status = 'failed') AS failed,
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM messages om
+				JOIN messages im ON im.contact_id = om.contact_id
+				WHERE om.id = r.message_id
+					AND im.direction = 'incoming'
+					AND im.message_type <> 'button'
+					AND im.created_at > r.sent_at
+					AND im.deleted_at IS NULL
+			)) AS replied,
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM messages im
+				WHERE im.reply_to_message_id = r.message_id
+					AND im.message_type = 'button'
+					AND im.deleted_at IS NULL
+			)) AS clicked
+		FROM bulk_message_recipients r
+		WHERE r.campaign_id = ? AND r.variant_id IS NOT NULL AND r.deleted_at IS NULL
+		GROUP BY r.variant_id`, campaign.ID).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stats := make([]CampaignVariantStats, len(campaign.Variants))
+	for i, v := range campaign.Variants {
+		stats[i] = CampaignVariantStats{VariantID: v.ID, Name: v.Name}
+		for _, row := range rows {
+			if row.VariantID != v.ID {
+				continue
+			}
+			s := &stats[i]
+			s.Recipients, s.Sent, s.Delivered, s.Read = row.Recipients, row.Sent, row.Delivered, row.Read
+			s.Failed, s.Replied, s.Clicked = row.Failed, row.Replied, row.Clicked
+			if s.Sent > 0 {
+				sent := float64(s.Sent)
+				s.DeliveryRate = float64(s.Delivered) / sent
+				s.ReadRate = float64(s.Read) / sent
+				s.ReplyRate = float64(s.Replied) / sent
+				s.ClickRate = float64(s.Clicked) / sent
+			}
+		}
+	}
+	return stats, nil
+}
+
+// pickWinner returns the variant with the best rate on metric. Ties go to the
+// variant that sent more messages, then to the first by name.
+func pickWinner(stats []CampaignVariantStats, metric models.VariantMetric) *CampaignVariantStats {
+	var best *CampaignVariantStats
+	for i := range stats {
+		s := &stats[i]
+		if best == nil || s.rate(metric) > best.rate(metric) ||
+			(s.rate(metric) == best.rate(metric) && s.Sent > best.Sent) {
+			best = s
+		}
+	}
+	return best
+}
+
+// GetCampaignVariants returns an A/B tested campaign's variants with their stats
+func (a *App) GetCampaignVariants(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "campaign")
+	if err != nil {
+		return nil
+	}
+
+	var campaign models.BulkMessageCampaign
+	if err := preloadVariants(a.DB).Where("id = ? AND organization_id = ?", id, orgID).First(&campaign).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Campaign not found", nil, "")
+	}
+
+	stats, err := a.campaignVariantStats(&campaign)
+	if err != nil {
+		a.Log.Error("Failed to compute variant stats", "error", err, "campaign_id", id)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load variant stats", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"variants":            variantsToResponse(campaign.Variants),
+		"stats":               stats,
+		"winner_after_hours":  campaign.WinnerAfterHours,
+		"winner_test_percent": campaign.WinnerTestPercent,
+		"winner_metric":       campaign.WinnerMetric,
+		"winner_variant_id":   campaign.WinnerVariantID,
+	})
+}
+
+// UploadCampaignVariantMedia uploads header media for one variant of a campaign
+func (a *App) UploadCampaignVariantMedia(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	campaignUUID, err := parsePathUUID(r, "id", "campaign")
+	if err != nil {
+		return nil
+	}
+	variantUUID, err := parsePathUUID(r, "variantId", "variant")
+	if err != nil {
+		return nil
+	}
+
+	campaign, err := findByIDAndOrg[models.BulkMessageCampaign](a.DB, r, campaignUUID, orgID, "Campaign")
+	if err != nil {
+		return nil
+	}
+	if campaign.Status != models.CampaignStatusDraft {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only upload media for draft campaigns", nil, "")
+	}
+
+	var variant models.CampaignVariant
+	if err := a.DB.Where("id = ? AND campaign_id = ?", variantUUID, campaign.ID).Preload("Template").First(&variant).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Variant not found", nil, "")
+	}
+	if variant.Template == nil || variant.Template.HeaderType == "" || variant.Template.HeaderType == "TEXT" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Template does not have a media header", nil, "")
+	}
+
+	account, err := a.resolveWhatsAppAccount(orgID, campaign.WhatsAppAccount)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
+	}
+
+	media, err := a.uploadHeaderMedia(r, account)
+	if err != nil {
+		return nil
+	}
+
+	localPath, err := a.saveCampaignMedia(variant.ID.String(), media.data, media.mimeType)
+	if err != nil {
+		a.Log.Error("Failed to save media locally", "error", err)
+	}
+
+	if err := a.DB.Model(&variant).Updates(map[string]any{
+		"header_media_id":         media.mediaID,
+		"header_media_filename":   sanitizeFilename(media.filename),
+		"header_media_mime_type":  media.mimeType,
+		"header_media_local_path": localPath,
+	}).Error; err != nil {
+		a.Log.Error("Failed to update variant with media info", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save media info", nil, "")
+	}
+
+	a.Log.Info("Campaign variant media uploaded", "campaign_id", campaign.ID, "variant", variant.Name, "media_id", media.mediaID)
+
+	return r.SendEnvelope(map[string]any{
+		"media_id":  media.mediaID,
+		"filename":  media.filename,
+		"mime_type": media.mimeType,
+		"message":   "Media uploaded successfully",
+	})
+}
+
+// ServeCampaignVariantMedia serves a variant's header media for preview
+func (a *App) ServeCampaignVariantMedia(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	campaignUUID, err := parsePathUUID(r, "id", "campaign")
+	if err != nil {
+		return nil
+	}
+	variantUUID, err := parsePathUUID(r, "variantId", "variant")
+	if err != nil {
+		return nil
+	}
+
+	campaign, err := findByIDAndOrg[models.BulkMessageCampaign](a.DB, r, campaignUUID, orgID, "Campaign")
+	if err != nil {
+		return nil
+	}
+
+	var variant models.CampaignVariant
+	if err := a.DB.Where("id = ? AND campaign_id = ?", variantUUID, campaign.ID).First(&variant).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Variant not found", nil, "")
+	}
+
+	return a.serveCampaignMediaFile(r, variant.HeaderMediaLocalPath, variant.HeaderMediaMimeType)
+}
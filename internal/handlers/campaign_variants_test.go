@@ -0,0 +1,161 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestApp_CreateCampaign_Variants(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, orgID)
+	templateA := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+	templateB := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"name":             "A/B Campaign",
+		"whatsapp_account": account.Name,
+		"variants": []map[string]any{
+			{"template_id": templateA.ID.String(), "weight": 3},
+			{"template_id": templateB.ID.String()},
+		},
+		"winner_after_hours":  4,
+		"winner_test_percent": 20,
+	})
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.CreateCampaign(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.CampaignResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	require.Len(t, resp.Data.Variants, 2)
+	assert.Equal(t, "A", resp.Data.Variants[0].Name)
+	assert.Equal(t, 3, resp.Data.Variants[0].Weight)
+	assert.Equal(t, "B", resp.Data.Variants[1].Name)
+	assert.Equal(t, 1, resp.Data.Variants[1].Weight)
+	assert.Equal(t, templateA.ID, resp.Data.TemplateID)
+	assert.Equal(t, models.VariantMetricRead, resp.Data.WinnerMetric)
+}
+
+func TestApp_CreateCampaign_VariantValidation(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, orgID)
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+
+	tests := []struct {
+		name string
+		body map[string]any
+		want string
+	}{
+		{"single variant", map[string]any{
+			"variants": []map[string]any{{"template_id": template.ID.String()}},
+		}, "at least 2 variants"},
+		{"duplicate names", map[string]any{
+			"variants": []map[string]any{
+				{"name": "X", "template_id": template.ID.String()},
+				{"name": "X", "template_id": template.ID.String()},
+			},
+		}, "Duplicate variant name"},
+		{"winner mode without variants", map[string]any{
+			"template_id":         template.ID.String(),
+			"winner_after_hours":  2,
+			"winner_test_percent": 10,
+		}, "Winner mode needs template variants"},
+		{"winner test percent", map[string]any{
+			"variants": []map[string]any{
+				{"template_id": template.ID.String()},
+				{"template_id": template.ID.String()},
+			},
+			"winner_after_hours": 2,
+		}, "Winner test percent must be between 1 and 99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.body["name"] = "Invalid A/B"
+			tt.body["whatsapp_account"] = account.Name
+			req := testutil.NewJSONRequest(t, tt.body)
+			testutil.SetAuthContext(req, orgID, userID)
+			require.NoError(t, app.CreateCampaign(req))
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+			assert.Contains(t, string(testutil.GetResponseBody(req)), tt.want)
+		})
+	}
+}
+
+func TestCampaignScheduler_SendsWinnerToHeldRecipients(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("sched-winner")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("sched-winner-account"))
+	templateA := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	templateB := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	campaign := createTestCampaign(t, app, org.ID, templateA.ID, user.ID, account.Name, models.CampaignStatusDraft)
+	variantA := models.CampaignVariant{CampaignID: campaign.ID, Name: "A", TemplateID: templateA.ID, Weight: 1}
+	variantB := models.CampaignVariant{CampaignID: campaign.ID, Name: "B", TemplateID: templateB.ID, Weight: 1}
+	require.NoError(t, app.DB.Create(&variantA).Error)
+	require.NoError(t, app.DB.Create(&variantB).Error)
+	require.NoError(t, app.DB.Model(campaign).Updates(map[string]any{
+		"winner_after_hours":  2,
+		"winner_test_percent": 30,
+		"winner_metric":       models.VariantMetricDelivered,
+	}).Error)
+
+	const total = 50
+	for i := 0; i < total; i++ {
+		createTestRecipient(t, app, campaign.ID, fmt.Sprintf("1555%07d", i), models.MessageStatusPending)
+	}
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+	require.NoError(t, app.StartCampaign(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	testGroup := len(mockQueue.Jobs)
+	assert.Positive(t, testGroup)
+	assert.Less(t, testGroup, total)
+
+	// Variant B delivers better in the test group
+	for _, job := range mockQueue.Jobs {
+		require.NoError(t, app.DB.Model(&models.BulkMessageRecipient{}).Where("id = ?", job.RecipientID).Updates(map[string]any{
+			"status":     models.MessageStatusSent,
+			"sent_at":    time.Now(),
+			"variant_id": variantA.ID,
+		}).Error)
+	}
+	require.NoError(t, app.DB.Model(&models.BulkMessageRecipient{}).Where("id = ?", mockQueue.Jobs[0].RecipientID).Updates(map[string]any{
+		"status":     models.MessageStatusDelivered,
+		"variant_id": variantB.ID,
+	}).Error)
+
+	scheduler := handlers.NewCampaignScheduler(app, time.Minute)
+	assert.Equal(t, 0, scheduler.SendDueWinners(context.Background()), "winner is not due yet")
+
+	require.NoError(t, app.DB.Model(campaign).Update("started_at", time.Now().Add(-3*time.Hour)).Error)
+	assert.Equal(t, 1, scheduler.SendDueWinners(context.Background()))
+	assert.Len(t, mockQueue.Jobs, total)
+
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&updated, campaign.ID).Error)
+	require.NotNil(t, updated.WinnerVariantID)
+	assert.Equal(t, variantB.ID, *updated.WinnerVariantID)
+
+	// The winner is only sent once
+	assert.Equal(t, 0, scheduler.SendDueWinners(context.Background()))
+	assert.Len(t, mockQueue.Jobs, total)
+}
@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/abtest"
+	"github.com/shridarpatil/whatomate/internal/frequencycap"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/internal/senderpool"
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -19,6 +26,9 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// errCampaignStarted is returned when a campaign left draft/scheduled while being updated
+var errCampaignStarted = errors.New("campaign has already started")
+
 // CampaignRequest represents campaign create/update request
 type CampaignRequest struct {
 	Name            string     `json:"name" validate:"required"`
@@ -26,29 +36,59 @@ type CampaignRequest struct {
 	TemplateID      string     `json:"template_id" validate:"required"`
 	HeaderMediaID   string     `json:"header_media_id"`
 	ScheduledAt     *time.Time `json:"scheduled_at"`
+	// Sender pool: extra accounts to spread recipients over, and how
+	SenderAccounts []string              `json:"sender_accounts"`
+	SenderStrategy models.SenderStrategy `json:"sender_strategy"`
+	// Audience segment, resolved into recipients when the campaign starts,
+	// and the contact field each template parameter is filled from
+	SegmentID     *uuid.UUID        `json:"segment_id"`
+	SegmentParams map[string]string `json:"segment_params"`
+	// A/B test: template variants and the optional send-the-winner mode
+	Variants          []CampaignVariantRequest `json:"variants"`
+	WinnerAfterHours  int                      `json:"winner_after_hours"`
+	WinnerTestPercent int                      `json:"winner_test_percent"`
+	WinnerMetric      models.VariantMetric     `json:"winner_metric"`
+	// Frequency cap on marketing templates per contact, on top of the
+	// organization's, and whether capped recipients are skipped or deferred
+	FrequencyCapMax         int                       `json:"frequency_cap_max"`
+	FrequencyCapWindowHours int                       `json:"frequency_cap_window_hours"`
+	FrequencyCapAction      models.FrequencyCapAction `json:"frequency_cap_action"`
 }
 
 // CampaignResponse represents campaign in API responses
 type CampaignResponse struct {
-	ID                    uuid.UUID             `json:"id"`
-	Name                  string                `json:"name"`
-	WhatsAppAccount       string                `json:"whatsapp_account"`
-	TemplateID            uuid.UUID             `json:"template_id"`
-	TemplateName          string                `json:"template_name,omitempty"`
-	HeaderMediaID         string                `json:"header_media_id,omitempty"`
-	HeaderMediaFilename   string                `json:"header_media_filename,omitempty"`
-	HeaderMediaMimeType   string                `json:"header_media_mime_type,omitempty"`
-	Status                models.CampaignStatus `json:"status"`
-	TotalRecipients int                  `json:"total_recipients"`
-	SentCount       int                  `json:"sent_count"`
-	DeliveredCount  int                  `json:"delivered_count"`
-	ReadCount       int                  `json:"read_count"`
-	FailedCount     int                  `json:"failed_count"`
-	ScheduledAt     *time.Time           `json:"scheduled_at,omitempty"`
-	StartedAt       *time.Time           `json:"started_at,omitempty"`
-	CompletedAt     *time.Time           `json:"completed_at,omitempty"`
-	CreatedAt       time.Time            `json:"created_at"`
-	UpdatedAt       time.Time            `json:"updated_at"`
+	ID                      uuid.UUID                 `json:"id"`
+	Name                    string                    `json:"name"`
+	WhatsAppAccount         string                    `json:"whatsapp_account"`
+	TemplateID              uuid.UUID                 `json:"template_id"`
+	TemplateName            string                    `json:"template_name,omitempty"`
+	HeaderMediaID           string                    `json:"header_media_id,omitempty"`
+	HeaderMediaFilename     string                    `json:"header_media_filename,omitempty"`
+	HeaderMediaMimeType     string                    `json:"header_media_mime_type,omitempty"`
+	SenderAccounts          []string                  `json:"sender_accounts,omitempty"`
+	SenderStrategy          models.SenderStrategy     `json:"sender_strategy,omitempty"`
+	SegmentID               *uuid.UUID                `json:"segment_id,omitempty"`
+	SegmentParams           models.JSONB              `json:"segment_params,omitempty"`
+	Variants                []CampaignVariantResponse `json:"variants,omitempty"`
+	WinnerAfterHours        int                       `json:"winner_after_hours,omitempty"`
+	WinnerTestPercent       int                       `json:"winner_test_percent,omitempty"`
+	WinnerMetric            models.VariantMetric      `json:"winner_metric,omitempty"`
+	WinnerVariantID         *uuid.UUID                `json:"winner_variant_id,omitempty"`
+	FrequencyCapMax         int                       `json:"frequency_cap_max,omitempty"`
+	FrequencyCapWindowHours int                       `json:"frequency_cap_window_hours,omitempty"`
+	FrequencyCapAction      models.FrequencyCapAction `json:"frequency_cap_action,omitempty"`
+	RecurringCampaignID     *uuid.UUID                `json:"recurring_campaign_id,omitempty"`
+	Status                  models.CampaignStatus     `json:"status"`
+	TotalRecipients         int                       `json:"total_recipients"`
+	SentCount               int                       `json:"sent_count"`
+	DeliveredCount          int                       `json:"delivered_count"`
+	ReadCount               int                       `json:"read_count"`
+	FailedCount             int                       `json:"failed_count"`
+	ScheduledAt             *time.Time                `json:"scheduled_at,omitempty"`
+	StartedAt               *time.Time                `json:"started_at,omitempty"`
+	CompletedAt             *time.Time                `json:"completed_at,omitempty"`
+	CreatedAt               time.Time                 `json:"created_at"`
+	UpdatedAt               time.Time                 `json:"updated_at"`
 }
 
 // RecipientRequest represents recipient import request
@@ -96,7 +136,7 @@ func (a *App) ListCampaigns(r *fastglue.Request) error {
 	baseQuery.Model(&models.BulkMessageCampaign{}).Count(&total)
 
 	var campaigns []models.BulkMessageCampaign
-	if err := pg.Apply(baseQuery.
+	if err := pg.Apply(preloadVariants(baseQuery).
 		Preload("Template").
 		Order("created_at DESC")).
 		Find(&campaigns).Error; err != nil {
@@ -108,24 +148,37 @@ func (a *App) ListCampaigns(r *fastglue.Request) error {
 	response := make([]CampaignResponse, len(campaigns))
 	for i, c := range campaigns {
 		response[i] = CampaignResponse{
-			ID:                  c.ID,
-			Name:                c.Name,
-			WhatsAppAccount:     c.WhatsAppAccount,
-			TemplateID:          c.TemplateID,
-			HeaderMediaID:       c.HeaderMediaID,
-			HeaderMediaFilename: c.HeaderMediaFilename,
-			HeaderMediaMimeType: c.HeaderMediaMimeType,
-			Status:              c.Status,
-			TotalRecipients:     c.TotalRecipients,
-			SentCount:           c.SentCount,
-			DeliveredCount:      c.DeliveredCount,
-			ReadCount:           c.ReadCount,
-			FailedCount:         c.FailedCount,
-			ScheduledAt:         c.ScheduledAt,
-			StartedAt:           c.StartedAt,
-			CompletedAt:         c.CompletedAt,
-			CreatedAt:           c.CreatedAt,
-			UpdatedAt:           c.UpdatedAt,
+			ID:                      c.ID,
+			Name:                    c.Name,
+			WhatsAppAccount:         c.WhatsAppAccount,
+			TemplateID:              c.TemplateID,
+			HeaderMediaID:           c.HeaderMediaID,
+			HeaderMediaFilename:     c.HeaderMediaFilename,
+			HeaderMediaMimeType:     c.HeaderMediaMimeType,
+			SenderAccounts:          c.SenderAccounts,
+			SenderStrategy:          c.SenderStrategy,
+			SegmentID:               c.SegmentID,
+			SegmentParams:           c.SegmentParams,
+			Variants:                variantsToResponse(c.Variants),
+			WinnerAfterHours:        c.WinnerAfterHours,
+			WinnerTestPercent:       c.WinnerTestPercent,
+			WinnerMetric:            c.WinnerMetric,
+			WinnerVariantID:         c.WinnerVariantID,
+			FrequencyCapMax:         c.FrequencyCapMax,
+			FrequencyCapWindowHours: c.FrequencyCapWindowHours,
+			FrequencyCapAction:      c.FrequencyCapAction,
+			RecurringCampaignID:     c.RecurringCampaignID,
+			Status:                  c.Status,
+			TotalRecipients:         c.TotalRecipients,
+			SentCount:               c.SentCount,
+			DeliveredCount:          c.DeliveredCount,
+			ReadCount:               c.ReadCount,
+			FailedCount:             c.FailedCount,
+			ScheduledAt:             c.ScheduledAt,
+			StartedAt:               c.StartedAt,
+			CompletedAt:             c.CompletedAt,
+			CreatedAt:               c.CreatedAt,
+			UpdatedAt:               c.UpdatedAt,
 		}
 		if c.Template != nil {
 			response[i].TemplateName = c.Template.Name
@@ -152,57 +205,127 @@ func (a *App) CreateCampaign(r *fastglue.Request) error {
 		return nil
 	}
 
-	// Validate template exists
-	templateID, err := uuid.Parse(req.TemplateID)
+	// Validate WhatsApp account exists
+	account, err := a.resolveWhatsAppAccount(orgID, req.WhatsAppAccount)
 	if err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid template ID", nil, "")
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
 	}
 
-	template, err := findByIDAndOrg[models.Template](a.DB, r, templateID, orgID, "Template")
+	// With variants, the first variant's template stands in as the campaign's
+	// template and every variant's template must pass the checks below
+	var templates []*models.Template
+	var variants []models.CampaignVariant
+	if len(req.Variants) > 0 {
+		if variants, templates, err = a.validateCampaignVariants(orgID, req.Variants); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	} else {
+		// Validate template exists
+		templateID, err := uuid.Parse(req.TemplateID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid template ID", nil, "")
+		}
+		template, err := findByIDAndOrg[models.Template](a.DB, r, templateID, orgID, "Template")
+		if err != nil {
+			return nil
+		}
+		templates = []*models.Template{template}
+	}
+	template := templates[0]
+	winnerMetric, err := validateWinnerMode(req.WinnerAfterHours, req.WinnerTestPercent, req.WinnerMetric, len(variants))
 	if err != nil {
-		return nil
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
 	}
 
-	// Validate WhatsApp account exists
-	if _, err := a.resolveWhatsAppAccount(orgID, req.WhatsAppAccount); err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
+	capWindowHours, capAction, err := validateFrequencyCap(req.FrequencyCapMax, req.FrequencyCapWindowHours, req.FrequencyCapAction)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	var senderAccounts models.StringArray
+	var senderStrategy models.SenderStrategy
+	for _, t := range templates {
+		if senderAccounts, senderStrategy, err = a.validateSenderPool(orgID, account, t, req.SenderAccounts, req.SenderStrategy); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	}
+
+	var segmentParams models.JSONB
+	if req.SegmentID != nil {
+		for _, t := range templates {
+			if err := a.validateCampaignSegment(orgID, *req.SegmentID, t, req.SegmentParams); err != nil {
+				return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+			}
+		}
+		segmentParams = stringMapToJSONB(req.SegmentParams)
 	}
 
 	campaign := models.BulkMessageCampaign{
 		OrganizationID:  orgID,
 		WhatsAppAccount: req.WhatsAppAccount,
 		Name:            req.Name,
-		TemplateID:      templateID,
+		TemplateID:      template.ID,
 		HeaderMediaID:  req.HeaderMediaID,
-		Status:          models.CampaignStatusDraft,
+		SenderAccounts:  senderAccounts,
+		SenderStrategy:  senderStrategy,
+		SegmentID:       req.SegmentID,
+		SegmentParams:   segmentParams,
+		Variants:        variants,
+		Status:          campaignStatusForSchedule(req.ScheduledAt),
 		ScheduledAt:     req.ScheduledAt,
 		CreatedBy:       userID,
 	}
+	if capAction != "" {
+		campaign.FrequencyCapMax = req.FrequencyCapMax
+		campaign.FrequencyCapWindowHours = capWindowHours
+		campaign.FrequencyCapAction = capAction
+	}
+	if winnerMetric != "" {
+		campaign.WinnerAfterHours = req.WinnerAfterHours
+		campaign.WinnerTestPercent = req.WinnerTestPercent
+		campaign.WinnerMetric = winnerMetric
+	}
 
 	if err := a.DB.Create(&campaign).Error; err != nil {
 		a.Log.Error("Failed to create campaign", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create campaign", nil, "")
 	}
+	for i := range campaign.Variants {
+		campaign.Variants[i].Template = templates[i]
+	}
 
 	a.Log.Info("Campaign created", "campaign_id", campaign.ID, "name", campaign.Name)
 
 	return r.SendEnvelope(CampaignResponse{
-		ID:                  campaign.ID,
-		Name:                campaign.Name,
-		WhatsAppAccount:     campaign.WhatsAppAccount,
-		TemplateID:          campaign.TemplateID,
-		TemplateName:        template.Name,
-		HeaderMediaID:       campaign.HeaderMediaID,
-		HeaderMediaFilename: campaign.HeaderMediaFilename,
-		HeaderMediaMimeType: campaign.HeaderMediaMimeType,
-		Status:              campaign.Status,
-		TotalRecipients:     campaign.TotalRecipients,
-		SentCount:           campaign.SentCount,
-		DeliveredCount:      campaign.DeliveredCount,
-		FailedCount:         campaign.FailedCount,
-		ScheduledAt:         campaign.ScheduledAt,
-		CreatedAt:           campaign.CreatedAt,
-		UpdatedAt:           campaign.UpdatedAt,
+		ID:                      campaign.ID,
+		Name:                    campaign.Name,
+		WhatsAppAccount:         campaign.WhatsAppAccount,
+		TemplateID:              campaign.TemplateID,
+		TemplateName:            template.Name,
+		HeaderMediaID:           campaign.HeaderMediaID,
+		HeaderMediaFilename:     campaign.HeaderMediaFilename,
+		HeaderMediaMimeType:     campaign.HeaderMediaMimeType,
+		SenderAccounts:          campaign.SenderAccounts,
+		SenderStrategy:          campaign.SenderStrategy,
+		SegmentID:               campaign.SegmentID,
+		SegmentParams:           campaign.SegmentParams,
+		Variants:                variantsToResponse(campaign.Variants),
+		WinnerAfterHours:        campaign.WinnerAfterHours,
+		WinnerTestPercent:       campaign.WinnerTestPercent,
+		WinnerMetric:            campaign.WinnerMetric,
+		WinnerVariantID:         campaign.WinnerVariantID,
+		FrequencyCapMax:         campaign.FrequencyCapMax,
+		FrequencyCapWindowHours: campaign.FrequencyCapWindowHours,
+		FrequencyCapAction:      campaign.FrequencyCapAction,
+		RecurringCampaignID:     campaign.RecurringCampaignID,
+		Status:                  campaign.Status,
+		TotalRecipients:         campaign.TotalRecipients,
+		SentCount:               campaign.SentCount,
+		DeliveredCount:          campaign.DeliveredCount,
+		FailedCount:             campaign.FailedCount,
+		ScheduledAt:             campaign.ScheduledAt,
+		CreatedAt:               campaign.CreatedAt,
+		UpdatedAt:               campaign.UpdatedAt,
 	})
 }
 
@@ -219,30 +342,43 @@ func (a *App) GetCampaign(r *fastglue.Request) error {
 	}
 
 	var campaign models.BulkMessageCampaign
-	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
+	if err := preloadVariants(a.DB).Where("id = ? AND organization_id = ?", id, orgID).
 		Preload("Template").
 		First(&campaign).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Campaign not found", nil, "")
 	}
 
 	response := CampaignResponse{
-		ID:                  campaign.ID,
-		Name:                campaign.Name,
-		WhatsAppAccount:     campaign.WhatsAppAccount,
-		TemplateID:          campaign.TemplateID,
-		HeaderMediaID:       campaign.HeaderMediaID,
-		HeaderMediaFilename: campaign.HeaderMediaFilename,
-		HeaderMediaMimeType: campaign.HeaderMediaMimeType,
-		Status:              campaign.Status,
-		TotalRecipients:     campaign.TotalRecipients,
-		SentCount:           campaign.SentCount,
-		DeliveredCount:      campaign.DeliveredCount,
-		FailedCount:         campaign.FailedCount,
-		ScheduledAt:         campaign.ScheduledAt,
-		StartedAt:           campaign.StartedAt,
-		CompletedAt:         campaign.CompletedAt,
-		CreatedAt:           campaign.CreatedAt,
-		UpdatedAt:           campaign.UpdatedAt,
+		ID:                      campaign.ID,
+		Name:                    campaign.Name,
+		WhatsAppAccount:         campaign.WhatsAppAccount,
+		TemplateID:              campaign.TemplateID,
+		HeaderMediaID:           campaign.HeaderMediaID,
+		HeaderMediaFilename:     campaign.HeaderMediaFilename,
+		HeaderMediaMimeType:     campaign.HeaderMediaMimeType,
+		SenderAccounts:          campaign.SenderAccounts,
+		SenderStrategy:          campaign.SenderStrategy,
+		SegmentID:               campaign.SegmentID,
+		SegmentParams:           campaign.SegmentParams,
+		Variants:                variantsToResponse(campaign.Variants),
+		WinnerAfterHours:        campaign.WinnerAfterHours,
+		WinnerTestPercent:       campaign.WinnerTestPercent,
+		WinnerMetric:            campaign.WinnerMetric,
+		WinnerVariantID:         campaign.WinnerVariantID,
+		FrequencyCapMax:         campaign.FrequencyCapMax,
+		FrequencyCapWindowHours: campaign.FrequencyCapWindowHours,
+		FrequencyCapAction:      campaign.FrequencyCapAction,
+		RecurringCampaignID:     campaign.RecurringCampaignID,
+		Status:                  campaign.Status,
+		TotalRecipients:         campaign.TotalRecipients,
+		SentCount:               campaign.SentCount,
+		DeliveredCount:          campaign.DeliveredCount,
+		FailedCount:             campaign.FailedCount,
+		ScheduledAt:             campaign.ScheduledAt,
+		StartedAt:               campaign.StartedAt,
+		CompletedAt:             campaign.CompletedAt,
+		CreatedAt:               campaign.CreatedAt,
+		UpdatedAt:               campaign.UpdatedAt,
 	}
 	if campaign.Template != nil {
 		response.TemplateName = campaign.Template.Name
@@ -268,9 +404,9 @@ func (a *App) UpdateCampaign(r *fastglue.Request) error {
 		return nil
 	}
 
-	// Only allow updates to draft campaigns
-	if campaign.Status != models.CampaignStatusDraft {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only update draft campaigns", nil, "")
+	// Only allow updates to campaigns that haven't started yet
+	if !isEditableCampaignStatus(campaign.Status) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only update draft or scheduled campaigns", nil, "")
 	}
 
 	var req CampaignRequest
@@ -280,8 +416,17 @@ func (a *App) UpdateCampaign(r *fastglue.Request) error {
 
 	// Update fields
 	updates := map[string]interface{}{
-		"name":         req.Name,
-		"scheduled_at": req.ScheduledAt,
+		"name": req.Name,
+	}
+
+	// Only touch the schedule when the client sent it, so a rename from a form
+	// that doesn't carry scheduled_at doesn't silently unschedule the campaign
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &fields); err == nil {
+		if _, ok := fields["scheduled_at"]; ok {
+			updates["scheduled_at"] = req.ScheduledAt
+			updates["status"] = campaignStatusForSchedule(req.ScheduledAt)
+		}
 	}
 
 	if req.TemplateID != "" {
@@ -296,30 +441,215 @@ func (a *App) UpdateCampaign(r *fastglue.Request) error {
 		updates["whats_app_account"] = req.WhatsAppAccount
 	}
 
-	if err := a.DB.Model(campaign).Updates(updates).Error; err != nil {
+	// A/B variants, when sent, replace the campaign's variant set. The first
+	// variant's template stands in as the campaign's template.
+	var existingVariants []models.CampaignVariant
+	a.DB.Where("campaign_id = ?", campaign.ID).Preload("Template").Order("name ASC").Find(&existingVariants)
+	var variants []models.CampaignVariant
+	var variantTemplates []*models.Template
+	_, variantsChanged := fields["variants"]
+	if variantsChanged && len(req.Variants) > 0 {
+		if variants, variantTemplates, err = a.validateCampaignVariants(orgID, req.Variants); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		updates["template_id"] = variantTemplates[0].ID
+	} else if !variantsChanged && len(existingVariants) > 0 {
+		updates["template_id"] = existingVariants[0].TemplateID
+		for i := range existingVariants {
+			variantTemplates = append(variantTemplates, existingVariants[i].Template)
+		}
+	}
+	templatesChanged := req.TemplateID != "" || variantsChanged
+
+	// The templates the campaign will send after this update
+	campaignTemplates := func() ([]*models.Template, error) {
+		if len(variantTemplates) > 0 {
+			for _, t := range variantTemplates {
+				if t == nil {
+					return nil, fmt.Errorf("Template not found")
+				}
+			}
+			return variantTemplates, nil
+		}
+		templateID := campaign.TemplateID
+		if id, ok := updates["template_id"].(uuid.UUID); ok {
+			templateID = id
+		}
+		var template models.Template
+		if err := a.DB.Where("id = ? AND organization_id = ?", templateID, orgID).First(&template).Error; err != nil {
+			return nil, fmt.Errorf("Template not found")
+		}
+		return []*models.Template{&template}, nil
+	}
+
+	// Winner mode needs variants, so it is checked against the variant count
+	// the campaign will have
+	if _, ok := fields["winner_after_hours"]; ok || variantsChanged {
+		afterHours, testPercent, metric := campaign.WinnerAfterHours, campaign.WinnerTestPercent, campaign.WinnerMetric
+		if ok {
+			afterHours = req.WinnerAfterHours
+		}
+		if _, ok := fields["winner_test_percent"]; ok {
+			testPercent = req.WinnerTestPercent
+		}
+		if _, ok := fields["winner_metric"]; ok {
+			metric = req.WinnerMetric
+		}
+		metric, err := validateWinnerMode(afterHours, testPercent, metric, len(variantTemplates))
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		if metric == "" {
+			afterHours, testPercent = 0, 0
+		}
+		updates["winner_after_hours"] = afterHours
+		updates["winner_test_percent"] = testPercent
+		updates["winner_metric"] = metric
+	}
+
+	if _, ok := fields["frequency_cap_max"]; ok {
+		maxSends, windowHours, action := req.FrequencyCapMax, campaign.FrequencyCapWindowHours, campaign.FrequencyCapAction
+		if _, ok := fields["frequency_cap_window_hours"]; ok {
+			windowHours = req.FrequencyCapWindowHours
+		}
+		if _, ok := fields["frequency_cap_action"]; ok {
+			action = req.FrequencyCapAction
+		}
+		windowHours, action, err := validateFrequencyCap(maxSends, windowHours, action)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		if action == "" {
+			maxSends = 0
+		}
+		updates["frequency_cap_max"] = maxSends
+		updates["frequency_cap_window_hours"] = windowHours
+		updates["frequency_cap_action"] = action
+	}
+
+	// Re-check the sender pool whenever it, the template or the campaign's own
+	// account changes, since pool accounts must be able to send the template
+	senderAccounts := []string(campaign.SenderAccounts)
+	senderStrategy := campaign.SenderStrategy
+	_, poolChanged := fields["sender_accounts"]
+	if poolChanged {
+		senderAccounts = req.SenderAccounts
+	}
+	if _, ok := fields["sender_strategy"]; ok {
+		senderStrategy = req.SenderStrategy
+		poolChanged = true
+	}
+	if len(senderAccounts) > 0 && (poolChanged || templatesChanged || req.WhatsAppAccount != "") {
+		accountName := campaign.WhatsAppAccount
+		if req.WhatsAppAccount != "" {
+			accountName = req.WhatsAppAccount
+		}
+		account, err := a.resolveWhatsAppAccount(orgID, accountName)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
+		}
+		templates, err := campaignTemplates()
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		for _, template := range templates {
+			pool, strategy, err := a.validateSenderPool(orgID, account, template, senderAccounts, senderStrategy)
+			if err != nil {
+				return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+			}
+			updates["sender_accounts"] = pool
+			updates["sender_strategy"] = strategy
+		}
+	} else if poolChanged {
+		updates["sender_accounts"] = nil
+		updates["sender_strategy"] = ""
+	}
+
+	// Likewise the segment's parameter mapping must cover the template
+	segmentID := campaign.SegmentID
+	segmentParams := jsonbToStringMap(campaign.SegmentParams)
+	_, segmentChanged := fields["segment_id"]
+	if segmentChanged {
+		segmentID = req.SegmentID
+	}
+	if _, ok := fields["segment_params"]; ok {
+		segmentParams = req.SegmentParams
+		segmentChanged = true
+	}
+	if segmentID != nil && (segmentChanged || templatesChanged) {
+		templates, err := campaignTemplates()
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		for _, template := range templates {
+			if err := a.validateCampaignSegment(orgID, *segmentID, template, segmentParams); err != nil {
+				return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+			}
+		}
+		updates["segment_id"] = segmentID
+		updates["segment_params"] = stringMapToJSONB(segmentParams)
+	} else if segmentChanged {
+		updates["segment_id"] = nil
+		updates["segment_params"] = nil
+	}
+
+	// Conditional on status so an update racing the scheduler's claim can't move a
+	// queued/processing campaign back to draft or scheduled
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(campaign).
+			Where("status IN ?", []models.CampaignStatus{models.CampaignStatusDraft, models.CampaignStatusScheduled}).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errCampaignStarted
+		}
+		if variantsChanged {
+			return replaceCampaignVariants(tx, campaign.ID, variants)
+		}
+		return nil
+	})
+	if errors.Is(err, errCampaignStarted) {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Campaign has already started", nil, "")
+	}
+	if err != nil {
 		a.Log.Error("Failed to update campaign", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update campaign", nil, "")
 	}
 
 	// Reload campaign
-	a.DB.Where("id = ?", id).Preload("Template").First(campaign)
+	preloadVariants(a.DB).Where("id = ?", id).Preload("Template").First(campaign)
 
 	response := CampaignResponse{
-		ID:                  campaign.ID,
-		Name:                campaign.Name,
-		WhatsAppAccount:     campaign.WhatsAppAccount,
-		TemplateID:          campaign.TemplateID,
-		HeaderMediaID:       campaign.HeaderMediaID,
-		HeaderMediaFilename: campaign.HeaderMediaFilename,
-		HeaderMediaMimeType: campaign.HeaderMediaMimeType,
-		Status:              campaign.Status,
-		TotalRecipients:     campaign.TotalRecipients,
-		SentCount:           campaign.SentCount,
-		DeliveredCount:      campaign.DeliveredCount,
-		FailedCount:         campaign.FailedCount,
-		ScheduledAt:         campaign.ScheduledAt,
-		CreatedAt:           campaign.CreatedAt,
-		UpdatedAt:           campaign.UpdatedAt,
+		ID:                      campaign.ID,
+		Name:                    campaign.Name,
+		WhatsAppAccount:         campaign.WhatsAppAccount,
+		TemplateID:              campaign.TemplateID,
+		HeaderMediaID:           campaign.HeaderMediaID,
+		HeaderMediaFilename:     campaign.HeaderMediaFilename,
+		HeaderMediaMimeType:     campaign.HeaderMediaMimeType,
+		SenderAccounts:          campaign.SenderAccounts,
+		SenderStrategy:          campaign.SenderStrategy,
+		SegmentID:               campaign.SegmentID,
+		SegmentParams:           campaign.SegmentParams,
+		Variants:                variantsToResponse(campaign.Variants),
+		WinnerAfterHours:        campaign.WinnerAfterHours,
+		WinnerTestPercent:       campaign.WinnerTestPercent,
+		WinnerMetric:            campaign.WinnerMetric,
+		WinnerVariantID:         campaign.WinnerVariantID,
+		FrequencyCapMax:         campaign.FrequencyCapMax,
+		FrequencyCapWindowHours: campaign.FrequencyCapWindowHours,
+		FrequencyCapAction:      campaign.FrequencyCapAction,
+		RecurringCampaignID:     campaign.RecurringCampaignID,
+		Status:                  campaign.Status,
+		TotalRecipients:         campaign.TotalRecipients,
+		SentCount:               campaign.SentCount,
+		DeliveredCount:          campaign.DeliveredCount,
+		FailedCount:             campaign.FailedCount,
+		ScheduledAt:             campaign.ScheduledAt,
+		CreatedAt:               campaign.CreatedAt,
+		UpdatedAt:               campaign.UpdatedAt,
 	}
 	if campaign.Template != nil {
 		response.TemplateName = campaign.Template.Name
@@ -391,26 +721,74 @@ func (a *App) StartCampaign(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Campaign cannot be started in current state", nil, "")
 	}
 
+	// Validate template still exists
+	if campaign.TemplateID != uuid.Nil {
+		var template models.Template
+		if err := a.DB.Where("id = ? AND organization_id = ?", campaign.TemplateID, orgID).First(&template).Error; err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Campaign template no longer exists", nil, "")
+		}
+	}
+
+	// Claim the campaign with a conditional update, the same way the scheduler does,
+	// so a manual start racing a scheduled dispatch can't enqueue recipients twice
+	originalStatus := campaign.Status
+	result := a.DB.Model(&models.BulkMessageCampaign{}).
+		Where("id = ? AND status IN ?", campaign.ID, []models.CampaignStatus{models.CampaignStatusDraft, models.CampaignStatusScheduled, models.CampaignStatusPaused}).
+		Update("status", models.CampaignStatusQueued)
+	if result.Error != nil {
+		a.Log.Error("Failed to claim campaign", "error", result.Error, "campaign_id", id)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to start campaign", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Campaign has already been started", nil, "")
+	}
+	campaign.Status = models.CampaignStatusQueued
+
+	// A segment audience is resolved on the first start only; resuming a
+	// paused campaign sends to the recipients it already has
+	var segmentAdded, segmentSkipped int
+	if campaign.SegmentID != nil && originalStatus != models.CampaignStatusPaused {
+		if segmentAdded, segmentSkipped, err = a.resolveCampaignSegment(campaign); err != nil {
+			a.Log.Error("Failed to resolve campaign segment", "error", err, "campaign_id", id)
+			a.DB.Model(campaign).Update("status", originalStatus)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to resolve campaign segment", nil, "")
+		}
+	}
+
 	// Get all pending recipients
 	var recipients []models.BulkMessageRecipient
 	if err := a.DB.Where("campaign_id = ? AND status = ?", id, models.MessageStatusPending).Find(&recipients).Error; err != nil {
 		a.Log.Error("Failed to load recipients", "error", err)
+		a.DB.Model(campaign).Update("status", originalStatus)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load recipients", nil, "")
 	}
 
 	if len(recipients) == 0 {
+		a.DB.Model(campaign).Update("status", originalStatus)
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Campaign has no pending recipients", nil, "")
 	}
 
-	// Validate template still exists
-	if campaign.TemplateID != uuid.Nil {
-		var template models.Template
-		if err := a.DB.Where("id = ? AND organization_id = ?", campaign.TemplateID, orgID).First(&template).Error; err != nil {
-			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Campaign template no longer exists", nil, "")
-		}
+	if err := a.enqueueCampaignRecipients(r.RequestCtx, campaign, recipients); err != nil {
+		a.Log.Error("Failed to start campaign", "error", err)
+		// Revert to the status the campaign had before it was claimed
+		a.DB.Model(campaign).Update("status", originalStatus)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to queue recipients", nil, "")
+	}
+
+	resp := map[string]interface{}{
+		"message": "Campaign started",
+		"status":  models.CampaignStatusProcessing,
 	}
+	if campaign.SegmentID != nil && originalStatus != models.CampaignStatusPaused {
+		resp["segment_added"] = segmentAdded
+		resp["segment_skipped"] = segmentSkipped
+	}
+	return r.SendEnvelope(resp)
+}
 
-	// Update status to processing
+// enqueueCampaignRecipients marks a campaign as processing and enqueues a job per recipient.
+// Callers are responsible for reverting the campaign status if this returns an error.
+func (a *App) enqueueCampaignRecipients(ctx context.Context, campaign *models.BulkMessageCampaign, recipients []models.BulkMessageRecipient) error {
 	now := time.Now()
 	updates := map[string]interface{}{
 		"status":     models.CampaignStatusProcessing,
@@ -418,38 +796,140 @@ func (a *App) StartCampaign(r *fastglue.Request) error {
 	}
 
 	if err := a.DB.Model(campaign).Updates(updates).Error; err != nil {
-		a.Log.Error("Failed to start campaign", "error", err)
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to start campaign", nil, "")
+		return fmt.Errorf("failed to update campaign status: %w", err)
 	}
 
-	a.Log.Info("Campaign started", "campaign_id", id, "recipients", len(recipients))
+	a.Log.Info("Campaign started", "campaign_id", campaign.ID, "recipients", len(recipients))
+
+	// Until an A/B test has a winner, only its test group is sent; the
+	// scheduler enqueues the rest once the winner is picked
+	if campaign.WinnerAfterHours > 0 && campaign.WinnerVariantID == nil {
+		testGroup := recipients[:0:0]
+		for _, recipient := range recipients {
+			if abtest.InTestGroup(campaign, recipient.PhoneNumber) {
+				testGroup = append(testGroup, recipient)
+			}
+		}
+		a.Log.Info("Holding recipients for A/B test winner", "campaign_id", campaign.ID, "held", len(recipients)-len(testGroup))
+		recipients = testGroup
+	}
+
+	return a.enqueueRecipientJobs(ctx, campaign, recipients)
+}
+
+// enqueueRecipientJobs enqueues a job per recipient for parallel processing
+func (a *App) enqueueRecipientJobs(ctx context.Context, campaign *models.BulkMessageCampaign, recipients []models.BulkMessageRecipient) error {
+	if len(recipients) == 0 {
+		return nil
+	}
 
-	// Enqueue all recipients as individual jobs for parallel processing
 	jobs := make([]*queue.RecipientJob, len(recipients))
 	for i, recipient := range recipients {
 		jobs[i] = &queue.RecipientJob{
-			CampaignID:     id,
+			CampaignID:     campaign.ID,
 			RecipientID:    recipient.ID,
-			OrganizationID: orgID,
+			OrganizationID: campaign.OrganizationID,
 			PhoneNumber:    recipient.PhoneNumber,
 			RecipientName:  recipient.RecipientName,
 			TemplateParams: recipient.TemplateParams,
 		}
 	}
 
-	if err := a.Queue.EnqueueRecipients(r.RequestCtx, jobs); err != nil {
-		a.Log.Error("Failed to enqueue recipients", "error", err)
-		// Revert status on failure
-		a.DB.Model(campaign).Update("status", models.CampaignStatusDraft)
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to queue recipients", nil, "")
+	if err := a.Queue.EnqueueRecipients(ctx, jobs); err != nil {
+		return fmt.Errorf("failed to enqueue recipients: %w", err)
 	}
 
-	a.Log.Info("Recipients enqueued for processing", "campaign_id", id, "count", len(jobs))
+	a.Log.Info("Recipients enqueued for processing", "campaign_id", campaign.ID, "count", len(jobs))
+	return nil
+}
 
-	return r.SendEnvelope(map[string]interface{}{
-		"message": "Campaign started",
-		"status":  models.CampaignStatusProcessing,
-	})
+// isEditableCampaignStatus reports whether a campaign's settings and recipients can still be changed
+func isEditableCampaignStatus(status models.CampaignStatus) bool {
+	return status == models.CampaignStatusDraft || status == models.CampaignStatusScheduled
+}
+
+// campaignStatusForSchedule returns the status a not-yet-started campaign should have
+// given its scheduled start time: scheduled when it is in the future, draft otherwise.
+func campaignStatusForSchedule(scheduledAt *time.Time) models.CampaignStatus {
+	if scheduledAt != nil && scheduledAt.After(time.Now()) {
+		return models.CampaignStatusScheduled
+	}
+	return models.CampaignStatusDraft
+}
+
+// validateSenderPool checks a campaign's sender pool and returns it without
+// duplicates or the campaign's own account, plus the strategy to store. Every
+// pool account must belong to the organization and be able to send the
+// campaign's template: either it shares the template's business account, or
+// it has its own approved template with the same name and language.
+func (a *App) validateSenderPool(orgID uuid.UUID, account *models.WhatsAppAccount, template *models.Template, names []string, strategy models.SenderStrategy) (models.StringArray, models.SenderStrategy, error) {
+	var pool models.StringArray
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && name != account.Name && !slices.Contains(pool, name) {
+			pool = append(pool, name)
+		}
+	}
+	if len(pool) == 0 {
+		return nil, "", nil
+	}
+
+	if strategy == "" {
+		strategy = models.SenderStrategyRoundRobin
+	}
+	if !senderpool.ValidStrategy(strategy) {
+		return nil, "", fmt.Errorf("Invalid sender strategy: %s", strategy)
+	}
+
+	var accounts []models.WhatsAppAccount
+	if err := a.DB.Where("organization_id = ? AND name IN ?", orgID, []string(pool)).Find(&accounts).Error; err != nil {
+		return nil, "", fmt.Errorf("Failed to load sender accounts")
+	}
+	if len(accounts) != len(pool) {
+		return nil, "", fmt.Errorf("Sender account not found")
+	}
+
+	for _, acc := range accounts {
+		if acc.BusinessID == account.BusinessID {
+			continue
+		}
+		var count int64
+		a.DB.Model(&models.Template{}).
+			Where("organization_id = ? AND whats_app_account = ? AND name = ? AND language = ? AND status = ?",
+				orgID, acc.Name, template.Name, template.Language, string(models.TemplateStatusApproved)).
+			Count(&count)
+		if count == 0 {
+			return nil, "", fmt.Errorf("Template %s (%s) is not approved for sender account %s", template.Name, template.Language, acc.Name)
+		}
+	}
+
+	return pool, strategy, nil
+}
+
+// validateFrequencyCap checks a campaign's frequency cap and returns its
+// window and action with defaults applied. A cap of 0 turns it off, in which
+// case the action returned is empty.
+func validateFrequencyCap(maxSends, windowHours int, action models.FrequencyCapAction) (int, models.FrequencyCapAction, error) {
+	if maxSends == 0 {
+		return 0, "", nil
+	}
+	if maxSends < 0 {
+		return 0, "", fmt.Errorf("Frequency cap cannot be negative")
+	}
+	if windowHours == 0 {
+		windowHours = frequencycap.DefaultWindowHours
+	}
+	if windowHours < 0 || windowHours > frequencycap.MaxWindowHours {
+		return 0, "", fmt.Errorf("Frequency cap window must be between 1 and %d hours", frequencycap.MaxWindowHours)
+	}
+	switch action {
+	case "":
+		action = models.FrequencyCapActionSkip
+	case models.FrequencyCapActionSkip, models.FrequencyCapActionDefer:
+	default:
+		return 0, "", fmt.Errorf("Invalid frequency cap action")
+	}
+	return windowHours, action, nil
 }
 
 // PauseCampaign implements pausing a campaign
@@ -629,8 +1109,8 @@ func (a *App) ImportRecipients(r *fastglue.Request) error {
 		return nil
 	}
 
-	if campaign.Status != models.CampaignStatusDraft {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only add recipients to draft campaigns", nil, "")
+	if !isEditableCampaignStatus(campaign.Status) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only add recipients to draft or scheduled campaigns", nil, "")
 	}
 
 	var req struct {
@@ -725,14 +1205,14 @@ func (a *App) DeleteCampaignRecipient(r *fastglue.Request) error {
 		return nil
 	}
 
-	// Verify campaign belongs to org and is in draft status
+	// Verify campaign belongs to org and hasn't started yet
 	campaign, err := findByIDAndOrg[models.BulkMessageCampaign](a.DB, r, campaignUUID, orgID, "Campaign")
 	if err != nil {
 		return nil
 	}
 
-	if campaign.Status != models.CampaignStatusDraft {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only delete recipients from draft campaigns", nil, "")
+	if !isEditableCampaignStatus(campaign.Status) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only delete recipients from draft or scheduled campaigns", nil, "")
 	}
 
 	// Verify recipient belongs to campaign and delete
@@ -790,21 +1270,70 @@ func (a *App) UploadCampaignMedia(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
 	}
 
+	media, err := a.uploadHeaderMedia(r, account)
+	if err != nil {
+		return nil
+	}
+
+	// Save file locally for preview
+	localPath, err := a.saveCampaignMedia(campaignUUID.String(), media.data, media.mimeType)
+	if err != nil {
+		a.Log.Error("Failed to save media locally", "error", err)
+		// Don't fail the request, just log the error - preview won't work
+	}
+
+	// Update campaign with media ID, filename, mime type, and local path
+	updates := map[string]interface{}{
+		"header_media_id":         media.mediaID,
+		"header_media_filename":   sanitizeFilename(media.filename),
+		"header_media_mime_type":  media.mimeType,
+		"header_media_local_path": localPath,
+	}
+	if err := a.DB.Model(&campaign).Updates(updates).Error; err != nil {
+		a.Log.Error("Failed to update campaign with media info", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save media info", nil, "")
+	}
+
+	a.Log.Info("Campaign media uploaded", "campaign_id", campaignUUID, "media_id", media.mediaID, "filename", media.filename, "local_path", localPath)
+
+	return r.SendEnvelope(map[string]interface{}{
+		"media_id":   media.mediaID,
+		"filename":   media.filename,
+		"mime_type":  media.mimeType,
+		"local_path": localPath,
+		"message":    "Media uploaded successfully",
+	})
+}
+
+// headerMediaUpload is a template header file uploaded to WhatsApp
+type headerMediaUpload struct {
+	mediaID  string
+	filename string
+	mimeType string
+	data     []byte
+}
+
+// uploadHeaderMedia reads the multipart "file" field, validates it and
+// uploads it to WhatsApp through account. On error the response has been sent.
+func (a *App) uploadHeaderMedia(r *fastglue.Request, account *models.WhatsAppAccount) (*headerMediaUpload, error) {
 	// Parse multipart form
 	form, err := r.RequestCtx.MultipartForm()
 	if err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid multipart form", nil, "")
+		_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid multipart form", nil, "")
+		return nil, errEnvelopeSent
 	}
 
 	files := form.File["file"]
 	if len(files) == 0 {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No file provided", nil, "")
+		_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No file provided", nil, "")
+		return nil, errEnvelopeSent
 	}
 
 	fileHeader := files[0]
 	file, err := fileHeader.Open()
 	if err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to open file", nil, "")
+		_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to open file", nil, "")
+		return nil, errEnvelopeSent
 	}
 	defer func() { _ = file.Close() }()
 
@@ -812,10 +1341,12 @@ func (a *App) UploadCampaignMedia(r *fastglue.Request) error {
 	const maxMediaSize = 16 << 20 // 16MB
 	data, err := io.ReadAll(io.LimitReader(file, maxMediaSize+1))
 	if err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file", nil, "")
+		_ = r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file", nil, "")
+		return nil, errEnvelopeSent
 	}
 	if len(data) > maxMediaSize {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "File too large. Maximum size is 16MB", nil, "")
+		_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "File too large. Maximum size is 16MB", nil, "")
+		return nil, errEnvelopeSent
 	}
 
 	// Determine and validate MIME type
@@ -832,7 +1363,8 @@ func (a *App) UploadCampaignMedia(r *fastglue.Request) error {
 		"application/vnd.openxmlformats-officedocument.presentationml.presentation": true,
 	}
 	if !allowedMIME[mimeType] {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Unsupported file type: "+mimeType, nil, "")
+		_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Unsupported file type: "+mimeType, nil, "")
+		return nil, errEnvelopeSent
 	}
 
 	// Upload to WhatsApp
@@ -842,37 +1374,11 @@ func (a *App) UploadCampaignMedia(r *fastglue.Request) error {
 	mediaID, err := a.WhatsApp.UploadMedia(ctx, waAccount, data, mimeType, fileHeader.Filename)
 	if err != nil {
 		a.Log.Error("Failed to upload media to WhatsApp", "error", err)
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to upload media to WhatsApp", nil, "")
+		_ = r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to upload media to WhatsApp", nil, "")
+		return nil, errEnvelopeSent
 	}
 
-	// Save file locally for preview
-	localPath, err := a.saveCampaignMedia(campaignUUID.String(), data, mimeType)
-	if err != nil {
-		a.Log.Error("Failed to save media locally", "error", err)
-		// Don't fail the request, just log the error - preview won't work
-	}
-
-	// Update campaign with media ID, filename, mime type, and local path
-	updates := map[string]interface{}{
-		"header_media_id":         mediaID,
-		"header_media_filename":   sanitizeFilename(fileHeader.Filename),
-		"header_media_mime_type":  mimeType,
-		"header_media_local_path": localPath,
-	}
-	if err := a.DB.Model(&campaign).Updates(updates).Error; err != nil {
-		a.Log.Error("Failed to update campaign with media info", "error", err)
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save media info", nil, "")
-	}
-
-	a.Log.Info("Campaign media uploaded", "campaign_id", campaignUUID, "media_id", mediaID, "filename", fileHeader.Filename, "local_path", localPath)
-
-	return r.SendEnvelope(map[string]interface{}{
-		"media_id":   mediaID,
-		"filename":   fileHeader.Filename,
-		"mime_type":  mimeType,
-		"local_path": localPath,
-		"message":    "Media uploaded successfully",
-	})
+	return &headerMediaUpload{mediaID: mediaID, filename: fileHeader.Filename, mimeType: mimeType, data: data}, nil
 }
 
 // saveCampaignMedia saves uploaded media locally for preview
@@ -925,13 +1431,18 @@ func (a *App) ServeCampaignMedia(r *fastglue.Request) error {
 		return nil
 	}
 
+	return a.serveCampaignMediaFile(r, campaign.HeaderMediaLocalPath, campaign.HeaderMediaMimeType)
+}
+
+// serveCampaignMediaFile writes a stored campaign media file to the response
+func (a *App) serveCampaignMediaFile(r *fastglue.Request, localPath, mimeType string) error {
 	// Check if campaign has media
-	if campaign.HeaderMediaLocalPath == "" {
+	if localPath == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "No media found", nil, "")
 	}
 
 	// Security: prevent directory traversal and symlink attacks
-	filePath := filepath.Clean(campaign.HeaderMediaLocalPath)
+	filePath := filepath.Clean(localPath)
 	baseDir, err := filepath.Abs(a.getMediaStoragePath())
 	if err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Storage configuration error", nil, "")
@@ -958,7 +1469,7 @@ func (a *App) ServeCampaignMedia(r *fastglue.Request) error {
 	}
 
 	// Use stored mime type or determine from extension
-	contentType := campaign.HeaderMediaMimeType
+	contentType := mimeType
 	if contentType == "" {
 		ext := strings.ToLower(filepath.Ext(filePath))
 		contentType = getMimeTypeFromExtension(ext)
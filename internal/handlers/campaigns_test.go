@@ -2,6 +2,7 @@ package handlers_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
 )
 
 // createTestCampaign creates a test campaign in the database.
@@ -181,6 +183,7 @@ func TestApp_CreateCampaign_WithScheduledAt(t *testing.T) {
 	err = json.Unmarshal(testutil.GetResponseBody(req), &resp)
 	require.NoError(t, err)
 	assert.NotNil(t, resp.Data.ScheduledAt)
+	assert.Equal(t, models.CampaignStatusScheduled, resp.Data.Status)
 }
 
 func TestApp_CreateCampaign_InvalidTemplateID(t *testing.T) {
@@ -366,6 +369,254 @@ func TestApp_UpdateCampaign_NotDraft(t *testing.T) {
 	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
 }
 
+func TestApp_UpdateCampaign_RenameKeepsSchedule(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("update-scheduled")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("update-scheduled-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusScheduled)
+	scheduledAt := time.Now().Add(time.Hour)
+	require.NoError(t, app.DB.Model(campaign).Update("scheduled_at", scheduledAt).Error)
+
+	// The edit form doesn't send scheduled_at
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"name":             "Renamed Campaign",
+		"whatsapp_account": account.Name,
+		"template_id":      template.ID.String(),
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+
+	err := app.UpdateCampaign(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("id = ?", campaign.ID).First(&updated).Error)
+	assert.Equal(t, "Renamed Campaign", updated.Name)
+	assert.Equal(t, models.CampaignStatusScheduled, updated.Status)
+	require.NotNil(t, updated.ScheduledAt)
+	assert.WithinDuration(t, scheduledAt, *updated.ScheduledAt, time.Second)
+}
+
+func TestApp_UpdateCampaign_ClearScheduleRevertsToDraft(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("update-unschedule")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("update-unschedule-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusScheduled)
+	require.NoError(t, app.DB.Model(campaign).Update("scheduled_at", time.Now().Add(time.Hour)).Error)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"name":             campaign.Name,
+		"whatsapp_account": account.Name,
+		"template_id":      template.ID.String(),
+		"scheduled_at":     nil,
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+
+	err := app.UpdateCampaign(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("id = ?", campaign.ID).First(&updated).Error)
+	assert.Equal(t, models.CampaignStatusDraft, updated.Status)
+	assert.Nil(t, updated.ScheduledAt)
+}
+
+func TestApp_CreateCampaign_SenderPool(t *testing.T) {
+	app := newTestApp(t, withQueue(testutil.NewMockQueue()))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("pool-campaign")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	// Same business account: can send the template as is
+	sameWABA := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, func(a *models.WhatsAppAccount) {
+		a.BusinessID = account.BusinessID
+	})
+	// Other business account with the template approved there too
+	otherWABA := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID)
+	copied := testutil.CreateTestTemplate(t, app.DB, org.ID, otherWABA.Name)
+	require.NoError(t, app.DB.Model(copied).Update("name", template.Name).Error)
+	// Other business account without the template
+	missing := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID)
+
+	create := func(body map[string]interface{}) *fastglue.Request {
+		body["name"] = "Pool Campaign"
+		body["whatsapp_account"] = account.Name
+		body["template_id"] = template.ID.String()
+		req := testutil.NewJSONRequest(t, body)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.CreateCampaign(req))
+		return req
+	}
+
+	req := create(map[string]interface{}{
+		"sender_accounts": []string{sameWABA.Name, otherWABA.Name, account.Name, sameWABA.Name},
+	})
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var resp struct {
+		Data handlers.CampaignResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, []string{sameWABA.Name, otherWABA.Name}, resp.Data.SenderAccounts)
+	assert.Equal(t, models.SenderStrategyRoundRobin, resp.Data.SenderStrategy)
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+		want string
+	}{
+		{"unknown account", map[string]interface{}{"sender_accounts": []string{"no-such-account"}}, "Sender account not found"},
+		{"template not approved", map[string]interface{}{"sender_accounts": []string{missing.Name}}, "is not approved for sender account"},
+		{"invalid strategy", map[string]interface{}{"sender_accounts": []string{sameWABA.Name}, "sender_strategy": "random"}, "Invalid sender strategy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := create(tt.body)
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+			assert.Contains(t, string(testutil.GetResponseBody(req)), tt.want)
+		})
+	}
+}
+
+func TestApp_UpdateCampaign_SenderPool(t *testing.T) {
+	app := newTestApp(t, withQueue(testutil.NewMockQueue()))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("pool-update")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	second := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, func(a *models.WhatsAppAccount) {
+		a.BusinessID = account.BusinessID
+	})
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusDraft)
+
+	update := func(body map[string]interface{}) handlers.CampaignResponse {
+		req := testutil.NewJSONRequest(t, body)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", campaign.ID.String())
+		require.NoError(t, app.UpdateCampaign(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		var resp struct {
+			Data handlers.CampaignResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		return resp.Data
+	}
+
+	resp := update(map[string]interface{}{
+		"name":            campaign.Name,
+		"sender_accounts": []string{second.Name},
+		"sender_strategy": models.SenderStrategySticky,
+	})
+	assert.Equal(t, []string{second.Name}, resp.SenderAccounts)
+	assert.Equal(t, models.SenderStrategySticky, resp.SenderStrategy)
+
+	// A rename keeps the pool
+	resp = update(map[string]interface{}{"name": "Renamed"})
+	assert.Equal(t, []string{second.Name}, resp.SenderAccounts)
+
+	// An empty list removes it
+	resp = update(map[string]interface{}{"name": "Renamed", "sender_accounts": []string{}})
+	assert.Empty(t, resp.SenderAccounts)
+	assert.Empty(t, resp.SenderStrategy)
+}
+
+func TestApp_CreateCampaign_FrequencyCap(t *testing.T) {
+	app := newTestApp(t, withQueue(testutil.NewMockQueue()))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("cap-campaign")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	create := func(body map[string]interface{}) *fastglue.Request {
+		body["name"] = "Capped Campaign"
+		body["whatsapp_account"] = account.Name
+		body["template_id"] = template.ID.String()
+		req := testutil.NewJSONRequest(t, body)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.CreateCampaign(req))
+		return req
+	}
+
+	// The window and action default when only a cap is given
+	req := create(map[string]interface{}{"frequency_cap_max": 2})
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var resp struct {
+		Data handlers.CampaignResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, 2, resp.Data.FrequencyCapMax)
+	assert.Equal(t, 24, resp.Data.FrequencyCapWindowHours)
+	assert.Equal(t, models.FrequencyCapActionSkip, resp.Data.FrequencyCapAction)
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+		want string
+	}{
+		{"negative cap", map[string]interface{}{"frequency_cap_max": -1}, "cannot be negative"},
+		{"window too long", map[string]interface{}{"frequency_cap_max": 1, "frequency_cap_window_hours": 200}, "window must be between"},
+		{"invalid action", map[string]interface{}{"frequency_cap_max": 1, "frequency_cap_action": "drop"}, "Invalid frequency cap action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := create(tt.body)
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+			assert.Contains(t, string(testutil.GetResponseBody(req)), tt.want)
+		})
+	}
+}
+
+func TestApp_UpdateCampaign_FrequencyCap(t *testing.T) {
+	app := newTestApp(t, withQueue(testutil.NewMockQueue()))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("cap-update")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusDraft)
+
+	update := func(body map[string]interface{}) handlers.CampaignResponse {
+		req := testutil.NewJSONRequest(t, body)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", campaign.ID.String())
+		require.NoError(t, app.UpdateCampaign(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		var resp struct {
+			Data handlers.CampaignResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		return resp.Data
+	}
+
+	resp := update(map[string]interface{}{
+		"name":                       campaign.Name,
+		"frequency_cap_max":          1,
+		"frequency_cap_window_hours": 72,
+		"frequency_cap_action":       models.FrequencyCapActionDefer,
+	})
+	assert.Equal(t, 1, resp.FrequencyCapMax)
+	assert.Equal(t, 72, resp.FrequencyCapWindowHours)
+	assert.Equal(t, models.FrequencyCapActionDefer, resp.FrequencyCapAction)
+
+	// A rename keeps the cap
+	resp = update(map[string]interface{}{"name": "Renamed"})
+	assert.Equal(t, 1, resp.FrequencyCapMax)
+
+	// A cap of 0 turns it off
+	resp = update(map[string]interface{}{"name": "Renamed", "frequency_cap_max": 0})
+	assert.Zero(t, resp.FrequencyCapMax)
+	assert.Zero(t, resp.FrequencyCapWindowHours)
+	assert.Empty(t, resp.FrequencyCapAction)
+}
+
 func TestApp_UpdateCampaign_NotFound(t *testing.T) {
 	mockQueue := testutil.NewMockQueue()
 	app := newTestApp(t, withQueue(mockQueue))
@@ -541,6 +792,30 @@ func TestApp_StartCampaign_InvalidStatus(t *testing.T) {
 	}
 }
 
+func TestApp_StartCampaign_RevertsToOriginalStatusOnEnqueueFailure(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	mockQueue.Error = errors.New("redis unavailable")
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("start-revert")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("start-revert-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusPaused)
+	createTestRecipient(t, app, campaign.ID, "+1234567890", models.MessageStatusPending)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+
+	err := app.StartCampaign(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusInternalServerError, testutil.GetResponseStatusCode(req))
+
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("id = ?", campaign.ID).First(&updated).Error)
+	assert.Equal(t, models.CampaignStatusPaused, updated.Status)
+}
+
 func TestApp_StartCampaign_CanResumePaused(t *testing.T) {
 	mockQueue := testutil.NewMockQueue()
 	app := newTestApp(t, withQueue(mockQueue))
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -65,6 +66,7 @@ type KeywordRuleResponse struct {
 	ID              string             `json:"id"`
 	Name            string             `json:"name"`
 	Keywords        []string           `json:"keywords"`
+	AdIDs           []string           `json:"ad_ids"`
 	MatchType       models.MatchType   `json:"match_type"`
 	ResponseType    models.ResponseType `json:"response_type"`
 	ResponseContent json.RawMessage    `json:"response_content"`
@@ -79,6 +81,7 @@ type ChatbotFlowResponse struct {
 	Name            string   `json:"name"`
 	Description     string   `json:"description"`
 	TriggerKeywords []string `json:"trigger_keywords"`
+	TriggerAdIDs    []string `json:"trigger_ad_ids"`
 	Enabled         bool     `json:"enabled"`
 	StepsCount      int      `json:"steps_count"`
 	CreatedAt       string   `json:"created_at"`
@@ -443,6 +446,7 @@ func (a *App) ListKeywordRules(r *fastglue.Request) error {
 			ID:              rule.ID.String(),
 			Name:            rule.Name,
 			Keywords:        rule.Keywords,
+			AdIDs:           rule.AdIDs,
 			MatchType:       rule.MatchType,
 			ResponseType:    rule.ResponseType,
 			ResponseContent: responseContent,
@@ -470,6 +474,7 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 	var req struct {
 		Name            string                 `json:"name"`
 		Keywords        []string               `json:"keywords"`
+		AdIDs           []string               `json:"ad_ids"`
 		MatchType       models.MatchType       `json:"match_type"`
 		ResponseType    models.ResponseType    `json:"response_type"`
 		ResponseContent map[string]interface{} `json:"response_content"`
@@ -481,8 +486,9 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
 	}
 
-	if len(req.Keywords) == 0 {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "At least one keyword is required", nil, "")
+	// Rules for click-to-WhatsApp ads may match on the ad alone
+	if len(req.Keywords) == 0 && len(req.AdIDs) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "At least one keyword or ad ID is required", nil, "")
 	}
 
 	// Set defaults
@@ -493,7 +499,19 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 		req.ResponseType = models.ResponseTypeText
 	}
 	if req.Name == "" {
-		req.Name = req.Keywords[0]
+		if len(req.Keywords) > 0 {
+			req.Name = req.Keywords[0]
+		} else {
+			req.Name = "Ad " + req.AdIDs[0]
+		}
+	}
+	if req.Keywords == nil {
+		req.Keywords = []string{}
+	}
+	if req.ResponseType == models.ResponseTypeScript {
+		if err := validateChatbotScript(getStringFromMap(req.ResponseContent, "script")); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
 	}
 
 	rule := models.KeywordRule{
@@ -501,6 +519,7 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 		OrganizationID:  orgID,
 		Name:            req.Name,
 		Keywords:        req.Keywords,
+		AdIDs:           req.AdIDs,
 		MatchType:       req.MatchType,
 		ResponseType:    req.ResponseType,
 		ResponseContent: models.JSONB(req.ResponseContent),
@@ -543,6 +562,7 @@ func (a *App) GetKeywordRule(r *fastglue.Request) error {
 		ID:              rule.ID.String(),
 		Name:            rule.Name,
 		Keywords:        rule.Keywords,
+		AdIDs:           rule.AdIDs,
 		MatchType:       rule.MatchType,
 		ResponseType:    rule.ResponseType,
 		ResponseContent: responseContent,
@@ -574,6 +594,7 @@ func (a *App) UpdateKeywordRule(r *fastglue.Request) error {
 	var req struct {
 		Name            *string                 `json:"name"`
 		Keywords        []string                `json:"keywords"`
+		AdIDs           *[]string               `json:"ad_ids"`
 		MatchType       *models.MatchType       `json:"match_type"`
 		ResponseType    *models.ResponseType    `json:"response_type"`
 		ResponseContent map[string]interface{}  `json:"response_content"`
@@ -592,6 +613,9 @@ func (a *App) UpdateKeywordRule(r *fastglue.Request) error {
 	if len(req.Keywords) > 0 {
 		rule.Keywords = req.Keywords
 	}
+	if req.AdIDs != nil {
+		rule.AdIDs = *req.AdIDs
+	}
 	if req.MatchType != nil {
 		rule.MatchType = *req.MatchType
 	}
@@ -607,6 +631,11 @@ func (a *App) UpdateKeywordRule(r *fastglue.Request) error {
 	if req.Enabled != nil {
 		rule.IsEnabled = *req.Enabled
 	}
+	if rule.ResponseType == models.ResponseTypeScript {
+		if err := validateChatbotScript(getStringFromMap(rule.ResponseContent, "script")); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	}
 
 	if err := a.DB.Save(rule).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update keyword rule", nil, "")
@@ -686,6 +715,7 @@ func (a *App) ListChatbotFlows(r *fastglue.Request) error {
 			Name:            flow.Name,
 			Description:     flow.Description,
 			TriggerKeywords: flow.TriggerKeywords,
+			TriggerAdIDs:    flow.TriggerAdIDs,
 			Enabled:         flow.IsEnabled,
 			StepsCount:      len(flow.Steps),
 			CreatedAt:       flow.CreatedAt.Format(time.RFC3339),
@@ -736,6 +766,7 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		Name              string                 `json:"name"`
 		Description       string                 `json:"description"`
 		TriggerKeywords   []string               `json:"trigger_keywords"`
+		TriggerAdIDs      []string               `json:"trigger_ad_ids"`
 		InitialMessage    string                 `json:"initial_message"`
 		CompletionMessage string                 `json:"completion_message"`
 		OnCompleteAction  string                 `json:"on_complete_action"`
@@ -763,6 +794,7 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		Name:              req.Name,
 		Description:       req.Description,
 		TriggerKeywords:   req.TriggerKeywords,
+		TriggerAdIDs:      req.TriggerAdIDs,
 		InitialMessage:    req.InitialMessage,
 		CompletionMessage: req.CompletionMessage,
 		OnCompleteAction:  req.OnCompleteAction,
@@ -808,6 +840,12 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		if step.MessageType == "" {
 			step.MessageType = models.FlowStepTypeText
 		}
+		if step.MessageType == models.FlowStepTypeScript {
+			if err := validateChatbotScript(getStringFromMap(step.ApiConfig, "script")); err != nil {
+				tx.Rollback()
+				return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Step %q: %s", step.StepName, err.Error()), nil, "")
+			}
+		}
 		if step.MaxRetries == 0 {
 			step.MaxRetries = 3
 		}
@@ -881,6 +919,7 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 		Name              *string                `json:"name"`
 		Description       *string                `json:"description"`
 		TriggerKeywords   []string               `json:"trigger_keywords"`
+		TriggerAdIDs      *[]string              `json:"trigger_ad_ids"`
 		InitialMessage    *string                `json:"initial_message"`
 		CompletionMessage *string                `json:"completion_message"`
 		OnCompleteAction  *string                `json:"on_complete_action"`
@@ -905,6 +944,9 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 	if len(req.TriggerKeywords) > 0 {
 		flow.TriggerKeywords = req.TriggerKeywords
 	}
+	if req.TriggerAdIDs != nil {
+		flow.TriggerAdIDs = *req.TriggerAdIDs
+	}
 	if req.InitialMessage != nil {
 		flow.InitialMessage = *req.InitialMessage
 	}
@@ -969,6 +1011,12 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 			if step.MessageType == "" {
 				step.MessageType = models.FlowStepTypeText
 			}
+			if step.MessageType == models.FlowStepTypeScript {
+				if err := validateChatbotScript(getStringFromMap(step.ApiConfig, "script")); err != nil {
+					tx.Rollback()
+					return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Step %q: %s", step.StepName, err.Error()), nil, "")
+				}
+			}
 			if step.MaxRetries == 0 {
 				step.MaxRetries = 3
 			}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"gorm.io/gorm"
 )
 
 // IncomingTextMessage represents a text, interactive, or media message from the webhook
@@ -73,10 +76,11 @@ type IncomingTextMessage struct {
 		SHA256   string `json:"sha256"`
 		Animated bool   `json:"animated,omitempty"`
 	} `json:"sticker,omitempty"`
-	Context *struct {
-		From string `json:"from"`
-		ID   string `json:"id"` // WhatsApp message ID being replied to
-	} `json:"context,omitempty"`
+	Button *struct {
+		Payload string `json:"payload"`
+		Text    string `json:"text"`
+	} `json:"button,omitempty"` // Quick reply button of a template message
+	Context  *IncomingContext `json:"context,omitempty"`
 	Reaction *struct {
 		MessageID string `json:"message_id"` // WhatsApp message ID being reacted to
 		Emoji     string `json:"emoji"`      // The emoji reaction (empty string = remove reaction)
@@ -98,10 +102,50 @@ type IncomingTextMessage struct {
 			Type  string `json:"type,omitempty"`
 		} `json:"phones,omitempty"`
 	} `json:"contacts,omitempty"`
+	Order    *IncomingOrder    `json:"order,omitempty"`
+	Referral *IncomingReferral `json:"referral,omitempty"` // Set when the user clicked a click-to-WhatsApp ad or post
 }
 
-// processIncomingMessageFull processes incoming WhatsApp messages with chatbot logic
-func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextMessage, profileName string) {
+// IncomingContext is the context Meta attaches to a message the user sent as
+// a reply, forwarded, or sent from a product ("Message business" button)
+type IncomingContext struct {
+	From                string `json:"from,omitempty"`
+	ID                  string `json:"id,omitempty"` // WhatsApp message ID being replied to
+	Forwarded           bool   `json:"forwarded,omitempty"`
+	FrequentlyForwarded bool   `json:"frequently_forwarded,omitempty"`
+	ReferredProduct     *struct {
+		CatalogID         string `json:"catalog_id"`
+		ProductRetailerID string `json:"product_retailer_id"`
+	} `json:"referred_product,omitempty"`
+}
+
+func (c *IncomingContext) toJSONB() models.JSONB {
+	out := models.JSONB{}
+	if c.ID != "" {
+		out["id"] = c.ID
+	}
+	if c.From != "" {
+		out["from"] = c.From
+	}
+	if c.Forwarded {
+		out["forwarded"] = true
+	}
+	if c.FrequentlyForwarded {
+		out["frequently_forwarded"] = true
+	}
+	if c.ReferredProduct != nil {
+		out["referred_product"] = map[string]interface{}{
+			"catalog_id":          c.ReferredProduct.CatalogID,
+			"product_retailer_id": c.ReferredProduct.ProductRetailerID,
+		}
+	}
+	return out
+}
+
+// processIncomingMessageFull processes incoming WhatsApp messages with chatbot logic.
+// It returns an error when the message could not be stored, so the webhook
+// event is retried.
+func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextMessage, profileName string) error {
 	a.Log.Info("Processing incoming message",
 		"phone_number_id", phoneNumberID,
 		"from", msg.From,
@@ -113,17 +157,29 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	account, err := a.getWhatsAppAccountCached(phoneNumberID)
 	if err != nil {
 		a.Log.Error("WhatsApp account not found", "phone_id", phoneNumberID, "error", err)
-		return
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load account: %w", err)
 	}
 
 	// Handle reaction messages specially - they update existing messages, not create new ones
 	if msg.Type == "reaction" && msg.Reaction != nil {
 		a.handleIncomingReaction(account, msg.From, msg.Reaction.MessageID, msg.Reaction.Emoji, profileName)
-		return
+		return nil
 	}
 
 	// Get or create contact (always do this for all incoming messages)
-	contact, isNewContact, _ := contactutil.GetOrCreateContact(a.DB, account.OrganizationID, msg.From, profileName)
+	contact, isNewContact, err := contactutil.GetOrCreateContact(a.DB, account.OrganizationID, msg.From, profileName)
+	if err != nil || contact == nil {
+		a.Log.Error("Failed to get or create contact", "error", err, "from", msg.From)
+		return fmt.Errorf("failed to get or create contact: %w", err)
+	}
+
+	// Remember the ad or post the contact first came from
+	if msg.Referral != nil {
+		a.recordContactReferral(contact, msg.Referral)
+	}
 
 	// Dispatch webhook if new contact was created
 	if isNewContact {
@@ -146,6 +202,9 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 
 	if msg.Type == "text" && msg.Text != nil {
 		messageText = msg.Text.Body
+	} else if msg.Type == "button" && msg.Button != nil {
+		// Quick reply on a template, e.g. a campaign message; context.id is the template message
+		messageText = msg.Button.Text
 	} else if msg.Type == "interactive" && msg.Interactive != nil {
 		// Handle button reply
 		if msg.Interactive.ButtonReply != nil {
@@ -272,37 +331,51 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 		if jsonBytes, err := json.Marshal(contactsData); err == nil {
 			messageText = string(jsonBytes)
 		}
+	} else if msg.Type == "order" && msg.Order != nil {
+		// Handle catalog order - persist it and store a summary as JSON in content
+		messageText = a.handleIncomingOrder(account, contact, msg.ID, msg.Order)
 	}
 
 	// Save incoming message to messages table (always, even if chatbot is disabled)
-	var replyToWAMID string
-	if msg.Context != nil && msg.Context.ID != "" {
-		replyToWAMID = msg.Context.ID
+	if err := a.saveIncomingMessage(account, contact, msg.ID, messageType, messageText, mediaInfo, msg.Context, msg.Referral); err != nil {
+		return err
 	}
-	a.saveIncomingMessage(account, contact, msg.ID, messageType, messageText, mediaInfo, replyToWAMID)
 
 	// Clear chatbot tracking since client has replied
 	a.ClearContactChatbotTracking(contact.ID)
 
+	// Opt-out and opt-in keywords update the suppression list instead of reaching the chatbot
+	if (msg.Type == "text" || msg.Type == "button") && a.handleOptKeyword(account.OrganizationID, contact, messageText) {
+		return nil
+	}
+
+	// Blocked contacts get no automated replies; the message is kept for the record
+	if contact.IsBlocked {
+		a.Log.Info("Contact is blocked, skipping chatbot processing",
+			"contact_id", contact.ID,
+			"phone_number", contact.PhoneNumber)
+		return nil
+	}
+
 	// Check for active agent transfer - skip chatbot processing if transferred
 	if a.hasActiveAgentTransfer(account.OrganizationID, contact.ID) {
 		a.Log.Info("Contact has active agent transfer, skipping chatbot processing",
 			"contact_id", contact.ID,
 			"phone_number", contact.PhoneNumber)
-		return
+		return nil
 	}
 
 	// Check if chatbot is enabled for this account (use cache)
 	settings, err := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
 	if err != nil {
 		a.Log.Error("Failed to load chatbot settings", "error", err, "account", account.Name, "org_id", account.OrganizationID)
-		return
+		return nil
 	}
 	if !settings.IsEnabled {
 		a.Log.Debug("Chatbot not enabled for this account, creating transfer for agent queue", "account", account.Name, "settings_id", settings.ID)
 		// Create transfer to agent queue when chatbot is disabled
 		a.createTransferToQueue(account, contact, models.TransferSourceChatbotDisabled)
-		return
+		return nil
 	}
 	a.Log.Info("Chatbot settings loaded", "settings_id", settings.ID, "is_enabled", settings.IsEnabled, "ai_enabled", settings.AI.Enabled, "ai_provider", settings.AI.Provider, "default_response", settings.DefaultResponse)
 
@@ -317,17 +390,22 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 						a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
 					}
 				}
-				return
+				return nil
 			}
 			// AllowAutomatedOutsideHours is true, continue processing flows/keywords/AI
 			a.Log.Info("Outside business hours but automated responses allowed, continuing")
 		}
 	}
 
+	// Orders are confirmed by agents; don't run chatbot matching on the cart summary
+	if msg.Type == "order" {
+		return nil
+	}
+
 	// Only process text and interactive messages for chatbot
 	if messageText == "" {
 		a.Log.Debug("Skipping message with no text content for chatbot", "type", msg.Type)
-		return
+		return nil
 	}
 
 	a.Log.Info("Processing message", "text", messageText, "buttonID", buttonID, "from", msg.From)
@@ -339,7 +417,7 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	a.logSessionMessage(session.ID, models.DirectionIncoming, messageText, "keyword_check")
 
 	// Check for transfer keyword BEFORE sending greeting (transfer takes priority)
-	keywordResponse, keywordMatched := a.matchKeywordRules(account.OrganizationID, account.Name, messageText)
+	keywordResponse, keywordMatched := a.matchKeywordRulesForAd(account.OrganizationID, account.Name, messageText, msg.Referral.AdID())
 	if keywordMatched && keywordResponse.ResponseType == models.ResponseTypeTransfer {
 		a.Log.Info("Transfer keyword matched", "response", keywordResponse.Body)
 		// Check business hours - if outside hours, send out of hours message instead
@@ -351,7 +429,7 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 						a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
 					}
 				}
-				return
+				return nil
 			}
 		}
 		// Within business hours - send transfer message and create transfer
@@ -361,19 +439,19 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 			}
 		}
 		a.createTransferFromKeyword(account, contact)
-		return
+		return nil
 	}
 
 	// Check if user is in an active flow
 	if session.CurrentFlowID != nil {
 		a.processFlowResponse(account, session, contact, messageText, buttonID, flowResponseData)
-		return
+		return nil
 	}
 
 	// Try to match flow trigger keywords first (before greeting to avoid duplicate messages)
-	if flow := a.matchFlowTrigger(account.OrganizationID, account.Name, messageText); flow != nil {
+	if flow := a.matchFlowTriggerForAd(account.OrganizationID, account.Name, messageText, msg.Referral.AdID()); flow != nil {
 		a.startFlow(account, session, contact, flow)
-		return
+		return nil
 	}
 
 	// Send greeting message for new sessions (only if no flow was triggered)
@@ -401,13 +479,18 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 			}
 		}
 		a.logSessionMessage(session.ID, models.DirectionOutgoing, settings.DefaultResponse, "greeting")
-		return // After greeting, don't process further for new sessions
+		return nil // After greeting, don't process further for new sessions
 	}
 
 	// Handle non-transfer keyword matches (transfer was already handled above)
 	if keywordMatched && keywordResponse.ResponseType != models.ResponseTypeTransfer {
 		a.Log.Info("Keyword rule matched", "response_type", keywordResponse.ResponseType, "response", keywordResponse.Body)
 
+		if keywordResponse.ResponseType == models.ResponseTypeScript {
+			a.sendScriptKeywordResponse(account, session, contact, keywordResponse, messageText)
+			return nil
+		}
+
 		// Handle regular text response
 		if len(keywordResponse.Buttons) > 0 {
 			if err := a.sendAndSaveInteractiveButtons(account, contact, keywordResponse.Body, keywordResponse.Buttons); err != nil {
@@ -420,7 +503,7 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 		}
 		// Log outgoing message
 		a.logSessionMessage(session.ID, models.DirectionOutgoing, keywordResponse.Body, "keyword_response")
-		return
+		return nil
 	}
 
 	// If no keyword matched, try AI response if enabled
@@ -436,7 +519,7 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 				a.Log.Error("Failed to send AI response", "error", err, "contact", contact.PhoneNumber)
 			}
 			a.logSessionMessage(session.ID, models.DirectionOutgoing, aiResponse, "ai_response")
-			return
+			return nil
 		} else {
 			a.Log.Warn("AI returned empty response")
 		}
@@ -473,17 +556,26 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	} else if !isNewSession {
 		a.Log.Info("No fallback message configured for existing session")
 	}
+	return nil
 }
 
 // KeywordResponse holds the response content and optional buttons
 type KeywordResponse struct {
 	Body         string
 	Buttons      []map[string]interface{}
-	ResponseType models.ResponseType // text, transfer
+	ResponseType models.ResponseType // text, transfer, script
+	Script       string              // JavaScript source for script responses (Body is the fallback)
 }
 
 // matchKeywordRules checks if the message matches any keyword rules
 func (a *App) matchKeywordRules(orgID uuid.UUID, accountName, messageText string) (*KeywordResponse, bool) {
+	return a.matchKeywordRulesForAd(orgID, accountName, messageText, "")
+}
+
+// matchKeywordRulesForAd checks keyword rules against a message that may come
+// from a click-to-WhatsApp ad. Rules limited to ads only match messages from
+// those ads; such a rule without keywords matches any message from them.
+func (a *App) matchKeywordRulesForAd(orgID uuid.UUID, accountName, messageText, adID string) (*KeywordResponse, bool) {
 	// Use cached keyword rules (includes both account-specific and global rules)
 	rules, err := a.getKeywordRulesCached(orgID, accountName)
 	if err != nil {
@@ -491,77 +583,117 @@ func (a *App) matchKeywordRules(orgID uuid.UUID, accountName, messageText string
 		return nil, false
 	}
 
-	messageLower := strings.ToLower(messageText)
-
 	for _, rule := range rules {
-		for _, keyword := range rule.Keywords {
-			keywordLower := strings.ToLower(keyword)
-			matched := false
-
-			switch rule.MatchType {
-			case models.MatchTypeExact:
-				if rule.CaseSensitive {
-					matched = messageText == keyword
-				} else {
-					matched = messageLower == keywordLower
-				}
-			case models.MatchTypeContains:
-				if rule.CaseSensitive {
-					matched = strings.Contains(messageText, keyword)
-				} else {
-					matched = strings.Contains(messageLower, keywordLower)
-				}
-			case models.MatchTypeStartsWith:
-				if rule.CaseSensitive {
-					matched = strings.HasPrefix(messageText, keyword)
-				} else {
-					matched = strings.HasPrefix(messageLower, keywordLower)
-				}
-			case models.MatchTypeRegex:
-				re, err := regexp.Compile(keyword)
-				if err == nil {
-					matched = re.MatchString(messageText)
+		if len(rule.AdIDs) > 0 {
+			if adID == "" || !slices.Contains(rule.AdIDs, adID) {
+				continue
+			}
+			if len(rule.Keywords) == 0 {
+				if response, ok := keywordRuleResponse(rule); ok {
+					return response, true
 				}
-			default:
-				// Default to contains
+				continue
+			}
+		}
+
+		if !keywordRuleMatchesText(rule, messageText) {
+			continue
+		}
+		if response, ok := keywordRuleResponse(rule); ok {
+			return response, true
+		}
+	}
+
+	return nil, false
+}
+
+// keywordRuleMatchesText reports whether any of the rule's keywords match
+func keywordRuleMatchesText(rule models.KeywordRule, messageText string) bool {
+	messageLower := strings.ToLower(messageText)
+
+	for _, keyword := range rule.Keywords {
+		keywordLower := strings.ToLower(keyword)
+		matched := false
+
+		switch rule.MatchType {
+		case models.MatchTypeExact:
+			if rule.CaseSensitive {
+				matched = messageText == keyword
+			} else {
+				matched = messageLower == keywordLower
+			}
+		case models.MatchTypeContains:
+			if rule.CaseSensitive {
+				matched = strings.Contains(messageText, keyword)
+			} else {
 				matched = strings.Contains(messageLower, keywordLower)
 			}
+		case models.MatchTypeStartsWith:
+			if rule.CaseSensitive {
+				matched = strings.HasPrefix(messageText, keyword)
+			} else {
+				matched = strings.HasPrefix(messageLower, keywordLower)
+			}
+		case models.MatchTypeRegex:
+			re, err := regexp.Compile(keyword)
+			if err == nil {
+				matched = re.MatchString(messageText)
+			}
+		default:
+			// Default to contains
+			matched = strings.Contains(messageLower, keywordLower)
+		}
 
-			if matched {
-				response := &KeywordResponse{
-					ResponseType: rule.ResponseType,
-				}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
 
-				// For transfer type, use body as the transfer message
-				if rule.ResponseType == models.ResponseTypeTransfer {
-					if body, ok := rule.ResponseContent["body"].(string); ok {
-						response.Body = body
-					}
-					return response, true
-				}
+// keywordRuleResponse builds the response of a matched rule. Rules with
+// nothing to send don't count as a match.
+func keywordRuleResponse(rule models.KeywordRule) (*KeywordResponse, bool) {
+	response := &KeywordResponse{
+		ResponseType: rule.ResponseType,
+	}
 
-				// Get response body
-				if body, ok := rule.ResponseContent["body"].(string); ok {
-					response.Body = body
-				}
+	// For transfer type, use body as the transfer message
+	if rule.ResponseType == models.ResponseTypeTransfer {
+		if body, ok := rule.ResponseContent["body"].(string); ok {
+			response.Body = body
+		}
+		return response, true
+	}
 
-				// Get buttons if present
-				if buttons, ok := rule.ResponseContent["buttons"].([]interface{}); ok && len(buttons) > 0 {
-					response.Buttons = make([]map[string]interface{}, 0, len(buttons))
-					for _, btn := range buttons {
-						if btnMap, ok := btn.(map[string]interface{}); ok {
-							response.Buttons = append(response.Buttons, btnMap)
-						}
-					}
-				}
+	// For script type, the reply is produced by running the script
+	if rule.ResponseType == models.ResponseTypeScript {
+		if script, ok := rule.ResponseContent["script"].(string); ok && script != "" {
+			response.Script = script
+			response.Body, _ = rule.ResponseContent["body"].(string)
+			return response, true
+		}
+		return nil, false
+	}
 
-				if response.Body != "" {
-					return response, true
-				}
+	// Get response body
+	if body, ok := rule.ResponseContent["body"].(string); ok {
+		response.Body = body
+	}
+
+	// Get buttons if present
+	if buttons, ok := rule.ResponseContent["buttons"].([]interface{}); ok && len(buttons) > 0 {
+		response.Buttons = make([]map[string]interface{}, 0, len(buttons))
+		for _, btn := range buttons {
+			if btnMap, ok := btn.(map[string]interface{}); ok {
+				response.Buttons = append(response.Buttons, btnMap)
 			}
 		}
 	}
 
+	if response.Body != "" {
+		return response, true
+	}
 	return nil, false
 }
 
@@ -764,6 +896,13 @@ func (a *App) logSessionMessage(sessionID uuid.UUID, direction models.Direction,
 
 // matchFlowTrigger checks if the message triggers any flow
 func (a *App) matchFlowTrigger(orgID uuid.UUID, accountName, messageText string) *models.ChatbotFlow {
+	return a.matchFlowTriggerForAd(orgID, accountName, messageText, "")
+}
+
+// matchFlowTriggerForAd finds the flow a message starts, which may come from
+// a click-to-WhatsApp ad. Flows triggered by ads only start for messages from
+// those ads; such a flow without trigger keywords starts on any of them.
+func (a *App) matchFlowTriggerForAd(orgID uuid.UUID, accountName, messageText, adID string) *models.ChatbotFlow {
 	// Use cached flows (includes steps)
 	flows, err := a.getChatbotFlowsCached(orgID)
 	if err != nil {
@@ -774,6 +913,14 @@ func (a *App) matchFlowTrigger(orgID uuid.UUID, accountName, messageText string)
 	messageLower := strings.ToLower(messageText)
 
 	for _, flow := range flows {
+		if len(flow.TriggerAdIDs) > 0 {
+			if adID == "" || !slices.Contains(flow.TriggerAdIDs, adID) {
+				continue
+			}
+			if len(flow.TriggerKeywords) == 0 {
+				return &flow
+			}
+		}
 		for _, keyword := range flow.TriggerKeywords {
 			if strings.Contains(messageLower, strings.ToLower(keyword)) {
 				return &flow
@@ -1369,6 +1516,20 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 		}
 		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
 
+	case models.FlowStepTypeScript:
+		// Run the step's script; it produces the reply and may set session variables
+		message = a.sendScriptStep(account, session, contact, step)
+		if message != "" {
+			a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+		}
+
+	case models.FlowStepTypeProduct:
+		// Send catalog products picked by retailer ID or by a product query
+		message = a.sendProductStep(account, session, contact, step)
+		if message != "" {
+			a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+		}
+
 	case models.FlowStepTypeButtons:
 		// Send interactive buttons message
 		message = processTemplate(step.Message, session.SessionData)
@@ -2269,7 +2430,7 @@ type MediaInfo struct {
 }
 
 // saveIncomingMessage saves an incoming message to the messages table
-func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *models.Contact, whatsappMsgID, msgType, content string, mediaInfo *MediaInfo, replyToWAMID string) {
+func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *models.Contact, whatsappMsgID, msgType, content string, mediaInfo *MediaInfo, msgContext *IncomingContext, referral *IncomingReferral) error {
 	now := time.Now()
 
 	message := models.Message{
@@ -2284,14 +2445,22 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 		Status:            models.MessageStatusReceived,
 	}
 
-	// Handle reply context - look up the original message by WhatsApp message ID
-	if replyToWAMID != "" {
-		var replyToMsg models.Message
-		if err := a.DB.Where("whats_app_message_id = ?", replyToWAMID).First(&replyToMsg).Error; err == nil {
-			message.IsReply = true
-			message.ReplyToMessageID = &replyToMsg.ID
-		} else {
-			a.Log.Warn("Reply-to message not found", "reply_to_wamid", replyToWAMID)
+	// Handle reply context - look up the original message by WhatsApp message ID.
+	// The context is kept even when the message isn't found, e.g. one sent
+	// before the account was connected.
+	var replyToMsg *models.Message
+	if msgContext != nil {
+		message.Context = msgContext.toJSONB()
+		if msgContext.ID != "" {
+			var original models.Message
+			if err := a.DB.Where("organization_id = ? AND whats_app_message_id = ?", account.OrganizationID, msgContext.ID).
+				First(&original).Error; err == nil {
+				message.IsReply = true
+				message.ReplyToMessageID = &original.ID
+				replyToMsg = &original
+			} else {
+				a.Log.Warn("Reply-to message not found", "reply_to_wamid", msgContext.ID)
+			}
 		}
 	}
 
@@ -2302,9 +2471,14 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 		message.MediaFilename = mediaInfo.MediaFilename
 	}
 
+	if referral != nil {
+		message.AdID = referral.AdID()
+		message.Referral = referral.toJSONB()
+	}
+
 	if err := a.DB.Create(&message).Error; err != nil {
 		a.Log.Error("Failed to save incoming message", "error", err)
-		return
+		return fmt.Errorf("failed to save incoming message: %w", err)
 	}
 
 	// Update contact's last message info
@@ -2354,18 +2528,12 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 			"is_reply":         message.IsReply,
 		}
 		// Include reply context if this is a reply
-		if message.IsReply && message.ReplyToMessageID != nil {
-			wsPayload["reply_to_message_id"] = message.ReplyToMessageID.String()
-			// Load the replied-to message for preview
-			var replyToMsg models.Message
-			if err := a.DB.First(&replyToMsg, message.ReplyToMessageID).Error; err == nil {
-				wsPayload["reply_to_message"] = map[string]any{
-					"id":           replyToMsg.ID.String(),
-					"content":      map[string]string{"body": replyToMsg.Content},
-					"message_type": replyToMsg.MessageType,
-					"direction":    replyToMsg.Direction,
-				}
-			}
+		if replyToMsg != nil {
+			wsPayload["reply_to_message_id"] = replyToMsg.ID.String()
+			wsPayload["reply_to_message"] = buildReplyPreview(replyToMsg)
+		}
+		if message.Context != nil {
+			wsPayload["context"] = message.Context
 		}
 		a.WSHub.BroadcastToOrg(account.OrganizationID, websocket.WSMessage{
 			Type:    websocket.TypeNewMessage,
@@ -2383,7 +2551,9 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 		Content:         content,
 		WhatsAppAccount: account.Name,
 		Direction:       models.DirectionIncoming,
+		Context:         messageEventContext(msgContext, replyToMsg),
 	})
+	return nil
 }
 
 // isWithinBusinessHours checks if current time is within configured business hours
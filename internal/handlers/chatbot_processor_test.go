@@ -312,6 +312,32 @@ func TestMatchKeywordRules_TransferType(t *testing.T) {
 	assert.Equal(t, "Connecting you to an agent...", resp.Body)
 }
 
+func TestMatchKeywordRules_ScriptType(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+
+	rule := &models.KeywordRule{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "script-balance",
+		Keywords:        models.StringArray{"balance"},
+		MatchType:       models.MatchTypeContains,
+		ResponseType:    models.ResponseTypeScript,
+		ResponseContent: models.JSONB{"script": `return "Your balance is 42";`, "body": "Try again later"},
+		Priority:        10,
+		IsEnabled:       true,
+	}
+	require.NoError(t, app.DB.Create(rule).Error)
+
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "what is my balance")
+	assert.True(t, matched)
+	require.NotNil(t, resp)
+	assert.Equal(t, models.ResponseTypeScript, resp.ResponseType)
+	assert.Equal(t, `return "Your balance is 42";`, resp.Script)
+	assert.Equal(t, "Try again later", resp.Body)
+}
+
 func TestMatchKeywordRules_WithButtons(t *testing.T) {
 	app := newProcessorTestApp(t)
 	org, account := createProcessorTestOrg(t, app)
@@ -343,6 +369,62 @@ func TestMatchKeywordRules_WithButtons(t *testing.T) {
 	assert.Len(t, resp.Buttons, 2)
 }
 
+func TestMatchKeywordRules_AdID(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+
+	adOnly := &models.KeywordRule{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "summer-ad",
+		Keywords:        models.StringArray{},
+		AdIDs:           models.StringArray{"ad-summer"},
+		MatchType:       models.MatchTypeContains,
+		ResponseType:    models.ResponseTypeText,
+		ResponseContent: models.JSONB{"body": "Thanks for checking out our summer sale"},
+		Priority:        10,
+		IsEnabled:       true,
+	}
+	require.NoError(t, app.DB.Create(adOnly).Error)
+
+	adKeyword := &models.KeywordRule{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "winter-price",
+		Keywords:        models.StringArray{"price"},
+		AdIDs:           models.StringArray{"ad-winter"},
+		MatchType:       models.MatchTypeContains,
+		ResponseType:    models.ResponseTypeText,
+		ResponseContent: models.JSONB{"body": "Winter prices"},
+		Priority:        5,
+		IsEnabled:       true,
+	}
+	require.NoError(t, app.DB.Create(adKeyword).Error)
+
+	// Any message from the ad matches a rule without keywords
+	resp, matched := app.matchKeywordRulesForAd(org.ID, account.Name, "Hi", "ad-summer")
+	assert.True(t, matched)
+	require.NotNil(t, resp)
+	assert.Equal(t, "Thanks for checking out our summer sale", resp.Body)
+
+	// Ad rules need both the ad and a keyword when they have keywords
+	resp, matched = app.matchKeywordRulesForAd(org.ID, account.Name, "What's the price?", "ad-winter")
+	assert.True(t, matched)
+	require.NotNil(t, resp)
+	assert.Equal(t, "Winter prices", resp.Body)
+
+	_, matched = app.matchKeywordRulesForAd(org.ID, account.Name, "Hi", "ad-winter")
+	assert.False(t, matched)
+
+	// Messages from other ads or no ad don't match ad rules
+	_, matched = app.matchKeywordRulesForAd(org.ID, account.Name, "What's the price?", "ad-other")
+	assert.False(t, matched)
+	_, matched = app.matchKeywordRules(org.ID, account.Name, "What's the price?")
+	assert.False(t, matched)
+}
+
 // =============================================================================
 // getOrCreateSession
 // =============================================================================
@@ -788,7 +870,7 @@ func TestSaveIncomingMessage_TextMessage(t *testing.T) {
 	contact := testutil.CreateTestContact(t, app.DB, org.ID)
 
 	waMsgID := "wamid." + uuid.New().String()[:16]
-	app.saveIncomingMessage(account, contact, waMsgID, "text", "Hello from test", nil, "")
+	app.saveIncomingMessage(account, contact, waMsgID, "text", "Hello from test", nil, nil, nil)
 
 	// Verify message was saved
 	var msg models.Message
@@ -819,7 +901,7 @@ func TestSaveIncomingMessage_WithMedia(t *testing.T) {
 		MediaMimeType: "image/jpeg",
 		MediaFilename: "photo.jpg",
 	}
-	app.saveIncomingMessage(account, contact, waMsgID, "image", "Look at this", media, "")
+	app.saveIncomingMessage(account, contact, waMsgID, "image", "Look at this", media, nil, nil)
 
 	var msg models.Message
 	require.NoError(t, app.DB.Where("whats_app_message_id = ?", waMsgID).First(&msg).Error)
@@ -855,7 +937,7 @@ func TestSaveIncomingMessage_WithReplyContext(t *testing.T) {
 
 	// Save reply message
 	replyWAMID := "wamid.reply_" + uuid.New().String()[:8]
-	app.saveIncomingMessage(account, contact, replyWAMID, "text", "Reply to your message", nil, originalWAMID)
+	app.saveIncomingMessage(account, contact, replyWAMID, "text", "Reply to your message", nil, &IncomingContext{ID: originalWAMID}, nil)
 
 	var replyMsg models.Message
 	require.NoError(t, app.DB.Where("whats_app_message_id = ?", replyWAMID).First(&replyMsg).Error)
@@ -864,6 +946,64 @@ func TestSaveIncomingMessage_WithReplyContext(t *testing.T) {
 	assert.Equal(t, originalMsg.ID, *replyMsg.ReplyToMessageID)
 }
 
+func TestSaveIncomingMessage_UnresolvedContext(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	// A message with the same wamid in another organization must not be linked
+	otherOrg := testutil.CreateTestOrganization(t, app.DB)
+	otherContact := testutil.CreateTestContact(t, app.DB, otherOrg.ID)
+	otherWAMID := "wamid.other_" + uuid.New().String()[:8]
+	require.NoError(t, app.DB.Create(&models.Message{
+		OrganizationID:    otherOrg.ID,
+		ContactID:         otherContact.ID,
+		WhatsAppMessageID: otherWAMID,
+		Direction:         models.DirectionOutgoing,
+		MessageType:       models.MessageTypeText,
+		Content:           "Other org",
+	}).Error)
+
+	replyWAMID := "wamid.reply_" + uuid.New().String()[:8]
+	app.saveIncomingMessage(account, contact, replyWAMID, "text", "Forwarded reply", nil,
+		&IncomingContext{ID: otherWAMID, From: "15550001111", Forwarded: true}, nil)
+
+	var replyMsg models.Message
+	require.NoError(t, app.DB.Where("whats_app_message_id = ?", replyWAMID).First(&replyMsg).Error)
+	assert.False(t, replyMsg.IsReply)
+	assert.Nil(t, replyMsg.ReplyToMessageID)
+	// The quoted wamid is kept for reference
+	assert.Equal(t, otherWAMID, replyMsg.Context["id"])
+	assert.Equal(t, true, replyMsg.Context["forwarded"])
+}
+
+func TestMessageEventContext_CampaignReply(t *testing.T) {
+	assert.Nil(t, messageEventContext(nil, nil))
+
+	campaignID := uuid.New().String()
+	original := &models.Message{
+		BaseModel:         models.BaseModel{ID: uuid.New()},
+		WhatsAppMessageID: "wamid.campaign",
+		TemplateName:      "summer_promo",
+		Metadata:          models.JSONB{"campaign_id": campaignID},
+	}
+	ctx := messageEventContext(&IncomingContext{ID: "wamid.campaign", From: "15550001111"}, original)
+	require.NotNil(t, ctx)
+	assert.Equal(t, "wamid.campaign", ctx.WAMID)
+	assert.Equal(t, original.ID.String(), ctx.ReplyToMessageID)
+	assert.Equal(t, campaignID, ctx.ReplyToCampaignID)
+	assert.Equal(t, "summer_promo", ctx.ReplyToTemplate)
+
+	preview := buildReplyPreview(original)
+	assert.Equal(t, campaignID, preview.CampaignID)
+	assert.Equal(t, "wamid.campaign", preview.WAMID)
+
+	// Unknown quoted messages still report the wamid
+	ctx = messageEventContext(&IncomingContext{ID: "wamid.unknown"}, nil)
+	assert.Equal(t, "wamid.unknown", ctx.WAMID)
+	assert.Empty(t, ctx.ReplyToMessageID)
+}
+
 func TestSaveIncomingMessage_LongContent(t *testing.T) {
 	app := newProcessorTestApp(t)
 	org, account := createProcessorTestOrg(t, app)
@@ -875,7 +1015,7 @@ func TestSaveIncomingMessage_LongContent(t *testing.T) {
 		longContent += "x"
 	}
 	waMsgID := "wamid." + uuid.New().String()[:16]
-	app.saveIncomingMessage(account, contact, waMsgID, "text", longContent, nil, "")
+	app.saveIncomingMessage(account, contact, waMsgID, "text", longContent, nil, nil, nil)
 
 	var dbContact models.Contact
 	require.NoError(t, app.DB.First(&dbContact, contact.ID).Error)
@@ -884,6 +1024,57 @@ func TestSaveIncomingMessage_LongContent(t *testing.T) {
 	assert.True(t, len(dbContact.LastMessagePreview) <= 100)
 }
 
+func TestSaveIncomingMessage_WithReferral(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	referral := &IncomingReferral{
+		SourceURL:  "https://fb.me/abc",
+		SourceID:   "ad-123",
+		SourceType: "ad",
+		Headline:   "Summer sale",
+		CtwaClid:   "clid-1",
+	}
+	waMsgID := "wamid." + uuid.New().String()[:16]
+	app.saveIncomingMessage(account, contact, waMsgID, "text", "Hi", nil, nil, referral)
+
+	var msg models.Message
+	require.NoError(t, app.DB.Where("whats_app_message_id = ?", waMsgID).First(&msg).Error)
+	assert.Equal(t, "ad-123", msg.AdID)
+	assert.Equal(t, "Summer sale", msg.Referral["headline"])
+	assert.Equal(t, "clid-1", msg.Referral["ctwa_clid"])
+}
+
+func TestRecordContactReferral_KeepsFirstTouch(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, _ := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	app.recordContactReferral(contact, &IncomingReferral{SourceID: "ad-first", SourceType: "ad", SourceURL: "https://fb.me/first"})
+	assert.Equal(t, "ad-first", contact.AdID)
+	require.NotNil(t, contact.ReferredAt)
+
+	// A stale copy of the contact must not overwrite the first touch
+	var stale models.Contact
+	require.NoError(t, app.DB.First(&stale, contact.ID).Error)
+	stale.Referral = nil
+	app.recordContactReferral(&stale, &IncomingReferral{SourceID: "ad-second", SourceType: "ad"})
+	assert.Empty(t, stale.AdID)
+
+	var dbContact models.Contact
+	require.NoError(t, app.DB.First(&dbContact, contact.ID).Error)
+	assert.Equal(t, "ad-first", dbContact.AdID)
+	assert.Equal(t, "https://fb.me/first", dbContact.Referral["source_url"])
+
+	// Post referrals are kept but carry no ad
+	post := testutil.CreateTestContact(t, app.DB, org.ID)
+	app.recordContactReferral(post, &IncomingReferral{SourceID: "post-1", SourceType: "post"})
+	require.NoError(t, app.DB.First(&dbContact, post.ID).Error)
+	assert.Empty(t, dbContact.AdID)
+	assert.Equal(t, "post", dbContact.Referral["source_type"])
+}
+
 // =============================================================================
 // replaceVariables
 // =============================================================================
@@ -971,6 +1162,29 @@ func TestMatchFlowTrigger_Match(t *testing.T) {
 	assert.Nil(t, noMatch)
 }
 
+func TestMatchFlowTrigger_AdID(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+
+	flow := &models.ChatbotFlow{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "Ad Lead Flow",
+		TriggerKeywords: models.StringArray{},
+		TriggerAdIDs:    models.StringArray{"ad-leads"},
+		IsEnabled:       true,
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+
+	result := app.matchFlowTriggerForAd(org.ID, account.Name, "Can I get more info?", "ad-leads")
+	require.NotNil(t, result)
+	assert.Equal(t, flow.ID, result.ID)
+
+	assert.Nil(t, app.matchFlowTriggerForAd(org.ID, account.Name, "Can I get more info?", "ad-other"))
+	assert.Nil(t, app.matchFlowTrigger(org.ID, account.Name, "Can I get more info?"))
+}
+
 // =============================================================================
 // evaluateExpression (package-level, not on App)
 // =============================================================================
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+)
+
+// sendProductStep sends catalog products for a "product" flow step.
+//
+// Products are configured in input_config:
+//   - catalog_id: local catalog ID (defaults to the account's first active catalog)
+//   - retailer_ids: explicit product SKUs, as an array or comma-separated string
+//   - product_query: case-insensitive match over product name and description
+//   - max_products, header, footer, no_results_message
+//
+// One product is sent as a "product" message and several as a "product_list".
+// With neither retailer_ids nor product_query the whole catalog is offered via
+// a "catalog_message". Returns the message that was sent, or "" if nothing was sent.
+func (a *App) sendProductStep(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep) string {
+	cfg := step.InputConfig
+	message := processTemplate(step.Message, session.SessionData)
+	footer := processTemplate(getStringFromMap(cfg, "footer"), session.SessionData)
+
+	catalog, err := a.resolveStepCatalog(account, getStringFromMap(cfg, "catalog_id"))
+	if err != nil {
+		a.Log.Error("Product step has no usable catalog", "error", err, "step", step.StepName)
+		return a.sendProductStepFallback(account, session, contact, step)
+	}
+
+	retailerIDs := stepRetailerIDs(cfg["retailer_ids"], session.SessionData)
+	query := strings.TrimSpace(processTemplate(getStringFromMap(cfg, "product_query"), session.SessionData))
+
+	req := OutgoingMessageRequest{
+		Account:    account,
+		Contact:    contact,
+		Type:       models.MessageTypeInteractive,
+		BodyText:   message,
+		FooterText: footer,
+		CatalogID:  catalog.MetaCatalogID,
+	}
+
+	if len(retailerIDs) == 0 && query == "" {
+		if message == "" {
+			message = catalog.Name
+			req.BodyText = message
+		}
+		req.InteractiveType = "catalog_message"
+		req.ProductRetailerID = getStringFromMap(cfg, "thumbnail_retailer_id")
+	} else {
+		limit := whatsapp.MaxProductsPerList
+		if n, ok := cfg["max_products"].(float64); ok && n > 0 {
+			limit = int(n)
+		}
+		products, err := a.findStepProducts(catalog, retailerIDs, query, limit)
+		if err != nil {
+			a.Log.Error("Failed to look up products", "error", err, "step", step.StepName)
+			return a.sendProductStepFallback(account, session, contact, step)
+		}
+		if len(products) == 0 {
+			return a.sendProductStepFallback(account, session, contact, step)
+		}
+
+		if len(products) == 1 {
+			req.InteractiveType = "product"
+			req.ProductRetailerID = products[0].RetailerID
+		} else {
+			header := processTemplate(getStringFromMap(cfg, "header"), session.SessionData)
+			if header == "" {
+				header = catalog.Name
+			}
+			if message == "" {
+				message = header
+				req.BodyText = message
+			}
+			ids := make([]string, len(products))
+			for i, p := range products {
+				ids[i] = p.RetailerID
+			}
+			req.InteractiveType = "product_list"
+			req.HeaderText = header
+			req.ProductSections = []whatsapp.ProductSection{{Title: header, RetailerIDs: ids}}
+		}
+	}
+
+	if _, err := a.SendOutgoingMessage(context.Background(), req, ChatbotSendOptions()); err != nil {
+		a.Log.Error("Failed to send product message", "error", err, "contact", contact.PhoneNumber)
+	}
+	return message
+}
+
+// sendProductStepFallback sends input_config.no_results_message when a product step
+// has nothing to show. Returns the message that was sent, or "" if none is configured.
+func (a *App) sendProductStepFallback(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep) string {
+	message := processTemplate(getStringFromMap(step.InputConfig, "no_results_message"), session.SessionData)
+	if message == "" {
+		return ""
+	}
+	if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+		a.Log.Error("Failed to send product fallback message", "error", err, "contact", contact.PhoneNumber)
+	}
+	return message
+}
+
+// resolveStepCatalog loads the catalog a product step sends from. Without an
+// explicit ID the account's oldest active catalog is used.
+func (a *App) resolveStepCatalog(account *models.WhatsAppAccount, catalogID string) (*models.Catalog, error) {
+	var catalog models.Catalog
+	query := a.DB.Where("organization_id = ? AND is_active = ?", account.OrganizationID, true)
+	if catalogID != "" {
+		id, err := uuid.Parse(catalogID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid catalog_id: %w", err)
+		}
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("whats_app_account = ?", account.Name).Order("created_at ASC")
+	}
+	if err := query.First(&catalog).Error; err != nil {
+		return nil, err
+	}
+	if catalog.MetaCatalogID == "" {
+		return nil, fmt.Errorf("catalog %s is not synced to Meta", catalog.ID)
+	}
+	return &catalog, nil
+}
+
+// findStepProducts returns active products from the catalog, either the given
+// retailer IDs (in the order listed) or those matching query, capped at limit.
+func (a *App) findStepProducts(catalog *models.Catalog, retailerIDs []string, query string, limit int) ([]models.CatalogProduct, error) {
+	if limit > whatsapp.MaxProductsPerList {
+		limit = whatsapp.MaxProductsPerList
+	}
+
+	db := a.DB.Where("catalog_id = ? AND is_active = ? AND retailer_id <> ''", catalog.ID, true)
+
+	var products []models.CatalogProduct
+	if len(retailerIDs) > 0 {
+		if err := db.Where("retailer_id IN ?", retailerIDs).Find(&products).Error; err != nil {
+			return nil, err
+		}
+		byID := make(map[string]models.CatalogProduct, len(products))
+		for _, p := range products {
+			byID[p.RetailerID] = p
+		}
+		ordered := make([]models.CatalogProduct, 0, len(products))
+		for _, id := range retailerIDs {
+			if p, ok := byID[id]; ok && len(ordered) < limit {
+				ordered = append(ordered, p)
+				delete(byID, id)
+			}
+		}
+		return ordered, nil
+	}
+
+	pattern := "%" + query + "%"
+	if err := db.Where("name ILIKE ? OR description ILIKE ?", pattern, pattern).
+		Order("name ASC").Limit(limit).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// stepRetailerIDs reads retailer_ids from step config. Both an array and a
+// comma-separated string are accepted, and each entry may use {{variables}}.
+func stepRetailerIDs(raw interface{}, sessionData models.JSONB) []string {
+	var entries []string
+	switch v := raw.(type) {
+	case string:
+		entries = strings.Split(processTemplate(v, sessionData), ",")
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				entries = append(entries, processTemplate(s, sessionData))
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e = strings.TrimSpace(e); e != "" {
+			ids = append(ids, e)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createStepCatalog creates a synced catalog for the account with the given products (retailer ID -> name).
+func createStepCatalog(t *testing.T, app *App, account *models.WhatsAppAccount, products map[string]string) *models.Catalog {
+	t.Helper()
+	catalog := &models.Catalog{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  account.OrganizationID,
+		WhatsAppAccount: account.Name,
+		MetaCatalogID:   "meta-cat-" + uuid.New().String()[:8],
+		Name:            "Shop",
+		IsActive:        true,
+	}
+	require.NoError(t, app.DB.Create(catalog).Error)
+
+	for retailerID, name := range products {
+		require.NoError(t, app.DB.Create(&models.CatalogProduct{
+			BaseModel:      models.BaseModel{ID: uuid.New()},
+			OrganizationID: account.OrganizationID,
+			CatalogID:      catalog.ID,
+			MetaProductID:  "meta-prod-" + uuid.New().String()[:8],
+			Name:           name,
+			Price:          1000,
+			Currency:       "USD",
+			RetailerID:     retailerID,
+			IsActive:       true,
+		}).Error)
+	}
+	return catalog
+}
+
+// sendProductStepForTest runs a product step and returns the stored outgoing message.
+func sendProductStepForTest(t *testing.T, app *App, account *models.WhatsAppAccount, message string, config models.JSONB, sessionData models.JSONB) *models.Message {
+	t.Helper()
+	contact := testutil.CreateTestContact(t, app.DB, account.OrganizationID)
+	session := &models.ChatbotSession{BaseModel: models.BaseModel{ID: uuid.New()}, SessionData: sessionData}
+	step := &models.ChatbotFlowStep{StepName: "products", MessageType: models.FlowStepTypeProduct, Message: message, InputConfig: config}
+
+	app.sendProductStep(account, session, contact, step)
+
+	var msg models.Message
+	require.NoError(t, app.DB.Where("contact_id = ?", contact.ID).First(&msg).Error)
+	return &msg
+}
+
+func TestSendProductStep_RetailerIDsFromSession(t *testing.T) {
+	app := newProcessorTestApp(t)
+	_, account := createProcessorTestOrg(t, app)
+	catalog := createStepCatalog(t, app, account, map[string]string{"SKU-1": "Red Shirt", "SKU-2": "Blue Shirt", "SKU-3": "Hat"})
+
+	msg := sendProductStepForTest(t, app, account, "Picked for you",
+		models.JSONB{"retailer_ids": "{{picks}}, SKU-404"},
+		models.JSONB{"picks": "SKU-3,SKU-1"})
+
+	assert.Equal(t, models.MessageTypeInteractive, msg.MessageType)
+	assert.Equal(t, models.MessageStatusSent, msg.Status)
+	assert.Equal(t, "product_list", msg.InteractiveData["type"])
+	assert.Equal(t, catalog.MetaCatalogID, msg.InteractiveData["catalog_id"])
+	assert.Equal(t, "Shop", msg.InteractiveData["header"], "header defaults to the catalog name")
+
+	sections := msg.InteractiveData["sections"].([]interface{})
+	require.Len(t, sections, 1)
+	ids := sections[0].(map[string]interface{})["product_retailer_ids"].([]interface{})
+	assert.Equal(t, []interface{}{"SKU-3", "SKU-1"}, ids, "order follows retailer_ids and unknown IDs are dropped")
+}
+
+func TestSendProductStep_QuerySingleMatchSendsProduct(t *testing.T) {
+	app := newProcessorTestApp(t)
+	_, account := createProcessorTestOrg(t, app)
+	createStepCatalog(t, app, account, map[string]string{"SKU-1": "Red Shirt", "SKU-2": "Blue Shirt", "SKU-3": "Hat"})
+
+	msg := sendProductStepForTest(t, app, account, "",
+		models.JSONB{"product_query": "{{search}}"},
+		models.JSONB{"search": "hat"})
+
+	assert.Equal(t, "product", msg.InteractiveData["type"])
+	assert.Equal(t, "SKU-3", msg.InteractiveData["product_retailer_id"])
+}
+
+func TestSendProductStep_NoSelectionSendsCatalog(t *testing.T) {
+	app := newProcessorTestApp(t)
+	_, account := createProcessorTestOrg(t, app)
+	createStepCatalog(t, app, account, map[string]string{"SKU-1": "Red Shirt"})
+
+	msg := sendProductStepForTest(t, app, account, "Browse everything", models.JSONB{}, models.JSONB{})
+
+	assert.Equal(t, "catalog_message", msg.InteractiveData["type"])
+	assert.Equal(t, "Browse everything", msg.Content)
+}
+
+func TestSendProductStep_NoResultsFallback(t *testing.T) {
+	app := newProcessorTestApp(t)
+	_, account := createProcessorTestOrg(t, app)
+	createStepCatalog(t, app, account, map[string]string{"SKU-1": "Red Shirt"})
+
+	msg := sendProductStepForTest(t, app, account, "",
+		models.JSONB{"product_query": "umbrella", "no_results_message": "Nothing matched {{search}}"},
+		models.JSONB{"search": "umbrella"})
+
+	assert.Equal(t, models.MessageTypeText, msg.MessageType)
+	assert.Equal(t, "Nothing matched umbrella", msg.Content)
+}
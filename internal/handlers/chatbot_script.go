@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dop251/goja"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// Limits for chatbot scripts (keyword rule "script" responses and "script" flow steps).
+// Scripts are authored by org admins but run on every matching incoming message, so a
+// runaway loop or allocation must not be able to stall the webhook handler.
+const (
+	scriptCPUTimeout     = 2 * time.Second    // Time spent executing JS, excluding fetch() waits
+	scriptMemoryLimit    = 1024 * 1024 * 1024 // Process-wide bytes allocated while the script runs, see watchScriptMemory
+	scriptMaxCallStack   = 512
+	scriptMaxFetches     = 3
+	scriptFetchTimeout   = 5 * time.Second
+	scriptMaxFetchBody   = 256 * 1024
+	scriptMaxMessageLen  = 4096 // WhatsApp text body limit
+	scriptMaxButtons     = 10   // 3 = buttons, 4-10 = list
+	scriptMaxLogEntries  = 20
+	scriptMemoryInterval = 5 * time.Millisecond
+)
+
+var (
+	errScriptTimeout = errors.New("script exceeded CPU time limit")
+	errScriptMemory  = errors.New("script exceeded memory limit")
+)
+
+// ScriptInput is the data a chatbot script can read
+type ScriptInput struct {
+	Session map[string]interface{} // Session variables (copy; changes must be returned via "variables")
+	Contact *models.Contact
+	Input   string // Incoming message text (keyword responses only; flow steps read earlier answers from Session)
+}
+
+// ScriptResult is what a chatbot script returns
+type ScriptResult struct {
+	Message   string
+	Buttons   []map[string]interface{}
+	Variables map[string]interface{}
+}
+
+// sendScriptKeywordResponse runs a keyword rule's script and sends its reply. When the
+// script fails, the rule's body (if any) is sent as a fallback.
+func (a *App) sendScriptKeywordResponse(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, response *KeywordResponse, messageText string) {
+	result, err := a.runChatbotScript(context.Background(), response.Script, ScriptInput{
+		Session: session.SessionData,
+		Contact: contact,
+		Input:   messageText,
+	})
+	if err != nil {
+		a.Log.Error("Keyword rule script failed", "error", err, "contact", contact.PhoneNumber)
+		if response.Body != "" {
+			if err := a.sendAndSaveTextMessage(account, contact, response.Body); err != nil {
+				a.Log.Error("Failed to send script fallback message", "error", err, "contact", contact.PhoneNumber)
+			}
+			a.logSessionMessage(session.ID, models.DirectionOutgoing, response.Body, "keyword_response")
+		}
+		return
+	}
+
+	a.storeScriptVariables(session, result.Variables)
+	if a.sendScriptReply(account, contact, result) {
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, result.Message, "keyword_response")
+	}
+}
+
+// sendScriptStep runs a script flow step (code in api_config.script) and sends its reply.
+// On failure api_config.fallback_message is sent instead, mirroring api_fetch steps.
+// Returns the message that was sent, or "" if nothing was sent.
+func (a *App) sendScriptStep(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep) string {
+	code := getStringFromMap(step.ApiConfig, "script")
+	result, err := a.runChatbotScript(context.Background(), code, ScriptInput{
+		Session: session.SessionData,
+		Contact: contact,
+	})
+	if err != nil {
+		a.Log.Error("Flow step script failed", "error", err, "step", step.StepName)
+		fallback := getStringFromMap(step.ApiConfig, "fallback_message")
+		if fallback == "" {
+			fallback = "Sorry, there was an error processing your request."
+		}
+		message := processTemplate(fallback, session.SessionData)
+		if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+			a.Log.Error("Failed to send script fallback message", "error", err, "contact", contact.PhoneNumber)
+		}
+		return message
+	}
+
+	a.storeScriptVariables(session, result.Variables)
+	if !a.sendScriptReply(account, contact, result) {
+		return ""
+	}
+	return result.Message
+}
+
+// storeScriptVariables merges variables returned by a script into the session data
+func (a *App) storeScriptVariables(session *models.ChatbotSession, variables map[string]interface{}) {
+	if len(variables) == 0 {
+		return
+	}
+	if session.SessionData == nil {
+		session.SessionData = models.JSONB{}
+	}
+	for k, v := range variables {
+		session.SessionData[k] = v
+	}
+	a.DB.Model(session).Update("session_data", session.SessionData)
+}
+
+// sendScriptReply sends a script's message, as interactive buttons when it returned any.
+// A script may return no message (e.g. a step that only sets variables); nothing is sent then.
+func (a *App) sendScriptReply(account *models.WhatsAppAccount, contact *models.Contact, result *ScriptResult) bool {
+	if result.Message == "" {
+		return false
+	}
+	if len(result.Buttons) > 0 {
+		if err := a.sendAndSaveInteractiveButtons(account, contact, result.Message, result.Buttons); err != nil {
+			a.Log.Error("Failed to send script buttons", "error", err, "contact", contact.PhoneNumber)
+		}
+		return true
+	}
+	if err := a.sendAndSaveTextMessage(account, contact, result.Message); err != nil {
+		a.Log.Error("Failed to send script message", "error", err, "contact", contact.PhoneNumber)
+	}
+	return true
+}
+
+// validateChatbotScript checks that a script compiles, so syntax errors are reported
+// when the rule or flow is saved instead of when a customer triggers it
+func validateChatbotScript(code string) error {
+	if strings.TrimSpace(code) == "" {
+		return fmt.Errorf("script is required")
+	}
+	if _, err := goja.Compile("script", wrapChatbotScript(code), true); err != nil {
+		return fmt.Errorf("script does not compile: %w", err)
+	}
+	return nil
+}
+
+// wrapChatbotScript wraps user code in a function so it can use return
+func wrapChatbotScript(code string) string {
+	return fmt.Sprintf("(function(session, contact, input) { %s\n})(session, contact, input)", code)
+}
+
+// runChatbotScript executes a chatbot script in the same goja runtime used by custom
+// JavaScript actions, with CPU time, memory and call stack limits. Scripts may return
+// a string (the reply text) or an object {message, buttons, variables}.
+func (a *App) runChatbotScript(ctx context.Context, code string, in ScriptInput) (*ScriptResult, error) {
+	if strings.TrimSpace(code) == "" {
+		return nil, fmt.Errorf("script is empty")
+	}
+
+	vm := goja.New()
+	vm.SetMaxCallStackSize(scriptMaxCallStack)
+
+	budget := newScriptBudget(vm, scriptCPUTimeout)
+	defer budget.stop()
+
+	stopMemWatch := watchScriptMemory(vm, scriptMemoryLimit)
+	defer stopMemWatch()
+
+	session := make(map[string]interface{}, len(in.Session))
+	for k, v := range in.Session {
+		session[k] = v
+	}
+	_ = vm.Set("session", session)
+	_ = vm.Set("contact", scriptContact(in.Contact))
+	_ = vm.Set("input", in.Input)
+
+	var logs []string
+	_ = vm.Set("log", func(call goja.FunctionCall) goja.Value {
+		if len(logs) >= scriptMaxLogEntries {
+			return goja.Undefined()
+		}
+		parts := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			parts[i] = arg.String()
+		}
+		logs = append(logs, strings.Join(parts, " "))
+		return goja.Undefined()
+	})
+
+	fetches := 0
+	_ = vm.Set("fetch", func(call goja.FunctionCall) goja.Value {
+		fetches++
+		if fetches > scriptMaxFetches {
+			panic(vm.NewGoError(fmt.Errorf("fetch limit of %d calls exceeded", scriptMaxFetches)))
+		}
+		rawURL := call.Argument(0).String()
+		var opts map[string]interface{}
+		if o := call.Argument(1); !goja.IsUndefined(o) && !goja.IsNull(o) {
+			opts, _ = o.Export().(map[string]interface{})
+		}
+
+		// Waiting on the network doesn't count against the CPU budget
+		budget.pause()
+		resp, err := a.scriptFetch(ctx, rawURL, opts)
+		budget.resume()
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return vm.ToValue(resp)
+	})
+
+	val, err := vm.RunString(wrapChatbotScript(code))
+	for _, line := range logs {
+		a.Log.Debug("Chatbot script log", "message", line)
+	}
+	if err != nil {
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			if v, ok := interrupted.Value().(error); ok {
+				return nil, v
+			}
+		}
+		return nil, fmt.Errorf("script error: %w", err)
+	}
+
+	return parseScriptResult(val)
+}
+
+// parseScriptResult converts a script's return value into a ScriptResult
+func parseScriptResult(val goja.Value) (*ScriptResult, error) {
+	result := &ScriptResult{}
+	if val == nil || goja.IsUndefined(val) || goja.IsNull(val) {
+		return result, nil
+	}
+
+	switch exported := val.Export().(type) {
+	case string:
+		result.Message = exported
+	case map[string]interface{}:
+		if msg, ok := exported["message"]; ok && msg != nil {
+			result.Message = fmt.Sprintf("%v", msg)
+		}
+		if buttons, ok := exported["buttons"].([]interface{}); ok {
+			for i, btn := range buttons {
+				btnMap, ok := btn.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				title := getStringFromMap(btnMap, "title")
+				if title == "" {
+					continue
+				}
+				id := getStringFromMap(btnMap, "id")
+				if id == "" {
+					id = fmt.Sprintf("btn_%d", i+1)
+				}
+				result.Buttons = append(result.Buttons, map[string]interface{}{"id": id, "title": title})
+			}
+		}
+		if vars, ok := exported["variables"].(map[string]interface{}); ok {
+			result.Variables = vars
+		}
+	default:
+		return nil, fmt.Errorf("script must return a string or an object, got %T", exported)
+	}
+
+	if len(result.Message) > scriptMaxMessageLen {
+		// Cut on a rune boundary so the reply stays valid UTF-8
+		cut := scriptMaxMessageLen
+		for cut > 0 && !utf8.RuneStart(result.Message[cut]) {
+			cut--
+		}
+		result.Message = result.Message[:cut]
+	}
+	if len(result.Buttons) > scriptMaxButtons {
+		result.Buttons = result.Buttons[:scriptMaxButtons]
+	}
+	return result, nil
+}
+
+// scriptContact exposes the contact fields a script may read
+func scriptContact(contact *models.Contact) map[string]interface{} {
+	if contact == nil {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"id":           contact.ID.String(),
+		"phone_number": contact.PhoneNumber,
+		"name":         contact.ProfileName,
+		"profile_name": contact.ProfileName,
+		"tags":         []interface{}(contact.Tags),
+		"metadata":     map[string]interface{}(contact.Metadata),
+	}
+}
+
+// scriptFetch performs the controlled HTTP request behind the script fetch() helper.
+// URLs go through the same validation as webhooks and the request uses the shared
+// SSRF-safe client, with its own timeout and a capped response body.
+func (a *App) scriptFetch(ctx context.Context, rawURL string, opts map[string]interface{}) (map[string]interface{}, error) {
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+
+	method := strings.ToUpper(getStringFromMap(opts, "method"))
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	switch b := opts["body"].(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("fetch: invalid body: %w", err)
+		}
+		body = strings.NewReader(string(encoded))
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, scriptFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, method, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if headers, ok := opts["headers"].(map[string]interface{}); ok {
+		for key, value := range headers {
+			req.Header.Set(key, fmt.Sprintf("%v", value))
+		}
+	}
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, scriptMaxFetchBody))
+	if err != nil {
+		return nil, fmt.Errorf("fetch: failed to read response: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"status": resp.StatusCode,
+		"ok":     resp.StatusCode >= 200 && resp.StatusCode < 300,
+		"body":   string(respBody),
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(respBody, &parsed); err == nil {
+		result["json"] = parsed
+	}
+	return result, nil
+}
+
+// scriptBudget interrupts a VM once it has spent its CPU budget. The clock can be
+// paused while the script waits on I/O so slow upstream APIs don't eat into it.
+type scriptBudget struct {
+	mu        sync.Mutex
+	vm        *goja.Runtime
+	timer     *time.Timer
+	remaining time.Duration
+	started   time.Time
+}
+
+func newScriptBudget(vm *goja.Runtime, limit time.Duration) *scriptBudget {
+	b := &scriptBudget{vm: vm, remaining: limit}
+	b.resume()
+	return b
+}
+
+func (b *scriptBudget) resume() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.started = time.Now()
+	b.timer = time.AfterFunc(b.remaining, func() {
+		b.vm.Interrupt(errScriptTimeout)
+	})
+}
+
+func (b *scriptBudget) pause() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.timer != nil && b.timer.Stop() {
+		b.remaining -= time.Since(b.started)
+	}
+	b.timer = nil
+}
+
+func (b *scriptBudget) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+}
+
+// watchScriptMemory interrupts the VM if heap allocations grow past limit while the
+// script runs. goja has no per-runtime memory accounting, so this samples the
+// process-wide cumulative allocation counter: allocations made by other goroutines
+// (webhooks, campaigns, other scripts) count too. It is only a coarse guard against
+// runaway string/array growth that could take the process down, not a per-script
+// quota, so the limit is set far above what a busy server allocates in the few
+// seconds a script may run. The CPU timeout remains the primary limit.
+func watchScriptMemory(vm *goja.Runtime, limit uint64) func() {
+	sample := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(sample)
+	baseline := sample[0].Value.Uint64()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(scriptMemoryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				metrics.Read(sample)
+				if sample[0].Value.Uint64()-baseline > limit {
+					vm.Interrupt(errScriptMemory)
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/dop251/goja"
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newScriptTestApp() *App {
+	return &App{Log: testutil.NopLogger()}
+}
+
+func TestRunChatbotScript_ReturnsMessageButtonsAndVariables(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	contact := &models.Contact{
+		BaseModel:   models.BaseModel{ID: uuid.New()},
+		PhoneNumber: "919876543210",
+		ProfileName: "Alice",
+	}
+	code := `
+		var total = session.qty * 10;
+		return {
+			message: "Hi " + contact.name + ", you said " + input + ". Total: " + total,
+			buttons: [{id: "pay", title: "Pay now"}, {title: "Cancel"}],
+			variables: {total: total}
+		};`
+
+	result, err := app.runChatbotScript(context.Background(), code, ScriptInput{
+		Session: map[string]interface{}{"qty": 3},
+		Contact: contact,
+		Input:   "order",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Alice, you said order. Total: 30", result.Message)
+	require.Len(t, result.Buttons, 2)
+	assert.Equal(t, "pay", result.Buttons[0]["id"])
+	assert.Equal(t, "btn_2", result.Buttons[1]["id"])
+	assert.EqualValues(t, 30, result.Variables["total"])
+}
+
+func TestRunChatbotScript_StringReturn(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	result, err := app.runChatbotScript(context.Background(), `return "plain reply";`, ScriptInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "plain reply", result.Message)
+	assert.Empty(t, result.Buttons)
+}
+
+func TestRunChatbotScript_SessionIsACopy(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	session := map[string]interface{}{"name": "Alice"}
+	_, err := app.runChatbotScript(context.Background(), `session.name = "Mallory"; return "";`, ScriptInput{Session: session})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", session["name"])
+}
+
+func TestRunChatbotScript_CPUTimeLimit(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	_, err := app.runChatbotScript(context.Background(), `while (true) {}`, ScriptInput{})
+	assert.ErrorIs(t, err, errScriptTimeout)
+}
+
+func TestRunChatbotScript_RunawayAllocationIsStopped(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	// Whichever guard trips first, the script must not run unbounded
+	_, err := app.runChatbotScript(context.Background(), `var a = []; while (true) { a.push(new Array(100000).fill("x")); }`, ScriptInput{})
+	require.Error(t, err)
+}
+
+func TestWatchScriptMemory_InterruptsPastLimit(t *testing.T) {
+	t.Parallel()
+
+	vm := goja.New()
+	stop := watchScriptMemory(vm, 8*1024*1024)
+	defer stop()
+
+	_, err := vm.RunString(`var a = []; while (true) { a.push(new Array(1000).fill("x")); }`)
+	assert.ErrorIs(t, err, errScriptMemory)
+}
+
+func TestRunChatbotScript_TruncatesMessageOnRuneBoundary(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	// "é" is two bytes, so byte 4096 falls in the middle of a rune
+	result, err := app.runChatbotScript(context.Background(), `return "a" + "é".repeat(3000);`, ScriptInput{})
+	require.NoError(t, err)
+	assert.True(t, utf8.ValidString(result.Message))
+	assert.Len(t, result.Message, scriptMaxMessageLen-1)
+}
+
+func TestRunChatbotScript_StackLimit(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	_, err := app.runChatbotScript(context.Background(), `function f() { return f(); } return f();`, ScriptInput{})
+	assert.Error(t, err)
+}
+
+func TestRunChatbotScript_FetchBlocksInternalAddresses(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	_, err := app.runChatbotScript(context.Background(), `return fetch("http://127.0.0.1:8080/admin").body;`, ScriptInput{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "internal addresses")
+}
+
+func TestRunChatbotScript_NoHostAccess(t *testing.T) {
+	t.Parallel()
+	app := newScriptTestApp()
+
+	result, err := app.runChatbotScript(context.Background(), `return typeof require + "," + typeof process;`, ScriptInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "undefined,undefined", result.Message)
+}
+
+func TestValidateChatbotScript(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, validateChatbotScript(`return "ok";`))
+	assert.Error(t, validateChatbotScript(""))
+	assert.Error(t, validateChatbotScript(`return {;`))
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// maxBlockedReasonLength caps the note agents keep on why a contact was blocked
+const maxBlockedReasonLength = 500
+
+var errContactBlocked = errors.New("contact is blocked")
+
+// BlockContactRequest is the body of POST /api/contacts/{id}/block
+type BlockContactRequest struct {
+	Reason string `json:"reason"`
+}
+
+// BlockContact blocks a contact on the WhatsApp number they message, so Meta
+// stops delivering their messages. Blocked contacts are skipped by the
+// chatbot, the transfer queue and campaigns.
+func (a *App) BlockContact(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+
+	var req BlockContactRequest
+	if len(r.RequestCtx.PostBody()) > 0 {
+		if err := a.decodeRequest(r, &req); err != nil {
+			return nil
+		}
+	}
+	if len(req.Reason) > maxBlockedReasonLength {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "reason must be at most "+strconv.Itoa(maxBlockedReasonLength)+" characters", nil, "")
+	}
+
+	contact, err := findByIDAndOrg[models.Contact](a.DB, r, contactID, orgID, "Contact")
+	if err != nil {
+		return nil
+	}
+	if contact.IsBlocked {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Contact is already blocked", nil, "")
+	}
+
+	account, err := a.resolveWhatsAppAccount(orgID, contact.WhatsAppAccount)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	result, err := a.WhatsApp.BlockUsers(r.RequestCtx, a.toWhatsAppAccount(account), []string{contact.PhoneNumber})
+	if err != nil {
+		return a.sendBlockUsersError(r, err, "Failed to block contact")
+	}
+	if len(result.Failed) > 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to block contact: "+result.Failed[0].Reason(), nil, "")
+	}
+
+	now := time.Now()
+	if err := a.DB.Model(contact).Updates(map[string]any{
+		"is_blocked":     true,
+		"blocked_reason": req.Reason,
+		"blocked_at":     now,
+		"blocked_by_id":  userID,
+	}).Error; err != nil {
+		a.Log.Error("Failed to save contact block", "error", err, "contact_id", contact.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Contact blocked on WhatsApp but failed to save", nil, "")
+	}
+	contact.IsBlocked = true
+	contact.BlockedReason = req.Reason
+	contact.BlockedAt = &now
+	contact.BlockedByID = &userID
+
+	a.releaseBlockedContact(contact, userID)
+
+	a.Log.Info("Contact blocked", "contact_id", contact.ID, "account", account.Name, "user_id", userID)
+	return r.SendEnvelope(a.buildContactResponse(contact, orgID))
+}
+
+// UnblockContact lets a blocked contact message the business again
+func (a *App) UnblockContact(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+
+	contact, err := findByIDAndOrg[models.Contact](a.DB, r, contactID, orgID, "Contact")
+	if err != nil {
+		return nil
+	}
+	if !contact.IsBlocked {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Contact is not blocked", nil, "")
+	}
+
+	account, err := a.resolveWhatsAppAccount(orgID, contact.WhatsAppAccount)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	result, err := a.WhatsApp.UnblockUsers(r.RequestCtx, a.toWhatsAppAccount(account), []string{contact.PhoneNumber})
+	if err != nil {
+		return a.sendBlockUsersError(r, err, "Failed to unblock contact")
+	}
+	if len(result.Failed) > 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to unblock contact: "+result.Failed[0].Reason(), nil, "")
+	}
+
+	if err := a.DB.Model(contact).Updates(map[string]any{
+		"is_blocked":     false,
+		"blocked_reason": "",
+		"blocked_at":     nil,
+		"blocked_by_id":  nil,
+	}).Error; err != nil {
+		a.Log.Error("Failed to save contact unblock", "error", err, "contact_id", contact.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Contact unblocked on WhatsApp but failed to save", nil, "")
+	}
+	contact.IsBlocked = false
+	contact.BlockedReason = ""
+	contact.BlockedAt = nil
+	contact.BlockedByID = nil
+
+	a.Log.Info("Contact unblocked", "contact_id", contact.ID, "account", account.Name, "user_id", userID)
+	return r.SendEnvelope(a.buildContactResponse(contact, orgID))
+}
+
+// ListBlockedUsers returns a page of an account's block list as Meta keeps
+// it, which includes users blocked outside Whatomate
+func (a *App) ListBlockedUsers(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionRead); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "account")
+	if err != nil {
+		return nil
+	}
+	account, err := a.resolveWhatsAppAccountByID(r, id, orgID)
+	if err != nil {
+		return nil
+	}
+
+	limit, _ := strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("limit")))
+	after := string(r.RequestCtx.QueryArgs().Peek("after"))
+
+	page, err := a.WhatsApp.ListBlockedUsers(r.RequestCtx, a.toWhatsAppAccount(account), limit, after)
+	if err != nil {
+		return a.sendBlockUsersError(r, err, "Failed to list blocked users")
+	}
+
+	waIDs := make([]string, len(page.Users))
+	for i, u := range page.Users {
+		waIDs[i] = u.WaID
+	}
+	if a.ShouldMaskPhoneNumbers(orgID) {
+		for i := range waIDs {
+			waIDs[i] = MaskPhoneNumber(waIDs[i])
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"users": waIDs,
+		"after": page.After,
+	})
+}
+
+// sendBlockUsersError reports a failed block_users call. Meta's own
+// explanation is passed on when it rejected the request.
+func (a *App) sendBlockUsersError(r *fastglue.Request, err error, msg string) error {
+	a.Log.Error(msg, "error", err)
+	if apiErr, ok := whatsapp.AsAPIError(err); ok && apiErr.Class() == whatsapp.ErrorClassPermanent {
+		reason := apiErr.Message
+		if apiErr.Details != "" {
+			reason = apiErr.Details
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, msg+": "+reason, nil, "")
+	}
+	return r.SendErrorEnvelope(fasthttp.StatusBadGateway, msg, nil, "")
+}
+
+// releaseBlockedContact ends a blocked contact's chatbot sessions and
+// resumes their active transfers so they leave the agent queue
+func (a *App) releaseBlockedContact(contact *models.Contact, userID uuid.UUID) {
+	now := time.Now()
+	if err := a.DB.Model(&models.ChatbotSession{}).
+		Where("organization_id = ? AND contact_id = ? AND status = ?", contact.OrganizationID, contact.ID, models.SessionStatusActive).
+		Updates(map[string]any{
+			"status":       models.SessionStatusCancelled,
+			"completed_at": now,
+		}).Error; err != nil {
+		a.Log.Error("Failed to cancel chatbot sessions of blocked contact", "error", err, "contact_id", contact.ID)
+	}
+	a.ClearContactChatbotTracking(contact.ID)
+
+	var transfers []models.AgentTransfer
+	if err := a.DB.Where("organization_id = ? AND contact_id = ? AND status = ?",
+		contact.OrganizationID, contact.ID, models.TransferStatusActive).Find(&transfers).Error; err != nil {
+		a.Log.Error("Failed to load transfers of blocked contact", "error", err, "contact_id", contact.ID)
+		return
+	}
+
+	for i := range transfers {
+		transfer := &transfers[i]
+		if err := a.DB.Model(transfer).Updates(map[string]any{
+			"status":     models.TransferStatusResumed,
+			"resumed_at": now,
+			"resumed_by": userID,
+		}).Error; err != nil {
+			a.Log.Error("Failed to close transfer of blocked contact", "error", err, "transfer_id", transfer.ID)
+			continue
+		}
+		a.broadcastTransferResumed(transfer)
+	}
+}
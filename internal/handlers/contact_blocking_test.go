@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestApp_BlockContact(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID,
+		testutil.WithPhoneNumber("15551234567"), testutil.WithContactAccount(account.Name))
+	transfer := createTestAgentTransfer(t, app, orgID, contact.ID, nil,
+		models.TransferStatusActive, models.TransferSourceManual, time.Now(), nil)
+
+	req := phoneRequest(t, handlers.BlockContactRequest{Reason: "Abusive messages"}, contact.ID, orgID, userID)
+	require.NoError(t, app.BlockContact(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.ContactResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.True(t, resp.Data.IsBlocked)
+	assert.Equal(t, "blocked", resp.Data.Status)
+	assert.Equal(t, "Abusive messages", resp.Data.BlockedReason)
+	assert.Equal(t, []string{"15551234567"}, srv.BlockedUsers(account.PhoneID))
+
+	var updated models.Contact
+	require.NoError(t, app.DB.First(&updated, contact.ID).Error)
+	assert.True(t, updated.IsBlocked)
+	require.NotNil(t, updated.BlockedByID)
+	assert.Equal(t, userID, *updated.BlockedByID)
+
+	// The contact's transfer leaves the queue
+	var closed models.AgentTransfer
+	require.NoError(t, app.DB.First(&closed, transfer.ID).Error)
+	assert.Equal(t, models.TransferStatusResumed, closed.Status)
+
+	// Blocking twice is a conflict
+	req = phoneRequest(t, nil, contact.ID, orgID, userID)
+	require.NoError(t, app.BlockContact(req))
+	assert.Equal(t, fasthttp.StatusConflict, testutil.GetResponseStatusCode(req))
+
+	req = phoneRequest(t, nil, contact.ID, orgID, userID)
+	require.NoError(t, app.UnblockContact(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	assert.Empty(t, srv.BlockedUsers(account.PhoneID))
+
+	require.NoError(t, app.DB.First(&updated, contact.ID).Error)
+	assert.False(t, updated.IsBlocked)
+	assert.Empty(t, updated.BlockedReason)
+	assert.Nil(t, updated.BlockedAt)
+}
+
+func TestApp_BlockContact_MetaRejects(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID,
+		testutil.WithPhoneNumber("not-a-number"), testutil.WithContactAccount(account.Name))
+
+	req := phoneRequest(t, nil, contact.ID, orgID, userID)
+	require.NoError(t, app.BlockContact(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	assert.Contains(t, string(testutil.GetResponseBody(req)), "Invalid user")
+	assert.Empty(t, srv.BlockedUsers(account.PhoneID))
+
+	var updated models.Contact
+	require.NoError(t, app.DB.First(&updated, contact.ID).Error)
+	assert.False(t, updated.IsBlocked)
+}
+
+func TestApp_BlockContact_RequiresContactsWrite(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, _ := phoneTestSetup(t)
+	agentRole := testutil.CreateAgentRole(t, app.DB, orgID)
+	agent := testutil.CreateTestUser(t, app.DB, orgID, testutil.WithRoleID(&agentRole.ID))
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID,
+		testutil.WithPhoneNumber("15551234567"), testutil.WithContactAccount(account.Name))
+
+	req := phoneRequest(t, nil, contact.ID, orgID, agent.ID)
+	require.NoError(t, app.BlockContact(req))
+	assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+	assert.Empty(t, srv.BlockedUsers(account.PhoneID))
+}
+
+func TestApp_ListBlockedUsers(t *testing.T) {
+	t.Parallel()
+	app, _, account, orgID, userID := phoneTestSetup(t)
+	for _, phone := range []string{"15551230001", "15551230002", "15551230003"} {
+		contact := testutil.CreateTestContactWith(t, app.DB, orgID,
+			testutil.WithPhoneNumber(phone), testutil.WithContactAccount(account.Name))
+		req := phoneRequest(t, nil, contact.ID, orgID, userID)
+		require.NoError(t, app.BlockContact(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	}
+
+	var resp struct {
+		Data struct {
+			Users []string `json:"users"`
+			After string   `json:"after"`
+		} `json:"data"`
+	}
+	req := phoneRequest(t, nil, account.ID, orgID, userID)
+	req.RequestCtx.QueryArgs().Set("limit", "2")
+	require.NoError(t, app.ListBlockedUsers(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, []string{"15551230001", "15551230002"}, resp.Data.Users)
+	require.NotEmpty(t, resp.Data.After)
+
+	req = phoneRequest(t, nil, account.ID, orgID, userID)
+	req.RequestCtx.QueryArgs().Set("after", resp.Data.After)
+	require.NoError(t, app.ListBlockedUsers(req))
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, []string{"15551230003"}, resp.Data.Users)
+	assert.Empty(t, resp.Data.After)
+}
+
+func TestApp_CreateAgentTransfer_BlockedContact(t *testing.T) {
+	t.Parallel()
+	app, _, account, orgID, userID := phoneTestSetup(t)
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID,
+		testutil.WithPhoneNumber("15551234567"), testutil.WithContactAccount(account.Name))
+	require.NoError(t, app.DB.Model(contact).Update("is_blocked", true).Error)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"contact_id":       contact.ID.String(),
+		"whatsapp_account": account.Name,
+	})
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.CreateAgentTransfer(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/suppression"
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 	"github.com/valyala/fasthttp"
@@ -23,23 +24,31 @@ import (
 
 // ContactResponse represents a contact with additional fields for the frontend
 type ContactResponse struct {
-	ID                 uuid.UUID  `json:"id"`
-	PhoneNumber        string     `json:"phone_number"`
-	Name               string     `json:"name"`
-	ProfileName        string     `json:"profile_name"`
-	AvatarURL          string     `json:"avatar_url"`
-	Status             string     `json:"status"`
-	Tags               []string   `json:"tags"`
-	Metadata           any        `json:"metadata"`
-	LastMessageAt      *time.Time `json:"last_message_at"`
-	LastMessagePreview string     `json:"last_message_preview"`
-	UnreadCount        int        `json:"unread_count"`
-	AssignedUserID     *uuid.UUID `json:"assigned_user_id,omitempty"`
-	WhatsAppAccount    string     `json:"whatsapp_account,omitempty"`
-	LastInboundAt      *time.Time `json:"last_inbound_at,omitempty"`
-	ServiceWindowOpen  bool       `json:"service_window_open"`
-	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at"`
+	ID                 uuid.UUID    `json:"id"`
+	PhoneNumber        string       `json:"phone_number"`
+	Name               string       `json:"name"`
+	ProfileName        string       `json:"profile_name"`
+	AvatarURL          string       `json:"avatar_url"`
+	Status             string       `json:"status"`
+	Tags               []string     `json:"tags"`
+	Metadata           any          `json:"metadata"`
+	LastMessageAt      *time.Time   `json:"last_message_at"`
+	LastMessagePreview string       `json:"last_message_preview"`
+	UnreadCount        int          `json:"unread_count"`
+	AssignedUserID     *uuid.UUID   `json:"assigned_user_id,omitempty"`
+	WhatsAppAccount    string       `json:"whatsapp_account,omitempty"`
+	LastInboundAt      *time.Time   `json:"last_inbound_at,omitempty"`
+	ServiceWindowOpen  bool         `json:"service_window_open"`
+	IsBlocked          bool         `json:"is_blocked"`
+	BlockedReason      string       `json:"blocked_reason,omitempty"`
+	BlockedAt          *time.Time   `json:"blocked_at,omitempty"`
+	AdID               string       `json:"ad_id,omitempty"`
+	Referral           models.JSONB `json:"referral,omitempty"`
+	ReferredAt         *time.Time   `json:"referred_at,omitempty"`
+	OptInSource        string       `json:"opt_in_source,omitempty"`
+	OptedInAt          *time.Time   `json:"opted_in_at,omitempty"`
+	CreatedAt          time.Time    `json:"created_at"`
+	UpdatedAt          time.Time    `json:"updated_at"`
 }
 
 // MessageResponse represents a message for the frontend
@@ -59,6 +68,7 @@ type MessageResponse struct {
 	IsReply          bool                 `json:"is_reply"`
 	ReplyToMessageID *string              `json:"reply_to_message_id,omitempty"`
 	ReplyToMessage   *ReplyPreview        `json:"reply_to_message,omitempty"`
+	Context          models.JSONB         `json:"context,omitempty"` // Incoming only: quoted wamid, forwarded flags
 	Reactions        []ReactionInfo       `json:"reactions,omitempty"`
 	WhatsAppAccount  string               `json:"whatsapp_account,omitempty"`
 	CreatedAt        time.Time            `json:"created_at"`
@@ -67,10 +77,31 @@ type MessageResponse struct {
 
 // ReplyPreview contains a preview of the replied-to message
 type ReplyPreview struct {
-	ID          string             `json:"id"`
-	Content     any                `json:"content"`
-	MessageType models.MessageType `json:"message_type"`
-	Direction   models.Direction   `json:"direction"`
+	ID            string             `json:"id"`
+	WAMID         string             `json:"wamid,omitempty"`
+	Content       any                `json:"content"`
+	MessageType   models.MessageType `json:"message_type"`
+	Direction     models.Direction   `json:"direction"`
+	MediaURL      string             `json:"media_url,omitempty"`
+	MediaMimeType string             `json:"media_mime_type,omitempty"`
+	TemplateName  string             `json:"template_name,omitempty"`
+	CampaignID    string             `json:"campaign_id,omitempty"` // Set when the quoted message was sent by a campaign
+}
+
+// buildReplyPreview returns the preview of a quoted message
+func buildReplyPreview(m *models.Message) *ReplyPreview {
+	campaignID, _ := m.Metadata["campaign_id"].(string)
+	return &ReplyPreview{
+		ID:            m.ID.String(),
+		WAMID:         m.WhatsAppMessageID,
+		Content:       map[string]string{"body": m.Content},
+		MessageType:   m.MessageType,
+		Direction:     m.Direction,
+		MediaURL:      m.MediaURL,
+		MediaMimeType: m.MediaMimeType,
+		TemplateName:  m.TemplateName,
+		CampaignID:    campaignID,
+	}
 }
 
 // ReactionInfo represents a reaction on a message
@@ -92,6 +123,7 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 	pg := parsePagination(r)
 	search := string(r.RequestCtx.QueryArgs().Peek("search"))
 	tagsParam := string(r.RequestCtx.QueryArgs().Peek("tags"))
+	blockedParam := string(r.RequestCtx.QueryArgs().Peek("blocked"))
 
 	var contacts []models.Contact
 	query := a.ScopeToOrg(a.DB, userID, orgID)
@@ -132,6 +164,14 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 		}
 	}
 
+	// Filter by block status
+	switch blockedParam {
+	case "true":
+		query = query.Where("is_blocked = ?", true)
+	case "false":
+		query = query.Where("is_blocked = ?", false)
+	}
+
 	// Order by last message time (most recent first)
 	query = query.Order("last_message_at DESC NULLS LAST, created_at DESC")
 
@@ -171,14 +211,14 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 			profileName = MaskIfPhoneNumber(profileName)
 		}
 
-		serviceWindowOpen := c.LastInboundAt != nil && time.Since(*c.LastInboundAt) < 24*time.Hour
+		serviceWindowOpen := isServiceWindowOpen(c.LastInboundAt)
 
 		response[i] = ContactResponse{
 			ID:                 c.ID,
 			PhoneNumber:        phoneNumber,
 			Name:               profileName,
 			ProfileName:        profileName,
-			Status:             "active",
+			Status:             contactStatus(&c),
 			Tags:               tags,
 			Metadata:           c.Metadata,
 			LastMessageAt:      c.LastMessageAt,
@@ -188,6 +228,14 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 			WhatsAppAccount:    c.WhatsAppAccount,
 			LastInboundAt:      c.LastInboundAt,
 			ServiceWindowOpen:  serviceWindowOpen,
+			IsBlocked:          c.IsBlocked,
+			BlockedReason:      c.BlockedReason,
+			BlockedAt:          c.BlockedAt,
+			AdID:               c.AdID,
+			Referral:           c.Referral,
+			ReferredAt:         c.ReferredAt,
+			OptInSource:        c.OptInSource,
+			OptedInAt:          c.OptedInAt,
 			CreatedAt:          c.CreatedAt,
 			UpdatedAt:          c.UpdatedAt,
 		}
@@ -253,7 +301,7 @@ func (a *App) GetContact(r *fastglue.Request) error {
 		PhoneNumber:        phoneNumber,
 		Name:               profileName,
 		ProfileName:        profileName,
-		Status:             "active",
+		Status:             contactStatus(&contact),
 		Tags:               tags,
 		Metadata:           contact.Metadata,
 		LastMessageAt:      contact.LastMessageAt,
@@ -261,6 +309,14 @@ func (a *App) GetContact(r *fastglue.Request) error {
 		UnreadCount:        int(unreadCount),
 		AssignedUserID:     contact.AssignedUserID,
 		WhatsAppAccount:    contact.WhatsAppAccount,
+		IsBlocked:          contact.IsBlocked,
+		BlockedReason:      contact.BlockedReason,
+		BlockedAt:          contact.BlockedAt,
+		AdID:               contact.AdID,
+		Referral:           contact.Referral,
+		ReferredAt:         contact.ReferredAt,
+		OptInSource:        contact.OptInSource,
+		OptedInAt:          contact.OptedInAt,
 		CreatedAt:          contact.CreatedAt,
 		UpdatedAt:          contact.UpdatedAt,
 	}
@@ -430,14 +486,12 @@ func (a *App) buildMessagesResponse(messages []models.Message) []MessageResponse
 			replyToID := m.ReplyToMessageID.String()
 			msgResp.ReplyToMessageID = &replyToID
 			if m.ReplyToMessage != nil {
-				msgResp.ReplyToMessage = &ReplyPreview{
-					ID:          m.ReplyToMessage.ID.String(),
-					Content:     map[string]string{"body": m.ReplyToMessage.Content},
-					MessageType: m.ReplyToMessage.MessageType,
-					Direction:   m.ReplyToMessage.Direction,
-				}
+				msgResp.ReplyToMessage = buildReplyPreview(m.ReplyToMessage)
 			}
 		}
+		if len(m.Context) > 0 {
+			msgResp.Context = m.Context
+		}
 
 		if m.Metadata != nil {
 			if reactionsRaw, ok := m.Metadata["reactions"]; ok {
@@ -515,6 +569,12 @@ type SendMessageRequest struct {
 
 	// Interactive message fields (for type="interactive")
 	Interactive *InteractiveContent `json:"interactive,omitempty"`
+
+	// Location pin (for type="location")
+	Location *whatsapp.Location `json:"location,omitempty"`
+
+	// Contact cards (for type="contacts")
+	Contacts []whatsapp.ContactCard `json:"contacts,omitempty"`
 }
 
 // InteractiveContent holds interactive message data
@@ -549,6 +609,10 @@ func (a *App) SendMessage(r *fastglue.Request) error {
 	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
 	}
+	restoreSharedContent(&req)
+	if msg := validateSendMessageRequest(&req); msg != "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, msg, nil, "")
+	}
 
 	// Get contact (users without full read permission can only message their assigned contacts)
 	var contact models.Contact
@@ -588,6 +652,8 @@ func (a *App) SendMessage(r *fastglue.Request) error {
 		Contact:        &contact,
 		Type:           req.Type,
 		Content:        req.Content.Body,
+		Location:       req.Location,
+		Contacts:       req.Contacts,
 		ReplyToMessage: replyToMessage,
 	}
 
@@ -638,17 +704,69 @@ func (a *App) SendMessage(r *fastglue.Request) error {
 	if message.IsReply && message.ReplyToMessageID != nil && replyToMessage != nil {
 		replyToID := message.ReplyToMessageID.String()
 		response.ReplyToMessageID = &replyToID
-		response.ReplyToMessage = &ReplyPreview{
-			ID:          replyToMessage.ID.String(),
-			Content:     map[string]string{"body": replyToMessage.Content},
-			MessageType: replyToMessage.MessageType,
-			Direction:   replyToMessage.Direction,
-		}
+		response.ReplyToMessage = buildReplyPreview(replyToMessage)
 	}
 
 	return r.SendEnvelope(response)
 }
 
+// restoreSharedContent fills a location or contacts request from content.body when
+// the structured field is missing, so a stored message can be resent as-is (retry).
+func restoreSharedContent(req *SendMessageRequest) {
+	if req.Content.Body == "" {
+		return
+	}
+	switch req.Type {
+	case models.MessageTypeLocation:
+		if req.Location == nil {
+			var loc whatsapp.Location
+			if err := json.Unmarshal([]byte(req.Content.Body), &loc); err == nil {
+				req.Location = &loc
+			}
+		}
+	case models.MessageTypeContact:
+		if len(req.Contacts) == 0 {
+			var stored []struct {
+				Name   string   `json:"name"`
+				Phones []string `json:"phones"`
+			}
+			if err := json.Unmarshal([]byte(req.Content.Body), &stored); err == nil {
+				for _, c := range stored {
+					card := whatsapp.ContactCard{Name: whatsapp.ContactName{FormattedName: c.Name}}
+					for _, p := range c.Phones {
+						card.Phones = append(card.Phones, whatsapp.ContactPhone{Phone: p})
+					}
+					req.Contacts = append(req.Contacts, card)
+				}
+			}
+		}
+	}
+}
+
+// validateSendMessageRequest checks the type-specific payload of a send request.
+// Returns an error message, or "" if the request is valid.
+func validateSendMessageRequest(req *SendMessageRequest) string {
+	switch req.Type {
+	case models.MessageTypeLocation:
+		if req.Location == nil {
+			return "location is required"
+		}
+		if req.Location.Latitude < -90 || req.Location.Latitude > 90 || req.Location.Longitude < -180 || req.Location.Longitude > 180 {
+			return "Invalid location coordinates"
+		}
+	case models.MessageTypeContact:
+		if len(req.Contacts) == 0 {
+			return "At least one contact is required"
+		}
+		for _, c := range req.Contacts {
+			if strings.TrimSpace(c.Name.FormattedName) == "" {
+				return "Contact name is required"
+			}
+		}
+	}
+	return ""
+}
+
 // resolveWhatsAppAccount gets the WhatsApp account for sending messages
 func (a *App) resolveWhatsAppAccount(orgID uuid.UUID, accountName string) (*models.WhatsAppAccount, error) {
 	var account models.WhatsAppAccount
@@ -689,7 +807,7 @@ func truncateString(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
-// SendMediaMessage sends a media message (image, document, video, audio) to a contact
+// SendMediaMessage sends a media message (image, document, video, audio, sticker) to a contact
 func (a *App) SendMediaMessage(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
 	if err != nil {
@@ -755,6 +873,9 @@ func (a *App) SendMediaMessage(r *fastglue.Request) error {
 	if mimeType == "" {
 		mimeType = "application/octet-stream"
 	}
+	if mediaType == string(models.MessageTypeSticker) && mimeType != "image/webp" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Stickers must be WebP images", nil, "")
+	}
 
 	// Get contact (users without full read permission can only message their assigned contacts)
 	var contact models.Contact
@@ -1382,6 +1503,7 @@ type UpdateContactRequest struct {
 	Tags            []string        `json:"tags"`
 	Metadata        *map[string]any `json:"metadata"`
 	AssignedUserID  *uuid.UUID      `json:"assigned_user_id"`
+	OptInSource     *string         `json:"opt_in_source"` // Records an opt-in collected elsewhere, e.g. "website"
 }
 
 // UpdateContact updates an existing contact
@@ -1440,6 +1562,15 @@ func (a *App) UpdateContact(r *fastglue.Request) error {
 		updates["assigned_user_id"] = req.AssignedUserID
 	}
 
+	if req.OptInSource != nil {
+		source := strings.TrimSpace(*req.OptInSource)
+		if source == "" || len(source) > 100 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "opt_in_source must be 1 to 100 characters", nil, "")
+		}
+		updates["opt_in_source"] = source
+		updates["opted_in_at"] = time.Now()
+	}
+
 	if len(updates) == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No fields to update", nil, "")
 	}
@@ -1449,6 +1580,13 @@ func (a *App) UpdateContact(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update contact", nil, "")
 	}
 
+	// A recorded opt-in supersedes an earlier opt-out
+	if req.OptInSource != nil {
+		if _, err := suppression.Remove(a.DB, orgID, contact.PhoneNumber); err != nil {
+			a.Log.Error("Failed to remove opt-out of opted-in contact", "error", err, "contact_id", contact.ID)
+		}
+	}
+
 	// Reload contact
 	a.DB.First(contact, contactID)
 
@@ -1489,6 +1627,14 @@ func (a *App) DeleteContact(r *fastglue.Request) error {
 	})
 }
 
+// contactStatus is the status shown for a contact
+func contactStatus(contact *models.Contact) string {
+	if contact.IsBlocked {
+		return "blocked"
+	}
+	return "active"
+}
+
 // buildContactResponse creates a ContactResponse from a Contact model
 func (a *App) buildContactResponse(contact *models.Contact, orgID uuid.UUID) ContactResponse {
 	// Count unread messages
@@ -1515,14 +1661,14 @@ func (a *App) buildContactResponse(contact *models.Contact, orgID uuid.UUID) Con
 	}
 
 	// 24-hour service window: open if customer messaged within the last 24 hours.
-	serviceWindowOpen := contact.LastInboundAt != nil && time.Since(*contact.LastInboundAt) < 24*time.Hour
+	serviceWindowOpen := isServiceWindowOpen(contact.LastInboundAt)
 
 	return ContactResponse{
 		ID:                 contact.ID,
 		PhoneNumber:        phoneNumber,
 		Name:               profileName,
 		ProfileName:        profileName,
-		Status:             "active",
+		Status:             contactStatus(contact),
 		Tags:               tags,
 		Metadata:           contact.Metadata,
 		LastMessageAt:      contact.LastMessageAt,
@@ -1532,6 +1678,14 @@ func (a *App) buildContactResponse(contact *models.Contact, orgID uuid.UUID) Con
 		WhatsAppAccount:    contact.WhatsAppAccount,
 		LastInboundAt:      contact.LastInboundAt,
 		ServiceWindowOpen:  serviceWindowOpen,
+		IsBlocked:          contact.IsBlocked,
+		BlockedReason:      contact.BlockedReason,
+		BlockedAt:          contact.BlockedAt,
+		AdID:               contact.AdID,
+		Referral:           contact.Referral,
+		ReferredAt:         contact.ReferredAt,
+		OptInSource:        contact.OptInSource,
+		OptedInAt:          contact.OptedInAt,
 		CreatedAt:          contact.CreatedAt,
 		UpdatedAt:          contact.UpdatedAt,
 	}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/crypto"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// Outcomes of onboarding a phone number through Embedded Signup
+const (
+	signupResultCreated = "created"
+	signupResultExists  = "exists"
+	signupResultFailed  = "failed"
+)
+
+// EmbeddedSignupRequest is the body of POST /api/accounts/embedded-signup
+type EmbeddedSignupRequest struct {
+	Code       string   `json:"code"`        // Code from FB.login once signup completes
+	BusinessID string   `json:"business_id"` // WABA ID from the signup session event, optional
+	PhoneIDs   []string `json:"phone_ids"`   // Limits onboarding to these phone number IDs, optional
+}
+
+// EmbeddedSignupAccountResult reports what happened to one shared phone number
+type EmbeddedSignupAccountResult struct {
+	PhoneID            string           `json:"phone_id"`
+	BusinessID         string           `json:"business_id"`
+	DisplayPhoneNumber string           `json:"display_phone_number"`
+	Result             string           `json:"result"` // created, exists or failed
+	Account            *AccountResponse `json:"account,omitempty"`
+	Subscribed         bool             `json:"subscribed"`
+	Validated          bool             `json:"validated"`
+	Warning            string           `json:"warning,omitempty"`
+	Error              string           `json:"error,omitempty"`
+}
+
+// GetEmbeddedSignupConfig returns what the frontend needs to launch Embedded Signup
+func (a *App) GetEmbeddedSignupConfig(r *fastglue.Request) error {
+	_, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAccounts, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	cfg := a.Config.WhatsApp
+	return r.SendEnvelope(map[string]interface{}{
+		"enabled":     a.embeddedSignupEnabled(),
+		"app_id":      cfg.AppID,
+		"config_id":   cfg.EmbeddedSignupConfigID,
+		"api_version": defaultAccountAPIVersion,
+	})
+}
+
+// CompleteEmbeddedSignup finishes onboarding after a business completes
+// Embedded Signup. It exchanges the code for a business token, creates an
+// account for every shared phone number, subscribes the app to each business
+// account's webhooks and validates the new credentials.
+func (a *App) CompleteEmbeddedSignup(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAccounts, models.ActionWrite); err != nil {
+		return nil
+	}
+	if !a.embeddedSignupEnabled() {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Embedded Signup is not configured", nil, "")
+	}
+
+	var req EmbeddedSignupRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if req.Code == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "code is required", nil, "")
+	}
+
+	ctx := r.RequestCtx
+	cfg := a.Config.WhatsApp
+	apiVersion := defaultAccountAPIVersion
+
+	token, err := a.WhatsApp.ExchangeCode(ctx, cfg.AppID, cfg.AppSecret, req.Code, apiVersion)
+	if err != nil {
+		a.Log.Error("Failed to exchange Embedded Signup code", "error", err)
+		if _, ok := whatsapp.AsAPIError(err); ok {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Signup code is invalid or has expired. Please sign up again.", nil, "")
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusBadGateway, "Failed to exchange signup code", nil, "")
+	}
+
+	info, err := a.WhatsApp.DebugToken(ctx, cfg.AppID, cfg.AppSecret, token.AccessToken, apiVersion)
+	if err != nil {
+		a.Log.Error("Failed to inspect Embedded Signup token", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusBadGateway, "Failed to look up shared business accounts", nil, "")
+	}
+	businessIDs := info.TargetIDs(whatsapp.ScopeBusinessManagement)
+	if req.BusinessID != "" {
+		if !slices.Contains(businessIDs, req.BusinessID) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "The business account was not shared with this app", nil, "")
+		}
+		businessIDs = []string{req.BusinessID}
+	}
+	if len(businessIDs) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No WhatsApp Business Account was shared during signup", nil, "")
+	}
+
+	encKey := a.Config.App.EncryptionKey
+	encAccessToken, err := crypto.Encrypt(token.AccessToken, encKey)
+	if err != nil {
+		a.Log.Error("Failed to encrypt access token", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create accounts", nil, "")
+	}
+	encAppSecret, err := crypto.Encrypt(cfg.AppSecret, encKey)
+	if err != nil {
+		a.Log.Error("Failed to encrypt app secret", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create accounts", nil, "")
+	}
+
+	results := []EmbeddedSignupAccountResult{}
+	for _, businessID := range businessIDs {
+		phones, err := a.WhatsApp.ListPhoneNumbers(ctx, businessID, token.AccessToken, apiVersion)
+		if err != nil {
+			a.Log.Error("Failed to list phone numbers", "error", err, "business_id", businessID)
+			results = append(results, EmbeddedSignupAccountResult{
+				BusinessID: businessID,
+				Result:     signupResultFailed,
+				Error:      "Failed to list the business account's phone numbers",
+			})
+			continue
+		}
+
+		// Subscribe once per business account; every number shares its webhooks
+		subscribeErr := a.WhatsApp.SubscribeApp(ctx, &whatsapp.Account{
+			BusinessID:  businessID,
+			APIVersion:  apiVersion,
+			AccessToken: token.AccessToken,
+		})
+		if subscribeErr != nil {
+			a.Log.Error("Failed to subscribe app to webhooks", "error", subscribeErr, "business_id", businessID)
+		}
+
+		for _, phone := range phones {
+			if len(req.PhoneIDs) > 0 && !slices.Contains(req.PhoneIDs, phone.ID) {
+				continue
+			}
+			result := EmbeddedSignupAccountResult{
+				PhoneID:            phone.ID,
+				BusinessID:         businessID,
+				DisplayPhoneNumber: phone.DisplayPhoneNumber,
+				Subscribed:         subscribeErr == nil,
+			}
+			if subscribeErr != nil {
+				result.Warning = "Failed to subscribe the app to webhooks. Use Subscribe on the account to retry."
+			}
+
+			var existing models.WhatsAppAccount
+			if err := a.DB.Where("phone_id = ?", phone.ID).First(&existing).Error; err == nil {
+				if existing.OrganizationID != orgID {
+					result.Result = signupResultFailed
+					result.Error = "This phone number is already connected to another organization"
+				} else {
+					result.Result = signupResultExists
+					resp := accountToResponse(existing)
+					result.Account = &resp
+				}
+				results = append(results, result)
+				continue
+			}
+
+			account := models.WhatsAppAccount{
+				OrganizationID:     orgID,
+				Name:               a.uniqueAccountName(orgID, phone),
+				AppID:              cfg.AppID,
+				PhoneID:            phone.ID,
+				BusinessID:         businessID,
+				AccessToken:        encAccessToken,
+				AppSecret:          encAppSecret,
+				WebhookVerifyToken: generateVerifyToken(),
+				APIVersion:         apiVersion,
+				AutoPauseCampaigns: true,
+				Status:             models.AccountStatusActive,
+				DisplayPhoneNumber: phone.DisplayPhoneNumber,
+				QualityRating:      models.QualityRating(phone.QualityRating),
+				MessagingLimitTier: phone.MessagingLimitTier,
+			}
+			if err := a.DB.Create(&account).Error; err != nil {
+				a.Log.Error("Failed to create account", "error", err, "phone_id", phone.ID)
+				result.Result = signupResultFailed
+				result.Error = "Failed to create account"
+				results = append(results, result)
+				continue
+			}
+			result.Result = signupResultCreated
+			resp := accountToResponse(account)
+			result.Account = &resp
+
+			validation, err := a.WhatsApp.ValidateCredentials(ctx, phone.ID, businessID, token.AccessToken, apiVersion)
+			if err != nil {
+				// New numbers still need registering before they can send
+				a.Log.Warn("Embedded Signup account failed validation", "error", err, "phone_id", phone.ID)
+				result.Error = err.Error()
+			} else {
+				result.Validated = true
+				if validation.Warning != "" {
+					result.Warning = validation.Warning
+				}
+			}
+
+			a.Log.Info("Account created through Embedded Signup", "account", account.Name, "phone_id", phone.ID, "business_id", businessID)
+			results = append(results, result)
+		}
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"accounts": results,
+	})
+}
+
+// embeddedSignupEnabled reports whether the Meta app used for Embedded Signup is configured
+func (a *App) embeddedSignupEnabled() bool {
+	cfg := a.Config.WhatsApp
+	return cfg.AppID != "" && cfg.AppSecret != ""
+}
+
+// uniqueAccountName picks an account name for a shared phone number that is
+// not yet used in the organization: the verified name, then the verified name
+// with the number, then the phone number ID.
+func (a *App) uniqueAccountName(orgID uuid.UUID, phone whatsapp.PhoneNumberHealth) string {
+	candidates := []string{phone.VerifiedName, phone.DisplayPhoneNumber}
+	if phone.VerifiedName != "" && phone.DisplayPhoneNumber != "" {
+		candidates = []string{phone.VerifiedName, fmt.Sprintf("%s (%s)", phone.VerifiedName, phone.DisplayPhoneNumber)}
+	}
+	for _, name := range candidates {
+		if name == "" || len(name) > 100 {
+			continue
+		}
+		var count int64
+		a.DB.Model(&models.WhatsAppAccount{}).Where("organization_id = ? AND name = ?", orgID, name).Count(&count)
+		if count == 0 {
+			return name
+		}
+	}
+	return "WhatsApp " + phone.ID
+}
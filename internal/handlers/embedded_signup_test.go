@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp/whatsapptest"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func embeddedSignupTestApp(t *testing.T) (*handlers.App, *whatsapptest.Server) {
+	t.Helper()
+	srv := whatsapptest.New(whatsapptest.Config{AppID: "signup-app", AppSecret: "signup-secret"}).Start()
+	t.Cleanup(srv.Close)
+
+	app := newTestApp(t, withWhatsApp(srv.Client(testutil.NopLogger())))
+	app.Config.WhatsApp.AppID = "signup-app"
+	app.Config.WhatsApp.AppSecret = "signup-secret"
+	return app, srv
+}
+
+func TestApp_CompleteEmbeddedSignup(t *testing.T) {
+	t.Parallel()
+	app, srv := embeddedSignupTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+
+	phone := srv.AddPhoneNumber(whatsapptest.PhoneNumber{
+		DisplayPhoneNumber: "+1 555-010-2000",
+		VerifiedName:       "Acme Corp",
+	})
+	code := srv.SignupCode(phone.BusinessID)
+
+	req := testutil.NewJSONRequest(t, map[string]string{"code": code})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CompleteEmbeddedSignup(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Accounts []handlers.EmbeddedSignupAccountResult `json:"accounts"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	require.Len(t, resp.Data.Accounts, 1)
+	result := resp.Data.Accounts[0]
+	assert.Equal(t, "created", result.Result)
+	assert.True(t, result.Subscribed)
+	assert.True(t, result.Validated)
+	require.NotNil(t, result.Account)
+	assert.Equal(t, phone.VerifiedName, result.Account.Name)
+	assert.True(t, srv.Business(phone.BusinessID).Subscribed)
+
+	var account models.WhatsAppAccount
+	require.NoError(t, app.DB.Where("phone_id = ?", phone.ID).First(&account).Error)
+	assert.Equal(t, org.ID, account.OrganizationID)
+	assert.Equal(t, phone.BusinessID, account.BusinessID)
+	assert.Equal(t, "signup-app", account.AppID)
+	assert.NotEmpty(t, account.AccessToken)
+	assert.Equal(t, "+1 555-010-2000", account.DisplayPhoneNumber)
+
+	// Signing up again reports the existing account instead of duplicating it
+	req = testutil.NewJSONRequest(t, map[string]string{"code": srv.SignupCode(phone.BusinessID)})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CompleteEmbeddedSignup(req))
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	require.Len(t, resp.Data.Accounts, 1)
+	assert.Equal(t, "exists", resp.Data.Accounts[0].Result)
+
+	var count int64
+	app.DB.Model(&models.WhatsAppAccount{}).Where("phone_id = ?", phone.ID).Count(&count)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestApp_CompleteEmbeddedSignup_UsedCode(t *testing.T) {
+	t.Parallel()
+	app, srv := embeddedSignupTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+
+	phone := srv.AddPhoneNumber(whatsapptest.PhoneNumber{})
+	code := srv.SignupCode(phone.BusinessID)
+
+	req := testutil.NewJSONRequest(t, map[string]string{"code": code, "business_id": "not-shared"})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CompleteEmbeddedSignup(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+
+	// The code was spent by the first attempt
+	req = testutil.NewJSONRequest(t, map[string]string{"code": code})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CompleteEmbeddedSignup(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_CompleteEmbeddedSignup_NotConfigured(t *testing.T) {
+	t.Parallel()
+	app, _ := embeddedSignupTestApp(t)
+	app.Config.WhatsApp.AppSecret = ""
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+
+	req := testutil.NewJSONRequest(t, map[string]string{"code": "anything"})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CompleteEmbeddedSignup(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
@@ -283,8 +283,7 @@ func (a *App) SaveFlowToMeta(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
 	}
 
-	// Create WhatsApp API client
-	waClient := whatsapp.New(a.Log)
+	waClient := a.WhatsApp
 	waAccount := a.toWhatsAppAccount(account)
 
 	a.Log.Info("SaveFlowToMeta: Account details",
@@ -395,8 +394,7 @@ func (a *App) PublishFlow(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
 	}
 
-	// Create WhatsApp API client
-	waClient := whatsapp.New(a.Log)
+	waClient := a.WhatsApp
 	waAccount := a.toWhatsAppAccount(account)
 
 	ctx := context.Background()
@@ -464,7 +462,7 @@ func (a *App) DeprecateFlow(r *fastglue.Request) error {
 			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
 		}
 
-		waClient := whatsapp.New(a.Log)
+		waClient := a.WhatsApp
 		waAccount := a.toWhatsAppAccount(account)
 
 		ctx := context.Background()
@@ -561,8 +559,7 @@ func (a *App) SyncFlows(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
 	}
 
-	// Create WhatsApp API client
-	waClient := whatsapp.New(a.Log)
+	waClient := a.WhatsApp
 	waAccount := a.toWhatsAppAccount(account)
 
 	ctx := context.Background()
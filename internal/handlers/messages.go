@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -26,12 +27,12 @@ type OutgoingMessageRequest struct {
 	Contact *models.Contact
 
 	// Message type determines which fields are used
-	Type models.MessageType // text, image, video, audio, document, interactive, template
+	Type models.MessageType // text, image, video, audio, document, sticker, location, contacts, interactive, template
 
 	// Text messages
 	Content string
 
-	// Media messages (image, video, audio, document)
+	// Media messages (image, video, audio, document, sticker)
 	MediaID       string // WhatsApp media ID (if already uploaded)
 	MediaData     []byte // Raw media data (if upload needed)
 	MediaURL      string // Local media URL (for storage)
@@ -39,16 +40,29 @@ type OutgoingMessageRequest struct {
 	MediaFilename string
 	Caption       string
 
+	// Location and contact card messages
+	Location *whatsapp.Location
+	Contacts []whatsapp.ContactCard
+
 	// Interactive messages
-	InteractiveType string            // "button", "list", "cta_url"
+	InteractiveType string            // "button", "list", "cta_url", "product", "product_list", "catalog_message"
 	BodyText        string            // Body text for interactive messages
 	Buttons         []whatsapp.Button // For button/list messages
 	ButtonText      string            // For CTA URL button
 	URL             string            // For CTA URL button
 
+	// Catalog messages (product, product_list, catalog_message)
+	CatalogID         string                    // Meta catalog ID (product, product_list)
+	ProductRetailerID string                    // Product to send, or the catalog_message thumbnail
+	ProductSections   []whatsapp.ProductSection // For product_list
+	HeaderText        string                    // Required for product_list
+	FooterText        string                    // Optional footer for catalog messages
+
 	// Template messages
-	Template   *models.Template
-	BodyParams map[string]string // Parameter name -> value (supports both named and positional)
+	Template       *models.Template
+	BodyParams     map[string]string // Parameter name -> value (supports both named and positional)
+	HeaderMediaID  string            // Meta media ID for IMAGE/VIDEO/DOCUMENT headers
+	HeaderMediaURL string            // Public URL for IMAGE/VIDEO/DOCUMENT headers (used when HeaderMediaID is empty)
 
 	// WhatsApp Flow messages
 	FlowID          string // Meta Flow ID
@@ -110,6 +124,28 @@ func APISendOptions() MessageSendOptions {
 	}
 }
 
+// NotificationSendOptions returns options suitable for notification rule sends.
+// Sync so the trigger caller learns whether the message was accepted.
+func NotificationSendOptions() MessageSendOptions {
+	return MessageSendOptions{
+		BroadcastWebSocket: true,
+		DispatchWebhook:    true,
+		TrackSLA:           false,
+		Async:              false,
+	}
+}
+
+// ScheduledSendOptions returns options suitable for scheduled chat message sends.
+// Sync so the dispatcher can record whether the message was accepted.
+func ScheduledSendOptions() MessageSendOptions {
+	return MessageSendOptions{
+		BroadcastWebSocket: true,
+		DispatchWebhook:    true,
+		TrackSLA:           false,
+		Async:              false,
+	}
+}
+
 // SLASendOptions returns options suitable for SLA system notifications
 func SLASendOptions() MessageSendOptions {
 	return MessageSendOptions{
@@ -121,7 +157,7 @@ func SLASendOptions() MessageSendOptions {
 }
 
 // SendOutgoingMessage is the unified method for sending all types of WhatsApp messages.
-// It handles: text, media (image/video/audio/document), interactive (buttons/list/cta_url), and template messages.
+// It handles: text, media (image/video/audio/document/sticker), location, contacts, interactive (buttons/list/cta_url/catalog), and template messages.
 func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageRequest, opts MessageSendOptions) (*models.Message, error) {
 	// 1. Create message record
 	msg := a.createOutgoingMessage(req, opts)
@@ -146,7 +182,7 @@ func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageReques
 		case models.MessageTypeText:
 			return a.WhatsApp.SendTextMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.Content, replyToMsgID)
 
-		case models.MessageTypeImage, models.MessageTypeVideo, models.MessageTypeAudio, models.MessageTypeDocument:
+		case models.MessageTypeImage, models.MessageTypeVideo, models.MessageTypeAudio, models.MessageTypeDocument, models.MessageTypeSticker:
 			// Upload media if MediaData is provided and MediaID is not set
 			mediaID := req.MediaID
 			if mediaID == "" && len(req.MediaData) > 0 {
@@ -164,14 +200,31 @@ func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageReques
 				return a.WhatsApp.SendVideoMessage(sendCtx, waAccount, req.Contact.PhoneNumber, mediaID, req.Caption)
 			case models.MessageTypeAudio:
 				return a.WhatsApp.SendAudioMessage(sendCtx, waAccount, req.Contact.PhoneNumber, mediaID)
+			case models.MessageTypeSticker:
+				return a.WhatsApp.SendStickerMessage(sendCtx, waAccount, req.Contact.PhoneNumber, mediaID)
 			default: // document
 				return a.WhatsApp.SendDocumentMessage(sendCtx, waAccount, req.Contact.PhoneNumber, mediaID, req.MediaFilename, req.Caption)
 			}
 
+		case models.MessageTypeLocation:
+			if req.Location == nil {
+				return "", fmt.Errorf("location is required for location messages")
+			}
+			return a.WhatsApp.SendLocationMessage(sendCtx, waAccount, req.Contact.PhoneNumber, *req.Location)
+
+		case models.MessageTypeContact:
+			return a.WhatsApp.SendContactsMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.Contacts)
+
 		case models.MessageTypeInteractive:
 			switch req.InteractiveType {
 			case "cta_url":
 				return a.WhatsApp.SendCTAURLButton(sendCtx, waAccount, req.Contact.PhoneNumber, req.BodyText, req.ButtonText, req.URL)
+			case "product":
+				return a.WhatsApp.SendProductMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.CatalogID, req.ProductRetailerID, req.BodyText, req.FooterText)
+			case "product_list":
+				return a.WhatsApp.SendProductListMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.CatalogID, req.HeaderText, req.BodyText, req.FooterText, req.ProductSections)
+			case "catalog_message":
+				return a.WhatsApp.SendCatalogMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.BodyText, req.FooterText, req.ProductRetailerID)
 			default: // "button" or "list"
 				return a.WhatsApp.SendInteractiveButtons(sendCtx, waAccount, req.Contact.PhoneNumber, req.BodyText, req.Buttons)
 			}
@@ -181,6 +234,15 @@ func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageReques
 				return "", fmt.Errorf("template is required for template messages")
 			}
 			components := whatsapp.BodyParamsToComponents(req.BodyParams)
+			if req.HeaderMediaID != "" {
+				if header := whatsapp.HeaderMediaComponent(req.Template.HeaderType, "id", req.HeaderMediaID); header != nil {
+					components = append([]map[string]interface{}{header}, components...)
+				}
+			} else if req.HeaderMediaURL != "" {
+				if header := whatsapp.HeaderMediaComponent(req.Template.HeaderType, "link", req.HeaderMediaURL); header != nil {
+					components = append([]map[string]interface{}{header}, components...)
+				}
+			}
 			return a.WhatsApp.SendTemplateMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.Template.Name, req.Template.Language, components)
 
 		case models.MessageTypeFlow:
@@ -238,6 +300,7 @@ func (a *App) toWhatsAppAccount(account *models.WhatsAppAccount) *whatsapp.Accou
 		AppID:       account.AppID,
 		APIVersion:  account.APIVersion,
 		AccessToken: account.AccessToken,
+		SendRate:    account.SendRateLimit,
 	}
 }
 
@@ -259,12 +322,20 @@ func (a *App) createOutgoingMessage(req OutgoingMessageRequest, opts MessageSend
 	case models.MessageTypeText:
 		msg.Content = req.Content
 
-	case models.MessageTypeImage, models.MessageTypeVideo, models.MessageTypeAudio, models.MessageTypeDocument:
+	case models.MessageTypeImage, models.MessageTypeVideo, models.MessageTypeAudio, models.MessageTypeDocument, models.MessageTypeSticker:
 		msg.Content = req.Caption
 		msg.MediaURL = req.MediaURL
 		msg.MediaMimeType = req.MediaMimeType
 		msg.MediaFilename = req.MediaFilename
 
+	case models.MessageTypeLocation:
+		// Stored as JSON in the same shape as incoming locations
+		msg.Content = locationContent(req.Location)
+
+	case models.MessageTypeContact:
+		// Stored as JSON in the same shape as incoming contact cards
+		msg.Content = contactsContent(req.Contacts)
+
 	case models.MessageTypeInteractive:
 		msg.Content = req.BodyText
 		msg.InteractiveData = a.buildInteractiveData(req)
@@ -317,6 +388,35 @@ func (a *App) buildInteractiveData(req OutgoingMessageRequest) models.JSONB {
 			"button_text": req.ButtonText,
 			"url":         req.URL,
 		}
+	case "product":
+		return models.JSONB{
+			"type":                "product",
+			"body":                req.BodyText,
+			"catalog_id":          req.CatalogID,
+			"product_retailer_id": req.ProductRetailerID,
+		}
+	case "product_list":
+		sections := make([]interface{}, len(req.ProductSections))
+		for i, section := range req.ProductSections {
+			ids := make([]interface{}, len(section.RetailerIDs))
+			for j, id := range section.RetailerIDs {
+				ids[j] = id
+			}
+			sections[i] = map[string]interface{}{"title": section.Title, "product_retailer_ids": ids}
+		}
+		return models.JSONB{
+			"type":       "product_list",
+			"header":     req.HeaderText,
+			"body":       req.BodyText,
+			"catalog_id": req.CatalogID,
+			"sections":   sections,
+		}
+	case "catalog_message":
+		return models.JSONB{
+			"type":                "catalog_message",
+			"body":                req.BodyText,
+			"product_retailer_id": req.ProductRetailerID,
+		}
 	case "list":
 		rows := make([]interface{}, len(req.Buttons))
 		for i, btn := range req.Buttons {
@@ -351,7 +451,17 @@ func (a *App) finalizeMessageSend(msg *models.Message, req OutgoingMessageReques
 			"status":        models.MessageStatusFailed,
 			"error_message": errMsg,
 		})
-		a.Log.Error("Failed to send message", "error", err, "message_id", msg.ID, "type", msg.MessageType)
+		if apiErr, ok := whatsapp.AsAPIError(err); ok {
+			a.Log.Error("Failed to send message", "error", err, "message_id", msg.ID, "type", msg.MessageType,
+				"code", apiErr.Code, "class", apiErr.Class(), "fbtrace_id", apiErr.FBTraceID)
+			if apiErr.Class() == whatsapp.ErrorClassAuth && req.Account != nil {
+				// Copy so the caller's, possibly cached, account is left alone
+				account := *req.Account
+				a.markAccountAuthFailed(&account, accountHealthSourceSend, apiErr)
+			}
+		} else {
+			a.Log.Error("Failed to send message", "error", err, "message_id", msg.ID, "type", msg.MessageType)
+		}
 
 		// Broadcast failure status via WebSocket so frontend updates immediately
 		if opts.BroadcastWebSocket && a.WSHub != nil {
@@ -475,6 +585,45 @@ func (a *App) dispatchMessageSentWebhook(account *models.WhatsAppAccount, contac
 	})
 }
 
+// locationContent serializes a location as message content
+func locationContent(loc *whatsapp.Location) string {
+	if loc == nil {
+		return ""
+	}
+	data := map[string]any{
+		"latitude":  loc.Latitude,
+		"longitude": loc.Longitude,
+	}
+	if loc.Name != "" {
+		data["name"] = loc.Name
+	}
+	if loc.Address != "" {
+		data["address"] = loc.Address
+	}
+	jsonBytes, _ := json.Marshal(data)
+	return string(jsonBytes)
+}
+
+// contactsContent serializes contact cards as message content (name and phones)
+func contactsContent(cards []whatsapp.ContactCard) string {
+	data := make([]map[string]any, 0, len(cards))
+	for _, c := range cards {
+		contact := map[string]any{
+			"name": c.Name.FormattedName,
+		}
+		if len(c.Phones) > 0 {
+			phones := make([]string, 0, len(c.Phones))
+			for _, p := range c.Phones {
+				phones = append(phones, p.Phone)
+			}
+			contact["phones"] = phones
+		}
+		data = append(data, contact)
+	}
+	jsonBytes, _ := json.Marshal(data)
+	return string(jsonBytes)
+}
+
 // updateContactLastMessage updates contact's last_message_at and preview
 func (a *App) updateContactLastMessage(contact *models.Contact, preview string) {
 	a.DB.Model(contact).Updates(map[string]any{
@@ -500,12 +649,32 @@ func (a *App) getMessagePreview(req OutgoingMessageRequest) string {
 		return "[Video]"
 	case models.MessageTypeAudio:
 		return "[Audio]"
+	case models.MessageTypeSticker:
+		return "[Sticker]"
+	case models.MessageTypeLocation:
+		if req.Location != nil && req.Location.Name != "" {
+			return truncateString("[Location: "+req.Location.Name+"]", 100)
+		}
+		return "[Location]"
+	case models.MessageTypeContact:
+		if len(req.Contacts) == 1 {
+			return truncateString("[Contact: "+req.Contacts[0].Name.FormattedName+"]", 100)
+		}
+		return fmt.Sprintf("[%d Contacts]", len(req.Contacts))
 	case models.MessageTypeDocument:
 		if req.MediaFilename != "" {
 			return "[Document: " + req.MediaFilename + "]"
 		}
 		return "[Document]"
 	case models.MessageTypeInteractive:
+		if req.BodyText == "" {
+			switch req.InteractiveType {
+			case "product", "product_list":
+				return "[Product]"
+			case "catalog_message":
+				return "[Catalog]"
+			}
+		}
 		return truncateString(req.BodyText, 100)
 	case models.MessageTypeTemplate:
 		if req.Template != nil {
@@ -433,6 +433,126 @@ func TestApp_SendOutgoingMessage_AudioMessage(t *testing.T) {
 	assert.Equal(t, "audio-media-id", audioContent["id"])
 }
 
+func TestApp_SendOutgoingMessage_StickerMessage(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := createTestAccount(t, app, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	ctx := testutil.TestContext(t)
+
+	req := handlers.OutgoingMessageRequest{
+		Account:       account,
+		Contact:       contact,
+		Type:          models.MessageTypeSticker,
+		MediaID:       "sticker-media-id",
+		MediaMimeType: "image/webp",
+	}
+
+	msg, err := app.SendOutgoingMessage(ctx, req, handlers.ChatbotSendOptions())
+
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageTypeSticker, msg.MessageType)
+	assert.Equal(t, "image/webp", msg.MediaMimeType)
+
+	require.Len(t, mockServer.sentMessages, 1)
+	sentMsg := mockServer.sentMessages[0]
+	assert.Equal(t, "sticker", sentMsg["type"])
+	assert.Equal(t, "sticker-media-id", sentMsg["sticker"].(map[string]interface{})["id"])
+
+	var updatedContact models.Contact
+	require.NoError(t, app.DB.First(&updatedContact, contact.ID).Error)
+	assert.Equal(t, "[Sticker]", updatedContact.LastMessagePreview)
+}
+
+func TestApp_SendOutgoingMessage_LocationMessage(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := createTestAccount(t, app, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	ctx := testutil.TestContext(t)
+
+	req := handlers.OutgoingMessageRequest{
+		Account: account,
+		Contact: contact,
+		Type:    models.MessageTypeLocation,
+		Location: &whatsapp.Location{
+			Latitude:  12.9716,
+			Longitude: 77.5946,
+			Name:      "Main Store",
+			Address:   "1 MG Road",
+		},
+	}
+
+	msg, err := app.SendOutgoingMessage(ctx, req, handlers.ChatbotSendOptions())
+
+	require.NoError(t, err)
+
+	// Stored in the same JSON shape as incoming locations
+	var stored map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(msg.Content), &stored))
+	assert.Equal(t, 12.9716, stored["latitude"])
+	assert.Equal(t, "Main Store", stored["name"])
+
+	require.Len(t, mockServer.sentMessages, 1)
+	sentMsg := mockServer.sentMessages[0]
+	assert.Equal(t, "location", sentMsg["type"])
+	location := sentMsg["location"].(map[string]interface{})
+	assert.Equal(t, 77.5946, location["longitude"])
+	assert.Equal(t, "1 MG Road", location["address"])
+
+	var updatedContact models.Contact
+	require.NoError(t, app.DB.First(&updatedContact, contact.ID).Error)
+	assert.Equal(t, "[Location: Main Store]", updatedContact.LastMessagePreview)
+}
+
+func TestApp_SendOutgoingMessage_ContactsMessage(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := createTestAccount(t, app, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	ctx := testutil.TestContext(t)
+
+	req := handlers.OutgoingMessageRequest{
+		Account: account,
+		Contact: contact,
+		Type:    models.MessageTypeContact,
+		Contacts: []whatsapp.ContactCard{{
+			Name:   whatsapp.ContactName{FormattedName: "Asha Rao"},
+			Phones: []whatsapp.ContactPhone{{Phone: "+919800000000", Type: "WORK"}},
+		}},
+	}
+
+	msg, err := app.SendOutgoingMessage(ctx, req, handlers.ChatbotSendOptions())
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name":"Asha Rao","phones":["+919800000000"]}]`, msg.Content)
+
+	require.Len(t, mockServer.sentMessages, 1)
+	sentMsg := mockServer.sentMessages[0]
+	assert.Equal(t, "contacts", sentMsg["type"])
+	cards := sentMsg["contacts"].([]interface{})
+	require.Len(t, cards, 1)
+	name := cards[0].(map[string]interface{})["name"].(map[string]interface{})
+	assert.Equal(t, "Asha Rao", name["formatted_name"])
+	assert.Equal(t, "Asha Rao", name["first_name"], "first name defaults to the formatted name")
+
+	var updatedContact models.Contact
+	require.NoError(t, app.DB.First(&updatedContact, contact.ID).Error)
+	assert.Equal(t, "[Contact: Asha Rao]", updatedContact.LastMessagePreview)
+}
+
 func TestApp_SendOutgoingMessage_InteractiveButtons(t *testing.T) {
 	mockServer := newMockWhatsAppServer()
 	defer mockServer.close()
@@ -0,0 +1,722 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/suppression"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// notificationTriggerPath is the public path prefix for notification rule trigger URLs
+const notificationTriggerPath = "/api/notification-rules/trigger/"
+
+// notificationTriggerWebhook is the only trigger type with an implementation:
+// the rule fires when its inbound trigger URL is called
+const notificationTriggerWebhook = "webhook"
+
+// Default payload paths used when a rule's trigger_config doesn't specify them
+const (
+	defaultNotificationPhoneField = "phone"
+	defaultNotificationNameField  = "name"
+)
+
+// NotificationRuleRequest represents the request body for creating/updating a notification rule
+type NotificationRuleRequest struct {
+	Name             string                 `json:"name"`
+	WhatsAppAccount  string                 `json:"whatsapp_account"`
+	TemplateID       string                 `json:"template_id"`
+	IsEnabled        *bool                  `json:"is_enabled"`
+	TriggerType      string                 `json:"trigger_type"`
+	TriggerConfig    map[string]interface{} `json:"trigger_config"`
+	FieldMappings    map[string]interface{} `json:"field_mappings"`
+	Conditions       map[string]interface{} `json:"conditions"`
+	AttachmentConfig map[string]interface{} `json:"attachment_config"`
+}
+
+// NotificationRuleResponse represents a notification rule in API responses
+type NotificationRuleResponse struct {
+	ID               uuid.UUID    `json:"id"`
+	Name             string       `json:"name"`
+	WhatsAppAccount  string       `json:"whatsapp_account"`
+	TemplateID       uuid.UUID    `json:"template_id"`
+	TemplateName     string       `json:"template_name,omitempty"`
+	IsEnabled        bool         `json:"is_enabled"`
+	TriggerType      string       `json:"trigger_type"`
+	TriggerConfig    models.JSONB `json:"trigger_config"`
+	TriggerURL       string       `json:"trigger_url"`
+	FieldMappings    models.JSONB `json:"field_mappings"`
+	Conditions       models.JSONB `json:"conditions"`
+	AttachmentConfig models.JSONB `json:"attachment_config"`
+	CreatedAt        string       `json:"created_at"`
+	UpdatedAt        string       `json:"updated_at"`
+}
+
+// ListNotificationRules returns all notification rules for the organization
+func (a *App) ListNotificationRules(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	pg := parsePagination(r)
+	search := string(r.RequestCtx.QueryArgs().Peek("search"))
+	account := string(r.RequestCtx.QueryArgs().Peek("whatsapp_account"))
+
+	query := a.DB.Where("organization_id = ?", orgID)
+	if search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+	if account != "" {
+		query = query.Where("whats_app_account = ?", account)
+	}
+
+	var total int64
+	query.Model(&models.NotificationRule{}).Count(&total)
+
+	var rules []models.NotificationRule
+	if err := pg.Apply(query.Preload("Template").Order("created_at DESC")).
+		Find(&rules).Error; err != nil {
+		a.Log.Error("Failed to list notification rules", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list notification rules", nil, "")
+	}
+
+	result := make([]NotificationRuleResponse, len(rules))
+	for i, rule := range rules {
+		result[i] = notificationRuleToResponse(rule)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"rules": result,
+		"total": total,
+		"page":  pg.Page,
+		"limit": pg.Limit,
+	})
+}
+
+// GetNotificationRule returns a single notification rule
+func (a *App) GetNotificationRule(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	var rule models.NotificationRule
+	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
+		Preload("Template").
+		First(&rule).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification rule not found", nil, "")
+	}
+
+	return r.SendEnvelope(notificationRuleToResponse(rule))
+}
+
+// CreateNotificationRule creates a new notification rule with a fresh trigger token
+func (a *App) CreateNotificationRule(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	var req NotificationRuleRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.Name == "" || req.WhatsAppAccount == "" || req.TemplateID == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "name, whatsapp_account and template_id are required", nil, "")
+	}
+
+	templateID, err := uuid.Parse(req.TemplateID)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid template ID", nil, "")
+	}
+	template, err := findByIDAndOrg[models.Template](a.DB, r, templateID, orgID, "Template")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := a.resolveWhatsAppAccount(orgID, req.WhatsAppAccount); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
+	}
+
+	if err := validateNotificationConditions(req.Conditions); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	if err := validateNotificationAttachment(req.AttachmentConfig); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	triggerType := req.TriggerType
+	if triggerType == "" {
+		triggerType = notificationTriggerWebhook
+	}
+	if triggerType != notificationTriggerWebhook {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "trigger_type must be 'webhook'", nil, "")
+	}
+
+	isEnabled := true
+	if req.IsEnabled != nil {
+		isEnabled = *req.IsEnabled
+	}
+
+	rule := models.NotificationRule{
+		OrganizationID:   orgID,
+		WhatsAppAccount:  req.WhatsAppAccount,
+		Name:             req.Name,
+		IsEnabled:        isEnabled,
+		TriggerType:      triggerType,
+		TriggerConfig:    jsonbOrEmpty(req.TriggerConfig),
+		TriggerToken:     generateVerifyToken(),
+		TemplateID:       templateID,
+		FieldMappings:    jsonbOrEmpty(req.FieldMappings),
+		Conditions:       jsonbOrEmpty(req.Conditions),
+		AttachmentConfig: jsonbOrEmpty(req.AttachmentConfig),
+	}
+
+	if err := a.DB.Create(&rule).Error; err != nil {
+		a.Log.Error("Failed to create notification rule", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create notification rule", nil, "")
+	}
+
+	rule.Template = template
+	a.Log.Info("Notification rule created", "rule_id", rule.ID, "name", rule.Name)
+
+	return r.SendEnvelope(notificationRuleToResponse(rule))
+}
+
+// UpdateNotificationRule updates an existing notification rule
+func (a *App) UpdateNotificationRule(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	rule, err := findByIDAndOrg[models.NotificationRule](a.DB, r, id, orgID, "Notification rule")
+	if err != nil {
+		return nil
+	}
+
+	var req NotificationRuleRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.Name != "" {
+		rule.Name = req.Name
+	}
+	if req.WhatsAppAccount != "" {
+		if _, err := a.resolveWhatsAppAccount(orgID, req.WhatsAppAccount); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
+		}
+		rule.WhatsAppAccount = req.WhatsAppAccount
+	}
+	if req.TemplateID != "" {
+		templateID, err := uuid.Parse(req.TemplateID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid template ID", nil, "")
+		}
+		if _, err := findByIDAndOrg[models.Template](a.DB, r, templateID, orgID, "Template"); err != nil {
+			return nil
+		}
+		rule.TemplateID = templateID
+	}
+	if req.IsEnabled != nil {
+		rule.IsEnabled = *req.IsEnabled
+	}
+	if req.TriggerType != "" {
+		if req.TriggerType != notificationTriggerWebhook {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "trigger_type must be 'webhook'", nil, "")
+		}
+		rule.TriggerType = req.TriggerType
+	}
+	if req.TriggerConfig != nil {
+		rule.TriggerConfig = models.JSONB(req.TriggerConfig)
+	}
+	if req.FieldMappings != nil {
+		rule.FieldMappings = models.JSONB(req.FieldMappings)
+	}
+	if req.Conditions != nil {
+		if err := validateNotificationConditions(req.Conditions); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		rule.Conditions = models.JSONB(req.Conditions)
+	}
+	if req.AttachmentConfig != nil {
+		if err := validateNotificationAttachment(req.AttachmentConfig); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		rule.AttachmentConfig = models.JSONB(req.AttachmentConfig)
+	}
+	// Rules created before trigger URLs existed won't have a token yet
+	if rule.TriggerToken == "" {
+		rule.TriggerToken = generateVerifyToken()
+	}
+
+	if err := a.DB.Save(rule).Error; err != nil {
+		a.Log.Error("Failed to update notification rule", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update notification rule", nil, "")
+	}
+
+	a.DB.Where("id = ?", rule.ID).Preload("Template").First(rule)
+
+	return r.SendEnvelope(notificationRuleToResponse(*rule))
+}
+
+// DeleteNotificationRule deletes a notification rule
+func (a *App) DeleteNotificationRule(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	result := a.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.NotificationRule{})
+	if result.Error != nil {
+		a.Log.Error("Failed to delete notification rule", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete notification rule", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification rule not found", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]string{"message": "Notification rule deleted successfully"})
+}
+
+// RegenerateNotificationRuleToken issues a new trigger token, invalidating the old trigger URL
+func (a *App) RegenerateNotificationRuleToken(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	rule, err := findByIDAndOrg[models.NotificationRule](a.DB, r, id, orgID, "Notification rule")
+	if err != nil {
+		return nil
+	}
+
+	token := generateVerifyToken()
+	if err := a.DB.Model(rule).Update("trigger_token", token).Error; err != nil {
+		a.Log.Error("Failed to regenerate notification rule token", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to regenerate token", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]string{
+		"trigger_url": notificationTriggerPath + token,
+	})
+}
+
+// ListNotificationRuleLogs returns the invocation log for a notification rule
+func (a *App) ListNotificationRuleLogs(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := findByIDAndOrg[models.NotificationRule](a.DB, r, id, orgID, "Notification rule"); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	status := string(r.RequestCtx.QueryArgs().Peek("status"))
+
+	query := a.DB.Where("rule_id = ? AND organization_id = ?", id, orgID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Model(&models.NotificationRuleLog{}).Count(&total)
+
+	var logs []models.NotificationRuleLog
+	if err := pg.Apply(query.Order("created_at DESC")).Find(&logs).Error; err != nil {
+		a.Log.Error("Failed to list notification rule logs", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list logs", nil, "")
+	}
+
+	if a.ShouldMaskPhoneNumbers(orgID) {
+		for i := range logs {
+			logs[i].PhoneNumber = MaskPhoneNumber(logs[i].PhoneNumber)
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"logs":  logs,
+		"total": total,
+		"page":  pg.Page,
+		"limit": pg.Limit,
+	})
+}
+
+// TriggerNotificationRule is the public inbound endpoint for a notification rule.
+// External systems POST an arbitrary JSON payload to /api/notification-rules/trigger/{token}.
+func (a *App) TriggerNotificationRule(r *fastglue.Request) error {
+	token, _ := r.RequestCtx.UserValue("token").(string)
+	if token == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification rule not found", nil, "")
+	}
+
+	var rule models.NotificationRule
+	if err := a.DB.Where("trigger_token = ?", token).Preload("Template").First(&rule).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification rule not found", nil, "")
+	}
+	remoteAddr := r.RequestCtx.RemoteIP().String()
+
+	// From here on the rule is known, so every outcome is logged against it
+	if !rule.IsEnabled {
+		logEntry := a.saveNotificationRuleLog(&models.NotificationRuleLog{
+			OrganizationID: rule.OrganizationID,
+			RuleID:         rule.ID,
+			Status:         models.NotificationLogStatusSkipped,
+			ErrorMessage:   "rule is disabled",
+			RemoteAddr:     remoteAddr,
+		})
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Notification rule is disabled", map[string]any{"log_id": logEntry.ID}, "")
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &payload); err != nil {
+		logEntry := a.saveNotificationRuleLog(&models.NotificationRuleLog{
+			OrganizationID: rule.OrganizationID,
+			RuleID:         rule.ID,
+			Status:         models.NotificationLogStatusFailed,
+			ErrorMessage:   "request body is not a JSON object: " + err.Error(),
+			RemoteAddr:     remoteAddr,
+		})
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Request body must be a JSON object", map[string]any{"log_id": logEntry.ID}, "")
+	}
+
+	logEntry := a.executeNotificationRule(r.RequestCtx, &rule, payload, remoteAddr)
+
+	data := map[string]any{
+		"log_id": logEntry.ID,
+		"status": logEntry.Status,
+	}
+	if logEntry.MessageID != nil {
+		data["message_id"] = logEntry.MessageID
+	}
+
+	// The caller is unauthenticated, so the detailed reason (which may include
+	// Meta API or database errors) stays in the log row referenced by log_id
+	if logEntry.Status == models.NotificationLogStatusFailed {
+		return r.SendErrorEnvelope(fasthttp.StatusUnprocessableEntity, "Notification could not be sent", data, "")
+	}
+	return r.SendEnvelope(data)
+}
+
+// executeNotificationRule evaluates a rule against a payload, sends the bound template when
+// the conditions match and records the outcome. The returned log entry is always persisted.
+func (a *App) executeNotificationRule(ctx context.Context, rule *models.NotificationRule, payload map[string]interface{}, remoteAddr string) *models.NotificationRuleLog {
+	logEntry := &models.NotificationRuleLog{
+		OrganizationID: rule.OrganizationID,
+		RuleID:         rule.ID,
+		Payload:        models.JSONB(payload),
+		RemoteAddr:     remoteAddr,
+	}
+	defer a.saveNotificationRuleLog(logEntry)
+
+	fail := func(msg string) *models.NotificationRuleLog {
+		logEntry.Status = models.NotificationLogStatusFailed
+		logEntry.ErrorMessage = msg
+		a.Log.Warn("Notification rule failed", "rule_id", rule.ID, "error", msg)
+		return logEntry
+	}
+
+	// 1. Conditions
+	flat := flattenPayload(payload)
+	if !notificationConditionsMatch(rule.Conditions, flat) {
+		logEntry.Status = models.NotificationLogStatusSkipped
+		return logEntry
+	}
+
+	// 2. Recipient
+	phoneField := getStringFromMap(rule.TriggerConfig, "phone_field")
+	if phoneField == "" {
+		phoneField = defaultNotificationPhoneField
+	}
+	nameField := getStringFromMap(rule.TriggerConfig, "name_field")
+	if nameField == "" {
+		nameField = defaultNotificationNameField
+	}
+
+	phone := payloadString(flat, phoneField)
+	if phone == "" {
+		return fail(fmt.Sprintf("payload field %q (phone number) is missing", phoneField))
+	}
+	logEntry.PhoneNumber = phone
+
+	// 3. Template
+	if rule.Template == nil {
+		var template models.Template
+		if err := a.DB.Where("id = ? AND organization_id = ?", rule.TemplateID, rule.OrganizationID).First(&template).Error; err != nil {
+			return fail("template not found")
+		}
+		rule.Template = &template
+	}
+	if rule.Template.Status != string(models.TemplateStatusApproved) {
+		return fail(fmt.Sprintf("template is not approved (status: %s)", rule.Template.Status))
+	}
+
+	// Media headers need a per-send image/video/document, resolved from attachment_config
+	var headerMediaID, headerMediaURL string
+	if isMediaHeaderType(rule.Template.HeaderType) {
+		headerMediaID, headerMediaURL = resolveNotificationAttachment(rule.AttachmentConfig, flat)
+		if headerMediaID == "" && headerMediaURL == "" {
+			return fail(fmt.Sprintf("template has a %s header but attachment_config resolved no media", rule.Template.HeaderType))
+		}
+	}
+
+	// 4. Parameters
+	bodyParams := make(map[string]string, len(rule.FieldMappings))
+	for param, path := range rule.FieldMappings {
+		pathStr, ok := path.(string)
+		if !ok {
+			continue
+		}
+		bodyParams[param] = payloadString(flat, pathStr)
+	}
+	logEntry.TemplateParams = stringMapToJSONB(bodyParams)
+
+	paramNames := templateutil.ExtParamNames(rule.Template.BodyContent)
+	resolved := templateutil.ResolveParamsFromMap(paramNames, bodyParams)
+	var missing []string
+	for i, name := range paramNames {
+		if i >= len(resolved) || resolved[i] == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fail("missing template parameters: " + strings.Join(missing, ", "))
+	}
+
+	// 5. Send
+	account, err := a.resolveWhatsAppAccount(rule.OrganizationID, rule.WhatsAppAccount)
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	contact, _, err := contactutil.GetOrCreateContact(a.DB, rule.OrganizationID, phone, payloadString(flat, nameField))
+	if err != nil {
+		return fail("failed to resolve contact")
+	}
+	logEntry.ContactID = &contact.ID
+
+	if suppressed, err := suppression.IsSuppressed(a.DB, rule.OrganizationID, phone); err != nil {
+		return fail("failed to check suppression list")
+	} else if suppressed {
+		logEntry.Status = models.NotificationLogStatusSuppressed
+		logEntry.ErrorMessage = errContactSuppressed.Error()
+		return logEntry
+	}
+
+	msg, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
+		Account:        account,
+		Contact:        contact,
+		Type:           models.MessageTypeTemplate,
+		Template:       rule.Template,
+		BodyParams:     bodyParams,
+		HeaderMediaID:  headerMediaID,
+		HeaderMediaURL: headerMediaURL,
+	}, NotificationSendOptions())
+	if err != nil {
+		return fail(err.Error())
+	}
+	logEntry.MessageID = &msg.ID
+
+	// Sync send: the final status has already been written to the message row
+	var sent models.Message
+	if err := a.DB.Select("status", "error_message").Where("id = ?", msg.ID).First(&sent).Error; err == nil &&
+		sent.Status == models.MessageStatusFailed {
+		return fail(sent.ErrorMessage)
+	}
+
+	logEntry.Status = models.NotificationLogStatusSent
+	a.Log.Info("Notification rule sent", "rule_id", rule.ID, "message_id", msg.ID)
+	return logEntry
+}
+
+// saveNotificationRuleLog persists an invocation log entry. Failures are logged but not
+// returned, since the invocation outcome itself has already been decided.
+func (a *App) saveNotificationRuleLog(logEntry *models.NotificationRuleLog) *models.NotificationRuleLog {
+	if err := a.DB.Create(logEntry).Error; err != nil {
+		a.Log.Error("Failed to save notification rule log", "error", err, "rule_id", logEntry.RuleID)
+	}
+	return logEntry
+}
+
+// notificationAttachmentKeys are the attachment_config keys a rule may set. The *_field
+// variants are payload paths; the others are static values used for every send.
+var notificationAttachmentKeys = []string{"media_id", "media_id_field", "media_url", "media_url_field"}
+
+// validateNotificationAttachment checks the shape of a rule's attachment_config
+func validateNotificationAttachment(config map[string]interface{}) error {
+	for key, value := range config {
+		if !slices.Contains(notificationAttachmentKeys, key) {
+			return fmt.Errorf("attachment_config.%s is not supported (use one of: %s)", key, strings.Join(notificationAttachmentKeys, ", "))
+		}
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("attachment_config.%s must be a string", key)
+		}
+	}
+	return nil
+}
+
+// resolveNotificationAttachment returns the header media ID or URL for a send. A media ID
+// takes precedence over a URL, and payload paths take precedence over static values.
+func resolveNotificationAttachment(config models.JSONB, flat map[string]interface{}) (mediaID, mediaURL string) {
+	if field := getStringFromMap(config, "media_id_field"); field != "" {
+		mediaID = payloadString(flat, field)
+	}
+	if mediaID == "" {
+		mediaID = getStringFromMap(config, "media_id")
+	}
+	if mediaID != "" {
+		return mediaID, ""
+	}
+	if field := getStringFromMap(config, "media_url_field"); field != "" {
+		mediaURL = payloadString(flat, field)
+	}
+	if mediaURL == "" {
+		mediaURL = getStringFromMap(config, "media_url")
+	}
+	return "", mediaURL
+}
+
+// isMediaHeaderType reports whether a template header carries media rather than text
+func isMediaHeaderType(headerType string) bool {
+	return headerType == "IMAGE" || headerType == "VIDEO" || headerType == "DOCUMENT"
+}
+
+// validateNotificationConditions checks the shape of a rule's conditions
+func validateNotificationConditions(conditions map[string]interface{}) error {
+	if expr, ok := conditions["expression"]; ok {
+		if _, isStr := expr.(string); !isStr {
+			return fmt.Errorf("conditions.expression must be a string")
+		}
+	}
+	return nil
+}
+
+// notificationConditionsMatch evaluates conditions.expression (same syntax as flow skip
+// conditions, e.g. "status == 'shipped' AND total > 100") against the flattened payload.
+// An empty expression always matches.
+func notificationConditionsMatch(conditions models.JSONB, flat map[string]interface{}) bool {
+	expr := strings.TrimSpace(getStringFromMap(conditions, "expression"))
+	if expr == "" {
+		return true
+	}
+	return evaluateExpression(expr, flat)
+}
+
+// flattenPayload converts a nested JSON object into a map keyed by dot paths
+// ("order.items.0.sku"), so payload values can be referenced from conditions and mappings.
+func flattenPayload(payload map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for k, child := range val {
+				key := k
+				if prefix != "" {
+					key = prefix + "." + k
+				}
+				walk(key, child)
+			}
+		case []interface{}:
+			for i, child := range val {
+				walk(prefix+"."+strconv.Itoa(i), child)
+			}
+		default:
+			flat[prefix] = val
+		}
+	}
+	walk("", payload)
+	return flat
+}
+
+// payloadString returns the value at a dot path as a string, or "" if it isn't set
+func payloadString(flat map[string]interface{}, path string) string {
+	val, ok := flat[path]
+	if !ok || val == nil {
+		return ""
+	}
+	if f, isFloat := val.(float64); isFloat {
+		// Avoid exponent formatting for large integers such as phone numbers or order IDs
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+func stringMapToJSONB(m map[string]string) models.JSONB {
+	out := make(models.JSONB, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func jsonbOrEmpty(m map[string]interface{}) models.JSONB {
+	if m == nil {
+		return models.JSONB{}
+	}
+	return models.JSONB(m)
+}
+
+func notificationRuleToResponse(rule models.NotificationRule) NotificationRuleResponse {
+	resp := NotificationRuleResponse{
+		ID:               rule.ID,
+		Name:             rule.Name,
+		WhatsAppAccount:  rule.WhatsAppAccount,
+		TemplateID:       rule.TemplateID,
+		IsEnabled:        rule.IsEnabled,
+		TriggerType:      rule.TriggerType,
+		TriggerConfig:    rule.TriggerConfig,
+		FieldMappings:    rule.FieldMappings,
+		Conditions:       rule.Conditions,
+		AttachmentConfig: rule.AttachmentConfig,
+		CreatedAt:        rule.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        rule.UpdatedAt.Format(time.RFC3339),
+	}
+	if rule.TriggerToken != "" {
+		resp.TriggerURL = notificationTriggerPath + rule.TriggerToken
+	}
+	if rule.Template != nil {
+		resp.TemplateName = rule.Template.Name
+	}
+	return resp
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlattenPayload(t *testing.T) {
+	t.Parallel()
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"customer": {"phone": 919876543210, "name": "Alice"},
+		"items": [{"sku": "A1"}, {"sku": "B2"}],
+		"paid": true
+	}`), &payload))
+
+	flat := flattenPayload(payload)
+
+	assert.Equal(t, "919876543210", payloadString(flat, "customer.phone"))
+	assert.Equal(t, "Alice", payloadString(flat, "customer.name"))
+	assert.Equal(t, "B2", payloadString(flat, "items.1.sku"))
+	assert.Equal(t, "true", payloadString(flat, "paid"))
+	assert.Equal(t, "", payloadString(flat, "customer.email"))
+}
+
+func TestNotificationConditionsMatch(t *testing.T) {
+	t.Parallel()
+
+	flat := flattenPayload(map[string]interface{}{
+		"order": map[string]interface{}{"status": "shipped", "total": 150.0},
+	})
+
+	tests := []struct {
+		name       string
+		conditions models.JSONB
+		want       bool
+	}{
+		{"no conditions", nil, true},
+		{"empty expression", models.JSONB{"expression": ""}, true},
+		{"equality match", models.JSONB{"expression": "order.status == 'shipped'"}, true},
+		{"equality mismatch", models.JSONB{"expression": "order.status == 'pending'"}, false},
+		{"numeric and", models.JSONB{"expression": "order.status == 'shipped' AND order.total > 100"}, true},
+		{"numeric or", models.JSONB{"expression": "order.total < 100 OR order.status == 'pending'"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, notificationConditionsMatch(tt.conditions, flat))
+		})
+	}
+}
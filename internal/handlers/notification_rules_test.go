@@ -0,0 +1,342 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// createTestNotificationRule creates a rule bound to the given template via the API.
+func createTestNotificationRule(t *testing.T, app *handlers.App, orgID, userID uuid.UUID, accountName string, templateID uuid.UUID, conditions map[string]interface{}) handlers.NotificationRuleResponse {
+	t.Helper()
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"name":             "Order confirmation",
+		"whatsapp_account": accountName,
+		"template_id":      templateID.String(),
+		"trigger_config": map[string]interface{}{
+			"phone_field": "customer.phone",
+			"name_field":  "customer.name",
+		},
+		"field_mappings": map[string]interface{}{
+			"name":     "customer.name",
+			"order_id": "order.id",
+		},
+		"conditions": conditions,
+	})
+	testutil.SetAuthContext(req, orgID, userID)
+
+	require.NoError(t, app.CreateNotificationRule(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.NotificationRuleResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	return resp.Data
+}
+
+func triggerToken(rule handlers.NotificationRuleResponse) string {
+	return strings.TrimPrefix(rule.TriggerURL, "/api/notification-rules/trigger/")
+}
+
+func TestApp_CreateNotificationRule_ReturnsTriggerURL(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+
+	rule := createTestNotificationRule(t, app, org.ID, user.ID, account.Name, tpl.ID, nil)
+
+	assert.Equal(t, "webhook", rule.TriggerType)
+	assert.True(t, rule.IsEnabled)
+	assert.Equal(t, tpl.Name, rule.TemplateName)
+	assert.True(t, strings.HasPrefix(rule.TriggerURL, "/api/notification-rules/trigger/"))
+	assert.Len(t, triggerToken(rule), 64)
+}
+
+func TestApp_TriggerNotificationRule_SendsTemplate(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+	rule := createTestNotificationRule(t, app, org.ID, user.ID, account.Name, tpl.ID, map[string]interface{}{
+		"expression": "order.status == 'confirmed'",
+	})
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"customer": map[string]interface{}{"phone": "919876543210", "name": "Alice"},
+		"order":    map[string]interface{}{"id": 4242, "status": "confirmed"},
+	})
+	testutil.SetPathParam(req, "token", triggerToken(rule))
+
+	require.NoError(t, app.TriggerNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	require.Len(t, mockServer.sentMessages, 1)
+	assert.Equal(t, "template", mockServer.sentMessages[0]["type"])
+
+	var logEntry models.NotificationRuleLog
+	require.NoError(t, app.DB.Where("rule_id = ?", rule.ID).First(&logEntry).Error)
+	assert.Equal(t, models.NotificationLogStatusSent, logEntry.Status)
+	assert.Equal(t, "919876543210", logEntry.PhoneNumber)
+	assert.Equal(t, "4242", logEntry.TemplateParams["order_id"])
+	require.NotNil(t, logEntry.MessageID)
+
+	var msg models.Message
+	require.NoError(t, app.DB.Where("id = ?", *logEntry.MessageID).First(&msg).Error)
+	assert.Equal(t, "Hello Alice! Your order 4242 has been confirmed.", msg.Content)
+}
+
+func TestApp_TriggerNotificationRule_ConditionsNotMet(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+	rule := createTestNotificationRule(t, app, org.ID, user.ID, account.Name, tpl.ID, map[string]interface{}{
+		"expression": "order.status == 'confirmed'",
+	})
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"customer": map[string]interface{}{"phone": "919876543210", "name": "Alice"},
+		"order":    map[string]interface{}{"id": 4242, "status": "cancelled"},
+	})
+	testutil.SetPathParam(req, "token", triggerToken(rule))
+
+	require.NoError(t, app.TriggerNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	assert.Empty(t, mockServer.sentMessages)
+
+	var logEntry models.NotificationRuleLog
+	require.NoError(t, app.DB.Where("rule_id = ?", rule.ID).First(&logEntry).Error)
+	assert.Equal(t, models.NotificationLogStatusSkipped, logEntry.Status)
+}
+
+func TestApp_TriggerNotificationRule_MissingParams(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+	rule := createTestNotificationRule(t, app, org.ID, user.ID, account.Name, tpl.ID, nil)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"customer": map[string]interface{}{"phone": "919876543210", "name": "Alice"},
+	})
+	testutil.SetPathParam(req, "token", triggerToken(rule))
+
+	require.NoError(t, app.TriggerNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusUnprocessableEntity, testutil.GetResponseStatusCode(req))
+	assert.Empty(t, mockServer.sentMessages)
+
+	var logEntry models.NotificationRuleLog
+	require.NoError(t, app.DB.Where("rule_id = ?", rule.ID).First(&logEntry).Error)
+	assert.Equal(t, models.NotificationLogStatusFailed, logEntry.Status)
+	assert.Contains(t, logEntry.ErrorMessage, "order_id")
+}
+
+func TestApp_TriggerNotificationRule_UnknownToken(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{"phone": "919876543210"})
+	testutil.SetPathParam(req, "token", "does-not-exist")
+
+	require.NoError(t, app.TriggerNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_TriggerNotificationRule_DisabledIsLogged(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+	rule := createTestNotificationRule(t, app, org.ID, user.ID, account.Name, tpl.ID, nil)
+	require.NoError(t, app.DB.Model(&models.NotificationRule{}).Where("id = ?", rule.ID).Update("is_enabled", false).Error)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"customer": map[string]interface{}{"phone": "919876543210", "name": "Alice"},
+		"order":    map[string]interface{}{"id": 4242},
+	})
+	testutil.SetPathParam(req, "token", triggerToken(rule))
+
+	require.NoError(t, app.TriggerNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+	assert.Empty(t, mockServer.sentMessages)
+
+	var logEntry models.NotificationRuleLog
+	require.NoError(t, app.DB.Where("rule_id = ?", rule.ID).First(&logEntry).Error)
+	assert.Equal(t, models.NotificationLogStatusSkipped, logEntry.Status)
+	assert.Equal(t, "rule is disabled", logEntry.ErrorMessage)
+}
+
+func TestApp_TriggerNotificationRule_InvalidJSONIsLogged(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+	rule := createTestNotificationRule(t, app, org.ID, user.ID, account.Name, tpl.ID, nil)
+
+	req := testutil.NewJSONRequest(t, nil)
+	req.RequestCtx.Request.SetBody([]byte("not json"))
+	testutil.SetPathParam(req, "token", triggerToken(rule))
+
+	require.NoError(t, app.TriggerNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+
+	var logEntry models.NotificationRuleLog
+	require.NoError(t, app.DB.Where("rule_id = ?", rule.ID).First(&logEntry).Error)
+	assert.Equal(t, models.NotificationLogStatusFailed, logEntry.Status)
+	assert.Contains(t, logEntry.ErrorMessage, "not a JSON object")
+}
+
+func TestApp_TriggerNotificationRule_FailureHidesDetails(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+	mockServer.returnError = true
+	mockServer.errorMessage = "(#132001) Template name does not exist in the translation"
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+	rule := createTestNotificationRule(t, app, org.ID, user.ID, account.Name, tpl.ID, nil)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"customer": map[string]interface{}{"phone": "919876543210", "name": "Alice"},
+		"order":    map[string]interface{}{"id": 4242},
+	})
+	testutil.SetPathParam(req, "token", triggerToken(rule))
+
+	require.NoError(t, app.TriggerNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusUnprocessableEntity, testutil.GetResponseStatusCode(req))
+	assert.NotContains(t, string(testutil.GetResponseBody(req)), "132001")
+	assert.Contains(t, string(testutil.GetResponseBody(req)), "log_id")
+
+	var logEntry models.NotificationRuleLog
+	require.NoError(t, app.DB.Where("rule_id = ?", rule.ID).First(&logEntry).Error)
+	assert.Equal(t, models.NotificationLogStatusFailed, logEntry.Status)
+	assert.Contains(t, logEntry.ErrorMessage, "132001")
+}
+
+func TestApp_TriggerNotificationRule_HeaderMediaFromPayload(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+	require.NoError(t, app.DB.Model(tpl).Update("header_type", "DOCUMENT").Error)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"name":             "Invoice",
+		"whatsapp_account": account.Name,
+		"template_id":      tpl.ID.String(),
+		"trigger_config":   map[string]interface{}{"phone_field": "customer.phone"},
+		"field_mappings": map[string]interface{}{
+			"name":     "customer.name",
+			"order_id": "order.id",
+		},
+		"attachment_config": map[string]interface{}{"media_url_field": "order.invoice_url"},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CreateNotificationRule(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var created struct {
+		Data handlers.NotificationRuleResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &created))
+
+	req = testutil.NewJSONRequest(t, map[string]interface{}{
+		"customer": map[string]interface{}{"phone": "919876543210", "name": "Alice"},
+		"order":    map[string]interface{}{"id": 4242, "invoice_url": "https://erp.example.com/inv/4242.pdf"},
+	})
+	testutil.SetPathParam(req, "token", triggerToken(created.Data))
+
+	require.NoError(t, app.TriggerNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	require.Len(t, mockServer.sentMessages, 1)
+	template, _ := mockServer.sentMessages[0]["template"].(map[string]interface{})
+	components, _ := template["components"].([]interface{})
+	require.NotEmpty(t, components)
+	header, _ := components[0].(map[string]interface{})
+	assert.Equal(t, "header", header["type"])
+	params, _ := header["parameters"].([]interface{})
+	require.Len(t, params, 1)
+	param, _ := params[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"link": "https://erp.example.com/inv/4242.pdf"}, param["document"])
+}
+
+func TestApp_CreateNotificationRule_RejectsUnsupportedConfig(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := createTestAccount(t, app, org.ID)
+	tpl := createTestTemplate(t, app, org.ID, account.Name)
+
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+	}{
+		{"scheduler trigger type", "trigger_type", "scheduler"},
+		{"unknown attachment key", "attachment_config", map[string]interface{}{"path": "/tmp/x.pdf"}},
+		{"non-string attachment value", "attachment_config", map[string]interface{}{"media_url": 42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]interface{}{
+				"name":             "Rule",
+				"whatsapp_account": account.Name,
+				"template_id":      tpl.ID.String(),
+			}
+			body[tt.field] = tt.value
+			req := testutil.NewJSONRequest(t, body)
+			testutil.SetAuthContext(req, org.ID, user.ID)
+
+			require.NoError(t, app.CreateNotificationRule(req))
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// IncomingOrder is the "order" payload Meta sends when a customer submits a cart
+type IncomingOrder struct {
+	CatalogID    string `json:"catalog_id"`
+	Text         string `json:"text,omitempty"`
+	ProductItems []struct {
+		ProductRetailerID string  `json:"product_retailer_id"`
+		Quantity          int     `json:"quantity"`
+		ItemPrice         float64 `json:"item_price"`
+		Currency          string  `json:"currency"`
+	} `json:"product_items"`
+}
+
+// OrderItemResponse represents the API response for an order line
+type OrderItemResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	ProductID         *uuid.UUID `json:"product_id,omitempty"`
+	ProductRetailerID string     `json:"product_retailer_id"`
+	Name              string     `json:"name"`
+	Quantity          int        `json:"quantity"`
+	UnitPrice         int64      `json:"unit_price"`
+	Currency          string     `json:"currency"`
+}
+
+// OrderResponse represents the API response for an order
+type OrderResponse struct {
+	ID              uuid.UUID           `json:"id"`
+	WhatsAppAccount string              `json:"whatsapp_account"`
+	ContactID       uuid.UUID           `json:"contact_id"`
+	ContactName     string              `json:"contact_name"`
+	CatalogID       *uuid.UUID          `json:"catalog_id,omitempty"`
+	MetaCatalogID   string              `json:"meta_catalog_id"`
+	Status          models.OrderStatus  `json:"status"`
+	Note            string              `json:"note"`
+	TotalAmount     int64               `json:"total_amount"`
+	Currency        string              `json:"currency"`
+	ItemCount       int                 `json:"item_count"`
+	Items           []OrderItemResponse `json:"items,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// UpdateOrderStatusRequest represents the request body for changing an order's status
+type UpdateOrderStatusRequest struct {
+	Status models.OrderStatus `json:"status"`
+}
+
+// validOrderStatuses lists the statuses an order can be moved to
+var validOrderStatuses = map[models.OrderStatus]bool{
+	models.OrderStatusPending:   true,
+	models.OrderStatusConfirmed: true,
+	models.OrderStatusShipped:   true,
+	models.OrderStatusDelivered: true,
+	models.OrderStatusCancelled: true,
+}
+
+// openOrderStatuses are the statuses from which an order can still change
+var openOrderStatuses = []models.OrderStatus{
+	models.OrderStatusPending,
+	models.OrderStatusConfirmed,
+	models.OrderStatusShipped,
+}
+
+// ListOrders returns orders for the organization
+func (a *App) ListOrders(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionRead); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+
+	status := string(r.RequestCtx.QueryArgs().Peek("status"))
+	contactID := string(r.RequestCtx.QueryArgs().Peek("contact_id"))
+	whatsappAccount := string(r.RequestCtx.QueryArgs().Peek("whatsapp_account"))
+
+	baseQuery := a.DB.Where("organization_id = ?", orgID)
+	if status != "" {
+		baseQuery = baseQuery.Where("status = ?", status)
+	}
+	if contactID != "" {
+		id, err := uuid.Parse(contactID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid contact ID", nil, "")
+		}
+		baseQuery = baseQuery.Where("contact_id = ?", id)
+	}
+	if whatsappAccount != "" {
+		baseQuery = baseQuery.Where("whats_app_account = ?", whatsappAccount)
+	}
+	if from, ok := parseDateParam(r, "from"); ok {
+		baseQuery = baseQuery.Where("created_at >= ?", from)
+	}
+	if to, ok := parseDateParam(r, "to"); ok {
+		baseQuery = baseQuery.Where("created_at <= ?", endOfDay(to))
+	}
+
+	var total int64
+	baseQuery.Model(&models.Order{}).Count(&total)
+
+	var orders []models.Order
+	if err := pg.Apply(baseQuery.
+		Preload("Contact").
+		Preload("Items").
+		Order("created_at DESC")).
+		Find(&orders).Error; err != nil {
+		a.Log.Error("Failed to list orders", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list orders", nil, "")
+	}
+
+	maskPhones := a.ShouldMaskPhoneNumbers(orgID)
+	result := make([]OrderResponse, len(orders))
+	for i, o := range orders {
+		result[i] = orderToResponse(o, maskPhones, false)
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"orders": result,
+		"total":  total,
+		"page":   pg.Page,
+		"limit":  pg.Limit,
+	})
+}
+
+// GetOrder returns a single order with its items
+func (a *App) GetOrder(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionRead); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "order")
+	if err != nil {
+		return nil
+	}
+
+	var order models.Order
+	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).
+		Preload("Contact").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&order).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Order not found", nil, "")
+	}
+
+	return r.SendEnvelope(orderToResponse(order, a.ShouldMaskPhoneNumbers(orgID), true))
+}
+
+// UpdateOrderStatus moves an order to a new status. Delivered and cancelled orders are final.
+func (a *App) UpdateOrderStatus(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "order")
+	if err != nil {
+		return nil
+	}
+
+	var req UpdateOrderStatusRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if !validOrderStatuses[req.Status] {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid order status", nil, "")
+	}
+
+	order, err := findByIDAndOrg[models.Order](a.DB, r, id, orgID, "Order")
+	if err != nil {
+		return nil
+	}
+
+	// Conditional update so a concurrent cancel/deliver is not overwritten
+	result := a.DB.Model(&models.Order{}).
+		Where("id = ? AND status IN ?", order.ID, openOrderStatuses).
+		Update("status", req.Status)
+	if result.Error != nil {
+		a.Log.Error("Failed to update order status", "error", result.Error, "order_id", order.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update order", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Order is already closed", nil, "")
+	}
+
+	a.DB.Where("id = ?", order.ID).Preload("Contact").Preload("Items").First(order)
+	return r.SendEnvelope(orderToResponse(*order, a.ShouldMaskPhoneNumbers(orgID), true))
+}
+
+// handleIncomingOrder persists an incoming "order" message and returns the JSON
+// summary stored as the message content for the chat timeline.
+func (a *App) handleIncomingOrder(account *models.WhatsAppAccount, contact *models.Contact, whatsappMsgID string, in *IncomingOrder) string {
+	order, created, err := a.createOrderFromMessage(account, contact, whatsappMsgID, in)
+	if err != nil {
+		a.Log.Error("Failed to save order", "error", err, "message_id", whatsappMsgID)
+		return ""
+	}
+
+	if created {
+		a.Log.Info("Order received", "order_id", order.ID, "contact_id", contact.ID, "items", len(order.Items))
+		a.DispatchWebhook(account.OrganizationID, models.WebhookEventOrderCreated, orderEventData(order, contact, account.Name))
+	}
+
+	return orderMessageContent(order)
+}
+
+// createOrderFromMessage creates an Order and its items from an incoming order payload.
+// Webhook retries are idempotent: an order already stored for the message is returned as is.
+func (a *App) createOrderFromMessage(account *models.WhatsAppAccount, contact *models.Contact, whatsappMsgID string, in *IncomingOrder) (*models.Order, bool, error) {
+	var existing models.Order
+	if err := a.DB.Where("whats_app_message_id = ?", whatsappMsgID).Preload("Items").First(&existing).Error; err == nil {
+		return &existing, false, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	order := &models.Order{
+		BaseModel:         models.BaseModel{ID: uuid.New()},
+		OrganizationID:    account.OrganizationID,
+		WhatsAppAccount:   account.Name,
+		ContactID:         contact.ID,
+		MetaCatalogID:     in.CatalogID,
+		WhatsAppMessageID: whatsappMsgID,
+		Status:            models.OrderStatusPending,
+		Note:              in.Text,
+		AdID:              contact.AdID,
+	}
+
+	// Link the local catalog and products when they have been synced
+	products := map[string]models.CatalogProduct{}
+	var catalog models.Catalog
+	if in.CatalogID != "" && a.DB.Where("organization_id = ? AND meta_catalog_id = ?", account.OrganizationID, in.CatalogID).First(&catalog).Error == nil {
+		order.CatalogID = &catalog.ID
+
+		retailerIDs := make([]string, 0, len(in.ProductItems))
+		for _, item := range in.ProductItems {
+			retailerIDs = append(retailerIDs, item.ProductRetailerID)
+		}
+		var found []models.CatalogProduct
+		a.DB.Where("catalog_id = ? AND retailer_id IN ?", catalog.ID, retailerIDs).Find(&found)
+		for _, p := range found {
+			products[p.RetailerID] = p
+		}
+	}
+
+	for _, item := range in.ProductItems {
+		line := models.OrderItem{
+			BaseModel:         models.BaseModel{ID: uuid.New()},
+			OrderID:           order.ID,
+			ProductRetailerID: item.ProductRetailerID,
+			Name:              item.ProductRetailerID,
+			Quantity:          item.Quantity,
+			UnitPrice:         int64(math.Round(item.ItemPrice * 100)),
+			Currency:          item.Currency,
+		}
+		if p, ok := products[item.ProductRetailerID]; ok {
+			productID := p.ID
+			line.ProductID = &productID
+			line.Name = p.Name
+		}
+		if order.Currency == "" {
+			order.Currency = item.Currency
+		}
+		order.TotalAmount += line.UnitPrice * int64(line.Quantity)
+		order.Items = append(order.Items, line)
+	}
+
+	if err := a.DB.Create(order).Error; err != nil {
+		// A concurrent delivery of the same webhook may have won the unique index
+		if a.DB.Where("whats_app_message_id = ?", whatsappMsgID).Preload("Items").First(&existing).Error == nil {
+			return &existing, false, nil
+		}
+		return nil, false, err
+	}
+	return order, true, nil
+}
+
+// orderMessageContent builds the JSON summary stored in an order message's content
+func orderMessageContent(order *models.Order) string {
+	items := make([]map[string]any, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = map[string]any{
+			"name":        item.Name,
+			"retailer_id": item.ProductRetailerID,
+			"quantity":    item.Quantity,
+			"unit_price":  item.UnitPrice,
+			"currency":    item.Currency,
+		}
+	}
+	data := map[string]any{
+		"order_id":     order.ID.String(),
+		"note":         order.Note,
+		"items":        items,
+		"total_amount": order.TotalAmount,
+		"currency":     order.Currency,
+	}
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	return string(jsonBytes)
+}
+
+// orderEventData builds the order.created webhook payload
+func orderEventData(order *models.Order, contact *models.Contact, accountName string) OrderEventData {
+	items := make([]OrderEventItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = OrderEventItem{
+			ProductRetailerID: item.ProductRetailerID,
+			Name:              item.Name,
+			Quantity:          item.Quantity,
+			UnitPrice:         item.UnitPrice,
+			Currency:          item.Currency,
+		}
+	}
+	return OrderEventData{
+		OrderID:         order.ID.String(),
+		ContactID:       contact.ID.String(),
+		ContactPhone:    contact.PhoneNumber,
+		ContactName:     contact.ProfileName,
+		MetaCatalogID:   order.MetaCatalogID,
+		Status:          order.Status,
+		Note:            order.Note,
+		TotalAmount:     order.TotalAmount,
+		Currency:        order.Currency,
+		Items:           items,
+		WhatsAppAccount: accountName,
+	}
+}
+
+// orderToResponse converts an Order model to the API response
+func orderToResponse(o models.Order, maskPhones, includeItems bool) OrderResponse {
+	resp := OrderResponse{
+		ID:              o.ID,
+		WhatsAppAccount: o.WhatsAppAccount,
+		ContactID:       o.ContactID,
+		CatalogID:       o.CatalogID,
+		MetaCatalogID:   o.MetaCatalogID,
+		Status:          o.Status,
+		Note:            o.Note,
+		TotalAmount:     o.TotalAmount,
+		Currency:        o.Currency,
+		ItemCount:       len(o.Items),
+		CreatedAt:       o.CreatedAt,
+		UpdatedAt:       o.UpdatedAt,
+	}
+	if o.Contact != nil {
+		resp.ContactName = o.Contact.ProfileName
+		if maskPhones {
+			resp.ContactName = MaskIfPhoneNumber(resp.ContactName)
+		}
+	}
+	if includeItems {
+		resp.Items = make([]OrderItemResponse, len(o.Items))
+		for i, item := range o.Items {
+			resp.Items[i] = OrderItemResponse{
+				ID:                item.ID,
+				ProductID:         item.ProductID,
+				ProductRetailerID: item.ProductRetailerID,
+				Name:              item.Name,
+				Quantity:          item.Quantity,
+				UnitPrice:         item.UnitPrice,
+				Currency:          item.Currency,
+			}
+		}
+	}
+	return resp
+}
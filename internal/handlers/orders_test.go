@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// parseIncomingOrder decodes an order payload as Meta sends it in the webhook.
+func parseIncomingOrder(t *testing.T, payload string) *IncomingOrder {
+	t.Helper()
+	var in IncomingOrder
+	require.NoError(t, json.Unmarshal([]byte(payload), &in))
+	return &in
+}
+
+func TestHandleIncomingOrder_CreatesOrderWithItems(t *testing.T) {
+	app := newProcessorTestApp(t)
+	_, account := createProcessorTestOrg(t, app)
+	catalog := createStepCatalog(t, app, account, map[string]string{"SKU-1": "Red Shirt"})
+	contact := testutil.CreateTestContact(t, app.DB, account.OrganizationID)
+
+	in := parseIncomingOrder(t, `{
+		"catalog_id": "`+catalog.MetaCatalogID+`",
+		"text": "Please gift wrap",
+		"product_items": [
+			{"product_retailer_id": "SKU-1", "quantity": 2, "item_price": 12.5, "currency": "USD"},
+			{"product_retailer_id": "SKU-UNKNOWN", "quantity": 1, "item_price": 3, "currency": "USD"}
+		]
+	}`)
+
+	wamid := "wamid.order-" + uuid.New().String()[:8]
+	content := app.handleIncomingOrder(account, contact, wamid, in)
+	require.NotEmpty(t, content)
+
+	var order models.Order
+	require.NoError(t, app.DB.Where("whats_app_message_id = ?", wamid).Preload("Items").First(&order).Error)
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+	assert.Equal(t, contact.ID, order.ContactID)
+	require.NotNil(t, order.CatalogID)
+	assert.Equal(t, catalog.ID, *order.CatalogID)
+	assert.Equal(t, "Please gift wrap", order.Note)
+	assert.Equal(t, int64(2800), order.TotalAmount)
+	assert.Equal(t, "USD", order.Currency)
+	require.Len(t, order.Items, 2)
+
+	byRetailerID := map[string]models.OrderItem{}
+	for _, item := range order.Items {
+		byRetailerID[item.ProductRetailerID] = item
+	}
+	assert.Equal(t, "Red Shirt", byRetailerID["SKU-1"].Name)
+	assert.NotNil(t, byRetailerID["SKU-1"].ProductID)
+	assert.Equal(t, int64(1250), byRetailerID["SKU-1"].UnitPrice)
+	assert.Nil(t, byRetailerID["SKU-UNKNOWN"].ProductID, "unsynced products are kept without a product link")
+
+	var summary map[string]any
+	require.NoError(t, json.Unmarshal([]byte(content), &summary))
+	assert.Equal(t, order.ID.String(), summary["order_id"])
+	assert.Len(t, summary["items"], 2)
+
+	// A redelivered webhook must not create a second order
+	app.handleIncomingOrder(account, contact, wamid, in)
+	var count int64
+	app.DB.Model(&models.Order{}).Where("whats_app_message_id = ?", wamid).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestListOrders_FiltersByStatus(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	role := testutil.CreateAgentRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	in := parseIncomingOrder(t, `{"catalog_id": "meta-unknown", "product_items": [{"product_retailer_id": "A", "quantity": 1, "item_price": 1, "currency": "EUR"}]}`)
+	confirmedWAMID := "wamid.a-" + uuid.New().String()[:8]
+	app.handleIncomingOrder(account, contact, confirmedWAMID, in)
+	app.handleIncomingOrder(account, contact, "wamid.b-"+uuid.New().String()[:8], in)
+	require.NoError(t, app.DB.Model(&models.Order{}).Where("whats_app_message_id = ?", confirmedWAMID).
+		Update("status", models.OrderStatusConfirmed).Error)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetQueryParam(req, "status", "pending")
+
+	require.NoError(t, app.ListOrders(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Orders []OrderResponse `json:"orders"`
+			Total  int             `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, 1, resp.Data.Total)
+	require.Len(t, resp.Data.Orders, 1)
+	assert.Equal(t, models.OrderStatusPending, resp.Data.Orders[0].Status)
+	assert.Equal(t, 1, resp.Data.Orders[0].ItemCount)
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	role := testutil.CreateAgentRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	in := parseIncomingOrder(t, `{"catalog_id": "meta-unknown", "product_items": [{"product_retailer_id": "A", "quantity": 1, "item_price": 1, "currency": "EUR"}]}`)
+	wamid := "wamid.status-" + uuid.New().String()[:8]
+	app.handleIncomingOrder(account, contact, wamid, in)
+	var order models.Order
+	require.NoError(t, app.DB.Where("whats_app_message_id = ?", wamid).First(&order).Error)
+
+	update := func(status string) int {
+		req := testutil.NewJSONRequest(t, map[string]any{"status": status})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", order.ID.String())
+		require.NoError(t, app.UpdateOrderStatus(req))
+		return testutil.GetResponseStatusCode(req)
+	}
+
+	assert.Equal(t, fasthttp.StatusBadRequest, update("lost"))
+	assert.Equal(t, fasthttp.StatusOK, update("confirmed"))
+	assert.Equal(t, fasthttp.StatusOK, update("cancelled"))
+	assert.Equal(t, fasthttp.StatusConflict, update("shipped"), "cancelled orders are final")
+
+	require.NoError(t, app.DB.First(&order, order.ID).Error)
+	assert.Equal(t, models.OrderStatusCancelled, order.Status)
+}
@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/database"
+	"github.com/shridarpatil/whatomate/internal/frequencycap"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/suppression"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
@@ -21,6 +26,13 @@ type OrganizationSettings struct {
 	TransferTimeoutSecs int    `json:"transfer_timeout_secs"`
 	HoldMusicFile       string `json:"hold_music_file"`
 	RingbackFile        string `json:"ringback_file"`
+	// Replies that put a contact on or take them off the suppression list
+	OptOutKeywords []string `json:"opt_out_keywords"`
+	OptInKeywords  []string `json:"opt_in_keywords"`
+	// At most MarketingCapMax marketing templates per contact in any
+	// MarketingCapWindowHours; 0 turns the cap off
+	MarketingCapMax         int `json:"marketing_cap_max"`
+	MarketingCapWindowHours int `json:"marketing_cap_window_hours"`
 }
 
 // GetOrganizationSettings returns the organization settings
@@ -47,6 +59,11 @@ func (a *App) GetOrganizationSettings(r *fastglue.Request) error {
 		RingbackFile:        a.Config.Calling.RingbackFile,
 	}
 
+	settings.OptOutKeywords, settings.OptInKeywords = suppression.Keywords(org.Settings)
+	marketingCap := frequencycap.OrgCap(org.Settings)
+	settings.MarketingCapMax = marketingCap.Max
+	settings.MarketingCapWindowHours = int(marketingCap.Window / time.Hour)
+
 	if org.Settings != nil {
 		if v, ok := org.Settings["mask_phone_numbers"].(bool); ok {
 			settings.MaskPhoneNumbers = v
@@ -88,26 +105,63 @@ func (a *App) UpdateOrganizationSettings(r *fastglue.Request) error {
 	}
 
 	var req struct {
-		MaskPhoneNumbers    *bool   `json:"mask_phone_numbers"`
-		Timezone            *string `json:"timezone"`
-		DateFormat          *string `json:"date_format"`
-		Name                *string `json:"name"`
-		CallingEnabled      *bool   `json:"calling_enabled"`
-		MaxCallDuration     *int    `json:"max_call_duration"`
-		TransferTimeoutSecs *int    `json:"transfer_timeout_secs"`
-		HoldMusicFile       *string `json:"hold_music_file"`
-		RingbackFile        *string `json:"ringback_file"`
+		MaskPhoneNumbers        *bool     `json:"mask_phone_numbers"`
+		Timezone                *string   `json:"timezone"`
+		DateFormat              *string   `json:"date_format"`
+		Name                    *string   `json:"name"`
+		CallingEnabled          *bool     `json:"calling_enabled"`
+		MaxCallDuration         *int      `json:"max_call_duration"`
+		TransferTimeoutSecs     *int      `json:"transfer_timeout_secs"`
+		HoldMusicFile           *string   `json:"hold_music_file"`
+		RingbackFile            *string   `json:"ringback_file"`
+		OptOutKeywords          *[]string `json:"opt_out_keywords"`
+		OptInKeywords           *[]string `json:"opt_in_keywords"`
+		MarketingCapMax         *int      `json:"marketing_cap_max"`
+		MarketingCapWindowHours *int      `json:"marketing_cap_window_hours"`
 	}
 
 	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
 	}
 
+	var optOut, optIn []string
+	if req.OptOutKeywords != nil {
+		if optOut, err = cleanOptKeywords(*req.OptOutKeywords); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "opt_out_keywords: "+err.Error(), nil, "")
+		}
+	}
+	if req.OptInKeywords != nil {
+		if optIn, err = cleanOptKeywords(*req.OptInKeywords); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "opt_in_keywords: "+err.Error(), nil, "")
+		}
+	}
+
+	if req.MarketingCapMax != nil && *req.MarketingCapMax < 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Marketing cap cannot be negative", nil, "")
+	}
+	if req.MarketingCapWindowHours != nil && (*req.MarketingCapWindowHours < 1 || *req.MarketingCapWindowHours > frequencycap.MaxWindowHours) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Marketing cap window must be between 1 and %d hours", frequencycap.MaxWindowHours), nil, "")
+	}
+
 	var org models.Organization
 	if err := a.DB.Where("id = ?", orgID).First(&org).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Organization not found", nil, "")
 	}
 
+	// A keyword can't both opt out and opt in
+	currentOptOut, currentOptIn := suppression.Keywords(org.Settings)
+	if req.OptOutKeywords != nil {
+		currentOptOut = optOut
+	}
+	if req.OptInKeywords != nil {
+		currentOptIn = optIn
+	}
+	for _, k := range currentOptOut {
+		if suppression.Matches(currentOptIn, k) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("%q can't be both an opt-out and an opt-in keyword", k), nil, "")
+		}
+	}
+
 	// Update settings
 	if org.Settings == nil {
 		org.Settings = models.JSONB{}
@@ -137,6 +191,18 @@ func (a *App) UpdateOrganizationSettings(r *fastglue.Request) error {
 	if req.RingbackFile != nil {
 		org.Settings["ringback_file"] = *req.RingbackFile
 	}
+	if req.OptOutKeywords != nil {
+		org.Settings[suppression.OptOutKeywordsSetting] = optOut
+	}
+	if req.OptInKeywords != nil {
+		org.Settings[suppression.OptInKeywordsSetting] = optIn
+	}
+	if req.MarketingCapMax != nil {
+		org.Settings[frequencycap.MaxSetting] = *req.MarketingCapMax
+	}
+	if req.MarketingCapWindowHours != nil {
+		org.Settings[frequencycap.WindowHoursSetting] = *req.MarketingCapWindowHours
+	}
 	if req.Name != nil && *req.Name != "" {
 		org.Name = *req.Name
 	}
@@ -150,6 +216,29 @@ func (a *App) UpdateOrganizationSettings(r *fastglue.Request) error {
 	})
 }
 
+// maxOptKeywords caps each of an organization's opt-out and opt-in keyword lists
+const maxOptKeywords = 20
+
+// cleanOptKeywords trims and dedupes a keyword list. An empty list is kept,
+// since it turns detection off.
+func cleanOptKeywords(keywords []string) ([]string, error) {
+	cleaned := []string{}
+	for _, k := range keywords {
+		k = strings.ToUpper(strings.Join(strings.Fields(k), " "))
+		if k == "" || slices.Contains(cleaned, k) {
+			continue
+		}
+		if len(k) > 50 {
+			return nil, fmt.Errorf("%q is longer than 50 characters", k)
+		}
+		cleaned = append(cleaned, k)
+	}
+	if len(cleaned) > maxOptKeywords {
+		return nil, fmt.Errorf("at most %d keywords are allowed", maxOptKeywords)
+	}
+	return cleaned, nil
+}
+
 // IsCallingEnabledForOrg checks if calling is enabled for an organization.
 // Both the global CallManager and the per-org setting must be active.
 func (a *App) IsCallingEnabledForOrg(orgID interface{}) bool {
@@ -490,6 +490,41 @@ func TestApp_UpdateOrganizationSettings_CallingZeroDurationIgnored(t *testing.T)
 
 // --- IsCallingEnabledForOrg Tests ---
 
+func TestApp_UpdateOrganizationSettings_MarketingCap(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("marketing-cap")))
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"marketing_cap_max":          2,
+		"marketing_cap_window_hours": 48,
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.UpdateOrganizationSettings(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.GetOrganizationSettings(req))
+
+	var resp struct {
+		Data struct {
+			Settings handlers.OrganizationSettings `json:"settings"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, 2, resp.Data.Settings.MarketingCapMax)
+	assert.Equal(t, 48, resp.Data.Settings.MarketingCapWindowHours)
+
+	// The window is bounded
+	req = testutil.NewJSONRequest(t, map[string]any{"marketing_cap_window_hours": 0})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.UpdateOrganizationSettings(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
+
 func TestApp_IsCallingEnabledForOrg_NoCallManager(t *testing.T) {
 	t.Parallel()
 
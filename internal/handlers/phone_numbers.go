@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// RequestCodeRequest is the body of POST /api/accounts/{id}/phone/request_code
+type RequestCodeRequest struct {
+	CodeMethod string `json:"code_method"` // SMS (default) or VOICE
+	Language   string `json:"language"`    // Defaults to en_US
+}
+
+// VerifyCodeRequest is the body of POST /api/accounts/{id}/phone/verify_code
+type VerifyCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// PhonePINRequest is the body of the register and two-step PIN endpoints
+type PhonePINRequest struct {
+	PIN string `json:"pin"`
+}
+
+// RequestPhoneVerificationCode asks Meta to send a verification code to the
+// account's phone number
+func (a *App) RequestPhoneVerificationCode(r *fastglue.Request) error {
+	account, err := a.phoneLifecycleAccount(r, models.ActionWrite)
+	if err != nil {
+		return nil
+	}
+
+	var req RequestCodeRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if req.CodeMethod == "" {
+		req.CodeMethod = whatsapp.CodeMethodSMS
+	}
+	if req.CodeMethod != whatsapp.CodeMethodSMS && req.CodeMethod != whatsapp.CodeMethodVoice {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "code_method must be SMS or VOICE", nil, "")
+	}
+	if req.Language == "" {
+		req.Language = "en_US"
+	}
+
+	err = a.WhatsApp.RequestVerificationCode(r.RequestCtx, a.toWhatsAppAccount(account), req.CodeMethod, req.Language)
+	if err != nil {
+		return a.sendPhoneLifecycleError(r, account, err, "Failed to request verification code")
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"success": true,
+		"message": "Verification code sent by " + req.CodeMethod,
+	})
+}
+
+// VerifyPhoneCode submits the verification code received by the phone number
+func (a *App) VerifyPhoneCode(r *fastglue.Request) error {
+	account, err := a.phoneLifecycleAccount(r, models.ActionWrite)
+	if err != nil {
+		return nil
+	}
+
+	var req VerifyCodeRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if req.Code == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "code is required", nil, "")
+	}
+
+	if err := a.WhatsApp.VerifyCode(r.RequestCtx, a.toWhatsAppAccount(account), req.Code); err != nil {
+		return a.sendPhoneLifecycleError(r, account, err, "Failed to verify code")
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"success": true,
+		"message": "Phone number verified",
+	})
+}
+
+// RegisterPhone registers the account's phone number with the Cloud API
+func (a *App) RegisterPhone(r *fastglue.Request) error {
+	account, err := a.phoneLifecycleAccount(r, models.ActionWrite)
+	if err != nil {
+		return nil
+	}
+
+	var req PhonePINRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if !isValidPhonePIN(req.PIN) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "pin must be 6 digits", nil, "")
+	}
+
+	if err := a.WhatsApp.RegisterPhoneNumber(r.RequestCtx, a.toWhatsAppAccount(account), req.PIN); err != nil {
+		return a.sendPhoneLifecycleError(r, account, err, "Failed to register phone number")
+	}
+
+	a.Log.Info("Phone number registered", "account", account.Name, "phone_id", account.PhoneID)
+	return r.SendEnvelope(map[string]interface{}{
+		"success": true,
+		"message": "Phone number registered. Subscribe the app to webhooks to start receiving messages.",
+	})
+}
+
+// DeregisterPhone removes the account's phone number from the Cloud API
+func (a *App) DeregisterPhone(r *fastglue.Request) error {
+	account, err := a.phoneLifecycleAccount(r, models.ActionWrite)
+	if err != nil {
+		return nil
+	}
+
+	if err := a.WhatsApp.DeregisterPhoneNumber(r.RequestCtx, a.toWhatsAppAccount(account)); err != nil {
+		return a.sendPhoneLifecycleError(r, account, err, "Failed to deregister phone number")
+	}
+
+	a.Log.Info("Phone number deregistered", "account", account.Name, "phone_id", account.PhoneID)
+	return r.SendEnvelope(map[string]interface{}{
+		"success": true,
+		"message": "Phone number deregistered",
+	})
+}
+
+// SetPhoneTwoStepPIN sets or changes the phone number's two-step verification PIN
+func (a *App) SetPhoneTwoStepPIN(r *fastglue.Request) error {
+	account, err := a.phoneLifecycleAccount(r, models.ActionWrite)
+	if err != nil {
+		return nil
+	}
+
+	var req PhonePINRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if !isValidPhonePIN(req.PIN) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "pin must be 6 digits", nil, "")
+	}
+
+	if err := a.WhatsApp.SetTwoStepPIN(r.RequestCtx, a.toWhatsAppAccount(account), req.PIN); err != nil {
+		return a.sendPhoneLifecycleError(r, account, err, "Failed to set two-step verification PIN")
+	}
+
+	return r.SendEnvelope(map[string]interface{}{
+		"success": true,
+		"message": "Two-step verification PIN updated",
+	})
+}
+
+// GetPhoneDisplayNameStatus returns the review state of the phone number's display name
+func (a *App) GetPhoneDisplayNameStatus(r *fastglue.Request) error {
+	account, err := a.phoneLifecycleAccount(r, models.ActionRead)
+	if err != nil {
+		return nil
+	}
+
+	status, err := a.WhatsApp.GetDisplayNameStatus(r.RequestCtx, a.toWhatsAppAccount(account))
+	if err != nil {
+		return a.sendPhoneLifecycleError(r, account, err, "Failed to get display name status")
+	}
+
+	return r.SendEnvelope(status)
+}
+
+// phoneLifecycleAccount checks the caller's accounts permission and loads the
+// account named in the path with decrypted secrets. On error a response has
+// already been sent.
+func (a *App) phoneLifecycleAccount(r *fastglue.Request, action string) (*models.WhatsAppAccount, error) {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		_ = r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+		return nil, errEnvelopeSent
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAccounts, action); err != nil {
+		return nil, err
+	}
+
+	id, err := parsePathUUID(r, "id", "account")
+	if err != nil {
+		return nil, err
+	}
+
+	return a.resolveWhatsAppAccountByID(r, id, orgID)
+}
+
+// sendPhoneLifecycleError reports a failed Graph call. Errors Meta attributes
+// to the request, such as a wrong code or PIN, are passed on to the caller.
+func (a *App) sendPhoneLifecycleError(r *fastglue.Request, account *models.WhatsAppAccount, err error, message string) error {
+	a.Log.Error(message, "error", err, "account", account.Name, "phone_id", account.PhoneID)
+
+	apiErr, ok := whatsapp.AsAPIError(err)
+	if !ok {
+		return r.SendErrorEnvelope(fasthttp.StatusBadGateway, message, nil, "")
+	}
+	switch apiErr.Class() {
+	case whatsapp.ErrorClassPermanent:
+		detail := apiErr.Message
+		if apiErr.Details != "" {
+			detail += ": " + apiErr.Details
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, message+": "+detail, nil, "")
+	case whatsapp.ErrorClassAuth:
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, message+": access token is invalid or lacks permission", nil, "")
+	case whatsapp.ErrorClassRateLimited:
+		return r.SendErrorEnvelope(fasthttp.StatusTooManyRequests, message+": too many attempts, try again later", nil, "")
+	default:
+		return r.SendErrorEnvelope(fasthttp.StatusBadGateway, message, nil, "")
+	}
+}
+
+// isValidPhonePIN reports whether pin is a six-digit two-step verification PIN
+func isValidPhonePIN(pin string) bool {
+	if len(pin) != 6 {
+		return false
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp/whatsapptest"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// phoneTestSetup creates an app backed by the fake Graph API, an admin user
+// and an account whose phone number is registered with the fake.
+func phoneTestSetup(t *testing.T) (*handlers.App, *whatsapptest.Server, *models.WhatsAppAccount, uuid.UUID, uuid.UUID) {
+	t.Helper()
+
+	srv := whatsapptest.New(whatsapptest.Config{}).Start()
+	t.Cleanup(srv.Close)
+
+	app := newTestApp(t, withWhatsApp(srv.Client(testutil.NopLogger())))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	srv.AddPhoneNumber(whatsapptest.PhoneNumber{ID: account.PhoneID, BusinessID: account.BusinessID})
+
+	return app, srv, account, org.ID, user.ID
+}
+
+func phoneRequest(t *testing.T, body any, accountID, orgID, userID uuid.UUID) *fastglue.Request {
+	t.Helper()
+	req := testutil.NewJSONRequest(t, body)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", accountID.String())
+	return req
+}
+
+func TestApp_PhoneLifecycle(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+
+	req := phoneRequest(t, map[string]string{"code_method": "VOICE"}, account.ID, orgID, userID)
+	require.NoError(t, app.RequestPhoneVerificationCode(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	req = phoneRequest(t, map[string]string{"code": srv.VerificationCode(account.PhoneID)}, account.ID, orgID, userID)
+	require.NoError(t, app.VerifyPhoneCode(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	req = phoneRequest(t, map[string]string{"pin": "246810"}, account.ID, orgID, userID)
+	require.NoError(t, app.RegisterPhone(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	assert.Equal(t, "246810", srv.PhoneNumber(account.PhoneID).PIN)
+
+	req = phoneRequest(t, map[string]string{"pin": "135790"}, account.ID, orgID, userID)
+	require.NoError(t, app.SetPhoneTwoStepPIN(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	assert.Equal(t, "135790", srv.PhoneNumber(account.PhoneID).PIN)
+
+	req = phoneRequest(t, nil, account.ID, orgID, userID)
+	require.NoError(t, app.DeregisterPhone(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	assert.Equal(t, "DISCONNECTED", srv.PhoneNumber(account.PhoneID).Status)
+
+	req = phoneRequest(t, nil, account.ID, orgID, userID)
+	require.NoError(t, app.GetPhoneDisplayNameStatus(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			NameStatus             string `json:"name_status"`
+			CodeVerificationStatus string `json:"code_verification_status"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, "APPROVED", resp.Data.NameStatus)
+	assert.Equal(t, "VERIFIED", resp.Data.CodeVerificationStatus)
+}
+
+func TestApp_VerifyPhoneCode_WrongCode(t *testing.T) {
+	t.Parallel()
+	app, _, account, orgID, userID := phoneTestSetup(t)
+
+	req := phoneRequest(t, map[string]string{}, account.ID, orgID, userID)
+	require.NoError(t, app.RequestPhoneVerificationCode(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	req = phoneRequest(t, map[string]string{"code": "000000"}, account.ID, orgID, userID)
+	require.NoError(t, app.VerifyPhoneCode(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	assert.Contains(t, string(testutil.GetResponseBody(req)), "Verify code error")
+}
+
+func TestApp_RegisterPhone_InvalidPIN(t *testing.T) {
+	t.Parallel()
+	app, _, account, orgID, userID := phoneTestSetup(t)
+
+	req := phoneRequest(t, map[string]string{"pin": "12ab"}, account.ID, orgID, userID)
+	require.NoError(t, app.RegisterPhone(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_DeregisterPhone_RequiresPermission(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, _ := phoneTestSetup(t)
+	agentRole := testutil.CreateAgentRole(t, app.DB, orgID)
+	agent := testutil.CreateTestUser(t, app.DB, orgID, testutil.WithRoleID(&agentRole.ID))
+
+	req := phoneRequest(t, nil, account.ID, orgID, agent.ID)
+	require.NoError(t, app.DeregisterPhone(req))
+	assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+	assert.Equal(t, "CONNECTED", srv.PhoneNumber(account.PhoneID).Status)
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PricingRateInput is one line of a rate card in a request
+type PricingRateInput struct {
+	CountryCode string  `json:"country_code"`
+	Category    string  `json:"category"`
+	Rate        float64 `json:"rate"`
+}
+
+// PricingRateCardRequest replaces an organization's rate card
+type PricingRateCardRequest struct {
+	Currency string             `json:"currency"`
+	Rates    []PricingRateInput `json:"rates"`
+}
+
+// PricingRateResponse is one line of a rate card in a response
+type PricingRateResponse struct {
+	ID          uuid.UUID `json:"id"`
+	CountryCode string    `json:"country_code"`
+	Category    string    `json:"category"`
+	Rate        float64   `json:"rate"`
+	Currency    string    `json:"currency"`
+}
+
+// SpendTotal is the spend in one currency
+type SpendTotal struct {
+	Currency      string  `json:"currency"`
+	Cost          float64 `json:"cost"`
+	Conversations int64   `json:"conversations"`
+	Unrated       int64   `json:"unrated"`
+}
+
+// SpendBreakdownRow is the spend of one account, campaign, template, agent,
+// category or country
+type SpendBreakdownRow struct {
+	Key           string  `json:"key"`
+	Label         string  `json:"label"`
+	Currency      string  `json:"currency"`
+	Cost          float64 `json:"cost"`
+	Conversations int64   `json:"conversations"`
+	Unrated       int64   `json:"unrated"`
+}
+
+// SpendAnalyticsResponse is the response for spend analytics
+type SpendAnalyticsResponse struct {
+	GroupBy   string              `json:"group_by"`
+	Totals    []SpendTotal        `json:"totals"`
+	Breakdown []SpendBreakdownRow `json:"breakdown"`
+}
+
+// spendGroupings maps a spend group_by value to the key and label columns
+// of conversation_costs (aliased c), and any join the label needs
+var spendGroupings = map[string]struct{ key, label, join string }{
+	"account":  {key: "c.whats_app_account", label: "c.whats_app_account"},
+	"campaign": {key: "c.campaign_id::text", label: "bmc.name", join: "LEFT JOIN bulk_message_campaigns bmc ON bmc.id = c.campaign_id"},
+	"template": {key: "c.template_name", label: "c.template_name"},
+	"agent":    {key: "c.sent_by_user_id::text", label: "u.full_name", join: "LEFT JOIN users u ON u.id = c.sent_by_user_id"},
+	"category": {key: "c.category", label: "c.category"},
+	"country":  {key: "c.country_code", label: "c.country_code"},
+}
+
+// recordMessagePricing stores the pricing Meta reports on a status update on
+// the message and, when it is billable, records the conversation's cost.
+func (a *App) recordMessagePricing(status WebhookStatus) {
+	if status.Pricing == nil {
+		return
+	}
+
+	var message models.Message
+	if err := a.DB.Where("whats_app_message_id = ?", status.ID).First(&message).Error; err != nil {
+		return
+	}
+
+	conversationID := ""
+	if status.Conversation != nil {
+		conversationID = status.Conversation.ID
+	}
+	category := strings.ToLower(status.Pricing.Category)
+
+	if message.PricingCategory != category || message.PricingModel != status.Pricing.PricingModel ||
+		message.Billable != status.Pricing.Billable || (conversationID != "" && message.ConversationID != conversationID) {
+		updates := map[string]any{
+			"pricing_category": category,
+			"pricing_model":    status.Pricing.PricingModel,
+			"billable":         status.Pricing.Billable,
+		}
+		if conversationID != "" {
+			updates["conversation_id"] = conversationID
+		}
+		if err := a.DB.Model(&message).Updates(updates).Error; err != nil {
+			a.Log.Error("Failed to store message pricing", "error", err, "message_id", message.ID)
+		}
+	}
+
+	if !status.Pricing.Billable {
+		return
+	}
+
+	// Per-message pricing charges every message; conversation-based pricing
+	// charges once per conversation
+	if status.Pricing.PricingModel == "PMP" || conversationID == "" {
+		conversationID = message.WhatsAppMessageID
+	}
+
+	recipient := status.RecipientID
+	if recipient == "" {
+		var contact models.Contact
+		if err := a.DB.Select("phone_number").Where("id = ?", message.ContactID).First(&contact).Error; err == nil {
+			recipient = contact.PhoneNumber
+		}
+	}
+
+	var rates []models.PricingRate
+	if err := a.DB.Where("organization_id = ?", message.OrganizationID).Find(&rates).Error; err != nil {
+		a.Log.Error("Failed to load rate card", "error", err, "organization_id", message.OrganizationID)
+	}
+
+	cost := models.ConversationCost{
+		OrganizationID:  message.OrganizationID,
+		ConversationID:  conversationID,
+		WhatsAppAccount: message.WhatsAppAccount,
+		ContactID:       message.ContactID,
+		MessageID:       message.ID,
+		TemplateName:    message.TemplateName,
+		SentByUserID:    message.SentByUserID,
+		Category:        category,
+		PricingModel:    status.Pricing.PricingModel,
+		StartedAt:       time.Now(),
+	}
+	if campaignID, ok := message.Metadata["campaign_id"].(string); ok {
+		if id, err := uuid.Parse(campaignID); err == nil {
+			cost.CampaignID = &id
+		}
+	}
+	if rate := matchPricingRate(rates, recipient, category); rate != nil {
+		cost.CountryCode = rate.CountryCode
+		cost.Cost = rate.Rate
+		cost.Currency = rate.Currency
+		cost.Rated = true
+	} else {
+		if len(rates) > 0 {
+			cost.Currency = rates[0].Currency
+		}
+		a.Log.Warn("No rate card line for billable conversation", "organization_id", message.OrganizationID, "category", category)
+	}
+
+	// Meta reports pricing on every status of a message, and on every message
+	// of a conversation; only the first report creates the row
+	if err := a.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "conversation_id"}},
+		DoNothing: true,
+	}).Create(&cost).Error; err != nil {
+		a.Log.Error("Failed to record conversation cost", "error", err, "conversation_id", conversationID)
+	}
+}
+
+// matchPricingRate returns the rate card line for a category whose country
+// code is the longest prefix of phone, falling back to a line without a
+// country code. Returns nil if none matches.
+func matchPricingRate(rates []models.PricingRate, phone, category string) *models.PricingRate {
+	phone = strings.TrimPrefix(phone, "+")
+
+	var match *models.PricingRate
+	for i := range rates {
+		rate := &rates[i]
+		if rate.Category != category || !strings.HasPrefix(phone, rate.CountryCode) {
+			continue
+		}
+		if match == nil || len(rate.CountryCode) > len(match.CountryCode) {
+			match = rate
+		}
+	}
+	return match
+}
+
+// GetPricingRates returns the organization's rate card
+func (a *App) GetPricingRates(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceSettingsGeneral, models.ActionRead); err != nil {
+		return nil
+	}
+
+	var rates []models.PricingRate
+	if err := a.DB.Where("organization_id = ?", orgID).Order("category ASC, country_code ASC").Find(&rates).Error; err != nil {
+		a.Log.Error("Failed to list pricing rates", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list pricing rates", nil, "")
+	}
+
+	currency := ""
+	response := make([]PricingRateResponse, len(rates))
+	for i, rate := range rates {
+		currency = rate.Currency
+		response[i] = PricingRateResponse{
+			ID:          rate.ID,
+			CountryCode: rate.CountryCode,
+			Category:    rate.Category,
+			Rate:        rate.Rate,
+			Currency:    rate.Currency,
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"currency": currency,
+		"rates":    response,
+	})
+}
+
+// UpdatePricingRates replaces the organization's rate card. Costs already
+// recorded keep the rate they were priced with.
+func (a *App) UpdatePricingRates(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceSettingsGeneral, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req PricingRateCardRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if len(req.Rates) > 0 && !isCurrencyCode(currency) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "currency must be a 3-letter ISO code", nil, "")
+	}
+
+	rates := make([]models.PricingRate, 0, len(req.Rates))
+	seen := make(map[string]bool, len(req.Rates))
+	for _, in := range req.Rates {
+		countryCode := strings.TrimPrefix(strings.TrimSpace(in.CountryCode), "+")
+		category := strings.ToLower(strings.TrimSpace(in.Category))
+		if category == "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "category is required for every rate", nil, "")
+		}
+		if !isCallingCode(countryCode) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Invalid country code %q, use the calling code e.g. 91", in.CountryCode), nil, "")
+		}
+		if in.Rate < 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "rate cannot be negative", nil, "")
+		}
+		key := countryCode + "/" + category
+		if seen[key] {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Duplicate rate for country code %q and category %q", countryCode, category), nil, "")
+		}
+		seen[key] = true
+
+		rates = append(rates, models.PricingRate{
+			OrganizationID: orgID,
+			CountryCode:    countryCode,
+			Category:       category,
+			Rate:           in.Rate,
+			Currency:       currency,
+		})
+	}
+
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("organization_id = ?", orgID).Delete(&models.PricingRate{}).Error; err != nil {
+			return err
+		}
+		if len(rates) == 0 {
+			return nil
+		}
+		return tx.Create(&rates).Error
+	})
+	if err != nil {
+		a.Log.Error("Failed to update pricing rates", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update pricing rates", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"message": "Rate card updated",
+		"count":   len(rates),
+	})
+}
+
+// GetSpendAnalytics returns what billable conversations cost in a date
+// range, broken down by account, campaign, template, agent, category or
+// country
+func (a *App) GetSpendAnalytics(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAnalytics, models.ActionRead); err != nil {
+		return nil
+	}
+
+	fromStr := string(r.RequestCtx.QueryArgs().Peek("from"))
+	toStr := string(r.RequestCtx.QueryArgs().Peek("to"))
+	groupBy := string(r.RequestCtx.QueryArgs().Peek("group_by"))
+	if groupBy == "" {
+		groupBy = "campaign"
+	}
+	grouping, ok := spendGroupings[groupBy]
+	if !ok {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "group_by must be one of account, campaign, template, agent, category, country", nil, "")
+	}
+
+	now := time.Now()
+	var periodStart, periodEnd time.Time
+	if fromStr != "" && toStr != "" {
+		var errMsg string
+		periodStart, periodEnd, errMsg = parseDateRange(fromStr, toStr)
+		if errMsg != "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, errMsg, nil, "")
+		}
+	} else {
+		// Default to current month
+		periodStart = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		periodEnd = now
+	}
+
+	response := SpendAnalyticsResponse{
+		GroupBy:   groupBy,
+		Totals:    []SpendTotal{},
+		Breakdown: []SpendBreakdownRow{},
+	}
+
+	if err := a.DB.Raw(`
+		SELECT currency, COALESCE(SUM(cost), 0) AS cost, COUNT(*) AS conversations,
+			COUNT(*) FILTER (WHERE NOT rated) AS unrated
+		FROM conversation_costs
+		WHERE organization_id = ? AND started_at >= ? AND started_at <= ? AND deleted_at IS NULL
+		GROUP BY currency ORDER BY cost DESC
+	`, orgID, periodStart, periodEnd).Scan(&response.Totals).Error; err != nil {
+		a.Log.Error("Failed to load spend totals", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load spend", nil, "")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT COALESCE(%s, '') AS key, COALESCE(MAX(%s), '') AS label, c.currency,
+			COALESCE(SUM(c.cost), 0) AS cost, COUNT(*) AS conversations,
+			COUNT(*) FILTER (WHERE NOT c.rated) AS unrated
+		FROM conversation_costs c %s
+		WHERE c.organization_id = ? AND c.started_at >= ? AND c.started_at <= ? AND c.deleted_at IS NULL
+		GROUP BY 1, c.currency ORDER BY cost DESC, conversations DESC
+	`, grouping.key, grouping.label, grouping.join)
+	if err := a.DB.Raw(query, orgID, periodStart, periodEnd).Scan(&response.Breakdown).Error; err != nil {
+		a.Log.Error("Failed to load spend breakdown", "error", err, "group_by", groupBy)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load spend", nil, "")
+	}
+
+	return r.SendEnvelope(response)
+}
+
+// isCurrencyCode reports whether s looks like an ISO 4217 code
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isCallingCode reports whether s is empty or a 1-4 digit calling code
+func isCallingCode(s string) bool {
+	if len(s) > 4 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
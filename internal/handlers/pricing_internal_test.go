@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPricingRate(t *testing.T) {
+	t.Parallel()
+
+	rates := []models.PricingRate{
+		{CountryCode: "", Category: "marketing", Rate: 0.05},
+		{CountryCode: "1", Category: "marketing", Rate: 0.025},
+		{CountryCode: "91", Category: "marketing", Rate: 0.0107},
+		{CountryCode: "91", Category: "utility", Rate: 0.0014},
+	}
+
+	tests := []struct {
+		name     string
+		phone    string
+		category string
+		want     float64
+		wantNil  bool
+	}{
+		{name: "country match", phone: "919876543210", category: "marketing", want: 0.0107},
+		{name: "leading plus", phone: "+919876543210", category: "utility", want: 0.0014},
+		{name: "shorter prefix", phone: "15551234567", category: "marketing", want: 0.025},
+		{name: "falls back to any country", phone: "447700900123", category: "marketing", want: 0.05},
+		{name: "no line for category", phone: "447700900123", category: "utility", wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate := matchPricingRate(rates, tt.phone, tt.category)
+			if tt.wantNil {
+				assert.Nil(t, rate)
+				return
+			}
+			require.NotNil(t, rate)
+			assert.Equal(t, tt.want, rate.Rate)
+		})
+	}
+}
+
+// pricedStatus builds a status update carrying conversation and pricing info.
+func pricedStatus(t *testing.T, wamid, status, conversationID, pricingModel, category string, billable bool) WebhookStatus {
+	t.Helper()
+	var s WebhookStatus
+	require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(`{
+		"id": %q, "status": %q, "recipient_id": "919876543210",
+		"conversation": {"id": %q},
+		"pricing": {"billable": %t, "pricing_model": %q, "category": %q}
+	}`, wamid, status, conversationID, billable, pricingModel, category)), &s))
+	return s
+}
+
+func TestRecordMessagePricing_RecordsConversationCostOnce(t *testing.T) {
+	app := webhookTestApp(t)
+	org, msg, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+
+	require.NoError(t, app.DB.Create(&models.PricingRate{
+		OrganizationID: org.ID, CountryCode: "91", Category: "marketing", Rate: 0.0107, Currency: "USD",
+	}).Error)
+
+	conversationID := "conv-" + msg.ID.String()
+	app.recordMessagePricing(pricedStatus(t, msg.WhatsAppMessageID, "sent", conversationID, "CBP", "marketing", true))
+	app.recordMessagePricing(pricedStatus(t, msg.WhatsAppMessageID, "delivered", conversationID, "CBP", "marketing", true))
+
+	var updated models.Message
+	require.NoError(t, app.DB.First(&updated, msg.ID).Error)
+	assert.Equal(t, conversationID, updated.ConversationID)
+	assert.Equal(t, "marketing", updated.PricingCategory)
+	assert.Equal(t, "CBP", updated.PricingModel)
+	assert.True(t, updated.Billable)
+
+	var costs []models.ConversationCost
+	require.NoError(t, app.DB.Where("organization_id = ?", org.ID).Find(&costs).Error)
+	require.Len(t, costs, 1)
+	assert.Equal(t, conversationID, costs[0].ConversationID)
+	assert.InDelta(t, 0.0107, costs[0].Cost, 1e-9)
+	assert.Equal(t, "USD", costs[0].Currency)
+	assert.Equal(t, "91", costs[0].CountryCode)
+	assert.True(t, costs[0].Rated)
+	require.NotNil(t, costs[0].CampaignID)
+	assert.Equal(t, campaign.ID, *costs[0].CampaignID)
+	assert.Equal(t, msg.ID, costs[0].MessageID)
+}
+
+func TestRecordMessagePricing_NotBillable(t *testing.T) {
+	app := webhookTestApp(t)
+	org, msg, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+
+	app.recordMessagePricing(pricedStatus(t, msg.WhatsAppMessageID, "sent", "conv-free", "PMP", "service", false))
+
+	var updated models.Message
+	require.NoError(t, app.DB.First(&updated, msg.ID).Error)
+	assert.Equal(t, "service", updated.PricingCategory)
+	assert.False(t, updated.Billable)
+
+	var count int64
+	app.DB.Model(&models.ConversationCost{}).Where("organization_id = ?", org.ID).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestRecordMessagePricing_UnratedWithoutRateCard(t *testing.T) {
+	app := webhookTestApp(t)
+	org, msg, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+
+	app.recordMessagePricing(pricedStatus(t, msg.WhatsAppMessageID, "sent", "conv-x", "PMP", "utility", true))
+
+	var cost models.ConversationCost
+	require.NoError(t, app.DB.Where("organization_id = ?", org.ID).First(&cost).Error)
+	// Per-message pricing keys the cost on the message, not the conversation
+	assert.Equal(t, msg.WhatsAppMessageID, cost.ConversationID)
+	assert.False(t, cost.Rated)
+	assert.Zero(t, cost.Cost)
+}
@@ -0,0 +1,165 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestApp_UpdatePricingRates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replaces the rate card", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+		for _, body := range []handlers.PricingRateCardRequest{
+			{Currency: "usd", Rates: []handlers.PricingRateInput{
+				{CountryCode: "91", Category: "Marketing", Rate: 0.0107},
+				{CountryCode: "", Category: "marketing", Rate: 0.05},
+			}},
+			{Currency: "EUR", Rates: []handlers.PricingRateInput{
+				{CountryCode: "+44", Category: "utility", Rate: 0.02},
+			}},
+		} {
+			req := testutil.NewJSONRequest(t, body)
+			testutil.SetAuthContext(req, org.ID, user.ID)
+			require.NoError(t, app.UpdatePricingRates(req))
+			require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		}
+
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.GetPricingRates(req))
+
+		var resp struct {
+			Data struct {
+				Currency string                         `json:"currency"`
+				Rates    []handlers.PricingRateResponse `json:"rates"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, "EUR", resp.Data.Currency)
+		require.Len(t, resp.Data.Rates, 1)
+		assert.Equal(t, "44", resp.Data.Rates[0].CountryCode)
+		assert.Equal(t, "utility", resp.Data.Rates[0].Category)
+	})
+
+	t.Run("rejects invalid lines", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+		for name, body := range map[string]handlers.PricingRateCardRequest{
+			"bad currency":   {Currency: "dollars", Rates: []handlers.PricingRateInput{{Category: "marketing", Rate: 1}}},
+			"no category":    {Currency: "USD", Rates: []handlers.PricingRateInput{{CountryCode: "91", Rate: 1}}},
+			"iso country":    {Currency: "USD", Rates: []handlers.PricingRateInput{{CountryCode: "IN", Category: "marketing", Rate: 1}}},
+			"negative rate":  {Currency: "USD", Rates: []handlers.PricingRateInput{{Category: "marketing", Rate: -1}}},
+			"duplicate line": {Currency: "USD", Rates: []handlers.PricingRateInput{{CountryCode: "91", Category: "marketing", Rate: 1}, {CountryCode: "91", Category: "marketing", Rate: 2}}},
+		} {
+			req := testutil.NewJSONRequest(t, body)
+			testutil.SetAuthContext(req, org.ID, user.ID)
+			require.NoError(t, app.UpdatePricingRates(req))
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req), name)
+		}
+	})
+}
+
+func TestApp_GetSpendAnalytics(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+	now := time.Now()
+	for i, tc := range []struct {
+		template string
+		cost     float64
+		rated    bool
+	}{
+		{"promo", 0.0107, true},
+		{"promo", 0.0107, true},
+		{"otp", 0.0014, true},
+		{"otp", 0, false},
+	} {
+		require.NoError(t, app.DB.Create(&models.ConversationCost{
+			OrganizationID:  org.ID,
+			ConversationID:  uuid.New().String(),
+			WhatsAppAccount: "main",
+			TemplateName:    tc.template,
+			Category:        "marketing",
+			Cost:            tc.cost,
+			Currency:        "USD",
+			Rated:           tc.rated,
+			StartedAt:       now.Add(-time.Duration(i) * time.Minute),
+		}).Error)
+	}
+
+	t.Run("group by template", func(t *testing.T) {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetQueryParam(req, "group_by", "template")
+
+		require.NoError(t, app.GetSpendAnalytics(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data handlers.SpendAnalyticsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+
+		require.Len(t, resp.Data.Totals, 1)
+		assert.Equal(t, "USD", resp.Data.Totals[0].Currency)
+		assert.InDelta(t, 0.0228, resp.Data.Totals[0].Cost, 1e-9)
+		assert.Equal(t, int64(4), resp.Data.Totals[0].Conversations)
+		assert.Equal(t, int64(1), resp.Data.Totals[0].Unrated)
+
+		require.Len(t, resp.Data.Breakdown, 2)
+		assert.Equal(t, "promo", resp.Data.Breakdown[0].Key)
+		assert.InDelta(t, 0.0214, resp.Data.Breakdown[0].Cost, 1e-9)
+		assert.Equal(t, "otp", resp.Data.Breakdown[1].Key)
+		assert.Equal(t, int64(1), resp.Data.Breakdown[1].Unrated)
+	})
+
+	t.Run("group by account", func(t *testing.T) {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetQueryParam(req, "group_by", "account")
+
+		require.NoError(t, app.GetSpendAnalytics(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data handlers.SpendAnalyticsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+
+		require.Len(t, resp.Data.Breakdown, 1)
+		assert.Equal(t, "main", resp.Data.Breakdown[0].Key)
+		assert.Equal(t, "main", resp.Data.Breakdown[0].Label)
+		assert.InDelta(t, 0.0228, resp.Data.Breakdown[0].Cost, 1e-9)
+		assert.Equal(t, int64(4), resp.Data.Breakdown[0].Conversations)
+	})
+
+	t.Run("invalid group by", func(t *testing.T) {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetQueryParam(req, "group_by", "phone_number")
+
+		require.NoError(t, app.GetSpendAnalytics(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+}
@@ -0,0 +1,567 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/cronexpr"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// RecurringCampaignRequest represents the request body for creating/updating a recurring campaign
+type RecurringCampaignRequest struct {
+	Name            string                `json:"name"`
+	WhatsAppAccount string                `json:"whatsapp_account"`
+	TemplateID      string                `json:"template_id"`
+	CronExpression  string                `json:"cron_expression"`
+	Timezone        string                `json:"timezone"`
+	SenderAccounts  []string              `json:"sender_accounts"`
+	SenderStrategy  models.SenderStrategy `json:"sender_strategy"`
+	SegmentID       *uuid.UUID            `json:"segment_id"`
+	SegmentParams   map[string]string     `json:"segment_params"`
+	Recipients      *[]RecipientRequest   `json:"recipients"` // Omitted on update keeps the current list
+}
+
+// RecurringCampaignResponse represents a recurring campaign in API responses
+type RecurringCampaignResponse struct {
+	ID              uuid.UUID                      `json:"id"`
+	Name            string                         `json:"name"`
+	WhatsAppAccount string                         `json:"whatsapp_account"`
+	TemplateID      uuid.UUID                      `json:"template_id"`
+	TemplateName    string                         `json:"template_name,omitempty"`
+	CronExpression  string                         `json:"cron_expression"`
+	Timezone        string                         `json:"timezone"`
+	Status          models.RecurringCampaignStatus `json:"status"`
+	NextRunAt       *time.Time                     `json:"next_run_at,omitempty"`
+	LastRunAt       *time.Time                     `json:"last_run_at,omitempty"`
+	SenderAccounts  []string                       `json:"sender_accounts,omitempty"`
+	SenderStrategy  models.SenderStrategy          `json:"sender_strategy,omitempty"`
+	SegmentID       *uuid.UUID                     `json:"segment_id,omitempty"`
+	SegmentParams   map[string]string              `json:"segment_params,omitempty"`
+	Recipients      []models.RecurringRecipient    `json:"recipients"`
+	CreatedAt       time.Time                      `json:"created_at"`
+	UpdatedAt       time.Time                      `json:"updated_at"`
+}
+
+// RecurringCampaignRun is one campaign created by a recurring campaign
+type RecurringCampaignRun struct {
+	ID              uuid.UUID             `json:"id"`
+	Name            string                `json:"name"`
+	Status          models.CampaignStatus `json:"status"`
+	TotalRecipients int                   `json:"total_recipients"`
+	SentCount       int                   `json:"sent_count"`
+	DeliveredCount  int                   `json:"delivered_count"`
+	ReadCount       int                   `json:"read_count"`
+	FailedCount     int                   `json:"failed_count"`
+	ScheduledAt     *time.Time            `json:"scheduled_at,omitempty"`
+	StartedAt       *time.Time            `json:"started_at,omitempty"`
+	CompletedAt     *time.Time            `json:"completed_at,omitempty"`
+}
+
+// ListRecurringCampaigns returns the organization's recurring campaigns
+func (a *App) ListRecurringCampaigns(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionRead); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	search := string(r.RequestCtx.QueryArgs().Peek("search"))
+
+	query := a.DB.Where("organization_id = ?", orgID)
+	if search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	query.Model(&models.RecurringCampaign{}).Count(&total)
+
+	var recurring []models.RecurringCampaign
+	if err := pg.Apply(query.Preload("Template").Order("name ASC")).Find(&recurring).Error; err != nil {
+		a.Log.Error("Failed to list recurring campaigns", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list recurring campaigns", nil, "")
+	}
+
+	mask := a.ShouldMaskPhoneNumbers(orgID)
+	result := make([]RecurringCampaignResponse, len(recurring))
+	for i, rc := range recurring {
+		result[i] = recurringCampaignToResponse(rc, mask)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"recurring_campaigns": result,
+		"total":               total,
+		"page":                pg.Page,
+		"limit":               pg.Limit,
+	})
+}
+
+// CreateRecurringCampaign creates a recurring campaign. It is active from the
+// start, so its first run is the next time the schedule fires.
+func (a *App) CreateRecurringCampaign(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req RecurringCampaignRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	rc := models.RecurringCampaign{
+		OrganizationID: orgID,
+		Status:         models.RecurringCampaignStatusActive,
+		CreatedBy:      userID,
+	}
+	if err := a.applyRecurringCampaignRequest(orgID, &rc, &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	if err := a.DB.Omit("Template").Create(&rc).Error; err != nil {
+		a.Log.Error("Failed to create recurring campaign", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create recurring campaign", nil, "")
+	}
+
+	a.Log.Info("Recurring campaign created", "recurring_campaign_id", rc.ID, "cron", rc.CronExpression, "next_run_at", rc.NextRunAt)
+	return r.SendEnvelope(recurringCampaignToResponse(rc, a.ShouldMaskPhoneNumbers(orgID)))
+}
+
+// GetRecurringCampaign returns a recurring campaign
+func (a *App) GetRecurringCampaign(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionRead); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "recurring campaign")
+	if err != nil {
+		return nil
+	}
+
+	var rc models.RecurringCampaign
+	if err := a.DB.Where("id = ? AND organization_id = ?", id, orgID).Preload("Template").First(&rc).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Recurring campaign not found", nil, "")
+	}
+
+	return r.SendEnvelope(recurringCampaignToResponse(rc, a.ShouldMaskPhoneNumbers(orgID)))
+}
+
+// UpdateRecurringCampaign updates a recurring campaign. Runs already created
+// keep their settings; an active campaign's next run follows the new schedule.
+func (a *App) UpdateRecurringCampaign(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "recurring campaign")
+	if err != nil {
+		return nil
+	}
+
+	rc, err := findByIDAndOrg[models.RecurringCampaign](a.DB, r, id, orgID, "Recurring campaign")
+	if err != nil {
+		return nil
+	}
+
+	var req RecurringCampaignRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if err := a.applyRecurringCampaignRequest(orgID, rc, &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	// Save writes zero values too, so a cleared segment or pool is cleared
+	if err := a.DB.Omit("Template", "Segment").Save(rc).Error; err != nil {
+		a.Log.Error("Failed to update recurring campaign", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update recurring campaign", nil, "")
+	}
+
+	return r.SendEnvelope(recurringCampaignToResponse(*rc, a.ShouldMaskPhoneNumbers(orgID)))
+}
+
+// DeleteRecurringCampaign deletes a recurring campaign. Its runs are kept.
+func (a *App) DeleteRecurringCampaign(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionDelete); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "recurring campaign")
+	if err != nil {
+		return nil
+	}
+
+	rc, err := findByIDAndOrg[models.RecurringCampaign](a.DB, r, id, orgID, "Recurring campaign")
+	if err != nil {
+		return nil
+	}
+
+	if err := a.DB.Delete(rc).Error; err != nil {
+		a.Log.Error("Failed to delete recurring campaign", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete recurring campaign", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{"message": "Recurring campaign deleted"})
+}
+
+// PauseRecurringCampaign stops a recurring campaign from creating runs.
+// Runs already created are not affected.
+func (a *App) PauseRecurringCampaign(r *fastglue.Request) error {
+	return a.setRecurringCampaignStatus(r, models.RecurringCampaignStatusPaused)
+}
+
+// ResumeRecurringCampaign reactivates a paused recurring campaign. Occurrences
+// missed while paused are skipped; the next run is the next time the
+// schedule fires.
+func (a *App) ResumeRecurringCampaign(r *fastglue.Request) error {
+	return a.setRecurringCampaignStatus(r, models.RecurringCampaignStatusActive)
+}
+
+func (a *App) setRecurringCampaignStatus(r *fastglue.Request, status models.RecurringCampaignStatus) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "recurring campaign")
+	if err != nil {
+		return nil
+	}
+
+	rc, err := findByIDAndOrg[models.RecurringCampaign](a.DB, r, id, orgID, "Recurring campaign")
+	if err != nil {
+		return nil
+	}
+
+	if rc.Status == status {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Recurring campaign is already "+string(status), nil, "")
+	}
+
+	rc.Status = status
+	rc.NextRunAt = nil
+	if status == models.RecurringCampaignStatusActive {
+		next, err := nextRecurringRun(rc.CronExpression, rc.Timezone, time.Now())
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		rc.NextRunAt = &next
+	}
+
+	if err := a.DB.Model(rc).Updates(map[string]any{
+		"status":      rc.Status,
+		"next_run_at": rc.NextRunAt,
+	}).Error; err != nil {
+		a.Log.Error("Failed to update recurring campaign status", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update recurring campaign", nil, "")
+	}
+
+	return r.SendEnvelope(recurringCampaignToResponse(*rc, a.ShouldMaskPhoneNumbers(orgID)))
+}
+
+// ListRecurringCampaignRuns returns the campaigns a recurring campaign has
+// created, newest first
+func (a *App) ListRecurringCampaignRuns(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionRead); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "recurring campaign")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := findByIDAndOrg[models.RecurringCampaign](a.DB, r, id, orgID, "Recurring campaign"); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Where("organization_id = ? AND recurring_campaign_id = ?", orgID, id)
+
+	var total int64
+	query.Model(&models.BulkMessageCampaign{}).Count(&total)
+
+	var campaigns []models.BulkMessageCampaign
+	if err := pg.Apply(query.Order("created_at DESC")).Find(&campaigns).Error; err != nil {
+		a.Log.Error("Failed to list recurring campaign runs", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list runs", nil, "")
+	}
+
+	runs := make([]RecurringCampaignRun, len(campaigns))
+	for i, c := range campaigns {
+		runs[i] = RecurringCampaignRun{
+			ID:              c.ID,
+			Name:            c.Name,
+			Status:          c.Status,
+			TotalRecipients: c.TotalRecipients,
+			SentCount:       c.SentCount,
+			DeliveredCount:  c.DeliveredCount,
+			ReadCount:       c.ReadCount,
+			FailedCount:     c.FailedCount,
+			ScheduledAt:     c.ScheduledAt,
+			StartedAt:       c.StartedAt,
+			CompletedAt:     c.CompletedAt,
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"runs":  runs,
+		"total": total,
+		"page":  pg.Page,
+		"limit": pg.Limit,
+	})
+}
+
+// applyRecurringCampaignRequest validates a recurring campaign request and
+// copies it onto rc. An active campaign's next run is recomputed from now.
+func (a *App) applyRecurringCampaignRequest(orgID uuid.UUID, rc *models.RecurringCampaign, req *RecurringCampaignRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	templateID, err := uuid.Parse(req.TemplateID)
+	if err != nil {
+		return fmt.Errorf("Invalid template ID")
+	}
+	var template models.Template
+	if err := a.DB.Where("id = ? AND organization_id = ?", templateID, orgID).First(&template).Error; err != nil {
+		return fmt.Errorf("Template not found")
+	}
+
+	account, err := a.resolveWhatsAppAccount(orgID, req.WhatsAppAccount)
+	if err != nil {
+		return fmt.Errorf("WhatsApp account not found")
+	}
+
+	req.CronExpression = strings.TrimSpace(req.CronExpression)
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	next, err := nextRecurringRun(req.CronExpression, req.Timezone, time.Now())
+	if err != nil {
+		return err
+	}
+
+	senderAccounts, senderStrategy, err := a.validateSenderPool(orgID, account, &template, req.SenderAccounts, req.SenderStrategy)
+	if err != nil {
+		return err
+	}
+
+	var segmentParams models.JSONB
+	if req.SegmentID != nil {
+		if err := a.validateCampaignSegment(orgID, *req.SegmentID, &template, req.SegmentParams); err != nil {
+			return err
+		}
+		segmentParams = stringMapToJSONB(req.SegmentParams)
+	}
+
+	recipients := rc.Recipients
+	if req.Recipients != nil {
+		recipients = make(models.RecurringRecipients, 0, len(*req.Recipients))
+		for i, rec := range *req.Recipients {
+			phone := strings.TrimSpace(rec.PhoneNumber)
+			if phone == "" {
+				return fmt.Errorf("Recipient %d has no phone number", i+1)
+			}
+			recipients = append(recipients, models.RecurringRecipient{
+				PhoneNumber:    phone,
+				RecipientName:  rec.RecipientName,
+				TemplateParams: rec.TemplateParams,
+			})
+		}
+	}
+	if req.SegmentID == nil && len(recipients) == 0 {
+		return fmt.Errorf("A recurring campaign needs a segment or recipients")
+	}
+
+	rc.Name = req.Name
+	rc.WhatsAppAccount = account.Name
+	rc.TemplateID = template.ID
+	rc.Template = &template
+	rc.CronExpression = req.CronExpression
+	rc.Timezone = req.Timezone
+	rc.SenderAccounts = senderAccounts
+	rc.SenderStrategy = senderStrategy
+	rc.SegmentID = req.SegmentID
+	rc.SegmentParams = segmentParams
+	rc.Recipients = recipients
+	if rc.Status == models.RecurringCampaignStatusActive {
+		rc.NextRunAt = &next
+	}
+	return nil
+}
+
+// nextRecurringRun returns the first time after `after` that a cron
+// expression fires in the given time zone. Schedules may fire at most once
+// an hour.
+func nextRecurringRun(expr, timezone string, after time.Time) (time.Time, error) {
+	schedule, err := cronexpr.Parse(expr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid cron expression: %v", err)
+	}
+	if schedule.RunsPerHour() > 1 {
+		return time.Time{}, fmt.Errorf("A recurring campaign can run at most once an hour")
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid timezone")
+	}
+	next := schedule.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("Cron expression never fires")
+	}
+	return next, nil
+}
+
+// createRecurringRun claims one due occurrence of a recurring campaign and
+// creates its run as a scheduled campaign with the fixed recipients. The
+// claim moves next_run_at on only if it still holds the due time, so when
+// several replicas race for the same occurrence only one creates the run;
+// it returns nil for the others. Missed occurrences are not caught up: the
+// next run is the next time the schedule fires after now.
+func (a *App) createRecurringRun(rc *models.RecurringCampaign, now time.Time) (*models.BulkMessageCampaign, error) {
+	if rc.NextRunAt == nil {
+		return nil, nil
+	}
+	dueAt := *rc.NextRunAt
+
+	var nextRunAt *time.Time
+	if next, err := nextRecurringRun(rc.CronExpression, rc.Timezone, now); err == nil {
+		nextRunAt = &next
+	} else {
+		a.Log.Warn("Recurring campaign schedule no longer valid, it will not run again", "error", err, "recurring_campaign_id", rc.ID)
+	}
+
+	var run *models.BulkMessageCampaign
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RecurringCampaign{}).
+			Where("id = ? AND status = ? AND next_run_at = ?", rc.ID, models.RecurringCampaignStatusActive, dueAt).
+			Updates(map[string]any{"next_run_at": nextRunAt, "last_run_at": dueAt})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		loc, err := time.LoadLocation(rc.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		run = &models.BulkMessageCampaign{
+			OrganizationID:      rc.OrganizationID,
+			WhatsAppAccount:     rc.WhatsAppAccount,
+			Name:                fmt.Sprintf("%s (%s)", rc.Name, dueAt.In(loc).Format("2006-01-02 15:04")),
+			TemplateID:          rc.TemplateID,
+			Status:              models.CampaignStatusScheduled,
+			TotalRecipients:     len(rc.Recipients),
+			ScheduledAt:         &dueAt,
+			CreatedBy:           rc.CreatedBy,
+			SenderAccounts:      rc.SenderAccounts,
+			SenderStrategy:      rc.SenderStrategy,
+			SegmentID:           rc.SegmentID,
+			SegmentParams:       rc.SegmentParams,
+			RecurringCampaignID: &rc.ID,
+		}
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+
+		if len(rc.Recipients) == 0 {
+			return nil
+		}
+		recipients := make([]models.BulkMessageRecipient, len(rc.Recipients))
+		for i, rec := range rc.Recipients {
+			recipients[i] = models.BulkMessageRecipient{
+				CampaignID:     run.ID,
+				PhoneNumber:    rec.PhoneNumber,
+				RecipientName:  rec.RecipientName,
+				TemplateParams: models.JSONB(rec.TemplateParams),
+				Status:         models.MessageStatusPending,
+			}
+		}
+		return tx.Create(&recipients).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	rc.NextRunAt, rc.LastRunAt = nextRunAt, &dueAt
+	return run, nil
+}
+
+func recurringCampaignToResponse(rc models.RecurringCampaign, maskPhones bool) RecurringCampaignResponse {
+	resp := RecurringCampaignResponse{
+		ID:              rc.ID,
+		Name:            rc.Name,
+		WhatsAppAccount: rc.WhatsAppAccount,
+		TemplateID:      rc.TemplateID,
+		CronExpression:  rc.CronExpression,
+		Timezone:        rc.Timezone,
+		Status:          rc.Status,
+		NextRunAt:       rc.NextRunAt,
+		LastRunAt:       rc.LastRunAt,
+		SenderAccounts:  rc.SenderAccounts,
+		SenderStrategy:  rc.SenderStrategy,
+		SegmentID:       rc.SegmentID,
+		Recipients:      []models.RecurringRecipient(rc.Recipients),
+		CreatedAt:       rc.CreatedAt,
+		UpdatedAt:       rc.UpdatedAt,
+	}
+	if rc.Template != nil {
+		resp.TemplateName = rc.Template.Name
+	}
+	if rc.SegmentParams != nil {
+		resp.SegmentParams = jsonbToStringMap(rc.SegmentParams)
+	}
+	if resp.Recipients == nil {
+		resp.Recipients = []models.RecurringRecipient{}
+	}
+	if maskPhones {
+		masked := make([]models.RecurringRecipient, len(resp.Recipients))
+		for i, rec := range resp.Recipients {
+			rec.PhoneNumber = MaskPhoneNumber(rec.PhoneNumber)
+			rec.RecipientName = MaskIfPhoneNumber(rec.RecipientName)
+			masked[i] = rec
+		}
+		resp.Recipients = masked
+	}
+	return resp
+}
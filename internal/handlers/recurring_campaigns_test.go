@@ -0,0 +1,166 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+func TestApp_CreateRecurringCampaign_Validation(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, orgID)
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+	recipients := []map[string]any{{"phone_number": "15550000101"}}
+
+	tests := []struct {
+		name string
+		body map[string]any
+		want string
+	}{
+		{"bad cron", map[string]any{"cron_expression": "0 9 * *", "recipients": recipients}, "Invalid cron expression"},
+		{"too frequent", map[string]any{"cron_expression": "*/5 * * * *", "recipients": recipients}, "at most once an hour"},
+		{"bad timezone", map[string]any{"cron_expression": "0 9 * * *", "timezone": "Mars/Olympus", "recipients": recipients}, "Invalid timezone"},
+		{"no audience", map[string]any{"cron_expression": "0 9 * * *"}, "needs a segment or recipients"},
+		{"unknown segment", map[string]any{"cron_expression": "0 9 * * *", "segment_id": uuid.New()}, "Segment not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.body["name"] = "Weekly reminder"
+			tt.body["whatsapp_account"] = account.Name
+			tt.body["template_id"] = template.ID.String()
+			req := testutil.NewJSONRequest(t, tt.body)
+			testutil.SetAuthContext(req, orgID, userID)
+			require.NoError(t, app.CreateRecurringCampaign(req))
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+			assert.Contains(t, string(testutil.GetResponseBody(req)), tt.want)
+		})
+	}
+}
+
+func TestApp_RecurringCampaign_PauseResume(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, orgID)
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"name":             "Monthly statement",
+		"whatsapp_account": account.Name,
+		"template_id":      template.ID.String(),
+		"cron_expression":  "0 9 1 * *",
+		"timezone":         "Asia/Kolkata",
+		"recipients":       []map[string]any{{"phone_number": "15550000111", "template_params": map[string]any{"1": "Alice"}}},
+	})
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.CreateRecurringCampaign(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var created struct {
+		Data handlers.RecurringCampaignResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &created))
+	assert.Equal(t, models.RecurringCampaignStatusActive, created.Data.Status)
+	require.NotNil(t, created.Data.NextRunAt)
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+	next := created.Data.NextRunAt.In(loc)
+	assert.Equal(t, 1, next.Day())
+	assert.Equal(t, 9, next.Hour())
+
+	setStatus := func(fn func(*fastglue.Request) error) handlers.RecurringCampaignResponse {
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, orgID, userID)
+		testutil.SetPathParam(req, "id", created.Data.ID.String())
+		require.NoError(t, fn(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		var resp struct {
+			Data handlers.RecurringCampaignResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		return resp.Data
+	}
+
+	paused := setStatus(app.PauseRecurringCampaign)
+	assert.Equal(t, models.RecurringCampaignStatusPaused, paused.Status)
+	assert.Nil(t, paused.NextRunAt)
+
+	resumed := setStatus(app.ResumeRecurringCampaign)
+	assert.Equal(t, models.RecurringCampaignStatusActive, resumed.Status)
+	assert.NotNil(t, resumed.NextRunAt)
+}
+
+func TestCampaignScheduler_CreatesRecurringRuns(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("sched-recurring")), testutil.WithRoleID(&role.ID))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("sched-recurring-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	due := time.Now().Add(-time.Minute).Truncate(time.Second)
+	rc := &models.RecurringCampaign{
+		OrganizationID:  org.ID,
+		Name:            "Weekly reminder",
+		WhatsAppAccount: account.Name,
+		TemplateID:      template.ID,
+		CronExpression:  "0 9 * * MON",
+		Timezone:        "UTC",
+		Status:          models.RecurringCampaignStatusActive,
+		NextRunAt:       &due,
+		CreatedBy:       user.ID,
+		Recipients: models.RecurringRecipients{
+			{PhoneNumber: "15550000121", TemplateParams: map[string]any{"1": "Alice"}},
+			{PhoneNumber: "15550000122", TemplateParams: map[string]any{"1": "Bob"}},
+		},
+	}
+	require.NoError(t, app.DB.Create(rc).Error)
+
+	scheduler := handlers.NewCampaignScheduler(app, time.Minute)
+	assert.Equal(t, 1, scheduler.CreateDueRecurringRuns(context.Background()))
+	assert.Len(t, mockQueue.Jobs, 2)
+
+	// The occurrence is claimed, so another pass creates nothing
+	assert.Equal(t, 0, handlers.NewCampaignScheduler(app, time.Minute).CreateDueRecurringRuns(context.Background()))
+	assert.Len(t, mockQueue.Jobs, 2)
+
+	var updated models.RecurringCampaign
+	require.NoError(t, app.DB.First(&updated, rc.ID).Error)
+	require.NotNil(t, updated.NextRunAt)
+	assert.True(t, updated.NextRunAt.After(time.Now()))
+	assert.Equal(t, time.Monday, updated.NextRunAt.UTC().Weekday())
+	require.NotNil(t, updated.LastRunAt)
+	assert.True(t, updated.LastRunAt.Equal(due))
+
+	var runs []models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("recurring_campaign_id = ?", rc.ID).Find(&runs).Error)
+	require.Len(t, runs, 1)
+	assert.Equal(t, models.CampaignStatusProcessing, runs[0].Status)
+	assert.Equal(t, 2, runs[0].TotalRecipients)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", rc.ID.String())
+	require.NoError(t, app.ListRecurringCampaignRuns(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var resp struct {
+		Data struct {
+			Runs  []handlers.RecurringCampaignRun `json:"runs"`
+			Total int64                           `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, int64(1), resp.Data.Total)
+	require.Len(t, resp.Data.Runs, 1)
+	assert.Equal(t, runs[0].ID, resp.Data.Runs[0].ID)
+}
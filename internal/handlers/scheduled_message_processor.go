@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/suppression"
+)
+
+const (
+	// scheduledMessageBatchSize caps how many due messages one tick sends
+	scheduledMessageBatchSize = 100
+
+	// staleScheduledSendTimeout is how long a scheduled message may stay claimed
+	// before the dispatcher that claimed it is assumed to have died
+	staleScheduledSendTimeout = 10 * time.Minute
+
+	// serviceWindow is how long after a customer's last message free-form
+	// messages may be sent to them
+	serviceWindow = 24 * time.Hour
+)
+
+// ScheduledMessageProcessor sends scheduled chat messages once they are due
+type ScheduledMessageProcessor struct {
+	app      *App
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewScheduledMessageProcessor creates a new scheduled message processor
+func NewScheduledMessageProcessor(app *App, interval time.Duration) *ScheduledMessageProcessor {
+	return &ScheduledMessageProcessor{
+		app:      app,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the dispatch loop
+func (p *ScheduledMessageProcessor) Start(ctx context.Context) {
+	p.app.Log.Info("Scheduled message processor started", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.app.Log.Info("Scheduled message processor stopped by context")
+			return
+		case <-p.stopCh:
+			p.app.Log.Info("Scheduled message processor stopped")
+			return
+		case <-ticker.C:
+			p.DispatchDueMessages(ctx)
+		}
+	}
+}
+
+// Stop stops the scheduled message processor
+func (p *ScheduledMessageProcessor) Stop() {
+	close(p.stopCh)
+}
+
+// DispatchDueMessages sends every pending scheduled message whose send_at has passed.
+// Returns the number of messages this instance handled, whatever their outcome.
+func (p *ScheduledMessageProcessor) DispatchDueMessages(ctx context.Context) int {
+	p.failStaleSends()
+
+	var due []models.ScheduledMessage
+	if err := p.app.DB.Where("status = ? AND send_at <= ?", models.ScheduledMessageStatusPending, time.Now()).
+		Order("send_at ASC").
+		Limit(scheduledMessageBatchSize).
+		Find(&due).Error; err != nil {
+		p.app.Log.Error("Failed to load due scheduled messages", "error", err)
+		return 0
+	}
+
+	handled := 0
+	for i := range due {
+		if p.dispatchMessage(ctx, &due[i]) {
+			handled++
+		}
+	}
+	return handled
+}
+
+// dispatchMessage claims a single scheduled message and sends it. The claim is
+// a conditional status update, so only one replica sends a given message and a
+// message cancelled in the meantime is left alone.
+func (p *ScheduledMessageProcessor) dispatchMessage(ctx context.Context, sm *models.ScheduledMessage) bool {
+	result := p.app.DB.Model(&models.ScheduledMessage{}).
+		Where("id = ? AND status = ?", sm.ID, models.ScheduledMessageStatusPending).
+		Update("status", models.ScheduledMessageStatusSending)
+	if result.Error != nil {
+		p.app.Log.Error("Failed to claim scheduled message", "error", result.Error, "scheduled_message_id", sm.ID)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		return false
+	}
+	sm.Status = models.ScheduledMessageStatusSending
+
+	p.app.sendScheduledMessage(ctx, sm)
+
+	updates := map[string]any{
+		"status":        sm.Status,
+		"message_id":    sm.MessageID,
+		"used_fallback": sm.UsedFallback,
+		"sent_at":       sm.SentAt,
+		"error_message": sm.ErrorMessage,
+	}
+	if err := p.app.DB.Model(&models.ScheduledMessage{}).Where("id = ?", sm.ID).Updates(updates).Error; err != nil {
+		p.app.Log.Error("Failed to save scheduled message outcome", "error", err, "scheduled_message_id", sm.ID, "status", sm.Status)
+	}
+
+	p.app.DB.Preload("CreatedBy").Where("id = ?", sm.ID).First(sm)
+	p.app.broadcastScheduledMessage(sm.OrganizationID, scheduledMessageToResponse(*sm))
+	return true
+}
+
+// sendScheduledMessage sends a claimed scheduled message and records the
+// outcome on it. A text message due after the 24-hour service window has
+// closed is replaced by its fallback template, or not sent at all.
+func (a *App) sendScheduledMessage(ctx context.Context, sm *models.ScheduledMessage) {
+	fail := func(status models.ScheduledMessageStatus, reason string) {
+		sm.Status = status
+		sm.ErrorMessage = reason
+		a.Log.Warn("Scheduled message not sent", "scheduled_message_id", sm.ID, "status", status, "reason", reason)
+	}
+
+	var contact models.Contact
+	if err := a.DB.Where("id = ? AND organization_id = ?", sm.ContactID, sm.OrganizationID).First(&contact).Error; err != nil {
+		fail(models.ScheduledMessageStatusFailed, "contact not found")
+		return
+	}
+	if contact.IsBlocked {
+		fail(models.ScheduledMessageStatusFailed, errContactBlocked.Error())
+		return
+	}
+	if suppressed, err := suppression.IsSuppressed(a.DB, sm.OrganizationID, contact.PhoneNumber); err != nil {
+		fail(models.ScheduledMessageStatusFailed, "failed to check suppression list")
+		return
+	} else if suppressed {
+		fail(models.ScheduledMessageStatusSuppressed, errContactSuppressed.Error())
+		return
+	}
+
+	accountName := sm.WhatsAppAccount
+	if accountName == "" {
+		accountName = contact.WhatsAppAccount
+	}
+	account, err := a.resolveWhatsAppAccount(sm.OrganizationID, accountName)
+	if err != nil {
+		fail(models.ScheduledMessageStatusFailed, err.Error())
+		return
+	}
+
+	req := OutgoingMessageRequest{
+		Account: account,
+		Contact: &contact,
+		Type:    sm.MessageType,
+		Content: sm.Content,
+	}
+
+	templateID, templateParams := sm.TemplateID, sm.TemplateParams
+	if sm.MessageType == models.MessageTypeText && !isServiceWindowOpen(contact.LastInboundAt) {
+		if sm.FallbackTemplateID == nil {
+			fail(models.ScheduledMessageStatusWindowClosed, "24-hour service window closed and no fallback template was set")
+			return
+		}
+		req.Type = models.MessageTypeTemplate
+		templateID, templateParams = sm.FallbackTemplateID, sm.FallbackTemplateParams
+		sm.UsedFallback = true
+	}
+
+	if req.Type == models.MessageTypeTemplate {
+		var template models.Template
+		if templateID == nil || a.DB.Where("id = ? AND organization_id = ?", *templateID, sm.OrganizationID).First(&template).Error != nil {
+			fail(models.ScheduledMessageStatusFailed, "template not found")
+			return
+		}
+		params := jsonbToStringMap(templateParams)
+		if reason := checkScheduleTemplate(&template, params); reason != "" {
+			fail(models.ScheduledMessageStatusFailed, reason)
+			return
+		}
+		req.Template = &template
+		req.BodyParams = params
+	}
+
+	opts := ScheduledSendOptions()
+	opts.SentByUserID = &sm.CreatedByID
+
+	msg, err := a.SendOutgoingMessage(ctx, req, opts)
+	if err != nil {
+		fail(models.ScheduledMessageStatusFailed, err.Error())
+		return
+	}
+	sm.MessageID = &msg.ID
+
+	// Sync send: the final status has already been written to the message row
+	var sent models.Message
+	if err := a.DB.Select("status", "error_message").Where("id = ?", msg.ID).First(&sent).Error; err == nil &&
+		sent.Status == models.MessageStatusFailed {
+		fail(models.ScheduledMessageStatusFailed, sent.ErrorMessage)
+		return
+	}
+
+	now := time.Now()
+	sm.Status = models.ScheduledMessageStatusSent
+	sm.SentAt = &now
+	a.Log.Info("Scheduled message sent", "scheduled_message_id", sm.ID, "message_id", msg.ID, "used_fallback", sm.UsedFallback)
+}
+
+// failStaleSends fails scheduled messages left claimed by a dispatcher that died.
+// They are not retried, since the message may have gone out before it died.
+func (p *ScheduledMessageProcessor) failStaleSends() {
+	result := p.app.DB.Model(&models.ScheduledMessage{}).
+		Where("status = ? AND updated_at < ?", models.ScheduledMessageStatusSending, time.Now().Add(-staleScheduledSendTimeout)).
+		Updates(map[string]any{
+			"status":        models.ScheduledMessageStatusFailed,
+			"error_message": "dispatcher stopped while sending; not retried in case the message went out",
+		})
+	if result.Error != nil {
+		p.app.Log.Error("Failed to fail stale scheduled sends", "error", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		p.app.Log.Warn("Failed stale scheduled sends", "count", result.RowsAffected)
+	}
+}
+
+// isServiceWindowOpen reports whether free-form messages may be sent to a
+// customer who last messaged at lastInboundAt
+func isServiceWindowOpen(lastInboundAt *time.Time) bool {
+	return lastInboundAt != nil && time.Since(*lastInboundAt) < serviceWindow
+}
+
+func jsonbToStringMap(m models.JSONB) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		} else if v != nil {
+			out[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// ScheduledMessageRequest is the body of POST /api/contacts/{id}/scheduled-messages
+type ScheduledMessageRequest struct {
+	SendAt          time.Time          `json:"send_at"`
+	Type            models.MessageType `json:"type"` // text (default) or template
+	Content         string             `json:"content"`
+	TemplateID      string             `json:"template_id,omitempty"`
+	TemplateParams  map[string]string  `json:"template_params,omitempty"`
+	WhatsAppAccount string             `json:"whatsapp_account,omitempty"`
+
+	// Template sent instead of a text message if the 24-hour window has closed by send_at
+	FallbackTemplateID     string            `json:"fallback_template_id,omitempty"`
+	FallbackTemplateParams map[string]string `json:"fallback_template_params,omitempty"`
+}
+
+// ScheduledMessageResponse is the API response for a scheduled message
+type ScheduledMessageResponse struct {
+	ID                     uuid.UUID                     `json:"id"`
+	ContactID              uuid.UUID                     `json:"contact_id"`
+	WhatsAppAccount        string                        `json:"whatsapp_account"`
+	CreatedByID            uuid.UUID                     `json:"created_by_id"`
+	CreatedByName          string                        `json:"created_by_name"`
+	SendAt                 time.Time                     `json:"send_at"`
+	Status                 models.ScheduledMessageStatus `json:"status"`
+	Type                   models.MessageType            `json:"type"`
+	Content                string                        `json:"content"`
+	TemplateID             *uuid.UUID                    `json:"template_id,omitempty"`
+	TemplateParams         models.JSONB                  `json:"template_params,omitempty"`
+	FallbackTemplateID     *uuid.UUID                    `json:"fallback_template_id,omitempty"`
+	FallbackTemplateParams models.JSONB                  `json:"fallback_template_params,omitempty"`
+	MessageID              *uuid.UUID                    `json:"message_id,omitempty"`
+	UsedFallback           bool                          `json:"used_fallback"`
+	SentAt                 *time.Time                    `json:"sent_at,omitempty"`
+	ErrorMessage           string                        `json:"error_message,omitempty"`
+	CancelledAt            *time.Time                    `json:"cancelled_at,omitempty"`
+	CreatedAt              time.Time                     `json:"created_at"`
+}
+
+// ListScheduledMessages returns a contact's scheduled messages, soonest first.
+// Pass ?status=pending to only get those still waiting to be sent.
+func (a *App) ListScheduledMessages(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionRead); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+	if _, err := a.findScheduleContact(r, contactID, orgID, userID); err != nil {
+		return nil
+	}
+
+	pg := parsePaginationWithDefaults(r, 50, 100)
+
+	query := a.DB.Model(&models.ScheduledMessage{}).Where("organization_id = ? AND contact_id = ?", orgID, contactID)
+	if status := string(r.RequestCtx.QueryArgs().Peek("status")); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var scheduled []models.ScheduledMessage
+	if err := pg.Apply(query.Preload("CreatedBy").Order("send_at ASC")).Find(&scheduled).Error; err != nil {
+		a.Log.Error("Failed to list scheduled messages", "error", err, "contact_id", contactID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list scheduled messages", nil, "")
+	}
+
+	result := make([]ScheduledMessageResponse, len(scheduled))
+	for i, sm := range scheduled {
+		result[i] = scheduledMessageToResponse(sm)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"scheduled_messages": result,
+		"total":              total,
+		"page":               pg.Page,
+		"limit":              pg.Limit,
+	})
+}
+
+// CreateScheduledMessage queues a message to be sent to a contact at send_at
+func (a *App) CreateScheduledMessage(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+
+	var req ScheduledMessageRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.SendAt.IsZero() {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "send_at is required", nil, "")
+	}
+	if !req.SendAt.After(time.Now()) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "send_at must be in the future", nil, "")
+	}
+	if req.Type == "" {
+		req.Type = models.MessageTypeText
+	}
+
+	contact, err := a.findScheduleContact(r, contactID, orgID, userID)
+	if err != nil {
+		return nil
+	}
+	if contact.IsBlocked {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Contact is blocked", nil, "")
+	}
+
+	if req.WhatsAppAccount != "" {
+		if _, err := a.resolveWhatsAppAccount(orgID, req.WhatsAppAccount); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	}
+
+	scheduled := models.ScheduledMessage{
+		OrganizationID:  orgID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: req.WhatsAppAccount,
+		CreatedByID:     userID,
+		SendAt:          req.SendAt,
+		Status:          models.ScheduledMessageStatusPending,
+		MessageType:     req.Type,
+	}
+
+	switch req.Type {
+	case models.MessageTypeText:
+		req.Content = strings.TrimSpace(req.Content)
+		if req.Content == "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "content is required", nil, "")
+		}
+		scheduled.Content = req.Content
+	case models.MessageTypeTemplate:
+		template, errMsg := a.loadScheduleTemplate(orgID, req.TemplateID, req.TemplateParams, "template_id")
+		if errMsg != "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, errMsg, nil, "")
+		}
+		scheduled.TemplateID = &template.ID
+		scheduled.TemplateParams = stringMapToJSONB(req.TemplateParams)
+	default:
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "type must be text or template", nil, "")
+	}
+
+	if req.FallbackTemplateID != "" {
+		if req.Type != models.MessageTypeText {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "fallback_template_id only applies to text messages", nil, "")
+		}
+		template, errMsg := a.loadScheduleTemplate(orgID, req.FallbackTemplateID, req.FallbackTemplateParams, "fallback_template_id")
+		if errMsg != "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, errMsg, nil, "")
+		}
+		scheduled.FallbackTemplateID = &template.ID
+		scheduled.FallbackTemplateParams = stringMapToJSONB(req.FallbackTemplateParams)
+	}
+
+	if err := a.DB.Create(&scheduled).Error; err != nil {
+		a.Log.Error("Failed to create scheduled message", "error", err, "contact_id", contact.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to schedule message", nil, "")
+	}
+
+	var user models.User
+	a.DB.First(&user, "id = ?", userID)
+	scheduled.CreatedBy = &user
+
+	resp := scheduledMessageToResponse(scheduled)
+	a.broadcastScheduledMessage(orgID, resp)
+
+	a.Log.Info("Message scheduled", "scheduled_message_id", scheduled.ID, "contact_id", contact.ID, "send_at", scheduled.SendAt)
+	return r.SendEnvelope(resp)
+}
+
+// CancelScheduledMessage cancels a scheduled message that has not been sent yet
+func (a *App) CancelScheduledMessage(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+	scheduledID, err := parsePathUUID(r, "scheduled_id", "scheduled message")
+	if err != nil {
+		return nil
+	}
+	if _, err := a.findScheduleContact(r, contactID, orgID, userID); err != nil {
+		return nil
+	}
+
+	scheduled, err := findByIDAndOrg[models.ScheduledMessage](a.DB, r, scheduledID, orgID, "Scheduled message")
+	if err != nil {
+		return nil
+	}
+	if scheduled.ContactID != contactID {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Scheduled message not found", nil, "")
+	}
+
+	// Conditional so a message the dispatcher has already claimed is not reported as cancelled
+	now := time.Now()
+	result := a.DB.Model(&models.ScheduledMessage{}).
+		Where("id = ? AND status = ?", scheduled.ID, models.ScheduledMessageStatusPending).
+		Updates(map[string]any{
+			"status":          models.ScheduledMessageStatusCancelled,
+			"cancelled_at":    now,
+			"cancelled_by_id": userID,
+		})
+	if result.Error != nil {
+		a.Log.Error("Failed to cancel scheduled message", "error", result.Error, "scheduled_message_id", scheduled.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to cancel scheduled message", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		a.DB.Select("status").Where("id = ?", scheduled.ID).First(scheduled)
+		return r.SendErrorEnvelope(fasthttp.StatusConflict,
+			fmt.Sprintf("Scheduled message can no longer be cancelled (status: %s)", scheduled.Status), nil, "")
+	}
+
+	scheduled.Status = models.ScheduledMessageStatusCancelled
+	scheduled.CancelledAt = &now
+	scheduled.CancelledByID = &userID
+	a.DB.Preload("CreatedBy").Where("id = ?", scheduled.ID).First(scheduled)
+
+	resp := scheduledMessageToResponse(*scheduled)
+	a.broadcastScheduledMessage(orgID, resp)
+
+	return r.SendEnvelope(resp)
+}
+
+// findScheduleContact loads a contact the user may message. Users without
+// full contacts read permission only see their assigned contacts.
+func (a *App) findScheduleContact(r *fastglue.Request, contactID, orgID, userID uuid.UUID) (*models.Contact, error) {
+	var contact models.Contact
+	query := a.DB.Where("id = ? AND organization_id = ?", contactID, orgID)
+	if !a.HasPermission(userID, models.ResourceContacts, models.ActionRead, orgID) {
+		query = query.Where("assigned_user_id = ?", userID)
+	}
+	if err := query.First(&contact).Error; err != nil {
+		_ = r.SendErrorEnvelope(fasthttp.StatusNotFound, "Contact not found", nil, "")
+		return nil, errEnvelopeSent
+	}
+	return &contact, nil
+}
+
+// loadScheduleTemplate checks that a template can be sent with the given
+// parameters. Returns an error message, or "" if it can.
+func (a *App) loadScheduleTemplate(orgID uuid.UUID, idStr string, params map[string]string, field string) (*models.Template, string) {
+	if idStr == "" {
+		return nil, field + " is required"
+	}
+	templateID, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, "Invalid " + field
+	}
+	var template models.Template
+	if err := a.DB.Where("id = ? AND organization_id = ?", templateID, orgID).First(&template).Error; err != nil {
+		return nil, "Template not found"
+	}
+	if errMsg := checkScheduleTemplate(&template, params); errMsg != "" {
+		return nil, errMsg
+	}
+	return &template, ""
+}
+
+// checkScheduleTemplate returns why a template cannot be sent with the given
+// parameters, or "" if it can
+func checkScheduleTemplate(template *models.Template, params map[string]string) string {
+	if template.Status != string(models.TemplateStatusApproved) {
+		return fmt.Sprintf("Template is not approved (status: %s)", template.Status)
+	}
+	paramNames := templateutil.ExtParamNames(template.BodyContent)
+	resolved := templateutil.ResolveParamsFromMap(paramNames, params)
+	var missing []string
+	for i, name := range paramNames {
+		if i >= len(resolved) || resolved[i] == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "Missing template parameters: " + strings.Join(missing, ", ")
+	}
+	return ""
+}
+
+// broadcastScheduledMessage tells agents viewing the contact that one of its
+// scheduled messages was created or changed status
+func (a *App) broadcastScheduledMessage(orgID uuid.UUID, resp ScheduledMessageResponse) {
+	if a.WSHub == nil {
+		return
+	}
+	a.WSHub.BroadcastToContact(orgID, resp.ContactID, websocket.WSMessage{
+		Type:    websocket.TypeScheduledMessageUpdated,
+		Payload: resp,
+	})
+}
+
+func scheduledMessageToResponse(sm models.ScheduledMessage) ScheduledMessageResponse {
+	createdByName := ""
+	if sm.CreatedBy != nil {
+		createdByName = sm.CreatedBy.FullName
+	}
+	return ScheduledMessageResponse{
+		ID:                     sm.ID,
+		ContactID:              sm.ContactID,
+		WhatsAppAccount:        sm.WhatsAppAccount,
+		CreatedByID:            sm.CreatedByID,
+		CreatedByName:          createdByName,
+		SendAt:                 sm.SendAt,
+		Status:                 sm.Status,
+		Type:                   sm.MessageType,
+		Content:                sm.Content,
+		TemplateID:             sm.TemplateID,
+		TemplateParams:         sm.TemplateParams,
+		FallbackTemplateID:     sm.FallbackTemplateID,
+		FallbackTemplateParams: sm.FallbackTemplateParams,
+		MessageID:              sm.MessageID,
+		UsedFallback:           sm.UsedFallback,
+		SentAt:                 sm.SentAt,
+		ErrorMessage:           sm.ErrorMessage,
+		CancelledAt:            sm.CancelledAt,
+		CreatedAt:              sm.CreatedAt,
+	}
+}
@@ -0,0 +1,213 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+func createTestScheduledMessage(t *testing.T, db *gorm.DB, orgID, contactID, userID uuid.UUID, sendAt time.Time, opts func(*models.ScheduledMessage)) *models.ScheduledMessage {
+	t.Helper()
+	sm := &models.ScheduledMessage{
+		OrganizationID: orgID,
+		ContactID:      contactID,
+		CreatedByID:    userID,
+		SendAt:         sendAt,
+		Status:         models.ScheduledMessageStatusPending,
+		MessageType:    models.MessageTypeText,
+		Content:        "Following up on your order",
+	}
+	if opts != nil {
+		opts(sm)
+	}
+	require.NoError(t, db.Create(sm).Error)
+	return sm
+}
+
+func TestApp_ScheduledMessages_CreateListCancel(t *testing.T) {
+	t.Parallel()
+	app, _, account, orgID, userID := phoneTestSetup(t)
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID, testutil.WithContactAccount(account.Name))
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+
+	sendAt := time.Now().Add(12 * time.Hour).UTC().Truncate(time.Second)
+	req := phoneRequest(t, handlers.ScheduledMessageRequest{
+		SendAt:                 sendAt,
+		Content:                "Good morning! Your order is ready",
+		FallbackTemplateID:     template.ID.String(),
+		FallbackTemplateParams: map[string]string{"1": "Alice"},
+	}, contact.ID, orgID, userID)
+	require.NoError(t, app.CreateScheduledMessage(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var created struct {
+		Data handlers.ScheduledMessageResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &created))
+	assert.Equal(t, models.ScheduledMessageStatusPending, created.Data.Status)
+	assert.Equal(t, models.MessageTypeText, created.Data.Type)
+	assert.True(t, sendAt.Equal(created.Data.SendAt))
+	require.NotNil(t, created.Data.FallbackTemplateID)
+	assert.Equal(t, template.ID, *created.Data.FallbackTemplateID)
+
+	req = phoneRequest(t, nil, contact.ID, orgID, userID)
+	require.NoError(t, app.ListScheduledMessages(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var list struct {
+		Data struct {
+			ScheduledMessages []handlers.ScheduledMessageResponse `json:"scheduled_messages"`
+			Total             int64                               `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &list))
+	require.Len(t, list.Data.ScheduledMessages, 1)
+	assert.Equal(t, created.Data.ID, list.Data.ScheduledMessages[0].ID)
+
+	req = phoneRequest(t, nil, contact.ID, orgID, userID)
+	testutil.SetPathParam(req, "scheduled_id", created.Data.ID.String())
+	require.NoError(t, app.CancelScheduledMessage(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var updated models.ScheduledMessage
+	require.NoError(t, app.DB.First(&updated, created.Data.ID).Error)
+	assert.Equal(t, models.ScheduledMessageStatusCancelled, updated.Status)
+	require.NotNil(t, updated.CancelledByID)
+	assert.Equal(t, userID, *updated.CancelledByID)
+
+	// Cancelling twice is a conflict
+	req = phoneRequest(t, nil, contact.ID, orgID, userID)
+	testutil.SetPathParam(req, "scheduled_id", created.Data.ID.String())
+	require.NoError(t, app.CancelScheduledMessage(req))
+	assert.Equal(t, fasthttp.StatusConflict, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_CreateScheduledMessage_Validation(t *testing.T) {
+	t.Parallel()
+	app, _, account, orgID, userID := phoneTestSetup(t)
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID, testutil.WithContactAccount(account.Name))
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+	pending := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+	require.NoError(t, app.DB.Model(pending).Update("status", models.TemplateStatusPending).Error)
+
+	later := time.Now().Add(time.Hour)
+	tests := []struct {
+		name string
+		body handlers.ScheduledMessageRequest
+		want string
+	}{
+		{"missing send_at", handlers.ScheduledMessageRequest{Content: "Hi"}, "send_at is required"},
+		{"past send_at", handlers.ScheduledMessageRequest{SendAt: time.Now().Add(-time.Minute), Content: "Hi"}, "send_at must be in the future"},
+		{"empty content", handlers.ScheduledMessageRequest{SendAt: later, Content: "  "}, "content is required"},
+		{"unsupported type", handlers.ScheduledMessageRequest{SendAt: later, Type: models.MessageTypeImage}, "type must be text or template"},
+		{"missing template params", handlers.ScheduledMessageRequest{SendAt: later, Type: models.MessageTypeTemplate, TemplateID: template.ID.String()}, "Missing template parameters"},
+		{"unapproved fallback", handlers.ScheduledMessageRequest{SendAt: later, Content: "Hi", FallbackTemplateID: pending.ID.String()}, "not approved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := phoneRequest(t, tt.body, contact.ID, orgID, userID)
+			require.NoError(t, app.CreateScheduledMessage(req))
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+			assert.Contains(t, string(testutil.GetResponseBody(req)), tt.want)
+		})
+	}
+}
+
+func TestScheduledMessageProcessor_SendsDueMessage(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID,
+		testutil.WithPhoneNumber("15551230001"), testutil.WithContactAccount(account.Name))
+	require.NoError(t, app.DB.Model(contact).Update("last_inbound_at", time.Now().Add(-2*time.Hour)).Error)
+
+	due := createTestScheduledMessage(t, app.DB, orgID, contact.ID, userID, time.Now().Add(-time.Minute), nil)
+	future := createTestScheduledMessage(t, app.DB, orgID, contact.ID, userID, time.Now().Add(time.Hour), nil)
+
+	processor := handlers.NewScheduledMessageProcessor(app, time.Minute)
+	assert.Equal(t, 1, processor.DispatchDueMessages(context.Background()))
+
+	sent := srv.Messages()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "text", sent[0].Type)
+	assert.Equal(t, "15551230001", sent[0].To)
+
+	var updated models.ScheduledMessage
+	require.NoError(t, app.DB.First(&updated, due.ID).Error)
+	assert.Equal(t, models.ScheduledMessageStatusSent, updated.Status)
+	assert.False(t, updated.UsedFallback)
+	assert.NotNil(t, updated.SentAt)
+	require.NotNil(t, updated.MessageID)
+
+	var msg models.Message
+	require.NoError(t, app.DB.First(&msg, *updated.MessageID).Error)
+	assert.Equal(t, "Following up on your order", msg.Content)
+	require.NotNil(t, msg.SentByUserID)
+	assert.Equal(t, userID, *msg.SentByUserID)
+
+	require.NoError(t, app.DB.First(&updated, future.ID).Error)
+	assert.Equal(t, models.ScheduledMessageStatusPending, updated.Status)
+
+	// A second pass (e.g. another replica) must not send it again
+	assert.Equal(t, 0, processor.DispatchDueMessages(context.Background()))
+	assert.Len(t, srv.Messages(), 1)
+}
+
+func TestScheduledMessageProcessor_WindowClosedFallsBackToTemplate(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID,
+		testutil.WithPhoneNumber("15551230002"), testutil.WithContactAccount(account.Name))
+	require.NoError(t, app.DB.Model(contact).Update("last_inbound_at", time.Now().Add(-30*time.Hour)).Error)
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+
+	sm := createTestScheduledMessage(t, app.DB, orgID, contact.ID, userID, time.Now().Add(-time.Minute), func(sm *models.ScheduledMessage) {
+		sm.FallbackTemplateID = &template.ID
+		sm.FallbackTemplateParams = models.JSONB{"1": "Alice"}
+	})
+
+	processor := handlers.NewScheduledMessageProcessor(app, time.Minute)
+	assert.Equal(t, 1, processor.DispatchDueMessages(context.Background()))
+
+	sent := srv.Messages()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "template", sent[0].Type)
+
+	var updated models.ScheduledMessage
+	require.NoError(t, app.DB.First(&updated, sm.ID).Error)
+	assert.Equal(t, models.ScheduledMessageStatusSent, updated.Status)
+	assert.True(t, updated.UsedFallback)
+}
+
+func TestScheduledMessageProcessor_WindowClosedWithoutFallback(t *testing.T) {
+	t.Parallel()
+	app, srv, account, orgID, userID := phoneTestSetup(t)
+	contact := testutil.CreateTestContactWith(t, app.DB, orgID, testutil.WithContactAccount(account.Name))
+
+	sm := createTestScheduledMessage(t, app.DB, orgID, contact.ID, userID, time.Now().Add(-time.Minute), nil)
+	cancelled := createTestScheduledMessage(t, app.DB, orgID, contact.ID, userID, time.Now().Add(-time.Minute), func(sm *models.ScheduledMessage) {
+		sm.Status = models.ScheduledMessageStatusCancelled
+	})
+
+	processor := handlers.NewScheduledMessageProcessor(app, time.Minute)
+	assert.Equal(t, 1, processor.DispatchDueMessages(context.Background()))
+	assert.Empty(t, srv.Messages())
+
+	var updated models.ScheduledMessage
+	require.NoError(t, app.DB.First(&updated, sm.ID).Error)
+	assert.Equal(t, models.ScheduledMessageStatusWindowClosed, updated.Status)
+	assert.Contains(t, updated.ErrorMessage, "service window closed")
+	assert.Nil(t, updated.MessageID)
+
+	require.NoError(t, app.DB.First(&updated, cancelled.ID).Error)
+	assert.Equal(t, models.ScheduledMessageStatusCancelled, updated.Status)
+}
@@ -0,0 +1,512 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// segmentPreviewLimit is how many matching contacts a preview returns
+const segmentPreviewLimit = 10
+
+// SegmentRequest represents the request body for creating/updating a contact segment
+type SegmentRequest struct {
+	Name              string         `json:"name"`
+	Description       string         `json:"description"`
+	Tags              []string       `json:"tags"`
+	Metadata          map[string]any `json:"metadata"`
+	InboundWithinDays int            `json:"inbound_within_days"`
+	NoInboundForDays  int            `json:"no_inbound_for_days"`
+	AssignedUserID    *uuid.UUID     `json:"assigned_user_id"`
+	WhatsAppAccount   string         `json:"whatsapp_account"`
+}
+
+// SegmentResponse represents a contact segment in API responses
+type SegmentResponse struct {
+	ID                uuid.UUID      `json:"id"`
+	Name              string         `json:"name"`
+	Description       string         `json:"description"`
+	Tags              []string       `json:"tags"`
+	Metadata          map[string]any `json:"metadata"`
+	InboundWithinDays int            `json:"inbound_within_days"`
+	NoInboundForDays  int            `json:"no_inbound_for_days"`
+	AssignedUserID    *uuid.UUID     `json:"assigned_user_id,omitempty"`
+	WhatsAppAccount   string         `json:"whatsapp_account,omitempty"`
+	ContactCount      *int64         `json:"contact_count,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+// SegmentPreviewContact is a matching contact in a segment preview
+type SegmentPreviewContact struct {
+	ID          uuid.UUID `json:"id"`
+	PhoneNumber string    `json:"phone_number"`
+	ProfileName string    `json:"profile_name"`
+}
+
+// ListSegments returns the organization's contact segments
+func (a *App) ListSegments(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionRead); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	search := string(r.RequestCtx.QueryArgs().Peek("search"))
+
+	query := a.DB.Where("organization_id = ?", orgID)
+	if search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	query.Model(&models.ContactSegment{}).Count(&total)
+
+	var segments []models.ContactSegment
+	if err := pg.Apply(query.Order("name ASC")).Find(&segments).Error; err != nil {
+		a.Log.Error("Failed to list segments", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list segments", nil, "")
+	}
+
+	result := make([]SegmentResponse, len(segments))
+	for i, s := range segments {
+		result[i] = segmentToResponse(s)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"segments": result,
+		"total":    total,
+		"page":     pg.Page,
+		"limit":    pg.Limit,
+	})
+}
+
+// CreateSegment creates a contact segment
+func (a *App) CreateSegment(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req SegmentRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	segment := models.ContactSegment{
+		OrganizationID: orgID,
+		CreatedByID:    userID,
+	}
+	if err := a.applySegmentRequest(orgID, &segment, &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	if err := a.DB.Create(&segment).Error; err != nil {
+		a.Log.Error("Failed to create segment", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create segment", nil, "")
+	}
+
+	return r.SendEnvelope(segmentToResponse(segment))
+}
+
+// GetSegment returns a contact segment with the number of contacts it currently matches
+func (a *App) GetSegment(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionRead); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "segment")
+	if err != nil {
+		return nil
+	}
+
+	segment, err := findByIDAndOrg[models.ContactSegment](a.DB, r, id, orgID, "Segment")
+	if err != nil {
+		return nil
+	}
+
+	var count int64
+	if err := segmentContactsQuery(a.DB, segment).Model(&models.Contact{}).Count(&count).Error; err != nil {
+		a.Log.Error("Failed to count segment contacts", "error", err, "segment_id", id)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to count segment contacts", nil, "")
+	}
+
+	resp := segmentToResponse(*segment)
+	resp.ContactCount = &count
+	return r.SendEnvelope(resp)
+}
+
+// UpdateSegment updates a contact segment. Campaigns that already started
+// keep the recipients resolved from the old rules.
+func (a *App) UpdateSegment(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "segment")
+	if err != nil {
+		return nil
+	}
+
+	segment, err := findByIDAndOrg[models.ContactSegment](a.DB, r, id, orgID, "Segment")
+	if err != nil {
+		return nil
+	}
+
+	var req SegmentRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if err := a.applySegmentRequest(orgID, segment, &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	// Save writes zero values too, so cleared rules are cleared
+	if err := a.DB.Save(segment).Error; err != nil {
+		a.Log.Error("Failed to update segment", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update segment", nil, "")
+	}
+
+	return r.SendEnvelope(segmentToResponse(*segment))
+}
+
+// DeleteSegment deletes a contact segment that no unstarted campaign targets
+func (a *App) DeleteSegment(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionDelete); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "segment")
+	if err != nil {
+		return nil
+	}
+
+	segment, err := findByIDAndOrg[models.ContactSegment](a.DB, r, id, orgID, "Segment")
+	if err != nil {
+		return nil
+	}
+
+	var inUse int64
+	a.DB.Model(&models.BulkMessageCampaign{}).
+		Where("segment_id = ? AND status IN ?", id, []models.CampaignStatus{models.CampaignStatusDraft, models.CampaignStatusScheduled}).
+		Count(&inUse)
+	if inUse > 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Segment is the audience of campaigns that have not started", nil, "")
+	}
+	a.DB.Model(&models.RecurringCampaign{}).Where("segment_id = ?", id).Count(&inUse)
+	if inUse > 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Segment is the audience of recurring campaigns", nil, "")
+	}
+
+	if err := a.DB.Delete(segment).Error; err != nil {
+		a.Log.Error("Failed to delete segment", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete segment", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{"message": "Segment deleted"})
+}
+
+// PreviewSegment returns how many contacts a set of rules matches and a
+// sample of them, without saving a segment
+func (a *App) PreviewSegment(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionRead); err != nil {
+		return nil
+	}
+
+	var req SegmentRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if req.Name == "" {
+		req.Name = "preview"
+	}
+
+	segment := models.ContactSegment{OrganizationID: orgID}
+	if err := a.applySegmentRequest(orgID, &segment, &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	var count int64
+	if err := segmentContactsQuery(a.DB, &segment).Model(&models.Contact{}).Count(&count).Error; err != nil {
+		a.Log.Error("Failed to preview segment", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to preview segment", nil, "")
+	}
+
+	var contacts []models.Contact
+	segmentContactsQuery(a.DB, &segment).
+		Select("id", "phone_number", "profile_name").
+		Order("last_message_at DESC NULLS LAST").
+		Limit(segmentPreviewLimit).
+		Find(&contacts)
+
+	sample := make([]SegmentPreviewContact, len(contacts))
+	for i, c := range contacts {
+		sample[i] = SegmentPreviewContact{ID: c.ID, PhoneNumber: c.PhoneNumber, ProfileName: c.ProfileName}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"contact_count": count,
+		"contacts":      sample,
+	})
+}
+
+// applySegmentRequest validates a segment request and copies it onto segment
+func (a *App) applySegmentRequest(orgID uuid.UUID, segment *models.ContactSegment, req *SegmentRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if req.InboundWithinDays < 0 || req.NoInboundForDays < 0 {
+		return fmt.Errorf("day rules must not be negative")
+	}
+	if req.InboundWithinDays > 0 && req.NoInboundForDays >= req.InboundWithinDays {
+		return fmt.Errorf("no_inbound_for_days must be less than inbound_within_days")
+	}
+	if req.AssignedUserID != nil {
+		var user models.User
+		if err := a.DB.Where("id = ? AND organization_id = ?", req.AssignedUserID, orgID).First(&user).Error; err != nil {
+			return fmt.Errorf("Assigned user not found")
+		}
+	}
+	if req.WhatsAppAccount != "" {
+		if _, err := a.resolveWhatsAppAccount(orgID, req.WhatsAppAccount); err != nil {
+			return fmt.Errorf("WhatsApp account not found")
+		}
+	}
+
+	var tags models.StringArray
+	for _, tag := range req.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	segment.Name = req.Name
+	segment.Description = req.Description
+	segment.Tags = tags
+	segment.Metadata = models.JSONB(req.Metadata)
+	segment.InboundWithinDays = req.InboundWithinDays
+	segment.NoInboundForDays = req.NoInboundForDays
+	segment.AssignedUserID = req.AssignedUserID
+	segment.WhatsAppAccount = req.WhatsAppAccount
+	return nil
+}
+
+// segmentContactsQuery returns a query over the contacts a segment matches.
+// Blocked contacts are never part of a segment.
+func segmentContactsQuery(db *gorm.DB, segment *models.ContactSegment) *gorm.DB {
+	query := db.Where("organization_id = ? AND is_blocked = ?", segment.OrganizationID, false)
+
+	if len(segment.Tags) > 0 {
+		// Any of the tags, using the GIN index on tags
+		conditions := make([]string, len(segment.Tags))
+		args := make([]any, len(segment.Tags))
+		for i, tag := range segment.Tags {
+			tagJSON, _ := json.Marshal([]string{tag})
+			conditions[i] = "tags @> ?::jsonb"
+			args[i] = string(tagJSON)
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	if len(segment.Metadata) > 0 {
+		metadataJSON, _ := json.Marshal(segment.Metadata)
+		query = query.Where("metadata @> ?::jsonb", string(metadataJSON))
+	}
+	now := time.Now()
+	if segment.InboundWithinDays > 0 {
+		query = query.Where("last_inbound_at >= ?", now.AddDate(0, 0, -segment.InboundWithinDays))
+	}
+	if segment.NoInboundForDays > 0 {
+		query = query.Where("last_inbound_at IS NULL OR last_inbound_at < ?", now.AddDate(0, 0, -segment.NoInboundForDays))
+	}
+	if segment.AssignedUserID != nil {
+		query = query.Where("assigned_user_id = ?", *segment.AssignedUserID)
+	}
+	if segment.WhatsAppAccount != "" {
+		query = query.Where("whats_app_account = ?", segment.WhatsAppAccount)
+	}
+	return query
+}
+
+// validateCampaignSegment checks that a campaign's audience segment exists
+// and its parameter mapping fits the campaign's template
+func (a *App) validateCampaignSegment(orgID, segmentID uuid.UUID, template *models.Template, params map[string]string) error {
+	var count int64
+	a.DB.Model(&models.ContactSegment{}).Where("id = ? AND organization_id = ?", segmentID, orgID).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("Segment not found")
+	}
+	return validateSegmentParams(template, params)
+}
+
+// validateSegmentParams checks that a campaign's segment parameter mapping
+// covers every parameter of the template body and only uses known contact fields
+func validateSegmentParams(template *models.Template, params map[string]string) error {
+	for name, source := range params {
+		if !validSegmentParamSource(source) {
+			return fmt.Errorf("Invalid contact field %q for parameter %s", source, name)
+		}
+	}
+	for _, name := range templateutil.ExtParamNames(template.BodyContent) {
+		if params[name] == "" {
+			return fmt.Errorf("Template parameter {{%s}} has no contact field", name)
+		}
+	}
+	return nil
+}
+
+func validSegmentParamSource(source string) bool {
+	switch source {
+	case "profile_name", "phone_number":
+		return true
+	}
+	key, ok := strings.CutPrefix(source, "metadata.")
+	return ok && key != ""
+}
+
+// segmentParamValue returns the value of a contact field named by a segment
+// parameter mapping, or "" when the contact doesn't have it
+func segmentParamValue(contact *models.Contact, source string) string {
+	switch source {
+	case "profile_name":
+		return contact.ProfileName
+	case "phone_number":
+		return contact.PhoneNumber
+	}
+	key, _ := strings.CutPrefix(source, "metadata.")
+	return payloadString(contact.Metadata, key)
+}
+
+// resolveCampaignSegment adds the campaign's segment contacts as pending
+// recipients, skipping phone numbers the campaign already has and contacts
+// missing a field a template parameter is mapped to. It returns how many
+// recipients were added and skipped.
+func (a *App) resolveCampaignSegment(campaign *models.BulkMessageCampaign) (added, skipped int, err error) {
+	var segment models.ContactSegment
+	if err := a.DB.Where("id = ? AND organization_id = ?", campaign.SegmentID, campaign.OrganizationID).First(&segment).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to load segment: %w", err)
+	}
+
+	var existing []string
+	if err := a.DB.Model(&models.BulkMessageRecipient{}).
+		Where("campaign_id = ?", campaign.ID).
+		Pluck("phone_number", &existing).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to load recipients: %w", err)
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, phone := range existing {
+		seen[phone] = true
+	}
+
+	mapping := jsonbToStringMap(campaign.SegmentParams)
+
+	var recipients []models.BulkMessageRecipient
+	var contacts []models.Contact
+	result := segmentContactsQuery(a.DB, &segment).
+		Select("id", "phone_number", "profile_name", "metadata").
+		FindInBatches(&contacts, 1000, func(tx *gorm.DB, batch int) error {
+		contactLoop:
+			for i := range contacts {
+				c := &contacts[i]
+				if seen[c.PhoneNumber] {
+					continue
+				}
+				seen[c.PhoneNumber] = true
+
+				params := models.JSONB{}
+				for name, source := range mapping {
+					value := segmentParamValue(c, source)
+					if value == "" {
+						skipped++
+						continue contactLoop
+					}
+					params[name] = value
+				}
+				recipients = append(recipients, models.BulkMessageRecipient{
+					CampaignID:     campaign.ID,
+					PhoneNumber:    c.PhoneNumber,
+					RecipientName:  c.ProfileName,
+					TemplateParams: params,
+					Status:         models.MessageStatusPending,
+				})
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return 0, 0, fmt.Errorf("failed to load segment contacts: %w", result.Error)
+	}
+
+	if len(recipients) > 0 {
+		if err := a.DB.CreateInBatches(&recipients, 500).Error; err != nil {
+			return 0, 0, fmt.Errorf("failed to add recipients: %w", err)
+		}
+	}
+
+	var total int64
+	a.DB.Model(&models.BulkMessageRecipient{}).Where("campaign_id = ?", campaign.ID).Count(&total)
+	a.DB.Model(campaign).Update("total_recipients", total)
+
+	a.Log.Info("Campaign segment resolved", "campaign_id", campaign.ID, "segment_id", segment.ID, "added", len(recipients), "skipped", skipped)
+	return len(recipients), skipped, nil
+}
+
+func segmentToResponse(s models.ContactSegment) SegmentResponse {
+	tags := []string(s.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	metadata := map[string]any(s.Metadata)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	return SegmentResponse{
+		ID:                s.ID,
+		Name:              s.Name,
+		Description:       s.Description,
+		Tags:              tags,
+		Metadata:          metadata,
+		InboundWithinDays: s.InboundWithinDays,
+		NoInboundForDays:  s.NoInboundForDays,
+		AssignedUserID:    s.AssignedUserID,
+		WhatsAppAccount:   s.WhatsAppAccount,
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
+	}
+}
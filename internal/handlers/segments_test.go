@@ -0,0 +1,235 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// createSegmentContact creates a contact with the given tags and metadata
+func createSegmentContact(t *testing.T, db *gorm.DB, orgID uuid.UUID, phone, name string, tags []string, metadata models.JSONB) *models.Contact {
+	t.Helper()
+	contact := testutil.CreateTestContactWith(t, db, orgID, testutil.WithPhoneNumber(phone))
+	tagArray := make(models.JSONBArray, len(tags))
+	for i, tag := range tags {
+		tagArray[i] = tag
+	}
+	require.NoError(t, db.Model(contact).Updates(map[string]any{
+		"profile_name": name,
+		"tags":         tagArray,
+		"metadata":     metadata,
+	}).Error)
+	return contact
+}
+
+func segmentTestSetup(t *testing.T) (*handlers.App, uuid.UUID, uuid.UUID) {
+	t.Helper()
+	app := newTestApp(t, withQueue(testutil.NewMockQueue()))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	return app, org.ID, user.ID
+}
+
+func TestApp_Segments_CreatePreviewDelete(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+
+	vip := createSegmentContact(t, app.DB, orgID, "15550000001", "Alice", []string{"vip"}, models.JSONB{"city": "Pune"})
+	createSegmentContact(t, app.DB, orgID, "15550000002", "Bob", []string{"vip"}, models.JSONB{"city": "Delhi"})
+	createSegmentContact(t, app.DB, orgID, "15550000003", "Carol", []string{"lead"}, models.JSONB{"city": "Pune"})
+	blocked := createSegmentContact(t, app.DB, orgID, "15550000004", "Dan", []string{"vip"}, models.JSONB{"city": "Pune"})
+	require.NoError(t, app.DB.Model(blocked).Update("is_blocked", true).Error)
+
+	rules := handlers.SegmentRequest{
+		Name:     "Pune VIPs",
+		Tags:     []string{"vip"},
+		Metadata: map[string]any{"city": "Pune"},
+	}
+
+	req := testutil.NewJSONRequest(t, rules)
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.PreviewSegment(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var preview struct {
+		Data struct {
+			ContactCount int64                            `json:"contact_count"`
+			Contacts     []handlers.SegmentPreviewContact `json:"contacts"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &preview))
+	assert.Equal(t, int64(1), preview.Data.ContactCount)
+	require.Len(t, preview.Data.Contacts, 1)
+	assert.Equal(t, vip.ID, preview.Data.Contacts[0].ID)
+
+	req = testutil.NewJSONRequest(t, rules)
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.CreateSegment(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var created struct {
+		Data handlers.SegmentResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &created))
+	assert.Equal(t, "Pune VIPs", created.Data.Name)
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", created.Data.ID.String())
+	require.NoError(t, app.GetSegment(req))
+	var got struct {
+		Data handlers.SegmentResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &got))
+	require.NotNil(t, got.Data.ContactCount)
+	assert.Equal(t, int64(1), *got.Data.ContactCount)
+
+	// A draft campaign targeting the segment keeps it from being deleted
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, orgID)
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+	campaign := createTestCampaign(t, app, orgID, template.ID, userID, account.Name, models.CampaignStatusDraft)
+	require.NoError(t, app.DB.Model(campaign).Update("segment_id", created.Data.ID).Error)
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", created.Data.ID.String())
+	require.NoError(t, app.DeleteSegment(req))
+	assert.Equal(t, fasthttp.StatusConflict, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_PreviewSegment_InboundRules(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+
+	recent := createSegmentContact(t, app.DB, orgID, "15550000011", "Recent", nil, nil)
+	require.NoError(t, app.DB.Model(recent).Update("last_inbound_at", time.Now().Add(-2*24*time.Hour)).Error)
+	lapsed := createSegmentContact(t, app.DB, orgID, "15550000012", "Lapsed", nil, nil)
+	require.NoError(t, app.DB.Model(lapsed).Update("last_inbound_at", time.Now().Add(-20*24*time.Hour)).Error)
+	createSegmentContact(t, app.DB, orgID, "15550000013", "Never", nil, nil)
+
+	preview := func(rules handlers.SegmentRequest) []string {
+		req := testutil.NewJSONRequest(t, rules)
+		testutil.SetAuthContext(req, orgID, userID)
+		require.NoError(t, app.PreviewSegment(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		var resp struct {
+			Data struct {
+				Contacts []handlers.SegmentPreviewContact `json:"contacts"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		var names []string
+		for _, c := range resp.Data.Contacts {
+			names = append(names, c.ProfileName)
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"Recent"}, preview(handlers.SegmentRequest{InboundWithinDays: 7}))
+	assert.ElementsMatch(t, []string{"Lapsed", "Never"}, preview(handlers.SegmentRequest{NoInboundForDays: 7}))
+	assert.ElementsMatch(t, []string{"Lapsed"}, preview(handlers.SegmentRequest{InboundWithinDays: 30, NoInboundForDays: 7}))
+}
+
+func TestApp_StartCampaign_ResolvesSegment(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, orgID)
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+
+	createSegmentContact(t, app.DB, orgID, "15550000021", "Alice", []string{"vip"}, models.JSONB{"code": float64(20240101)})
+	createSegmentContact(t, app.DB, orgID, "15550000022", "Bob", []string{"vip"}, models.JSONB{"code": "B-2"})
+	createSegmentContact(t, app.DB, orgID, "15550000023", "Carol", []string{"vip"}, nil) // No code: skipped
+	createSegmentContact(t, app.DB, orgID, "15550000024", "Dan", []string{"lead"}, models.JSONB{"code": "D-4"})
+
+	segment := &models.ContactSegment{
+		OrganizationID: orgID,
+		Name:           "VIPs",
+		CreatedByID:    userID,
+		Tags:           models.StringArray{"vip"},
+	}
+	require.NoError(t, app.DB.Create(segment).Error)
+
+	// A recipient imported by CSV is not added twice
+	campaign := createTestCampaign(t, app, orgID, template.ID, userID, account.Name, models.CampaignStatusDraft)
+	createTestRecipient(t, app, campaign.ID, "15550000022", models.MessageStatusPending)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"name":           campaign.Name,
+		"segment_id":     segment.ID,
+		"segment_params": map[string]string{"1": "metadata.code"},
+	})
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+	require.NoError(t, app.UpdateCampaign(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	req = testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+	require.NoError(t, app.StartCampaign(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			SegmentAdded   int `json:"segment_added"`
+			SegmentSkipped int `json:"segment_skipped"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, 1, resp.Data.SegmentAdded)
+	assert.Equal(t, 1, resp.Data.SegmentSkipped)
+
+	var recipients []models.BulkMessageRecipient
+	require.NoError(t, app.DB.Where("campaign_id = ?", campaign.ID).Order("phone_number").Find(&recipients).Error)
+	require.Len(t, recipients, 2)
+	assert.Equal(t, "15550000021", recipients[0].PhoneNumber)
+	assert.Equal(t, "Alice", recipients[0].RecipientName)
+	assert.Equal(t, "20240101", recipients[0].TemplateParams["1"])
+
+	var updated models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&updated, campaign.ID).Error)
+	assert.Equal(t, 2, updated.TotalRecipients)
+}
+
+func TestApp_CreateCampaign_SegmentValidation(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, orgID)
+	template := testutil.CreateTestTemplate(t, app.DB, orgID, account.Name)
+	segment := &models.ContactSegment{OrganizationID: orgID, Name: "All", CreatedByID: userID}
+	require.NoError(t, app.DB.Create(segment).Error)
+
+	tests := []struct {
+		name   string
+		id     uuid.UUID
+		params map[string]string
+		want   string
+	}{
+		{"unknown segment", uuid.New(), map[string]string{"1": "profile_name"}, "Segment not found"},
+		{"unmapped parameter", segment.ID, nil, "Template parameter {{1}} has no contact field"},
+		{"unknown field", segment.ID, map[string]string{"1": "email"}, "Invalid contact field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testutil.NewJSONRequest(t, map[string]any{
+				"name":             "Segment Campaign",
+				"whatsapp_account": account.Name,
+				"template_id":      template.ID.String(),
+				"segment_id":       tt.id,
+				"segment_params":   tt.params,
+			})
+			testutil.SetAuthContext(req, orgID, userID)
+			require.NoError(t, app.CreateCampaign(req))
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+			assert.Contains(t, string(testutil.GetResponseBody(req)), tt.want)
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/suppression"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// maxSuppressionImportRows caps the numbers one import may add
+	maxSuppressionImportRows = 10000
+
+	// maxSuppressionImportSize caps the size of an imported CSV file
+	maxSuppressionImportSize = 10 << 20
+)
+
+var errContactSuppressed = errors.New("contact opted out")
+
+// SuppressionEntryResponse is a suppression list entry in API responses
+type SuppressionEntryResponse struct {
+	ID          uuid.UUID                `json:"id"`
+	PhoneNumber string                   `json:"phone_number"`
+	Source      models.SuppressionSource `json:"source"`
+	Reason      string                   `json:"reason,omitempty"`
+	CreatedByID *uuid.UUID               `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time                `json:"created_at"`
+}
+
+// SuppressionRequest is the body of POST /api/suppressions
+type SuppressionRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Reason      string `json:"reason"`
+}
+
+// ImportSuppressionsRequest is the JSON body of POST /api/suppressions/import.
+// The endpoint also takes a CSV file with a phone_number column and an
+// optional reason column as multipart form field "file".
+type ImportSuppressionsRequest struct {
+	PhoneNumbers []string `json:"phone_numbers"`
+	Reason       string   `json:"reason"`
+}
+
+// ListSuppressions returns a page of the organization's suppression list
+func (a *App) ListSuppressions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionRead); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	search := suppression.NormalizePhone(string(r.RequestCtx.QueryArgs().Peek("search")))
+	source := string(r.RequestCtx.QueryArgs().Peek("source"))
+
+	query := a.DB.Where("organization_id = ?", orgID)
+	if search != "" {
+		query = query.Where("phone_number LIKE ?", "%"+search+"%")
+	}
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+
+	var total int64
+	query.Model(&models.SuppressionEntry{}).Count(&total)
+
+	var entries []models.SuppressionEntry
+	if err := pg.Apply(query.Order("created_at DESC")).Find(&entries).Error; err != nil {
+		a.Log.Error("Failed to list suppressions", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list suppressions", nil, "")
+	}
+
+	mask := a.ShouldMaskPhoneNumbers(orgID)
+	result := make([]SuppressionEntryResponse, len(entries))
+	for i := range entries {
+		result[i] = suppressionEntryToResponse(&entries[i], mask)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"suppressions": result,
+		"total":        total,
+		"page":         pg.Page,
+		"limit":        pg.Limit,
+	})
+}
+
+// CreateSuppression adds a phone number to the suppression list
+func (a *App) CreateSuppression(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req SuppressionRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if suppression.NormalizePhone(req.PhoneNumber) == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "phone_number is required", nil, "")
+	}
+	if len(req.Reason) > maxBlockedReasonLength {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("reason must be at most %d characters", maxBlockedReasonLength), nil, "")
+	}
+
+	entry := models.SuppressionEntry{
+		OrganizationID: orgID,
+		PhoneNumber:    req.PhoneNumber,
+		Source:         models.SuppressionSourceManual,
+		Reason:         req.Reason,
+		CreatedByID:    &userID,
+	}
+	added, err := suppression.Add(a.DB, &entry)
+	if err != nil {
+		a.Log.Error("Failed to add suppression", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to add suppression", nil, "")
+	}
+	if !added {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Phone number is already suppressed", nil, "")
+	}
+
+	a.Log.Info("Phone number suppressed", "suppression_id", entry.ID, "user_id", userID)
+	return r.SendEnvelope(suppressionEntryToResponse(&entry, a.ShouldMaskPhoneNumbers(orgID)))
+}
+
+// DeleteSuppression removes a phone number from the suppression list, so
+// campaigns and notifications reach it again
+func (a *App) DeleteSuppression(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionDelete); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "suppression")
+	if err != nil {
+		return nil
+	}
+	entry, err := findByIDAndOrg[models.SuppressionEntry](a.DB, r, id, orgID, "Suppression")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := suppression.Remove(a.DB, orgID, entry.PhoneNumber); err != nil {
+		a.Log.Error("Failed to remove suppression", "error", err, "suppression_id", id)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to remove suppression", nil, "")
+	}
+
+	a.Log.Info("Suppression removed", "suppression_id", id, "user_id", userID)
+	return r.SendEnvelope(map[string]string{"message": "Suppression removed"})
+}
+
+// ImportSuppressions adds numbers to the suppression list in bulk, from a
+// CSV file or a JSON list. Numbers already on the list are skipped.
+func (a *App) ImportSuppressions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionImport); err != nil {
+		return nil
+	}
+
+	var rows []SuppressionRequest
+	if strings.HasPrefix(string(r.RequestCtx.Request.Header.ContentType()), "multipart/form-data") {
+		if rows, err = a.readSuppressionCSV(r); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	} else {
+		var req ImportSuppressionsRequest
+		if err := a.decodeRequest(r, &req); err != nil {
+			return nil
+		}
+		for _, phone := range req.PhoneNumbers {
+			rows = append(rows, SuppressionRequest{PhoneNumber: phone, Reason: req.Reason})
+		}
+	}
+	if len(rows) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No phone numbers to import", nil, "")
+	}
+	if len(rows) > maxSuppressionImportRows {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Import is limited to %d phone numbers", maxSuppressionImportRows), nil, "")
+	}
+
+	var created, skipped, invalid int
+	for _, row := range rows {
+		if suppression.NormalizePhone(row.PhoneNumber) == "" {
+			invalid++
+			continue
+		}
+		reason := row.Reason
+		if len(reason) > maxBlockedReasonLength {
+			reason = reason[:maxBlockedReasonLength]
+		}
+		added, err := suppression.Add(a.DB, &models.SuppressionEntry{
+			OrganizationID: orgID,
+			PhoneNumber:    row.PhoneNumber,
+			Source:         models.SuppressionSourceImport,
+			Reason:         reason,
+			CreatedByID:    &userID,
+		})
+		if err != nil {
+			a.Log.Error("Failed to import suppression", "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to import suppressions", nil, "")
+		}
+		if added {
+			created++
+		} else {
+			skipped++
+		}
+	}
+
+	a.Log.Info("Suppressions imported", "created", created, "skipped", skipped, "invalid", invalid, "user_id", userID)
+	return r.SendEnvelope(map[string]int{
+		"created": created,
+		"skipped": skipped,
+		"invalid": invalid,
+	})
+}
+
+// readSuppressionCSV reads the rows of an uploaded suppression list. The
+// header must have a phone_number column; a reason column is optional.
+func (a *App) readSuppressionCSV(r *fastglue.Request) ([]SuppressionRequest, error) {
+	fileHeader, err := r.RequestCtx.FormFile("file")
+	if err != nil {
+		return nil, errors.New("file is required")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, errors.New("failed to read file")
+	}
+	defer file.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSuppressionImportSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read file")
+	}
+	if len(data) > maxSuppressionImportSize {
+		return nil, errors.New("file is too large")
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("failed to read CSV header")
+	}
+	phoneCol, reasonCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.ReplaceAll(h, " ", "_"))) {
+		case "phone_number", "phone":
+			phoneCol = i
+		case "reason":
+			reasonCol = i
+		}
+	}
+	if phoneCol < 0 {
+		return nil, errors.New("CSV must have a phone_number column")
+	}
+
+	var rows []SuppressionRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV row %d", len(rows)+2)
+		}
+		if phoneCol >= len(record) {
+			continue
+		}
+		row := SuppressionRequest{PhoneNumber: strings.TrimSpace(record[phoneCol])}
+		if reasonCol >= 0 && reasonCol < len(record) {
+			row.Reason = strings.TrimSpace(record[reasonCol])
+		}
+		rows = append(rows, row)
+		if len(rows) > maxSuppressionImportRows {
+			break
+		}
+	}
+	return rows, nil
+}
+
+// ExportSuppressions downloads the suppression list as CSV
+func (a *App) ExportSuppressions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionExport); err != nil {
+		return nil
+	}
+
+	var entries []models.SuppressionEntry
+	if err := a.DB.Where("organization_id = ?", orgID).Order("created_at ASC").Find(&entries).Error; err != nil {
+		a.Log.Error("Failed to export suppressions", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to export suppressions", nil, "")
+	}
+
+	mask := a.ShouldMaskPhoneNumbers(orgID)
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{"phone_number", "source", "reason", "created_at"})
+	for i := range entries {
+		entry := suppressionEntryToResponse(&entries[i], mask)
+		reason := entry.Reason
+		// Reasons are free text from customers; keep them from running as formulas
+		if len(reason) > 0 && (reason[0] == '=' || reason[0] == '@') {
+			reason = "'" + reason
+		}
+		_ = writer.Write([]string{entry.PhoneNumber, string(entry.Source), reason, entry.CreatedAt.Format(time.RFC3339)})
+	}
+	writer.Flush()
+
+	filename := fmt.Sprintf("suppressions_export_%s.csv", time.Now().Format("20060102_150405"))
+	r.RequestCtx.Response.Header.Set("Content-Type", "text/csv")
+	r.RequestCtx.Response.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	r.RequestCtx.SetBody(buf.Bytes())
+	return nil
+}
+
+// handleOptKeyword puts a contact who replied with one of the organization's
+// opt-out keywords on the suppression list, and takes one who replied with an
+// opt-in keyword off it. It reports whether the message was such a keyword,
+// in which case the chatbot leaves it alone.
+func (a *App) handleOptKeyword(orgID uuid.UUID, contact *models.Contact, text string) bool {
+	if text == "" {
+		return false
+	}
+
+	var org models.Organization
+	if err := a.DB.Select("id", "settings").Where("id = ?", orgID).First(&org).Error; err != nil {
+		a.Log.Error("Failed to load organization for opt-out keywords", "error", err, "org_id", orgID)
+		return false
+	}
+	optOut, optIn := suppression.Keywords(org.Settings)
+
+	switch {
+	case suppression.Matches(optOut, text):
+		added, err := suppression.Add(a.DB, &models.SuppressionEntry{
+			OrganizationID: orgID,
+			PhoneNumber:    contact.PhoneNumber,
+			Source:         models.SuppressionSourceKeyword,
+			Reason:         strings.TrimSpace(text),
+		})
+		if err != nil {
+			a.Log.Error("Failed to save opt-out", "error", err, "contact_id", contact.ID)
+		} else if added {
+			a.Log.Info("Contact opted out", "contact_id", contact.ID, "keyword", text)
+		}
+		return true
+
+	case suppression.Matches(optIn, text):
+		if _, err := suppression.Remove(a.DB, orgID, contact.PhoneNumber); err != nil {
+			a.Log.Error("Failed to remove opt-out", "error", err, "contact_id", contact.ID)
+			return true
+		}
+		now := time.Now()
+		if err := a.DB.Model(contact).Updates(map[string]any{
+			"opt_in_source": models.OptInSourceKeyword,
+			"opted_in_at":   now,
+		}).Error; err != nil {
+			a.Log.Error("Failed to save opt-in", "error", err, "contact_id", contact.ID)
+		}
+		contact.OptInSource = models.OptInSourceKeyword
+		contact.OptedInAt = &now
+		a.Log.Info("Contact opted in", "contact_id", contact.ID, "keyword", text)
+		return true
+	}
+	return false
+}
+
+func suppressionEntryToResponse(entry *models.SuppressionEntry, mask bool) SuppressionEntryResponse {
+	phone := entry.PhoneNumber
+	if mask {
+		phone = MaskPhoneNumber(phone)
+	}
+	return SuppressionEntryResponse{
+		ID:          entry.ID,
+		PhoneNumber: phone,
+		Source:      entry.Source,
+		Reason:      entry.Reason,
+		CreatedByID: entry.CreatedByID,
+		CreatedAt:   entry.CreatedAt,
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/suppression"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleOptKeyword(t *testing.T) {
+	app := &App{DB: testutil.SetupTestDB(t), Log: testutil.NopLogger()}
+	org := testutil.CreateTestOrganization(t, app.DB)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithPhoneNumber("15550000001"))
+
+	assert.False(t, app.handleOptKeyword(org.ID, contact, "please stop by tomorrow"))
+
+	// Default keywords apply until the organization sets its own
+	assert.True(t, app.handleOptKeyword(org.ID, contact, "Stop!"))
+	suppressed, err := suppression.IsSuppressed(app.DB, org.ID, contact.PhoneNumber)
+	require.NoError(t, err)
+	assert.True(t, suppressed)
+
+	assert.True(t, app.handleOptKeyword(org.ID, contact, "start"))
+	suppressed, err = suppression.IsSuppressed(app.DB, org.ID, contact.PhoneNumber)
+	require.NoError(t, err)
+	assert.False(t, suppressed)
+
+	var updated models.Contact
+	require.NoError(t, app.DB.First(&updated, "id = ?", contact.ID).Error)
+	assert.Equal(t, models.OptInSourceKeyword, updated.OptInSource)
+	assert.NotNil(t, updated.OptedInAt)
+
+	// Custom keywords replace the defaults
+	require.NoError(t, app.DB.Model(org).Update("settings", models.JSONB{
+		suppression.OptOutKeywordsSetting: []string{"BASTA"},
+	}).Error)
+	assert.False(t, app.handleOptKeyword(org.ID, contact, "STOP"))
+	assert.True(t, app.handleOptKeyword(org.ID, contact, "basta"))
+}
@@ -0,0 +1,122 @@
+package handlers_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/suppression"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestApp_Suppressions_CreateListDelete(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+
+	req := testutil.NewJSONRequest(t, handlers.SuppressionRequest{PhoneNumber: "+1 555 000 0001", Reason: "Asked by phone"})
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.CreateSuppression(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var created struct {
+		Data handlers.SuppressionEntryResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &created))
+	assert.Equal(t, "15550000001", created.Data.PhoneNumber)
+	assert.Equal(t, models.SuppressionSourceManual, created.Data.Source)
+
+	// The same number in another format is a duplicate
+	req = testutil.NewJSONRequest(t, handlers.SuppressionRequest{PhoneNumber: "15550000001"})
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.CreateSuppression(req))
+	assert.Equal(t, fasthttp.StatusConflict, testutil.GetResponseStatusCode(req))
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetQueryParam(req, "search", "0001")
+	require.NoError(t, app.ListSuppressions(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var list struct {
+		Data struct {
+			Suppressions []handlers.SuppressionEntryResponse `json:"suppressions"`
+			Total        int64                               `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &list))
+	assert.Equal(t, int64(1), list.Data.Total)
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", created.Data.ID.String())
+	require.NoError(t, app.DeleteSuppression(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	suppressed, err := suppression.IsSuppressed(app.DB, orgID, "15550000001")
+	require.NoError(t, err)
+	assert.False(t, suppressed)
+}
+
+func TestApp_Suppressions_ImportExport(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+
+	_, err := suppression.Add(app.DB, &models.SuppressionEntry{OrganizationID: orgID, PhoneNumber: "15550000001", Source: models.SuppressionSourceKeyword, Reason: "STOP"})
+	require.NoError(t, err)
+
+	req := testutil.NewJSONRequest(t, handlers.ImportSuppressionsRequest{
+		PhoneNumbers: []string{"+15550000001", "15550000002", "+1 555 000 0003", "n/a"},
+		Reason:       "CRM export",
+	})
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.ImportSuppressions(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	var imported struct {
+		Data map[string]int `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &imported))
+	assert.Equal(t, 2, imported.Data["created"])
+	assert.Equal(t, 1, imported.Data["skipped"])
+	assert.Equal(t, 1, imported.Data["invalid"])
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.ExportSuppressions(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	rows, err := csv.NewReader(strings.NewReader(string(testutil.GetResponseBody(req)))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, []string{"phone_number", "source", "reason", "created_at"}, rows[0])
+	phones := []string{rows[1][0], rows[2][0], rows[3][0]}
+	assert.ElementsMatch(t, []string{"15550000001", "15550000002", "15550000003"}, phones)
+}
+
+func TestApp_UpdateOrganizationSettings_OptKeywords(t *testing.T) {
+	t.Parallel()
+	app, orgID, userID := segmentTestSetup(t)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"opt_out_keywords": []string{" stop ", "Basta", "STOP"},
+		"opt_in_keywords":  []string{},
+	})
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.UpdateOrganizationSettings(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var org models.Organization
+	require.NoError(t, app.DB.First(&org, "id = ?", orgID).Error)
+	optOut, optIn := suppression.Keywords(org.Settings)
+	assert.Equal(t, []string{"STOP", "BASTA"}, optOut)
+	assert.Empty(t, optIn)
+
+	// A keyword can't be in both lists
+	req = testutil.NewJSONRequest(t, map[string]any{"opt_in_keywords": []string{"basta"}})
+	testutil.SetAuthContext(req, orgID, userID)
+	require.NoError(t, app.UpdateOrganizationSettings(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
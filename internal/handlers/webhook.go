@@ -6,6 +6,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
 )
 
 // WebhookVerify handles Meta's webhook verification challenge
@@ -102,6 +105,9 @@ type WebhookPayload struct {
 				MessageTemplateName     string `json:"message_template_name,omitempty"`
 				MessageTemplateLanguage string `json:"message_template_language,omitempty"`
 				Reason                  string `json:"reason,omitempty"`
+				// Account health fields (when field is "phone_number_quality_update",
+				// "account_update" or "account_alerts")
+				AccountHealthValue
 				Contacts                []struct {
 					Profile struct {
 						Name string `json:"name"`
@@ -183,10 +189,13 @@ type WebhookPayload struct {
 							Type  string `json:"type,omitempty"`
 						} `json:"phones,omitempty"`
 					} `json:"contacts,omitempty"`
-					Context *struct {
-						From string `json:"from"`
-						ID   string `json:"id"`
-					} `json:"context,omitempty"`
+					Order    *IncomingOrder    `json:"order,omitempty"`
+					Referral *IncomingReferral `json:"referral,omitempty"`
+					Button *struct {
+						Payload string `json:"payload"`
+						Text    string `json:"text"`
+					} `json:"button,omitempty"`
+					Context *IncomingContext `json:"context,omitempty"`
 				} `json:"messages,omitempty"`
 				Statuses []WebhookStatus `json:"statuses,omitempty"`
 			Calls []struct {
@@ -217,7 +226,9 @@ type WebhookPayload struct {
 	} `json:"entry"`
 }
 
-// WebhookHandler processes incoming webhook events from Meta
+// WebhookHandler stores incoming webhook events from Meta and acknowledges
+// them. Processing happens asynchronously from the stored copy, so a crash
+// or database error mid-processing can be recovered by re-running the event.
 func (a *App) WebhookHandler(r *fastglue.Request) error {
 	body := r.RequestCtx.PostBody()
 	signature := r.RequestCtx.Request.Header.Peek("X-Hub-Signature-256")
@@ -228,10 +239,58 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid payload", nil, "")
 	}
 
-	// Track if signature has been verified (only need to verify once per request)
-	signatureVerified := false
+	if !a.verifyWebhookPayloadSignature(&payload, body, signature) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Invalid signature", nil, "")
+	}
+
+	// Persist before acknowledging; if this fails Meta will retry delivery
+	event, err := a.storeWebhookEvent(&payload, body)
+	if err != nil {
+		a.Log.Error("Failed to store webhook event", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to store webhook", nil, "")
+	}
+
+	go a.processWebhookEvent(event.ID, &payload)
+
+	// Always respond with 200 to acknowledge receipt
+	return r.SendEnvelope(map[string]string{"status": "ok"})
+}
+
+// verifyWebhookPayloadSignature checks the X-Hub-Signature-256 header against
+// the app secret of the first phone number in a messages change. Payloads
+// without a signature, or for accounts without an app secret, are accepted.
+func (a *App) verifyWebhookPayloadSignature(payload *WebhookPayload, body, signature []byte) bool {
+	if len(signature) == 0 {
+		return true
+	}
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			phoneNumberID := change.Value.Metadata.PhoneNumberID
+			if change.Field != "messages" || phoneNumberID == "" {
+				continue
+			}
+			account, err := a.getWhatsAppAccountCached(phoneNumberID)
+			if err == nil && account.AppSecret != "" {
+				if !verifyWebhookSignature(body, signature, []byte(account.AppSecret)) {
+					a.Log.Warn("Invalid webhook signature", "phone_id", phoneNumberID)
+					return false
+				}
+				a.Log.Debug("Webhook signature verified successfully")
+			}
+			return true
+		}
+	}
+	return true
+}
 
-	// Process each entry
+// dispatchWebhookPayload runs the handlers for every change in a payload.
+// Live deliveries claim each message and status so Meta's retries are not
+// processed twice; replays skip the claim and rely on the handlers being
+// idempotent on the WhatsApp message ID. Call events are real-time only and
+// are skipped on replay. Messages and statuses that could not be stored are
+// reported in the returned error, after the rest of the payload has run.
+func (a *App) dispatchWebhookPayload(payload *WebhookPayload, replay bool) error {
+	var errs []error
 	for _, entry := range payload.Entry {
 		for _, change := range entry.Changes {
 			// Handle template status updates
@@ -242,13 +301,27 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 					"template_language", change.Value.MessageTemplateLanguage,
 					"waba_id", entry.ID,
 				)
-				go a.processTemplateStatusUpdate(entry.ID, change.Value.Event, change.Value.MessageTemplateName, change.Value.MessageTemplateLanguage, change.Value.Reason)
+				a.processTemplateStatusUpdate(entry.ID, change.Value.Event, change.Value.MessageTemplateName, change.Value.MessageTemplateLanguage, change.Value.Reason)
+				continue
+			}
+
+			// Handle quality rating, messaging tier and account status changes
+			if change.Field == "phone_number_quality_update" || change.Field == "account_update" || change.Field == "account_alerts" {
+				a.Log.Info("Received account health update",
+					"field", change.Field,
+					"event", change.Value.Event,
+					"waba_id", entry.ID,
+				)
+				a.processAccountHealthWebhook(entry.ID, change.Field, change.Value.Event, change.Value.AccountHealthValue)
 				continue
 			}
 
 			// Handle voice call events (processed sequentially to preserve event order
 			// and avoid race conditions between ringing/connect for the same call)
 			if change.Field == "calls" {
+				if replay {
+					continue
+				}
 				phoneNumberID := change.Value.Metadata.PhoneNumberID
 				for _, call := range change.Value.Calls {
 					a.Log.Info("Received call event",
@@ -283,19 +356,6 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 
 			phoneNumberID := change.Value.Metadata.PhoneNumberID
 
-			// Verify webhook signature on first message processing (uses cached account)
-			if !signatureVerified && len(signature) > 0 && phoneNumberID != "" {
-				account, err := a.getWhatsAppAccountCached(phoneNumberID)
-				if err == nil && account.AppSecret != "" {
-					if !verifyWebhookSignature(body, signature, []byte(account.AppSecret)) {
-						a.Log.Warn("Invalid webhook signature", "phone_id", phoneNumberID)
-						return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Invalid signature", nil, "")
-					}
-					a.Log.Debug("Webhook signature verified successfully")
-				}
-				signatureVerified = true
-			}
-
 			// Process messages
 			for _, msg := range change.Value.Messages {
 				a.Log.Info("Received message",
@@ -303,6 +363,10 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 					"type", msg.Type,
 					"phone_number_id", phoneNumberID,
 				)
+				if !replay && !a.claimWebhookItem("msg:"+msg.ID) {
+					a.Log.Debug("Duplicate message delivery, skipping", "message_id", msg.ID)
+					continue
+				}
 
 				// Handle call permission replies before regular message processing
 				if msg.Type == "interactive" && msg.Interactive != nil &&
@@ -310,7 +374,7 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 					msg.Interactive.CallPermissionReply != nil {
 					cpr := msg.Interactive.CallPermissionReply
 					expTS, _ := cpr.ExpirationTimestamp.Int64()
-					go a.processCallPermissionReply(phoneNumberID, msg.From, &CallPermissionReplyData{
+					a.processCallPermissionReply(phoneNumberID, msg.From, &CallPermissionReplyData{
 						Response:            cpr.Response,
 						IsPermanent:         cpr.IsPermanent,
 						ExpirationTimestamp: expTS,
@@ -328,8 +392,9 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 					}
 				}
 
-				// Process message asynchronously
-				go a.processIncomingMessage(phoneNumberID, msg, profileName)
+				if err := a.processIncomingMessage(phoneNumberID, msg, profileName); err != nil {
+					errs = append(errs, fmt.Errorf("message %s: %w", msg.ID, err))
+				}
 			}
 
 			// Process status updates
@@ -338,51 +403,66 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 					"message_id", status.ID,
 					"status", status.Status,
 				)
+				if !replay && !a.claimWebhookItem("status:"+status.ID+":"+status.Status) {
+					a.Log.Debug("Duplicate status delivery, skipping", "message_id", status.ID, "status", status.Status)
+					continue
+				}
 
-				go a.processStatusUpdate(phoneNumberID, status)
+				if err := a.processStatusUpdate(phoneNumberID, status); err != nil {
+					errs = append(errs, fmt.Errorf("status %s of %s: %w", status.Status, status.ID, err))
+				}
 			}
 		}
 	}
-
-	// Always respond with 200 to acknowledge receipt
-	return r.SendEnvelope(map[string]string{"status": "ok"})
+	return errors.Join(errs...)
 }
 
-func (a *App) processIncomingMessage(phoneNumberID string, msg interface{}, profileName string) {
+func (a *App) processIncomingMessage(phoneNumberID string, msg interface{}, profileName string) error {
 	// Convert msg interface to the message struct
 	msgBytes, err := json.Marshal(msg)
 	if err != nil {
 		a.Log.Error("Failed to marshal message", "error", err)
-		return
+		return nil
 	}
 
 	var textMsg IncomingTextMessage
 	if err := json.Unmarshal(msgBytes, &textMsg); err != nil {
 		a.Log.Error("Failed to unmarshal message", "error", err)
-		return
+		return nil
 	}
 
-	// Check for duplicate message - Meta sometimes sends the same message multiple times
+	// Check for duplicate message - Meta sometimes sends the same message multiple
+	// times, and recovered or replayed webhook events run again
 	if textMsg.ID != "" {
 		var existingMsg models.Message
-		if err := a.DB.Where("whats_app_message_id = ?", textMsg.ID).First(&existingMsg).Error; err == nil {
+		err := a.DB.Where("whats_app_message_id = ?", textMsg.ID).First(&existingMsg).Error
+		if err == nil {
 			a.Log.Debug("Duplicate message detected, skipping", "message_id", textMsg.ID)
-			return
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check for duplicate message: %w", err)
 		}
 	}
 
 	// Process the message with chatbot logic
-	a.processIncomingMessageFull(phoneNumberID, textMsg, profileName)
+	return a.processIncomingMessageFull(phoneNumberID, textMsg, profileName)
 }
 
-func (a *App) processStatusUpdate(phoneNumberID string, status WebhookStatus) {
+func (a *App) processStatusUpdate(phoneNumberID string, status WebhookStatus) error {
 	messageID := status.ID
 	statusValue := status.Status
 
 	a.Log.Info("Processing status update", "message_id", messageID, "status", statusValue, "phone_number_id", phoneNumberID)
 
 	// Update messages table - this also handles campaign stats via incrementCampaignStat
-	a.updateMessageStatus(messageID, statusValue, status.Errors)
+	if err := a.updateMessageStatus(messageID, statusValue, status.Errors); err != nil {
+		return err
+	}
+
+	// Record what the message costs, if Meta says it is billable
+	a.recordMessagePricing(status)
+	return nil
 }
 
 // statusPriority returns the priority of a status (higher = more progressed)
@@ -404,13 +484,16 @@ func statusPriority(status models.MessageStatus) int {
 }
 
 // updateMessageStatus updates the status of a regular message in the messages table
-func (a *App) updateMessageStatus(whatsappMsgID, statusValue string, errors []WebhookStatusError) {
+func (a *App) updateMessageStatus(whatsappMsgID, statusValue string, statusErrors []WebhookStatusError) error {
 	// Find the message by WhatsApp message ID
 	var message models.Message
 	result := a.DB.Where("whats_app_message_id = ?", whatsappMsgID).First(&message)
-	if result.Error != nil {
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		a.Log.Debug("No message found for status update", "whats_app_message_id", whatsappMsgID)
-		return
+		return nil
+	}
+	if result.Error != nil {
+		return fmt.Errorf("failed to load message: %w", result.Error)
 	}
 
 	newStatus := models.MessageStatus(statusValue)
@@ -418,12 +501,13 @@ func (a *App) updateMessageStatus(whatsappMsgID, statusValue string, errors []We
 	newPriority := statusPriority(newStatus)
 
 	// Only update if new status is a progression (higher priority) or if it's failed
-	if newPriority <= currentPriority && newStatus != models.MessageStatusFailed {
+	// Failed is terminal: a repeated failed status must not count twice
+	if (newPriority <= currentPriority && newStatus != models.MessageStatusFailed) || message.Status == models.MessageStatusFailed {
 		a.Log.Debug("Ignoring status update - not a progression",
 			"message_id", message.ID,
 			"current_status", message.Status,
 			"new_status", statusValue)
-		return
+		return nil
 	}
 
 	updates := map[string]interface{}{}
@@ -437,26 +521,26 @@ func (a *App) updateMessageStatus(whatsappMsgID, statusValue string, errors []We
 		updates["status"] = models.MessageStatusRead
 	case models.MessageStatusFailed:
 		updates["status"] = models.MessageStatusFailed
-		if len(errors) > 0 {
+		if len(statusErrors) > 0 {
 			// Prefer error_data.details (most descriptive), then Message, then Title.
-			errText := errors[0].ErrorData.Details
+			errText := statusErrors[0].ErrorData.Details
 			if errText == "" {
-				errText = errors[0].Message
+				errText = statusErrors[0].Message
 			}
-			if errText == "" || errText == errors[0].Title {
-				errText = errors[0].Title
+			if errText == "" || errText == statusErrors[0].Title {
+				errText = statusErrors[0].Title
 			}
 
 			updates["error_message"] = errText
 		}
 	default:
 		a.Log.Debug("Ignoring message status update", "status", statusValue)
-		return
+		return nil
 	}
 
 	if err := a.DB.Model(&message).Updates(updates).Error; err != nil {
 		a.Log.Error("Failed to update message status", "error", err, "message_id", message.ID)
-		return
+		return fmt.Errorf("failed to update message status: %w", err)
 	}
 
 	a.Log.Info("Updated message status", "message_id", message.ID, "status", statusValue)
@@ -496,6 +580,7 @@ func (a *App) updateMessageStatus(whatsappMsgID, statusValue string, errors []We
 			Payload: wsPayload,
 		})
 	}
+	return nil
 }
 
 // processTemplateStatusUpdate updates template status when Meta sends a status update webhook
@@ -25,15 +25,49 @@ type OutboundWebhookPayload struct {
 
 // MessageEventData represents data for message events
 type MessageEventData struct {
-	MessageID       string             `json:"message_id"`
-	ContactID       string             `json:"contact_id"`
-	ContactPhone    string             `json:"contact_phone"`
-	ContactName     string             `json:"contact_name"`
-	MessageType     models.MessageType `json:"message_type"`
-	Content         string             `json:"content"`
-	WhatsAppAccount string             `json:"whatsapp_account"`
-	Direction       models.Direction   `json:"direction,omitempty"`
-	SentByUserID    string             `json:"sent_by_user_id,omitempty"`
+	MessageID       string               `json:"message_id"`
+	ContactID       string               `json:"contact_id"`
+	ContactPhone    string               `json:"contact_phone"`
+	ContactName     string               `json:"contact_name"`
+	MessageType     models.MessageType   `json:"message_type"`
+	Content         string               `json:"content"`
+	WhatsAppAccount string               `json:"whatsapp_account"`
+	Direction       models.Direction     `json:"direction,omitempty"`
+	SentByUserID    string               `json:"sent_by_user_id,omitempty"`
+	Context         *MessageEventContext `json:"context,omitempty"` // Incoming replies and forwards
+}
+
+// MessageEventContext tells integrations which message a customer answered
+type MessageEventContext struct {
+	WAMID               string `json:"wamid,omitempty"`               // WhatsApp ID of the quoted message
+	ReplyToMessageID    string `json:"reply_to_message_id,omitempty"` // Set when the quoted message is stored
+	ReplyToCampaignID   string `json:"reply_to_campaign_id,omitempty"`
+	ReplyToTemplate     string `json:"reply_to_template,omitempty"`
+	Forwarded           bool   `json:"forwarded,omitempty"`
+	FrequentlyForwarded bool   `json:"frequently_forwarded,omitempty"`
+	ReferredProduct     any    `json:"referred_product,omitempty"`
+}
+
+// messageEventContext builds the webhook context of an incoming message.
+// replyTo is the stored message it quotes, if any.
+func messageEventContext(msgContext *IncomingContext, replyTo *models.Message) *MessageEventContext {
+	if msgContext == nil {
+		return nil
+	}
+	ctx := &MessageEventContext{
+		WAMID:               msgContext.ID,
+		Forwarded:           msgContext.Forwarded,
+		FrequentlyForwarded: msgContext.FrequentlyForwarded,
+	}
+	if msgContext.ReferredProduct != nil {
+		ctx.ReferredProduct = msgContext.ReferredProduct
+	}
+	if replyTo != nil {
+		ctx.ReplyToMessageID = replyTo.ID.String()
+		ctx.ReplyToCampaignID, _ = replyTo.Metadata["campaign_id"].(string)
+		ctx.ReplyToTemplate = replyTo.TemplateName
+	}
+	return ctx
 }
 
 // ContactEventData represents data for contact events
@@ -57,6 +91,41 @@ type TransferEventData struct {
 	WhatsAppAccount string                `json:"whatsapp_account"`
 }
 
+// OrderEventData represents data for order events
+type OrderEventData struct {
+	OrderID         string             `json:"order_id"`
+	ContactID       string             `json:"contact_id"`
+	ContactPhone    string             `json:"contact_phone"`
+	ContactName     string             `json:"contact_name"`
+	MetaCatalogID   string             `json:"catalog_id"`
+	Status          models.OrderStatus `json:"status"`
+	Note            string             `json:"note,omitempty"`
+	TotalAmount     int64              `json:"total_amount"` // In cents
+	Currency        string             `json:"currency"`
+	Items           []OrderEventItem   `json:"items"`
+	WhatsAppAccount string             `json:"whatsapp_account"`
+}
+
+// OrderEventItem represents a product line in an order event
+type OrderEventItem struct {
+	ProductRetailerID string `json:"product_retailer_id"`
+	Name              string `json:"name"`
+	Quantity          int    `json:"quantity"`
+	UnitPrice         int64  `json:"unit_price"` // In cents
+	Currency          string `json:"currency"`
+}
+
+// AccountAlertEventData represents data for account health alerts
+type AccountAlertEventData struct {
+	WhatsAppAccount string                        `json:"whatsapp_account"`
+	Kind            models.AccountHealthEventKind `json:"kind"`
+	Event           string                        `json:"event,omitempty"`
+	OldValue        string                        `json:"old_value,omitempty"`
+	NewValue        string                        `json:"new_value,omitempty"`
+	Severity        string                        `json:"severity"`
+	Description     string                        `json:"description,omitempty"`
+}
+
 // maxConcurrentWebhooks limits the number of concurrent webhook deliveries per dispatch
 const maxConcurrentWebhooks = 10
 
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+const (
+	// webhookDedupeTTL covers the window in which Meta retries a delivery
+	webhookDedupeTTL = 7 * 24 * time.Hour
+
+	// webhookEventRetention is how long processed events are kept for replay
+	webhookEventRetention = 30 * 24 * time.Hour
+
+	// webhookEventMaxAttempts bounds how often a stuck event is re-run
+	webhookEventMaxAttempts = 5
+
+	// webhookEventPendingGrace and webhookEventProcessingTimeout decide when
+	// an event was lost by a crash: never picked up, or picked up and never
+	// finished.
+	webhookEventPendingGrace      = time.Minute
+	webhookEventProcessingTimeout = 10 * time.Minute
+)
+
+// WebhookReplayRequest is the body for replaying stored webhook events
+type WebhookReplayRequest struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// WebhookReplayResult reports the outcome of a replay
+type WebhookReplayResult struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// storeWebhookEvent persists the raw payload before any processing
+func (a *App) storeWebhookEvent(payload *WebhookPayload, body []byte) (*models.InboundWebhookEvent, error) {
+	event := &models.InboundWebhookEvent{
+		ReceivedAt: time.Now(),
+		Object:     payload.Object,
+		Payload:    string(body),
+		Status:     models.InboundWebhookPending,
+	}
+
+	var fields []string
+	for _, entry := range payload.Entry {
+		if event.EntryID == "" {
+			event.EntryID = entry.ID
+		}
+		for _, change := range entry.Changes {
+			if event.PhoneNumberID == "" {
+				event.PhoneNumberID = change.Value.Metadata.PhoneNumberID
+			}
+			if !slices.Contains(fields, change.Field) {
+				fields = append(fields, change.Field)
+			}
+		}
+	}
+	event.Fields = strings.Join(fields, ",")
+
+	if err := a.DB.Create(event).Error; err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// processWebhookEvent claims a freshly stored event and runs it
+func (a *App) processWebhookEvent(eventID uuid.UUID, payload *WebhookPayload) {
+	if !a.claimWebhookEvent(eventID, models.InboundWebhookPending, nil) {
+		return
+	}
+	_ = a.runWebhookEvent(eventID, payload, false)
+}
+
+// claimWebhookEvent moves an event to processing. The update is conditional on
+// the status (and last update time when given), so when the live path and the
+// recovery sweep race only one of them runs the event.
+func (a *App) claimWebhookEvent(eventID uuid.UUID, from models.InboundWebhookStatus, updatedAt *time.Time) bool {
+	q := a.DB.Model(&models.InboundWebhookEvent{}).Where("id = ? AND status = ?", eventID, from)
+	if updatedAt != nil {
+		q = q.Where("updated_at = ?", *updatedAt)
+	}
+	result := q.Updates(map[string]any{
+		"status":   models.InboundWebhookProcessing,
+		"attempts": gorm.Expr("attempts + 1"),
+	})
+	if result.Error != nil {
+		a.Log.Error("Failed to claim webhook event", "error", result.Error, "event_id", eventID)
+		return false
+	}
+	return result.RowsAffected == 1
+}
+
+// runWebhookEvent dispatches a payload and records the outcome on the event.
+// An event that didn't run cleanly goes back to pending, so the recovery sweep
+// retries it until webhookEventMaxAttempts.
+func (a *App) runWebhookEvent(eventID uuid.UUID, payload *WebhookPayload, replay bool) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+			a.Log.Error("Webhook event processing panicked", "error", err, "event_id", eventID)
+		}
+
+		updates := map[string]any{"status": models.InboundWebhookProcessed, "error": ""}
+		if err != nil {
+			a.Log.Error("Webhook event not fully processed, will retry", "error", err, "event_id", eventID)
+			updates = map[string]any{"status": models.InboundWebhookPending, "error": err.Error()}
+		} else {
+			updates["processed_at"] = time.Now()
+		}
+		if dbErr := a.DB.Model(&models.InboundWebhookEvent{}).Where("id = ?", eventID).Updates(updates).Error; dbErr != nil {
+			a.Log.Error("Failed to update webhook event status", "error", dbErr, "event_id", eventID)
+		}
+	}()
+
+	return a.dispatchWebhookPayload(payload, replay)
+}
+
+// claimWebhookItem records that a message or status has been seen. It returns
+// false for a repeat delivery. Without Redis, or if Redis is unavailable,
+// every delivery is processed.
+func (a *App) claimWebhookItem(key string) bool {
+	if a.Redis == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ok, err := a.Redis.SetNX(ctx, "whatomate:webhook:seen:"+key, 1, webhookDedupeTTL).Result()
+	if err != nil {
+		a.Log.Warn("Webhook dedupe check failed, processing anyway", "error", err, "key", key)
+		return true
+	}
+	return ok
+}
+
+// RecoverWebhookEvents re-runs events that were stored but never finished,
+// e.g. because the process crashed mid-processing, and prunes old processed
+// events. Returns the number of events re-run.
+func (a *App) RecoverWebhookEvents(ctx context.Context) int {
+	now := time.Now()
+
+	var events []models.InboundWebhookEvent
+	if err := a.DB.Where("(status = ? AND updated_at < ?) OR (status = ? AND updated_at < ?)",
+		models.InboundWebhookPending, now.Add(-webhookEventPendingGrace),
+		models.InboundWebhookProcessing, now.Add(-webhookEventProcessingTimeout)).
+		Order("received_at ASC").
+		Limit(100).
+		Find(&events).Error; err != nil {
+		a.Log.Error("Failed to load unfinished webhook events", "error", err)
+		return 0
+	}
+
+	recovered := 0
+	for i := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		event := &events[i]
+
+		if event.Attempts >= webhookEventMaxAttempts {
+			a.Log.Error("Giving up on webhook event", "event_id", event.ID, "attempts", event.Attempts)
+			a.DB.Model(&models.InboundWebhookEvent{}).
+				Where("id = ? AND status = ?", event.ID, event.Status).
+				Updates(map[string]any{"status": models.InboundWebhookFailed, "error": fmt.Sprintf("not completed after %d attempts", event.Attempts)})
+			continue
+		}
+
+		var payload WebhookPayload
+		if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+			a.DB.Model(&models.InboundWebhookEvent{}).Where("id = ?", event.ID).
+				Updates(map[string]any{"status": models.InboundWebhookFailed, "error": "invalid payload: " + err.Error()})
+			continue
+		}
+		if !a.claimWebhookEvent(event.ID, event.Status, &event.UpdatedAt) {
+			continue
+		}
+
+		// Run as a replay: the crashed run may already have claimed the
+		// event's messages and statuses, and those stored are skipped by
+		// the message ID checks
+		a.Log.Warn("Recovering unfinished webhook event", "event_id", event.ID, "received_at", event.ReceivedAt, "attempt", event.Attempts+1)
+		_ = a.runWebhookEvent(event.ID, &payload, true)
+		recovered++
+	}
+
+	a.DB.Unscoped().
+		Where("status = ? AND received_at < ?", models.InboundWebhookProcessed, now.Add(-webhookEventRetention)).
+		Delete(&models.InboundWebhookEvent{})
+
+	return recovered
+}
+
+// ReplayWebhookEvents re-runs every stored event received in [from, to), in
+// the order received. When orgID is set only events for that organization's
+// accounts are replayed. Messages already stored are skipped by the normal
+// duplicate checks, so replaying a range twice is safe.
+func (a *App) ReplayWebhookEvents(ctx context.Context, from, to time.Time, orgID *uuid.UUID) (WebhookReplayResult, error) {
+	var result WebhookReplayResult
+
+	// Page on (received_at, id) so events are replayed in the order received
+	var (
+		lastReceived time.Time
+		lastID       uuid.UUID
+		err          error
+	)
+	for {
+		var events []models.InboundWebhookEvent
+		q := a.webhookEventsQuery(from, to, orgID)
+		if lastID != uuid.Nil {
+			q = q.Where("(received_at, id) > (?, ?)", lastReceived, lastID)
+		}
+		if err = q.Order("received_at ASC, id ASC").Limit(100).Find(&events).Error; err != nil || len(events) == 0 {
+			break
+		}
+
+		for i := range events {
+			if err = ctx.Err(); err != nil {
+				break
+			}
+			event := &events[i]
+			lastReceived, lastID = event.ReceivedAt, event.ID
+
+			var payload WebhookPayload
+			if jsonErr := json.Unmarshal([]byte(event.Payload), &payload); jsonErr != nil {
+				a.Log.Error("Skipping webhook event with invalid payload", "error", jsonErr, "event_id", event.ID)
+				result.Failed++
+				continue
+			}
+			if runErr := a.runWebhookEvent(event.ID, &payload, true); runErr != nil {
+				result.Failed++
+				continue
+			}
+			result.Replayed++
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	a.Log.Info("Webhook events replayed", "from", from, "to", to, "replayed", result.Replayed, "failed", result.Failed)
+	return result, err
+}
+
+// webhookEventsQuery selects events received in [from, to), optionally
+// limited to the phone numbers and business accounts of one organization
+func (a *App) webhookEventsQuery(from, to time.Time, orgID *uuid.UUID) *gorm.DB {
+	q := a.DB.Model(&models.InboundWebhookEvent{}).Where("received_at >= ? AND received_at < ?", from, to)
+	if orgID != nil {
+		accounts := a.DB.Model(&models.WhatsAppAccount{}).Where("organization_id = ?", *orgID)
+		q = q.Where("phone_number_id IN (?) OR (phone_number_id = '' AND entry_id IN (?))",
+			accounts.Select("phone_id"), accounts.Session(&gorm.Session{}).Select("business_id"))
+	}
+	return q
+}
+
+// ReplayWebhookEventsHandler re-runs the organization's stored webhook events
+// for a time range. The replay runs in the background; the response reports
+// how many events matched.
+func (a *App) ReplayWebhookEventsHandler(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceAccounts, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req WebhookReplayRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if req.From.IsZero() || req.To.IsZero() || !req.To.After(req.From) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "from and to are required and to must be after from", nil, "")
+	}
+
+	var count int64
+	if err := a.webhookEventsQuery(req.From, req.To, &orgID).Count(&count).Error; err != nil {
+		a.Log.Error("Failed to count webhook events", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to replay webhook events", nil, "")
+	}
+
+	if count > 0 {
+		go func() {
+			if _, err := a.ReplayWebhookEvents(context.Background(), req.From, req.To, &orgID); err != nil {
+				a.Log.Error("Webhook replay failed", "error", err, "organization_id", orgID)
+			}
+		}()
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"status": "started",
+		"events": count,
+	})
+}
+
+// WebhookEventProcessor periodically recovers webhook events that were
+// stored but never finished
+type WebhookEventProcessor struct {
+	app      *App
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewWebhookEventProcessor creates a new webhook event processor
+func NewWebhookEventProcessor(app *App, interval time.Duration) *WebhookEventProcessor {
+	return &WebhookEventProcessor{
+		app:      app,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start runs the recovery loop until ctx is done or Stop is called
+func (p *WebhookEventProcessor) Start(ctx context.Context) {
+	p.app.Log.Info("Webhook event processor started", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			p.app.RecoverWebhookEvents(ctx)
+		}
+	}
+}
+
+// Stop stops the webhook event processor
+func (p *WebhookEventProcessor) Stop() {
+	close(p.stopCh)
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusWebhookBody builds a raw "messages" webhook carrying one status update.
+func statusWebhookBody(t *testing.T, businessID, phoneID, wamid, status string) (*WebhookPayload, []byte) {
+	t.Helper()
+	body := []byte(fmt.Sprintf(`{
+		"object": "whatsapp_business_account",
+		"entry": [{
+			"id": %q,
+			"changes": [{
+				"field": "messages",
+				"value": {
+					"messaging_product": "whatsapp",
+					"metadata": {"display_phone_number": "15550001111", "phone_number_id": %q},
+					"statuses": [{"id": %q, "status": %q, "timestamp": "1700000000", "recipient_id": "15551234567"}]
+				}
+			}]
+		}]
+	}`, businessID, phoneID, wamid, status))
+
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	return &payload, body
+}
+
+// webhookAccount returns the WhatsApp account created by webhookTestData.
+func webhookAccount(t *testing.T, app *App, orgID uuid.UUID) models.WhatsAppAccount {
+	t.Helper()
+	var account models.WhatsAppAccount
+	require.NoError(t, app.DB.Where("organization_id = ?", orgID).First(&account).Error)
+	return account
+}
+
+func TestStoreWebhookEvent_RecordsPayloadAndMetadata(t *testing.T) {
+	app := webhookTestApp(t)
+
+	payload, body := statusWebhookBody(t, "biz-store", "phone-store", "wamid.store", "delivered")
+	event, err := app.storeWebhookEvent(payload, body)
+	require.NoError(t, err)
+
+	var stored models.InboundWebhookEvent
+	require.NoError(t, app.DB.First(&stored, event.ID).Error)
+	assert.Equal(t, models.InboundWebhookPending, stored.Status)
+	assert.Equal(t, "whatsapp_business_account", stored.Object)
+	assert.Equal(t, "biz-store", stored.EntryID)
+	assert.Equal(t, "phone-store", stored.PhoneNumberID)
+	assert.Equal(t, "messages", stored.Fields)
+	assert.JSONEq(t, string(body), stored.Payload)
+}
+
+func TestProcessWebhookEvent_MarksProcessed(t *testing.T) {
+	app := webhookTestApp(t)
+	org, msg, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+
+	payload, body := statusWebhookBody(t, account.BusinessID, account.PhoneID, msg.WhatsAppMessageID, "delivered")
+	event, err := app.storeWebhookEvent(payload, body)
+	require.NoError(t, err)
+
+	app.processWebhookEvent(event.ID, payload)
+
+	var stored models.InboundWebhookEvent
+	require.NoError(t, app.DB.First(&stored, event.ID).Error)
+	assert.Equal(t, models.InboundWebhookProcessed, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.NotNil(t, stored.ProcessedAt)
+
+	var updated models.Message
+	require.NoError(t, app.DB.First(&updated, msg.ID).Error)
+	assert.Equal(t, models.MessageStatusDelivered, updated.Status)
+
+	// A second claim of the same event is refused
+	assert.False(t, app.claimWebhookEvent(event.ID, models.InboundWebhookPending, nil))
+}
+
+func TestRecoverWebhookEvents_RerunsStalePendingEvent(t *testing.T) {
+	app := webhookTestApp(t)
+	org, msg, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+
+	payload, body := statusWebhookBody(t, account.BusinessID, account.PhoneID, msg.WhatsAppMessageID, "delivered")
+	event, err := app.storeWebhookEvent(payload, body)
+	require.NoError(t, err)
+
+	// Simulate a crash between storing and processing
+	require.NoError(t, app.DB.Model(&models.InboundWebhookEvent{}).Where("id = ?", event.ID).
+		UpdateColumn("updated_at", time.Now().Add(-2*webhookEventPendingGrace)).Error)
+
+	assert.GreaterOrEqual(t, app.RecoverWebhookEvents(context.Background()), 1)
+
+	var stored models.InboundWebhookEvent
+	require.NoError(t, app.DB.First(&stored, event.ID).Error)
+	assert.Equal(t, models.InboundWebhookProcessed, stored.Status)
+
+	var updated models.Message
+	require.NoError(t, app.DB.First(&updated, msg.ID).Error)
+	assert.Equal(t, models.MessageStatusDelivered, updated.Status)
+}
+
+func TestRecoverWebhookEvents_RerunsClaimedItems(t *testing.T) {
+	app := webhookTestApp(t)
+	app.Redis = testutil.SetupTestRedis(t)
+	if app.Redis == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	org, msg, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+
+	// Blocked, so the chatbot stays out of it once the message is stored
+	var contact models.Contact
+	require.NoError(t, app.DB.Where("organization_id = ?", org.ID).First(&contact).Error)
+	require.NoError(t, app.DB.Model(&contact).Update("is_blocked", true).Error)
+
+	incomingID := "wamid.in-" + uuid.New().String()
+	body := []byte(fmt.Sprintf(`{
+		"object": "whatsapp_business_account",
+		"entry": [{
+			"id": %q,
+			"changes": [{
+				"field": "messages",
+				"value": {
+					"messaging_product": "whatsapp",
+					"metadata": {"display_phone_number": "15550001111", "phone_number_id": %q},
+					"contacts": [{"wa_id": %q, "profile": {"name": "Test User"}}],
+					"messages": [{"from": %q, "id": %q, "timestamp": "1700000000", "type": "text", "text": {"body": "hello"}}],
+					"statuses": [{"id": %q, "status": "delivered", "timestamp": "1700000000", "recipient_id": %q}]
+				}
+			}]
+		}]
+	}`, account.BusinessID, account.PhoneID, contact.PhoneNumber, contact.PhoneNumber, incomingID, msg.WhatsAppMessageID, contact.PhoneNumber))
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	event, err := app.storeWebhookEvent(&payload, body)
+	require.NoError(t, err)
+
+	// Simulate a crash after the message and status were claimed but before
+	// they were stored
+	require.True(t, app.claimWebhookItem("msg:"+incomingID))
+	require.True(t, app.claimWebhookItem("status:"+msg.WhatsAppMessageID+":delivered"))
+	require.NoError(t, app.DB.Model(&models.InboundWebhookEvent{}).Where("id = ?", event.ID).
+		UpdateColumns(map[string]any{
+			"status":     models.InboundWebhookProcessing,
+			"attempts":   1,
+			"updated_at": time.Now().Add(-2 * webhookEventProcessingTimeout),
+		}).Error)
+
+	assert.GreaterOrEqual(t, app.RecoverWebhookEvents(context.Background()), 1)
+
+	var stored models.InboundWebhookEvent
+	require.NoError(t, app.DB.First(&stored, event.ID).Error)
+	assert.Equal(t, models.InboundWebhookProcessed, stored.Status)
+
+	var incoming models.Message
+	require.NoError(t, app.DB.Where("whats_app_message_id = ?", incomingID).First(&incoming).Error)
+	assert.Equal(t, "hello", incoming.Content)
+
+	var updated models.Message
+	require.NoError(t, app.DB.First(&updated, msg.ID).Error)
+	assert.Equal(t, models.MessageStatusDelivered, updated.Status)
+}
+
+func TestRunWebhookEvent_ErrorLeavesEventPending(t *testing.T) {
+	app := webhookTestApp(t)
+	org, msg, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+
+	payload, body := statusWebhookBody(t, account.BusinessID, account.PhoneID, msg.WhatsAppMessageID, "delivered")
+	event, err := app.storeWebhookEvent(payload, body)
+	require.NoError(t, err)
+	require.True(t, app.claimWebhookEvent(event.ID, models.InboundWebhookPending, nil))
+
+	// A cancelled statement context makes the message lookup fail like a
+	// dropped connection would
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	db := app.DB
+	app.DB = db.WithContext(ctx)
+	runErr := app.runWebhookEvent(event.ID, payload, false)
+	app.DB = db
+	require.Error(t, runErr)
+
+	var stored models.InboundWebhookEvent
+	require.NoError(t, app.DB.First(&stored, event.ID).Error)
+	assert.NotEqual(t, models.InboundWebhookProcessed, stored.Status)
+}
+
+func TestRecoverWebhookEvents_GivesUpAfterMaxAttempts(t *testing.T) {
+	app := webhookTestApp(t)
+
+	payload, body := statusWebhookBody(t, "biz-stuck", "phone-stuck", "wamid.stuck", "delivered")
+	event, err := app.storeWebhookEvent(payload, body)
+	require.NoError(t, err)
+
+	require.NoError(t, app.DB.Model(&models.InboundWebhookEvent{}).Where("id = ?", event.ID).
+		UpdateColumns(map[string]any{
+			"status":     models.InboundWebhookProcessing,
+			"attempts":   webhookEventMaxAttempts,
+			"updated_at": time.Now().Add(-2 * webhookEventProcessingTimeout),
+		}).Error)
+
+	app.RecoverWebhookEvents(context.Background())
+
+	var stored models.InboundWebhookEvent
+	require.NoError(t, app.DB.First(&stored, event.ID).Error)
+	assert.Equal(t, models.InboundWebhookFailed, stored.Status)
+	assert.Contains(t, stored.Error, "attempts")
+}
+
+func TestReplayWebhookEvents_IsIdempotent(t *testing.T) {
+	app := webhookTestApp(t)
+	org, msg, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+
+	from := time.Now().Add(-time.Second)
+	payload, body := statusWebhookBody(t, account.BusinessID, account.PhoneID, msg.WhatsAppMessageID, "delivered")
+	event, err := app.storeWebhookEvent(payload, body)
+	require.NoError(t, err)
+	to := time.Now().Add(time.Second)
+
+	for range 2 {
+		result, err := app.ReplayWebhookEvents(context.Background(), from, to, &org.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Replayed)
+		assert.Zero(t, result.Failed)
+	}
+
+	var stored models.InboundWebhookEvent
+	require.NoError(t, app.DB.First(&stored, event.ID).Error)
+	assert.Equal(t, models.InboundWebhookProcessed, stored.Status)
+
+	// The delivered count moves once however often the event is replayed
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 1, updatedCampaign.DeliveredCount)
+}
+
+func TestReplayWebhookEvents_ScopedToOrganization(t *testing.T) {
+	app := webhookTestApp(t)
+	org, msg, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	otherOrg, _, _, _ := webhookTestData(t, app, models.MessageStatusSent)
+	account := webhookAccount(t, app, org.ID)
+
+	from := time.Now().Add(-time.Second)
+	payload, body := statusWebhookBody(t, account.BusinessID, account.PhoneID, msg.WhatsAppMessageID, "delivered")
+	_, err := app.storeWebhookEvent(payload, body)
+	require.NoError(t, err)
+	to := time.Now().Add(time.Second)
+
+	result, err := app.ReplayWebhookEvents(context.Background(), from, to, &otherOrg.ID)
+	require.NoError(t, err)
+	assert.Zero(t, result.Replayed)
+
+	var unchanged models.Message
+	require.NoError(t, app.DB.First(&unchanged, msg.ID).Error)
+	assert.Equal(t, models.MessageStatusSent, unchanged.Status)
+}
+
+func TestUpdateMessageStatus_RepeatedFailureCountedOnce(t *testing.T) {
+	app := webhookTestApp(t)
+	_, msg, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+
+	errors := []WebhookStatusError{{Code: 131026, Title: "Message undeliverable"}}
+	app.updateMessageStatus(msg.WhatsAppMessageID, "failed", errors)
+	app.updateMessageStatus(msg.WhatsAppMessageID, "failed", errors)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 1, updatedCampaign.FailedCount)
+}
@@ -114,6 +114,8 @@ var AvailableWebhookEvents = []map[string]string{
 	{"value": string(models.WebhookEventTransferCreated), "label": "Transfer Created", "description": "When a transfer to human agent is requested"},
 	{"value": string(models.WebhookEventTransferAssigned), "label": "Transfer Assigned", "description": "When a transfer is assigned to an agent"},
 	{"value": string(models.WebhookEventTransferResumed), "label": "Transfer Resumed", "description": "When chatbot is resumed (transfer closed)"},
+	{"value": string(models.WebhookEventOrderCreated), "label": "Order Created", "description": "When a customer submits an order from a catalog"},
+	{"value": string(models.WebhookEventAccountAlert), "label": "Account Alert", "description": "When Meta downgrades an account's quality rating or messaging tier, restricts it or raises an alert"},
 }
 
 // ListWebhooks returns all webhooks for the organization
@@ -119,11 +119,27 @@ type DataPoint struct {
 
 // Available data sources and their filterable fields
 var widgetDataSources = map[string][]string{
-	"messages":  {"status", "direction", "message_type", "whatsapp_account"},
-	"contacts":  {"whatsapp_account", "is_read"},
+	"messages":  {"status", "direction", "message_type", "whatsapp_account", "ad_id"},
+	"contacts":  {"whatsapp_account", "is_read", "ad_id"},
 	"campaigns": {"status", "message_status"},
-	"transfers": {"status", "source"},
+	"transfers": {"status", "source", "ad_id"},
 	"sessions":  {"status"},
+	"spend":     {"category", "whatsapp_account", "template_name", "country_code", "pricing_model"},
+	"orders":    {"status", "whatsapp_account", "currency", "ad_id"},
+}
+
+// widgetFieldColumns maps widget field names that differ from their database
+// column; fields not listed here are used as the column name
+var widgetFieldColumns = map[string]string{
+	"whatsapp_account": "whats_app_account",
+}
+
+// widgetColumn returns the database column for a widget field name
+func widgetColumn(field string) string {
+	if column, ok := widgetFieldColumns[field]; ok {
+		return column
+	}
+	return field
 }
 
 // Available metrics
@@ -820,6 +836,14 @@ func (a *App) executeWidgetQuery(orgID uuid.UUID, widget models.Widget, fromStr,
 	case "sessions":
 		currentValue = a.querySessions(orgID, widget.Metric, filters, periodStart, periodEnd)
 		previousValue = a.querySessions(orgID, widget.Metric, filters, previousPeriodStart, previousPeriodEnd)
+
+	case "spend":
+		currentValue = a.querySpend(orgID, widget.Metric, filters, periodStart, periodEnd)
+		previousValue = a.querySpend(orgID, widget.Metric, filters, previousPeriodStart, previousPeriodEnd)
+
+	case "orders":
+		currentValue = a.queryOrders(orgID, widget.Metric, filters, periodStart, periodEnd)
+		previousValue = a.queryOrders(orgID, widget.Metric, filters, previousPeriodStart, previousPeriodEnd)
 	}
 
 	response.Value = currentValue
@@ -937,6 +961,68 @@ func (a *App) querySessions(orgID uuid.UUID, _ string, filters []FilterInput, st
 	return float64(count)
 }
 
+// querySpend counts billable conversations, or sums or averages their cost
+func (a *App) querySpend(orgID uuid.UUID, metric string, filters []FilterInput, start, end time.Time) float64 {
+	query := a.DB.Model(&models.ConversationCost{}).Where("organization_id = ? AND started_at >= ? AND started_at <= ?", orgID, start, end)
+
+	for _, f := range filters {
+		query = applyFilter(query, f)
+	}
+
+	var result float64
+	switch metric {
+	case "count":
+		var count int64
+		query.Count(&count)
+		result = float64(count)
+	case "sum", "avg":
+		query.Select(widgetAggregateSQL("spend", metric)).Scan(&result)
+	}
+	return result
+}
+
+// queryOrders counts orders, or sums or averages their total in cents
+func (a *App) queryOrders(orgID uuid.UUID, metric string, filters []FilterInput, start, end time.Time) float64 {
+	query := a.DB.Model(&models.Order{}).Where("organization_id = ? AND created_at >= ? AND created_at <= ?", orgID, start, end)
+
+	for _, f := range filters {
+		query = applyFilter(query, f)
+	}
+
+	var result float64
+	switch metric {
+	case "count":
+		var count int64
+		query.Count(&count)
+		result = float64(count)
+	case "sum", "avg":
+		query.Select(widgetAggregateSQL("orders", metric)).Scan(&result)
+	}
+	return result
+}
+
+// widgetAggregateSQL returns the SQL aggregate a widget charts. Spend widgets
+// sum or average the cost and order widgets the order total; everything else
+// counts rows.
+func widgetAggregateSQL(dataSource, metric string) string {
+	column := ""
+	switch dataSource {
+	case "spend":
+		column = "cost"
+	case "orders":
+		column = "total_amount"
+	}
+	if column != "" {
+		switch metric {
+		case "sum":
+			return "COALESCE(SUM(" + column + "), 0)"
+		case "avg":
+			return "COALESCE(AVG(" + column + "), 0)"
+		}
+	}
+	return "COUNT(*)"
+}
+
 func (a *App) getChartData(orgID uuid.UUID, widget models.Widget, filters []FilterInput, start, end time.Time) []ChartPoint {
 	chartData := make([]ChartPoint, 0)
 
@@ -947,10 +1033,10 @@ func (a *App) getChartData(orgID uuid.UUID, widget models.Widget, filters []Filt
 
 	// Build raw query for daily aggregation
 	query := fmt.Sprintf(`
-		SELECT DATE_TRUNC('day', %s) as date, COUNT(*) as count
+		SELECT DATE_TRUNC('day', %s) as date, %s as count
 		FROM %s
 		WHERE organization_id = ? AND %s >= ? AND %s <= ?
-	`, dateField, tableName, dateField, dateField)
+	`, dateField, widgetAggregateSQL(widget.DataSource, widget.Metric), tableName, dateField, dateField)
 
 	args := []interface{}{orgID, start, end}
 	query, args = appendFilterSQL(query, args, filters)
@@ -959,7 +1045,7 @@ func (a *App) getChartData(orgID uuid.UUID, widget models.Widget, filters []Filt
 
 	type DailyCount struct {
 		Date  time.Time
-		Count int64
+		Count float64
 	}
 
 	var results []DailyCount
@@ -968,7 +1054,7 @@ func (a *App) getChartData(orgID uuid.UUID, widget models.Widget, filters []Filt
 	for _, r := range results {
 		chartData = append(chartData, ChartPoint{
 			Label: r.Date.Format("Jan 02"),
-			Value: float64(r.Count),
+			Value: r.Count,
 		})
 	}
 
@@ -988,6 +1074,10 @@ func resolveDataSourceTable(dataSource string) (tableName, dateField string, ok
 		return "agent_transfers", "transferred_at", true
 	case "sessions":
 		return "chatbot_sessions", "created_at", true
+	case "spend":
+		return "conversation_costs", "started_at", true
+	case "orders":
+		return "orders", "created_at", true
 	default:
 		return "", "", false
 	}
@@ -1023,26 +1113,29 @@ func (a *App) getGroupedData(orgID uuid.UUID, widget models.Widget, filters []Fi
 		"message_type": true, "assigned_user_id": true, "channel": true,
 		"is_active": true, "priority": true, "category": true,
 		"type": true, "action_type": true, "provider": true,
+		"whatsapp_account": true, "template_name": true, "country_code": true,
+		"pricing_model": true, "ad_id": true, "currency": true,
 	}
 	if !allowedGroupByFields[widget.GroupByField] {
 		a.Log.Error("Invalid GroupByField", "field", widget.GroupByField)
 		return dataPoints
 	}
+	groupColumn := widgetColumn(widget.GroupByField)
 
 	query := fmt.Sprintf(`
-		SELECT %s as label, COUNT(*) as value
+		SELECT %s as label, %s as value
 		FROM %s
 		WHERE organization_id = ? AND %s >= ? AND %s <= ?
-	`, widget.GroupByField, tableName, dateField, dateField)
+	`, groupColumn, widgetAggregateSQL(widget.DataSource, widget.Metric), tableName, dateField, dateField)
 
 	args := []interface{}{orgID, start, end}
 	query, args = appendFilterSQL(query, args, filters)
 
-	query += fmt.Sprintf(" GROUP BY %s ORDER BY value DESC", widget.GroupByField)
+	query += fmt.Sprintf(" GROUP BY %s ORDER BY value DESC", groupColumn)
 
 	type GroupedCount struct {
 		Label string
-		Value int64
+		Value float64
 	}
 
 	var results []GroupedCount
@@ -1055,7 +1148,7 @@ func (a *App) getGroupedData(orgID uuid.UUID, widget models.Widget, filters []Fi
 		}
 		dataPoints = append(dataPoints, DataPoint{
 			Label: label,
-			Value: float64(r.Value),
+			Value: r.Value,
 		})
 	}
 
@@ -1111,22 +1204,23 @@ func (a *App) getGroupedTimeSeriesData(orgID uuid.UUID, widget models.Widget, fi
 	if !ok {
 		return result
 	}
+	groupColumn := widgetColumn(widget.GroupByField)
 
 	query := fmt.Sprintf(`
-		SELECT DATE_TRUNC('day', %s) as date, %s as group_value, COUNT(*) as count
+		SELECT DATE_TRUNC('day', %s) as date, %s as group_value, %s as count
 		FROM %s
 		WHERE organization_id = ? AND %s >= ? AND %s <= ?
-	`, dateField, widget.GroupByField, tableName, dateField, dateField)
+	`, dateField, groupColumn, widgetAggregateSQL(widget.DataSource, widget.Metric), tableName, dateField, dateField)
 
 	args := []interface{}{orgID, start, end}
 	query, args = appendFilterSQL(query, args, filters)
 
-	query += fmt.Sprintf(" GROUP BY DATE_TRUNC('day', %s), %s ORDER BY date ASC", dateField, widget.GroupByField)
+	query += fmt.Sprintf(" GROUP BY DATE_TRUNC('day', %s), %s ORDER BY date ASC", dateField, groupColumn)
 
 	type GroupedRow struct {
 		Date       time.Time
 		GroupValue string
-		Count      int64
+		Count      float64
 	}
 
 	var rows []GroupedRow
@@ -1167,7 +1261,7 @@ func (a *App) getGroupedTimeSeriesData(orgID uuid.UUID, widget models.Widget, fi
 		if lookup[gv] == nil {
 			lookup[gv] = make(map[string]float64)
 		}
-		lookup[gv][dateLabel] = float64(row.Count)
+		lookup[gv][dateLabel] = row.Count
 	}
 
 	// Build datasets
@@ -1249,7 +1343,7 @@ func applyFilter(query *gorm.DB, filter FilterInput) *gorm.DB {
 }
 
 func buildFilterSQL(filter FilterInput) (string, interface{}) {
-	field := filter.Field
+	field := widgetColumn(filter.Field)
 	value := filter.Value
 
 	switch filter.Operator {
@@ -1272,14 +1366,21 @@ func buildFilterSQL(filter FilterInput) (string, interface{}) {
 	}
 }
 
+// contactLabelSQL selects the name of the contact a row belongs to. It is a
+// subquery rather than a join so that filters on columns both tables have,
+// like whatsapp_account or ad_id, stay unambiguous.
+func contactLabelSQL(alias string) string {
+	return "(SELECT COALESCE(c.profile_name, c.phone_number) FROM contacts c WHERE c.id = " + alias + ".contact_id)"
+}
+
 // tableQuerySQL maps each data source to its SELECT + WHERE clause and ORDER BY suffix.
 // Each query must select: id, label, sub_label, status, direction, created_at
 // and use positional args: $1=orgID, $2=start, $3=end.
 var tableQuerySQL = map[string]struct{ base, orderBy string }{
 	"messages": {
-		base: `SELECT m.id, COALESCE(c.profile_name, c.phone_number) as label,
+		base: `SELECT m.id, ` + contactLabelSQL("m") + ` as label,
 			LEFT(m.content, 80) as sub_label, m.status, m.direction, m.created_at
-			FROM messages m LEFT JOIN contacts c ON c.id = m.contact_id
+			FROM messages m
 			WHERE m.organization_id = ? AND m.created_at >= ? AND m.created_at <= ?`,
 		orderBy: " ORDER BY m.created_at DESC LIMIT 10",
 	},
@@ -1297,9 +1398,9 @@ var tableQuerySQL = map[string]struct{ base, orderBy string }{
 		orderBy: " ORDER BY created_at DESC LIMIT 10",
 	},
 	"transfers": {
-		base: `SELECT t.id, COALESCE(c.profile_name, c.phone_number) as label,
+		base: `SELECT t.id, ` + contactLabelSQL("t") + ` as label,
 			t.source as sub_label, t.status, '' as direction, t.transferred_at as created_at
-			FROM agent_transfers t LEFT JOIN contacts c ON c.id = t.contact_id
+			FROM agent_transfers t
 			WHERE t.organization_id = ? AND t.transferred_at >= ? AND t.transferred_at <= ?`,
 		orderBy: " ORDER BY t.transferred_at DESC LIMIT 10",
 	},
@@ -1310,6 +1411,22 @@ var tableQuerySQL = map[string]struct{ base, orderBy string }{
 			WHERE s.organization_id = ? AND s.created_at >= ? AND s.created_at <= ?`,
 		orderBy: " ORDER BY s.created_at DESC LIMIT 10",
 	},
+	"spend": {
+		base: `SELECT id, COALESCE(NULLIF(template_name, ''), category) as label,
+			TRIM(TO_CHAR(cost, 'FM999999990.0000')) || ' ' || currency as sub_label,
+			category as status, '' as direction, started_at as created_at
+			FROM conversation_costs
+			WHERE organization_id = ? AND started_at >= ? AND started_at <= ?`,
+		orderBy: " ORDER BY started_at DESC LIMIT 10",
+	},
+	"orders": {
+		base: `SELECT o.id, ` + contactLabelSQL("o") + ` as label,
+			TRIM(TO_CHAR(o.total_amount / 100.0, 'FM999999990.00')) || ' ' || o.currency as sub_label,
+			o.status, '' as direction, o.created_at
+			FROM orders o
+			WHERE o.organization_id = ? AND o.created_at >= ? AND o.created_at <= ?`,
+		orderBy: " ORDER BY o.created_at DESC LIMIT 10",
+	},
 }
 
 // getTableRows returns the last 10 rows for a table widget based on the data source.
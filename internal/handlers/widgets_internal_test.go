@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFilterSQL_MapsWidgetFieldToColumn(t *testing.T) {
+	t.Parallel()
+
+	condition, value := buildFilterSQL(FilterInput{Field: "whatsapp_account", Operator: "equals", Value: "main"})
+	assert.Equal(t, "whats_app_account = ?", condition)
+	assert.Equal(t, "main", value)
+
+	condition, _ = buildFilterSQL(FilterInput{Field: "template_name", Operator: "contains", Value: "promo"})
+	assert.Equal(t, "template_name ILIKE ?", condition)
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// AccountHealthEvent records a change in a WhatsApp account's standing with
+// Meta: a quality rating or messaging tier move, an account status change
+// such as a restriction or ban, or an alert raised against the business.
+type AccountHealthEvent struct {
+	BaseModel
+	OrganizationID  uuid.UUID              `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WhatsAppAccount string                 `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	Kind            AccountHealthEventKind `gorm:"size:20;not null" json:"kind"`
+	Source          string                 `gorm:"size:20" json:"source"` // webhook, poll, send or api
+	Event           string                 `gorm:"size:100" json:"event"` // Meta's event name, e.g. FLAGGED or ACCOUNT_RESTRICTION
+	OldValue        string                 `gorm:"size:50" json:"old_value"`
+	NewValue        string                 `gorm:"size:50" json:"new_value"`
+	Severity        string                 `gorm:"size:20" json:"severity"` // info, warning or critical
+	Description     string                 `gorm:"type:text" json:"description"`
+	Downgrade       bool                   `gorm:"default:false" json:"downgrade"`
+	Details         JSONB                  `gorm:"type:jsonb;default:'{}'" json:"details,omitempty"`
+
+	// Relations
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+}
+
+func (AccountHealthEvent) TableName() string {
+	return "account_health_events"
+}
@@ -28,10 +28,41 @@ type BulkMessageCampaign struct {
 	CompletedAt     *time.Time `json:"completed_at,omitempty"`
 	CreatedBy       uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
 
+	// Sender pool: when set, each recipient is sent from one of these accounts
+	// (WhatsAppAccount included) instead of WhatsAppAccount alone
+	SenderAccounts StringArray    `gorm:"type:jsonb" json:"sender_accounts,omitempty"`
+	SenderStrategy SenderStrategy `gorm:"size:20" json:"sender_strategy,omitempty"`
+
+	// Audience: when set, the segment's contacts are added as recipients when
+	// the campaign starts. SegmentParams maps template parameters to contact
+	// fields: "profile_name", "phone_number" or "metadata.<key>".
+	SegmentID     *uuid.UUID `gorm:"type:uuid" json:"segment_id,omitempty"`
+	SegmentParams JSONB      `gorm:"type:jsonb" json:"segment_params,omitempty"`
+
+	// A/B test: with winner mode on, only WinnerTestPercent of recipients get
+	// the variants; the rest are held until WinnerAfterHours after the start
+	// and then sent the variant that did best on WinnerMetric
+	WinnerAfterHours  int           `gorm:"default:0" json:"winner_after_hours"`
+	WinnerTestPercent int           `gorm:"default:0" json:"winner_test_percent"`
+	WinnerMetric      VariantMetric `gorm:"size:20" json:"winner_metric,omitempty"`
+	WinnerVariantID   *uuid.UUID    `gorm:"type:uuid" json:"winner_variant_id,omitempty"`
+
+	// Frequency cap: at most FrequencyCapMax marketing templates per contact
+	// in FrequencyCapWindowHours, on top of the organization's own cap.
+	// Recipients over either cap are skipped, or deferred until it frees up.
+	FrequencyCapMax         int                `gorm:"default:0" json:"frequency_cap_max"`
+	FrequencyCapWindowHours int                `gorm:"default:0" json:"frequency_cap_window_hours"`
+	FrequencyCapAction      FrequencyCapAction `gorm:"size:10" json:"frequency_cap_action,omitempty"`
+
+	// Set on campaigns created as a run of a recurring campaign
+	RecurringCampaignID *uuid.UUID `gorm:"type:uuid;index" json:"recurring_campaign_id,omitempty"`
+
 	// Relations
 	Organization *Organization          `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
 	Template     *Template              `gorm:"foreignKey:TemplateID" json:"template,omitempty"`
 	Creator      *User                  `gorm:"foreignKey:CreatedBy" json:"creator,omitempty"`
+	Segment      *ContactSegment        `gorm:"foreignKey:SegmentID" json:"segment,omitempty"`
+	Variants     []CampaignVariant      `gorm:"foreignKey:CampaignID" json:"variants,omitempty"`
 	Recipients   []BulkMessageRecipient `gorm:"foreignKey:CampaignID" json:"recipients,omitempty"`
 }
 
@@ -39,6 +70,27 @@ func (BulkMessageCampaign) TableName() string {
 	return "bulk_message_campaigns"
 }
 
+// CampaignVariant is one template variant of an A/B tested campaign.
+// Recipients are split between a campaign's variants by weight.
+type CampaignVariant struct {
+	BaseModel
+	CampaignID           uuid.UUID `gorm:"type:uuid;index;not null" json:"campaign_id"`
+	Name                 string    `gorm:"size:50;not null" json:"name"` // A, B, C...
+	TemplateID           uuid.UUID `gorm:"type:uuid;not null" json:"template_id"`
+	Weight               int       `gorm:"default:1" json:"weight"`
+	HeaderMediaID        string    `gorm:"type:text" json:"header_media_id"`
+	HeaderMediaFilename  string    `gorm:"type:text" json:"header_media_filename"`
+	HeaderMediaMimeType  string    `gorm:"type:text" json:"header_media_mime_type"`
+	HeaderMediaLocalPath string    `gorm:"type:text" json:"header_media_local_path"`
+
+	// Relations
+	Template *Template `gorm:"foreignKey:TemplateID" json:"template,omitempty"`
+}
+
+func (CampaignVariant) TableName() string {
+	return "campaign_variants"
+}
+
 // BulkMessageRecipient represents a recipient in a bulk message campaign
 type BulkMessageRecipient struct {
 	BaseModel
@@ -53,6 +105,8 @@ type BulkMessageRecipient struct {
 	SentAt             *time.Time `json:"sent_at,omitempty"`
 	DeliveredAt        *time.Time `json:"delivered_at,omitempty"`
 	ReadAt             *time.Time `json:"read_at,omitempty"`
+	WhatsAppAccount    string     `gorm:"size:100" json:"whatsapp_account,omitempty"` // Account the message was sent from
+	VariantID          *uuid.UUID `gorm:"type:uuid;index" json:"variant_id,omitempty"`  // A/B variant the recipient was sent
 
 	// Relations
 	Campaign *BulkMessageCampaign `gorm:"foreignKey:CampaignID" json:"campaign,omitempty"`
@@ -70,8 +124,9 @@ type NotificationRule struct {
 	WhatsAppAccount  string    `gorm:"size:100;index;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
 	Name             string    `gorm:"size:255;not null" json:"name"`
 	IsEnabled        bool      `gorm:"default:true" json:"is_enabled"`
-	TriggerType      string    `gorm:"size:50;not null" json:"trigger_type"` // webhook, scheduler, api
+	TriggerType      string    `gorm:"size:50;not null" json:"trigger_type"` // webhook
 	TriggerConfig    JSONB     `gorm:"type:jsonb;not null" json:"trigger_config"`
+	TriggerToken     string    `gorm:"size:64" json:"-"` // Secret token for the inbound trigger URL
 	TemplateID       uuid.UUID `gorm:"type:uuid;not null" json:"template_id"`
 	FieldMappings    JSONB     `gorm:"type:jsonb;default:'{}'" json:"field_mappings"`
 	Conditions       JSONB     `gorm:"type:jsonb;default:'{}'" json:"conditions"`
@@ -85,3 +140,25 @@ type NotificationRule struct {
 func (NotificationRule) TableName() string {
 	return "notification_rules"
 }
+
+// NotificationRuleLog records a single invocation of a notification rule
+type NotificationRuleLog struct {
+	BaseModel
+	OrganizationID uuid.UUID             `gorm:"type:uuid;index;not null" json:"organization_id"`
+	RuleID         uuid.UUID             `gorm:"type:uuid;index;not null" json:"rule_id"`
+	Status         NotificationLogStatus `gorm:"size:20;not null" json:"status"` // sent, skipped, failed, suppressed
+	Payload        JSONB                 `gorm:"type:jsonb" json:"payload"`
+	PhoneNumber    string                `gorm:"size:50" json:"phone_number"`
+	TemplateParams JSONB                 `gorm:"type:jsonb" json:"template_params"`
+	ContactID      *uuid.UUID            `gorm:"type:uuid" json:"contact_id,omitempty"`
+	MessageID      *uuid.UUID            `gorm:"type:uuid" json:"message_id,omitempty"`
+	ErrorMessage   string                `gorm:"type:text" json:"error_message,omitempty"`
+	RemoteAddr     string                `gorm:"size:100" json:"remote_addr"`
+
+	// Relations
+	Rule *NotificationRule `gorm:"foreignKey:RuleID" json:"rule,omitempty"`
+}
+
+func (NotificationRuleLog) TableName() string {
+	return "notification_rule_logs"
+}
@@ -129,6 +129,7 @@ type KeywordRule struct {
 	Conditions      string      `gorm:"type:text" json:"conditions"`
 	ActiveFrom      *time.Time  `json:"active_from,omitempty"`
 	ActiveUntil     *time.Time  `json:"active_until,omitempty"`
+	AdIDs           StringArray `gorm:"type:jsonb" json:"ad_ids"` // Only match messages from these click-to-WhatsApp ads
 
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -148,6 +149,7 @@ type ChatbotFlow struct {
 	Description        string      `gorm:"type:text" json:"description"`
 	TriggerKeywords    StringArray `gorm:"type:jsonb" json:"trigger_keywords"`
 	TriggerButtonID    string      `gorm:"size:100" json:"trigger_button_id"`
+	TriggerAdIDs       StringArray `gorm:"type:jsonb" json:"trigger_ad_ids"` // Start on messages from these click-to-WhatsApp ads
 	InitialMessage     string       `gorm:"type:text" json:"initial_message"`
 	InitialMessageType FlowStepType `gorm:"size:20;default:'text'" json:"initial_message_type"`
 	InitialTemplateID  *uuid.UUID  `gorm:"type:uuid" json:"initial_template_id,omitempty"`
@@ -294,6 +296,7 @@ type AgentTransfer struct {
 	TransferredAt       time.Time  `gorm:"autoCreateTime" json:"transferred_at"`
 	ResumedAt           *time.Time `json:"resumed_at,omitempty"`
 	ResumedBy           *uuid.UUID `gorm:"type:uuid" json:"resumed_by,omitempty"`
+	AdID                string     `gorm:"size:100;index" json:"ad_id,omitempty"` // The contact's first-touch ad
 
 	// SLA Tracking (embedded - all fields stored in same table)
 	SLA SLATracking `gorm:"embedded"`
@@ -30,7 +30,9 @@ const (
 	MessageTypeFlow        MessageType = "flow"
 	MessageTypeReaction    MessageType = "reaction"
 	MessageTypeLocation    MessageType = "location"
-	MessageTypeContact     MessageType = "contact"
+	MessageTypeContact     MessageType = "contacts" // Meta's type name, as stored for incoming cards
+	MessageTypeSticker     MessageType = "sticker"
+	MessageTypeOrder       MessageType = "order"
 )
 
 // MessageStatus represents the delivery status of a message
@@ -43,6 +45,21 @@ const (
 	MessageStatusRead      MessageStatus = "read"
 	MessageStatusFailed    MessageStatus = "failed"
 	MessageStatusReceived  MessageStatus = "received"
+
+	// MessageStatusSuppressed marks campaign recipients skipped because they opted out
+	MessageStatusSuppressed MessageStatus = "suppressed"
+	// MessageStatusCapped marks campaign recipients skipped by a frequency cap
+	MessageStatusCapped MessageStatus = "capped"
+	// MessageStatusBlocked marks campaign recipients skipped because the contact is blocked
+	MessageStatusBlocked MessageStatus = "blocked"
+)
+
+// FrequencyCapAction is what a campaign does with recipients over a frequency cap
+type FrequencyCapAction string
+
+const (
+	FrequencyCapActionSkip  FrequencyCapAction = "skip"  // Mark the recipient capped
+	FrequencyCapActionDefer FrequencyCapAction = "defer" // Send once the cap frees up
 )
 
 // AIProvider represents supported AI providers
@@ -87,6 +104,7 @@ const (
 	FlowStepTypeButtons      FlowStepType = "buttons"
 	FlowStepTypeTransfer     FlowStepType = "transfer"
 	FlowStepTypeWhatsAppFlow FlowStepType = "whatsapp_flow"
+	FlowStepTypeProduct      FlowStepType = "product"
 )
 
 // SessionStatus represents chatbot session states
@@ -132,6 +150,121 @@ const (
 	CampaignStatusFailed     CampaignStatus = "failed"
 )
 
+// SenderStrategy represents how a campaign spreads recipients over its sender pool
+type SenderStrategy string
+
+const (
+	SenderStrategyRoundRobin SenderStrategy = "round_robin"
+	SenderStrategyWeighted   SenderStrategy = "weighted" // By messaging limit tier
+	SenderStrategySticky     SenderStrategy = "sticky"   // A contact always hears from the same number
+)
+
+// VariantMetric represents the rate an A/B tested campaign picks its winner by
+type VariantMetric string
+
+const (
+	VariantMetricDelivered VariantMetric = "delivered"
+	VariantMetricRead      VariantMetric = "read"
+	VariantMetricReplied   VariantMetric = "replied"
+	VariantMetricClicked   VariantMetric = "clicked" // Quick reply button taps
+)
+
+// RecurringCampaignStatus represents whether a recurring campaign creates runs
+type RecurringCampaignStatus string
+
+const (
+	RecurringCampaignStatusActive RecurringCampaignStatus = "active"
+	RecurringCampaignStatusPaused RecurringCampaignStatus = "paused"
+)
+
+// AccountStatus represents a WhatsApp account's standing with Meta
+type AccountStatus string
+
+const (
+	AccountStatusActive     AccountStatus = "active"
+	AccountStatusRestricted AccountStatus = "restricted"  // Messaging limited by Meta, e.g. after a policy violation
+	AccountStatusDisabled   AccountStatus = "disabled"    // Business account banned
+	AccountStatusAuthFailed AccountStatus = "auth_failed" // Access token expired, revoked or lacks permissions
+)
+
+// QualityRating represents Meta's quality rating of a business phone number
+type QualityRating string
+
+const (
+	QualityRatingGreen   QualityRating = "GREEN"
+	QualityRatingYellow  QualityRating = "YELLOW"
+	QualityRatingRed     QualityRating = "RED"
+	QualityRatingUnknown QualityRating = "UNKNOWN"
+)
+
+// AccountHealthEventKind represents what changed in an account health event
+type AccountHealthEventKind string
+
+const (
+	AccountHealthQuality AccountHealthEventKind = "quality"
+	AccountHealthTier    AccountHealthEventKind = "tier"
+	AccountHealthStatus  AccountHealthEventKind = "status"
+	AccountHealthAlert   AccountHealthEventKind = "alert"
+	AccountHealthToken   AccountHealthEventKind = "token" // Access token rotated or about to expire
+)
+
+// InboundWebhookStatus represents the processing state of a stored Meta webhook
+type InboundWebhookStatus string
+
+const (
+	InboundWebhookPending    InboundWebhookStatus = "pending"
+	InboundWebhookProcessing InboundWebhookStatus = "processing"
+	InboundWebhookProcessed  InboundWebhookStatus = "processed"
+	InboundWebhookFailed     InboundWebhookStatus = "failed"
+)
+
+// OrderStatus represents the lifecycle of a customer order
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusConfirmed OrderStatus = "confirmed"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// NotificationLogStatus represents the outcome of a notification rule invocation
+type NotificationLogStatus string
+
+const (
+	NotificationLogStatusSent    NotificationLogStatus = "sent"
+	NotificationLogStatusSkipped NotificationLogStatus = "skipped" // Conditions did not match
+	NotificationLogStatusFailed  NotificationLogStatus = "failed"
+
+	NotificationLogStatusSuppressed NotificationLogStatus = "suppressed" // Recipient opted out
+)
+
+// ScheduledMessageStatus represents the states of a send-later chat message
+type ScheduledMessageStatus string
+
+const (
+	ScheduledMessageStatusPending      ScheduledMessageStatus = "pending"
+	ScheduledMessageStatusSending      ScheduledMessageStatus = "sending" // Claimed by the dispatcher
+	ScheduledMessageStatusSent         ScheduledMessageStatus = "sent"
+	ScheduledMessageStatusFailed       ScheduledMessageStatus = "failed"
+	ScheduledMessageStatusWindowClosed ScheduledMessageStatus = "window_closed" // 24h window closed and no fallback template
+	ScheduledMessageStatusCancelled    ScheduledMessageStatus = "cancelled"
+	ScheduledMessageStatusSuppressed   ScheduledMessageStatus = "suppressed" // Contact opted out
+)
+
+// SuppressionSource records how a number got onto the suppression list
+type SuppressionSource string
+
+const (
+	SuppressionSourceKeyword SuppressionSource = "keyword" // Customer replied with an opt-out keyword
+	SuppressionSourceManual  SuppressionSource = "manual"
+	SuppressionSourceImport  SuppressionSource = "import"
+)
+
+// OptInSourceKeyword is Contact.OptInSource for customers who replied with an opt-in keyword
+const OptInSourceKeyword = "keyword"
+
 // TemplateStatus represents WhatsApp template approval states
 type TemplateStatus string
 
@@ -204,6 +337,8 @@ const (
 	WebhookEventTransferCreated  WebhookEvent = "transfer.created"
 	WebhookEventTransferResumed  WebhookEvent = "transfer.resumed"
 	WebhookEventTransferAssigned WebhookEvent = "transfer.assigned"
+	WebhookEventOrderCreated     WebhookEvent = "order.created"
+	WebhookEventAccountAlert     WebhookEvent = "account.alert"
 )
 
 // ActionType represents custom action types
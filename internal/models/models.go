@@ -285,19 +285,33 @@ func (CustomAction) TableName() string {
 // WhatsAppAccount represents a WhatsApp Business Account
 type WhatsAppAccount struct {
 	BaseModel
-	OrganizationID     uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
-	Name               string    `gorm:"size:100;uniqueIndex:idx_wa_org_name;not null" json:"name"` // Unique per org, used as reference
-	AppID              string    `gorm:"size:100" json:"app_id"`                                    // Meta App ID
-	PhoneID            string    `gorm:"size:100;not null" json:"phone_id"`
-	BusinessID         string    `gorm:"size:100;not null" json:"business_id"`
-	AccessToken        string    `gorm:"type:text;not null" json:"-"` // encrypted
-	AppSecret          string    `gorm:"size:255" json:"-"`           // Meta App Secret for webhook signature verification
-	WebhookVerifyToken string    `gorm:"size:255" json:"webhook_verify_token"`
-	APIVersion         string    `gorm:"size:20;default:'v21.0'" json:"api_version"`
-	IsDefaultIncoming  bool      `gorm:"default:false" json:"is_default_incoming"`
-	IsDefaultOutgoing  bool      `gorm:"default:false" json:"is_default_outgoing"`
-	AutoReadReceipt    bool      `gorm:"default:false" json:"auto_read_receipt"`
-	Status             string    `gorm:"size:20;default:'active'" json:"status"`
+	OrganizationID     uuid.UUID     `gorm:"type:uuid;index;not null" json:"organization_id"`
+	Name               string        `gorm:"size:100;uniqueIndex:idx_wa_org_name;not null" json:"name"` // Unique per org, used as reference
+	AppID              string        `gorm:"size:100" json:"app_id"`                                    // Meta App ID
+	PhoneID            string        `gorm:"size:100;not null" json:"phone_id"`
+	BusinessID         string        `gorm:"size:100;not null" json:"business_id"`
+	AccessToken        string        `gorm:"type:text;not null" json:"-"` // encrypted
+	AppSecret          string        `gorm:"size:255" json:"-"`           // Meta App Secret for webhook signature verification
+	WebhookVerifyToken string        `gorm:"size:255" json:"webhook_verify_token"`
+	APIVersion         string        `gorm:"size:20;default:'v21.0'" json:"api_version"`
+	IsDefaultIncoming  bool          `gorm:"default:false" json:"is_default_incoming"`
+	IsDefaultOutgoing  bool          `gorm:"default:false" json:"is_default_outgoing"`
+	AutoReadReceipt    bool          `gorm:"default:false" json:"auto_read_receipt"`
+	SendRateLimit      int           `gorm:"default:0" json:"send_rate_limit"`         // Messages per second, 0 = server default
+	AutoPauseCampaigns bool          `gorm:"default:true" json:"auto_pause_campaigns"` // Pause running campaigns when quality drops to RED
+	Status             AccountStatus `gorm:"size:20;default:'active'" json:"status"`
+
+	// Health as last reported by Meta; AccountHealthEvent keeps the history
+	DisplayPhoneNumber string        `gorm:"size:50" json:"display_phone_number"`
+	QualityRating      QualityRating `gorm:"size:20" json:"quality_rating"`
+	MessagingLimitTier string        `gorm:"size:30" json:"messaging_limit_tier"` // e.g. TIER_1K, TIER_UNLIMITED
+	HealthCheckedAt    *time.Time    `json:"health_checked_at,omitempty"`
+
+	// Access token as last inspected through debug_token
+	TokenType      string     `gorm:"size:30" json:"token_type"`  // SYSTEM_USER, USER, ...
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"` // Nil for tokens that never expire
+	TokenCheckedAt *time.Time `json:"token_checked_at,omitempty"`
+	TokenError     string     `gorm:"type:text" json:"token_error,omitempty"` // Why the token was last rejected
 
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -322,6 +336,24 @@ type Contact struct {
 	Metadata           JSONB      `gorm:"type:jsonb;default:'{}'" json:"metadata"`
 	LastInboundAt      *time.Time `json:"last_inbound_at,omitempty"` // When customer last sent a message (for 24h window tracking)
 
+	// Blocking via the Cloud API block_users endpoint
+	IsBlocked     bool       `gorm:"default:false;index" json:"is_blocked"`
+	BlockedReason string     `gorm:"type:text" json:"blocked_reason,omitempty"`
+	BlockedAt     *time.Time `json:"blocked_at,omitempty"`
+	BlockedByID   *uuid.UUID `gorm:"type:uuid" json:"blocked_by_id,omitempty"`
+
+	// First-touch referral, from the first click-to-WhatsApp ad or post the
+	// contact messaged from. AdID is empty for post referrals.
+	AdID       string     `gorm:"size:100;index" json:"ad_id,omitempty"`
+	Referral   JSONB      `gorm:"type:jsonb" json:"referral,omitempty"`
+	ReferredAt *time.Time `json:"referred_at,omitempty"`
+
+	// Where and when the contact last opted in to messages, e.g. "keyword"
+	// when they replied with an opt-in keyword. Opt-outs live on the
+	// organization's suppression list.
+	OptInSource string     `gorm:"size:100" json:"opt_in_source,omitempty"`
+	OptedInAt   *time.Time `json:"opted_in_at,omitempty"`
+
 	// Chatbot SLA tracking
 	ChatbotLastMessageAt *time.Time `json:"chatbot_last_message_at,omitempty"` // When chatbot last sent a message
 	ChatbotReminderSent  bool       `gorm:"default:false" json:"chatbot_reminder_sent"`
@@ -344,6 +376,9 @@ type Message struct {
 	ContactID         uuid.UUID  `gorm:"type:uuid;index;not null" json:"contact_id"`
 	WhatsAppMessageID string     `gorm:"column:whats_app_message_id;size:255;index" json:"whatsapp_message_id"`
 	ConversationID    string     `gorm:"size:255;index" json:"conversation_id"`
+	PricingCategory   string     `gorm:"size:50" json:"pricing_category"` // From status webhooks, e.g. "marketing"
+	PricingModel      string     `gorm:"size:20" json:"pricing_model"`    // CBP or PMP
+	Billable          bool       `gorm:"default:false" json:"billable"`
 	Direction         Direction   `gorm:"size:10;not null" json:"direction"`
 	MessageType       MessageType `gorm:"size:20;not null" json:"message_type"`
 	Content           string     `gorm:"type:text" json:"content"`
@@ -360,6 +395,9 @@ type Message struct {
 	ReplyToMessageID  *uuid.UUID `gorm:"type:uuid" json:"reply_to_message_id,omitempty"`
 	SentByUserID      *uuid.UUID `gorm:"type:uuid;index" json:"sent_by_user_id,omitempty"` // User who sent outgoing message
 	Metadata          JSONB      `gorm:"type:jsonb;default:'{}'" json:"metadata"`
+	AdID              string     `gorm:"size:100;index" json:"ad_id,omitempty"` // Click-to-WhatsApp ad the message came from
+	Referral          JSONB      `gorm:"type:jsonb" json:"referral,omitempty"`  // Meta's referral object, as received
+	Context           JSONB      `gorm:"type:jsonb" json:"context,omitempty"`   // Meta's context of an incoming reply or forward
 
 	// Relations
 	Organization   *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
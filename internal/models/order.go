@@ -0,0 +1,49 @@
+package models
+
+import "github.com/google/uuid"
+
+// Order represents a cart submitted by a customer from a WhatsApp catalog
+type Order struct {
+	BaseModel
+	OrganizationID    uuid.UUID   `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WhatsAppAccount   string      `gorm:"size:100;index" json:"whatsapp_account"` // Links to WhatsAppAccount.Name
+	ContactID         uuid.UUID   `gorm:"type:uuid;index;not null" json:"contact_id"`
+	CatalogID         *uuid.UUID  `gorm:"type:uuid;index" json:"catalog_id,omitempty"` // Nil if the catalog is not synced locally
+	MetaCatalogID     string      `gorm:"size:100" json:"meta_catalog_id"`
+	WhatsAppMessageID string      `gorm:"size:255;uniqueIndex" json:"whatsapp_message_id"` // The incoming "order" message
+	Status            OrderStatus `gorm:"size:20;default:'pending';index" json:"status"`
+	Note              string      `gorm:"type:text" json:"note"`        // Text the customer sent with the cart
+	TotalAmount       int64       `gorm:"not null" json:"total_amount"` // Sum of item totals in cents
+	Currency          string      `gorm:"size:3" json:"currency"`
+	AdID              string      `gorm:"size:100;index" json:"ad_id,omitempty"` // The contact's first-touch ad
+
+	// Relations
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	Contact      *Contact      `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+	Catalog      *Catalog      `gorm:"foreignKey:CatalogID" json:"catalog,omitempty"`
+	Items        []OrderItem   `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+}
+
+func (Order) TableName() string {
+	return "orders"
+}
+
+// OrderItem represents a product line in an order
+type OrderItem struct {
+	BaseModel
+	OrderID           uuid.UUID  `gorm:"type:uuid;index;not null" json:"order_id"`
+	ProductID         *uuid.UUID `gorm:"type:uuid;index" json:"product_id,omitempty"` // Nil if the product is not synced locally
+	ProductRetailerID string     `gorm:"size:100;not null" json:"product_retailer_id"`
+	Name              string     `gorm:"size:255" json:"name"`
+	Quantity          int        `gorm:"not null" json:"quantity"`
+	UnitPrice         int64      `gorm:"not null" json:"unit_price"` // Price in cents, as quoted to the customer
+	Currency          string     `gorm:"size:3" json:"currency"`
+
+	// Relations
+	Order   *Order          `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Product *CatalogProduct `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (OrderItem) TableName() string {
+	return "order_items"
+}
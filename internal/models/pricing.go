@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PricingRate is one line of an organization's rate card: what Meta charges
+// for a billable conversation (or message, under per-message pricing) of a
+// category, for recipients whose phone number starts with CountryCode.
+type PricingRate struct {
+	BaseModel
+	OrganizationID uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
+	CountryCode    string    `gorm:"size:5" json:"country_code"`              // Calling code, e.g. "91"; empty matches any country
+	Category       string    `gorm:"size:50;not null" json:"category"`        // Meta pricing category, e.g. "marketing"
+	Rate           float64   `gorm:"type:numeric(12,6);not null" json:"rate"` // In currency units, e.g. 0.0107
+	Currency       string    `gorm:"size:3;not null" json:"currency"`
+
+	// Relations
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+}
+
+func (PricingRate) TableName() string {
+	return "pricing_rates"
+}
+
+// ConversationCost is one billable conversation, priced with the rate card
+// when Meta first reported it and attributed to the message that opened it.
+// Under per-message pricing every billable message is its own row, keyed by
+// its WhatsApp message ID.
+type ConversationCost struct {
+	BaseModel
+	OrganizationID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_conversation_costs_org_conversation" json:"organization_id"`
+	ConversationID  string     `gorm:"size:255;not null;uniqueIndex:idx_conversation_costs_org_conversation" json:"conversation_id"`
+	WhatsAppAccount string     `gorm:"size:100;index" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	ContactID       uuid.UUID  `gorm:"type:uuid;index" json:"contact_id"`
+	MessageID       uuid.UUID  `gorm:"type:uuid;index" json:"message_id"`
+	CampaignID      *uuid.UUID `gorm:"type:uuid;index" json:"campaign_id,omitempty"`
+	TemplateName    string     `gorm:"size:255;index" json:"template_name"`
+	SentByUserID    *uuid.UUID `gorm:"type:uuid;index" json:"sent_by_user_id,omitempty"`
+	Category        string     `gorm:"size:50;index" json:"category"`
+	PricingModel    string     `gorm:"size:20" json:"pricing_model"` // CBP or PMP
+	CountryCode     string     `gorm:"size:5" json:"country_code"`   // Rate card line that matched
+	Cost            float64    `gorm:"type:numeric(12,6);default:0" json:"cost"`
+	Currency        string     `gorm:"size:3" json:"currency"`
+	Rated           bool       `gorm:"default:false" json:"rated"` // False when no rate card line matched
+	StartedAt       time.Time  `gorm:"index;not null" json:"started_at"`
+}
+
+func (ConversationCost) TableName() string {
+	return "conversation_costs"
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecurringCampaign is a campaign definition that creates a new
+// BulkMessageCampaign run each time its cron schedule fires. Runs get their
+// recipients from the segment, the fixed recipient list, or both.
+type RecurringCampaign struct {
+	BaseModel
+	OrganizationID  uuid.UUID               `gorm:"type:uuid;index;not null" json:"organization_id"`
+	Name            string                  `gorm:"size:255;not null" json:"name"`
+	WhatsAppAccount string                  `gorm:"size:100;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	TemplateID      uuid.UUID               `gorm:"type:uuid;not null" json:"template_id"`
+	CronExpression  string                  `gorm:"size:100;not null" json:"cron_expression"`
+	Timezone        string                  `gorm:"size:50;not null;default:'UTC'" json:"timezone"` // IANA name the schedule is read in
+	Status          RecurringCampaignStatus `gorm:"size:20;index;not null;default:'active'" json:"status"`
+	NextRunAt       *time.Time              `gorm:"index" json:"next_run_at,omitempty"` // Cleared while paused
+	LastRunAt       *time.Time              `json:"last_run_at,omitempty"`
+	CreatedBy       uuid.UUID               `gorm:"type:uuid;not null" json:"created_by"`
+
+	// Copied onto every run
+	SenderAccounts StringArray         `gorm:"type:jsonb" json:"sender_accounts,omitempty"`
+	SenderStrategy SenderStrategy      `gorm:"size:20" json:"sender_strategy,omitempty"`
+	SegmentID      *uuid.UUID          `gorm:"type:uuid" json:"segment_id,omitempty"`
+	SegmentParams  JSONB               `gorm:"type:jsonb" json:"segment_params,omitempty"`
+	Recipients     RecurringRecipients `gorm:"type:jsonb" json:"recipients,omitempty"`
+
+	// Relations
+	Template *Template       `gorm:"foreignKey:TemplateID" json:"template,omitempty"`
+	Segment  *ContactSegment `gorm:"foreignKey:SegmentID" json:"segment,omitempty"`
+}
+
+func (RecurringCampaign) TableName() string {
+	return "recurring_campaigns"
+}
+
+// RecurringRecipient is a fixed recipient added to every run of a recurring campaign
+type RecurringRecipient struct {
+	PhoneNumber    string         `json:"phone_number"`
+	RecipientName  string         `json:"recipient_name,omitempty"`
+	TemplateParams map[string]any `json:"template_params,omitempty"`
+}
+
+// RecurringRecipients is a jsonb list of recurring campaign recipients
+type RecurringRecipients []RecurringRecipient
+
+func (r RecurringRecipients) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *RecurringRecipients) Scan(value interface{}) error {
+	if value == nil {
+		*r = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, r)
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduledMessage is a chat message an agent queued to be sent to a contact later
+type ScheduledMessage struct {
+	BaseModel
+	OrganizationID  uuid.UUID              `gorm:"type:uuid;index;not null" json:"organization_id"`
+	ContactID       uuid.UUID              `gorm:"type:uuid;index;not null" json:"contact_id"`
+	WhatsAppAccount string                 `gorm:"size:100" json:"whatsapp_account"` // Empty uses the contact's account
+	CreatedByID     uuid.UUID              `gorm:"type:uuid;not null" json:"created_by_id"`
+	SendAt          time.Time              `gorm:"index;not null" json:"send_at"`
+	Status          ScheduledMessageStatus `gorm:"size:20;index;not null;default:'pending'" json:"status"`
+	MessageType     MessageType            `gorm:"size:20;not null" json:"message_type"` // text or template
+	Content         string                 `gorm:"type:text" json:"content"`
+	TemplateID      *uuid.UUID             `gorm:"type:uuid" json:"template_id,omitempty"`
+	TemplateParams  JSONB                  `gorm:"type:jsonb" json:"template_params,omitempty"`
+
+	// Sent instead of a text message when the 24-hour service window has closed by send time
+	FallbackTemplateID     *uuid.UUID `gorm:"type:uuid" json:"fallback_template_id,omitempty"`
+	FallbackTemplateParams JSONB      `gorm:"type:jsonb" json:"fallback_template_params,omitempty"`
+
+	// Outcome
+	MessageID     *uuid.UUID `gorm:"type:uuid" json:"message_id,omitempty"`
+	UsedFallback  bool       `gorm:"default:false" json:"used_fallback"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	ErrorMessage  string     `gorm:"type:text" json:"error_message,omitempty"`
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty"`
+	CancelledByID *uuid.UUID `gorm:"type:uuid" json:"cancelled_by_id,omitempty"`
+
+	// Relations
+	Contact   *Contact `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+	CreatedBy *User    `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+}
+
+func (ScheduledMessage) TableName() string {
+	return "scheduled_messages"
+}
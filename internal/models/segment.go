@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// ContactSegment is a saved set of rules selecting contacts, used as a
+// campaign audience. Rules left empty match every contact; the ones that
+// are set must all match. Day-based rules are relative to when the
+// segment is resolved, so a segment stays current without editing.
+type ContactSegment struct {
+	BaseModel
+	OrganizationID uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
+	Name           string    `gorm:"size:255;not null" json:"name"`
+	Description    string    `gorm:"type:text" json:"description"`
+	CreatedByID    uuid.UUID `gorm:"type:uuid;not null" json:"created_by_id"`
+
+	// Rules
+	Tags              StringArray `gorm:"type:jsonb" json:"tags,omitempty"`     // Contact has any of these tags
+	Metadata          JSONB       `gorm:"type:jsonb" json:"metadata,omitempty"` // Contact.Metadata fields that must equal these values
+	InboundWithinDays int         `gorm:"default:0" json:"inbound_within_days"` // Last inbound message at most this many days ago
+	NoInboundForDays  int         `gorm:"default:0" json:"no_inbound_for_days"` // No inbound message for at least this many days
+	AssignedUserID    *uuid.UUID  `gorm:"type:uuid" json:"assigned_user_id,omitempty"`
+	WhatsAppAccount   string      `gorm:"size:100" json:"whatsapp_account,omitempty"` // References WhatsAppAccount.Name
+}
+
+func (ContactSegment) TableName() string {
+	return "contact_segments"
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// SuppressionEntry is a phone number an organization must not send campaigns,
+// notifications or scheduled messages to. Entries are keyed by the number
+// rather than the contact, so imported numbers apply before a contact exists.
+type SuppressionEntry struct {
+	BaseModel
+	OrganizationID uuid.UUID         `gorm:"type:uuid;not null;uniqueIndex:idx_suppression_org_phone" json:"organization_id"`
+	PhoneNumber    string            `gorm:"size:50;not null;uniqueIndex:idx_suppression_org_phone" json:"phone_number"` // Digits only
+	Source         SuppressionSource `gorm:"size:20;not null" json:"source"`
+	Reason         string            `gorm:"type:text" json:"reason,omitempty"` // The keyword the customer sent, or a note
+	CreatedByID    *uuid.UUID        `gorm:"type:uuid" json:"created_by_id,omitempty"`
+}
+
+func (SuppressionEntry) TableName() string {
+	return "suppression_entries"
+}
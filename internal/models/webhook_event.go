@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// InboundWebhookEvent is a raw webhook payload received from Meta. It is
+// stored before processing so a crash mid-processing doesn't lose messages,
+// and so events can be replayed later.
+type InboundWebhookEvent struct {
+	BaseModel
+	ReceivedAt    time.Time            `gorm:"index;not null" json:"received_at"`
+	Object        string               `gorm:"size:50" json:"object"`
+	EntryID       string               `gorm:"size:100;index" json:"entry_id"`        // WABA ID of the first entry
+	PhoneNumberID string               `gorm:"size:100;index" json:"phone_number_id"` // First phone number in the payload, empty for template updates
+	Fields        string               `gorm:"size:255" json:"fields"`                // Comma-separated change fields, e.g. "messages"
+	Payload       string               `gorm:"type:text;not null" json:"payload"`
+	Status        InboundWebhookStatus `gorm:"size:20;default:'pending';index" json:"status"`
+	Attempts      int                  `gorm:"default:0" json:"attempts"`
+	Error         string               `gorm:"type:text" json:"error,omitempty"`
+	ProcessedAt   *time.Time           `json:"processed_at,omitempty"`
+}
+
+func (InboundWebhookEvent) TableName() string {
+	return "webhook_events"
+}
@@ -13,6 +13,9 @@ import (
 const (
 	// CampaignStatsChannel is the Redis pub/sub channel for campaign stats updates
 	CampaignStatsChannel = "whatomate:campaign_stats"
+	// AccountAuthChannel is the Redis pub/sub channel for access tokens
+	// rejected by Meta during worker sends
+	AccountAuthChannel = "whatomate:account_auth"
 )
 
 // CampaignStatsUpdate represents a campaign stats update message
@@ -26,6 +29,16 @@ type CampaignStatsUpdate struct {
 	FailedCount    int                  `json:"failed_count"`
 }
 
+// AccountAuthFailure reports a send the worker made that Meta rejected
+// because of the account's access token
+type AccountAuthFailure struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	AccountID      uuid.UUID `json:"account_id"`
+	Code           int       `json:"code"`
+	Subcode        int       `json:"subcode"`
+	Message        string    `json:"message"`
+}
+
 // Publisher publishes messages to Redis pub/sub channels
 type Publisher struct {
 	client *redis.Client
@@ -56,6 +69,20 @@ func (p *Publisher) PublishCampaignStats(ctx context.Context, update *CampaignSt
 	return nil
 }
 
+// PublishAccountAuthFailure publishes an access token rejection
+func (p *Publisher) PublishAccountAuthFailure(ctx context.Context, failure *AccountAuthFailure) error {
+	payload, err := json.Marshal(failure)
+	if err != nil {
+		return err
+	}
+
+	if err := p.client.Publish(ctx, AccountAuthChannel, payload).Err(); err != nil {
+		p.log.Error("Failed to publish account auth failure", "error", err, "account_id", failure.AccountID)
+		return err
+	}
+	return nil
+}
+
 // Subscriber subscribes to Redis pub/sub channels
 type Subscriber struct {
 	client *redis.Client
@@ -112,6 +139,45 @@ func (s *Subscriber) SubscribeCampaignStats(ctx context.Context, handler func(up
 	return nil
 }
 
+// SubscribeAccountAuthFailures subscribes to access token rejections
+// The handler is called for each received failure
+func (s *Subscriber) SubscribeAccountAuthFailures(ctx context.Context, handler func(failure *AccountAuthFailure)) error {
+	s.pubsub = s.client.Subscribe(ctx, AccountAuthChannel)
+
+	// Wait for subscription confirmation
+	if _, err := s.pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	s.log.Info("Subscribed to account auth channel")
+
+	ch := s.pubsub.Channel()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				s.log.Info("Account auth subscriber shutting down")
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					s.log.Info("Account auth channel closed")
+					return
+				}
+
+				var failure AccountAuthFailure
+				if err := json.Unmarshal([]byte(msg.Payload), &failure); err != nil {
+					s.log.Error("Failed to unmarshal account auth failure", "error", err)
+					continue
+				}
+
+				handler(&failure)
+			}
+		}
+	}()
+
+	return nil
+}
+
 // Close closes the subscriber
 func (s *Subscriber) Close() error {
 	if s.pubsub != nil {
@@ -25,6 +25,10 @@ type RecipientJob struct {
 	RecipientName  string        `json:"recipient_name"`
 	TemplateParams models.JSONB  `json:"template_params"`
 	EnqueuedAt     time.Time     `json:"enqueued_at"`
+	// Attempt counts earlier sends that failed with a retryable error
+	Attempt        int           `json:"attempt,omitempty"`
+	// Deferrals counts how often a frequency cap pushed the send back
+	Deferrals      int           `json:"deferrals,omitempty"`
 }
 
 // Queue defines the interface for job queue operations
@@ -35,6 +39,9 @@ type Queue interface {
 	// EnqueueRecipients adds multiple recipient jobs to the queue
 	EnqueueRecipients(ctx context.Context, jobs []*RecipientJob) error
 
+	// ScheduleRecipient re-queues a recipient job to run no earlier than at
+	ScheduleRecipient(ctx context.Context, job *RecipientJob, at time.Time) error
+
 	// Close closes the queue connection
 	Close() error
 }
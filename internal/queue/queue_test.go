@@ -30,9 +30,9 @@ func skipIfNoRedis(t *testing.T) *redis.Client {
 func cleanStream(t *testing.T, client *redis.Client) {
 	t.Helper()
 	ctx := context.Background()
-	client.Del(ctx, queue.StreamName)
+	client.Del(ctx, queue.StreamName, queue.DelayedSetName)
 	t.Cleanup(func() {
-		client.Del(ctx, queue.StreamName)
+		client.Del(ctx, queue.StreamName, queue.DelayedSetName)
 		// Also clean up the consumer group; ignore errors if it doesn't exist.
 		client.XGroupDestroy(ctx, queue.StreamName, queue.ConsumerGroup)
 	})
@@ -287,6 +287,50 @@ func TestConsume_ProcessesJob(t *testing.T) {
 	assert.Equal(t, job.RecipientName, received[0].RecipientName)
 }
 
+func TestConsume_PromotesDueScheduledJobs(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanStream(t, client)
+	log := testutil.NopLogger()
+	ctx := testutil.TestContextWithTimeout(t, 10*time.Second)
+
+	q := queue.NewRedisQueue(client, log)
+
+	due := makeRecipientJob()
+	due.Attempt = 2
+	require.NoError(t, q.ScheduleRecipient(ctx, due, time.Now().Add(-time.Second)))
+
+	later := makeRecipientJob()
+	require.NoError(t, q.ScheduleRecipient(ctx, later, time.Now().Add(time.Hour)))
+
+	consumer, err := queue.NewRedisConsumer(client, log)
+	require.NoError(t, err)
+	defer consumer.Close() //nolint:errcheck
+
+	handler := &mockHandler{}
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		_ = consumer.Consume(consumeCtx, handler)
+	}()
+
+	testutil.AssertEventually(t, func() bool {
+		return len(handler.getJobs()) >= 1
+	}, 8*time.Second, "handler should have received the due job")
+
+	cancel()
+
+	received := handler.getJobs()
+	require.Len(t, received, 1)
+	assert.Equal(t, due.RecipientID, received[0].RecipientID)
+	assert.Equal(t, 2, received[0].Attempt)
+
+	// The job that isn't due yet stays parked
+	remaining, err := client.ZCard(context.Background(), queue.DelayedSetName).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), remaining)
+}
+
 func TestConsume_EmptyQueue(t *testing.T) {
 	client := skipIfNoRedis(t)
 	cleanStream(t, client)
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -23,6 +24,10 @@ const (
 
 	// ClaimMinIdleTime is the minimum idle time before claiming a pending message
 	ClaimMinIdleTime = 5 * time.Minute
+
+	// DelayedSetName is the sorted set holding scheduled jobs, scored by the
+	// unix time they become due
+	DelayedSetName = "whatomate:campaigns:delayed"
 )
 
 // RedisQueue implements the Queue interface using Redis Streams
@@ -102,6 +107,25 @@ func (q *RedisQueue) EnqueueRecipients(ctx context.Context, jobs []*RecipientJob
 	return nil
 }
 
+// ScheduleRecipient parks a recipient job until at. Consumers move it onto the
+// stream once it is due.
+func (q *RedisQueue) ScheduleRecipient(ctx context.Context, job *RecipientJob, at time.Time) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipient job: %w", err)
+	}
+
+	err = q.client.ZAdd(ctx, DelayedSetName, redis.Z{
+		Score:  float64(at.Unix()),
+		Member: string(payload),
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to schedule recipient job: %w", err)
+	}
+
+	return nil
+}
+
 // Close closes the queue connection
 func (q *RedisQueue) Close() error {
 	return nil // Redis client is managed externally
@@ -154,6 +178,10 @@ func (c *RedisConsumer) Consume(ctx context.Context, handler JobHandler) error {
 		default:
 		}
 
+		if err := c.promoteDelayed(ctx); err != nil && ctx.Err() == nil {
+			c.log.Error("Failed to promote scheduled jobs", "error", err)
+		}
+
 		// Read new messages from the stream
 		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
 			Group:    ConsumerGroup,
@@ -193,6 +221,44 @@ func (c *RedisConsumer) Consume(ctx context.Context, handler JobHandler) error {
 	}
 }
 
+// promoteDelayed moves scheduled jobs that are due onto the stream. ZRem
+// decides ownership, so a job is promoted once even with several consumers.
+func (c *RedisConsumer) promoteDelayed(ctx context.Context) error {
+	due, err := c.client.ZRangeByScore(ctx, DelayedSetName, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: 100,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read scheduled jobs: %w", err)
+	}
+
+	for _, payload := range due {
+		removed, err := c.client.ZRem(ctx, DelayedSetName, payload).Result()
+		if err != nil {
+			return fmt.Errorf("failed to remove scheduled job: %w", err)
+		}
+		if removed == 0 {
+			continue // Another consumer took it
+		}
+
+		err = c.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: StreamName,
+			Values: map[string]interface{}{
+				"type":    string(JobTypeRecipient),
+				"payload": payload,
+			},
+		}).Err()
+		if err != nil {
+			// Put it back so it is not lost
+			c.client.ZAdd(ctx, DelayedSetName, redis.Z{Score: float64(time.Now().Unix()), Member: payload})
+			return fmt.Errorf("failed to promote scheduled job: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // claimPendingMessages claims stale pending messages from crashed workers
 func (c *RedisConsumer) claimPendingMessages(ctx context.Context, handler JobHandler) error {
 	// Get pending messages that have been idle for too long
@@ -0,0 +1,116 @@
+// Package senderpool picks which WhatsApp account sends to each recipient of
+// a campaign that targets a pool of accounts.
+package senderpool
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// tierWeights is the number of unique users each messaging limit tier may
+// message per day, which weighted pools use as the account's share
+var tierWeights = map[string]int{
+	"TIER_50":        50,
+	"TIER_250":       250,
+	"TIER_1K":        1000,
+	"TIER_2K":        2000,
+	"TIER_10K":       10000,
+	"TIER_100K":      100000,
+	"TIER_UNLIMITED": 1000000,
+}
+
+// defaultTierWeight is used for accounts whose tier has not been reported
+// yet; new numbers start at TIER_250
+const defaultTierWeight = 250
+
+// ValidStrategy reports whether s is a known distribution strategy
+func ValidStrategy(s models.SenderStrategy) bool {
+	switch s {
+	case models.SenderStrategyRoundRobin, models.SenderStrategyWeighted, models.SenderStrategySticky:
+		return true
+	}
+	return false
+}
+
+// Members returns the account names a campaign sends from: its pool with
+// the campaign's own account first, or just that account without a pool.
+func Members(campaign *models.BulkMessageCampaign) []string {
+	names := []string{campaign.WhatsAppAccount}
+	for _, name := range campaign.SenderAccounts {
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Usable reports whether an account may take campaign traffic. Accounts Meta
+// has restricted, disabled or rejected the token of, and numbers rated RED,
+// are left out so the rest of the pool carries the campaign.
+func Usable(account *models.WhatsAppAccount) bool {
+	if account.Status != "" && account.Status != models.AccountStatusActive {
+		return false
+	}
+	return account.QualityRating != models.QualityRatingRed
+}
+
+// Weight returns an account's share of a weighted pool
+func Weight(account *models.WhatsAppAccount) int {
+	if w, ok := tierWeights[account.MessagingLimitTier]; ok {
+		return w
+	}
+	return defaultTierWeight
+}
+
+// Pick chooses the account that sends to a recipient. accounts must be
+// usable and non-empty. seq is a counter shared by all workers sending the
+// campaign, used by round-robin. phone and preferred (the account the
+// contact already talks to, if any) are used by sticky.
+func Pick(strategy models.SenderStrategy, accounts []models.WhatsAppAccount, phone, preferred string, seq int64) *models.WhatsAppAccount {
+	if len(accounts) == 1 {
+		return &accounts[0]
+	}
+
+	switch strategy {
+	case models.SenderStrategyWeighted:
+		total := 0
+		for i := range accounts {
+			total += Weight(&accounts[i])
+		}
+		n := rand.N(total)
+		for i := range accounts {
+			n -= Weight(&accounts[i])
+			if n < 0 {
+				return &accounts[i]
+			}
+		}
+		return &accounts[len(accounts)-1]
+
+	case models.SenderStrategySticky:
+		for i := range accounts {
+			if accounts[i].Name == preferred {
+				return &accounts[i]
+			}
+		}
+		// Rendezvous hashing: a contact keeps its account when other
+		// accounts join or leave the pool
+		best, bestScore := 0, uint64(0)
+		for i := range accounts {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(accounts[i].Name + "|" + phone))
+			if score := h.Sum64(); i == 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		return &accounts[best]
+
+	default: // round_robin
+		if seq < 0 {
+			seq = -seq
+		}
+		return &accounts[seq%int64(len(accounts))]
+	}
+}
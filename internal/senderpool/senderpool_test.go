@@ -0,0 +1,88 @@
+package senderpool
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAccounts(tiers ...string) []models.WhatsAppAccount {
+	accounts := make([]models.WhatsAppAccount, len(tiers))
+	for i, tier := range tiers {
+		accounts[i] = models.WhatsAppAccount{Name: fmt.Sprintf("acct-%d", i), MessagingLimitTier: tier}
+	}
+	return accounts
+}
+
+func TestMembers(t *testing.T) {
+	campaign := &models.BulkMessageCampaign{WhatsAppAccount: "main"}
+	assert.Equal(t, []string{"main"}, Members(campaign))
+
+	campaign.SenderAccounts = models.StringArray{"second", "main", "", "third", "second"}
+	assert.Equal(t, []string{"main", "second", "third"}, Members(campaign))
+}
+
+func TestUsable(t *testing.T) {
+	assert.True(t, Usable(&models.WhatsAppAccount{}))
+	assert.True(t, Usable(&models.WhatsAppAccount{Status: models.AccountStatusActive, QualityRating: models.QualityRatingYellow}))
+	assert.False(t, Usable(&models.WhatsAppAccount{Status: models.AccountStatusAuthFailed}))
+	assert.False(t, Usable(&models.WhatsAppAccount{Status: models.AccountStatusRestricted}))
+	assert.False(t, Usable(&models.WhatsAppAccount{QualityRating: models.QualityRatingRed}))
+}
+
+func TestPick_RoundRobin(t *testing.T) {
+	accounts := testAccounts("", "", "")
+	var got []string
+	for seq := int64(1); seq <= 6; seq++ {
+		got = append(got, Pick(models.SenderStrategyRoundRobin, accounts, "", "", seq).Name)
+	}
+	assert.Equal(t, []string{"acct-1", "acct-2", "acct-0", "acct-1", "acct-2", "acct-0"}, got)
+}
+
+func TestPick_Weighted(t *testing.T) {
+	accounts := testAccounts("TIER_1K", "TIER_10K")
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		counts[Pick(models.SenderStrategyWeighted, accounts, "", "", 0).Name]++
+	}
+	// 1:10 split; generous bounds keep the test stable
+	assert.Greater(t, counts["acct-1"], counts["acct-0"]*4)
+	assert.Positive(t, counts["acct-0"])
+}
+
+func TestPick_Sticky(t *testing.T) {
+	accounts := testAccounts("", "", "")
+
+	// The contact's existing account wins when it is in the pool
+	assert.Equal(t, "acct-2", Pick(models.SenderStrategySticky, accounts, "15551230001", "acct-2", 0).Name)
+
+	// Otherwise the same phone always maps to the same account
+	first := Pick(models.SenderStrategySticky, accounts, "15551230001", "elsewhere", 0).Name
+	for seq := int64(0); seq < 5; seq++ {
+		assert.Equal(t, first, Pick(models.SenderStrategySticky, accounts, "15551230001", "", seq).Name)
+	}
+
+	// Removing another account from the pool does not move the contact
+	var rest []models.WhatsAppAccount
+	for _, a := range accounts {
+		if a.Name == first {
+			rest = append(rest, a)
+		}
+	}
+	for _, a := range accounts {
+		if a.Name != first {
+			rest = append(rest, a)
+			break
+		}
+	}
+	assert.Equal(t, first, Pick(models.SenderStrategySticky, rest, "15551230001", "", 0).Name)
+}
+
+func TestPick_SingleAccount(t *testing.T) {
+	accounts := testAccounts("TIER_50")
+	for _, s := range []models.SenderStrategy{models.SenderStrategyRoundRobin, models.SenderStrategyWeighted, models.SenderStrategySticky} {
+		assert.Equal(t, "acct-0", Pick(s, accounts, "15551230001", "", 7).Name)
+	}
+}
@@ -0,0 +1,113 @@
+// Package suppression keeps organizations from messaging customers who opted
+// out, and recognizes the keywords customers opt out and back in with.
+package suppression
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Organization settings keys holding the keyword lists
+const (
+	OptOutKeywordsSetting = "opt_out_keywords"
+	OptInKeywordsSetting  = "opt_in_keywords"
+)
+
+// Keywords used by organizations that have not configured their own
+var (
+	DefaultOptOutKeywords = []string{"STOP", "STOP ALL", "UNSUBSCRIBE", "OPT OUT"}
+	DefaultOptInKeywords  = []string{"START", "SUBSCRIBE", "OPT IN"}
+)
+
+// NormalizePhone reduces a phone number to its digits, the form entries are
+// stored and looked up in
+func NormalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
+
+// Keywords returns an organization's opt-out and opt-in keywords. A list
+// missing from the settings falls back to the defaults; an empty one turns
+// that detection off.
+func Keywords(settings models.JSONB) (optOut, optIn []string) {
+	return settingKeywords(settings, OptOutKeywordsSetting, DefaultOptOutKeywords),
+		settingKeywords(settings, OptInKeywordsSetting, DefaultOptInKeywords)
+}
+
+func settingKeywords(settings models.JSONB, key string, defaults []string) []string {
+	raw, ok := settings[key]
+	if !ok || raw == nil {
+		return defaults
+	}
+	var keywords []string
+	switch v := raw.(type) {
+	case []string:
+		keywords = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				keywords = append(keywords, s)
+			}
+		}
+	}
+	return keywords
+}
+
+// Matches reports whether a message is one of the keywords. The whole message
+// must be the keyword, ignoring case, spacing and trailing punctuation, so
+// "Stop!" matches STOP but "don't stop" does not.
+func Matches(keywords []string, text string) bool {
+	text = normalizeKeyword(text)
+	if text == "" {
+		return false
+	}
+	for _, k := range keywords {
+		if normalizeKeyword(k) == text {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeKeyword(s string) string {
+	s = strings.TrimRightFunc(s, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+	return strings.ToUpper(strings.Join(strings.Fields(s), " "))
+}
+
+// IsSuppressed reports whether an organization has suppressed a phone number
+func IsSuppressed(db *gorm.DB, orgID uuid.UUID, phone string) (bool, error) {
+	var count int64
+	err := db.Model(&models.SuppressionEntry{}).
+		Where("organization_id = ? AND phone_number = ?", orgID, NormalizePhone(phone)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Add puts an entry's number on its organization's suppression list. It
+// reports false without error when the number was already there, in which
+// case the existing entry is kept.
+func Add(db *gorm.DB, entry *models.SuppressionEntry) (bool, error) {
+	entry.PhoneNumber = NormalizePhone(entry.PhoneNumber)
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Remove takes a phone number off an organization's suppression list,
+// reporting whether it was there
+func Remove(db *gorm.DB, orgID uuid.UUID, phone string) (bool, error) {
+	result := db.Unscoped().
+		Where("organization_id = ? AND phone_number = ?", orgID, NormalizePhone(phone)).
+		Delete(&models.SuppressionEntry{})
+	return result.RowsAffected > 0, result.Error
+}
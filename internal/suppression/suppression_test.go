@@ -0,0 +1,76 @@
+package suppression
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	assert.Equal(t, "15551234567", NormalizePhone("+1 (555) 123-4567"))
+	assert.Equal(t, "15551234567", NormalizePhone("15551234567"))
+	assert.Equal(t, "", NormalizePhone("n/a"))
+}
+
+func TestKeywords(t *testing.T) {
+	optOut, optIn := Keywords(nil)
+	assert.Equal(t, DefaultOptOutKeywords, optOut)
+	assert.Equal(t, DefaultOptInKeywords, optIn)
+
+	// Settings decoded from JSON hold []any; an empty list turns detection off
+	optOut, optIn = Keywords(models.JSONB{
+		OptOutKeywordsSetting: []any{"BASTA", "PARAR"},
+		OptInKeywordsSetting:  []any{},
+	})
+	assert.Equal(t, []string{"BASTA", "PARAR"}, optOut)
+	assert.Empty(t, optIn)
+}
+
+func TestMatches(t *testing.T) {
+	keywords := []string{"STOP", "opt out"}
+
+	assert.True(t, Matches(keywords, "STOP"))
+	assert.True(t, Matches(keywords, "  stop! "))
+	assert.True(t, Matches(keywords, "Opt   Out."))
+	assert.False(t, Matches(keywords, "please don't stop"))
+	assert.False(t, Matches(keywords, "stopped"))
+	assert.False(t, Matches(keywords, ""))
+	assert.False(t, Matches(nil, "STOP"))
+}
+
+func TestAddRemove(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	uid := uuid.New().String()[:8]
+	org := models.Organization{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "test-" + uid, Slug: "test-" + uid}
+	require.NoError(t, db.Create(&org).Error)
+
+	added, err := Add(db, &models.SuppressionEntry{OrganizationID: org.ID, PhoneNumber: "+1 555 000 1111", Source: models.SuppressionSourceKeyword})
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	// The same number in another format is a duplicate
+	added, err = Add(db, &models.SuppressionEntry{OrganizationID: org.ID, PhoneNumber: "15550001111", Source: models.SuppressionSourceImport})
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	suppressed, err := IsSuppressed(db, org.ID, "15550001111")
+	require.NoError(t, err)
+	assert.True(t, suppressed)
+
+	removed, err := Remove(db, org.ID, "+15550001111")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	suppressed, err = IsSuppressed(db, org.ID, "15550001111")
+	require.NoError(t, err)
+	assert.False(t, suppressed)
+
+	// Removal is a hard delete, so the number can be added again
+	added, err = Add(db, &models.SuppressionEntry{OrganizationID: org.ID, PhoneNumber: "15550001111", Source: models.SuppressionSourceManual})
+	require.NoError(t, err)
+	assert.True(t, added)
+}
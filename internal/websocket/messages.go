@@ -26,6 +26,9 @@ const (
 	// Campaign types
 	TypeCampaignStatsUpdate = "campaign_stats_update"
 
+	// Account health types
+	TypeAccountHealthAlert = "account_health_alert"
+
 	// Permission types
 	TypePermissionsUpdated = "permissions_updated"
 
@@ -34,6 +37,9 @@ const (
 	TypeConversationNoteUpdated = "conversation_note_updated"
 	TypeConversationNoteDeleted = "conversation_note_deleted"
 
+	// Scheduled message types
+	TypeScheduledMessageUpdated = "scheduled_message_updated"
+
 	// Call types
 	TypeCallIncoming = "call_incoming"
 	TypeCallAnswered = "call_answered"
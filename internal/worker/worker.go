@@ -3,15 +3,21 @@ package worker
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"github.com/shridarpatil/whatomate/internal/abtest"
 	"github.com/shridarpatil/whatomate/internal/config"
 	"github.com/shridarpatil/whatomate/internal/contactutil"
 	"github.com/shridarpatil/whatomate/internal/crypto"
+	"github.com/shridarpatil/whatomate/internal/frequencycap"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/internal/senderpool"
+	"github.com/shridarpatil/whatomate/internal/suppression"
 	"github.com/shridarpatil/whatomate/internal/templateutil"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 	"github.com/zerodha/logf"
@@ -27,8 +33,26 @@ type Worker struct {
 	WhatsApp  *whatsapp.Client
 	Consumer  *queue.RedisConsumer
 	Publisher *queue.Publisher
+	// Queue re-schedules sends that failed with a retryable Meta error.
+	// When nil, those recipients are marked failed like any other error.
+	Queue queue.Queue
 }
 
+const (
+	// MaxSendAttempts is how many times a recipient is tried before a
+	// retryable error is recorded as a failure
+	MaxSendAttempts = 5
+
+	// RetryBaseDelay is the backoff before the first retry; it doubles
+	// with every attempt up to RetryMaxDelay
+	RetryBaseDelay = 30 * time.Second
+	RetryMaxDelay  = 15 * time.Minute
+
+	// MaxCapDeferrals is how many times a frequency cap defers a recipient
+	// before it is marked capped
+	MaxCapDeferrals = 3
+)
+
 // Ensure Worker implements JobHandler interface
 var _ queue.JobHandler = (*Worker)(nil)
 
@@ -41,14 +65,18 @@ func New(cfg *config.Config, db *gorm.DB, rdb *redis.Client, log logf.Logger) (*
 
 	publisher := queue.NewPublisher(rdb, log)
 
+	waClient := whatsapp.NewWithBaseURL(log, cfg.WhatsApp.BaseURL)
+	waClient.Limiter = whatsapp.NewRedisLimiter(rdb, log, cfg.WhatsApp.SendRate, cfg.WhatsApp.BulkReserve)
+
 	return &Worker{
 		Config:    cfg,
 		DB:        db,
 		Redis:     rdb,
 		Log:       log,
-		WhatsApp:  whatsapp.New(log),
+		WhatsApp:  waClient,
 		Consumer:  consumer,
 		Publisher: publisher,
+		Queue:     queue.NewRedisQueue(rdb, log),
 	}, nil
 }
 
@@ -70,7 +98,7 @@ func (w *Worker) Run(ctx context.Context) error {
 func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob) error {
 	// Check if campaign is still active before sending
 	var campaign models.BulkMessageCampaign
-	if err := w.DB.Where("id = ?", job.CampaignID).Preload("Template").First(&campaign).Error; err != nil {
+	if err := w.DB.Where("id = ?", job.CampaignID).Preload("Template").Preload("Variants.Template").First(&campaign).Error; err != nil {
 		w.Log.Error("Failed to load campaign", "error", err, "campaign_id", job.CampaignID)
 		return fmt.Errorf("failed to load campaign: %w", err)
 	}
@@ -81,15 +109,17 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 		return nil // Not an error, just skip
 	}
 
-	// Get WhatsApp account
-	var account models.WhatsAppAccount
-	if err := w.DB.Where("name = ? AND organization_id = ?", campaign.WhatsAppAccount, job.OrganizationID).First(&account).Error; err != nil {
-		w.Log.Error("Failed to load WhatsApp account", "error", err, "account_name", campaign.WhatsAppAccount)
-		w.updateRecipientStatus(job.RecipientID, models.MessageStatusFailed, "", "WhatsApp account not found")
-		w.incrementCampaignCount(job.CampaignID, "failed_count")
-		return nil // Don't retry, mark as failed
+	// A/B tested campaigns send each recipient one of their variants. Recipients
+	// outside the test group wait for the winner, which enqueues them again.
+	template, headerMediaID := campaign.Template, campaign.HeaderMediaID
+	variant, held := abtest.Assign(&campaign, job.PhoneNumber)
+	if held {
+		w.Log.Info("Recipient held for A/B test winner", "campaign_id", job.CampaignID, "recipient_id", job.RecipientID)
+		return nil
+	}
+	if variant != nil {
+		template, headerMediaID = variant.Template, variant.HeaderMediaID
 	}
-	w.decryptAccountSecrets(&account)
 
 	// Get or create contact for this recipient
 	contact, _, err := contactutil.GetOrCreateContact(w.DB, job.OrganizationID, job.PhoneNumber, job.RecipientName)
@@ -100,6 +130,80 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 		return nil // Don't retry
 	}
 
+	// The same recipient can be queued more than once (retries, deferrals, a pause
+	// and resume re-enqueueing pending recipients); only send if it is still waiting.
+	var current models.BulkMessageRecipient
+	if err := w.DB.Select("status").Where("id = ?", job.RecipientID).First(&current).Error; err != nil || current.Status != models.MessageStatusPending {
+		w.Log.Info("Recipient already processed, skipping job", "recipient_id", job.RecipientID, "attempt", job.Attempt)
+		return nil
+	}
+
+	// Blocked contacts are never messaged; like opt-outs they are skipped, not failed
+	if contact.IsBlocked {
+		w.Log.Info("Contact is blocked, skipping recipient", "campaign_id", job.CampaignID, "recipient_id", job.RecipientID)
+		w.updateRecipientStatus(job.RecipientID, models.MessageStatusBlocked, "", "Contact is blocked")
+		w.checkCampaignCompletion(ctx, job.CampaignID, job.OrganizationID)
+		return nil
+	}
+
+	// Opted-out numbers are skipped rather than failed
+	if suppressed, err := suppression.IsSuppressed(w.DB, job.OrganizationID, job.PhoneNumber); err != nil {
+		w.Log.Error("Failed to check suppression list", "error", err, "recipient_id", job.RecipientID)
+		return fmt.Errorf("failed to check suppression list: %w", err)
+	} else if suppressed {
+		w.Log.Info("Recipient opted out, skipping", "campaign_id", job.CampaignID, "recipient_id", job.RecipientID)
+		w.updateRecipientStatus(job.RecipientID, models.MessageStatusSuppressed, "", "Contact opted out")
+		w.checkCampaignCompletion(ctx, job.CampaignID, job.OrganizationID)
+		return nil
+	}
+
+	// Marketing templates count against the organization's and campaign's caps
+	if frequencycap.IsMarketing(template) {
+		capped, freeAt, err := w.checkFrequencyCap(job, &campaign, contact.ID)
+		if err != nil {
+			w.Log.Error("Failed to check frequency cap", "error", err, "recipient_id", job.RecipientID)
+			return fmt.Errorf("failed to check frequency cap: %w", err)
+		}
+		if capped {
+			if campaign.FrequencyCapAction == models.FrequencyCapActionDefer && w.deferRecipient(ctx, job, freeAt) {
+				return nil
+			}
+			w.Log.Info("Recipient over frequency cap, skipping", "campaign_id", job.CampaignID, "recipient_id", job.RecipientID)
+			w.updateRecipientStatus(job.RecipientID, models.MessageStatusCapped, "", "Frequency cap reached")
+			w.checkCampaignCompletion(ctx, job.CampaignID, job.OrganizationID)
+			return nil
+		}
+	}
+
+	// Pick the account to send from
+	account, errMsg := w.pickSender(ctx, &campaign, job, contact)
+	if account == nil {
+		w.updateRecipientStatus(job.RecipientID, models.MessageStatusFailed, "", errMsg)
+		w.incrementCampaignCount(job.CampaignID, "failed_count")
+		w.checkCampaignCompletion(ctx, job.CampaignID, job.OrganizationID)
+		return nil // Don't retry, mark as failed
+	}
+	w.decryptAccountSecrets(account)
+	recipientUpdates := map[string]any{"whats_app_account": account.Name}
+	if variant != nil {
+		recipientUpdates["variant_id"] = variant.ID
+	}
+	w.DB.Model(&models.BulkMessageRecipient{}).Where("id = ?", job.RecipientID).Updates(recipientUpdates)
+
+	if template == nil {
+		w.Log.Error("Campaign template not found", "campaign_id", job.CampaignID, "recipient_id", job.RecipientID)
+		w.updateRecipientStatus(job.RecipientID, models.MessageStatusFailed, "", "Template not found")
+		w.incrementCampaignCount(job.CampaignID, "failed_count")
+		w.checkCampaignCompletion(ctx, job.CampaignID, job.OrganizationID)
+		return nil
+	}
+
+	// Campaign header media was uploaded through the campaign's own number;
+	// other senders use the template's header URL
+	if account.Name != campaign.WhatsAppAccount {
+		headerMediaID = ""
+	}
+
 	// Build recipient for sending
 	recipient := &models.BulkMessageRecipient{
 		PhoneNumber:    job.PhoneNumber,
@@ -108,12 +212,15 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 	}
 
 	// Send template message
-	waMessageID, err := w.sendTemplateMessage(ctx, &account, campaign.Template, recipient, campaign.HeaderMediaID)
+	waMessageID, err := w.sendTemplateMessage(ctx, account, template, recipient, headerMediaID)
+	if err != nil && w.scheduleRetry(ctx, job, err) {
+		return nil
+	}
 
 	// Create Message record
 	message := models.Message{
 		OrganizationID:    job.OrganizationID,
-		WhatsAppAccount:   campaign.WhatsAppAccount,
+		WhatsAppAccount:   account.Name,
 		ContactID:         contact.ID,
 		WhatsAppMessageID: waMessageID,
 		Direction:         models.DirectionOutgoing,
@@ -124,16 +231,30 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 			"recipient_name": job.RecipientName,
 		},
 	}
-	if campaign.Template != nil {
-		message.TemplateName = campaign.Template.Name
-		content := templateutil.ReplaceWithJSONBParams(campaign.Template.BodyContent, campaign.Template.BodyContent, job.TemplateParams)
-		message.Content = content
+	message.TemplateName = template.Name
+	message.Content = templateutil.ReplaceWithJSONBParams(template.BodyContent, template.BodyContent, job.TemplateParams)
+	if variant != nil {
+		message.Metadata["variant"] = variant.Name
 	}
 
 	if err != nil {
 		w.Log.Error("Failed to send message", "error", err, "recipient", job.PhoneNumber)
 		message.Status = models.MessageStatusFailed
 		message.ErrorMessage = err.Error()
+		message.Metadata["error_class"] = string(whatsapp.ClassifyError(err))
+		if apiErr, ok := whatsapp.AsAPIError(err); ok {
+			message.Metadata["error_code"] = apiErr.Code
+			if apiErr.Class() == whatsapp.ErrorClassAuth && w.Publisher != nil {
+				// The server owns account health; let it mark the account
+				_ = w.Publisher.PublishAccountAuthFailure(ctx, &queue.AccountAuthFailure{
+					OrganizationID: account.OrganizationID,
+					AccountID:      account.ID,
+					Code:           apiErr.Code,
+					Subcode:        apiErr.Subcode,
+					Message:        apiErr.Message,
+				})
+			}
+		}
 		w.updateRecipientStatus(job.RecipientID, models.MessageStatusFailed, "", err.Error())
 		w.incrementCampaignCount(job.CampaignID, "failed_count")
 	} else {
@@ -146,6 +267,9 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 	// Save message record
 	if err := w.DB.Create(&message).Error; err != nil {
 		w.Log.Error("Failed to save message", "error", err, "recipient", job.PhoneNumber)
+	} else {
+		// Links replies and button taps on the message back to the recipient
+		w.DB.Model(&models.BulkMessageRecipient{}).Where("id = ?", job.RecipientID).Update("message_id", message.ID)
 	}
 
 	// Check if campaign is complete (all recipients processed)
@@ -154,6 +278,129 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 	return nil
 }
 
+// pickSender returns the account a recipient is sent from. Campaigns without
+// a sender pool always use their own account; pooled campaigns choose among
+// the pool's usable accounts by the campaign's strategy. When no account can
+// send, it returns nil and the reason to record on the recipient.
+func (w *Worker) pickSender(ctx context.Context, campaign *models.BulkMessageCampaign, job *queue.RecipientJob, contact *models.Contact) (*models.WhatsAppAccount, string) {
+	names := senderpool.Members(campaign)
+
+	var accounts []models.WhatsAppAccount
+	if err := w.DB.Where("organization_id = ? AND name IN ?", job.OrganizationID, names).
+		Order("name").Find(&accounts).Error; err != nil || len(accounts) == 0 {
+		w.Log.Error("Failed to load WhatsApp account", "error", err, "account_name", campaign.WhatsAppAccount)
+		return nil, "WhatsApp account not found"
+	}
+	if len(names) == 1 {
+		return &accounts[0], ""
+	}
+
+	usable := accounts[:0]
+	for _, a := range accounts {
+		if senderpool.Usable(&a) {
+			usable = append(usable, a)
+		}
+	}
+	if len(usable) == 0 {
+		w.Log.Warn("No usable account in sender pool", "campaign_id", campaign.ID, "accounts", names)
+		return nil, "No usable sender account in pool"
+	}
+
+	var seq int64
+	if campaign.SenderStrategy == models.SenderStrategyRoundRobin || campaign.SenderStrategy == "" {
+		seq = w.nextSenderSeq(ctx, campaign.ID, job.RecipientID)
+	}
+	return senderpool.Pick(campaign.SenderStrategy, usable, contact.PhoneNumber, contact.WhatsAppAccount, seq), ""
+}
+
+// nextSenderSeq returns the campaign's round-robin counter, shared by all
+// workers through Redis. Without Redis the recipient ID is hashed instead,
+// which spreads recipients evenly but not strictly in turn.
+func (w *Worker) nextSenderSeq(ctx context.Context, campaignID, recipientID uuid.UUID) int64 {
+	if w.Redis != nil {
+		key := fmt.Sprintf("campaign:%s:sender_seq", campaignID)
+		seq, err := w.Redis.Incr(ctx, key).Result()
+		if err == nil {
+			if seq == 1 {
+				w.Redis.Expire(ctx, key, 7*24*time.Hour)
+			}
+			return seq
+		}
+		w.Log.Warn("Failed to increment sender sequence", "error", err, "campaign_id", campaignID)
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(recipientID[:])
+	return int64(h.Sum64() >> 1)
+}
+
+// scheduleRetry re-queues the job with exponential backoff when the send
+// failed with a retryable or rate-limit error. It returns false when the
+// error is permanent, attempts are exhausted or the job could not be queued,
+// in which case the caller records the failure.
+func (w *Worker) scheduleRetry(ctx context.Context, job *queue.RecipientJob, err error) bool {
+	if w.Queue == nil || job.Attempt+1 >= MaxSendAttempts {
+		return false
+	}
+	class := whatsapp.ClassifyError(err)
+	if class != whatsapp.ErrorClassRetryable && class != whatsapp.ErrorClassRateLimited {
+		return false
+	}
+
+	delay := retryDelay(job.Attempt)
+	retry := *job
+	retry.Attempt++
+	if qerr := w.Queue.ScheduleRecipient(ctx, &retry, time.Now().Add(delay)); qerr != nil {
+		w.Log.Error("Failed to schedule retry", "error", qerr, "recipient_id", job.RecipientID)
+		return false
+	}
+
+	w.Log.Warn("Send failed, retrying later", "error", err, "class", class, "recipient", job.PhoneNumber, "attempt", retry.Attempt, "delay", delay)
+	return true
+}
+
+// checkFrequencyCap reports whether the contact has already received as many
+// marketing templates as the organization's or campaign's cap allows, and if
+// so when the next one may be sent
+func (w *Worker) checkFrequencyCap(job *queue.RecipientJob, campaign *models.BulkMessageCampaign, contactID uuid.UUID) (bool, time.Time, error) {
+	var org models.Organization
+	if err := w.DB.Select("settings").Where("id = ?", job.OrganizationID).First(&org).Error; err != nil {
+		return false, time.Time{}, err
+	}
+	return frequencycap.Check(w.DB, job.OrganizationID, contactID, time.Now(),
+		frequencycap.OrgCap(org.Settings), frequencycap.CampaignCap(campaign))
+}
+
+// deferRecipient re-queues a capped recipient for when its cap frees up. It
+// returns false when deferrals are exhausted or the job could not be queued,
+// in which case the caller marks the recipient capped.
+func (w *Worker) deferRecipient(ctx context.Context, job *queue.RecipientJob, at time.Time) bool {
+	if w.Queue == nil || job.Deferrals >= MaxCapDeferrals {
+		return false
+	}
+
+	deferred := *job
+	deferred.Deferrals++
+	if err := w.Queue.ScheduleRecipient(ctx, &deferred, at); err != nil {
+		w.Log.Error("Failed to defer recipient", "error", err, "recipient_id", job.RecipientID)
+		return false
+	}
+
+	w.DB.Model(&models.BulkMessageRecipient{}).Where("id = ?", job.RecipientID).
+		Update("error_message", fmt.Sprintf("Frequency cap reached, deferred until %s", at.UTC().Format(time.RFC3339)))
+	w.Log.Info("Recipient over frequency cap, deferred", "recipient_id", job.RecipientID, "until", at, "deferrals", deferred.Deferrals)
+	return true
+}
+
+// retryDelay returns the backoff before the given retry attempt, with up to
+// 20% jitter so throttled recipients don't all return at once
+func retryDelay(attempt int) time.Duration {
+	delay := RetryMaxDelay
+	if attempt < 16 {
+		delay = min(RetryBaseDelay<<attempt, RetryMaxDelay)
+	}
+	return delay + rand.N(delay/5+1)
+}
+
 // updateRecipientStatus updates the recipient's status in the database
 func (w *Worker) updateRecipientStatus(recipientID uuid.UUID, status models.MessageStatus, waMessageID, errorMsg string) {
 	updates := map[string]interface{}{
@@ -162,6 +409,7 @@ func (w *Worker) updateRecipientStatus(recipientID uuid.UUID, status models.Mess
 	}
 	if status == models.MessageStatusSent {
 		updates["sent_at"] = time.Now()
+		updates["error_message"] = "" // Drop a note left by a deferral
 	}
 	if errorMsg != "" {
 		updates["error_message"] = errorMsg
@@ -245,6 +493,7 @@ func (w *Worker) sendTemplateMessage(ctx context.Context, account *models.WhatsA
 		BusinessID:  account.BusinessID,
 		APIVersion:  account.APIVersion,
 		AccessToken: account.AccessToken,
+		SendRate:    account.SendRateLimit,
 	}
 
 	// Build template components with parameters
@@ -289,6 +538,8 @@ func (w *Worker) sendTemplateMessage(ctx context.Context, account *models.WhatsA
 		})
 	}
 
+	// Campaign sends yield to agent and chatbot traffic on the same number
+	ctx = whatsapp.WithPriority(ctx, whatsapp.PriorityBulk)
 	return w.WhatsApp.SendTemplateMessage(ctx, waAccount, recipient.PhoneNumber, template.Name, template.Language, components)
 }
 
@@ -6,12 +6,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/config"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
 	"github.com/shridarpatil/whatomate/internal/crypto"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/internal/suppression"
 	"github.com/shridarpatil/whatomate/internal/templateutil"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 	"github.com/shridarpatil/whatomate/test/testutil"
@@ -231,6 +234,156 @@ func TestWorker_HandleRecipientJob_AccountNotFound(t *testing.T) {
 	assert.Contains(t, updatedRecipient.ErrorMessage, "WhatsApp account not found")
 }
 
+func TestWorker_HandleRecipientJob_BlockedContact(t *testing.T) {
+	w := testWorker(t)
+	org, _, _, campaign, recipient := createTestCampaignData(t, w)
+
+	contact, _, err := contactutil.GetOrCreateContact(w.DB, org.ID, recipient.PhoneNumber, recipient.RecipientName)
+	require.NoError(t, err)
+	require.NoError(t, w.DB.Model(contact).Update("is_blocked", true).Error)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+	}
+
+	err = w.HandleRecipientJob(context.Background(), job)
+	require.NoError(t, err)
+
+	// Recipient is skipped without a send attempt and doesn't count as failed
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusBlocked, updatedRecipient.Status)
+	assert.Equal(t, "Contact is blocked", updatedRecipient.ErrorMessage)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Zero(t, updatedCampaign.FailedCount)
+
+	var messages int64
+	w.DB.Model(&models.Message{}).Where("contact_id = ?", contact.ID).Count(&messages)
+	assert.Zero(t, messages)
+}
+
+func TestWorker_HandleRecipientJob_SuppressedContact(t *testing.T) {
+	w := testWorker(t)
+	org, _, _, campaign, recipient := createTestCampaignData(t, w)
+
+	_, err := suppression.Add(w.DB, &models.SuppressionEntry{
+		OrganizationID: org.ID,
+		PhoneNumber:    "+" + recipient.PhoneNumber,
+		Source:         models.SuppressionSourceKeyword,
+	})
+	require.NoError(t, err)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+	}
+
+	err = w.HandleRecipientJob(context.Background(), job)
+	require.NoError(t, err)
+
+	// Recipient is skipped without a send attempt and doesn't count as failed
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusSuppressed, updatedRecipient.Status)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Zero(t, updatedCampaign.FailedCount)
+	assert.Zero(t, updatedCampaign.SentCount)
+}
+
+// sendMarketingTemplate records a marketing template already delivered to the
+// recipient's contact, so that a cap of one is reached
+func sendMarketingTemplate(t *testing.T, w *Worker, org *models.Organization, template *models.Template, phone string) {
+	t.Helper()
+	contact, _, err := contactutil.GetOrCreateContact(w.DB, org.ID, phone, "")
+	require.NoError(t, err)
+	require.NoError(t, w.DB.Create(&models.Message{
+		OrganizationID:  org.ID,
+		WhatsAppAccount: template.WhatsAppAccount,
+		ContactID:       contact.ID,
+		Direction:       models.DirectionOutgoing,
+		MessageType:     models.MessageTypeTemplate,
+		TemplateName:    template.Name,
+		Status:          models.MessageStatusDelivered,
+	}).Error)
+}
+
+func TestWorker_HandleRecipientJob_FrequencyCapped(t *testing.T) {
+	w := testWorker(t)
+	org, _, template, campaign, recipient := createTestCampaignData(t, w)
+
+	require.NoError(t, w.DB.Model(org).Update("settings", models.JSONB{"marketing_cap_max": 1}).Error)
+	sendMarketingTemplate(t, w, org, template, recipient.PhoneNumber)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+	}
+	require.NoError(t, w.HandleRecipientJob(context.Background(), job))
+
+	// Skipped without a send attempt and doesn't count as failed
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusCapped, updatedRecipient.Status)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Zero(t, updatedCampaign.FailedCount)
+	assert.Zero(t, updatedCampaign.SentCount)
+}
+
+func TestWorker_HandleRecipientJob_FrequencyCapDeferred(t *testing.T) {
+	w := testWorker(t)
+	org, _, template, campaign, recipient := createTestCampaignData(t, w)
+	mq := testutil.NewMockQueue()
+	w.Queue = mq
+
+	require.NoError(t, w.DB.Model(campaign).Updates(map[string]any{
+		"frequency_cap_max":          1,
+		"frequency_cap_window_hours": 48,
+		"frequency_cap_action":       models.FrequencyCapActionDefer,
+	}).Error)
+	sendMarketingTemplate(t, w, org, template, recipient.PhoneNumber)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+	}
+	require.NoError(t, w.HandleRecipientJob(context.Background(), job))
+
+	// Re-queued for when the earlier send leaves the window
+	require.Len(t, mq.Scheduled, 1)
+	assert.Equal(t, 1, mq.Scheduled[0].Job.Deferrals)
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), mq.Scheduled[0].At, time.Minute)
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusPending, updatedRecipient.Status)
+
+	// Once deferrals run out the recipient is capped
+	job.Deferrals = MaxCapDeferrals
+	require.NoError(t, w.HandleRecipientJob(context.Background(), job))
+	assert.Len(t, mq.Scheduled, 1)
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusCapped, updatedRecipient.Status)
+}
+
 func TestWorker_HandleRecipientJob_CampaignNotFound(t *testing.T) {
 	w := testWorker(t)
 
@@ -663,6 +816,177 @@ func TestWorker_HandleRecipientJob_WhatsAppError(t *testing.T) {
 	assert.Equal(t, 1, updatedCampaign.FailedCount)
 }
 
+func TestWorker_HandleRecipientJob_RateLimitedSchedulesRetry(t *testing.T) {
+	w := testWorker(t)
+	org, account, _, campaign, recipient := createTestCampaignData(t, w)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"error": map[string]interface{}{
+				"message": "(#130429) Rate limit hit",
+				"code":    130429,
+			},
+		})
+	}))
+	defer server.Close()
+
+	require.NoError(t, w.DB.Model(account).Update("api_version", "v21.0").Error)
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+	mq := testutil.NewMockQueue()
+	w.Queue = mq
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+		TemplateParams: recipient.TemplateParams,
+	}
+
+	start := time.Now()
+	require.NoError(t, w.HandleRecipientJob(context.Background(), job))
+
+	// Re-queued with backoff instead of failing
+	require.Len(t, mq.Scheduled, 1)
+	assert.Equal(t, 1, mq.Scheduled[0].Job.Attempt)
+	assert.Equal(t, recipient.ID, mq.Scheduled[0].Job.RecipientID)
+	assert.True(t, mq.Scheduled[0].At.After(start.Add(RetryBaseDelay-time.Second)))
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusPending, updatedRecipient.Status)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 0, updatedCampaign.FailedCount)
+}
+
+func TestWorker_HandleRecipientJob_SkipsRecipientNoLongerPending(t *testing.T) {
+	w := testWorker(t)
+	org, account, _, campaign, recipient := createTestCampaignData(t, w)
+
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	require.NoError(t, w.DB.Model(account).Update("api_version", "v21.0").Error)
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+	require.NoError(t, w.DB.Model(recipient).Update("status", models.MessageStatusSent).Error)
+
+	// A first-attempt job (e.g. enqueued twice by a pause and resume) still checks
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+		TemplateParams: recipient.TemplateParams,
+	}
+
+	require.NoError(t, w.HandleRecipientJob(context.Background(), job))
+	assert.Zero(t, hits)
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusSent, updatedRecipient.Status)
+}
+
+func TestWorker_HandleRecipientJob_RetriesExhausted(t *testing.T) {
+	w := testWorker(t)
+	org, account, template, campaign, recipient := createTestCampaignData(t, w)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"error": map[string]interface{}{
+				"message": "(#131016) Service unavailable",
+				"code":    131016,
+			},
+		})
+	}))
+	defer server.Close()
+
+	require.NoError(t, w.DB.Model(account).Update("api_version", "v21.0").Error)
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+	mq := testutil.NewMockQueue()
+	w.Queue = mq
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+		TemplateParams: recipient.TemplateParams,
+		Attempt:        MaxSendAttempts - 1,
+	}
+
+	require.NoError(t, w.HandleRecipientJob(context.Background(), job))
+	assert.Empty(t, mq.Scheduled)
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusFailed, updatedRecipient.Status)
+
+	var message models.Message
+	require.NoError(t, w.DB.Where("template_name = ?", template.Name).First(&message).Error)
+	assert.Equal(t, "retryable", message.Metadata["error_class"])
+}
+
+func TestWorker_HandleRecipientJob_PermanentErrorNotRetried(t *testing.T) {
+	w := testWorker(t)
+	org, account, _, campaign, recipient := createTestCampaignData(t, w)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"error": map[string]interface{}{
+				"message": "(#132015) Template is paused",
+				"code":    132015,
+			},
+		})
+	}))
+	defer server.Close()
+
+	require.NoError(t, w.DB.Model(account).Update("api_version", "v21.0").Error)
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+	mq := testutil.NewMockQueue()
+	w.Queue = mq
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+		TemplateParams: recipient.TemplateParams,
+	}
+
+	require.NoError(t, w.HandleRecipientJob(context.Background(), job))
+	assert.Empty(t, mq.Scheduled)
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusFailed, updatedRecipient.Status)
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt, base := range []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		d := retryDelay(attempt)
+		assert.GreaterOrEqual(t, d, base)
+		assert.LessOrEqual(t, d, base+base/5)
+	}
+	assert.LessOrEqual(t, retryDelay(40), RetryMaxDelay+RetryMaxDelay/5)
+	assert.GreaterOrEqual(t, retryDelay(40), RetryMaxDelay)
+}
+
 func TestWorker_HandleRecipientJob_CreatesContact(t *testing.T) {
 	w := testWorker(t)
 	org, account, _, campaign, recipient := createTestCampaignData(t, w)
@@ -886,6 +1210,96 @@ func TestWorker_HandleRecipientJob_WithEncryptedToken(t *testing.T) {
 	assert.Equal(t, models.MessageStatusSent, message.Status)
 }
 
+// createPoolAccount adds another account to the campaign's organization and
+// its sender pool
+func createPoolAccount(t *testing.T, w *Worker, campaign *models.BulkMessageCampaign, opts func(*models.WhatsAppAccount)) *models.WhatsAppAccount {
+	t.Helper()
+	uniqueID := uuid.New().String()[:8]
+	account := &models.WhatsAppAccount{
+		OrganizationID: campaign.OrganizationID,
+		Name:           "pool-account-" + uniqueID,
+		PhoneID:        "phone-" + uniqueID,
+		BusinessID:     "business-" + uniqueID,
+		AccessToken:    "test-token",
+		APIVersion:     "v21.0",
+	}
+	if opts != nil {
+		opts(account)
+	}
+	require.NoError(t, w.DB.Create(account).Error)
+	campaign.SenderAccounts = append(campaign.SenderAccounts, account.Name)
+	require.NoError(t, w.DB.Model(campaign).Update("sender_accounts", campaign.SenderAccounts).Error)
+	return account
+}
+
+func TestWorker_HandleRecipientJob_SenderPoolSticky(t *testing.T) {
+	w := testWorker(t)
+	org, account, _, campaign, recipient := createTestCampaignData(t, w)
+	require.NoError(t, w.DB.Model(account).Update("api_version", "v21.0").Error)
+
+	preferred := createPoolAccount(t, w, campaign, nil)
+	require.NoError(t, w.DB.Model(campaign).Update("sender_strategy", models.SenderStrategySticky).Error)
+
+	// The contact already talks to the second number
+	contact, _, err := contactutil.GetOrCreateContact(w.DB, org.ID, recipient.PhoneNumber, recipient.RecipientName)
+	require.NoError(t, err)
+	require.NoError(t, w.DB.Model(contact).Update("whats_app_account", preferred.Name).Error)
+
+	var capturedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		capturedPath = r.URL.Path
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"messages": []map[string]interface{}{{"id": "wamid.pool123"}},
+		})
+	}))
+	defer server.Close()
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+
+	err = w.HandleRecipientJob(context.Background(), &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+		TemplateParams: recipient.TemplateParams,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, capturedPath, preferred.PhoneID)
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusSent, updatedRecipient.Status)
+	assert.Equal(t, preferred.Name, updatedRecipient.WhatsAppAccount)
+
+	var message models.Message
+	require.NoError(t, w.DB.Where("whats_app_message_id = ?", "wamid.pool123").First(&message).Error)
+	assert.Equal(t, preferred.Name, message.WhatsAppAccount)
+}
+
+func TestWorker_HandleRecipientJob_SenderPoolSkipsUnusable(t *testing.T) {
+	w := testWorker(t)
+	org, account, _, campaign, recipient := createTestCampaignData(t, w)
+	require.NoError(t, w.DB.Model(account).Update("quality_rating", models.QualityRatingRed).Error)
+	createPoolAccount(t, w, campaign, func(a *models.WhatsAppAccount) {
+		a.Status = models.AccountStatusAuthFailed
+	})
+
+	err := w.HandleRecipientJob(context.Background(), &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+	})
+	require.NoError(t, err)
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusFailed, updatedRecipient.Status)
+	assert.Contains(t, updatedRecipient.ErrorMessage, "No usable sender account")
+}
+
 // Unit tests for parameter resolution functions (no database required)
 
 func TestResolveTemplateParams_NamedParams(t *testing.T) {
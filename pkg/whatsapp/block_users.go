@@ -0,0 +1,144 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// BlockedUser is a user on a phone number's block list
+type BlockedUser struct {
+	Input string `json:"input,omitempty"` // The phone number as sent
+	WaID  string `json:"wa_id"`
+}
+
+// BlockUserFailure is a user Meta could not block or unblock
+type BlockUserFailure struct {
+	Input  string `json:"input"`
+	Errors []struct {
+		Code      int    `json:"code"`
+		Message   string `json:"message"`
+		ErrorData struct {
+			Details string `json:"details"`
+		} `json:"error_data"`
+	} `json:"errors"`
+}
+
+// Reason returns Meta's explanation of the failure
+func (f BlockUserFailure) Reason() string {
+	if len(f.Errors) == 0 {
+		return "unknown error"
+	}
+	if f.Errors[0].ErrorData.Details != "" {
+		return f.Errors[0].ErrorData.Details
+	}
+	return f.Errors[0].Message
+}
+
+// BlockUsersResult lists the users a block or unblock call changed and those
+// it failed for
+type BlockUsersResult struct {
+	Users  []BlockedUser
+	Failed []BlockUserFailure
+}
+
+// BlockedUsersPage is one page of a phone number's block list
+type BlockedUsersPage struct {
+	Users []BlockedUser
+	After string // Cursor of the next page, empty on the last one
+}
+
+// BlockUsers blocks users from messaging the phone number. Users are phone
+// numbers or WhatsApp IDs.
+// Calls POST /{api_version}/{phone_number_id}/block_users
+func (c *Client) BlockUsers(ctx context.Context, account *Account, users []string) (*BlockUsersResult, error) {
+	return c.blockUsers(ctx, account, http.MethodPost, users, "block users")
+}
+
+// UnblockUsers lets blocked users message the phone number again.
+// Calls DELETE /{api_version}/{phone_number_id}/block_users
+func (c *Client) UnblockUsers(ctx context.Context, account *Account, users []string) (*BlockUsersResult, error) {
+	return c.blockUsers(ctx, account, http.MethodDelete, users, "unblock users")
+}
+
+func (c *Client) blockUsers(ctx context.Context, account *Account, method string, users []string, action string) (*BlockUsersResult, error) {
+	reqURL := fmt.Sprintf("%s/%s/%s/block_users", c.getBaseURL(), account.APIVersion, account.PhoneID)
+
+	list := make([]map[string]string, len(users))
+	for i, u := range users {
+		list[i] = map[string]string{"user": u}
+	}
+	body := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"block_users":       list,
+	}
+
+	respBody, err := c.doRequest(ctx, method, reqURL, body, account.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s: %w", action, err)
+	}
+
+	var resp struct {
+		BlockUsers struct {
+			AddedUsers   []BlockedUser      `json:"added_users"`
+			RemovedUsers []BlockedUser      `json:"removed_users"`
+			FailedUsers  []BlockUserFailure `json:"failed_users"`
+		} `json:"block_users"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse block users response: %w", err)
+	}
+
+	result := &BlockUsersResult{Failed: resp.BlockUsers.FailedUsers}
+	if method == http.MethodPost {
+		result.Users = resp.BlockUsers.AddedUsers
+	} else {
+		result.Users = resp.BlockUsers.RemovedUsers
+	}
+	return result, nil
+}
+
+// ListBlockedUsers returns a page of the phone number's block list. Pass the
+// previous page's After cursor to continue; limit 0 uses Meta's default.
+// Calls GET /{api_version}/{phone_number_id}/block_users
+func (c *Client) ListBlockedUsers(ctx context.Context, account *Account, limit int, after string) (*BlockedUsersPage, error) {
+	params := url.Values{}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if after != "" {
+		params.Set("after", after)
+	}
+	reqURL := fmt.Sprintf("%s/%s/%s/block_users", c.getBaseURL(), account.APIVersion, account.PhoneID)
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	respBody, err := c.doRequest(ctx, http.MethodGet, reqURL, nil, account.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked users: %w", err)
+	}
+
+	var resp struct {
+		Data   []BlockedUser `json:"data"`
+		Paging struct {
+			Cursors struct {
+				After string `json:"after"`
+			} `json:"cursors"`
+			Next string `json:"next"`
+		} `json:"paging"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse blocked users response: %w", err)
+	}
+
+	page := &BlockedUsersPage{Users: resp.Data}
+	// Meta omits "next" on the last page but may still return a cursor
+	if resp.Paging.Next != "" {
+		page.After = resp.Paging.Cursors.After
+	}
+	return page, nil
+}
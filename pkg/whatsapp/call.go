@@ -111,7 +111,7 @@ func (c *Client) SendCallPermissionRequest(ctx context.Context, account *Account
 	url := c.buildMessagesURL(account)
 	c.Log.Info("Sending call permission request", "phone", phoneNumber)
 
-	respBody, err := c.doRequest(ctx, http.MethodPost, url, payload, account.AccessToken)
+	respBody, err := c.postMessage(ctx, account, url, payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to send call permission request: %w", err)
 	}
@@ -163,3 +163,137 @@ func (c *Client) DeleteProduct(ctx context.Context, account *Account, productID
 	_, err := c.doRequest(ctx, http.MethodDelete, apiURL, nil, account.AccessToken)
 	return err
 }
+
+// Product message limits imposed by the Cloud API
+const (
+	MaxProductSections     = 10
+	MaxProductsPerList     = 30
+	maxProductSectionTitle = 24
+	maxProductHeaderText   = 60
+)
+
+// SendProductMessage sends a single-product interactive message
+func (c *Client) SendProductMessage(ctx context.Context, account *Account, phoneNumber, catalogID, retailerID, bodyText, footerText string) (string, error) {
+	if catalogID == "" || retailerID == "" {
+		return "", fmt.Errorf("catalog ID and product retailer ID are required")
+	}
+
+	interactive := map[string]interface{}{
+		"type": "product",
+		"action": map[string]interface{}{
+			"catalog_id":          catalogID,
+			"product_retailer_id": retailerID,
+		},
+	}
+	if bodyText != "" {
+		interactive["body"] = map[string]interface{}{"text": bodyText}
+	}
+	if footerText != "" {
+		interactive["footer"] = map[string]interface{}{"text": footerText}
+	}
+
+	return c.sendInteractiveMessage(ctx, account, phoneNumber, interactive)
+}
+
+// SendProductListMessage sends a multi-product interactive message.
+// Header and body text are required by WhatsApp for this message type.
+func (c *Client) SendProductListMessage(ctx context.Context, account *Account, phoneNumber, catalogID, headerText, bodyText, footerText string, sections []ProductSection) (string, error) {
+	if catalogID == "" {
+		return "", fmt.Errorf("catalog ID is required")
+	}
+	if headerText == "" || bodyText == "" {
+		return "", fmt.Errorf("header and body text are required")
+	}
+	if len(sections) == 0 {
+		return "", fmt.Errorf("at least one product section is required")
+	}
+	if len(sections) > MaxProductSections {
+		return "", fmt.Errorf("maximum %d product sections allowed", MaxProductSections)
+	}
+
+	total := 0
+	apiSections := make([]map[string]interface{}, 0, len(sections))
+	for i, section := range sections {
+		if len(section.RetailerIDs) == 0 {
+			return "", fmt.Errorf("product section %d has no products", i+1)
+		}
+		title := section.Title
+		if title == "" {
+			if len(sections) > 1 {
+				return "", fmt.Errorf("product section %d needs a title", i+1)
+			}
+			title = headerText
+		}
+		if len(title) > maxProductSectionTitle {
+			title = title[:maxProductSectionTitle]
+		}
+
+		items := make([]map[string]interface{}, len(section.RetailerIDs))
+		for j, id := range section.RetailerIDs {
+			items[j] = map[string]interface{}{"product_retailer_id": id}
+		}
+		total += len(items)
+
+		apiSections = append(apiSections, map[string]interface{}{
+			"title":         title,
+			"product_items": items,
+		})
+	}
+	if total > MaxProductsPerList {
+		return "", fmt.Errorf("maximum %d products allowed", MaxProductsPerList)
+	}
+
+	if len(headerText) > maxProductHeaderText {
+		headerText = headerText[:maxProductHeaderText]
+	}
+
+	interactive := map[string]interface{}{
+		"type": "product_list",
+		"header": map[string]interface{}{
+			"type": "text",
+			"text": headerText,
+		},
+		"body": map[string]interface{}{
+			"text": bodyText,
+		},
+		"action": map[string]interface{}{
+			"catalog_id": catalogID,
+			"sections":   apiSections,
+		},
+	}
+	if footerText != "" {
+		interactive["footer"] = map[string]interface{}{"text": footerText}
+	}
+
+	return c.sendInteractiveMessage(ctx, account, phoneNumber, interactive)
+}
+
+// SendCatalogMessage sends an interactive message with a "View catalog" button.
+// thumbnailRetailerID optionally picks the product shown as the thumbnail.
+func (c *Client) SendCatalogMessage(ctx context.Context, account *Account, phoneNumber, bodyText, footerText, thumbnailRetailerID string) (string, error) {
+	if bodyText == "" {
+		return "", fmt.Errorf("body text is required")
+	}
+
+	action := map[string]interface{}{
+		"name": "catalog_message",
+	}
+	if thumbnailRetailerID != "" {
+		action["parameters"] = map[string]interface{}{
+			"thumbnail_product_retailer_id": thumbnailRetailerID,
+		}
+	}
+
+	interactive := map[string]interface{}{
+		"type": "catalog_message",
+		"body": map[string]interface{}{
+			"text": bodyText,
+		},
+		"action": action,
+	}
+	if footerText != "" {
+		interactive["footer"] = map[string]interface{}{"text": footerText}
+	}
+
+	return c.sendInteractiveMessage(ctx, account, phoneNumber, interactive)
+}
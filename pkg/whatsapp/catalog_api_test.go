@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/test/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -294,3 +295,126 @@ func TestClient_DeleteProduct_APIError(t *testing.T) {
 	err := client.DeleteProduct(context.Background(), account, "nonexistent")
 	require.Error(t, err)
 }
+
+// --- Product messages ---
+
+// captureInteractive starts a server that records the interactive object of the sent message.
+func captureInteractive(t *testing.T, captured *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "interactive", body["type"])
+		*captured, _ = body["interactive"].(map[string]interface{})
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]string{{"id": "wamid.product123"}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_SendProductMessage_Success(t *testing.T) {
+	t.Parallel()
+
+	var interactive map[string]interface{}
+	server := captureInteractive(t, &interactive)
+	client := newTestClient(t, server)
+
+	id, err := client.SendProductMessage(context.Background(), testAccount(server.URL), "1234567890", "cat-1", "SKU-1", "Check this out", "")
+	require.NoError(t, err)
+	assert.Equal(t, "wamid.product123", id)
+
+	assert.Equal(t, "product", interactive["type"])
+	action := interactive["action"].(map[string]interface{})
+	assert.Equal(t, "cat-1", action["catalog_id"])
+	assert.Equal(t, "SKU-1", action["product_retailer_id"])
+	assert.NotContains(t, interactive, "footer")
+}
+
+func TestClient_SendProductMessage_RequiresRetailerID(t *testing.T) {
+	t.Parallel()
+
+	client := whatsapp.New(testutil.NopLogger())
+	_, err := client.SendProductMessage(context.Background(), testAccount(""), "1234567890", "cat-1", "", "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retailer ID")
+}
+
+func TestClient_SendProductListMessage_Success(t *testing.T) {
+	t.Parallel()
+
+	var interactive map[string]interface{}
+	server := captureInteractive(t, &interactive)
+	client := newTestClient(t, server)
+
+	sections := []whatsapp.ProductSection{{RetailerIDs: []string{"SKU-1", "SKU-2"}}}
+	_, err := client.SendProductListMessage(context.Background(), testAccount(server.URL), "1234567890", "cat-1", "Our picks", "Tap to view", "", sections)
+	require.NoError(t, err)
+
+	assert.Equal(t, "product_list", interactive["type"])
+	header := interactive["header"].(map[string]interface{})
+	assert.Equal(t, "Our picks", header["text"])
+
+	action := interactive["action"].(map[string]interface{})
+	apiSections := action["sections"].([]interface{})
+	require.Len(t, apiSections, 1)
+	section := apiSections[0].(map[string]interface{})
+	assert.Equal(t, "Our picks", section["title"], "single untitled section falls back to the header")
+	assert.Len(t, section["product_items"], 2)
+}
+
+func TestClient_SendProductListMessage_Validation(t *testing.T) {
+	t.Parallel()
+
+	tooMany := make([]string, whatsapp.MaxProductsPerList+1)
+	for i := range tooMany {
+		tooMany[i] = "SKU"
+	}
+
+	tests := []struct {
+		name         string
+		header       string
+		sections     []whatsapp.ProductSection
+		wantContains string
+	}{
+		{"missing header", "", []whatsapp.ProductSection{{RetailerIDs: []string{"a"}}}, "header and body"},
+		{"no sections", "Header", nil, "at least one"},
+		{"empty section", "Header", []whatsapp.ProductSection{{Title: "A"}}, "no products"},
+		{"untitled section among many", "Header", []whatsapp.ProductSection{
+			{Title: "A", RetailerIDs: []string{"a"}},
+			{RetailerIDs: []string{"b"}},
+		}, "needs a title"},
+		{"too many products", "Header", []whatsapp.ProductSection{{RetailerIDs: tooMany}}, "maximum"},
+	}
+
+	client := whatsapp.New(testutil.NopLogger())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.SendProductListMessage(context.Background(), testAccount(""), "1234567890", "cat-1", tt.header, "Body", "", tt.sections)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantContains)
+		})
+	}
+}
+
+func TestClient_SendCatalogMessage_Success(t *testing.T) {
+	t.Parallel()
+
+	var interactive map[string]interface{}
+	server := captureInteractive(t, &interactive)
+	client := newTestClient(t, server)
+
+	_, err := client.SendCatalogMessage(context.Background(), testAccount(server.URL), "1234567890", "Browse our catalog", "Free shipping", "SKU-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "catalog_message", interactive["type"])
+	action := interactive["action"].(map[string]interface{})
+	assert.Equal(t, "catalog_message", action["name"])
+	params := action["parameters"].(map[string]interface{})
+	assert.Equal(t, "SKU-1", params["thumbnail_product_retailer_id"])
+	footer := interactive["footer"].(map[string]interface{})
+	assert.Equal(t, "Free shipping", footer["text"])
+}
@@ -23,7 +23,9 @@ const (
 type Client struct {
 	HTTPClient *http.Client
 	Log        logf.Logger
-	baseURL    string // For testing with mock servers
+	// Limiter paces message sends per phone number when set
+	Limiter RateLimiter
+	baseURL string // For testing with mock servers
 }
 
 // New creates a new WhatsApp client
@@ -98,23 +100,23 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var apiErr MetaAPIError
-		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Error.Message != "" {
-			errMsg := fmt.Sprintf("API error %d: %s", apiErr.Error.Code, apiErr.Error.Message)
-			if apiErr.Error.ErrorData.Details != "" {
-				errMsg += " - Details: " + apiErr.Error.ErrorData.Details
-			}
-			if apiErr.Error.ErrorUserMsg != "" {
-				errMsg += " - " + apiErr.Error.ErrorUserMsg
-			}
-			return nil, fmt.Errorf("%s", errMsg)
-		}
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, parseAPIError(resp.StatusCode, respBody)
 	}
 
 	return respBody, nil
 }
 
+// postMessage sends a message payload once the phone number's rate limiter
+// allows it
+func (c *Client) postMessage(ctx context.Context, account *Account, url string, payload interface{}) ([]byte, error) {
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(ctx, account.PhoneID, account.SendRate, PriorityFromContext(ctx)); err != nil {
+			return nil, fmt.Errorf("waiting for send rate limit: %w", err)
+		}
+	}
+	return c.doRequest(ctx, http.MethodPost, url, payload, account.AccessToken)
+}
+
 // CredentialsValidationResult contains the result of credentials validation
 type CredentialsValidationResult struct {
 	PhoneNumber            string
@@ -319,7 +321,7 @@ func (c *Client) UploadMedia(ctx context.Context, account *Account, data []byte,
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return "", fmt.Errorf("media upload failed with status %d: %s", resp.StatusCode, string(respBody))
+		return "", fmt.Errorf("media upload failed: %w", parseAPIError(resp.StatusCode, respBody))
 	}
 
 	var uploadResp UploadMediaResponse
@@ -348,7 +350,7 @@ func (c *Client) sendMediaMessage(ctx context.Context, account *Account, phoneNu
 	url := c.buildMessagesURL(account)
 	c.Log.Debug("Sending media message", "type", mediaType, "phone", phoneNumber, "media_id", mediaFields["id"])
 
-	respBody, err := c.doRequest(ctx, "POST", url, payload, account.AccessToken)
+	respBody, err := c.postMessage(ctx, account, url, payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to send %s message: %w", mediaType, err)
 	}
@@ -395,6 +397,13 @@ func (c *Client) SendAudioMessage(ctx context.Context, account *Account, phoneNu
 	})
 }
 
+// SendStickerMessage sends a sticker using a media ID. Stickers must be WebP images.
+func (c *Client) SendStickerMessage(ctx context.Context, account *Account, phoneNumber, mediaID string) (string, error) {
+	return c.sendMediaMessage(ctx, account, phoneNumber, "sticker", map[string]interface{}{
+		"id": mediaID,
+	})
+}
+
 // MarkMessageRead sends a read receipt for a message
 func (c *Client) MarkMessageRead(ctx context.Context, account *Account, messageID string) error {
 	payload := map[string]interface{}{
@@ -406,7 +415,7 @@ func (c *Client) MarkMessageRead(ctx context.Context, account *Account, messageI
 	url := c.buildMessagesURL(account)
 	c.Log.Debug("Sending read receipt", "message_id", messageID)
 
-	_, err := c.doRequest(ctx, "POST", url, payload, account.AccessToken)
+	_, err := c.postMessage(ctx, account, url, payload)
 	if err != nil {
 		return fmt.Errorf("failed to send read receipt: %w", err)
 	}
@@ -484,7 +493,7 @@ func (c *Client) ResumableUpload(ctx context.Context, account *Account, data []b
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return "", fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(respBody))
+		return "", fmt.Errorf("upload failed: %w", parseAPIError(resp.StatusCode, respBody))
 	}
 
 	var finishResp ResumableUploadFinishResponse
@@ -0,0 +1,67 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Granular scopes Embedded Signup grants on the onboarded business accounts
+const (
+	ScopeBusinessManagement = "whatsapp_business_management"
+	ScopeBusinessMessaging  = "whatsapp_business_messaging"
+)
+
+// BusinessToken is the token a business grants the app through Embedded Signup
+type BusinessToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in,omitempty"` // Seconds; zero for tokens that never expire
+}
+
+// ExchangeCode exchanges the code returned by Embedded Signup for a business
+// token. Codes are single use and expire after a few minutes.
+// Calls GET /{api_version}/oauth/access_token
+func (c *Client) ExchangeCode(ctx context.Context, appID, appSecret, code, apiVersion string) (*BusinessToken, error) {
+	params := url.Values{}
+	params.Set("client_id", appID)
+	params.Set("client_secret", appSecret)
+	params.Set("code", code)
+	reqURL := fmt.Sprintf("%s/%s/oauth/access_token?%s", c.getBaseURL(), apiVersion, params.Encode())
+
+	respBody, err := c.doRequest(ctx, http.MethodGet, reqURL, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange signup code: %w", err)
+	}
+
+	var token BusinessToken
+	if err := json.Unmarshal(respBody, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("failed to exchange signup code: no access token returned")
+	}
+	return &token, nil
+}
+
+// ListPhoneNumbers returns the phone numbers of a WhatsApp Business Account.
+// Calls GET /{api_version}/{waba_id}/phone_numbers
+func (c *Client) ListPhoneNumbers(ctx context.Context, businessID, accessToken, apiVersion string) ([]PhoneNumberHealth, error) {
+	fields := "id,display_phone_number,verified_name,quality_rating,messaging_limit_tier,name_status,status"
+	reqURL := fmt.Sprintf("%s/%s/%s/phone_numbers?fields=%s", c.getBaseURL(), apiVersion, businessID, fields)
+
+	respBody, err := c.doRequest(ctx, http.MethodGet, reqURL, nil, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list phone numbers: %w", err)
+	}
+
+	var resp struct {
+		Data []PhoneNumberHealth `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse phone numbers list: %w", err)
+	}
+	return resp.Data, nil
+}
@@ -0,0 +1,159 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrorClass tells callers how to react to a failed API call
+type ErrorClass string
+
+const (
+	// ErrorClassRetryable is a transient Meta-side failure; the same request
+	// may succeed if sent again later.
+	ErrorClassRetryable ErrorClass = "retryable"
+	// ErrorClassRateLimited means a throughput or pair rate limit was hit;
+	// retry after backing off.
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	// ErrorClassPermanent means the request will keep failing as-is (invalid
+	// number, closed re-engagement window, paused template, bad parameter).
+	ErrorClassPermanent ErrorClass = "permanent"
+	// ErrorClassAuth means the access token is invalid, expired or lacks
+	// permission. Retrying will not help until the account is fixed.
+	ErrorClassAuth ErrorClass = "auth"
+)
+
+// Meta error codes referenced by the classification.
+// See https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+const (
+	ErrCodeAccessTokenExpired  = 190
+	ErrCodeAPITooManyCalls     = 4
+	ErrCodeRateLimitHit        = 80007
+	ErrCodeThroughputReached   = 130429
+	ErrCodeSpamRateLimit       = 131048
+	ErrCodePairRateLimit       = 131056
+	ErrCodeReEngagement        = 131047
+	ErrCodeUndeliverable       = 131026
+	ErrCodeTemplatePaused      = 132015
+	ErrCodeTemplateDisabled    = 132016
+	ErrCodeServiceUnavailable  = 131016
+	ErrCodeGenericUserError    = 131000
+	ErrCodeMaintenanceMode     = 131057
+	ErrCodeServerUnavailable   = 133004
+	ErrCodeRegisterRateLimited = 133016
+	ErrCodeBlockUsersFailed    = 139100 // e.g. the user has not messaged in the last 24 hours
+)
+
+// APIError is a failed Graph API call. Every non-2xx response from the
+// client is returned as an *APIError (possibly wrapped), so callers can use
+// AsAPIError or errors.As to inspect it.
+type APIError struct {
+	StatusCode  int    // HTTP status of the response
+	Code        int    // Meta error code, 0 if the body was not a Graph error
+	Subcode     int    // Meta error_subcode
+	Type        string // e.g. OAuthException
+	Message     string
+	Details     string // error_data.details
+	UserMessage string // error_user_msg
+	FBTraceID   string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == 0 && e.Type == "" {
+		return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Message)
+	}
+	msg := fmt.Sprintf("API error %d: %s", e.Code, e.Message)
+	if e.Details != "" {
+		msg += " - Details: " + e.Details
+	}
+	if e.UserMessage != "" {
+		msg += " - " + e.UserMessage
+	}
+	return msg
+}
+
+// Class classifies the error by its Meta code, falling back to the HTTP
+// status for responses that carry no Graph error body.
+func (e *APIError) Class() ErrorClass {
+	switch e.Code {
+	case ErrCodeAPITooManyCalls, ErrCodeRateLimitHit, ErrCodeThroughputReached,
+		ErrCodeSpamRateLimit, ErrCodePairRateLimit, ErrCodeRegisterRateLimited,
+		17, 32, 613:
+		return ErrorClassRateLimited
+	case ErrCodeAccessTokenExpired, 3, 10, 102:
+		return ErrorClassAuth
+	case 1, 2, ErrCodeGenericUserError, ErrCodeServiceUnavailable,
+		ErrCodeMaintenanceMode, ErrCodeServerUnavailable:
+		return ErrorClassRetryable
+	}
+	if e.Code >= 200 && e.Code <= 299 {
+		return ErrorClassAuth
+	}
+	if e.Code != 0 {
+		return ErrorClassPermanent
+	}
+
+	switch {
+	case e.Type == "OAuthException":
+		return ErrorClassAuth
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case e.StatusCode >= 500:
+		return ErrorClassRetryable
+	}
+	return ErrorClassPermanent
+}
+
+// Retryable reports whether sending the same request again later may succeed
+func (e *APIError) Retryable() bool {
+	c := e.Class()
+	return c == ErrorClassRetryable || c == ErrorClassRateLimited
+}
+
+// AsAPIError returns the *APIError in err's chain, if any
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// ClassifyError classifies any error returned by the client. Errors that
+// never reached Meta (timeouts, connection resets) are treated as retryable.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.Class()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorClassRetryable
+	}
+	return ErrorClassPermanent
+}
+
+// parseAPIError builds an APIError from a non-2xx response body
+func parseAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	var meta MetaAPIError
+	if err := json.Unmarshal(body, &meta); err == nil && meta.Error.Message != "" {
+		apiErr.Code = meta.Error.Code
+		apiErr.Subcode = meta.Error.ErrorSubcode
+		apiErr.Type = meta.Error.Type
+		apiErr.Message = meta.Error.Message
+		apiErr.Details = meta.Error.ErrorData.Details
+		apiErr.UserMessage = meta.Error.ErrorUserMsg
+		apiErr.FBTraceID = meta.Error.FBTraceID
+		return apiErr
+	}
+	apiErr.Message = string(body)
+	return apiErr
+}
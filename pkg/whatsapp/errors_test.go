@@ -0,0 +1,106 @@
+package whatsapp_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError_Class(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		err   whatsapp.APIError
+		want  whatsapp.ErrorClass
+		retry bool
+	}{
+		{"throughput", whatsapp.APIError{StatusCode: 400, Code: 130429}, whatsapp.ErrorClassRateLimited, true},
+		{"pair rate limit", whatsapp.APIError{StatusCode: 400, Code: 131056}, whatsapp.ErrorClassRateLimited, true},
+		{"app rate limit", whatsapp.APIError{StatusCode: 400, Code: 4}, whatsapp.ErrorClassRateLimited, true},
+		{"service unavailable", whatsapp.APIError{StatusCode: 503, Code: 131016}, whatsapp.ErrorClassRetryable, true},
+		{"unknown code on 5xx", whatsapp.APIError{StatusCode: 502}, whatsapp.ErrorClassRetryable, true},
+		{"plain 429", whatsapp.APIError{StatusCode: 429}, whatsapp.ErrorClassRateLimited, true},
+		{"re-engagement window", whatsapp.APIError{StatusCode: 400, Code: 131047}, whatsapp.ErrorClassPermanent, false},
+		{"undeliverable", whatsapp.APIError{StatusCode: 400, Code: 131026}, whatsapp.ErrorClassPermanent, false},
+		{"template paused", whatsapp.APIError{StatusCode: 400, Code: 132015}, whatsapp.ErrorClassPermanent, false},
+		{"invalid parameter", whatsapp.APIError{StatusCode: 400, Code: 100}, whatsapp.ErrorClassPermanent, false},
+		{"expired token", whatsapp.APIError{StatusCode: 401, Code: 190}, whatsapp.ErrorClassAuth, false},
+		{"permission", whatsapp.APIError{StatusCode: 403, Code: 200}, whatsapp.ErrorClassAuth, false},
+		{"oauth without code", whatsapp.APIError{StatusCode: 400, Type: "OAuthException"}, whatsapp.ErrorClassAuth, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.err.Class())
+			assert.Equal(t, tt.retry, tt.err.Retryable())
+		})
+	}
+}
+
+func TestClient_ReturnsTypedAPIError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"(#131056) (Business Account, Consumer Account) pair rate limit hit","type":"OAuthException","code":131056,"error_subcode":2494055,"error_data":{"details":"Message failed to send because there were too many messages sent from this phone number to the same phone number in a short period of time."},"fbtrace_id":"AbCdEf123"}}`))
+	}))
+	defer server.Close()
+
+	client := whatsapp.NewWithBaseURL(testutil.NopLogger(), server.URL)
+	_, err := client.SendTextMessage(testutil.TestContext(t), testAccount(server.URL), "1234567890", "Hello")
+	require.Error(t, err)
+
+	apiErr, ok := whatsapp.AsAPIError(err)
+	require.True(t, ok, "expected *APIError in chain, got %T", err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, 131056, apiErr.Code)
+	assert.Equal(t, 2494055, apiErr.Subcode)
+	assert.Equal(t, "AbCdEf123", apiErr.FBTraceID)
+	assert.Equal(t, whatsapp.ErrorClassRateLimited, whatsapp.ClassifyError(err))
+	assert.Contains(t, err.Error(), "API error 131056")
+	assert.Contains(t, err.Error(), "Details: Message failed to send")
+}
+
+func TestClient_NonGraphErrorBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("upstream unavailable"))
+	}))
+	defer server.Close()
+
+	client := whatsapp.NewWithBaseURL(testutil.NopLogger(), server.URL)
+	_, err := client.SendTextMessage(testutil.TestContext(t), testAccount(server.URL), "1234567890", "Hello")
+	require.Error(t, err)
+
+	apiErr, ok := whatsapp.AsAPIError(err)
+	require.True(t, ok)
+	assert.Equal(t, 0, apiErr.Code)
+	assert.True(t, apiErr.Retryable())
+	assert.Contains(t, err.Error(), "API returned status 503: upstream unavailable")
+}
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	wrapped := fmt.Errorf("failed to send template: %w", &whatsapp.APIError{StatusCode: 400, Code: 132015})
+	assert.Equal(t, whatsapp.ErrorClassPermanent, whatsapp.ClassifyError(wrapped))
+	assert.Equal(t, whatsapp.ErrorClass(""), whatsapp.ClassifyError(nil))
+	assert.Equal(t, whatsapp.ErrorClassPermanent, whatsapp.ClassifyError(errors.New("template is required")))
+
+	// Transport errors never reached Meta and are worth retrying
+	client := whatsapp.NewWithBaseURL(testutil.NopLogger(), "http://127.0.0.1:1")
+	_, err := client.SendTextMessage(testutil.TestContext(t), testAccount("http://127.0.0.1:1"), "1234567890", "Hello")
+	require.Error(t, err)
+	assert.Equal(t, whatsapp.ErrorClassRetryable, whatsapp.ClassifyError(err))
+}
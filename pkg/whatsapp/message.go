@@ -32,7 +32,7 @@ func (c *Client) SendTextMessage(ctx context.Context, account *Account, phoneNum
 	url := c.buildMessagesURL(account)
 	c.Log.Debug("Sending text message", "phone", phoneNumber, "url", url)
 
-	respBody, err := c.doRequest(ctx, "POST", url, payload, account.AccessToken)
+	respBody, err := c.postMessage(ctx, account, url, payload)
 	if err != nil {
 		c.Log.Error("Failed to send text message", "error", err, "phone", phoneNumber)
 		return "", fmt.Errorf("failed to send text message: %w", err)
@@ -132,7 +132,7 @@ func (c *Client) SendInteractiveButtons(ctx context.Context, account *Account, p
 	url := c.buildMessagesURL(account)
 	c.Log.Debug("Sending interactive message", "phone", phoneNumber, "button_count", len(buttons))
 
-	respBody, err := c.doRequest(ctx, "POST", url, payload, account.AccessToken)
+	respBody, err := c.postMessage(ctx, account, url, payload)
 	if err != nil {
 		c.Log.Error("Failed to send interactive message", "error", err, "phone", phoneNumber)
 		return "", fmt.Errorf("failed to send interactive message: %w", err)
@@ -152,6 +152,105 @@ func (c *Client) SendInteractiveButtons(ctx context.Context, account *Account, p
 	return messageID, nil
 }
 
+// sendInteractiveMessage is the shared implementation for interactive messages
+// whose payload is fully built by the caller.
+func (c *Client) sendInteractiveMessage(ctx context.Context, account *Account, phoneNumber string, interactive map[string]interface{}) (string, error) {
+	kind, _ := interactive["type"].(string)
+
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                phoneNumber,
+		"type":              "interactive",
+		"interactive":       interactive,
+	}
+
+	url := c.buildMessagesURL(account)
+	c.Log.Debug("Sending interactive message", "type", kind, "phone", phoneNumber)
+
+	respBody, err := c.postMessage(ctx, account, url, payload)
+	if err != nil {
+		c.Log.Error("Failed to send interactive message", "error", err, "type", kind, "phone", phoneNumber)
+		return "", fmt.Errorf("failed to send %s message: %w", kind, err)
+	}
+
+	var resp MetaAPIResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(resp.Messages) == 0 {
+		return "", fmt.Errorf("no message ID in response")
+	}
+
+	messageID := resp.Messages[0].ID
+	c.Log.Info("Interactive message sent", "type", kind, "message_id", messageID, "phone", phoneNumber)
+	return messageID, nil
+}
+
+// sendMessagePayload is the shared implementation for non-interactive messages
+// whose type-specific body (location, contacts) is built by the caller.
+func (c *Client) sendMessagePayload(ctx context.Context, account *Account, phoneNumber, msgType string, body interface{}) (string, error) {
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                phoneNumber,
+		"type":              msgType,
+		msgType:             body,
+	}
+
+	url := c.buildMessagesURL(account)
+	c.Log.Debug("Sending message", "type", msgType, "phone", phoneNumber)
+
+	respBody, err := c.postMessage(ctx, account, url, payload)
+	if err != nil {
+		c.Log.Error("Failed to send message", "error", err, "type", msgType, "phone", phoneNumber)
+		return "", fmt.Errorf("failed to send %s message: %w", msgType, err)
+	}
+
+	var resp MetaAPIResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(resp.Messages) == 0 {
+		return "", fmt.Errorf("no message ID in response")
+	}
+
+	messageID := resp.Messages[0].ID
+	c.Log.Info("Message sent", "type", msgType, "message_id", messageID, "phone", phoneNumber)
+	return messageID, nil
+}
+
+// SendLocationMessage sends a map pin with an optional name and address
+func (c *Client) SendLocationMessage(ctx context.Context, account *Account, phoneNumber string, location Location) (string, error) {
+	if location.Latitude < -90 || location.Latitude > 90 || location.Longitude < -180 || location.Longitude > 180 {
+		return "", fmt.Errorf("invalid coordinates: %f, %f", location.Latitude, location.Longitude)
+	}
+	return c.sendMessagePayload(ctx, account, phoneNumber, "location", location)
+}
+
+// SendContactsMessage sends one or more contact cards.
+// WhatsApp requires a name part besides formatted_name, so the first name
+// defaults to the formatted name when neither first nor last name is set.
+func (c *Client) SendContactsMessage(ctx context.Context, account *Account, phoneNumber string, contacts []ContactCard) (string, error) {
+	if len(contacts) == 0 {
+		return "", fmt.Errorf("at least one contact is required")
+	}
+
+	cards := make([]ContactCard, len(contacts))
+	for i, card := range contacts {
+		if card.Name.FormattedName == "" {
+			return "", fmt.Errorf("contact %d: formatted name is required", i+1)
+		}
+		if card.Name.FirstName == "" && card.Name.LastName == "" {
+			card.Name.FirstName = card.Name.FormattedName
+		}
+		cards[i] = card
+	}
+	return c.sendMessagePayload(ctx, account, phoneNumber, "contacts", cards)
+}
+
 // SendCTAURLButton sends an interactive message with a CTA URL button
 // This opens a URL when clicked instead of sending a reply
 func (c *Client) SendCTAURLButton(ctx context.Context, account *Account, phoneNumber, bodyText, buttonText, url string) (string, error) {
@@ -189,7 +288,7 @@ func (c *Client) SendCTAURLButton(ctx context.Context, account *Account, phoneNu
 	apiURL := c.buildMessagesURL(account)
 	c.Log.Debug("Sending CTA URL button message", "phone", phoneNumber, "url", url)
 
-	respBody, err := c.doRequest(ctx, "POST", apiURL, payload, account.AccessToken)
+	respBody, err := c.postMessage(ctx, account, apiURL, payload)
 	if err != nil {
 		c.Log.Error("Failed to send CTA URL button message", "error", err, "phone", phoneNumber)
 		return "", fmt.Errorf("failed to send CTA URL button message: %w", err)
@@ -225,6 +324,32 @@ type TemplateParam struct {
 	} `json:"video,omitempty"`
 }
 
+// HeaderMediaComponent builds a template header component for an IMAGE, VIDEO or DOCUMENT
+// header. keyName is "id" for Meta media IDs or "link" for public URLs. Returns nil for
+// text or missing headers.
+func HeaderMediaComponent(headerType, keyName, value string) map[string]interface{} {
+	var mediaType string
+	switch headerType {
+	case "IMAGE":
+		mediaType = "image"
+	case "VIDEO":
+		mediaType = "video"
+	case "DOCUMENT":
+		mediaType = "document"
+	default:
+		return nil
+	}
+	return map[string]interface{}{
+		"type": "header",
+		"parameters": []map[string]interface{}{
+			{
+				"type":    mediaType,
+				mediaType: map[string]interface{}{keyName: value},
+			},
+		},
+	}
+}
+
 // SendTemplateMessage sends a template message
 // BodyParamsToComponents converts a bodyParams map into WhatsApp template components.
 // Supports both positional (numeric keys) and named parameters.
@@ -334,7 +459,7 @@ func (c *Client) SendFlowMessage(ctx context.Context, account *Account, phoneNum
 	url := c.buildMessagesURL(account)
 	c.Log.Debug("Sending flow message", "phone", phoneNumber, "flow_id", flowID)
 
-	respBody, err := c.doRequest(ctx, "POST", url, payload, account.AccessToken)
+	respBody, err := c.postMessage(ctx, account, url, payload)
 	if err != nil {
 		c.Log.Error("Failed to send flow message", "error", err, "phone", phoneNumber, "flow_id", flowID)
 		return "", fmt.Errorf("failed to send flow message: %w", err)
@@ -377,7 +502,7 @@ func (c *Client) SendTemplateMessage(ctx context.Context, account *Account, phon
 	url := c.buildMessagesURL(account)
 	c.Log.Debug("Sending template message with components", "phone", phoneNumber, "template", templateName)
 
-	respBody, err := c.doRequest(ctx, "POST", url, payload, account.AccessToken)
+	respBody, err := c.postMessage(ctx, account, url, payload)
 	if err != nil {
 		c.Log.Error("Failed to send template message", "error", err, "phone", phoneNumber, "template", templateName)
 		return "", fmt.Errorf("failed to send template message: %w", err)
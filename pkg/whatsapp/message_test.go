@@ -462,3 +462,96 @@ func TestClient_SendTemplateMessage_WithComponents(t *testing.T) {
 	assert.Len(t, sentComponents, 2)
 }
 
+
+func TestClient_SendLocationMessage(t *testing.T) {
+	t.Parallel()
+
+	var capturedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]string{{"id": "wamid.loc123"}},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	ctx := testutil.TestContext(t)
+
+	msgID, err := client.SendLocationMessage(ctx, testAccount(server.URL), "1234567890", whatsapp.Location{
+		Latitude: 37.4847, Longitude: -122.1477, Name: "HQ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "wamid.loc123", msgID)
+
+	assert.Equal(t, "location", capturedBody["type"])
+	location := capturedBody["location"].(map[string]interface{})
+	assert.Equal(t, 37.4847, location["latitude"])
+	assert.Equal(t, "HQ", location["name"])
+	assert.NotContains(t, location, "address")
+
+	_, err = client.SendLocationMessage(ctx, testAccount(server.URL), "1234567890", whatsapp.Location{Latitude: 91})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid coordinates")
+}
+
+func TestClient_SendContactsMessage(t *testing.T) {
+	t.Parallel()
+
+	var capturedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]string{{"id": "wamid.contacts123"}},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	ctx := testutil.TestContext(t)
+
+	msgID, err := client.SendContactsMessage(ctx, testAccount(server.URL), "1234567890", []whatsapp.ContactCard{{
+		Name:   whatsapp.ContactName{FormattedName: "Jane Doe", LastName: "Doe"},
+		Phones: []whatsapp.ContactPhone{{Phone: "+15550100", Type: "CELL", WaID: "15550100"}},
+		Org:    &whatsapp.ContactOrg{Company: "Acme"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "wamid.contacts123", msgID)
+
+	assert.Equal(t, "contacts", capturedBody["type"])
+	cards := capturedBody["contacts"].([]interface{})
+	require.Len(t, cards, 1)
+	card := cards[0].(map[string]interface{})
+	name := card["name"].(map[string]interface{})
+	assert.Equal(t, "Jane Doe", name["formatted_name"])
+	assert.NotContains(t, name, "first_name", "an explicit last name is enough")
+	assert.Equal(t, "Acme", card["org"].(map[string]interface{})["company"])
+
+	_, err = client.SendContactsMessage(ctx, testAccount(server.URL), "1234567890", nil)
+	require.Error(t, err)
+
+	_, err = client.SendContactsMessage(ctx, testAccount(server.URL), "1234567890", []whatsapp.ContactCard{{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "formatted name is required")
+}
+
+func TestClient_SendStickerMessage(t *testing.T) {
+	t.Parallel()
+
+	var capturedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]string{{"id": "wamid.sticker123"}},
+		})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	msgID, err := client.SendStickerMessage(testutil.TestContext(t), testAccount(server.URL), "1234567890", "sticker-media")
+	require.NoError(t, err)
+	assert.Equal(t, "wamid.sticker123", msgID)
+	assert.Equal(t, "sticker", capturedBody["type"])
+	assert.Equal(t, "sticker-media", capturedBody["sticker"].(map[string]interface{})["id"])
+}
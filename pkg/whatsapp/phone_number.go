@@ -0,0 +1,134 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// PhoneNumberHealth is Meta's view of a business phone number's standing
+type PhoneNumberHealth struct {
+	ID                 string `json:"id"`
+	DisplayPhoneNumber string `json:"display_phone_number"`
+	VerifiedName       string `json:"verified_name"`
+	QualityRating      string `json:"quality_rating"`       // GREEN, YELLOW, RED or UNKNOWN
+	MessagingLimitTier string `json:"messaging_limit_tier"` // TIER_250, TIER_1K, ... TIER_UNLIMITED
+	NameStatus         string `json:"name_status,omitempty"`
+	Status             string `json:"status,omitempty"` // CONNECTED, FLAGGED, RESTRICTED, ...
+}
+
+// GetPhoneNumberHealth fetches the quality rating and messaging limit tier of
+// the account's phone number.
+// Calls GET /{api_version}/{phone_number_id}
+func (c *Client) GetPhoneNumberHealth(ctx context.Context, account *Account) (*PhoneNumberHealth, error) {
+	fields := "display_phone_number,verified_name,quality_rating,messaging_limit_tier,name_status,status"
+	url := fmt.Sprintf("%s/%s/%s?fields=%s", c.getBaseURL(), account.APIVersion, account.PhoneID, fields)
+
+	respBody, err := c.doRequest(ctx, http.MethodGet, url, nil, account.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get phone number health: %w", err)
+	}
+
+	var health PhoneNumberHealth
+	if err := json.Unmarshal(respBody, &health); err != nil {
+		return nil, fmt.Errorf("failed to parse phone number response: %w", err)
+	}
+	return &health, nil
+}
+
+// Verification code delivery methods for RequestVerificationCode
+const (
+	CodeMethodSMS   = "SMS"
+	CodeMethodVoice = "VOICE"
+)
+
+// DisplayNameStatus is the review state of a phone number's display name
+type DisplayNameStatus struct {
+	VerifiedName           string `json:"verified_name"`
+	NameStatus             string `json:"name_status"`               // APPROVED, PENDING_REVIEW, DECLINED, ...
+	NewNameStatus          string `json:"new_name_status,omitempty"` // Review state of a requested name change
+	CodeVerificationStatus string `json:"code_verification_status"`
+}
+
+// RequestVerificationCode asks Meta to send a verification code to the phone
+// number by SMS or voice call. language is a locale such as en_US.
+// Calls POST /{api_version}/{phone_number_id}/request_code
+func (c *Client) RequestVerificationCode(ctx context.Context, account *Account, method, language string) error {
+	body := map[string]string{"code_method": method, "language": language}
+	return c.phoneNumberAction(ctx, account, "request_code", body, "request verification code")
+}
+
+// VerifyCode submits the verification code received by the phone number.
+// Calls POST /{api_version}/{phone_number_id}/verify_code
+func (c *Client) VerifyCode(ctx context.Context, account *Account, code string) error {
+	return c.phoneNumberAction(ctx, account, "verify_code", map[string]string{"code": code}, "verify code")
+}
+
+// RegisterPhoneNumber registers a verified phone number for Cloud API use.
+// pin becomes the number's two-step verification PIN if it has none, and
+// must match it otherwise.
+// Calls POST /{api_version}/{phone_number_id}/register
+func (c *Client) RegisterPhoneNumber(ctx context.Context, account *Account, pin string) error {
+	body := map[string]string{"messaging_product": "whatsapp", "pin": pin}
+	return c.phoneNumberAction(ctx, account, "register", body, "register phone number")
+}
+
+// DeregisterPhoneNumber removes a phone number from the Cloud API. It stops
+// sending and receiving messages until registered again.
+// Calls POST /{api_version}/{phone_number_id}/deregister
+func (c *Client) DeregisterPhoneNumber(ctx context.Context, account *Account) error {
+	return c.phoneNumberAction(ctx, account, "deregister", nil, "deregister phone number")
+}
+
+// SetTwoStepPIN sets or changes the phone number's six-digit two-step
+// verification PIN.
+// Calls POST /{api_version}/{phone_number_id}
+func (c *Client) SetTwoStepPIN(ctx context.Context, account *Account, pin string) error {
+	return c.phoneNumberAction(ctx, account, "", map[string]string{"pin": pin}, "set two-step verification PIN")
+}
+
+// GetDisplayNameStatus fetches the review state of the phone number's display name.
+// Calls GET /{api_version}/{phone_number_id}
+func (c *Client) GetDisplayNameStatus(ctx context.Context, account *Account) (*DisplayNameStatus, error) {
+	fields := "verified_name,name_status,new_name_status,code_verification_status"
+	url := fmt.Sprintf("%s/%s/%s?fields=%s", c.getBaseURL(), account.APIVersion, account.PhoneID, fields)
+
+	respBody, err := c.doRequest(ctx, http.MethodGet, url, nil, account.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get display name status: %w", err)
+	}
+
+	var status DisplayNameStatus
+	if err := json.Unmarshal(respBody, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse phone number response: %w", err)
+	}
+	return &status, nil
+}
+
+// phoneNumberAction POSTs to the phone number, or one of its edges, and
+// checks for Meta's {"success": true} reply
+func (c *Client) phoneNumberAction(ctx context.Context, account *Account, edge string, body interface{}, action string) error {
+	url := fmt.Sprintf("%s/%s/%s", c.getBaseURL(), account.APIVersion, account.PhoneID)
+	if edge != "" {
+		url += "/" + edge
+	}
+
+	respBody, err := c.doRequest(ctx, http.MethodPost, url, body, account.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+
+	var resp struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", action, err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("failed to %s: request was not successful", action)
+	}
+
+	c.Log.Info("Phone number updated", "action", action, "phone_id", account.PhoneID)
+	return nil
+}
@@ -0,0 +1,139 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zerodha/logf"
+)
+
+// Priority orders message sends competing for a phone number's throughput
+type Priority int
+
+const (
+	// PriorityInteractive is for agent and chatbot replies. It is the default.
+	PriorityInteractive Priority = iota
+	// PriorityBulk is for campaign sends. Bulk sends leave part of the bucket
+	// untouched so live chats are not starved by a large campaign.
+	PriorityBulk
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose message sends use the given priority
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the send priority set with WithPriority
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityInteractive
+}
+
+// RateLimiter paces message sends per phone number. Wait blocks until a send
+// is allowed or ctx is done. rate is messages per second; 0 uses the
+// limiter's default.
+type RateLimiter interface {
+	Wait(ctx context.Context, phoneID string, rate int, priority Priority) error
+}
+
+// tokenBucketScript takes one token from the bucket at KEYS[1], refilling it
+// at ARGV[1] tokens/sec up to ARGV[2]. ARGV[3] is the number of tokens the
+// caller must leave in the bucket. Returns 0 when a token was taken,
+// otherwise the milliseconds to wait before trying again.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local reserve = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate / 1000)
+
+local wait = 0
+if tokens >= 1 + reserve then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 + reserve - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity * 1000 / rate) + 1000)
+return wait
+`)
+
+// RedisLimiter is a token bucket per phone number kept in Redis, so the API
+// server and every worker share one budget.
+type RedisLimiter struct {
+	client *redis.Client
+	log    logf.Logger
+	// DefaultRate applies to accounts without their own rate (messages/sec)
+	DefaultRate int
+	// BulkReserve is the fraction of the rate held back for interactive sends
+	BulkReserve float64
+}
+
+// NewRedisLimiter creates a Redis-backed send limiter
+func NewRedisLimiter(client *redis.Client, log logf.Logger, defaultRate int, bulkReserve float64) *RedisLimiter {
+	return &RedisLimiter{
+		client:      client,
+		log:         log,
+		DefaultRate: defaultRate,
+		BulkReserve: bulkReserve,
+	}
+}
+
+// Wait blocks until phoneID may send one message. It fails open: if Redis is
+// unavailable the send is allowed through.
+func (l *RedisLimiter) Wait(ctx context.Context, phoneID string, rate int, priority Priority) error {
+	if rate <= 0 {
+		rate = l.DefaultRate
+	}
+	if rate <= 0 {
+		return nil
+	}
+
+	// The reserve sits on top of one second of traffic, so bulk sends still
+	// get the full rate when nothing else is sending
+	held := float64(rate) * l.BulkReserve
+	capacity := float64(rate) + held
+	reserve := 0.0
+	if priority == PriorityBulk {
+		reserve = held
+	}
+	key := "whatomate:sendlimit:" + phoneID
+
+	for {
+		waitMs, err := tokenBucketScript.Run(ctx, l.client, []string{key}, rate, fmt.Sprintf("%g", capacity), fmt.Sprintf("%g", reserve)).Int64()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.log.Error("Send rate limiter unavailable, allowing send", "error", err, "phone_id", phoneID)
+			return nil
+		}
+		if waitMs <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(time.Duration(waitMs) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
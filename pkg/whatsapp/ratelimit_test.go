@@ -0,0 +1,150 @@
+package whatsapp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLimiter records Wait calls and optionally fails them.
+type recordingLimiter struct {
+	mu    sync.Mutex
+	calls []limiterCall
+	err   error
+}
+
+type limiterCall struct {
+	phoneID  string
+	rate     int
+	priority whatsapp.Priority
+}
+
+func (l *recordingLimiter) Wait(_ context.Context, phoneID string, rate int, priority whatsapp.Priority) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, limiterCall{phoneID, rate, priority})
+	return l.err
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, whatsapp.PriorityInteractive, whatsapp.PriorityFromContext(context.Background()))
+	ctx := whatsapp.WithPriority(context.Background(), whatsapp.PriorityBulk)
+	assert.Equal(t, whatsapp.PriorityBulk, whatsapp.PriorityFromContext(ctx))
+}
+
+func TestClient_SendWaitsForLimiter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]string{{"id": "wamid.ok"}},
+		})
+	}))
+	defer server.Close()
+
+	limiter := &recordingLimiter{}
+	client := whatsapp.NewWithBaseURL(testutil.NopLogger(), server.URL)
+	client.Limiter = limiter
+
+	account := testAccount(server.URL)
+	account.SendRate = 20
+
+	_, err := client.SendTextMessage(testutil.TestContext(t), account, "1234567890", "Hi")
+	require.NoError(t, err)
+
+	bulkCtx := whatsapp.WithPriority(testutil.TestContext(t), whatsapp.PriorityBulk)
+	_, err = client.SendTemplateMessage(bulkCtx, account, "1234567890", "promo", "en", nil)
+	require.NoError(t, err)
+
+	_, err = client.SendImageMessage(testutil.TestContext(t), account, "1234567890", "media-1", "")
+	require.NoError(t, err)
+
+	_, err = client.SendCallPermissionRequest(testutil.TestContext(t), account, "1234567890", "May we call?")
+	require.NoError(t, err)
+
+	require.Len(t, limiter.calls, 4)
+	assert.Equal(t, limiterCall{account.PhoneID, 20, whatsapp.PriorityInteractive}, limiter.calls[0])
+	assert.Equal(t, limiterCall{account.PhoneID, 20, whatsapp.PriorityBulk}, limiter.calls[1])
+	assert.Equal(t, limiterCall{account.PhoneID, 20, whatsapp.PriorityInteractive}, limiter.calls[2])
+	assert.Equal(t, limiterCall{account.PhoneID, 20, whatsapp.PriorityInteractive}, limiter.calls[3])
+}
+
+func TestClient_LimiterErrorAbortsSend(t *testing.T) {
+	t.Parallel()
+
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	client := whatsapp.NewWithBaseURL(testutil.NopLogger(), server.URL)
+	client.Limiter = &recordingLimiter{err: context.DeadlineExceeded}
+
+	_, err := client.SendTextMessage(testutil.TestContext(t), testAccount(server.URL), "1234567890", "Hi")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = client.SendImageMessage(testutil.TestContext(t), testAccount(server.URL), "1234567890", "media-1", "")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, hits)
+}
+
+func TestRedisLimiter_PacesSends(t *testing.T) {
+	rdb := testutil.SetupTestRedis(t)
+	if rdb == nil {
+		t.Skip("Redis not available, skipping test")
+	}
+	limiter := whatsapp.NewRedisLimiter(rdb, testutil.NopLogger(), 10, 0.2)
+	phoneID := "test-" + uuid.NewString()
+	ctx := testutil.TestContextWithTimeout(t, 10*time.Second)
+
+	// The bucket starts full (12 tokens at 10/sec with a 20% reserve), so a
+	// burst goes through immediately and the next sends are paced
+	start := time.Now()
+	for i := 0; i < 12; i++ {
+		require.NoError(t, limiter.Wait(ctx, phoneID, 0, whatsapp.PriorityInteractive))
+	}
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	start = time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, limiter.Wait(ctx, phoneID, 0, whatsapp.PriorityInteractive))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestRedisLimiter_BulkLeavesReserve(t *testing.T) {
+	rdb := testutil.SetupTestRedis(t)
+	if rdb == nil {
+		t.Skip("Redis not available, skipping test")
+	}
+	limiter := whatsapp.NewRedisLimiter(rdb, testutil.NopLogger(), 10, 0.2)
+	phoneID := "test-" + uuid.NewString()
+	ctx := testutil.TestContextWithTimeout(t, 10*time.Second)
+
+	// Bulk drains the bucket down to the reserve...
+	for i := 0; i < 10; i++ {
+		require.NoError(t, limiter.Wait(ctx, phoneID, 0, whatsapp.PriorityBulk))
+	}
+	bulkCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(bulkCtx, phoneID, 0, whatsapp.PriorityBulk), context.DeadlineExceeded)
+
+	// ...which interactive sends can still use right away
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx, phoneID, 0, whatsapp.PriorityInteractive))
+	require.NoError(t, limiter.Wait(ctx, phoneID, 0, whatsapp.PriorityInteractive))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
@@ -9,6 +9,9 @@ type Account struct {
 	AppID       string
 	APIVersion  string
 	AccessToken string
+	// SendRate caps message sends per second for this number; 0 uses the
+	// client limiter's default
+	SendRate int
 }
 
 // Button represents an interactive button
@@ -129,17 +132,17 @@ type WebhookContact struct {
 
 // WebhookMessage represents an incoming message
 type WebhookMessage struct {
-	From        string                  `json:"from"`
-	ID          string                  `json:"id"`
-	Timestamp   string                  `json:"timestamp"`
-	Type        string                  `json:"type"`
-	Text        *WebhookText            `json:"text,omitempty"`
-	Interactive *WebhookInteractive     `json:"interactive,omitempty"`
-	Image       *WebhookMedia           `json:"image,omitempty"`
-	Document    *WebhookMedia           `json:"document,omitempty"`
-	Audio       *WebhookMedia           `json:"audio,omitempty"`
-	Video       *WebhookMedia           `json:"video,omitempty"`
-	Context     *WebhookMessageContext  `json:"context,omitempty"`
+	From        string                 `json:"from"`
+	ID          string                 `json:"id"`
+	Timestamp   string                 `json:"timestamp"`
+	Type        string                 `json:"type"`
+	Text        *WebhookText           `json:"text,omitempty"`
+	Interactive *WebhookInteractive    `json:"interactive,omitempty"`
+	Image       *WebhookMedia          `json:"image,omitempty"`
+	Document    *WebhookMedia          `json:"document,omitempty"`
+	Audio       *WebhookMedia          `json:"audio,omitempty"`
+	Video       *WebhookMedia          `json:"video,omitempty"`
+	Context     *WebhookMessageContext `json:"context,omitempty"`
 }
 
 // WebhookText represents text content in a message
@@ -245,10 +248,16 @@ type CatalogListResponse struct {
 	Data []CatalogInfo `json:"data"`
 }
 
+// ProductSection is a titled group of products in a multi-product message
+type ProductSection struct {
+	Title       string   `json:"title"`
+	RetailerIDs []string `json:"product_retailer_ids"`
+}
+
 // ProductInput represents input for creating/updating a product
 type ProductInput struct {
 	Name        string `json:"name"`
-	Price       int64  `json:"price"`    // Price in cents
+	Price       int64  `json:"price"` // Price in cents
 	Currency    string `json:"currency"`
 	URL         string `json:"url"`
 	ImageURL    string `json:"image_url"`
@@ -301,3 +310,54 @@ type BusinessProfileInput struct {
 	ProfilePictureHandle string   `json:"profile_picture_handle,omitempty"`
 	About                string   `json:"about,omitempty"`
 }
+
+// Location is a map pin sent as a location message
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+}
+
+// ContactCard is a vCard-style contact sent in a contacts message
+type ContactCard struct {
+	Name     ContactName    `json:"name"`
+	Phones   []ContactPhone `json:"phones,omitempty"`
+	Emails   []ContactEmail `json:"emails,omitempty"`
+	Org      *ContactOrg    `json:"org,omitempty"`
+	URLs     []ContactURL   `json:"urls,omitempty"`
+	Birthday string         `json:"birthday,omitempty"` // YYYY-MM-DD
+}
+
+// ContactName is the name block of a contact card. FormattedName is required.
+type ContactName struct {
+	FormattedName string `json:"formatted_name"`
+	FirstName     string `json:"first_name,omitempty"`
+	LastName      string `json:"last_name,omitempty"`
+}
+
+// ContactPhone is a phone number on a contact card
+type ContactPhone struct {
+	Phone string `json:"phone"`
+	Type  string `json:"type,omitempty"`  // CELL, MAIN, HOME, WORK, ...
+	WaID  string `json:"wa_id,omitempty"` // Adds a "Message" button when set
+}
+
+// ContactEmail is an email address on a contact card
+type ContactEmail struct {
+	Email string `json:"email"`
+	Type  string `json:"type,omitempty"` // HOME or WORK
+}
+
+// ContactOrg is the organization block of a contact card
+type ContactOrg struct {
+	Company    string `json:"company,omitempty"`
+	Department string `json:"department,omitempty"`
+	Title      string `json:"title,omitempty"`
+}
+
+// ContactURL is a website on a contact card
+type ContactURL struct {
+	URL  string `json:"url"`
+	Type string `json:"type,omitempty"` // HOME or WORK
+}
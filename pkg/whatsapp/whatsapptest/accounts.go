@@ -0,0 +1,492 @@
+package whatsapptest
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+)
+
+// Business is a WhatsApp Business Account (WABA)
+type Business struct {
+	ID         string
+	Name       string
+	Subscribed bool
+}
+
+// PhoneNumber is a business phone number registered to a WABA
+type PhoneNumber struct {
+	ID                     string
+	BusinessID             string
+	DisplayPhoneNumber     string
+	VerifiedName           string
+	CodeVerificationStatus string
+	AccountMode            string
+	QualityRating          string
+	MessagingLimitTier     string
+	NameStatus             string
+	Status                 string // CONNECTED while registered, DISCONNECTED after deregister
+	PIN                    string // Two-step verification PIN, empty if unset
+	Profile                whatsapp.BusinessProfile
+
+	verificationCode string
+}
+
+// AddPhoneNumber registers a phone number, creating its business account if
+// needed. Empty IDs are generated and unset fields get the values of a
+// verified, live number.
+func (s *Server) AddPhoneNumber(p PhoneNumber) *PhoneNumber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.BusinessID == "" {
+		p.BusinessID = s.nextID()
+	}
+	if p.ID == "" {
+		p.ID = s.nextID()
+	}
+	if p.DisplayPhoneNumber == "" {
+		p.DisplayPhoneNumber = "+1 555-010-0000"
+	}
+	if p.VerifiedName == "" {
+		p.VerifiedName = "Fake Business"
+	}
+	if p.CodeVerificationStatus == "" {
+		p.CodeVerificationStatus = "VERIFIED"
+	}
+	if p.AccountMode == "" {
+		p.AccountMode = "LIVE"
+	}
+	if p.QualityRating == "" {
+		p.QualityRating = "GREEN"
+	}
+	if p.MessagingLimitTier == "" {
+		p.MessagingLimitTier = "TIER_1K"
+	}
+	if p.NameStatus == "" {
+		p.NameStatus = "APPROVED"
+	}
+	if p.Status == "" {
+		p.Status = "CONNECTED"
+	}
+	p.Profile.MessagingProduct = "whatsapp"
+
+	if _, ok := s.businesses[p.BusinessID]; !ok {
+		s.businesses[p.BusinessID] = &Business{ID: p.BusinessID, Name: p.VerifiedName}
+	}
+	stored := p
+	s.phones[p.ID] = &stored
+	return &p
+}
+
+// Account returns credentials for a registered phone number, ready to pass
+// to a client returned by Client.
+func (s *Server) Account(phoneID string) *whatsapp.Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acct := &whatsapp.Account{
+		PhoneID:     phoneID,
+		AppID:       s.cfg.AppID,
+		APIVersion:  s.cfg.APIVersion,
+		AccessToken: s.cfg.AccessToken,
+	}
+	if p, ok := s.phones[phoneID]; ok {
+		acct.BusinessID = p.BusinessID
+	}
+	if acct.AccessToken == "" {
+		acct.AccessToken = "fake-token"
+	}
+	return acct
+}
+
+// Business returns a copy of a business account, or nil if it does not exist
+func (s *Server) Business(id string) *Business {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.businesses[id]
+	if !ok {
+		return nil
+	}
+	cp := *b
+	return &cp
+}
+
+// messagingLimitTiers lists Meta's messaging limit tiers from lowest to highest
+var messagingLimitTiers = []string{"TIER_50", "TIER_250", "TIER_1K", "TIER_10K", "TIER_100K", "TIER_UNLIMITED"}
+
+// SetQualityRating changes a phone number's quality rating. Like Meta, it
+// pushes a FLAGGED phone_number_quality_update webhook when the rating drops
+// to RED and UNFLAGGED when it recovers; other moves are only visible by
+// fetching the phone number.
+func (s *Server) SetQualityRating(phoneID, rating string) error {
+	rating = strings.ToUpper(rating)
+	s.mu.Lock()
+	p, ok := s.phones[phoneID]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown phone number %q", phoneID)
+	}
+	old := p.QualityRating
+	p.QualityRating = rating
+	phone := *p
+	s.mu.Unlock()
+
+	event := ""
+	switch {
+	case rating == "RED" && old != "RED":
+		event = "FLAGGED"
+	case rating != "RED" && old == "RED":
+		event = "UNFLAGGED"
+	}
+	if event == "" {
+		return nil
+	}
+	return s.pushQualityUpdate(phone, event)
+}
+
+// SetMessagingLimitTier changes a phone number's messaging limit tier and
+// pushes an UPGRADE or DOWNGRADE phone_number_quality_update webhook
+func (s *Server) SetMessagingLimitTier(phoneID, tier string) error {
+	tier = strings.ToUpper(tier)
+	rank := slices.Index(messagingLimitTiers, tier)
+	if rank < 0 {
+		return fmt.Errorf("unknown messaging limit tier %q", tier)
+	}
+
+	s.mu.Lock()
+	p, ok := s.phones[phoneID]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown phone number %q", phoneID)
+	}
+	oldRank := slices.Index(messagingLimitTiers, p.MessagingLimitTier)
+	p.MessagingLimitTier = tier
+	phone := *p
+	s.mu.Unlock()
+
+	switch {
+	case rank > oldRank:
+		return s.pushQualityUpdate(phone, "UPGRADE")
+	case rank < oldRank:
+		return s.pushQualityUpdate(phone, "DOWNGRADE")
+	}
+	return nil
+}
+
+func (s *Server) pushQualityUpdate(p PhoneNumber, event string) error {
+	return s.push(p.BusinessID, "phone_number_quality_update", map[string]interface{}{
+		"display_phone_number": p.DisplayPhoneNumber,
+		"event":                event,
+		"current_limit":        p.MessagingLimitTier,
+	})
+}
+
+func (s *Server) handleGetPhone(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	p := *s.phones[id]
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                       p.ID,
+		"display_phone_number":     p.DisplayPhoneNumber,
+		"verified_name":            p.VerifiedName,
+		"code_verification_status": p.CodeVerificationStatus,
+		"account_mode":             p.AccountMode,
+		"quality_rating":           p.QualityRating,
+		"messaging_limit_tier":     p.MessagingLimitTier,
+		"name_status":              p.NameStatus,
+		"status":                   p.Status,
+	})
+}
+
+// PhoneNumber returns a copy of a registered phone number, or nil if it does
+// not exist
+func (s *Server) PhoneNumber(id string) *PhoneNumber {
+	p, ok := s.phoneNumber(id)
+	if !ok {
+		return nil
+	}
+	return &p
+}
+
+// VerificationCode returns the last code sent to a phone number by
+// request_code, standing in for the SMS or voice call
+func (s *Server) VerificationCode(phoneID string) string {
+	p, _ := s.phoneNumber(phoneID)
+	return p.verificationCode
+}
+
+func (s *Server) handleRequestCode(w http.ResponseWriter, r *http.Request, phoneID string) {
+	var in struct {
+		CodeMethod string `json:"code_method"`
+		Language   string `json:"language"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.CodeMethod != whatsapp.CodeMethodSMS && in.CodeMethod != whatsapp.CodeMethodVoice {
+		writeError(w, errInvalidParam("Param code_method must be one of {SMS, VOICE}"))
+		return
+	}
+	if in.Language == "" {
+		writeError(w, errInvalidParam("The parameter language is required."))
+		return
+	}
+
+	s.mu.Lock()
+	p, ok := s.phones[phoneID]
+	if ok {
+		id := s.nextID()
+		p.verificationCode = id[len(id)-6:]
+		p.CodeVerificationStatus = "NOT_VERIFIED"
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, errUnsupported(http.MethodPost, phoneID))
+		return
+	}
+	writeSuccess(w)
+}
+
+func (s *Server) handleVerifyCode(w http.ResponseWriter, r *http.Request, phoneID string) {
+	var in struct {
+		Code string `json:"code"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+
+	s.mu.Lock()
+	p, ok := s.phones[phoneID]
+	matched := ok && p.verificationCode != "" && in.Code == p.verificationCode
+	if matched {
+		p.verificationCode = ""
+		p.CodeVerificationStatus = "VERIFIED"
+	}
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		writeError(w, errUnsupported(http.MethodPost, phoneID))
+	case !matched:
+		writeError(w, &GraphError{Code: 136025, Message: "Verify code error", Details: "The code you entered is incorrect."})
+	default:
+		writeSuccess(w)
+	}
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request, phoneID string) {
+	var in struct {
+		MessagingProduct string `json:"messaging_product"`
+		PIN              string `json:"pin"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.MessagingProduct != "whatsapp" {
+		writeError(w, errInvalidParam("The parameter messaging_product is required."))
+		return
+	}
+	if !validPIN(in.PIN) {
+		writeError(w, errInvalidParam("Param pin must be 6 digits"))
+		return
+	}
+
+	s.mu.Lock()
+	p, ok := s.phones[phoneID]
+	var gerr *GraphError
+	switch {
+	case !ok:
+		gerr = errUnsupported(http.MethodPost, phoneID)
+	case p.CodeVerificationStatus != "VERIFIED":
+		gerr = &GraphError{Code: 133010, Message: "Phone number Not Registered", Details: "Verify the phone number before registering it."}
+	case p.PIN != "" && p.PIN != in.PIN:
+		gerr = &GraphError{Code: 133005, Message: "Two step verification PIN Mismatch"}
+	default:
+		p.PIN = in.PIN
+		p.Status = "CONNECTED"
+	}
+	s.mu.Unlock()
+
+	if gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	writeSuccess(w)
+}
+
+func (s *Server) handleDeregister(w http.ResponseWriter, phoneID string) {
+	s.mu.Lock()
+	p, ok := s.phones[phoneID]
+	if ok {
+		p.Status = "DISCONNECTED"
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, errUnsupported(http.MethodPost, phoneID))
+		return
+	}
+	writeSuccess(w)
+}
+
+// handleSetPIN handles POST /{phone-id}, which Meta uses to set the two-step
+// verification PIN
+func (s *Server) handleSetPIN(w http.ResponseWriter, r *http.Request, phoneID string) {
+	var in struct {
+		PIN string `json:"pin"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if !validPIN(in.PIN) {
+		writeError(w, errInvalidParam("Param pin must be 6 digits"))
+		return
+	}
+
+	s.mu.Lock()
+	s.phones[phoneID].PIN = in.PIN
+	s.mu.Unlock()
+	writeSuccess(w)
+}
+
+func validPIN(pin string) bool {
+	if len(pin) != 6 {
+		return false
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) handleGetBusiness(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	b := *s.businesses[id]
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": b.ID, "name": b.Name})
+}
+
+func (s *Server) handleListPhones(w http.ResponseWriter, businessID string) {
+	s.mu.Lock()
+	if _, ok := s.businesses[businessID]; !ok {
+		s.mu.Unlock()
+		writeError(w, errUnsupported(http.MethodGet, businessID))
+		return
+	}
+	data := []map[string]string{}
+	for _, p := range s.phones {
+		if p.BusinessID == businessID {
+			data = append(data, map[string]string{
+				"id":                   p.ID,
+				"display_phone_number": p.DisplayPhoneNumber,
+				"verified_name":        p.VerifiedName,
+				"quality_rating":       p.QualityRating,
+				"messaging_limit_tier": p.MessagingLimitTier,
+			})
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) handleGetProfile(w http.ResponseWriter, phoneID string) {
+	s.mu.Lock()
+	p, ok := s.phones[phoneID]
+	var profile whatsapp.BusinessProfile
+	if ok {
+		profile = p.Profile
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, errUnsupported(http.MethodGet, phoneID))
+		return
+	}
+	writeJSON(w, http.StatusOK, whatsapp.BusinessProfileResponse{Data: []whatsapp.BusinessProfile{profile}})
+}
+
+func (s *Server) handleUpdateProfile(w http.ResponseWriter, r *http.Request, phoneID string) {
+	var in whatsapp.BusinessProfileInput
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.MessagingProduct != "whatsapp" {
+		writeError(w, errInvalidParam("The parameter messaging_product is required."))
+		return
+	}
+
+	s.mu.Lock()
+	p, ok := s.phones[phoneID]
+	if ok {
+		if in.About != "" {
+			p.Profile.About = in.About
+		}
+		if in.Address != "" {
+			p.Profile.Address = in.Address
+		}
+		if in.Description != "" {
+			p.Profile.Description = in.Description
+		}
+		if in.Email != "" {
+			p.Profile.Email = in.Email
+		}
+		if in.Vertical != "" {
+			p.Profile.Vertical = in.Vertical
+		}
+		if in.Websites != nil {
+			p.Profile.Websites = in.Websites
+		}
+		if in.ProfilePictureHandle != "" {
+			if u := s.uploadByHandle(in.ProfilePictureHandle); u != nil {
+				p.Profile.ProfilePicture = s.URL + "/_uploads/" + u.ID
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, errUnsupported(http.MethodPost, phoneID))
+		return
+	}
+	writeSuccess(w)
+}
+
+func (s *Server) handleSubscribeApp(w http.ResponseWriter, businessID string) {
+	s.mu.Lock()
+	b, ok := s.businesses[businessID]
+	if ok {
+		b.Subscribed = true
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, errUnsupported(http.MethodPost, businessID))
+		return
+	}
+	writeSuccess(w)
+}
+
+// phoneNumber returns a copy of a registered phone number
+func (s *Server) phoneNumber(id string) (PhoneNumber, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.phones[id]
+	if !ok {
+		return PhoneNumber{}, false
+	}
+	return *p, true
+}
@@ -0,0 +1,141 @@
+package whatsapptest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BlockedUsers returns the WhatsApp IDs a phone number has blocked, sorted
+func (s *Server) BlockedUsers(phoneID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blockedUsers(phoneID)
+}
+
+// blockedUsers lists a phone number's block list. Callers hold s.mu.
+func (s *Server) blockedUsers(phoneID string) []string {
+	out := make([]string, 0, len(s.blocked[phoneID]))
+	for waID := range s.blocked[phoneID] {
+		out = append(out, waID)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// isBlocked reports whether a phone number has blocked a user
+func (s *Server) isBlocked(phoneID, user string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blocked[phoneID][userWaID(user)]
+}
+
+// userWaID turns a phone number as sent by the client into a WhatsApp ID
+func userWaID(user string) string {
+	return strings.TrimPrefix(strings.TrimSpace(user), "+")
+}
+
+func validWaID(waID string) bool {
+	if len(waID) < 7 {
+		return false
+	}
+	_, err := strconv.ParseUint(waID, 10, 64)
+	return err == nil
+}
+
+// handleBlockUsers handles POST and DELETE /{phone-id}/block_users. Like Meta,
+// the whole call fails if any user cannot be changed.
+func (s *Server) handleBlockUsers(w http.ResponseWriter, r *http.Request, phoneID string) {
+	if _, ok := s.phoneNumber(phoneID); !ok {
+		writeError(w, errUnsupported(r.Method, phoneID))
+		return
+	}
+
+	var in struct {
+		MessagingProduct string `json:"messaging_product"`
+		BlockUsers       []struct {
+			User string `json:"user"`
+		} `json:"block_users"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.MessagingProduct != "whatsapp" {
+		writeError(w, errInvalidParam("The parameter messaging_product is required."))
+		return
+	}
+	if len(in.BlockUsers) == 0 {
+		writeError(w, errInvalidParam("The parameter block_users is required."))
+		return
+	}
+
+	for _, u := range in.BlockUsers {
+		if !validWaID(userWaID(u.User)) {
+			writeError(w, &GraphError{Code: 139100, Message: "(#139100) Failed to block/unblock users", Details: "Invalid user " + u.User})
+			return
+		}
+	}
+
+	changed := make([]map[string]string, 0, len(in.BlockUsers))
+	s.mu.Lock()
+	if s.blocked[phoneID] == nil {
+		s.blocked[phoneID] = make(map[string]bool)
+	}
+	for _, u := range in.BlockUsers {
+		waID := userWaID(u.User)
+		if r.Method == http.MethodPost {
+			s.blocked[phoneID][waID] = true
+		} else {
+			delete(s.blocked[phoneID], waID)
+		}
+		changed = append(changed, map[string]string{"input": u.User, "wa_id": waID})
+	}
+	s.mu.Unlock()
+
+	key := "added_users"
+	if r.Method == http.MethodDelete {
+		key = "removed_users"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"block_users":       map[string]interface{}{key: changed},
+	})
+}
+
+// handleListBlockedUsers handles GET /{phone-id}/block_users. The cursor is
+// the offset into the sorted block list.
+func (s *Server) handleListBlockedUsers(w http.ResponseWriter, r *http.Request, phoneID string) {
+	if _, ok := s.phoneNumber(phoneID); !ok {
+		writeError(w, errUnsupported(r.Method, phoneID))
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 25
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("after"))
+
+	s.mu.Lock()
+	users := s.blockedUsers(phoneID)
+	s.mu.Unlock()
+
+	if offset > len(users) {
+		offset = len(users)
+	}
+	end := min(offset+limit, len(users))
+
+	data := make([]map[string]string, 0, end-offset)
+	for _, waID := range users[offset:end] {
+		data = append(data, map[string]string{"messaging_product": "whatsapp", "wa_id": waID})
+	}
+	paging := map[string]interface{}{
+		"cursors": map[string]string{"before": strconv.Itoa(offset), "after": strconv.Itoa(end)},
+	}
+	if end < len(users) {
+		paging["next"] = s.baseURL() + r.URL.Path + "?after=" + strconv.Itoa(end) + "&limit=" + strconv.Itoa(limit)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "paging": paging})
+}
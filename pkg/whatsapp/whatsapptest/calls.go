@@ -0,0 +1,298 @@
+package whatsapptest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Call is a voice call between a business number and a WhatsApp user
+type Call struct {
+	ID        string
+	PhoneID   string
+	Peer      string // the user's WhatsApp ID
+	Direction string // USER_INITIATED or BUSINESS_INITIATED
+	Status    string // RINGING, ACCEPTED, REJECTED, COMPLETED
+	StartedAt time.Time
+	// Actions records the actions the business sent for the call, in order
+	Actions []string
+}
+
+// Call returns a copy of a call, or nil if it does not exist
+func (s *Server) Call(id string) *Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.calls[id]
+	if !ok {
+		return nil
+	}
+	cp := *c
+	cp.Actions = append([]string(nil), c.Actions...)
+	return &cp
+}
+
+// SetCallPermission sets whether a user has allowed the business to call
+// them: "granted", "denied" or "no_permission" (the default).
+func (s *Server) SetCallPermission(phoneID, userWaID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.permissions[phoneID+":"+userWaID] = status
+}
+
+func (s *Server) callPermission(phoneID, userWaID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status, ok := s.permissions[phoneID+":"+userWaID]; ok {
+		return status
+	}
+	return "no_permission"
+}
+
+// StartCall places a call from a user to a business number and pushes the
+// "connect" webhook carrying the user's SDP offer. Returns the call ID.
+func (s *Server) StartCall(phoneID, from, sdpOffer string) (string, error) {
+	phone, ok := s.phoneNumber(phoneID)
+	if !ok {
+		return "", fmt.Errorf("unknown phone number %q", phoneID)
+	}
+
+	s.mu.Lock()
+	c := &Call{ID: "wacid." + s.nextID(), PhoneID: phoneID, Peer: from, Direction: "USER_INITIATED", Status: "RINGING", StartedAt: time.Now()}
+	s.calls[c.ID] = c
+	s.mu.Unlock()
+
+	return c.ID, s.pushCallEvent(phone, c, "connect", map[string]interface{}{
+		"session": map[string]string{"sdp_type": "offer", "sdp": sdpOffer},
+	})
+}
+
+// AnswerCall has the user pick up a business-initiated call. It pushes the
+// ACCEPTED status and the "connect" webhook carrying the user's SDP answer.
+func (s *Server) AnswerCall(callID, sdpAnswer string) error {
+	phone, c, err := s.outgoingCall(callID, "ACCEPTED")
+	if err != nil {
+		return err
+	}
+	if err := s.pushCallStatus(phone, c); err != nil {
+		return err
+	}
+	return s.pushCallEvent(phone, c, "connect", map[string]interface{}{
+		"session": map[string]string{"sdp_type": "answer", "sdp": sdpAnswer},
+	})
+}
+
+// DeclineCall has the user reject a business-initiated call
+func (s *Server) DeclineCall(callID string) error {
+	phone, c, err := s.outgoingCall(callID, "REJECTED")
+	if err != nil {
+		return err
+	}
+	return s.pushCallStatus(phone, c)
+}
+
+// HangUp has the user end a call and pushes the "terminate" webhook
+func (s *Server) HangUp(callID string) error {
+	s.mu.Lock()
+	c, ok := s.calls[callID]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown call %q", callID)
+	}
+	c.Status = "COMPLETED"
+	cp := *c
+	phone := *s.phones[c.PhoneID]
+	s.mu.Unlock()
+
+	return s.pushTerminate(phone, &cp)
+}
+
+func (s *Server) outgoingCall(callID, status string) (PhoneNumber, *Call, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.calls[callID]
+	if !ok {
+		return PhoneNumber{}, nil, fmt.Errorf("unknown call %q", callID)
+	}
+	if c.Direction != "BUSINESS_INITIATED" {
+		return PhoneNumber{}, nil, fmt.Errorf("call %q was not placed by the business", callID)
+	}
+	if c.Status != "RINGING" {
+		return PhoneNumber{}, nil, fmt.Errorf("call %q is %s, not ringing", callID, c.Status)
+	}
+	c.Status = status
+	cp := *c
+	return *s.phones[c.PhoneID], &cp, nil
+}
+
+// pushCallEvent pushes a call event (connect, terminate) under the "calls" field
+func (s *Server) pushCallEvent(phone PhoneNumber, c *Call, event string, extra map[string]interface{}) error {
+	from, to := c.Peer, phone.ID
+	if c.Direction == "BUSINESS_INITIATED" {
+		from, to = phone.ID, c.Peer
+	}
+	call := map[string]interface{}{
+		"id":        c.ID,
+		"from":      from,
+		"to":        to,
+		"timestamp": unixNow(),
+		"event":     event,
+		"direction": c.Direction,
+	}
+	for k, v := range extra {
+		call[k] = v
+	}
+
+	value := phoneValue(phone)
+	value["calls"] = []interface{}{call}
+	return s.push(phone.BusinessID, "calls", value)
+}
+
+// pushCallStatus pushes a business-initiated call's status, which Meta sends
+// in the statuses array under the "calls" field
+func (s *Server) pushCallStatus(phone PhoneNumber, c *Call) error {
+	value := phoneValue(phone)
+	value["statuses"] = []interface{}{map[string]interface{}{
+		"id":           c.ID,
+		"type":         "call",
+		"status":       c.Status,
+		"timestamp":    unixNow(),
+		"recipient_id": c.Peer,
+	}}
+	return s.push(phone.BusinessID, "calls", value)
+}
+
+func (s *Server) pushTerminate(phone PhoneNumber, c *Call) error {
+	end := time.Now()
+	return s.pushCallEvent(phone, c, "terminate", map[string]interface{}{
+		"status":     c.Status,
+		"start_time": fmt.Sprintf("%d", c.StartedAt.Unix()),
+		"end_time":   fmt.Sprintf("%d", end.Unix()),
+		"duration":   int(end.Sub(c.StartedAt).Seconds()),
+	})
+}
+
+// handleCalls implements the call actions: POST /{phone-id}/calls
+func (s *Server) handleCalls(w http.ResponseWriter, r *http.Request, phoneID string) {
+	phone, ok := s.phoneNumber(phoneID)
+	if !ok {
+		writeError(w, errUnsupported(r.Method, phoneID))
+		return
+	}
+	var in struct {
+		MessagingProduct string `json:"messaging_product"`
+		To               string `json:"to"`
+		CallID           string `json:"call_id"`
+		Action           string `json:"action"`
+		Session          *struct {
+			SDPType string `json:"sdp_type"`
+			SDP     string `json:"sdp"`
+		} `json:"session"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.MessagingProduct != "whatsapp" {
+		writeError(w, errInvalidParam("The parameter messaging_product is required."))
+		return
+	}
+
+	if in.Action == "connect" {
+		s.handleConnectCall(w, phone, in.To, in.Session != nil && in.Session.SDPType == "offer" && in.Session.SDP != "")
+		return
+	}
+
+	s.mu.Lock()
+	c, ok := s.calls[in.CallID]
+	if !ok || c.PhoneID != phoneID {
+		s.mu.Unlock()
+		writeError(w, &GraphError{Code: 138004, Message: "Invalid call_id", Details: fmt.Sprintf("Call %s not found.", in.CallID)})
+		return
+	}
+	var gerr *GraphError
+	terminated := false
+	switch in.Action {
+	case "pre_accept", "accept":
+		if c.Direction != "USER_INITIATED" || c.Status != "RINGING" {
+			gerr = &GraphError{Code: 138005, Message: "Call can't be accepted", Details: "Only ringing user-initiated calls can be accepted."}
+		} else if in.Session == nil || in.Session.SDPType != "answer" || in.Session.SDP == "" {
+			gerr = errInvalidParam("An SDP answer is required in session.")
+		} else if in.Action == "accept" {
+			c.Status = "ACCEPTED"
+		}
+	case "reject":
+		if c.Status != "RINGING" {
+			gerr = &GraphError{Code: 138005, Message: "Call can't be rejected", Details: "Only ringing calls can be rejected."}
+		} else {
+			c.Status = "REJECTED"
+			terminated = true
+		}
+	case "terminate":
+		if c.Status == "COMPLETED" || c.Status == "REJECTED" {
+			gerr = &GraphError{Code: 138005, Message: "Call already ended"}
+		} else {
+			c.Status = "COMPLETED"
+			terminated = true
+		}
+	default:
+		gerr = errInvalidParam("Param action must be one of {connect, pre_accept, accept, reject, terminate}.")
+	}
+	if gerr == nil {
+		c.Actions = append(c.Actions, in.Action)
+	}
+	cp := *c
+	s.mu.Unlock()
+
+	if gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if terminated {
+		s.after(0, func() { _ = s.pushTerminate(phone, &cp) })
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"messaging_product": "whatsapp", "success": true})
+}
+
+// handleConnectCall places a business-initiated call, which needs the user's permission
+func (s *Server) handleConnectCall(w http.ResponseWriter, phone PhoneNumber, to string, hasOffer bool) {
+	if to == "" {
+		writeError(w, errInvalidParam("The parameter to is required."))
+		return
+	}
+	if !hasOffer {
+		writeError(w, errInvalidParam("An SDP offer is required in session."))
+		return
+	}
+	if s.callPermission(phone.ID, to) != "granted" {
+		writeError(w, &GraphError{Code: 138006, Message: "Lack of call permission", Details: "The user has not granted permission for business-initiated calls."})
+		return
+	}
+
+	s.mu.Lock()
+	c := &Call{ID: "wacid." + s.nextID(), PhoneID: phone.ID, Peer: to, Direction: "BUSINESS_INITIATED", Status: "RINGING", StartedAt: time.Now(), Actions: []string{"connect"}}
+	s.calls[c.ID] = c
+	cp := *c
+	s.mu.Unlock()
+
+	s.after(0, func() { _ = s.pushCallStatus(phone, &cp) })
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"calls":             []map[string]string{{"id": c.ID}},
+	})
+}
+
+func (s *Server) handleGetCallPermission(w http.ResponseWriter, r *http.Request, phoneID string) {
+	user := r.URL.Query().Get("user_wa_id")
+	if user == "" {
+		writeError(w, errInvalidParam("The parameter user_wa_id is required."))
+		return
+	}
+	if _, ok := s.phoneNumber(phoneID); !ok {
+		writeError(w, errUnsupported(r.Method, phoneID))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"permission":        map[string]string{"status": s.callPermission(phoneID, user)},
+	})
+}
@@ -0,0 +1,225 @@
+package whatsapptest
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Catalog is a product catalog owned by a business account
+type Catalog struct {
+	ID         string
+	BusinessID string
+	Name       string
+}
+
+// Product is an item in a catalog. Price is in minor units, as a string like Meta returns it.
+type Product struct {
+	ID          string
+	CatalogID   string
+	RetailerID  string
+	Name        string
+	Description string
+	Price       string
+	Currency    string
+	URL         string
+	ImageURL    string
+}
+
+// Products returns a copy of a catalog's products
+func (s *Server) Products(catalogID string) []Product {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Product
+	for _, p := range s.products {
+		if p.CatalogID == catalogID {
+			out = append(out, *p)
+		}
+	}
+	return out
+}
+
+// findProduct looks a product up by retailer ID. Callers hold s.mu.
+func (s *Server) findProduct(catalogID, retailerID string) *Product {
+	for _, p := range s.products {
+		if p.CatalogID == catalogID && p.RetailerID == retailerID {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleListCatalogs(w http.ResponseWriter, businessID string) {
+	if s.Business(businessID) == nil {
+		writeError(w, errUnsupported(http.MethodGet, businessID))
+		return
+	}
+
+	s.mu.Lock()
+	data := []map[string]string{}
+	for _, c := range s.catalogs {
+		if c.BusinessID == businessID {
+			data = append(data, map[string]string{"id": c.ID, "name": c.Name})
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "paging": paging()})
+}
+
+func (s *Server) handleCreateCatalog(w http.ResponseWriter, r *http.Request, businessID string) {
+	if s.Business(businessID) == nil {
+		writeError(w, errUnsupported(r.Method, businessID))
+		return
+	}
+	var in struct {
+		Name string `json:"name"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.Name == "" {
+		writeError(w, errInvalidParam("The parameter name is required."))
+		return
+	}
+
+	s.mu.Lock()
+	c := &Catalog{ID: s.nextID(), BusinessID: businessID, Name: in.Name}
+	s.catalogs[c.ID] = c
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": c.ID})
+}
+
+func (s *Server) handleDeleteCatalog(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	delete(s.catalogs, id)
+	for pid, p := range s.products {
+		if p.CatalogID == id {
+			delete(s.products, pid)
+		}
+	}
+	s.mu.Unlock()
+	writeSuccess(w)
+}
+
+func (s *Server) handleListProducts(w http.ResponseWriter, catalogID string) {
+	s.mu.Lock()
+	_, ok := s.catalogs[catalogID]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, errUnsupported(http.MethodGet, catalogID))
+		return
+	}
+
+	data := []map[string]string{}
+	for _, p := range s.Products(catalogID) {
+		data = append(data, productJSON(p))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "paging": paging()})
+}
+
+type productInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       string `json:"price"`
+	Currency    string `json:"currency"`
+	URL         string `json:"url"`
+	ImageURL    string `json:"image_url"`
+	RetailerID  string `json:"retailer_id"`
+}
+
+func (s *Server) handleCreateProduct(w http.ResponseWriter, r *http.Request, catalogID string) {
+	var in productInput
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.Name == "" || in.Price == "" || in.Currency == "" || in.RetailerID == "" {
+		writeError(w, errInvalidParam("The parameters name, price, currency and retailer_id are required."))
+		return
+	}
+	if _, err := strconv.ParseInt(in.Price, 10, 64); err != nil {
+		writeError(w, errInvalidParam("Param price must be an integer amount in minor units."))
+		return
+	}
+
+	s.mu.Lock()
+	if _, ok := s.catalogs[catalogID]; !ok {
+		s.mu.Unlock()
+		writeError(w, errUnsupported(r.Method, catalogID))
+		return
+	}
+	if s.findProduct(catalogID, in.RetailerID) != nil {
+		s.mu.Unlock()
+		writeError(w, &GraphError{Code: 10800, Message: "Duplicate retailer_id", Details: "A product with retailer_id " + in.RetailerID + " already exists in this catalog."})
+		return
+	}
+	p := &Product{
+		ID:          s.nextID(),
+		CatalogID:   catalogID,
+		RetailerID:  in.RetailerID,
+		Name:        in.Name,
+		Description: in.Description,
+		Price:       in.Price,
+		Currency:    in.Currency,
+		URL:         in.URL,
+		ImageURL:    in.ImageURL,
+	}
+	s.products[p.ID] = p
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": p.ID})
+}
+
+func (s *Server) handleUpdateProduct(w http.ResponseWriter, r *http.Request, id string) {
+	var in productInput
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.Price != "" {
+		if _, err := strconv.ParseInt(in.Price, 10, 64); err != nil {
+			writeError(w, errInvalidParam("Param price must be an integer amount in minor units."))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	p := s.products[id]
+	for field, value := range map[*string]string{
+		&p.Name:        in.Name,
+		&p.Description: in.Description,
+		&p.Price:       in.Price,
+		&p.Currency:    in.Currency,
+		&p.URL:         in.URL,
+		&p.ImageURL:    in.ImageURL,
+	} {
+		if value != "" {
+			*field = value
+		}
+	}
+	s.mu.Unlock()
+
+	writeSuccess(w)
+}
+
+func (s *Server) handleDeleteProduct(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	delete(s.products, id)
+	s.mu.Unlock()
+	writeSuccess(w)
+}
+
+func productJSON(p Product) map[string]string {
+	return map[string]string{
+		"id":          p.ID,
+		"name":        p.Name,
+		"description": p.Description,
+		"price":       p.Price,
+		"currency":    p.Currency,
+		"url":         p.URL,
+		"image_url":   p.ImageURL,
+		"retailer_id": p.RetailerID,
+	}
+}
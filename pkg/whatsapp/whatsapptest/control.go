@@ -0,0 +1,125 @@
+package whatsapptest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// serveControl exposes the simulation helpers over HTTP under /_fake/, so a
+// standalone server (cmd/fakegraph) can be driven with curl:
+//
+//	GET  /_fake/messages                  outgoing messages
+//	GET  /_fake/webhooks                  pushed webhooks
+//	POST /_fake/inbound                   {"phone_id","from","name","type","content","media_id","reply_to"}
+//	POST /_fake/status                    {"message_id","status"}
+//	POST /_fake/template_status           {"template_id","status","reason"}
+//	POST /_fake/quality                   {"phone_id","rating"}
+//	POST /_fake/tier                      {"phone_id","tier"}
+//	POST /_fake/call_permission           {"phone_id","user","status"}
+//	POST /_fake/calls                     {"phone_id","from","sdp"}
+//	POST /_fake/calls/answer              {"call_id","sdp"}
+//	POST /_fake/calls/decline             {"call_id"}
+//	POST /_fake/calls/hangup              {"call_id"}
+//	POST /_fake/signup_code               {"business_ids"}
+//	POST /_fake/revoke_token              {"token"}
+//
+// Inbound text messages may pass "text" instead of type and content.
+func (s *Server) serveControl(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		writeError(w, errUnsupported(r.Method, "_fake"))
+		return
+	}
+	route := r.Method + " " + parts[0]
+	if len(parts) == 2 {
+		route += "/" + parts[1]
+	}
+
+	var in struct {
+		PhoneID    string                 `json:"phone_id"`
+		From       string                 `json:"from"`
+		Name       string                 `json:"name"`
+		Type       string                 `json:"type"`
+		Text       string                 `json:"text"`
+		Content    map[string]interface{} `json:"content"`
+		MediaID    string                 `json:"media_id"`
+		ReplyTo    string                 `json:"reply_to"`
+		MessageID  string                 `json:"message_id"`
+		TemplateID string                 `json:"template_id"`
+		CallID     string                 `json:"call_id"`
+		User       string                 `json:"user"`
+		Status     string                 `json:"status"`
+		Reason     string                 `json:"reason"`
+		Rating     string                 `json:"rating"`
+		Tier       string                 `json:"tier"`
+		SDP        string                 `json:"sdp"`
+		Businesses []string               `json:"business_ids"`
+		Token      string                 `json:"token"`
+	}
+	if r.Method == http.MethodPost {
+		if gerr := decodeBody(r, &in); gerr != nil {
+			writeError(w, gerr)
+			return
+		}
+	}
+
+	var (
+		result interface{} = map[string]bool{"success": true}
+		err    error
+	)
+	switch route {
+	case "GET messages":
+		result = map[string]interface{}{"data": s.Messages()}
+	case "GET webhooks":
+		hooks := s.Webhooks()
+		data := make([]map[string]interface{}, len(hooks))
+		for i, h := range hooks {
+			data[i] = map[string]interface{}{"field": h.Field, "status_code": h.StatusCode, "body": json.RawMessage(h.Body)}
+			if h.Err != nil {
+				data[i]["error"] = h.Err.Error()
+			}
+		}
+		result = map[string]interface{}{"data": data}
+	case "POST inbound":
+		msg := InboundMessage{PhoneID: in.PhoneID, From: in.From, ProfileName: in.Name, Type: in.Type, Content: in.Content, MediaID: in.MediaID, ReplyTo: in.ReplyTo}
+		if in.Text != "" {
+			msg.Type = "text"
+			msg.Content = map[string]interface{}{"body": in.Text}
+		}
+		var id string
+		id, err = s.SendMessage(msg)
+		result = map[string]string{"id": id}
+	case "POST status":
+		err = s.PushStatus(in.MessageID, in.Status)
+	case "POST template_status":
+		err = s.SetTemplateStatus(in.TemplateID, in.Status, in.Reason)
+	case "POST quality":
+		err = s.SetQualityRating(in.PhoneID, in.Rating)
+	case "POST tier":
+		err = s.SetMessagingLimitTier(in.PhoneID, in.Tier)
+	case "POST call_permission":
+		s.SetCallPermission(in.PhoneID, in.User, in.Status)
+	case "POST calls":
+		var id string
+		id, err = s.StartCall(in.PhoneID, in.From, in.SDP)
+		result = map[string]string{"id": id}
+	case "POST calls/answer":
+		err = s.AnswerCall(in.CallID, in.SDP)
+	case "POST calls/decline":
+		err = s.DeclineCall(in.CallID)
+	case "POST calls/hangup":
+		err = s.HangUp(in.CallID)
+	case "POST signup_code":
+		result = map[string]string{"code": s.SignupCode(in.Businesses...)}
+	case "POST revoke_token":
+		s.RevokeToken(in.Token)
+	default:
+		writeError(w, errUnsupported(r.Method, "_fake/"+parts[0]))
+		return
+	}
+
+	if err != nil {
+		writeError(w, errInvalidParam(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,251 @@
+package whatsapptest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Flow lifecycle states
+const (
+	FlowDraft      = "DRAFT"
+	FlowPublished  = "PUBLISHED"
+	FlowDeprecated = "DEPRECATED"
+)
+
+// Flow is a WhatsApp Flow owned by a business account
+type Flow struct {
+	ID         string
+	BusinessID string
+	Name       string
+	Categories []string
+	Status     string
+	// JSON is the uploaded flow.json asset, nil until one is uploaded
+	JSON []byte
+}
+
+// Flow returns a copy of a flow, or nil if it does not exist
+func (s *Server) Flow(id string) *Flow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.flows[id]
+	if !ok {
+		return nil
+	}
+	cp := *f
+	return &cp
+}
+
+func (s *Server) handleCreateFlow(w http.ResponseWriter, r *http.Request, businessID string) {
+	if s.Business(businessID) == nil {
+		writeError(w, errUnsupported(r.Method, businessID))
+		return
+	}
+	var in struct {
+		Name       string   `json:"name"`
+		Categories []string `json:"categories"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.Name == "" {
+		writeError(w, errInvalidParam("The parameter name is required."))
+		return
+	}
+	if len(in.Categories) == 0 {
+		writeError(w, errInvalidParam("The parameter categories is required."))
+		return
+	}
+
+	s.mu.Lock()
+	f := &Flow{ID: s.nextID(), BusinessID: businessID, Name: in.Name, Categories: in.Categories, Status: FlowDraft}
+	s.flows[f.ID] = f
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": f.ID})
+}
+
+func (s *Server) handleGetFlow(w http.ResponseWriter, id string) {
+	f := s.Flow(id)
+	writeJSON(w, http.StatusOK, s.flowJSON(f))
+}
+
+func (s *Server) handleListFlows(w http.ResponseWriter, businessID string) {
+	if s.Business(businessID) == nil {
+		writeError(w, errUnsupported(http.MethodGet, businessID))
+		return
+	}
+
+	s.mu.Lock()
+	var flows []Flow
+	for _, f := range s.flows {
+		if f.BusinessID == businessID {
+			flows = append(flows, *f)
+		}
+	}
+	s.mu.Unlock()
+
+	data := []map[string]interface{}{}
+	for i := range flows {
+		data = append(data, s.flowJSON(&flows[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "paging": paging()})
+}
+
+func (s *Server) flowJSON(f *Flow) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         f.ID,
+		"name":       f.Name,
+		"status":     f.Status,
+		"categories": f.Categories,
+		"preview": map[string]interface{}{
+			"preview_url": s.baseURL() + "/_flow_assets/" + f.ID + "?preview=1",
+			"expires_at":  time.Now().Add(30 * 24 * time.Hour).UTC().Format("2006-01-02T15:04:05-0700"),
+		},
+	}
+}
+
+// handleUpdateFlowAssets uploads flow.json: POST /{flow-id}/assets (multipart)
+func (s *Server) handleUpdateFlowAssets(w http.ResponseWriter, r *http.Request, id string) {
+	if s.Flow(id) == nil {
+		writeError(w, errUnsupported(r.Method, id))
+		return
+	}
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		writeError(w, errInvalidParam("Invalid multipart body: "+err.Error()))
+		return
+	}
+	if r.FormValue("asset_type") != "FLOW_JSON" {
+		writeError(w, errInvalidParam("Param asset_type must be FLOW_JSON."))
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, errInvalidParam("The parameter file is required."))
+		return
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, errInvalidParam("Failed to read file: "+err.Error()))
+		return
+	}
+
+	var doc struct {
+		Version string            `json:"version"`
+		Screens []json.RawMessage `json:"screens"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		writeError(w, &GraphError{Code: 139001, Message: "Flow JSON is not valid JSON", Details: err.Error()})
+		return
+	}
+	var validationErrors []map[string]string
+	if doc.Version == "" {
+		validationErrors = append(validationErrors, map[string]string{"error": "MISSING_REQUIRED_PROPERTY", "message": "Missing required property 'version'."})
+	}
+	if len(doc.Screens) == 0 {
+		validationErrors = append(validationErrors, map[string]string{"error": "MISSING_REQUIRED_PROPERTY", "message": "Flow JSON must define at least one screen."})
+	}
+
+	s.mu.Lock()
+	f := s.flows[id]
+	if f.Status != FlowDraft {
+		s.mu.Unlock()
+		writeError(w, &GraphError{Code: 139001, Message: "Flow can't be updated", Details: "Only draft flows can be updated. Clone the flow to make changes."})
+		return
+	}
+	f.JSON = data
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":           len(validationErrors) == 0,
+		"validation_errors": validationErrors,
+	})
+}
+
+func (s *Server) handleGetFlowAssets(w http.ResponseWriter, id string) {
+	f := s.Flow(id)
+	if f == nil {
+		writeError(w, errUnsupported(http.MethodGet, id))
+		return
+	}
+	data := []map[string]string{}
+	if f.JSON != nil {
+		data = append(data, map[string]string{
+			"name":         "flow.json",
+			"asset_type":   "FLOW_JSON",
+			"download_url": s.baseURL() + "/_flow_assets/" + f.ID,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "paging": paging()})
+}
+
+func (s *Server) handleFlowAssetDownload(w http.ResponseWriter, id string) {
+	f := s.Flow(id)
+	if f == nil || f.JSON == nil {
+		http.NotFound(w, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(f.JSON)
+}
+
+func (s *Server) handlePublishFlow(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	f, ok := s.flows[id]
+	var gerr *GraphError
+	switch {
+	case !ok:
+		gerr = errUnsupported(http.MethodPost, id)
+	case f.Status != FlowDraft:
+		gerr = &GraphError{Code: 139002, Message: "Publishing Flow in invalid state", Details: "Only draft flows can be published."}
+	case f.JSON == nil:
+		gerr = &GraphError{Code: 139002, Message: "Publishing Flow failed", Details: "Upload a valid Flow JSON before publishing."}
+	default:
+		f.Status = FlowPublished
+	}
+	s.mu.Unlock()
+
+	if gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	writeSuccess(w)
+}
+
+func (s *Server) handleDeprecateFlow(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	f, ok := s.flows[id]
+	var gerr *GraphError
+	switch {
+	case !ok:
+		gerr = errUnsupported(http.MethodPost, id)
+	case f.Status != FlowPublished:
+		gerr = &GraphError{Code: 139003, Message: "Can't deprecate a Flow that is not published"}
+	default:
+		f.Status = FlowDeprecated
+	}
+	s.mu.Unlock()
+
+	if gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	writeSuccess(w)
+}
+
+func (s *Server) handleDeleteFlow(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	f := s.flows[id]
+	if f.Status != FlowDraft {
+		s.mu.Unlock()
+		writeError(w, &GraphError{Code: 139004, Message: "Flow can't be deleted", Details: "Only draft flows can be deleted. Deprecate published flows instead."})
+		return
+	}
+	delete(s.flows, id)
+	s.mu.Unlock()
+
+	writeSuccess(w)
+}
@@ -0,0 +1,215 @@
+package whatsapptest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxUploadSize caps multipart uploads, matching Meta's 100MB document limit
+const maxUploadSize = 100 << 20
+
+// Media is a file uploaded to the media endpoint or attached to an inbound message
+type Media struct {
+	ID       string
+	PhoneID  string
+	MimeType string
+	Filename string
+	Data     []byte
+	SHA256   string
+}
+
+// upload is a resumable upload session, used for template samples and profile pictures
+type upload struct {
+	ID       string
+	FileName string
+	FileType string
+	Length   int
+	Data     []byte
+	Handle   string
+}
+
+// AddMedia stores a file as if a user had sent it and returns its media ID,
+// for use in inbound image, document, audio and video messages.
+func (s *Server) AddMedia(phoneID string, data []byte, mimeType, filename string) string {
+	sum := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := &Media{
+		ID:       s.nextID(),
+		PhoneID:  phoneID,
+		MimeType: mimeType,
+		Filename: filename,
+		Data:     data,
+		SHA256:   hex.EncodeToString(sum[:]),
+	}
+	s.media[m.ID] = m
+	return m.ID
+}
+
+// Media returns a copy of a stored media file, or nil if it does not exist
+func (s *Server) Media(id string) *Media {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.media[id]
+	if !ok {
+		return nil
+	}
+	cp := *m
+	return &cp
+}
+
+func (s *Server) handleUploadMedia(w http.ResponseWriter, r *http.Request, phoneID string) {
+	if _, ok := s.phoneNumber(phoneID); !ok {
+		writeError(w, errUnsupported(r.Method, phoneID))
+		return
+	}
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		writeError(w, errInvalidParam("Invalid multipart body: "+err.Error()))
+		return
+	}
+	if r.FormValue("messaging_product") != "whatsapp" {
+		writeError(w, errInvalidParam("The parameter messaging_product is required."))
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, errInvalidParam("The parameter file is required."))
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, errInvalidParam("Failed to read file: "+err.Error()))
+		return
+	}
+	mimeType := header.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		writeError(w, errInvalidParam("Param file must be a file with one of the supported media types."))
+		return
+	}
+
+	id := s.AddMedia(phoneID, data, mimeType, header.Filename)
+	writeJSON(w, http.StatusOK, map[string]string{"id": id})
+}
+
+func (s *Server) handleGetMedia(w http.ResponseWriter, id string) {
+	m := s.Media(id)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"id":                m.ID,
+		"url":               s.baseURL() + "/_media/" + m.ID,
+		"mime_type":         m.MimeType,
+		"sha256":            m.SHA256,
+		"file_size":         len(m.Data),
+	})
+}
+
+func (s *Server) handleDeleteMedia(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	delete(s.media, id)
+	s.mu.Unlock()
+	writeSuccess(w)
+}
+
+// handleMediaDownload serves the file behind a media URL, as Meta's CDN does
+func (s *Server) handleMediaDownload(w http.ResponseWriter, id string) {
+	m := s.Media(id)
+	if m == nil {
+		http.NotFound(w, nil)
+		return
+	}
+	w.Header().Set("Content-Type", m.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(m.Data)))
+	_, _ = w.Write(m.Data)
+}
+
+// handleCreateUpload starts a resumable upload session: POST /{app-id}/uploads
+func (s *Server) handleCreateUpload(w http.ResponseWriter, r *http.Request, appID string) {
+	var in struct {
+		FileLength int    `json:"file_length"`
+		FileType   string `json:"file_type"`
+		FileName   string `json:"file_name"`
+	}
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.FileLength <= 0 || in.FileType == "" {
+		writeError(w, errInvalidParam("The parameters file_length and file_type are required."))
+		return
+	}
+
+	s.mu.Lock()
+	u := &upload{ID: "upload:" + s.nextID(), FileName: in.FileName, FileType: in.FileType, Length: in.FileLength}
+	s.uploads[u.ID] = u
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": u.ID})
+}
+
+// handleUploadData receives the file for a resumable upload session and returns its handle
+func (s *Server) handleUploadData(w http.ResponseWriter, r *http.Request, id string) {
+	if offset := r.Header.Get("file_offset"); offset != "" && offset != "0" {
+		writeError(w, errInvalidParam("Only uploads starting at file_offset 0 are supported."))
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxUploadSize))
+	if err != nil {
+		writeError(w, errInvalidParam("Failed to read upload: "+err.Error()))
+		return
+	}
+
+	s.mu.Lock()
+	u := s.uploads[id]
+	if len(data) != u.Length {
+		s.mu.Unlock()
+		writeError(w, errInvalidParam("Uploaded "+strconv.Itoa(len(data))+" bytes, expected file_length "+strconv.Itoa(u.Length)+"."))
+		return
+	}
+	u.Data = data
+	u.Handle = "4::" + base64.StdEncoding.EncodeToString([]byte(u.FileType+":"+u.ID))
+	handle := u.Handle
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{"h": handle})
+}
+
+// handleUploadDownload serves the file of a completed resumable upload
+func (s *Server) handleUploadDownload(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	var u upload
+	if stored, ok := s.uploads[id]; ok {
+		u = *stored
+	}
+	s.mu.Unlock()
+	if u.Handle == "" {
+		http.NotFound(w, nil)
+		return
+	}
+	w.Header().Set("Content-Type", u.FileType)
+	_, _ = w.Write(u.Data)
+}
+
+// uploadByHandle finds a completed upload by the handle returned to the client. Callers hold s.mu.
+func (s *Server) uploadByHandle(handle string) *upload {
+	for _, u := range s.uploads {
+		if u.Handle != "" && u.Handle == handle {
+			return u
+		}
+	}
+	return nil
+}
+
+// isUploadHandle reports whether a template header sample refers to a completed upload
+func (s *Server) isUploadHandle(handle string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.HasPrefix(handle, "4::") && s.uploadByHandle(handle) != nil
+}
@@ -0,0 +1,254 @@
+package whatsapptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Message is an outgoing message accepted by the messages endpoint
+type Message struct {
+	ID      string `json:"id"`
+	PhoneID string `json:"phone_id"`
+	To      string `json:"to"`
+	Type    string `json:"type"`
+	// Payload is the request body as sent by the client
+	Payload map[string]interface{} `json:"payload"`
+	Status  string                 `json:"status"`
+	SentAt  time.Time              `json:"sent_at"`
+}
+
+// Messages returns the messages sent so far, oldest first
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Message, len(s.messages))
+	for i, m := range s.messages {
+		out[i] = *m
+	}
+	return out
+}
+
+// FailSendsTo makes sends to a recipient fail with the given Graph error code
+// and message, e.g. 131026 for an undeliverable number. Code 0 clears it.
+func (s *Server) FailSendsTo(to string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code == 0 {
+		delete(s.sendErrors, to)
+		return
+	}
+	s.sendErrors[to] = &GraphError{Code: code, Message: fmt.Sprintf("(#%d) %s", code, message)}
+}
+
+// PushStatus pushes a status webhook (sent, delivered, read) for an outgoing message
+func (s *Server) PushStatus(messageID, status string) error {
+	return s.pushStatus(messageID, status, nil)
+}
+
+// FailMessage pushes a "failed" status webhook for an outgoing message, as
+// Meta does when a message is accepted but cannot be delivered.
+func (s *Server) FailMessage(messageID string, code int, title string) error {
+	return s.pushStatus(messageID, "failed", []map[string]interface{}{{
+		"code":       code,
+		"title":      title,
+		"message":    title,
+		"error_data": map[string]string{"details": title},
+	}})
+}
+
+func (s *Server) pushStatus(messageID, status string, errs []map[string]interface{}) error {
+	s.mu.Lock()
+	var msg *Message
+	for _, m := range s.messages {
+		if m.ID == messageID {
+			msg = m
+			break
+		}
+	}
+	if msg == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown message %q", messageID)
+	}
+	msg.Status = status
+	m := *msg
+	phone := *s.phones[m.PhoneID]
+	s.mu.Unlock()
+
+	st := map[string]interface{}{
+		"id":           m.ID,
+		"status":       status,
+		"timestamp":    unixNow(),
+		"recipient_id": m.To,
+	}
+	if status == "sent" || status == "delivered" {
+		category := "service"
+		if m.Type == "template" {
+			category = "utility"
+		}
+		st["conversation"] = map[string]interface{}{"id": "conv-" + m.PhoneID + "-" + m.To, "origin": map[string]string{"type": category}}
+		st["pricing"] = map[string]interface{}{"billable": category != "service", "pricing_model": "PMP", "category": category}
+	}
+	if len(errs) > 0 {
+		st["errors"] = errs
+	}
+
+	value := phoneValue(phone)
+	value["statuses"] = []interface{}{st}
+	return s.push(phone.BusinessID, "messages", value)
+}
+
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request, phoneID string) {
+	phone, ok := s.phoneNumber(phoneID)
+	if !ok {
+		writeError(w, errUnsupported(r.Method, phoneID))
+		return
+	}
+
+	var payload map[string]interface{}
+	if gerr := decodeBody(r, &payload); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if payload["messaging_product"] != "whatsapp" {
+		writeError(w, errInvalidParam("The parameter messaging_product is required."))
+		return
+	}
+
+	// Read receipts and typing indicators share the endpoint
+	if payload["status"] == "read" {
+		if id, _ := payload["message_id"].(string); id == "" {
+			writeError(w, errInvalidParam("The parameter message_id is required."))
+			return
+		}
+		writeSuccess(w)
+		return
+	}
+
+	to, _ := payload["to"].(string)
+	if to == "" {
+		writeError(w, errInvalidParam("The parameter to is required."))
+		return
+	}
+	msgType, _ := payload["type"].(string)
+	if msgType == "" {
+		msgType = "text"
+	}
+	if gerr := s.validateMessage(phone, msgType, payload); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+
+	s.mu.Lock()
+	if gerr, failing := s.sendErrors[to]; failing {
+		s.mu.Unlock()
+		writeError(w, gerr)
+		return
+	}
+	msg := &Message{
+		ID:      "wamid." + s.nextID(),
+		PhoneID: phoneID,
+		To:      to,
+		Type:    msgType,
+		Payload: payload,
+		Status:  "accepted",
+		SentAt:  time.Now(),
+	}
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+
+	if s.cfg.AutoDeliver {
+		s.after(s.cfg.StatusDelay, func() {
+			if s.PushStatus(msg.ID, "sent") == nil {
+				_ = s.PushStatus(msg.ID, "delivered")
+			}
+		})
+	}
+
+	waID := strings.TrimPrefix(to, "+")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"contacts":          []map[string]string{{"input": to, "wa_id": waID}},
+		"messages":          []map[string]string{{"id": msg.ID}},
+	})
+}
+
+// validateMessage applies the checks Meta makes before accepting a send
+func (s *Server) validateMessage(phone PhoneNumber, msgType string, payload map[string]interface{}) *GraphError {
+	body, ok := payload[msgType].(map[string]interface{})
+	if !ok {
+		return errInvalidParam(fmt.Sprintf("The parameter %s is required.", msgType))
+	}
+
+	switch msgType {
+	case "text":
+		if text, _ := body["body"].(string); text == "" {
+			return errInvalidParam("The parameter text['body'] is required.")
+		}
+	case "image", "video", "audio", "document", "sticker":
+		id, _ := body["id"].(string)
+		link, _ := body["link"].(string)
+		if id == "" && link == "" {
+			return errInvalidParam(fmt.Sprintf("The parameter %s['id'] or %s['link'] is required.", msgType, msgType))
+		}
+		if id != "" && s.Media(id) == nil {
+			return &GraphError{Code: 131053, Message: "(#131053) Media upload error", Details: fmt.Sprintf("Media %s not found.", id)}
+		}
+	case "template":
+		name, _ := body["name"].(string)
+		lang, _ := body["language"].(map[string]interface{})
+		code, _ := lang["code"].(string)
+		t := s.Template(phone.BusinessID, name, code)
+		if t == nil {
+			return &GraphError{Code: 132001, Message: "(#132001) Template name does not exist in the translation", Details: fmt.Sprintf("template name (%s) does not exist in %s", name, code)}
+		}
+		if t.Status != TemplateApproved {
+			return &GraphError{Code: 132015, Message: "(#132015) Template is " + strings.ToLower(t.Status), Details: fmt.Sprintf("Template %s is %s and can't be sent.", name, t.Status)}
+		}
+	case "interactive":
+		return s.validateInteractive(phone, body)
+	}
+	return nil
+}
+
+// validateInteractive checks that catalog messages refer to products that exist
+func (s *Server) validateInteractive(phone PhoneNumber, body map[string]interface{}) *GraphError {
+	action, _ := body["action"].(map[string]interface{})
+	var retailerIDs []string
+	switch body["type"] {
+	case "product":
+		id, _ := action["product_retailer_id"].(string)
+		retailerIDs = append(retailerIDs, id)
+	case "product_list":
+		raw, _ := json.Marshal(action["sections"])
+		var sections []struct {
+			Items []struct {
+				ID string `json:"product_retailer_id"`
+			} `json:"product_items"`
+		}
+		_ = json.Unmarshal(raw, &sections)
+		for _, sec := range sections {
+			for _, item := range sec.Items {
+				retailerIDs = append(retailerIDs, item.ID)
+			}
+		}
+	default:
+		return nil
+	}
+
+	catalogID, _ := action["catalog_id"].(string)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.catalogs[catalogID]
+	if !ok || c.BusinessID != phone.BusinessID {
+		return &GraphError{Code: 131009, Message: "(#131009) Parameter value is not valid", Details: fmt.Sprintf("Catalog %s is not connected to this number.", catalogID)}
+	}
+	for _, id := range retailerIDs {
+		if s.findProduct(catalogID, id) == nil {
+			return &GraphError{Code: 131009, Message: "(#131009) Parameter value is not valid", Details: fmt.Sprintf("Product %s not found in catalog %s.", id, catalogID)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,439 @@
+// Package whatsapptest provides an in-memory fake of the Meta Graph API.
+//
+// It models the parts of the Cloud API that whatomate uses: phone numbers and
+// business accounts, message sends, templates with review states, media,
+// flows, catalogs, calls and block lists. Point a client at it with
+// whatsapp.NewWithBaseURL, or run cmd/fakegraph to develop against it without
+// a Meta account.
+//
+// Events that Meta would deliver by webhook (incoming messages, statuses,
+// template reviews, calls) are signed with Config.AppSecret and POSTed to
+// Config.WebhookURL, and are always recorded for inspection via Webhooks.
+package whatsapptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/zerodha/logf"
+)
+
+// Config configures a fake Graph API server
+type Config struct {
+	// BaseURL is the server's public URL, used in media and flow asset download
+	// links. Set automatically by Start.
+	BaseURL string
+
+	// AccessToken, when set, must be presented as the bearer token on every
+	// request. Empty accepts any token.
+	AccessToken string
+
+	// APIVersion and AppID are reported in Account. They default to v21.0
+	// and "fake-app"; any version prefix is accepted on requests.
+	APIVersion string
+	AppID      string
+
+	// WebhookURL receives pushed webhooks, e.g. http://localhost:8080/api/webhook.
+	// Empty records webhooks without delivering them.
+	WebhookURL string
+
+	// AppSecret signs pushed webhooks in the X-Hub-Signature-256 header
+	AppSecret string
+
+	// TemplateReviewDelay approves submitted templates after this delay.
+	// Zero leaves them PENDING until SetTemplateStatus is called.
+	TemplateReviewDelay time.Duration
+
+	// AutoDeliver pushes "sent" and "delivered" statuses for every outgoing
+	// message, StatusDelay after the send is accepted.
+	AutoDeliver bool
+	StatusDelay time.Duration
+
+	// HTTPClient delivers webhooks. Defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// Server is a fake Graph API server. All methods are safe for concurrent use.
+type Server struct {
+	URL string
+
+	cfg        Config
+	httpServer *httptest.Server
+	wg         sync.WaitGroup
+	done       chan struct{}
+	closeOnce  sync.Once
+
+	mu          sync.Mutex
+	seq         int64
+	businesses  map[string]*Business
+	phones      map[string]*PhoneNumber
+	templates   map[string]*Template
+	media       map[string]*Media
+	uploads     map[string]*upload
+	flows       map[string]*Flow
+	catalogs    map[string]*Catalog
+	products    map[string]*Product
+	calls       map[string]*Call
+	permissions map[string]string
+	sendErrors  map[string]*GraphError
+	signupCodes map[string]string   // Embedded Signup code -> business token
+	grants      map[string][]string // Business token -> business account IDs
+	revoked     map[string]bool
+	expiries    map[string]time.Time
+	blocked     map[string]map[string]bool // Phone number ID -> blocked WhatsApp IDs
+	messages    []*Message
+	webhooks    []Webhook
+}
+
+// GraphError is an error returned in Meta's error envelope
+type GraphError struct {
+	HTTPStatus int    `json:"-"`
+	Code       int    `json:"code"`
+	Subcode    int    `json:"error_subcode,omitempty"`
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+	Details    string `json:"-"`
+}
+
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("graph error %d: %s", e.Code, e.Message)
+}
+
+// New creates a fake server without starting it. Use it as an http.Handler, or
+// call Start to serve it on a local port.
+func New(cfg Config) *Server {
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = "v21.0"
+	}
+	if cfg.AppID == "" {
+		cfg.AppID = "fake-app"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Server{
+		URL:         strings.TrimRight(cfg.BaseURL, "/"),
+		cfg:         cfg,
+		done:        make(chan struct{}),
+		seq:         900000000000000,
+		businesses:  make(map[string]*Business),
+		phones:      make(map[string]*PhoneNumber),
+		templates:   make(map[string]*Template),
+		media:       make(map[string]*Media),
+		uploads:     make(map[string]*upload),
+		flows:       make(map[string]*Flow),
+		catalogs:    make(map[string]*Catalog),
+		products:    make(map[string]*Product),
+		calls:       make(map[string]*Call),
+		permissions: make(map[string]string),
+		sendErrors:  make(map[string]*GraphError),
+		signupCodes: make(map[string]string),
+		grants:      make(map[string][]string),
+		revoked:     make(map[string]bool),
+		expiries:    make(map[string]time.Time),
+		blocked:     make(map[string]map[string]bool),
+	}
+}
+
+// Start serves the fake on a random local port and sets URL
+func (s *Server) Start() *Server {
+	s.httpServer = httptest.NewServer(s)
+	s.mu.Lock()
+	s.URL = s.httpServer.URL
+	s.mu.Unlock()
+	return s
+}
+
+// Close cancels delayed events, waits for in-flight webhook deliveries and
+// stops the server if it was started.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.wg.Wait()
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// Client returns a whatsapp.Client pointed at the server
+func (s *Server) Client(log logf.Logger) *whatsapp.Client {
+	return whatsapp.NewWithBaseURL(log, s.baseURL())
+}
+
+func (s *Server) baseURL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.URL
+}
+
+// after runs fn after d in the background unless the server is closed first
+func (s *Server) after(d time.Duration, fn func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		select {
+		case <-time.After(d):
+			fn()
+		case <-s.done:
+		}
+	}()
+}
+
+// nextID returns a numeric ID in the style of Graph object IDs. Callers hold s.mu.
+func (s *Server) nextID() string {
+	s.seq++
+	return fmt.Sprintf("%d", s.seq)
+}
+
+var apiVersionPattern = regexp.MustCompile(`^v\d+\.\d+$`)
+
+// ServeHTTP routes Graph API requests. The version prefix is optional, as on graph.facebook.com.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) > 0 && apiVersionPattern.MatchString(parts[0]) {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 || parts[0] == "" {
+		writeError(w, errUnsupported(r.Method, ""))
+		return
+	}
+
+	if parts[0] == "_fake" {
+		s.serveControl(w, r, parts[1:])
+		return
+	}
+
+	// Token endpoints authenticate with the app's credentials, not a user token
+	switch {
+	case parts[0] == "oauth" && len(parts) == 2 && parts[1] == "access_token" && r.Method == http.MethodGet:
+		s.handleExchangeCode(w, r)
+		return
+	case parts[0] == "debug_token" && len(parts) == 1 && r.Method == http.MethodGet:
+		s.handleDebugToken(w, r)
+		return
+	}
+
+	if !s.authorized(r) {
+		writeError(w, &GraphError{HTTPStatus: http.StatusUnauthorized, Code: 190, Type: "OAuthException", Message: "Invalid OAuth access token - Cannot parse access token"})
+		return
+	}
+	if gerr := s.tokenError(bearerToken(r)); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+
+	switch parts[0] {
+	case "_media":
+		if len(parts) == 2 {
+			s.handleMediaDownload(w, parts[1])
+			return
+		}
+	case "_uploads":
+		if len(parts) == 2 {
+			s.handleUploadDownload(w, parts[1])
+			return
+		}
+	case "_flow_assets":
+		if len(parts) == 2 {
+			s.handleFlowAssetDownload(w, parts[1])
+			return
+		}
+	}
+
+	id := parts[0]
+	edge := ""
+	if len(parts) > 1 {
+		edge = parts[1]
+	}
+	if len(parts) > 2 {
+		writeError(w, errUnsupported(r.Method, id))
+		return
+	}
+
+	if edge == "" {
+		s.serveNode(w, r, id)
+		return
+	}
+	s.serveEdge(w, r, id, edge)
+}
+
+// serveNode handles requests on a bare object ID
+func (s *Server) serveNode(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	_, isPhone := s.phones[id]
+	_, isBusiness := s.businesses[id]
+	_, isTemplate := s.templates[id]
+	_, isMedia := s.media[id]
+	_, isUpload := s.uploads[id]
+	_, isFlow := s.flows[id]
+	_, isCatalog := s.catalogs[id]
+	_, isProduct := s.products[id]
+	s.mu.Unlock()
+
+	switch {
+	case isPhone && r.Method == http.MethodGet:
+		s.handleGetPhone(w, id)
+	case isPhone && r.Method == http.MethodPost:
+		s.handleSetPIN(w, r, id)
+	case isBusiness && r.Method == http.MethodGet:
+		s.handleGetBusiness(w, id)
+	case isTemplate && r.Method == http.MethodPost:
+		s.handleUpdateTemplate(w, r, id)
+	case isMedia && r.Method == http.MethodGet:
+		s.handleGetMedia(w, id)
+	case isMedia && r.Method == http.MethodDelete:
+		s.handleDeleteMedia(w, id)
+	case isUpload && r.Method == http.MethodPost:
+		s.handleUploadData(w, r, id)
+	case isFlow && r.Method == http.MethodGet:
+		s.handleGetFlow(w, id)
+	case isFlow && r.Method == http.MethodDelete:
+		s.handleDeleteFlow(w, id)
+	case isCatalog && r.Method == http.MethodDelete:
+		s.handleDeleteCatalog(w, id)
+	case isProduct && r.Method == http.MethodPost:
+		s.handleUpdateProduct(w, r, id)
+	case isProduct && r.Method == http.MethodDelete:
+		s.handleDeleteProduct(w, id)
+	default:
+		writeError(w, errUnsupported(r.Method, id))
+	}
+}
+
+// serveEdge handles requests on an object's edge, e.g. /{phone-id}/messages
+func (s *Server) serveEdge(w http.ResponseWriter, r *http.Request, id, edge string) {
+	route := r.Method + " " + edge
+	switch route {
+	case "POST messages":
+		s.handleMessages(w, r, id)
+	case "POST media":
+		s.handleUploadMedia(w, r, id)
+	case "GET whatsapp_business_profile":
+		s.handleGetProfile(w, id)
+	case "POST whatsapp_business_profile":
+		s.handleUpdateProfile(w, r, id)
+	case "GET phone_numbers":
+		s.handleListPhones(w, id)
+	case "POST subscribed_apps":
+		s.handleSubscribeApp(w, id)
+	case "POST request_code":
+		s.handleRequestCode(w, r, id)
+	case "POST verify_code":
+		s.handleVerifyCode(w, r, id)
+	case "POST register":
+		s.handleRegister(w, r, id)
+	case "POST deregister":
+		s.handleDeregister(w, id)
+	case "POST block_users", "DELETE block_users":
+		s.handleBlockUsers(w, r, id)
+	case "GET block_users":
+		s.handleListBlockedUsers(w, r, id)
+	case "GET message_templates":
+		s.handleListTemplates(w, id)
+	case "POST message_templates":
+		s.handleCreateTemplate(w, r, id)
+	case "DELETE message_templates":
+		s.handleDeleteTemplate(w, r, id)
+	case "POST uploads":
+		s.handleCreateUpload(w, r, id)
+	case "GET flows":
+		s.handleListFlows(w, id)
+	case "POST flows":
+		s.handleCreateFlow(w, r, id)
+	case "GET assets":
+		s.handleGetFlowAssets(w, id)
+	case "POST assets":
+		s.handleUpdateFlowAssets(w, r, id)
+	case "POST publish":
+		s.handlePublishFlow(w, id)
+	case "POST deprecate":
+		s.handleDeprecateFlow(w, id)
+	case "GET owned_product_catalogs":
+		s.handleListCatalogs(w, id)
+	case "POST owned_product_catalogs":
+		s.handleCreateCatalog(w, r, id)
+	case "GET products":
+		s.handleListProducts(w, id)
+	case "POST products":
+		s.handleCreateProduct(w, r, id)
+	case "POST calls":
+		s.handleCalls(w, r, id)
+	case "GET call_permissions":
+		s.handleGetCallPermission(w, r, id)
+	default:
+		writeError(w, errUnsupported(r.Method, id))
+	}
+}
+
+// authorized checks the bearer (or OAuth, for resumable uploads) token
+func (s *Server) authorized(r *http.Request) bool {
+	if s.cfg.AccessToken == "" {
+		return true
+	}
+	token := bearerToken(r)
+	return token == s.cfg.AccessToken || s.grantedToken(token)
+}
+
+// bearerToken returns the token of the Authorization header
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	return strings.TrimPrefix(strings.TrimPrefix(auth, "Bearer "), "OAuth ")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeSuccess(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func writeError(w http.ResponseWriter, e *GraphError) {
+	status := e.HTTPStatus
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+	if e.Type == "" {
+		e.Type = "OAuthException"
+	}
+	body := map[string]interface{}{
+		"message":    e.Message,
+		"type":       e.Type,
+		"code":       e.Code,
+		"fbtrace_id": "fake-trace",
+	}
+	if e.Subcode != 0 {
+		body["error_subcode"] = e.Subcode
+	}
+	if e.Details != "" {
+		body["error_data"] = map[string]string{"messaging_product": "whatsapp", "details": e.Details}
+	}
+	writeJSON(w, status, map[string]interface{}{"error": body})
+}
+
+func errUnsupported(method, id string) *GraphError {
+	return &GraphError{
+		Code:    100,
+		Subcode: 33,
+		Message: fmt.Sprintf("Unsupported %s request. Object with ID '%s' does not exist, cannot be loaded due to missing permissions, or does not support this operation.", strings.ToLower(method), id),
+	}
+}
+
+func errInvalidParam(msg string) *GraphError {
+	return &GraphError{Code: 100, Message: "(#100) " + msg}
+}
+
+func decodeBody(r *http.Request, v interface{}) *GraphError {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errInvalidParam("Invalid JSON body: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,567 @@
+package whatsapptest_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp/whatsapptest"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver records webhook bodies and signatures sent to it
+type webhookReceiver struct {
+	mu     sync.Mutex
+	bodies [][]byte
+	sigs   []string
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	wr.mu.Lock()
+	wr.bodies = append(wr.bodies, body)
+	wr.sigs = append(wr.sigs, r.Header.Get("X-Hub-Signature-256"))
+	wr.mu.Unlock()
+}
+
+func (wr *webhookReceiver) payloads(t *testing.T) []*whatsapp.WebhookPayload {
+	t.Helper()
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	out := make([]*whatsapp.WebhookPayload, len(wr.bodies))
+	for i, b := range wr.bodies {
+		p, err := whatsapp.ParseWebhook(b)
+		require.NoError(t, err)
+		out[i] = p
+	}
+	return out
+}
+
+func newFake(t *testing.T, cfg whatsapptest.Config) (*whatsapptest.Server, *whatsapp.Client, *whatsapp.Account) {
+	t.Helper()
+	if cfg.AccessToken == "" {
+		cfg.AccessToken = "test-token"
+	}
+	srv := whatsapptest.New(cfg).Start()
+	t.Cleanup(srv.Close)
+	phone := srv.AddPhoneNumber(whatsapptest.PhoneNumber{DisplayPhoneNumber: "+1 555-000-1111"})
+	return srv, srv.Client(testutil.NopLogger()), srv.Account(phone.ID)
+}
+
+func TestServer_ValidateCredentials(t *testing.T) {
+	t.Parallel()
+	_, client, acct := newFake(t, whatsapptest.Config{})
+	ctx := testutil.TestContext(t)
+
+	result, err := client.ValidateCredentials(ctx, acct.PhoneID, acct.BusinessID, acct.AccessToken, acct.APIVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "+1 555-000-1111", result.PhoneNumber)
+	assert.Equal(t, "GREEN", result.QualityRating)
+
+	_, err = client.ValidateCredentials(ctx, acct.PhoneID, acct.BusinessID, "wrong-token", acct.APIVersion)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API error 190")
+}
+
+func TestServer_SendMessagesAndStatuses(t *testing.T) {
+	t.Parallel()
+	receiver := &webhookReceiver{}
+	hookServer := httptest.NewServer(receiver)
+	defer hookServer.Close()
+
+	srv, client, acct := newFake(t, whatsapptest.Config{WebhookURL: hookServer.URL, AppSecret: "shh", AutoDeliver: true})
+	ctx := testutil.TestContext(t)
+
+	msgID, err := client.SendTextMessage(ctx, acct, "+15550002222", "Hello")
+	require.NoError(t, err)
+
+	sent := srv.Messages()
+	require.Len(t, sent, 1)
+	assert.Equal(t, msgID, sent[0].ID)
+	assert.Equal(t, "+15550002222", sent[0].To)
+	assert.Equal(t, "Hello", sent[0].Payload["text"].(map[string]interface{})["body"])
+
+	// Sends to unknown media and unapproved templates are rejected like Meta does
+	_, err = client.SendImageMessage(ctx, acct, "15550002222", "missing-media", "")
+	assert.ErrorContains(t, err, "131053")
+	_, err = client.SendTemplateMessage(ctx, acct, "15550002222", "no_such_template", "en", nil)
+	assert.ErrorContains(t, err, "132001")
+
+	srv.FailSendsTo("15550003333", 131026, "Message undeliverable")
+	_, err = client.SendTextMessage(ctx, acct, "15550003333", "Hi")
+	assert.ErrorContains(t, err, "131026")
+
+	srv.Close()
+	var statuses []string
+	for i, p := range receiver.payloads(t) {
+		assert.Equal(t, whatsapptest.Sign(receiver.bodies[i], "shh"), receiver.sigs[i])
+		for _, st := range p.ExtractStatuses() {
+			assert.Equal(t, msgID, st.MessageID)
+			statuses = append(statuses, st.Status)
+		}
+	}
+	assert.Equal(t, []string{"sent", "delivered"}, statuses)
+}
+
+func TestServer_InboundMessageWebhook(t *testing.T) {
+	t.Parallel()
+	receiver := &webhookReceiver{}
+	hookServer := httptest.NewServer(receiver)
+	defer hookServer.Close()
+
+	srv, client, acct := newFake(t, whatsapptest.Config{WebhookURL: hookServer.URL, AppSecret: "shh"})
+	ctx := testutil.TestContext(t)
+
+	_, err := srv.SendText(acct.PhoneID, "15550004444", "Alice", "hi there")
+	require.NoError(t, err)
+
+	mediaID := srv.AddMedia(acct.PhoneID, []byte("jpeg bytes"), "image/jpeg", "")
+	_, err = srv.SendMessage(whatsapptest.InboundMessage{
+		PhoneID: acct.PhoneID, From: "15550004444", Type: "image",
+		Content: map[string]interface{}{"caption": "look"}, MediaID: mediaID,
+	})
+	require.NoError(t, err)
+
+	payloads := receiver.payloads(t)
+	require.Len(t, payloads, 2)
+	assert.Equal(t, acct.PhoneID, payloads[0].GetPhoneNumberID())
+	text := payloads[0].ExtractMessages()
+	require.Len(t, text, 1)
+	assert.Equal(t, "hi there", text[0].Text)
+	assert.Equal(t, "Alice", text[0].ContactName)
+
+	image := payloads[1].ExtractMessages()
+	require.Len(t, image, 1)
+	assert.Equal(t, mediaID, image[0].MediaID)
+
+	// The app downloads inbound media through the media URL endpoint
+	url, err := client.GetMediaURL(ctx, mediaID, acct)
+	require.NoError(t, err)
+	data, err := client.DownloadMedia(ctx, url, acct.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("jpeg bytes"), data)
+}
+
+func TestServer_TemplateReview(t *testing.T) {
+	t.Parallel()
+	receiver := &webhookReceiver{}
+	hookServer := httptest.NewServer(receiver)
+	defer hookServer.Close()
+
+	srv, client, acct := newFake(t, whatsapptest.Config{WebhookURL: hookServer.URL})
+	ctx := testutil.TestContext(t)
+
+	handle, err := client.ResumableUpload(ctx, acct, []byte("png bytes"), "image/png", "sample.png")
+	require.NoError(t, err)
+
+	id, err := client.SubmitTemplate(ctx, acct, &whatsapp.TemplateSubmission{
+		Name:          "order_ready",
+		Language:      "en",
+		Category:      "UTILITY",
+		HeaderType:    "IMAGE",
+		HeaderContent: handle,
+		BodyContent:   "Your order is ready",
+	})
+	require.NoError(t, err)
+
+	_, err = client.SubmitTemplate(ctx, acct, &whatsapp.TemplateSubmission{Name: "order_ready", Language: "en", Category: "UTILITY", BodyContent: "Again"})
+	assert.ErrorContains(t, err, "already exists")
+
+	templates, err := client.FetchTemplates(ctx, acct)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, whatsapptest.TemplatePending, templates[0].Status)
+
+	_, err = client.SendTemplateMessage(ctx, acct, "15550002222", "order_ready", "en", nil)
+	assert.ErrorContains(t, err, "132015", "pending templates can't be sent")
+
+	require.NoError(t, srv.SetTemplateStatus(id, whatsapptest.TemplateApproved, ""))
+	_, err = client.SendTemplateMessage(ctx, acct, "15550002222", "order_ready", "en", nil)
+	require.NoError(t, err)
+
+	payloads := receiver.payloads(t)
+	require.Len(t, payloads, 1)
+	var hook struct {
+		Entry []struct {
+			ID      string `json:"id"`
+			Changes []struct {
+				Field string `json:"field"`
+				Value struct {
+					Event string `json:"event"`
+					Name  string `json:"message_template_name"`
+					ID    int64  `json:"message_template_id"`
+				} `json:"value"`
+			} `json:"changes"`
+		} `json:"entry"`
+	}
+	require.NoError(t, json.Unmarshal(receiver.bodies[0], &hook))
+	assert.Equal(t, acct.BusinessID, hook.Entry[0].ID)
+	assert.Equal(t, "message_template_status_update", hook.Entry[0].Changes[0].Field)
+	assert.Equal(t, "APPROVED", hook.Entry[0].Changes[0].Value.Event)
+	assert.Equal(t, "order_ready", hook.Entry[0].Changes[0].Value.Name)
+	assert.NotZero(t, hook.Entry[0].Changes[0].Value.ID, "template IDs are numeric")
+
+	require.NoError(t, client.DeleteTemplate(ctx, acct, "order_ready"))
+	assert.Nil(t, srv.Template(acct.BusinessID, "order_ready", "en"))
+}
+
+func TestServer_TemplateAutoApproval(t *testing.T) {
+	t.Parallel()
+	srv, client, acct := newFake(t, whatsapptest.Config{TemplateReviewDelay: 10 * time.Millisecond})
+	ctx := testutil.TestContext(t)
+
+	_, err := client.SubmitTemplate(ctx, acct, &whatsapp.TemplateSubmission{Name: "welcome", Language: "en", Category: "MARKETING", BodyContent: "Welcome!"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		tpl := srv.Template(acct.BusinessID, "welcome", "en")
+		return tpl != nil && tpl.Status == whatsapptest.TemplateApproved
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestServer_FlowLifecycle(t *testing.T) {
+	t.Parallel()
+	srv, client, acct := newFake(t, whatsapptest.Config{})
+	ctx := testutil.TestContext(t)
+
+	flowID, err := client.CreateFlow(ctx, acct, "Signup", []string{"SIGN_UP"})
+	require.NoError(t, err)
+
+	assert.Error(t, client.PublishFlow(ctx, acct, flowID), "flows need JSON before publishing")
+
+	flowJSON := &whatsapp.FlowJSON{Version: "6.0", Screens: []interface{}{map[string]interface{}{"id": "WELCOME"}}}
+	require.NoError(t, client.UpdateFlowJSON(ctx, acct, flowID, flowJSON))
+
+	got, err := client.GetFlowAssets(ctx, acct, flowID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "6.0", got.Version)
+	assert.Len(t, got.Screens, 1)
+
+	require.NoError(t, client.PublishFlow(ctx, acct, flowID))
+	assert.Error(t, client.UpdateFlowJSON(ctx, acct, flowID, flowJSON), "published flows are read-only")
+	assert.Error(t, client.DeleteFlow(ctx, acct, flowID), "only drafts can be deleted")
+
+	require.NoError(t, client.DeprecateFlow(ctx, acct, flowID))
+	flow, err := client.GetFlow(ctx, acct, flowID)
+	require.NoError(t, err)
+	assert.Equal(t, whatsapptest.FlowDeprecated, flow.Status)
+
+	flows, err := client.ListFlows(ctx, acct)
+	require.NoError(t, err)
+	assert.Len(t, flows, 1)
+	assert.Equal(t, whatsapptest.FlowDeprecated, srv.Flow(flowID).Status)
+}
+
+func TestServer_CatalogsAndProductMessages(t *testing.T) {
+	t.Parallel()
+	srv, client, acct := newFake(t, whatsapptest.Config{})
+	ctx := testutil.TestContext(t)
+
+	catalogID, err := client.CreateCatalog(ctx, acct, "Shop")
+	require.NoError(t, err)
+
+	productID, err := client.CreateProduct(ctx, acct, catalogID, &whatsapp.ProductInput{
+		Name: "Red Shirt", Price: 1999, Currency: "USD", RetailerID: "SKU-1",
+	})
+	require.NoError(t, err)
+	_, err = client.CreateProduct(ctx, acct, catalogID, &whatsapp.ProductInput{Name: "Dup", Price: 1, Currency: "USD", RetailerID: "SKU-1"})
+	assert.ErrorContains(t, err, "10800")
+
+	require.NoError(t, client.UpdateProduct(ctx, acct, productID, &whatsapp.ProductInput{Price: 2499}))
+	products, err := client.ListCatalogProducts(ctx, acct, catalogID)
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, "2499", products[0].Price)
+	assert.Equal(t, "Red Shirt", products[0].Name)
+
+	_, err = client.SendProductMessage(ctx, acct, "15550002222", catalogID, "SKU-1", "Take a look", "")
+	require.NoError(t, err)
+	_, err = client.SendProductMessage(ctx, acct, "15550002222", catalogID, "SKU-404", "Take a look", "")
+	assert.ErrorContains(t, err, "131009")
+
+	require.NoError(t, client.DeleteCatalog(ctx, acct, catalogID))
+	assert.Empty(t, srv.Products(catalogID))
+}
+
+func TestServer_Calls(t *testing.T) {
+	t.Parallel()
+	receiver := &webhookReceiver{}
+	hookServer := httptest.NewServer(receiver)
+	defer hookServer.Close()
+
+	srv, client, acct := newFake(t, whatsapptest.Config{WebhookURL: hookServer.URL})
+	ctx := testutil.TestContext(t)
+
+	// Business-initiated calls need the user's permission
+	_, err := client.InitiateCall(ctx, acct, "15550002222", "v=0 offer")
+	assert.ErrorContains(t, err, "138006")
+
+	srv.SetCallPermission(acct.PhoneID, "15550002222", "granted")
+	status, err := client.GetCallPermission(ctx, acct, "15550002222")
+	require.NoError(t, err)
+	assert.Equal(t, "granted", status)
+
+	callID, err := client.InitiateCall(ctx, acct, "15550002222", "v=0 offer")
+	require.NoError(t, err)
+	require.NoError(t, srv.AnswerCall(callID, "v=0 answer"))
+	require.NoError(t, client.TerminateCall(ctx, acct, callID))
+
+	// Incoming calls arrive as a connect event with the user's offer
+	incomingID, err := srv.StartCall(acct.PhoneID, "15550005555", "v=0 user offer")
+	require.NoError(t, err)
+	require.NoError(t, client.PreAcceptCall(ctx, acct, incomingID, "v=0 answer"))
+	require.NoError(t, client.AcceptCall(ctx, acct, incomingID, "v=0 answer"))
+	assert.Equal(t, "ACCEPTED", srv.Call(incomingID).Status)
+	assert.Equal(t, []string{"pre_accept", "accept"}, srv.Call(incomingID).Actions)
+
+	srv.Close()
+	var events []string
+	for _, body := range receiver.bodies {
+		var hook struct {
+			Entry []struct {
+				Changes []struct {
+					Field string `json:"field"`
+					Value struct {
+						Calls []struct {
+							ID      string `json:"id"`
+							Event   string `json:"event"`
+							Session *struct {
+								SDPType string `json:"sdp_type"`
+							} `json:"session"`
+						} `json:"calls"`
+						Statuses []struct {
+							ID     string `json:"id"`
+							Status string `json:"status"`
+						} `json:"statuses"`
+					} `json:"value"`
+				} `json:"changes"`
+			} `json:"entry"`
+		}
+		require.NoError(t, json.Unmarshal(body, &hook))
+		change := hook.Entry[0].Changes[0]
+		assert.Equal(t, "calls", change.Field)
+		for _, c := range change.Value.Calls {
+			events = append(events, c.ID+" "+c.Event)
+		}
+		for _, st := range change.Value.Statuses {
+			events = append(events, st.ID+" "+st.Status)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		callID + " RINGING",
+		callID + " ACCEPTED",
+		callID + " connect",
+		callID + " terminate",
+		incomingID + " connect",
+	}, events)
+}
+
+func TestServer_PhoneNumberHealth(t *testing.T) {
+	t.Parallel()
+	srv, client, acct := newFake(t, whatsapptest.Config{})
+	ctx := testutil.TestContext(t)
+
+	health, err := client.GetPhoneNumberHealth(ctx, acct)
+	require.NoError(t, err)
+	assert.Equal(t, "GREEN", health.QualityRating)
+	assert.Equal(t, "TIER_1K", health.MessagingLimitTier)
+	assert.Equal(t, "+1 555-000-1111", health.DisplayPhoneNumber)
+
+	// Only drops to and recoveries from RED are pushed
+	require.NoError(t, srv.SetQualityRating(acct.PhoneID, "YELLOW"))
+	require.NoError(t, srv.SetQualityRating(acct.PhoneID, "RED"))
+	require.NoError(t, srv.SetMessagingLimitTier(acct.PhoneID, "TIER_250"))
+	require.NoError(t, srv.SetQualityRating(acct.PhoneID, "GREEN"))
+	assert.Error(t, srv.SetMessagingLimitTier(acct.PhoneID, "TIER_5"))
+
+	var events []string
+	for _, h := range srv.Webhooks() {
+		assert.Equal(t, "phone_number_quality_update", h.Field)
+		var body struct {
+			Entry []struct {
+				ID      string `json:"id"`
+				Changes []struct {
+					Value struct {
+						Event        string `json:"event"`
+						CurrentLimit string `json:"current_limit"`
+					} `json:"value"`
+				} `json:"changes"`
+			} `json:"entry"`
+		}
+		require.NoError(t, json.Unmarshal(h.Body, &body))
+		assert.Equal(t, acct.BusinessID, body.Entry[0].ID)
+		v := body.Entry[0].Changes[0].Value
+		events = append(events, v.Event+" "+v.CurrentLimit)
+	}
+	assert.Equal(t, []string{"FLAGGED TIER_1K", "DOWNGRADE TIER_250", "UNFLAGGED TIER_250"}, events)
+
+	health, err = client.GetPhoneNumberHealth(ctx, acct)
+	require.NoError(t, err)
+	assert.Equal(t, "GREEN", health.QualityRating)
+	assert.Equal(t, "TIER_250", health.MessagingLimitTier)
+}
+
+func TestServer_PhoneNumberLifecycle(t *testing.T) {
+	t.Parallel()
+	srv, client, acct := newFake(t, whatsapptest.Config{})
+	ctx := testutil.TestContext(t)
+
+	require.NoError(t, client.DeregisterPhoneNumber(ctx, acct))
+	assert.Equal(t, "DISCONNECTED", srv.PhoneNumber(acct.PhoneID).Status)
+
+	// Registering needs a verified number
+	require.NoError(t, client.RequestVerificationCode(ctx, acct, whatsapp.CodeMethodSMS, "en_US"))
+	assert.Error(t, client.RegisterPhoneNumber(ctx, acct, "123456"))
+
+	err := client.VerifyCode(ctx, acct, "000000")
+	apiErr, ok := whatsapp.AsAPIError(err)
+	require.True(t, ok, "expected an API error, got %v", err)
+	assert.Equal(t, 136025, apiErr.Code)
+
+	require.NoError(t, client.VerifyCode(ctx, acct, srv.VerificationCode(acct.PhoneID)))
+	require.NoError(t, client.RegisterPhoneNumber(ctx, acct, "123456"))
+	assert.Equal(t, "CONNECTED", srv.PhoneNumber(acct.PhoneID).Status)
+
+	// The registration PIN became the two-step PIN
+	require.NoError(t, client.DeregisterPhoneNumber(ctx, acct))
+	err = client.RegisterPhoneNumber(ctx, acct, "654321")
+	apiErr, ok = whatsapp.AsAPIError(err)
+	require.True(t, ok, "expected an API error, got %v", err)
+	assert.Equal(t, 133005, apiErr.Code)
+
+	require.NoError(t, client.SetTwoStepPIN(ctx, acct, "654321"))
+	require.NoError(t, client.RegisterPhoneNumber(ctx, acct, "654321"))
+	assert.Error(t, client.SetTwoStepPIN(ctx, acct, "12ab"))
+
+	status, err := client.GetDisplayNameStatus(ctx, acct)
+	require.NoError(t, err)
+	assert.Equal(t, "APPROVED", status.NameStatus)
+	assert.Equal(t, "VERIFIED", status.CodeVerificationStatus)
+}
+
+func TestServer_BlockUsers(t *testing.T) {
+	t.Parallel()
+	srv, client, acct := newFake(t, whatsapptest.Config{})
+	ctx := testutil.TestContext(t)
+
+	result, err := client.BlockUsers(ctx, acct, []string{"+15550001234", "15550005678", "15550009999"})
+	require.NoError(t, err)
+	require.Len(t, result.Users, 3)
+	assert.Equal(t, "15550001234", result.Users[0].WaID)
+	assert.Equal(t, []string{"15550001234", "15550005678", "15550009999"}, srv.BlockedUsers(acct.PhoneID))
+
+	// Blocked users cannot message the number
+	_, err = srv.SendText(acct.PhoneID, "15550001234", "Spammer", "hi")
+	assert.Error(t, err)
+
+	page, err := client.ListBlockedUsers(ctx, acct, 2, "")
+	require.NoError(t, err)
+	require.Len(t, page.Users, 2)
+	require.NotEmpty(t, page.After)
+	page, err = client.ListBlockedUsers(ctx, acct, 2, page.After)
+	require.NoError(t, err)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, "15550009999", page.Users[0].WaID)
+	assert.Empty(t, page.After)
+
+	result, err = client.UnblockUsers(ctx, acct, []string{"+15550001234"})
+	require.NoError(t, err)
+	require.Len(t, result.Users, 1)
+	assert.Equal(t, []string{"15550005678", "15550009999"}, srv.BlockedUsers(acct.PhoneID))
+
+	_, err = client.BlockUsers(ctx, acct, []string{"not-a-number"})
+	apiErr, ok := whatsapp.AsAPIError(err)
+	require.True(t, ok, "expected an API error, got %v", err)
+	assert.Equal(t, whatsapp.ErrCodeBlockUsersFailed, apiErr.Code)
+}
+
+func TestServer_EmbeddedSignup(t *testing.T) {
+	t.Parallel()
+	srv, client, acct := newFake(t, whatsapptest.Config{AppSecret: "app-secret"})
+	ctx := testutil.TestContext(t)
+	code := srv.SignupCode(acct.BusinessID)
+
+	_, err := client.ExchangeCode(ctx, acct.AppID, "wrong-secret", code, acct.APIVersion)
+	assert.Error(t, err)
+
+	token, err := client.ExchangeCode(ctx, acct.AppID, "app-secret", code, acct.APIVersion)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.AccessToken)
+
+	// Codes are single use
+	_, err = client.ExchangeCode(ctx, acct.AppID, "app-secret", code, acct.APIVersion)
+	assert.Error(t, err)
+
+	info, err := client.DebugToken(ctx, acct.AppID, "app-secret", token.AccessToken, acct.APIVersion)
+	require.NoError(t, err)
+	assert.True(t, info.IsValid)
+	assert.Equal(t, []string{acct.BusinessID}, info.TargetIDs(whatsapp.ScopeBusinessManagement))
+
+	// The business token works on the shared business account
+	phones, err := client.ListPhoneNumbers(ctx, acct.BusinessID, token.AccessToken, acct.APIVersion)
+	require.NoError(t, err)
+	require.Len(t, phones, 1)
+	assert.Equal(t, acct.PhoneID, phones[0].ID)
+	assert.Equal(t, "+1 555-000-1111", phones[0].DisplayPhoneNumber)
+}
+
+func TestServer_TokenRevocationAndExpiry(t *testing.T) {
+	t.Parallel()
+	srv, client, acct := newFake(t, whatsapptest.Config{AppSecret: "app-secret"})
+	ctx := testutil.TestContext(t)
+
+	// Without app credentials a token inspects itself
+	info, err := client.DebugToken(ctx, "", "", acct.AccessToken, acct.APIVersion)
+	require.NoError(t, err)
+	assert.True(t, info.IsValid)
+	assert.Nil(t, info.ExpiresAtTime())
+	assert.True(t, info.HasScope(whatsapp.ScopeBusinessMessaging))
+
+	expires := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	srv.SetTokenExpiry(acct.AccessToken, expires)
+	info, err = client.DebugToken(ctx, "", "", acct.AccessToken, acct.APIVersion)
+	require.NoError(t, err)
+	require.NotNil(t, info.ExpiresAtTime())
+	assert.True(t, expires.Equal(*info.ExpiresAtTime()))
+
+	srv.RevokeToken(acct.AccessToken)
+	_, err = client.GetPhoneNumberHealth(ctx, acct)
+	apiErr, ok := whatsapp.AsAPIError(err)
+	require.True(t, ok, "expected an API error, got %v", err)
+	assert.Equal(t, 190, apiErr.Code)
+	assert.Equal(t, whatsapp.ErrorClassAuth, apiErr.Class())
+
+	// A revoked token can no longer inspect itself, but the app still can
+	_, err = client.DebugToken(ctx, "", "", acct.AccessToken, acct.APIVersion)
+	assert.Error(t, err)
+	info, err = client.DebugToken(ctx, acct.AppID, "app-secret", acct.AccessToken, acct.APIVersion)
+	require.NoError(t, err)
+	assert.False(t, info.IsValid)
+	require.NotNil(t, info.Error)
+	assert.Equal(t, 190, info.Error.Code)
+}
+
+func TestServer_ControlEndpoints(t *testing.T) {
+	t.Parallel()
+	srv, _, acct := newFake(t, whatsapptest.Config{})
+
+	body := `{"phone_id":"` + acct.PhoneID + `","from":"15550006666","name":"Bob","text":"ping"}`
+	resp, err := http.Post(srv.URL+"/_fake/inbound", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	hooks := srv.Webhooks()
+	require.Len(t, hooks, 1)
+	assert.Equal(t, "messages", hooks[0].Field)
+	assert.Contains(t, string(hooks[0].Body), `"body":"ping"`)
+}
@@ -0,0 +1,38 @@
+package whatsapptest
+
+import (
+	"net/http"
+)
+
+// SignupCode returns a one-time code, as Embedded Signup hands to the browser
+// when a business finishes onboarding. It exchanges for a business token with
+// access to the given business accounts, which must already exist.
+func (s *Server) SignupCode(businessIDs ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := "fake-signup-code-" + s.nextID()
+	token := "fake-business-token-" + s.nextID()
+	s.signupCodes[code] = token
+	s.grants[token] = append([]string(nil), businessIDs...)
+	return code
+}
+
+func (s *Server) handleExchangeCode(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != s.cfg.AppID || q.Get("client_secret") != s.cfg.AppSecret {
+		writeError(w, &GraphError{Code: 101, Message: "Error validating application. Invalid application ID."})
+		return
+	}
+
+	s.mu.Lock()
+	token, ok := s.signupCodes[q.Get("code")]
+	delete(s.signupCodes, q.Get("code"))
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, &GraphError{Code: 100, Subcode: 36007, Message: "This authorization code has been used."})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": token, "token_type": "bearer"})
+}
@@ -0,0 +1,308 @@
+package whatsapptest
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Template review states
+const (
+	TemplatePending  = "PENDING"
+	TemplateApproved = "APPROVED"
+	TemplateRejected = "REJECTED"
+	TemplatePaused   = "PAUSED"
+	TemplateDisabled = "DISABLED"
+)
+
+// Template is a message template submitted to a business account
+type Template struct {
+	ID              string
+	BusinessID      string
+	Name            string
+	Language        string
+	Category        string
+	Status          string
+	Reason          string
+	ParameterFormat string
+	Components      []map[string]interface{}
+}
+
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,512}$`)
+
+// AddTemplate stores a template directly, skipping review. Status defaults to APPROVED.
+func (s *Server) AddTemplate(t Template) *Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.ID == "" {
+		t.ID = s.nextID()
+	}
+	if t.Status == "" {
+		t.Status = TemplateApproved
+	}
+	if t.Category == "" {
+		t.Category = "UTILITY"
+	}
+	stored := t
+	s.templates[t.ID] = &stored
+	return &t
+}
+
+// Template returns a copy of a template by name and language, or nil
+func (s *Server) Template(businessID, name, language string) *Template {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.findTemplate(businessID, name, language)
+	if t == nil {
+		return nil
+	}
+	cp := *t
+	return &cp
+}
+
+// findTemplate looks a template up by name and language. Callers hold s.mu.
+func (s *Server) findTemplate(businessID, name, language string) *Template {
+	for _, t := range s.templates {
+		if t.BusinessID == businessID && t.Name == name && t.Language == language {
+			return t
+		}
+	}
+	return nil
+}
+
+// SetTemplateStatus completes a review, or pauses or disables a template, and
+// pushes the message_template_status_update webhook Meta sends for it.
+func (s *Server) SetTemplateStatus(templateID, status, reason string) error {
+	s.mu.Lock()
+	t, ok := s.templates[templateID]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown template %q", templateID)
+	}
+	t.Status = strings.ToUpper(status)
+	t.Reason = reason
+	tpl := *t
+	s.mu.Unlock()
+
+	id, _ := strconv.ParseInt(tpl.ID, 10, 64)
+	value := map[string]interface{}{
+		"event":                     tpl.Status,
+		"message_template_id":       id,
+		"message_template_name":     tpl.Name,
+		"message_template_language": tpl.Language,
+		"reason":                    "NONE",
+	}
+	if reason != "" {
+		value["reason"] = reason
+	}
+	return s.push(tpl.BusinessID, "message_template_status_update", value)
+}
+
+// scheduleReview approves a pending template after Config.TemplateReviewDelay
+func (s *Server) scheduleReview(templateID string) {
+	if s.cfg.TemplateReviewDelay <= 0 {
+		return
+	}
+	s.after(s.cfg.TemplateReviewDelay, func() {
+		s.mu.Lock()
+		t, ok := s.templates[templateID]
+		pending := ok && t.Status == TemplatePending
+		s.mu.Unlock()
+		if pending {
+			_ = s.SetTemplateStatus(templateID, TemplateApproved, "")
+		}
+	})
+}
+
+type templateInput struct {
+	Name            string                   `json:"name"`
+	Language        string                   `json:"language"`
+	Category        string                   `json:"category"`
+	ParameterFormat string                   `json:"parameter_format"`
+	Components      []map[string]interface{} `json:"components"`
+}
+
+// validateComponents checks the parts of a template Meta rejects outright
+func (s *Server) validateComponents(components []map[string]interface{}) *GraphError {
+	hasBody := false
+	for _, c := range components {
+		switch c["type"] {
+		case "BODY":
+			if text, _ := c["text"].(string); text == "" {
+				return errInvalidParam("Body text is required.")
+			}
+			hasBody = true
+		case "HEADER":
+			format, _ := c["format"].(string)
+			if format == "IMAGE" || format == "VIDEO" || format == "DOCUMENT" {
+				example, _ := c["example"].(map[string]interface{})
+				handles, _ := example["header_handle"].([]interface{})
+				if len(handles) == 0 {
+					return errInvalidParam("Media headers require an example header_handle.")
+				}
+				if h, _ := handles[0].(string); !s.isUploadHandle(h) {
+					return errInvalidParam("Invalid header_handle. Upload the sample with the resumable upload API.")
+				}
+			}
+		}
+	}
+	if !hasBody {
+		return errInvalidParam("A BODY component is required.")
+	}
+	return nil
+}
+
+func (s *Server) handleCreateTemplate(w http.ResponseWriter, r *http.Request, businessID string) {
+	if s.Business(businessID) == nil {
+		writeError(w, errUnsupported(r.Method, businessID))
+		return
+	}
+	var in templateInput
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if !templateNamePattern.MatchString(in.Name) {
+		writeError(w, errInvalidParam("Param name must contain only lowercase letters, numbers and underscores."))
+		return
+	}
+	if in.Language == "" {
+		writeError(w, errInvalidParam("The parameter language is required."))
+		return
+	}
+	switch in.Category {
+	case "MARKETING", "UTILITY", "AUTHENTICATION":
+	default:
+		writeError(w, errInvalidParam("Param category must be one of {MARKETING, UTILITY, AUTHENTICATION}."))
+		return
+	}
+	if gerr := s.validateComponents(in.Components); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+
+	s.mu.Lock()
+	if s.findTemplate(businessID, in.Name, in.Language) != nil {
+		s.mu.Unlock()
+		writeError(w, &GraphError{
+			Code:    100,
+			Subcode: 2388024,
+			Message: "Invalid parameter",
+			Details: fmt.Sprintf("Content in this language already exists for template %q.", in.Name),
+		})
+		return
+	}
+	t := &Template{
+		ID:              s.nextID(),
+		BusinessID:      businessID,
+		Name:            in.Name,
+		Language:        in.Language,
+		Category:        in.Category,
+		Status:          TemplatePending,
+		ParameterFormat: in.ParameterFormat,
+		Components:      in.Components,
+	}
+	s.templates[t.ID] = t
+	s.mu.Unlock()
+
+	s.scheduleReview(t.ID)
+	writeJSON(w, http.StatusOK, map[string]string{"id": t.ID, "status": t.Status, "category": t.Category})
+}
+
+// handleUpdateTemplate edits a template's content: POST /{template-id}. Edits send it back to review.
+func (s *Server) handleUpdateTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	var in templateInput
+	if gerr := decodeBody(r, &in); gerr != nil {
+		writeError(w, gerr)
+		return
+	}
+	if in.Components != nil {
+		if gerr := s.validateComponents(in.Components); gerr != nil {
+			writeError(w, gerr)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	t := s.templates[id]
+	if t.Status == TemplatePending {
+		s.mu.Unlock()
+		writeError(w, &GraphError{Code: 100, Subcode: 2388025, Message: "Invalid parameter", Details: "Templates can't be edited while they are in review."})
+		return
+	}
+	if in.Components != nil {
+		t.Components = in.Components
+	}
+	if in.Category != "" {
+		t.Category = in.Category
+	}
+	t.Status = TemplatePending
+	t.Reason = ""
+	s.mu.Unlock()
+
+	s.scheduleReview(id)
+	writeSuccess(w)
+}
+
+func (s *Server) handleListTemplates(w http.ResponseWriter, businessID string) {
+	if s.Business(businessID) == nil {
+		writeError(w, errUnsupported(http.MethodGet, businessID))
+		return
+	}
+
+	s.mu.Lock()
+	data := []map[string]interface{}{}
+	for _, t := range s.templates {
+		if t.BusinessID != businessID {
+			continue
+		}
+		item := map[string]interface{}{
+			"id":         t.ID,
+			"name":       t.Name,
+			"language":   t.Language,
+			"category":   t.Category,
+			"status":     t.Status,
+			"components": t.Components,
+		}
+		if t.ParameterFormat != "" {
+			item["parameter_format"] = t.ParameterFormat
+		}
+		data = append(data, item)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "paging": paging()})
+}
+
+// handleDeleteTemplate deletes every language of a template: DELETE /{waba-id}/message_templates?name=
+func (s *Server) handleDeleteTemplate(w http.ResponseWriter, r *http.Request, businessID string) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, errInvalidParam("The parameter name is required."))
+		return
+	}
+
+	s.mu.Lock()
+	deleted := 0
+	for id, t := range s.templates {
+		if t.BusinessID == businessID && t.Name == name {
+			delete(s.templates, id)
+			deleted++
+		}
+	}
+	s.mu.Unlock()
+
+	if deleted == 0 {
+		writeError(w, &GraphError{Code: 100, Subcode: 2593002, Message: "Invalid parameter", Details: fmt.Sprintf("Message template %q not found.", name)})
+		return
+	}
+	writeSuccess(w)
+}
+
+// paging returns an empty cursor block, as list endpoints include one even on the last page
+func paging() map[string]interface{} {
+	return map[string]interface{}{"cursors": map[string]string{"before": "", "after": ""}}
+}
@@ -0,0 +1,106 @@
+package whatsapptest
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+// RevokeToken invalidates an access token, as when a system user token is
+// deleted or the business removes the app. Requests made with it fail with
+// error 190 and debug_token reports it invalid.
+func (s *Server) RevokeToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[token] = true
+}
+
+// SetTokenExpiry makes debug_token report that token expires at t. Once t
+// has passed the token is rejected like a revoked one.
+func (s *Server) SetTokenExpiry(token string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expiries[token] = t
+}
+
+// appToken is the app access token debug_token expects, as Meta builds it
+func (s *Server) appToken() string {
+	return s.cfg.AppID + "|" + s.cfg.AppSecret
+}
+
+// grantedToken reports whether token was issued by a signup code exchange
+func (s *Server) grantedToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.grants[token]
+	return ok
+}
+
+// tokenError returns why a token can no longer be used, or nil if it can
+func (s *Server) tokenError(token string) *GraphError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.revoked[token] {
+		return &GraphError{HTTPStatus: http.StatusUnauthorized, Code: 190, Subcode: 460, Message: "Error validating access token: The session has been invalidated."}
+	}
+	if expires, ok := s.expiries[token]; ok && !time.Now().Before(expires) {
+		return &GraphError{HTTPStatus: http.StatusUnauthorized, Code: 190, Subcode: 463, Message: "Error validating access token: Session has expired."}
+	}
+	return nil
+}
+
+// knownToken reports whether token would be accepted on API requests, were
+// it not revoked or expired
+func (s *Server) knownToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	return s.cfg.AccessToken == "" || token == s.cfg.AccessToken || s.grantedToken(token)
+}
+
+func (s *Server) handleDebugToken(w http.ResponseWriter, r *http.Request) {
+	auth := bearerToken(r)
+	input := r.URL.Query().Get("input_token")
+
+	// Like Meta, a valid token may inspect itself without app credentials
+	selfInspect := auth == input && s.knownToken(input) && s.tokenError(input) == nil
+	if auth != s.appToken() && !selfInspect {
+		writeError(w, &GraphError{HTTPStatus: http.StatusUnauthorized, Code: 190, Message: "Invalid OAuth access token - Cannot parse access token"})
+		return
+	}
+
+	data := map[string]interface{}{"app_id": s.cfg.AppID, "is_valid": false}
+	if !s.knownToken(input) {
+		data["error"] = map[string]interface{}{"code": 190, "message": "Invalid OAuth access token."}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+		return
+	}
+
+	s.mu.Lock()
+	targets, granted := s.grants[input]
+	if !granted {
+		for id := range s.businesses {
+			targets = append(targets, id)
+		}
+		sort.Strings(targets)
+	}
+	var expiresAt int64
+	if t, ok := s.expiries[input]; ok {
+		expiresAt = t.Unix()
+	}
+	s.mu.Unlock()
+
+	data["type"] = "SYSTEM_USER"
+	data["expires_at"] = expiresAt
+	data["scopes"] = []string{"whatsapp_business_management", "whatsapp_business_messaging"}
+	data["granular_scopes"] = []map[string]interface{}{
+		{"scope": "whatsapp_business_management", "target_ids": append([]string{}, targets...)},
+		{"scope": "whatsapp_business_messaging", "target_ids": append([]string{}, targets...)},
+	}
+	if gerr := s.tokenError(input); gerr != nil {
+		data["error"] = map[string]interface{}{"code": gerr.Code, "subcode": gerr.Subcode, "message": gerr.Message}
+	} else {
+		data["is_valid"] = true
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
@@ -0,0 +1,187 @@
+package whatsapptest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook is a webhook payload pushed by the server
+type Webhook struct {
+	Field string
+	Body  []byte
+	// Signature is the X-Hub-Signature-256 header value, empty without an AppSecret
+	Signature string
+	// StatusCode is the receiver's response, zero if delivery failed or WebhookURL is unset
+	StatusCode int
+	Err        error
+}
+
+// Webhooks returns the webhooks pushed so far, oldest first
+func (s *Server) Webhooks() []Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Webhook, len(s.webhooks))
+	copy(out, s.webhooks)
+	return out
+}
+
+// Sign returns the X-Hub-Signature-256 header value for body
+func Sign(body []byte, appSecret string) string {
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// InboundMessage is a message sent by a WhatsApp user to a business number
+type InboundMessage struct {
+	PhoneID     string // business phone number ID receiving the message
+	From        string // sender's WhatsApp ID, e.g. "15551234567"
+	ProfileName string
+	Type        string // text, image, interactive, location, ...
+	// Content is the type-specific object, e.g. {"body": "hi"} for text.
+	// For media types, set MediaID to fill id, mime_type and sha256 from AddMedia.
+	Content map[string]interface{}
+	MediaID string
+	// ReplyTo is the ID of the message being replied to, sent as context
+	ReplyTo string
+}
+
+// SendMessage delivers an inbound message webhook and returns the message ID
+func (s *Server) SendMessage(in InboundMessage) (string, error) {
+	phone, ok := s.phoneNumber(in.PhoneID)
+	if !ok {
+		return "", fmt.Errorf("unknown phone number %q", in.PhoneID)
+	}
+	if in.Type == "" {
+		return "", fmt.Errorf("message type is required")
+	}
+	// Meta drops messages from users the business has blocked
+	if s.isBlocked(in.PhoneID, in.From) {
+		return "", fmt.Errorf("%s is blocked by phone number %q", in.From, in.PhoneID)
+	}
+
+	content := map[string]interface{}{}
+	for k, v := range in.Content {
+		content[k] = v
+	}
+	if in.MediaID != "" {
+		m := s.Media(in.MediaID)
+		if m == nil {
+			return "", fmt.Errorf("unknown media %q", in.MediaID)
+		}
+		content["id"] = m.ID
+		content["mime_type"] = m.MimeType
+		content["sha256"] = m.SHA256
+		if in.Type == "document" && m.Filename != "" {
+			content["filename"] = m.Filename
+		}
+	}
+
+	s.mu.Lock()
+	id := "wamid.in" + s.nextID()
+	s.mu.Unlock()
+
+	msg := map[string]interface{}{
+		"from":      in.From,
+		"id":        id,
+		"timestamp": unixNow(),
+		"type":      in.Type,
+		in.Type:     content,
+	}
+	if in.ReplyTo != "" {
+		msg["context"] = map[string]string{"from": phone.DisplayPhoneNumber, "id": in.ReplyTo}
+	}
+
+	value := phoneValue(phone)
+	value["contacts"] = []map[string]interface{}{{
+		"profile": map[string]string{"name": in.ProfileName},
+		"wa_id":   in.From,
+	}}
+	value["messages"] = []interface{}{msg}
+
+	return id, s.push(phone.BusinessID, "messages", value)
+}
+
+// SendText delivers an inbound text message webhook and returns the message ID
+func (s *Server) SendText(phoneID, from, profileName, body string) (string, error) {
+	return s.SendMessage(InboundMessage{
+		PhoneID:     phoneID,
+		From:        from,
+		ProfileName: profileName,
+		Type:        "text",
+		Content:     map[string]interface{}{"body": body},
+	})
+}
+
+// phoneValue starts a change value for events on a phone number
+func phoneValue(p PhoneNumber) map[string]interface{} {
+	return map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"metadata": map[string]string{
+			"display_phone_number": p.DisplayPhoneNumber,
+			"phone_number_id":      p.ID,
+		},
+	}
+}
+
+// push records a webhook for a single change and delivers it synchronously
+func (s *Server) push(entryID, field string, value map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"object": "whatsapp_business_account",
+		"entry": []map[string]interface{}{{
+			"id": entryID,
+			"changes": []map[string]interface{}{{
+				"field": field,
+				"value": value,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	hook := Webhook{Field: field, Body: body}
+	if s.cfg.AppSecret != "" {
+		hook.Signature = Sign(body, s.cfg.AppSecret)
+	}
+	if s.cfg.WebhookURL != "" {
+		hook.StatusCode, hook.Err = s.deliver(body, hook.Signature)
+	}
+
+	s.mu.Lock()
+	s.webhooks = append(s.webhooks, hook)
+	s.mu.Unlock()
+	return hook.Err
+}
+
+func (s *Server) deliver(body []byte, signature string) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if signature != "" {
+		req.Header.Set("X-Hub-Signature-256", signature)
+	}
+
+	resp, err := s.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook delivery failed: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("webhook receiver returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func unixNow() string {
+	return strconv.FormatInt(time.Now().Unix(), 10)
+}
@@ -99,6 +99,11 @@ func runMigrations(db *gorm.DB) error {
 		&models.Message{},
 		&models.Template{},
 		&models.WhatsAppFlow{},
+		&models.InboundWebhookEvent{},
+		&models.AccountHealthEvent{},
+		// Pricing models
+		&models.PricingRate{},
+		&models.ConversationCost{},
 		// Chatbot models
 		&models.ChatbotSettings{},
 		&models.KeywordRule{},
@@ -109,16 +114,26 @@ func runMigrations(db *gorm.DB) error {
 		&models.AIContext{},
 		&models.AgentTransfer{},
 		// Bulk message models
+		&models.ContactSegment{},
 		&models.BulkMessageCampaign{},
+		&models.CampaignVariant{},
 		&models.BulkMessageRecipient{},
+		&models.RecurringCampaign{},
+		&models.SuppressionEntry{},
 		&models.NotificationRule{},
+		&models.NotificationRuleLog{},
 		// Catalog models
 		&models.Catalog{},
 		&models.CatalogProduct{},
+		// Order models
+		&models.Order{},
+		&models.OrderItem{},
 		// Canned responses
 		&models.CannedResponse{},
 		// Dashboard
 		&models.Widget{},
+		// Scheduled chat messages
+		&models.ScheduledMessage{},
 	)
 }
 
@@ -126,8 +141,13 @@ func runMigrations(db *gorm.DB) error {
 // Uses TRUNCATE CASCADE to handle foreign key constraints properly.
 func cleanupTables(db *gorm.DB) {
 	tables := []string{
+		// Scheduled chat messages
+		"scheduled_messages",
 		// Dashboard tables
 		"widgets",
+		// Order tables
+		"order_items",
+		"orders",
 		// Catalog tables
 		"catalog_products",
 		"catalogs",
@@ -135,8 +155,13 @@ func cleanupTables(db *gorm.DB) {
 		"canned_responses",
 		// Bulk message tables
 		"bulk_message_recipients",
+		"campaign_variants",
 		"bulk_message_campaigns",
+		"recurring_campaigns",
+		"contact_segments",
+		"notification_rule_logs",
 		"notification_rules",
+		"suppression_entries",
 		// Chatbot tables
 		"chatbot_session_messages",
 		"chatbot_sessions",
@@ -146,7 +171,12 @@ func cleanupTables(db *gorm.DB) {
 		"chatbot_settings",
 		"ai_contexts",
 		"agent_transfers",
+		// Pricing tables
+		"conversation_costs",
+		"pricing_rates",
 		// WhatsApp tables
+		"account_health_events",
+		"webhook_events",
 		"messages",
 		"tags",
 		"contacts",
@@ -179,12 +209,19 @@ func cleanupTables(db *gorm.DB) {
 func TruncateTables(db *gorm.DB) {
 	tables := []string{
 		"widgets",
+		"order_items",
+		"orders",
 		"catalog_products",
 		"catalogs",
 		"canned_responses",
 		"bulk_message_recipients",
+		"campaign_variants",
 		"bulk_message_campaigns",
+		"recurring_campaigns",
+		"contact_segments",
+		"notification_rule_logs",
 		"notification_rules",
+		"suppression_entries",
 		"chatbot_session_messages",
 		"chatbot_sessions",
 		"chatbot_flow_steps",
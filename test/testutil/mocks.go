@@ -3,6 +3,7 @@ package testutil
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/queue"
@@ -257,8 +258,9 @@ func (m *MockWhatsAppClient) GetMessagesSentTo(phone string) []MockSentMessage {
 
 // MockQueue is a mock implementation of queue.Queue.
 type MockQueue struct {
-	mu   sync.Mutex
-	Jobs []*queue.RecipientJob
+	mu        sync.Mutex
+	Jobs      []*queue.RecipientJob
+	Scheduled []ScheduledJob
 
 	// Configurable behavior
 	EnqueueFunc  func(ctx context.Context, job *queue.RecipientJob) error
@@ -268,6 +270,12 @@ type MockQueue struct {
 	Error error
 }
 
+// ScheduledJob is a job passed to MockQueue.ScheduleRecipient.
+type ScheduledJob struct {
+	Job *queue.RecipientJob
+	At  time.Time
+}
+
 // NewMockQueue creates a new mock queue.
 func NewMockQueue() *MockQueue {
 	return &MockQueue{
@@ -309,6 +317,19 @@ func (m *MockQueue) EnqueueRecipients(ctx context.Context, jobs []*queue.Recipie
 	return nil
 }
 
+// ScheduleRecipient mocks scheduling a job; the job is recorded in Scheduled.
+func (m *MockQueue) ScheduleRecipient(ctx context.Context, job *queue.RecipientJob, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Error != nil {
+		return m.Error
+	}
+
+	m.Scheduled = append(m.Scheduled, ScheduledJob{Job: job, At: at})
+	return nil
+}
+
 // Close is a no-op for the mock.
 func (m *MockQueue) Close() error {
 	return nil